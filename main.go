@@ -8,6 +8,7 @@ import (
 	"foglio/v2/src/handlers"
 	"foglio/v2/src/lib"
 	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
 	"foglio/v2/src/routes"
 	"foglio/v2/src/services"
 	"log"
@@ -38,6 +39,9 @@ func main() {
 
 	app := gin.Default()
 	app.RedirectTrailingSlash = false
+	if err := app.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	docs.SetupSwagger(app)
 	app.Use(gin.Logger())
 
@@ -53,18 +57,39 @@ func main() {
 	}
 	app.Use(cors.New(corsConfig))
 	app.Use(middlewares.ErrorHandlerMiddleware())
+	app.Use(middlewares.SiteHostMiddleware(services.NewSiteHostService(database.GetDatabase()), handlers.NewPortfolioHandler().RenderHostPortfolio()))
 	app.Use(middlewares.AuthMiddleware())
 	app.Use(middlewares.RateLimiterMiddleware())
 	app.Use(lib.ErrorHandler())
 
 	app.MaxMultipartMemory = 10 << 20 // 10 MB
 
-	hub := lib.NewHub()
+	broker, err := lib.NewBroker(config.AppConfig.HubBroker, database.GetDatabase())
+	if err != nil {
+		log.Fatal("Hub broker error:", err)
+	}
+	hub := lib.NewHub(broker)
 	go hub.Run()
+	defer func() {
+		if err := hub.Close(); err != nil {
+			log.Printf("Error closing hub broker: %v", err)
+		}
+	}()
 
 	notificationService := services.NewNotificationService(database.GetDatabase(), hub)
 	chatService := services.NewChatService(database.GetDatabase(), hub, notificationService)
 	hub.SetChatMessageHandler(chatService)
+	hub.SetPresenceHandler(chatService)
+
+	authService := services.NewAuthService(database.GetDatabase())
+	hub.SetAuthenticator(func(token string) (string, string, error) {
+		user, claims, err := authService.AuthenticateToken(token)
+		if err != nil {
+			return "", "", err
+		}
+		return user.ID.String(), claims.ID, nil
+	})
+	services.SetSessionHub(hub)
 
 	websocket := lib.NewWebSocketHandler(hub)
 
@@ -84,8 +109,10 @@ func main() {
 	})
 	router.GET("/ws", websocket.HandleWebSocket)
 	router.GET("/ws/stats", websocket.GetStats)
-	router.POST("/ws/send-notification", websocket.SendNotification)
-	router.POST("/ws/broadcast", websocket.Broadcast)
+	broadcast := middlewares.RequirePermission(models.PermissionNotificationsBroadcast)
+	auditBroadcast := middlewares.Audit(models.AuditNotificationBroadcast, "notification")
+	router.POST("/ws/send-notification", broadcast, auditBroadcast, websocket.SendNotification)
+	router.POST("/ws/broadcast", broadcast, auditBroadcast, websocket.Broadcast)
 	router.GET("/health", func(ctx *gin.Context) {
 		lib.Success(ctx, "Foglio API is healthy", map[string]interface{}{
 			"version": config.AppConfig.Version,
@@ -97,11 +124,12 @@ func main() {
 	})
 
 	routes.AuthRoutes(router)
+	routes.SSORoutes(router)
 	routes.UserRoutes(router)
-	routes.JobRoutes(router)
-	routes.SelfRoutes(router)
+	routes.JobRoutes(router, hub)
+	routes.SelfRoutes(router, hub)
 	routes.TestingRoutes(router)
-	routes.NotificationRoutes(router)
+	routes.NotificationRoutes(router, hub)
 	routes.SubscriptionRoutes(router)
 	routes.PaystackRoutes(router)
 	routes.DomainRoutes(router)
@@ -110,7 +138,11 @@ func main() {
 	routes.NotificationSettingsRoutes(router)
 	routes.AnnouncementRoutes(router, hub)
 	routes.ChatRoutes(router, hub)
+	routes.OutreachRoutes(router, hub)
 	routes.ReviewRoutes(router)
+	routes.AdminRoutes(router, hub)
+	routes.SiteRoutes(app)
+	routes.ACMEChallengeRoutes(app)
 	app.NoRoute(lib.GlobalNotFound())
 
 	if config.AppConfig.RunSeeds {
@@ -133,6 +165,49 @@ func main() {
 		log.Printf("Failed to add subscription expiry cron job: %v", err)
 	}
 
+	deletionService := services.NewAccountDeletionService(database.GetDatabase())
+	err = scheduler.AddJob("0 30 * * * *", func() {
+		log.Println("Running scheduled account deletions...")
+		if err := deletionService.ProcessDueDeletions(); err != nil {
+			log.Printf("Error processing account deletions: %v", err)
+		}
+	})
+	if err != nil {
+		log.Printf("Failed to add account deletion cron job: %v", err)
+	}
+
+	exportService := services.NewDataExportService(database.GetDatabase())
+	err = scheduler.AddJob("0 */10 * * * *", func() {
+		if err := exportService.ProcessPendingExports(); err != nil {
+			log.Printf("Error processing data exports: %v", err)
+		}
+		if err := exportService.ExpireExports(); err != nil {
+			log.Printf("Error expiring data exports: %v", err)
+		}
+	})
+	if err != nil {
+		log.Printf("Failed to add data export cron job: %v", err)
+	}
+
+	certificateService := services.NewCertificateService(database.GetDatabase())
+	err = scheduler.AddJob("0 15 * * * *", func() {
+		if err := certificateService.ProcessCertificates(); err != nil {
+			log.Printf("Error processing domain certificates: %v", err)
+		}
+	})
+	if err != nil {
+		log.Printf("Failed to add domain certificate cron job: %v", err)
+	}
+
+	err = scheduler.AddJob("0 45 * * * *", func() {
+		if err := chatService.PurgeUnattachedAttachments(); err != nil {
+			log.Printf("Error purging unattached chat attachments: %v", err)
+		}
+	})
+	if err != nil {
+		log.Printf("Failed to add chat attachment cleanup cron job: %v", err)
+	}
+
 	scheduler.Start()
 	defer scheduler.Stop()
 
@@ -144,6 +219,28 @@ func main() {
 		MaxHeaderBytes: 1 << 20, // 1 MB
 	}
 
+	// HTTPS is served next to plain HTTP, which custom domains need for their certificates' HTTP-01 challenges
+	if config.AppConfig.TLSPort != "" {
+		tlsConfig, err := certificateService.TLSConfig()
+		if err != nil {
+			log.Fatal("TLS error:", err)
+		}
+		tlsServer := &http.Server{
+			Addr:           fmt.Sprintf(":%s", config.AppConfig.TLSPort),
+			Handler:        app,
+			TLSConfig:      tlsConfig,
+			ReadTimeout:    30 * time.Second,
+			WriteTimeout:   30 * time.Second,
+			MaxHeaderBytes: 1 << 20, // 1 MB
+		}
+		go func() {
+			log.Printf("TLS server starting on port %s", config.AppConfig.TLSPort)
+			if err := tlsServer.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				log.Fatal("TLS server failed to start:", err)
+			}
+		}()
+	}
+
 	if config.AppConfig.IsDevMode {
 		log.Printf("Server starting on port http://localhost:%s/%s", config.AppConfig.Port, config.AppConfig.Version)
 		log.Printf("Swagger docs at http://localhost:%s/swagger/index.html", config.AppConfig.Port)
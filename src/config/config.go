@@ -2,8 +2,10 @@ package config
 
 import (
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,9 +14,25 @@ type APIRoute struct {
 	Method   string
 }
 
+// OIDCProvider is an OpenID Connect identity provider added through OIDC_* variables. Its endpoints are
+// found through discovery on the issuer.
+type OIDCProvider struct {
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+}
+
 type Config struct {
 	AccessTokenExpiresIn  time.Duration
+	AcmeCaFile            string
+	AcmeDirectoryUrl      string
+	AcmeEmail             string
 	AppEmail              string
+	ApiHosts              []string
 	ApiUrl                string
 	ClientUrl             string
 	CloudinaryKey         string
@@ -30,10 +48,13 @@ type Config struct {
 	GoogleClientId        string
 	GoogleClientSecret    string
 	GoogleRedirectUrl     string
+	HubBroker             string
 	IsDevMode             bool
 	JWTSecret             []byte
 	MaxFileSize           int
 	NonAuthRoutes         []APIRoute
+	SelfOnlyRoutes        []APIRoute // Refused to impersonation tokens; only the account holder may call them
+	OIDCProviders         []OIDCProvider
 	PaystackSecretKey     string
 	PaystackPublicKey     string
 	PaystackWebhookSecret string
@@ -41,12 +62,21 @@ type Config struct {
 	PostgresUrl           string
 	ProjectId             string
 	RedisUrl              string
+	RefreshTokenExpiresIn time.Duration
 	RunSeeds              bool
+	SitesDomain           string
 	SmtpHost              string
 	SmtpPort              int
 	SmtpUser              string
 	SmtpPassword          string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSPort               string
+	TrustedProxies        []string // Proxies whose X-Forwarded-For is believed; nil trusts none
 	Version               string
+	WebAuthnOrigins       []string
+	WebAuthnRPID          string
+	WebAuthnRPName        string
 }
 
 var AppConfig *Config
@@ -54,7 +84,11 @@ var AppConfig *Config
 func InitializeConfig() {
 	AppConfig = &Config{
 		AccessTokenExpiresIn:  time.Minute * 30,
+		AcmeCaFile:            os.Getenv("ACME_CA_FILE"),
+		AcmeDirectoryUrl:      os.Getenv("ACME_DIRECTORY_URL"),
+		AcmeEmail:             os.Getenv("ACME_EMAIL"),
 		AppEmail:              os.Getenv("APP_EMAIL"),
+		ApiHosts:              apiHosts(),
 		ApiUrl:                os.Getenv("API_URL"),
 		ClientUrl:             os.Getenv("CLIENT_URL"),
 		CloudinaryKey:         os.Getenv("CLOUDINARY_KEY"),
@@ -70,9 +104,11 @@ func InitializeConfig() {
 		GoogleClientId:        os.Getenv("GOOGLE_CLIENT_ID"),
 		GoogleClientSecret:    os.Getenv("GOOGLE_CLIENT_SECRET"),
 		GoogleRedirectUrl:     os.Getenv("GOOGLE_REDIRECT_URL"),
+		HubBroker:             os.Getenv("HUB_BROKER"),
 		IsDevMode:             os.Getenv("GO_ENV") == "development",
 		JWTSecret:             []byte(os.Getenv("JWT_SECRET")),
 		MaxFileSize:           10 << 20, // default 10 MB
+		OIDCProviders:         oidcProviders(),
 		PaystackSecretKey:     os.Getenv("PAYSTACK_SECRET_KEY"),
 		PaystackPublicKey:     os.Getenv("PAYSTACK_PUBLIC_KEY"),
 		PaystackWebhookSecret: os.Getenv("PAYSTACK_WEBHOOK_SECRET"),
@@ -80,12 +116,21 @@ func InitializeConfig() {
 		PostgresUrl:           os.Getenv("POSTGRES_URL"),
 		ProjectId:             os.Getenv("PROJECT_ID"),
 		RedisUrl:              os.Getenv("REDIS_URL"),
+		RefreshTokenExpiresIn: time.Hour * 24 * 30,
 		RunSeeds:              os.Getenv("RUN_SEEDS") == "true",
+		SitesDomain:           sitesDomain(),
 		SmtpHost:              os.Getenv("SMTP_HOST"),
 		SmtpPort:              func() int { p, _ := strconv.Atoi(os.Getenv("SMTP_PORT")); return p }(),
 		SmtpUser:              os.Getenv("SMTP_USER"),
 		SmtpPassword:          os.Getenv("SMTP_PASSWORD"),
+		TLSCertFile:           os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:            os.Getenv("TLS_KEY_FILE"),
+		TLSPort:               os.Getenv("TLS_PORT"),
+		TrustedProxies:        trustedProxies(),
 		Version:               os.Getenv("VERSION"),
+		WebAuthnOrigins:       webAuthnOrigins(),
+		WebAuthnRPID:          os.Getenv("WEBAUTHN_RP_ID"),
+		WebAuthnRPName:        os.Getenv("WEBAUTHN_RP_NAME"),
 		NonAuthRoutes: []APIRoute{
 			{Endpoint: "/public/*", Method: "*"},
 			{Endpoint: "/swagger/*", Method: "*"},
@@ -94,16 +139,32 @@ func InitializeConfig() {
 			{Endpoint: "/api/v2", Method: http.MethodGet},
 			{Endpoint: "/api/v2/ws", Method: http.MethodGet},
 			{Endpoint: "/api/v2/ws/stats", Method: http.MethodGet},
-			{Endpoint: "/api/v2/ws/send-notification", Method: http.MethodPost},
-			{Endpoint: "/api/v2/ws/broadcast", Method: http.MethodPost},
 			{Endpoint: "/api/v2/health", Method: http.MethodGet},
+			{Endpoint: "/api/v2/chat/media/:attachmentId", Method: http.MethodGet},
+			{Endpoint: "/api/v2/chat/media/:attachmentId/thumbnail", Method: http.MethodGet},
 			{Endpoint: "/api/v2/test/*", Method: http.MethodGet},
 			{Endpoint: "/api/v2/auth/signup", Method: http.MethodPost},
 			{Endpoint: "/api/v2/auth/signin", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/refresh", Method: http.MethodPost},
 			{Endpoint: "/api/v2/auth/verification", Method: http.MethodPost},
 			{Endpoint: "/api/v2/auth/forgot-password", Method: http.MethodPost},
 			{Endpoint: "/api/v2/auth/reset-password", Method: http.MethodPost},
 			{Endpoint: "/api/v2/auth/2fa/verify", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/2fa/passkey/begin", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/2fa/passkey/verify", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/2fa/recovery", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/2fa/recovery/verify", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/2fa/recovery/complete", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/2fa/recovery/cancel", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/email/revert", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/deletion/cancel", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/passkeys/login/begin", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/passkeys/login/finish", Method: http.MethodPost},
+			{Endpoint: "/api/v2/auth/providers", Method: http.MethodGet},
+			{Endpoint: "/api/v2/sso/login", Method: http.MethodPost},
+			{Endpoint: "/api/v2/sso/exchange", Method: http.MethodPost},
+			{Endpoint: "/api/v2/sso/:companyId/metadata", Method: http.MethodGet},
+			{Endpoint: "/api/v2/sso/:companyId/acs", Method: http.MethodPost},
 			{Endpoint: "/api/v2/auth/github", Method: http.MethodGet},
 			{Endpoint: "/api/v2/auth/github/callback", Method: http.MethodGet},
 			{Endpoint: "/api/v2/auth/google", Method: http.MethodGet},
@@ -116,10 +177,129 @@ func InitializeConfig() {
 			{Endpoint: "/api/v2/subscriptions/:id", Method: http.MethodGet},
 			{Endpoint: "/api/v2/payments/webhook", Method: http.MethodPost},
 			{Endpoint: "/api/v2/portfolios/:slug", Method: http.MethodGet},
+			{Endpoint: "/p/:slug", Method: http.MethodGet},
+			{Endpoint: "/p/:slug", Method: http.MethodHead},
+			{Endpoint: "/.well-known/acme-challenge/:token", Method: http.MethodGet},
 			{Endpoint: "/api/v2/analytics/track/*", Method: http.MethodPost},
 			{Endpoint: "/api/v2/reviews", Method: http.MethodGet},
 			{Endpoint: "/api/v2/reviews/stats", Method: http.MethodGet},
 			{Endpoint: "/api/v2/reviews/:id", Method: http.MethodGet},
 		},
 	}
+
+	AppConfig.SelfOnlyRoutes = []APIRoute{
+		{Endpoint: "/api/v2/auth/update-password", Method: http.MethodPost},
+		{Endpoint: "/api/v2/auth/email", Method: http.MethodPost},
+		{Endpoint: "/api/v2/auth/email/confirm", Method: http.MethodPost},
+		{Endpoint: "/api/v2/auth/logout-all", Method: http.MethodPost},
+		{Endpoint: "/api/v2/auth/sessions/:id", Method: http.MethodDelete},
+		{Endpoint: "/api/v2/auth/passkeys/*", Method: "*"},
+		{Endpoint: "/api/v2/auth/identities/*", Method: "*"},
+		{Endpoint: "/api/v2/auth/2fa/*", Method: "*"},
+		{Endpoint: "/api/v2/me/verification", Method: http.MethodPost},
+		{Endpoint: "/api/v2/me/exports/*", Method: "*"},
+		{Endpoint: "/api/v2/me/deletion/*", Method: "*"},
+		{Endpoint: "/api/v2/users/:id", Method: http.MethodDelete},
+	}
+
+	for _, provider := range AppConfig.OIDCProviders {
+		AppConfig.NonAuthRoutes = append(AppConfig.NonAuthRoutes,
+			APIRoute{Endpoint: "/api/v2/auth/" + provider.Name, Method: http.MethodGet},
+			APIRoute{Endpoint: "/api/v2/auth/" + provider.Name + "/callback", Method: http.MethodGet},
+		)
+	}
+}
+
+// apiHosts returns the host names the API itself is reached at: the host of API_URL plus any in API_HOSTS,
+// a comma-separated list. Requests for any other host are answered with a portfolio site.
+func apiHosts() []string {
+	hosts := strings.Split(os.Getenv("API_HOSTS"), ",")
+	if apiUrl, err := url.Parse(os.Getenv("API_URL")); err == nil {
+		hosts = append(hosts, apiUrl.Hostname())
+	}
+
+	var result []string
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			result = append(result, host)
+		}
+	}
+	return result
+}
+
+// trustedProxies returns the addresses or CIDR ranges in TRUSTED_PROXIES, a comma-separated list. Client IP
+// addresses, which sign-in throttling is keyed on, are only read from X-Forwarded-For when the request
+// came through one of them.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// sitesDomain is the domain portfolio subdomains are claimed under, SITES_DOMAIN or foglio.app.
+func sitesDomain() string {
+	if domain := strings.ToLower(strings.TrimSpace(os.Getenv("SITES_DOMAIN"))); domain != "" {
+		return domain
+	}
+	return "foglio.app"
+}
+
+// webAuthnOrigins returns the origins passkeys may be used from, WEBAUTHN_ORIGINS as a comma-separated list
+// or the client URL when it is unset.
+func webAuthnOrigins() []string {
+	raw := os.Getenv("WEBAUTHN_ORIGINS")
+	if raw == "" {
+		raw = os.Getenv("CLIENT_URL")
+	}
+
+	var origins []string
+	for _, origin := range strings.Split(raw, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// reservedProviderNames are taken by built-in providers or by other routes under /auth, which a provider
+// of the same name would shadow or open up.
+var reservedProviderNames = map[string]bool{
+	"github": true, "google": true, "providers": true, "identities": true,
+	"sessions": true, "passkeys": true, "2fa": true,
+}
+
+// oidcProviders reads the providers named in OIDC_PROVIDERS. For a provider called "gitlab" the settings
+// are OIDC_GITLAB_ISSUER, OIDC_GITLAB_CLIENT_ID, OIDC_GITLAB_CLIENT_SECRET and OIDC_GITLAB_REDIRECT_URL,
+// with optional OIDC_GITLAB_DISPLAY_NAME and OIDC_GITLAB_SCOPES.
+func oidcProviders() []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || reservedProviderNames[name] {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OIDCProvider{
+			Name:         name,
+			DisplayName:  os.Getenv(prefix + "DISPLAY_NAME"),
+			Issuer:       strings.TrimSuffix(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientId:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectUrl:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if provider.DisplayName == "" {
+			provider.DisplayName = name
+		}
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"openid", "email", "profile"}
+		}
+		providers = append(providers, provider)
+	}
+	return providers
 }
@@ -244,6 +244,51 @@ func runMigrations(db *gorm.DB) error {
 		{"036_create_messages", &models.Message{}},
 		{"038_add_message_media", &models.Message{}},
 		{"039_create_reviews", &models.Review{}},
+		{"041_create_hub_connections", &models.HubConnection{}},
+		{"042_create_hub_payloads", &models.HubPayload{}},
+		{"043_add_user_presence", &models.User{}},
+		{"044_create_conversation_participants", &models.ConversationParticipant{}},
+		{"045_add_group_conversations", &models.Conversation{}},
+		{"046_add_message_replies_and_edits", &models.Message{}},
+		{"047_create_message_edits", &models.MessageEdit{}},
+		{"048_create_message_deletions", &models.MessageDeletion{}},
+		{"049_create_message_reactions", &models.MessageReaction{}},
+		{"050_create_chat_attachments", &models.ChatAttachment{}},
+		{"051_add_conversation_mutes", &models.ConversationParticipant{}},
+		{"052_create_user_blocks", &models.UserBlock{}},
+		{"053_create_chat_reports", &models.ChatReport{}},
+		{"054_create_chat_report_messages", &models.ChatReportMessage{}},
+		{"055_add_user_chat_bans", &models.User{}},
+		{"056_create_message_templates", &models.MessageTemplate{}},
+		{"057_create_outreach_messages", &models.OutreachMessage{}},
+		{"058_create_roles", &models.Role{}},
+		{"059_create_user_roles", &models.UserRole{}},
+		{"061_add_user_suspensions_and_verification_review", &models.User{}},
+		{"062_create_impersonation_sessions", &models.ImpersonationSession{}},
+		{"063_create_account_merges", &models.AccountMerge{}},
+		{"064_add_user_identity_verified", &models.User{}},
+		{"065_create_identity_verifications", &models.IdentityVerification{}},
+		{"066_create_audit_logs", &models.AuditLog{}},
+		{"068_create_sessions", &models.Session{}},
+		{"069_create_verification_tokens", &models.VerificationToken{}},
+		{"071_add_user_two_factor_lockout", &models.User{}},
+		{"072_create_trusted_devices", &models.TrustedDevice{}},
+		{"073_create_webauthn_credentials", &models.WebAuthnCredential{}},
+		{"074_create_webauthn_ceremonies", &models.WebAuthnCeremony{}},
+		{"075_create_user_identities", &models.UserIdentity{}},
+		{"076_create_oauth_states", &models.OAuthState{}},
+		{"078_create_company_ssos", &models.CompanySSO{}},
+		{"079_create_company_sso_domains", &models.CompanySSODomain{}},
+		{"080_create_saml_requests", &models.SAMLRequest{}},
+		{"081_create_saml_assertions", &models.SAMLAssertion{}},
+		{"082_create_auth_throttles", &models.AuthThrottle{}},
+		{"083_create_account_recoveries", &models.AccountRecovery{}},
+		{"084_create_data_exports", &models.DataExport{}},
+		{"085_create_account_deletions", &models.AccountDeletion{}},
+		{"086_create_acme_accounts", &models.ACMEAccount{}},
+		{"087_create_domain_certificates", &models.DomainCertificate{}},
+		{"088_create_acme_challenges", &models.ACMEChallenge{}},
+		{"089_add_account_deletion_requester", &models.AccountDeletion{}},
 	}
 
 	pendingCount := 0
@@ -329,6 +374,95 @@ func runCustomMigrations(db *gorm.DB, appliedMap map[string]bool) error {
 				      END IF;
 				  END $$;`,
 		},
+		{
+			name: "041_backfill_conversation_participants",
+			sql: `DO $$
+				  BEGIN
+				      INSERT INTO conversation_participants (conversation_id, user_id, role, joined_at, created_at, updated_at)
+				      SELECT c.id, p.user_id, 'MEMBER', c.created_at, NOW(), NOW()
+				      FROM conversations c
+				      CROSS JOIN LATERAL (VALUES (c.participant1), (c.participant2)) AS p(user_id)
+				      WHERE p.user_id IS NOT NULL
+				      ON CONFLICT (conversation_id, user_id) DO NOTHING;
+				      IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'messages' AND column_name = 'recipient_id') THEN
+				          UPDATE conversation_participants cp
+				          SET last_read_at = r.read_at
+				          FROM (
+				              SELECT conversation_id, recipient_id, MAX(COALESCE(read_at, created_at)) AS read_at
+				              FROM messages
+				              WHERE status = 'READ'
+				              GROUP BY conversation_id, recipient_id
+				          ) r
+				          WHERE cp.conversation_id = r.conversation_id AND cp.user_id = r.recipient_id;
+				          ALTER TABLE messages ALTER COLUMN recipient_id DROP NOT NULL;
+				      END IF;
+				  END $$;`,
+		},
+		{
+			name: "042_add_message_search_and_keyset_indexes",
+			sql: `DO $$
+				  BEGIN
+				      IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'messages' AND column_name = 'search_vector') THEN
+				          ALTER TABLE messages ADD COLUMN search_vector tsvector
+				              GENERATED ALWAYS AS (to_tsvector('simple', coalesce(content, ''))) STORED;
+				      END IF;
+				      IF NOT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_messages_search_vector') THEN
+				          CREATE INDEX idx_messages_search_vector ON messages USING GIN (search_vector);
+				      END IF;
+				      IF NOT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_messages_conversation_keyset') THEN
+				          CREATE INDEX idx_messages_conversation_keyset ON messages (conversation_id, created_at DESC, id DESC);
+				      END IF;
+				  END $$;`,
+		},
+		{
+			name: "060_seed_roles_and_migrate_admins",
+			sql: `DO $$
+				  BEGIN
+				      INSERT INTO roles (name, description, permissions, is_system, created_at, updated_at) VALUES
+				          ('super_admin', 'Full access to every admin capability', ARRAY['*'], true, NOW(), NOW()),
+				          ('moderator', 'Moderates users and chat reports', ARRAY['users:moderate'], true, NOW(), NOW()),
+				          ('content_manager', 'Publishes announcements and broadcasts notifications', ARRAY['announcements:write', 'notifications:broadcast'], true, NOW(), NOW()),
+				          ('billing_manager', 'Manages subscription tiers and reads revenue analytics', ARRAY['subscriptions:manage', 'analytics:read'], true, NOW(), NOW()),
+				          ('analyst', 'Reads platform analytics', ARRAY['analytics:read'], true, NOW(), NOW())
+				      ON CONFLICT (name) DO NOTHING;
+				      IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'is_admin') THEN
+				          INSERT INTO user_roles (user_id, role_id, created_at)
+				          SELECT u.id, r.id, NOW()
+				          FROM users u
+				          JOIN roles r ON r.name = 'super_admin'
+				          WHERE u.is_admin = true
+				          ON CONFLICT (user_id, role_id) DO NOTHING;
+				          ALTER TABLE users DROP COLUMN is_admin;
+				      END IF;
+				  END $$;`,
+		},
+		{
+			name: "067_make_audit_logs_append_only",
+			sql: `CREATE OR REPLACE FUNCTION reject_audit_log_change() RETURNS trigger AS $$
+				  BEGIN
+				      RAISE EXCEPTION 'audit_logs is append-only';
+				  END;
+				  $$ LANGUAGE plpgsql;
+				  DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+				  CREATE TRIGGER audit_logs_append_only
+				      BEFORE UPDATE OR DELETE ON audit_logs
+				      FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();`,
+		},
+		{
+			// Email codes now live hashed in verification_tokens
+			name: "070_drop_user_otp",
+			sql:  `ALTER TABLE users DROP COLUMN IF EXISTS otp;`,
+		},
+		{
+			// Accounts that signed up through a provider keep signing in through it
+			name: "077_backfill_user_identities",
+			sql: `INSERT INTO user_identities (user_id, provider, subject, email, name, last_used_at, created_at, updated_at)
+				  SELECT id, provider, provider_id, email, name, updated_at, NOW(), NOW()
+				  FROM users
+				  WHERE deleted_at IS NULL AND provider IS NOT NULL AND provider <> 'local'
+				      AND provider_id IS NOT NULL AND provider_id <> ''
+				  ON CONFLICT DO NOTHING;`,
+		},
 	}
 
 	for _, migration := range customMigrations {
@@ -51,7 +51,7 @@ const template = `{
         "/api/v2/ws": {
             "get": {
                 "summary": "WebSocket connection",
-                "description": "Establish a WebSocket connection for real-time notifications and chat messaging. Supported actions: send_message, typing, stop_typing, mark_messages_read, mark_read, ping. Messages are received as notifications with event_type in data field.",
+                "description": "Establish a WebSocket connection for real-time notifications and chat messaging. Supported actions: send_message, typing, stop_typing, mark_messages_read, mark_read, ping. Messages are received as notifications with event_type in data field. When the sign-in session the socket authenticated with is revoked, the socket receives an UNAUTHORIZED error and is closed.",
                 "tags": ["WebSocket"],
                 "security": [{"Bearer": []}],
                 "responses": {
@@ -80,6 +80,71 @@ const template = `{
                 }
             }
         },
+        "/api/v2/ws/send-notification": {
+            "post": {
+                "summary": "Send real-time notification",
+                "description": "Push a notification to a single connected user (requires the notifications:broadcast permission)",
+                "tags": ["WebSocket"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["user_id", "type", "title", "message"],
+                            "properties": {
+                                "user_id": {"type": "string", "format": "uuid"},
+                                "type": {"type": "string"},
+                                "title": {"type": "string"},
+                                "message": {"type": "string"},
+                                "data": {"type": "object"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Notification sent"},
+                    "400": {"description": "Invalid data"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            }
+        },
+        "/api/v2/ws/broadcast": {
+            "post": {
+                "summary": "Broadcast real-time notification",
+                "description": "Push a notification to every connected user (requires the notifications:broadcast permission)",
+                "tags": ["WebSocket"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["type", "title", "message"],
+                            "properties": {
+                                "type": {"type": "string"},
+                                "title": {"type": "string"},
+                                "message": {"type": "string"},
+                                "data": {"type": "object"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Broadcast sent to all users"},
+                    "400": {"description": "Invalid data"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            }
+        },
         "/api/v2/test/email": {
             "get": {
                 "summary": "Test endpoint",
@@ -141,7 +206,7 @@ const template = `{
         "/api/v2/auth/signin": {
             "post": {
                 "summary": "User signin",
-                "description": "Authenticate user and return token",
+                "description": "Authenticate user and return token. Unknown accounts and wrong passwords get the same response. Repeated failures for an account or from an IP address are slowed down and then locked out, and signing in from a new device or network emails the user.",
                 "tags": ["Authentication"],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
@@ -174,7 +239,15 @@ const template = `{
                             "properties": {
                                 "token": {
                                     "type": "string",
-                                    "description": "JWT token (empty if 2FA required)"
+                                    "description": "Short-lived JWT access token (empty if 2FA required)"
+                                },
+                                "refresh_token": {
+                                    "type": "string",
+                                    "description": "Single-use token exchanged at /auth/refresh for a new token pair (empty if 2FA required)"
+                                },
+                                "expires_in": {
+                                    "type": "integer",
+                                    "description": "Seconds until the access token expires"
                                 },
                                 "user": {
                                     "type": "object",
@@ -184,16 +257,317 @@ const template = `{
                                     "type": "boolean",
                                     "description": "True if 2FA verification is needed"
                                 },
-                                "user_id": {
+                                "challenge_token": {
                                     "type": "string",
-                                    "description": "User ID for 2FA verification (only if 2FA required)"
+                                    "description": "Token to send with the 2FA code to /auth/2fa/verify, or with a passkey to /auth/2fa/passkey/verify, within 5 minutes (only if 2FA required). Browsers remembered at the 2FA step skip it"
+                                },
+                                "two_factor_methods": {
+                                    "type": "array",
+                                    "items": {"type": "string", "enum": ["totp", "passkey"]},
+                                    "description": "Second factors the user can complete sign-in with (only if 2FA required)"
                                 }
                             }
                         }
                     },
+                    "400": {
+                        "description": "The email's domain belongs to a company that enforces SSO (SSO_REQUIRED), sign in through /sso/login instead"
+                    },
                     "401": {
-                        "description": "Unauthorized"
+                        "description": "Invalid email, username or password"
+                    },
+                    "403": {
+                        "description": "Account suspended"
+                    },
+                    "429": {
+                        "description": "Too many failed attempts; the Retry-After header gives the seconds to wait"
+                    }
+                }
+            }
+        },
+        "/api/v2/auth/refresh": {
+            "post": {
+                "summary": "Refresh access token",
+                "description": "Exchange a refresh token for a new access token and refresh token. Each refresh token works once; presenting one that was already used revokes its session",
+                "tags": ["Authentication"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["refresh_token"],
+                            "properties": {
+                                "refresh_token": {"type": "string"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token refreshed",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "token": {"type": "string"},
+                                "refresh_token": {"type": "string"},
+                                "expires_in": {"type": "integer"}
+                            }
+                        }
+                    },
+                    "401": {"description": "Invalid, expired or reused refresh token"},
+                    "403": {"description": "Account suspended"}
+                }
+            }
+        },
+        "/api/v2/auth/logout": {
+            "post": {
+                "summary": "Sign out",
+                "description": "Revoke the session the request is authenticated with",
+                "tags": ["Authentication"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Signed out"},
+                    "400": {"description": "Request is not signed in with a session"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/auth/logout-all": {
+            "post": {
+                "summary": "Sign out everywhere",
+                "description": "Revoke every session of the current user, including the current one. Their open WebSocket connections are closed",
+                "tags": ["Authentication"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Signed out of all sessions"},
+                    "400": {"description": "Request is not signed in with a session"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/auth/sessions": {
+            "get": {
+                "summary": "List sessions",
+                "description": "List the current user's active sessions, most recently used first. The session making the request is flagged as current",
+                "tags": ["Authentication"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Sessions retrieved"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/auth/sessions/{id}": {
+            "delete": {
+                "summary": "Revoke session",
+                "description": "Sign out one of the current user's sessions. Its open WebSocket connections are closed",
+                "tags": ["Authentication"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"in": "path", "name": "id", "required": true, "type": "string", "format": "uuid"}
+                ],
+                "responses": {
+                    "200": {"description": "Session revoked"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Session not found"}
+                }
+            }
+        },
+        "/api/v2/auth/passkeys": {
+            "get": {
+                "summary": "List passkeys",
+                "description": "List the passkeys registered to the account, with their names and when they were last used",
+                "tags": ["Passkeys"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Passkeys retrieved",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "properties": {
+                                    "id": {"type": "string", "format": "uuid"},
+                                    "user_id": {"type": "string", "format": "uuid"},
+                                    "name": {"type": "string"},
+                                    "transports": {"type": "array", "items": {"type": "string"}},
+                                    "synced": {"type": "boolean", "description": "Backed up to a passkey provider"},
+                                    "last_used_at": {"type": "string", "format": "date-time"},
+                                    "created_at": {"type": "string", "format": "date-time"},
+                                    "updated_at": {"type": "string", "format": "date-time"}
+                                }
+                            }
+                        }
+                    },
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/auth/passkeys/register/begin": {
+            "post": {
+                "summary": "Start passkey registration",
+                "description": "Get the options for navigator.credentials.create to add a passkey to the account. Passkeys already registered are excluded",
+                "tags": ["Passkeys"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Passkey registration started",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "ceremony_id": {"type": "string", "format": "uuid", "description": "Send back with the credential within 5 minutes"},
+                                "options": {"type": "object", "description": "PublicKeyCredentialCreationOptions"}
+                            }
+                        }
+                    },
+                    "401": {"description": "Unauthorized"},
+                    "500": {"description": "Passkeys are not configured on this server"}
+                }
+            }
+        },
+        "/api/v2/auth/passkeys/register/finish": {
+            "post": {
+                "summary": "Finish passkey registration",
+                "description": "Verify the new credential from the browser and save it under a name",
+                "tags": ["Passkeys"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["ceremony_id", "name", "credential"],
+                            "properties": {
+                                "ceremony_id": {"type": "string", "format": "uuid"},
+                                "name": {"type": "string", "example": "MacBook Touch ID", "maxLength": 64},
+                                "credential": {"type": "object", "description": "PublicKeyCredential returned by navigator.credentials.create"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {"description": "Passkey registered"},
+                    "400": {"description": "Ceremony invalid or expired, or passkey already registered"},
+                    "401": {"description": "Unauthorized or the credential could not be verified"}
+                }
+            }
+        },
+        "/api/v2/auth/passkeys/login/begin": {
+            "post": {
+                "summary": "Start passkey sign-in",
+                "description": "Get the options for navigator.credentials.get to sign in without a password. The browser offers whichever passkeys it holds for this site",
+                "tags": ["Passkeys"],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Passkey sign-in started",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "ceremony_id": {"type": "string", "format": "uuid", "description": "Send back with the credential within 5 minutes"},
+                                "options": {"type": "object", "description": "PublicKeyCredentialRequestOptions"}
+                            }
+                        }
+                    },
+                    "500": {"description": "Passkeys are not configured on this server"}
+                }
+            }
+        },
+        "/api/v2/auth/passkeys/login/finish": {
+            "post": {
+                "summary": "Sign in with a passkey",
+                "description": "Verify the passkey assertion and return the auth tokens. The passkey must verify the user (PIN or biometrics), so no 2FA step follows",
+                "tags": ["Passkeys"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["ceremony_id", "credential"],
+                            "properties": {
+                                "ceremony_id": {"type": "string", "format": "uuid"},
+                                "credential": {"type": "object", "description": "PublicKeyCredential returned by navigator.credentials.get"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User signed in",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "token": {"type": "string"},
+                                "refresh_token": {"type": "string"},
+                                "expires_in": {"type": "integer"},
+                                "user": {"type": "object"}
+                            }
+                        }
+                    },
+                    "400": {"description": "Ceremony invalid or expired"},
+                    "401": {"description": "Passkey could not be verified"},
+                    "403": {"description": "Account suspended"}
+                }
+            }
+        },
+        "/api/v2/auth/passkeys/{id}": {
+            "patch": {
+                "summary": "Rename a passkey",
+                "tags": ["Passkeys"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"in": "path", "name": "id", "required": true, "type": "string", "format": "uuid"},
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["name"],
+                            "properties": {
+                                "name": {"type": "string", "maxLength": 64}
+                            }
+                        }
                     }
+                ],
+                "responses": {
+                    "200": {"description": "Passkey renamed"},
+                    "400": {"description": "Invalid name"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Passkey not found"}
+                }
+            },
+            "delete": {
+                "summary": "Remove a passkey",
+                "tags": ["Passkeys"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"in": "path", "name": "id", "required": true, "type": "string", "format": "uuid"}
+                ],
+                "responses": {
+                    "200": {"description": "Passkey removed"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Passkey not found"}
                 }
             }
         },
@@ -288,16 +662,20 @@ const template = `{
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "required": ["user_id", "code"],
+                            "required": ["challenge_token", "code"],
                             "properties": {
-                                "user_id": {
+                                "challenge_token": {
                                     "type": "string",
-                                    "description": "User ID returned from signin"
+                                    "description": "Challenge token returned from signin"
                                 },
                                 "code": {
                                     "type": "string",
                                     "example": "123456",
                                     "description": "6-digit TOTP code or backup code"
+                                },
+                                "remember_device": {
+                                    "type": "boolean",
+                                    "description": "Set a cookie that skips 2FA on this browser for 30 days"
                                 }
                             }
                         }
@@ -312,6 +690,12 @@ const template = `{
                                 "token": {
                                     "type": "string"
                                 },
+                                "refresh_token": {
+                                    "type": "string"
+                                },
+                                "expires_in": {
+                                    "type": "integer"
+                                },
                                 "user": {
                                     "type": "object"
                                 }
@@ -319,15 +703,205 @@ const template = `{
                         }
                     },
                     "401": {
-                        "description": "Invalid verification code"
+                        "description": "Invalid verification code or expired challenge"
+                    },
+                    "429": {
+                        "description": "Too many incorrect codes; 2FA sign-in is locked for 15 minutes and the user is emailed. Too many from one IP address are also slowed down, see Retry-After"
+                    }
+                }
+            }
+        },
+        "/api/v2/auth/2fa/passkey/begin": {
+            "post": {
+                "summary": "Start passkey 2FA during login",
+                "description": "Get the options for navigator.credentials.get to finish a password sign-in with a registered passkey instead of a TOTP code",
+                "tags": ["Two-Factor Authentication"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["challenge_token"],
+                            "properties": {
+                                "challenge_token": {"type": "string", "description": "Challenge token returned from signin"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Passkey verification started",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "ceremony_id": {"type": "string", "format": "uuid"},
+                                "options": {"type": "object", "description": "PublicKeyCredentialRequestOptions"}
+                            }
+                        }
+                    },
+                    "400": {"description": "The user has no passkeys"},
+                    "401": {"description": "Expired challenge"}
+                }
+            }
+        },
+        "/api/v2/auth/2fa/passkey/verify": {
+            "post": {
+                "summary": "Verify 2FA during login with a passkey",
+                "description": "Exchange the challenge token and a passkey assertion for the auth tokens. Works while TOTP sign-in is locked",
+                "tags": ["Two-Factor Authentication"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["challenge_token", "ceremony_id", "credential"],
+                            "properties": {
+                                "challenge_token": {"type": "string"},
+                                "ceremony_id": {"type": "string", "format": "uuid"},
+                                "credential": {"type": "object", "description": "PublicKeyCredential returned by navigator.credentials.get"},
+                                "remember_device": {"type": "boolean", "description": "Set a cookie that skips 2FA on this browser for 30 days"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "2FA verification successful"},
+                    "400": {"description": "Ceremony invalid or expired"},
+                    "401": {"description": "Expired challenge or passkey could not be verified"}
+                }
+            }
+        },
+        "/api/v2/auth/2fa/recovery": {
+            "post": {
+                "summary": "Start account recovery",
+                "description": "For users who lost their authenticator and backup codes. Sign in with the password to get a challenge token, then send it here to get a recovery code by email.",
+                "tags": ["Two-Factor Authentication"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["challenge_token"],
+                            "properties": {"challenge_token": {"type": "string"}}
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Recovery code sent"},
+                    "400": {"description": "2FA is off (RECOVERY_NOT_NEEDED) or a recovery is already in progress (RECOVERY_PENDING)"},
+                    "401": {"description": "Invalid or expired challenge"},
+                    "403": {"description": "Account suspended"}
+                }
+            }
+        },
+        "/api/v2/auth/2fa/recovery/verify": {
+            "post": {
+                "summary": "Request account recovery",
+                "description": "Confirm the recovery code to open the recovery. 2FA can be turned off after a 72-hour waiting period, or sooner if an admin approves it, and the account is emailed a link to cancel it meanwhile.",
+                "tags": ["Two-Factor Authentication"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["challenge_token", "code"],
+                            "properties": {
+                                "challenge_token": {"type": "string"},
+                                "code": {"type": "string", "example": "123456"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Recovery requested",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "id": {"type": "string", "format": "uuid"},
+                                "status": {"type": "string", "enum": ["PENDING", "APPROVED", "REJECTED", "CANCELLED", "COMPLETED"]},
+                                "available_at": {"type": "string", "format": "date-time", "description": "When the recovery can be completed"}
+                            }
+                        }
+                    },
+                    "400": {"description": "Invalid or expired code, or a recovery is already in progress"},
+                    "401": {"description": "Invalid or expired challenge"}
+                }
+            }
+        },
+        "/api/v2/auth/2fa/recovery/complete": {
+            "post": {
+                "summary": "Complete account recovery",
+                "description": "Once the waiting period is over or an admin approved the recovery, turn off 2FA, sign out every session and trusted device, and sign in. Needs a fresh challenge token from a password sign-in.",
+                "tags": ["Two-Factor Authentication"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["challenge_token"],
+                            "properties": {"challenge_token": {"type": "string"}}
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Account recovered and signed in"},
+                    "400": {"description": "Still in the waiting period (RECOVERY_NOT_READY)"},
+                    "401": {"description": "Invalid or expired challenge"},
+                    "404": {"description": "No open recovery (RECOVERY_NOT_FOUND)"}
+                }
+            }
+        },
+        "/api/v2/auth/2fa/recovery/cancel": {
+            "post": {
+                "summary": "Cancel account recovery",
+                "description": "Stop a recovery with the link emailed when it was requested",
+                "tags": ["Two-Factor Authentication"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["token"],
+                            "properties": {"token": {"type": "string"}}
+                        }
                     }
+                ],
+                "responses": {
+                    "200": {"description": "Recovery cancelled"},
+                    "400": {"description": "Invalid or expired token"},
+                    "404": {"description": "The recovery is no longer open"}
                 }
             }
         },
         "/api/v2/auth/2fa/disable": {
             "post": {
                 "summary": "Disable 2FA",
-                "description": "Disable two-factor authentication (requires password confirmation)",
+                "description": "Disable two-factor authentication, forget trusted devices and sign out every other session (requires password confirmation)",
                 "tags": ["Two-Factor Authentication"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
@@ -412,6 +986,47 @@ const template = `{
                 }
             }
         },
+        "/api/v2/auth/2fa/trusted-devices": {
+            "get": {
+                "summary": "List trusted devices",
+                "description": "List the browsers that skip 2FA at sign-in",
+                "tags": ["Two-Factor Authentication"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Trusted devices retrieved"},
+                    "401": {"description": "Unauthorized"}
+                }
+            },
+            "delete": {
+                "summary": "Remove all trusted devices",
+                "description": "Make every remembered browser ask for 2FA again",
+                "tags": ["Two-Factor Authentication"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Trusted devices removed"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/auth/2fa/trusted-devices/{id}": {
+            "delete": {
+                "summary": "Remove a trusted device",
+                "description": "Make a remembered browser ask for 2FA again",
+                "tags": ["Two-Factor Authentication"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"in": "path", "name": "id", "required": true, "type": "string", "format": "uuid"}
+                ],
+                "responses": {
+                    "200": {"description": "Trusted device removed"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Trusted device not found"}
+                }
+            }
+        },
         "/api/v2/auth/2fa/status": {
             "get": {
                 "summary": "Get 2FA status",
@@ -430,6 +1045,10 @@ const template = `{
                                 },
                                 "backup_codes_left": {
                                     "type": "integer"
+                                },
+                                "passkeys": {
+                                    "type": "integer",
+                                    "description": "Registered passkeys, usable in place of a TOTP code"
                                 }
                             }
                         }
@@ -443,7 +1062,7 @@ const template = `{
         "/api/v2/auth/verification": {
             "post": {
                 "summary": "Account verification",
-                "description": "Verify user account with OTP",
+                "description": "Verify user account with the emailed code. Codes expire after 15 minutes and stop working after 5 wrong attempts; requesting a new code replaces the old one",
                 "tags": ["Authentication"],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
@@ -473,7 +1092,10 @@ const template = `{
                         "description": "Verification successful"
                     },
                     "400": {
-                        "description": "Invalid OTP"
+                        "description": "Invalid or expired code, or too many attempts"
+                    },
+                    "429": {
+                        "description": "Too many wrong codes for the email or from the IP address; see Retry-After"
                     }
                 }
             }
@@ -504,7 +1126,10 @@ const template = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Reset OTP sent"
+                        "description": "Reset link sent if the account exists; the response is the same either way"
+                    },
+                    "429": {
+                        "description": "Too many requests for the email or from the IP address; see Retry-After"
                     }
                 }
             }
@@ -512,7 +1137,7 @@ const template = `{
         "/api/v2/auth/reset-password": {
             "post": {
                 "summary": "Reset password",
-                "description": "Reset user password with OTP",
+                "description": "Reset user password with the emailed token and sign out every session",
                 "tags": ["Authentication"],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
@@ -523,17 +1148,13 @@ const template = `{
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "required": ["email", "otp", "newPassword"],
+                            "required": ["token", "new_password"],
                             "properties": {
-                                "email": {
-                                    "type": "string",
-                                    "example": "john@example.com"
-                                },
-                                "otp": {
+                                "token": {
                                     "type": "string",
-                                    "example": "123456"
+                                    "description": "Token from the reset link; valid for 30 minutes and usable once"
                                 },
-                                "newPassword": {
+                                "new_password": {
                                     "type": "string",
                                     "example": "newPassword123"
                                 }
@@ -544,6 +1165,9 @@ const template = `{
                 "responses": {
                     "200": {
                         "description": "Password reset successful"
+                    },
+                    "400": {
+                        "description": "Invalid, expired or already used token"
                     }
                 }
             }
@@ -551,7 +1175,7 @@ const template = `{
         "/api/v2/auth/update-password": {
             "post": {
                 "summary": "Update password",
-                "description": "Change user password",
+                "description": "Change user password and sign out every other session",
                 "tags": ["Authentication"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
@@ -604,359 +1228,580 @@ const template = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Verification OTP sent"
+                        "description": "Verification OTP sent if the account exists and is unverified; the response is the same either way"
                     },
                     "400": {
                         "description": "Bad request"
+                    },
+                    "429": {
+                        "description": "Too many requests for the email or from the IP address; see Retry-After"
                     }
                 }
             }
         },
-        "/api/v2/auth/{provider}": {
-            "get": {
-                "summary": "Get OAuth URL",
-                "description": "Get OAuth authorization URL for provider",
+        "/api/v2/auth/email": {
+            "post": {
+                "summary": "Change email",
+                "description": "Email a 6-digit confirmation code to the new address, valid for 15 minutes. Accounts with a password have to give it.",
                 "tags": ["Authentication"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "provider",
-                        "in": "path",
+                        "in": "body",
+                        "name": "body",
                         "required": true,
-                        "type": "string",
-                        "enum": ["google", "github"],
-                        "description": "OAuth provider"
+                        "schema": {
+                            "type": "object",
+                            "required": ["new_email"],
+                            "properties": {
+                                "new_email": {"type": "string", "example": "jane@newmail.com"},
+                                "password": {"type": "string", "description": "Current password, if the account has one"}
+                            }
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OAuth URL generated"
-                    }
+                    "200": {"description": "Confirmation code sent to the new email"},
+                    "400": {"description": "Incorrect password (INCORRECT_PASSWORD), same email (EMAIL_UNCHANGED) or email in use (EMAIL_IN_USE)"},
+                    "401": {"description": "Unauthorized"}
                 }
             }
         },
-        "/api/v2/auth/{provider}/callback": {
-            "get": {
-                "summary": "OAuth callback",
-                "description": "Handle OAuth provider callback",
+        "/api/v2/auth/email/confirm": {
+            "post": {
+                "summary": "Confirm email change",
+                "description": "Switch the account to the new email with the code sent to it. The old address is emailed a link, valid for 7 days, to revert the change.",
                 "tags": ["Authentication"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "provider",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "enum": ["google", "github"],
-                        "description": "OAuth provider"
-                    },
-                    {
-                        "name": "code",
-                        "in": "query",
+                        "in": "body",
+                        "name": "body",
                         "required": true,
-                        "type": "string",
-                        "description": "Authorization code"
-                    },
-                    {
-                        "name": "state",
-                        "in": "query",
-                        "type": "string",
-                        "description": "State parameter"
+                        "schema": {
+                            "type": "object",
+                            "required": ["code"],
+                            "properties": {"code": {"type": "string", "example": "123456"}}
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Authentication successful"
-                    },
-                    "400": {
-                        "description": "Bad request"
-                    }
+                    "200": {"description": "Email changed"},
+                    "400": {"description": "Invalid or expired code (INVALID_CODE), too many attempts, or the email was taken meanwhile (EMAIL_IN_USE)"},
+                    "401": {"description": "Unauthorized"}
                 }
             }
         },
-        "/api/v2/users/": {
-            "get": {
-                "summary": "List users",
-                "description": "Get all users",
-                "tags": ["Users"],
-                "security": [{"Bearer": []}],
+        "/api/v2/auth/email/revert": {
+            "post": {
+                "summary": "Revert email change",
+                "description": "Put back the previous email with the link sent to it. Every session and trusted device is signed out and pending email changes and password reset links stop working.",
+                "tags": ["Authentication"],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
-                "responses": {
-                    "200": {
-                        "description": "List of users"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["token"],
+                            "properties": {"token": {"type": "string"}}
+                        }
                     }
+                ],
+                "responses": {
+                    "200": {"description": "Email restored"},
+                    "400": {"description": "Invalid or expired token (INVALID_TOKEN), or the previous email now belongs to another account (EMAIL_REVERT_UNAVAILABLE)"}
                 }
             }
         },
-        "/api/v2/users/{id}": {
-            "get": {
-                "summary": "Get user",
-                "description": "Get user by ID",
-                "tags": ["Users"],
+        "/api/v2/auth/deletion/cancel": {
+            "post": {
+                "summary": "Cancel account deletion by link",
+                "description": "Keep the account with the link emailed when its deletion was scheduled",
+                "tags": ["Authentication"],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "id",
-                        "in": "path",
+                        "in": "body",
+                        "name": "body",
                         "required": true,
-                        "type": "string",
-                        "description": "User UUID"
+                        "schema": {
+                            "type": "object",
+                            "required": ["token"],
+                            "properties": {"token": {"type": "string"}}
+                        }
                     }
                 ],
+                "responses": {
+                    "200": {"description": "Account deletion cancelled"},
+                    "400": {"description": "Invalid or expired token (INVALID_TOKEN)"},
+                    "404": {"description": "The deletion was already cancelled or carried out (DELETION_NOT_FOUND)"}
+                }
+            }
+        },
+        "/api/v2/auth/providers": {
+            "get": {
+                "summary": "List sign-in providers",
+                "description": "List the identity providers users can sign in with or link: Google and GitHub when configured, plus any OpenID Connect providers from OIDC_PROVIDERS",
+                "tags": ["Authentication"],
+                "produces": ["application/json"],
                 "responses": {
                     "200": {
-                        "description": "User details"
-                    },
-                    "404": {
-                        "description": "User not found"
+                        "description": "Providers retrieved",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "properties": {
+                                    "name": {"type": "string", "description": "Used in /auth/{provider}"},
+                                    "display_name": {"type": "string"}
+                                }
+                            }
+                        }
                     }
                 }
+            }
+        },
+        "/api/v2/auth/identities": {
+            "get": {
+                "summary": "List linked providers",
+                "description": "List the provider accounts linked to the account",
+                "tags": ["Authentication"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Linked providers retrieved",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "properties": {
+                                    "id": {"type": "string", "format": "uuid"},
+                                    "user_id": {"type": "string", "format": "uuid"},
+                                    "provider": {"type": "string"},
+                                    "email": {"type": "string"},
+                                    "name": {"type": "string"},
+                                    "last_used_at": {"type": "string", "format": "date-time"},
+                                    "created_at": {"type": "string", "format": "date-time"},
+                                    "updated_at": {"type": "string", "format": "date-time"}
+                                }
+                            }
+                        }
+                    },
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/auth/identities/{provider}": {
+            "post": {
+                "summary": "Start linking a provider",
+                "description": "Get the provider's authorization URL to link another account to this one. The request's state is also set in the HTTP-only foglio_oauth_state cookie. The provider redirects to the same URL as for sign-in, so the client has to remember it started a link and call /auth/identities/{provider}/callback instead.",
+                "tags": ["Authentication"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "provider", "in": "path", "required": true, "type": "string", "description": "Provider name"}
+                ],
+                "responses": {
+                    "200": {"description": "Authorization URL generated", "schema": {"type": "string"}},
+                    "400": {"description": "Unsupported provider (UNSUPPORTED_PROVIDER)"},
+                    "401": {"description": "Unauthorized"}
+                }
             },
-            "put": {
-                "summary": "Update user",
-                "description": "Update user by ID",
-                "tags": ["Users"],
+            "delete": {
+                "summary": "Unlink a provider",
+                "description": "Remove a linked provider. The account must keep a password, a passkey or another provider to sign in with.",
+                "tags": ["Authentication"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "provider", "in": "path", "required": true, "type": "string", "description": "Provider name"}
+                ],
+                "responses": {
+                    "200": {"description": "Provider unlinked"},
+                    "400": {"description": "It is the last way to sign in (LAST_SIGN_IN_METHOD)"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Provider is not linked (IDENTITY_NOT_FOUND)"}
+                }
+            }
+        },
+        "/api/v2/auth/identities/{provider}/callback": {
+            "post": {
+                "summary": "Finish linking a provider",
+                "description": "Exchange the code the provider redirected back with and link the provider account. Must be sent with the foglio_oauth_state cookie.",
+                "tags": ["Authentication"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
+                    {"name": "provider", "in": "path", "required": true, "type": "string", "description": "Provider name"},
                     {
-                        "name": "id",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "description": "User UUID"
-                    },
-                    {
-                        "in": "body",
                         "name": "body",
+                        "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
+                            "required": ["code", "state"],
                             "properties": {
-                                "name": {"type": "string"},
-                                "headline": {"type": "string"},
-                                "location": {"type": "string"},
-                                "summary": {"type": "string"}
+                                "code": {"type": "string"},
+                                "state": {"type": "string"}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "User updated"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
-                    }
+                    "201": {"description": "Provider linked", "schema": {
+                                "type": "object",
+                                "properties": {
+                                    "id": {"type": "string", "format": "uuid"},
+                                    "user_id": {"type": "string", "format": "uuid"},
+                                    "provider": {"type": "string"},
+                                    "email": {"type": "string"},
+                                    "name": {"type": "string"},
+                                    "last_used_at": {"type": "string", "format": "date-time"},
+                                    "created_at": {"type": "string", "format": "date-time"},
+                                    "updated_at": {"type": "string", "format": "date-time"}
+                                }
+                            }},
+                    "400": {"description": "Invalid or expired state (INVALID_STATE), account linked to another user (IDENTITY_LINKED_ELSEWHERE) or another account from the provider already linked (PROVIDER_ALREADY_LINKED)"},
+                    "401": {"description": "Unauthorized"}
                 }
-            },
-            "delete": {
-                "summary": "Delete user",
-                "description": "Delete user by ID",
-                "tags": ["Users"],
-                "security": [{"Bearer": []}],
+            }
+        },
+        "/api/v2/auth/{provider}": {
+            "get": {
+                "summary": "Get OAuth URL",
+                "description": "Get the provider's authorization URL. The request uses PKCE, and its state is also set in the HTTP-only foglio_oauth_state cookie, which the callback must be sent with.",
+                "tags": ["Authentication"],
+                "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "id",
+                        "name": "provider",
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "User UUID"
+                        "description": "Provider name, see /auth/providers"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User deleted"
+                        "description": "OAuth URL generated"
                     },
-                    "401": {
-                        "description": "Unauthorized"
+                    "400": {
+                        "description": "Unsupported provider (UNSUPPORTED_PROVIDER)"
                     }
                 }
             }
         },
-        "/api/v2/users/{id}/avatar": {
-            "put": {
-                "summary": "Update avatar",
-                "description": "Update user avatar",
-                "tags": ["Users"],
-                "security": [{"Bearer": []}],
-                "consumes": ["multipart/form-data"],
+        "/api/v2/auth/{provider}/callback": {
+            "get": {
+                "summary": "OAuth callback",
+                "description": "Handle the provider callback. The state must match the foglio_oauth_state cookie. A new provider account signs in to the user it is linked to; otherwise it is linked to the user with the same email only when the provider has verified that email, or a new user is created.",
+                "tags": ["Authentication"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "id",
+                        "name": "provider",
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "User UUID"
+                        "description": "Provider name"
                     },
                     {
-                        "name": "avatar",
-                        "in": "formData",
+                        "name": "code",
+                        "in": "query",
                         "required": true,
-                        "type": "file",
-                        "description": "Avatar image file"
+                        "type": "string",
+                        "description": "Authorization code"
+                    },
+                    {
+                        "name": "state",
+                        "in": "query",
+                        "required": true,
+                        "type": "string",
+                        "description": "State parameter"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Avatar updated"
+                        "description": "Authentication successful"
                     },
-                    "401": {
-                        "description": "Unauthorized"
+                    "400": {
+                        "description": "Invalid or expired state (INVALID_STATE), or an account already uses the email (EMAIL_IN_USE)"
+                    },
+                    "403": {
+                        "description": "Account suspended"
                     }
                 }
             }
         },
-        "/api/v2/me": {
+        "/api/v2/sso/config": {
             "get": {
-                "summary": "Get current user",
-                "description": "Get authenticated user's profile",
-                "tags": ["Users"],
+                "summary": "Get company SSO setup",
+                "description": "Get the SAML setup of the recruiter's company. The service provider side, with its entity ID, ACS URL and certificate to enter at the IdP, is created on first request.",
+                "tags": ["SSO"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "responses": {
                     "200": {
-                        "description": "User profile"
+                        "description": "SSO setup retrieved",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "id": {"type": "string", "format": "uuid"},
+                                "company_id": {"type": "string", "format": "uuid"},
+                                "enabled": {"type": "boolean"},
+                                "enforce_sso": {"type": "boolean", "description": "Blocks password sign-in for the company's verified domains"},
+                                "allow_idp_initiated": {"type": "boolean"},
+                                "idp_entity_id": {"type": "string"},
+                                "idp_sso_url": {"type": "string"},
+                                "sp_certificate": {"type": "string", "description": "PEM certificate the IdP encrypts assertions to"},
+                                "sp_entity_id": {"type": "string"},
+                                "acs_url": {"type": "string"},
+                                "metadata_url": {"type": "string"},
+                                "domains": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "object",
+                                        "properties": {
+                                            "id": {"type": "string", "format": "uuid"},
+                                            "domain": {"type": "string"},
+                                            "dns_record": {"type": "object", "description": "TXT record proving ownership, with type, name, value and status"},
+                                            "verified_at": {"type": "string", "format": "date-time"}
+                                        }
+                                    }
+                                }
+                            }
+                        }
                     },
-                    "401": {
-                        "description": "Unauthorized"
-                    }
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a recruiter of a company"}
                 }
-            }
-        },
-        "/api/v2/me/jobs": {
-            "get": {
-                "summary": "Get my jobs",
-                "description": "Get jobs created by authenticated user",
-                "tags": ["Jobs"],
+            },
+            "put": {
+                "summary": "Update company SSO setup",
+                "description": "Upload the IdP metadata, as XML or an https URL to fetch it from, and turn SSO and its enforcement on or off. Enforcing SSO needs a verified domain.",
+                "tags": ["SSO"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "page",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Page number"
-                    },
-                    {
-                        "name": "limit",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Items per page"
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "idp_metadata": {"type": "string", "description": "IdP metadata XML"},
+                                "idp_metadata_url": {"type": "string"},
+                                "enabled": {"type": "boolean"},
+                                "enforce_sso": {"type": "boolean"},
+                                "allow_idp_initiated": {"type": "boolean"}
+                            }
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "List of user's jobs"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
-                    }
+                    "200": {"description": "SSO setup updated"},
+                    "400": {"description": "Invalid metadata (INVALID_IDP_METADATA), metadata missing (IDP_METADATA_REQUIRED) or no verified domain to enforce SSO on (NO_VERIFIED_DOMAIN)"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a recruiter of a company"}
                 }
-            }
-        },
-        "/api/v2/user/profile": {
-            "get": {
-                "summary": "Get user profile",
-                "description": "Get authenticated user's profile (alias for /me)",
-                "tags": ["Users"],
+            },
+            "delete": {
+                "summary": "Remove company SSO",
+                "description": "Turn SSO off and forget the IdP and the company's SSO domains",
+                "tags": ["SSO"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "responses": {
-                    "200": {
-                        "description": "User profile"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
-                    }
+                    "200": {"description": "SSO removed"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a recruiter of a company"},
+                    "404": {"description": "SSO is not set up (SSO_NOT_CONFIGURED)"}
                 }
             }
         },
-        "/api/v2/jobs/": {
+        "/api/v2/sso/domains": {
             "post": {
-                "summary": "Create job",
-                "description": "Create a new job posting",
-                "tags": ["Jobs"],
+                "summary": "Claim an SSO email domain",
+                "description": "Claim an email domain for the company. Returns the TXT record to create at _foglio-sso.<domain> before verifying.",
+                "tags": ["SSO"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "in": "body",
                         "name": "body",
+                        "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "required": ["title", "company", "location", "description", "employmentType"],
-                            "properties": {
-                                "title": {"type": "string", "example": "Senior Software Engineer"},
-                                "company": {"type": "string", "example": "Tech Corp"},
-                                "location": {"type": "string", "example": "San Francisco, CA"},
-                                "description": {"type": "string"},
-                                "requirements": {"type": "array", "items": {"type": "string"}},
-                                "employmentType": {"type": "string", "example": "Full-time"},
-                                "isRemote": {"type": "boolean", "example": true}
-                            }
+                            "required": ["domain"],
+                            "properties": {"domain": {"type": "string", "example": "acme.com"}}
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Job created"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
-                    }
+                    "201": {"description": "Domain added"},
+                    "400": {"description": "Bad request"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a recruiter of a company"}
                 }
-            },
-            "get": {
-                "summary": "List jobs",
-                "description": "Get all jobs with optional filters",
-                "tags": ["Jobs"],
+            }
+        },
+        "/api/v2/sso/domains/{id}/verify": {
+            "post": {
+                "summary": "Verify an SSO email domain",
+                "description": "Check the domain's TXT record. Only verified domains route sign-ins to the IdP and accept its users, and a domain can be verified by only one company.",
+                "tags": ["SSO"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Domain ID"}
+                ],
+                "responses": {
+                    "200": {"description": "Domain verified"},
+                    "400": {"description": "Record not found yet (DOMAIN_NOT_VERIFIED) or verified by another company (DOMAIN_TAKEN)"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Domain not found"}
+                }
+            }
+        },
+        "/api/v2/sso/domains/{id}": {
+            "delete": {
+                "summary": "Remove an SSO email domain",
+                "description": "Remove the domain. SSO stops being enforced once no verified domain is left.",
+                "tags": ["SSO"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Domain ID"}
+                ],
+                "responses": {
+                    "200": {"description": "Domain removed"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Domain not found"}
+                }
+            }
+        },
+        "/api/v2/sso/login": {
+            "post": {
+                "summary": "Start signing in with SSO",
+                "description": "Get the IdP sign-in URL for the company that verified the email's domain",
+                "tags": ["SSO"],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "location",
-                        "in": "query",
-                        "type": "string",
-                        "description": "Filter by location"
-                    },
-                    {
-                        "name": "isRemote",
-                        "in": "query",
-                        "type": "boolean",
-                        "description": "Filter by remote jobs"
-                    },
-                    {
-                        "name": "page",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Page number"
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["email"],
+                            "properties": {"email": {"type": "string", "example": "jane@acme.com"}}
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SSO sign-in started",
+                        "schema": {"type": "object", "properties": {"url": {"type": "string"}}}
                     },
+                    "404": {"description": "No SSO for the email's domain (SSO_NOT_AVAILABLE)"}
+                }
+            }
+        },
+        "/api/v2/sso/{companyId}/metadata": {
+            "get": {
+                "summary": "Service provider metadata",
+                "description": "SAML metadata of the company's service provider, to upload to the IdP. Its URL is also the entity ID.",
+                "tags": ["SSO"],
+                "produces": ["application/samlmetadata+xml"],
+                "parameters": [
+                    {"name": "companyId", "in": "path", "required": true, "type": "string", "description": "Company ID"}
+                ],
+                "responses": {
+                    "200": {"description": "SAML metadata"},
+                    "404": {"description": "SSO is not set up"}
+                }
+            }
+        },
+        "/api/v2/sso/{companyId}/acs": {
+            "post": {
+                "summary": "SAML assertion consumer service",
+                "description": "Receives the IdP's response through the browser, for SP- and (if allowed) IdP-initiated sign-in. Users are provisioned into the company as recruiters on first sign-in; their email must be on a verified domain. Redirects to CLIENT_URL/auth/sso/callback with a one-time code, or with an error.",
+                "tags": ["SSO"],
+                "consumes": ["application/x-www-form-urlencoded"],
+                "parameters": [
+                    {"name": "companyId", "in": "path", "required": true, "type": "string", "description": "Company ID"},
+                    {"name": "SAMLResponse", "in": "formData", "required": true, "type": "string", "description": "Base64 SAML response"},
+                    {"name": "RelayState", "in": "formData", "type": "string"}
+                ],
+                "responses": {
+                    "302": {"description": "Redirect to the client"}
+                }
+            }
+        },
+        "/api/v2/sso/exchange": {
+            "post": {
+                "summary": "Finish signing in with SSO",
+                "description": "Exchange the one-time code from the SSO callback, valid for 2 minutes, for the auth tokens or a 2FA challenge",
+                "tags": ["SSO"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
                     {
-                        "name": "size",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Items per page"
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["code"],
+                            "properties": {"code": {"type": "string"}}
+                        }
                     }
                 ],
+                "responses": {
+                    "200": {"description": "Authentication successful (or 2FA required)"},
+                    "400": {"description": "Invalid or expired code (INVALID_CODE)"},
+                    "403": {"description": "Account suspended"}
+                }
+            }
+        },
+        "/api/v2/users/": {
+            "get": {
+                "summary": "List users",
+                "description": "Get all users",
+                "tags": ["Users"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
                 "responses": {
                     "200": {
-                        "description": "List of jobs"
+                        "description": "List of users"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
                     }
                 }
             }
         },
-        "/api/v2/jobs/{id}": {
+        "/api/v2/users/{id}": {
             "get": {
-                "summary": "Get job",
-                "description": "Get job by ID",
-                "tags": ["Jobs"],
+                "summary": "Get user",
+                "description": "Get user by ID",
+                "tags": ["Users"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
@@ -964,22 +1809,22 @@ const template = `{
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Job UUID"
+                        "description": "User UUID"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Job details"
+                        "description": "User details"
                     },
                     "404": {
-                        "description": "Job not found"
+                        "description": "User not found"
                     }
                 }
             },
             "put": {
-                "summary": "Update job",
-                "description": "Update job by ID",
-                "tags": ["Jobs"],
+                "summary": "Update user",
+                "description": "Update user by ID",
+                "tags": ["Users"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
@@ -989,7 +1834,7 @@ const template = `{
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Job UUID"
+                        "description": "User UUID"
                     },
                     {
                         "in": "body",
@@ -998,26 +1843,30 @@ const template = `{
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "title": {"type": "string"},
-                                "description": {"type": "string"},
-                                "isRemote": {"type": "boolean"}
+                                "name": {"type": "string"},
+                                "headline": {"type": "string"},
+                                "location": {"type": "string"},
+                                "summary": {"type": "string"}
                             }
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Job updated"
+                        "description": "User updated"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Only the account owner or a user moderator can change this user"
                     }
                 }
             },
             "delete": {
-                "summary": "Delete job",
-                "description": "Delete job by ID",
-                "tags": ["Jobs"],
+                "summary": "Delete user",
+                "description": "Take a user's account down and schedule its erasure for the end of the 14-day grace period, until which staff can restore it through /admin/users/{id}/restore. The account is soft-deleted and signed out everywhere right away. Staff accounts and your own cannot be deleted here; owners use /me/deletion. For moderators",
+                "tags": ["Users"],
                 "security": [{"Bearer": []}],
                 "parameters": [
                     {
@@ -1025,24 +1874,33 @@ const template = `{
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Job UUID"
+                        "description": "User UUID"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Job deleted"
+                        "description": "User deleted; returns the scheduled account deletion"
+                    },
+                    "400": {
+                        "description": "Owners delete their own account through /me/deletion (DELETION_CONFIRMATION_REQUIRED)"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Only a user moderator can delete this user, and never a staff account"
+                    },
+                    "404": {
+                        "description": "User not found"
                     }
                 }
             }
         },
-        "/api/v2/jobs/{id}/apply": {
-            "post": {
-                "summary": "Apply to job",
-                "description": "Submit job application",
-                "tags": ["Jobs"],
+        "/api/v2/users/{id}/avatar": {
+            "put": {
+                "summary": "Update avatar",
+                "description": "Update user avatar",
+                "tags": ["Users"],
                 "security": [{"Bearer": []}],
                 "consumes": ["multipart/form-data"],
                 "produces": ["application/json"],
@@ -1052,25 +1910,19 @@ const template = `{
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Job UUID"
+                        "description": "User UUID"
                     },
                     {
-                        "name": "resume",
+                        "name": "avatar",
                         "in": "formData",
                         "required": true,
                         "type": "file",
-                        "description": "Resume file"
-                    },
-                    {
-                        "name": "coverLetter",
-                        "in": "formData",
-                        "type": "string",
-                        "description": "Cover letter text"
+                        "description": "Avatar image file"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Application submitted"
+                    "200": {
+                        "description": "Avatar updated"
                     },
                     "401": {
                         "description": "Unauthorized"
@@ -1078,30 +1930,16 @@ const template = `{
                 }
             }
         },
-        "/api/v2/jobs/applications/user": {
+        "/api/v2/me": {
             "get": {
-                "summary": "Get my applications",
-                "description": "Get all job applications submitted by authenticated user",
-                "tags": ["Job Applications"],
+                "summary": "Get current user",
+                "description": "Get authenticated user's profile",
+                "tags": ["Users"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
-                "parameters": [
-                    {
-                        "name": "page",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Page number"
-                    },
-                    {
-                        "name": "limit",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Items per page"
-                    }
-                ],
                 "responses": {
                     "200": {
-                        "description": "List of applications"
+                        "description": "User profile"
                     },
                     "401": {
                         "description": "Unauthorized"
@@ -1109,11 +1947,11 @@ const template = `{
                 }
             }
         },
-        "/api/v2/jobs/applications/recruiter": {
+        "/api/v2/me/jobs": {
             "get": {
-                "summary": "Get all applications for recruiter",
-                "description": "Get all applications from all jobs posted by the authenticated recruiter",
-                "tags": ["Job Applications"],
+                "summary": "Get my jobs",
+                "description": "Get jobs created by authenticated user",
+                "tags": ["Jobs"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
@@ -1124,367 +1962,323 @@ const template = `{
                         "description": "Page number"
                     },
                     {
-                        "name": "size",
+                        "name": "limit",
                         "in": "query",
                         "type": "integer",
                         "description": "Items per page"
-                    },
-                    {
-                        "name": "status",
-                        "in": "query",
-                        "type": "string",
-                        "description": "Filter by application status"
-                    },
-                    {
-                        "name": "submission_date",
-                        "in": "query",
-                        "type": "string",
-                        "description": "Filter by submission date (YYYY-MM-DD)"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of all applications for recruiter's jobs"
+                        "description": "List of user's jobs"
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "403": {
-                        "description": "Forbidden - only recruiters can access this endpoint"
                     }
                 }
             }
         },
-        "/api/v2/jobs/applications/job/{id}": {
+        "/api/v2/me/permissions": {
             "get": {
-                "summary": "Get applications for job",
-                "description": "Get all applications for a specific job (recruiter only)",
-                "tags": ["Job Applications"],
+                "summary": "Get my admin access",
+                "description": "List the admin roles assigned to the current user and the permissions they grant, with the super_admin wildcard expanded",
+                "tags": ["Users"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
-                "parameters": [
-                    {
-                        "name": "id",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "description": "Job UUID"
-                    },
-                    {
-                        "name": "page",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Page number"
+                "responses": {
+                    "200": {
+                        "description": "Permissions retrieved",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "roles": {"type": "array", "items": {"type": "string"}, "example": ["moderator"]},
+                                "permissions": {"type": "array", "items": {"type": "string"}, "example": ["users:moderate"]}
+                            }
+                        }
                     },
-                    {
-                        "name": "limit",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Items per page"
-                    },
-                    {
-                        "name": "status",
-                        "in": "query",
-                        "type": "string",
-                        "enum": ["PENDING", "REVIEWED", "ACCEPTED", "REJECTED", "HIRED"],
-                        "description": "Filter by application status"
-                    },
-                    {
-                        "name": "submission_date",
-                        "in": "query",
-                        "type": "string",
-                        "format": "date",
-                        "description": "Filter by submission date (YYYY-MM-DD)"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "List of applications"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
-                    },
-                    "403": {
-                        "description": "Forbidden - not a recruiter"
-                    }
+                    "401": {"description": "Unauthorized"}
                 }
             }
         },
-        "/api/v2/jobs/applications/{applicationId}": {
+        "/api/v2/me/verification": {
             "get": {
-                "summary": "Get application",
-                "description": "Get a specific job application by ID",
-                "tags": ["Job Applications"],
+                "summary": "Get my identity verification",
+                "description": "Get the current user's most recent identity verification submission and its review status",
+                "tags": ["Users"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Verification retrieved"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "No verification submitted"}
+                }
+            },
+            "post": {
+                "summary": "Submit identity verification",
+                "description": "Upload an identity document (JPEG, PNG or PDF, up to 10MB) for moderator review. The number is uppercased with spaces and separators removed, then checked against the format for its type: DRIVERS_LICENSE 5-20 letters or digits, INTERNATIONAL_PASSPORT a letter followed by 5-8 letters or digits, NATIONAL_ID_CARD 6-20, VOTERS_CARD 10-20. Approval grants the identity_verified badge shown on profiles and applications",
+                "tags": ["Users"],
                 "security": [{"Bearer": []}],
+                "consumes": ["multipart/form-data"],
                 "produces": ["application/json"],
                 "parameters": [
-                    {
-                        "name": "applicationId",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "description": "Application UUID"
-                    }
+                    {"name": "type", "in": "formData", "required": true, "type": "string", "enum": ["DRIVERS_LICENSE", "INTERNATIONAL_PASSPORT", "NATIONAL_ID_CARD", "VOTERS_CARD"]},
+                    {"name": "number", "in": "formData", "required": true, "type": "string", "example": "A12345678"},
+                    {"name": "document", "in": "formData", "required": true, "type": "file"}
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Application details"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Application not found"
-                    }
+                    "201": {"description": "Verification submitted"},
+                    "400": {"description": "Invalid data, bad number format, unsupported or oversized document, number linked to another account, submission already pending or identity already verified"},
+                    "401": {"description": "Unauthorized"}
                 }
             }
         },
-        "/api/v2/jobs/applications/{id}/accept": {
+        "/api/v2/me/exports": {
+            "get": {
+                "summary": "List data exports",
+                "description": "List the current user's 10 most recent data exports and their status: PENDING, PROCESSING, READY, FAILED or EXPIRED",
+                "tags": ["Users"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Data exports retrieved"},
+                    "401": {"description": "Unauthorized"}
+                }
+            },
             "post": {
-                "summary": "Accept application",
-                "description": "Accept a job application (recruiter only)",
-                "tags": ["Job Applications"],
+                "summary": "Request a data export",
+                "description": "Start building a ZIP of everything stored about the account: account.json, one JSON file per kind of record (portfolio, jobs, applications, messages, payments, sessions, activity and so on) and the uploaded media under media/. The account is emailed when it is ready, and it can be downloaded for 7 days. One export can be requested per day; a failed export can be retried straight away.",
+                "tags": ["Users"],
                 "security": [{"Bearer": []}],
-                "consumes": ["application/json"],
                 "produces": ["application/json"],
+                "responses": {
+                    "201": {"description": "Data export queued"},
+                    "400": {"description": "An export is already being prepared (EXPORT_IN_PROGRESS) or one was requested in the last 24 hours (EXPORT_TOO_SOON)"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/me/exports/{id}/download": {
+            "get": {
+                "summary": "Download a data export",
+                "description": "Download a ready export as a ZIP archive",
+                "tags": ["Users"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/zip"],
                 "parameters": [
-                    {
-                        "name": "id",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "description": "Application UUID"
-                    },
-                    {
-                        "in": "body",
-                        "name": "body",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "reason": {
-                                    "type": "string",
-                                    "description": "Optional reason/message"
-                                }
-                            }
-                        }
-                    }
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Export UUID"}
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Application accepted"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
-                    },
-                    "403": {
-                        "description": "Forbidden - not a recruiter"
-                    }
+                    "200": {"description": "ZIP archive", "schema": {"type": "file"}},
+                    "400": {"description": "The export is still being prepared, failed or expired (EXPORT_NOT_READY)"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Export not found (EXPORT_NOT_FOUND)"}
                 }
             }
         },
-        "/api/v2/jobs/applications/{id}/reject": {
+        "/api/v2/me/deletion": {
+            "get": {
+                "summary": "Get scheduled account deletion",
+                "description": "Get the current user's scheduled account deletion and when it takes effect",
+                "tags": ["Users"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Account deletion retrieved"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "No deletion scheduled (DELETION_NOT_FOUND)"}
+                }
+            },
             "post": {
-                "summary": "Reject application",
-                "description": "Reject a job application (recruiter only)",
-                "tags": ["Job Applications"],
+                "summary": "Delete account",
+                "description": "Email a 6-digit code, valid for 15 minutes, to confirm the deletion of the account. Accounts with a password have to give it.",
+                "tags": ["Users"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
-                    {
-                        "name": "id",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "description": "Application UUID"
-                    },
                     {
                         "in": "body",
                         "name": "body",
+                        "required": true,
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "reason": {
-                                    "type": "string",
-                                    "description": "Optional rejection reason"
-                                }
+                                "password": {"type": "string", "description": "Current password, if the account has one"}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Application rejected"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
-                    },
-                    "403": {
-                        "description": "Forbidden - not a recruiter"
-                    }
+                    "200": {"description": "Confirmation code sent"},
+                    "400": {"description": "Incorrect password (INCORRECT_PASSWORD) or a deletion is already scheduled (DELETION_PENDING)"},
+                    "401": {"description": "Unauthorized"}
+                }
+            },
+            "delete": {
+                "summary": "Cancel account deletion",
+                "description": "Keep the account while its deletion is still scheduled",
+                "tags": ["Users"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Account deletion cancelled"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "No deletion scheduled (DELETION_NOT_FOUND)"}
                 }
             }
         },
-        "/api/v2/jobs/applications/{id}/review": {
+        "/api/v2/me/deletion/confirm": {
             "post": {
-                "summary": "Mark application as reviewed",
-                "description": "Mark a job application as reviewed (recruiter only)",
-                "tags": ["Job Applications"],
+                "summary": "Confirm account deletion",
+                "description": "Schedule the deletion with the emailed code. The account keeps working for a 14-day grace period and is emailed a link to cancel it. After that its subscription is cancelled, its uploads are deleted, its records, including any job postings with their applications, are erased, and what other users still rely on, such as messages, reported messages and view statistics, is anonymized.",
+                "tags": ["Users"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
-                    {
-                        "name": "id",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "description": "Application UUID"
-                    },
                     {
                         "in": "body",
                         "name": "body",
+                        "required": true,
                         "schema": {
                             "type": "object",
+                            "required": ["code"],
                             "properties": {
-                                "reason": {
-                                    "type": "string",
-                                    "description": "Optional notes"
-                                }
+                                "code": {"type": "string", "example": "123456"},
+                                "reason": {"type": "string", "description": "Why the account is being deleted, up to 1000 characters"}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Application marked as reviewed"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
-                    },
-                    "403": {
-                        "description": "Forbidden - not a recruiter"
-                    }
+                    "201": {"description": "Account deletion scheduled"},
+                    "400": {"description": "Invalid or expired code (INVALID_CODE), too many attempts, or a deletion is already scheduled (DELETION_PENDING)"},
+                    "401": {"description": "Unauthorized"}
                 }
             }
         },
-        "/api/v2/jobs/applications/{id}/hire": {
-            "post": {
-                "summary": "Hire applicant",
-                "description": "Mark applicant as hired (recruiter only)",
-                "tags": ["Job Applications"],
+        "/api/v2/user/profile": {
+            "get": {
+                "summary": "Get user profile",
+                "description": "Get authenticated user's profile (alias for /me)",
+                "tags": ["Users"],
                 "security": [{"Bearer": []}],
-                "consumes": ["application/json"],
                 "produces": ["application/json"],
-                "parameters": [
-                    {
-                        "name": "id",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "description": "Application UUID"
-                    },
-                    {
-                        "in": "body",
-                        "name": "body",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "reason": {
-                                    "type": "string",
-                                    "description": "Optional message to applicant"
-                                }
-                            }
-                        }
-                    }
-                ],
                 "responses": {
                     "200": {
-                        "description": "Applicant hired"
+                        "description": "User profile"
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "403": {
-                        "description": "Forbidden - not a recruiter"
                     }
                 }
             }
         },
-        "/api/v2/jobs/{id}/comment": {
+        "/api/v2/jobs/": {
             "post": {
-                "summary": "Add comment",
-                "description": "Add a comment to a job posting",
+                "summary": "Create job",
+                "description": "Create a new job posting",
                 "tags": ["Jobs"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
-                    {
-                        "name": "id",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "description": "Job UUID"
-                    },
                     {
                         "in": "body",
                         "name": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "required": ["content"],
+                            "required": ["title", "company", "location", "description", "employmentType"],
                             "properties": {
-                                "content": {
-                                    "type": "string",
-                                    "example": "Great opportunity!"
-                                }
+                                "title": {"type": "string", "example": "Senior Software Engineer"},
+                                "company": {"type": "string", "example": "Tech Corp"},
+                                "location": {"type": "string", "example": "San Francisco, CA"},
+                                "description": {"type": "string"},
+                                "requirements": {"type": "array", "items": {"type": "string"}},
+                                "employmentType": {"type": "string", "example": "Full-time"},
+                                "isRemote": {"type": "boolean", "example": true}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Comment added"
+                    "201": {
+                        "description": "Job created"
                     },
                     "401": {
                         "description": "Unauthorized"
                     }
                 }
             },
-            "delete": {
-                "summary": "Delete comment",
-                "description": "Delete a comment from a job posting",
+            "get": {
+                "summary": "List jobs",
+                "description": "Get all jobs with optional filters",
                 "tags": ["Jobs"],
-                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "location",
+                        "in": "query",
+                        "type": "string",
+                        "description": "Filter by location"
+                    },
+                    {
+                        "name": "isRemote",
+                        "in": "query",
+                        "type": "boolean",
+                        "description": "Filter by remote jobs"
+                    },
+                    {
+                        "name": "page",
+                        "in": "query",
+                        "type": "integer",
+                        "description": "Page number"
+                    },
+                    {
+                        "name": "size",
+                        "in": "query",
+                        "type": "integer",
+                        "description": "Items per page"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of jobs"
+                    }
+                }
+            }
+        },
+        "/api/v2/jobs/{id}": {
+            "get": {
+                "summary": "Get job",
+                "description": "Get job by ID",
+                "tags": ["Jobs"],
+                "produces": ["application/json"],
                 "parameters": [
                     {
                         "name": "id",
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Comment UUID"
+                        "description": "Job UUID"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Comment deleted"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
+                        "description": "Job details"
                     },
                     "404": {
-                        "description": "Comment not found"
+                        "description": "Job not found"
                     }
                 }
-            }
-        },
-        "/api/v2/jobs/{id}/reaction/{reaction}": {
-            "post": {
-                "summary": "Add reaction",
-                "description": "Add a reaction to a job posting",
+            },
+            "put": {
+                "summary": "Update job",
+                "description": "Update job by ID",
                 "tags": ["Jobs"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
@@ -1495,28 +2289,31 @@ const template = `{
                         "description": "Job UUID"
                     },
                     {
-                        "name": "reaction",
-                        "in": "path",
+                        "in": "body",
+                        "name": "body",
                         "required": true,
-                        "type": "string",
-                        "enum": ["LIKE", "DISLIKE"],
-                        "description": "Reaction type"
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "title": {"type": "string"},
+                                "description": {"type": "string"},
+                                "isRemote": {"type": "boolean"}
+                            }
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Reaction added"
+                        "description": "Job updated"
                     },
                     "401": {
                         "description": "Unauthorized"
                     }
                 }
-            }
-        },
-        "/api/v2/jobs/{id}/reaction": {
+            },
             "delete": {
-                "summary": "Remove reaction",
-                "description": "Remove reaction from a job posting",
+                "summary": "Delete job",
+                "description": "Delete job by ID",
                 "tags": ["Jobs"],
                 "security": [{"Bearer": []}],
                 "parameters": [
@@ -1530,46 +2327,21 @@ const template = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Reaction removed"
+                        "description": "Job deleted"
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Reaction not found"
                     }
                 }
             }
         },
-        "/api/v2/notifications": {
-            "get": {
-                "summary": "Get notifications",
-                "description": "Get all notification",
-                "tags": ["Notifications"],
-                "security": [{"Bearer": []}],
-                "produces": ["application/json"],
-                "parameters": [
-                    {
-                        "name": "page",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Page number"
-                    },
-                    {
-                        "name": "size",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Items per page"
-                    }
-                ]
-            }
-        },
-        "/api/v2/notifications/{id}": {
-            "get": {
-                "summary": "Get notification",
-                "description": "Get notification by ID",
-                "tags": ["Notifications"],
+        "/api/v2/jobs/{id}/apply": {
+            "post": {
+                "summary": "Apply to job",
+                "description": "Submit job application",
+                "tags": ["Jobs"],
                 "security": [{"Bearer": []}],
+                "consumes": ["multipart/form-data"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
@@ -1577,97 +2349,124 @@ const template = `{
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Notification UUID"
+                        "description": "Job UUID"
+                    },
+                    {
+                        "name": "resume",
+                        "in": "formData",
+                        "required": true,
+                        "type": "file",
+                        "description": "Resume file"
+                    },
+                    {
+                        "name": "coverLetter",
+                        "in": "formData",
+                        "type": "string",
+                        "description": "Cover letter text"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Notification details"
+                    "201": {
+                        "description": "Application submitted"
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Notification not found"
                     }
                 }
-            },
-            "put": {
-                "summary": "Update notification",
-                "description": "Mark notification as read",
-                "tags": ["Notifications"],
+            }
+        },
+        "/api/v2/jobs/applications/user": {
+            "get": {
+                "summary": "Get my applications",
+                "description": "Get all job applications submitted by authenticated user",
+                "tags": ["Job Applications"],
                 "security": [{"Bearer": []}],
-                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "id",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "description": "Notification UUID"
+                        "name": "page",
+                        "in": "query",
+                        "type": "integer",
+                        "description": "Page number"
                     },
                     {
-                        "in": "body",
-                        "name": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "isRead": {
-                                    "type": "boolean",
-                                    "example": true
-                                }
-                            }
-                        }
+                        "name": "limit",
+                        "in": "query",
+                        "type": "integer",
+                        "description": "Items per page"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Notification updated"
+                        "description": "List of applications"
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Notification not found"
                     }
                 }
-            },
-            "delete": {
-                "summary": "Delete notification",
-                "description": "Delete notification by ID",
-                "tags": ["Notifications"],
+            }
+        },
+        "/api/v2/jobs/applications/recruiter": {
+            "get": {
+                "summary": "Get all applications for recruiter",
+                "description": "Get all applications from all jobs posted by the authenticated recruiter",
+                "tags": ["Job Applications"],
                 "security": [{"Bearer": []}],
+                "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "id",
-                        "in": "path",
-                        "required": true,
+                        "name": "page",
+                        "in": "query",
+                        "type": "integer",
+                        "description": "Page number"
+                    },
+                    {
+                        "name": "size",
+                        "in": "query",
+                        "type": "integer",
+                        "description": "Items per page"
+                    },
+                    {
+                        "name": "status",
+                        "in": "query",
                         "type": "string",
-                        "description": "Notification UUID"
+                        "description": "Filter by application status"
+                    },
+                    {
+                        "name": "submission_date",
+                        "in": "query",
+                        "type": "string",
+                        "description": "Filter by submission date (YYYY-MM-DD)"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Notification deleted"
+                        "description": "List of all applications for recruiter's jobs"
                     },
                     "401": {
                         "description": "Unauthorized"
                     },
-                    "404": {
-                        "description": "Notification not found"
+                    "403": {
+                        "description": "Forbidden - only recruiters can access this endpoint"
                     }
                 }
             }
         },
-        "/api/v2/subscriptions": {
+        "/api/v2/jobs/applications/job/{id}": {
             "get": {
-                "summary": "List subscription tiers",
-                "description": "Get all available subscription tiers",
-                "tags": ["Subscriptions"],
+                "summary": "Get applications for job",
+                "description": "Get all applications for a specific job (recruiter only)",
+                "tags": ["Job Applications"],
+                "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Job UUID"
+                    },
                     {
                         "name": "page",
                         "in": "query",
@@ -1679,85 +2478,69 @@ const template = `{
                         "in": "query",
                         "type": "integer",
                         "description": "Items per page"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "List of subscription tiers"
-                    }
-                }
-            },
-            "post": {
-                "summary": "Create subscription tier",
-                "description": "Create a new subscription tier (admin only)",
-                "tags": ["Subscriptions"],
-                "security": [{"Bearer": []}],
-                "consumes": ["application/json"],
-                "produces": ["application/json"],
-                "parameters": [
+                    },
                     {
-                        "in": "body",
-                        "name": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "required": ["name", "type", "tier", "price", "billingCycleDays"],
-                            "properties": {
-                                "name": {"type": "string", "example": "Pro Plan"},
-                                "description": {"type": "string", "example": "Best for professionals"},
-                                "type": {"type": "string", "enum": ["monthly", "yearly", "lifetime"], "example": "monthly"},
-                                "tier": {"type": "string", "enum": ["free", "basic", "premium", "business"], "example": "premium"},
-                                "price": {"type": "number", "example": 9.99},
-                                "currency": {"type": "string", "example": "USD"},
-                                "billingCycleDays": {"type": "integer", "example": 30},
-                                "trialPeriodDays": {"type": "integer", "example": 14},
-                                "features": {"type": "object"},
-                                "sortOrder": {"type": "integer", "example": 1}
-                            }
-                        }
+                        "name": "status",
+                        "in": "query",
+                        "type": "string",
+                        "enum": ["PENDING", "REVIEWED", "ACCEPTED", "REJECTED", "HIRED"],
+                        "description": "Filter by application status"
+                    },
+                    {
+                        "name": "submission_date",
+                        "in": "query",
+                        "type": "string",
+                        "format": "date",
+                        "description": "Filter by submission date (YYYY-MM-DD)"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Subscription tier created"
-                    },
-                    "400": {
-                        "description": "Bad request"
+                    "200": {
+                        "description": "List of applications"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden - not a recruiter"
                     }
                 }
             }
         },
-        "/api/v2/subscriptions/{id}": {
+        "/api/v2/jobs/applications/{applicationId}": {
             "get": {
-                "summary": "Get subscription tier",
-                "description": "Get subscription tier by ID",
-                "tags": ["Subscriptions"],
+                "summary": "Get application",
+                "description": "Get a specific job application by ID",
+                "tags": ["Job Applications"],
+                "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "id",
+                        "name": "applicationId",
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Subscription UUID"
+                        "description": "Application UUID"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Subscription tier details"
+                        "description": "Application details"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
                     },
                     "404": {
-                        "description": "Subscription not found"
+                        "description": "Application not found"
                     }
                 }
-            },
-            "put": {
-                "summary": "Update subscription tier",
-                "description": "Update subscription tier by ID (admin only)",
-                "tags": ["Subscriptions"],
+            }
+        },
+        "/api/v2/jobs/applications/{id}/accept": {
+            "post": {
+                "summary": "Accept application",
+                "description": "Accept a job application (recruiter only)",
+                "tags": ["Job Applications"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
@@ -1767,105 +2550,128 @@ const template = `{
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Subscription UUID"
+                        "description": "Application UUID"
                     },
                     {
                         "in": "body",
                         "name": "body",
-                        "required": true,
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "name": {"type": "string"},
-                                "description": {"type": "string"},
-                                "type": {"type": "string", "enum": ["monthly", "yearly", "lifetime"]},
-                                "tier": {"type": "string", "enum": ["free", "basic", "premium", "business"]},
-                                "price": {"type": "number"},
-                                "currency": {"type": "string"},
-                                "billingCycleDays": {"type": "integer"},
-                                "trialPeriodDays": {"type": "integer"},
-                                "features": {"type": "object"},
-                                "sortOrder": {"type": "integer"}
+                                "reason": {
+                                    "type": "string",
+                                    "description": "Optional reason/message"
+                                }
                             }
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Subscription tier updated"
+                        "description": "Application accepted"
                     },
                     "401": {
                         "description": "Unauthorized"
                     },
-                    "404": {
-                        "description": "Subscription not found"
+                    "403": {
+                        "description": "Forbidden - not a recruiter"
                     }
                 }
-            },
-            "delete": {
-                "summary": "Delete subscription tier",
-                "description": "Delete subscription tier by ID (admin only)",
-                "tags": ["Subscriptions"],
+            }
+        },
+        "/api/v2/jobs/applications/{id}/reject": {
+            "post": {
+                "summary": "Reject application",
+                "description": "Reject a job application (recruiter only)",
+                "tags": ["Job Applications"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
                 "parameters": [
                     {
                         "name": "id",
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Subscription UUID"
+                        "description": "Application UUID"
+                    },
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string",
+                                    "description": "Optional rejection reason"
+                                }
+                            }
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Subscription tier deleted"
+                        "description": "Application rejected"
                     },
                     "401": {
                         "description": "Unauthorized"
                     },
-                    "404": {
-                        "description": "Subscription not found"
+                    "403": {
+                        "description": "Forbidden - not a recruiter"
                     }
                 }
             }
         },
-        "/api/v2/user/subscriptions": {
-            "get": {
-                "summary": "Get user subscriptions",
-                "description": "Get current user's subscription history",
-                "tags": ["User Subscriptions"],
+        "/api/v2/jobs/applications/{id}/review": {
+            "post": {
+                "summary": "Mark application as reviewed",
+                "description": "Mark a job application as reviewed (recruiter only)",
+                "tags": ["Job Applications"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "page",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Page number"
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Application UUID"
                     },
                     {
-                        "name": "limit",
-                        "in": "query",
-                        "type": "integer",
-                        "description": "Items per page"
+                        "in": "body",
+                        "name": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string",
+                                    "description": "Optional notes"
+                                }
+                            }
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User's subscription list"
+                        "description": "Application marked as reviewed"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden - not a recruiter"
                     }
                 }
             }
         },
-        "/api/v2/user/subscriptions/{id}": {
-            "get": {
-                "summary": "Get user subscription",
-                "description": "Get user subscription by ID",
-                "tags": ["User Subscriptions"],
+        "/api/v2/jobs/applications/{id}/hire": {
+            "post": {
+                "summary": "Hire applicant",
+                "description": "Mark applicant as hired (recruiter only)",
+                "tags": ["Job Applications"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
@@ -1873,102 +2679,130 @@ const template = `{
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "User Subscription UUID"
+                        "description": "Application UUID"
+                    },
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string",
+                                    "description": "Optional message to applicant"
+                                }
+                            }
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User subscription details"
+                        "description": "Applicant hired"
                     },
                     "401": {
                         "description": "Unauthorized"
                     },
-                    "404": {
-                        "description": "Subscription not found"
+                    "403": {
+                        "description": "Forbidden - not a recruiter"
                     }
                 }
             }
         },
-        "/api/v2/user/subscriptions/{tierId}/subscribe": {
+        "/api/v2/jobs/{id}/comment": {
             "post": {
-                "summary": "Subscribe to tier",
-                "description": "Subscribe current user to a subscription tier",
-                "tags": ["User Subscriptions"],
+                "summary": "Add comment",
+                "description": "Add a comment to a job posting",
+                "tags": ["Jobs"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "tierId",
+                        "name": "id",
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Subscription Tier UUID"
+                        "description": "Job UUID"
+                    },
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["content"],
+                            "properties": {
+                                "content": {
+                                    "type": "string",
+                                    "example": "Great opportunity!"
+                                }
+                            }
+                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Subscribed successfully"
-                    },
-                    "400": {
-                        "description": "User already has an active subscription"
+                    "200": {
+                        "description": "Comment added"
                     },
                     "401": {
                         "description": "Unauthorized"
                     }
                 }
-            }
-        },
-        "/api/v2/user/subscriptions/{tierId}/upgrade": {
-            "put": {
-                "summary": "Upgrade subscription",
-                "description": "Upgrade current user's subscription to a higher tier",
-                "tags": ["User Subscriptions"],
+            },
+            "delete": {
+                "summary": "Delete comment",
+                "description": "Delete a comment from a job posting",
+                "tags": ["Jobs"],
                 "security": [{"Bearer": []}],
-                "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "tierId",
+                        "name": "id",
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "New Subscription Tier UUID"
+                        "description": "Comment UUID"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Subscription upgraded successfully"
-                    },
-                    "400": {
-                        "description": "No active subscription found"
+                        "description": "Comment deleted"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Comment not found"
                     }
                 }
             }
         },
-        "/api/v2/user/subscriptions/{tierId}/downgrade": {
-            "put": {
-                "summary": "Downgrade subscription",
-                "description": "Downgrade current user's subscription to a lower tier",
-                "tags": ["User Subscriptions"],
+        "/api/v2/jobs/{id}/reaction/{reaction}": {
+            "post": {
+                "summary": "Add reaction",
+                "description": "Add a reaction to a job posting",
+                "tags": ["Jobs"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "tierId",
+                        "name": "id",
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "New Subscription Tier UUID"
+                        "description": "Job UUID"
+                    },
+                    {
+                        "name": "reaction",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "enum": ["LIKE", "DISLIKE"],
+                        "description": "Reaction type"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Subscription downgraded successfully"
-                    },
-                    "400": {
-                        "description": "No active subscription found"
+                        "description": "Reaction added"
                     },
                     "401": {
                         "description": "Unauthorized"
@@ -1976,197 +2810,110 @@ const template = `{
                 }
             }
         },
-        "/api/v2/user/subscriptions/unsubscribe": {
+        "/api/v2/jobs/{id}/reaction": {
             "delete": {
-                "summary": "Unsubscribe",
-                "description": "Cancel current user's active subscription",
-                "tags": ["User Subscriptions"],
-                "security": [{"Bearer": []}],
-                "produces": ["application/json"],
-                "responses": {
-                    "200": {
-                        "description": "Unsubscribed successfully"
-                    },
-                    "400": {
-                        "description": "No active subscription found"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
-                    }
-                }
-            }
-        },
-        "/api/v2/payments/initialize": {
-            "post": {
-                "summary": "Initialize payment",
-                "description": "Initialize a Paystack payment transaction for subscription",
-                "tags": ["Payments"],
+                "summary": "Remove reaction",
+                "description": "Remove reaction from a job posting",
+                "tags": ["Jobs"],
                 "security": [{"Bearer": []}],
-                "consumes": ["application/json"],
-                "produces": ["application/json"],
                 "parameters": [
                     {
-                        "in": "body",
-                        "name": "body",
+                        "name": "id",
+                        "in": "path",
                         "required": true,
-                        "schema": {
-                            "type": "object",
-                            "required": ["subscription_tier_id"],
-                            "properties": {
-                                "subscription_tier_id": {
-                                    "type": "string",
-                                    "description": "UUID of the subscription tier to purchase",
-                                    "example": "123e4567-e89b-12d3-a456-426614174000"
-                                },
-                                "callback_url": {
-                                    "type": "string",
-                                    "description": "URL to redirect after payment",
-                                    "example": "https://yoursite.com/payment/callback"
-                                }
-                            }
-                        }
+                        "type": "string",
+                        "description": "Job UUID"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Payment initialized",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "authorization_url": {
-                                    "type": "string",
-                                    "description": "URL to redirect user for payment"
-                                },
-                                "access_code": {
-                                    "type": "string"
-                                },
-                                "reference": {
-                                    "type": "string",
-                                    "description": "Transaction reference for verification"
-                                }
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request - user already has subscription or invalid tier"
+                        "description": "Reaction removed"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Reaction not found"
                     }
                 }
             }
         },
-        "/api/v2/payments/verify": {
+        "/api/v2/notifications": {
             "get": {
-                "summary": "Verify payment",
-                "description": "Verify a Paystack payment transaction and activate subscription",
-                "tags": ["Payments"],
+                "summary": "Get notifications",
+                "description": "Get all notification",
+                "tags": ["Notifications"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "reference",
+                        "name": "page",
                         "in": "query",
-                        "required": true,
-                        "type": "string",
-                        "description": "Payment reference from Paystack"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Payment verified and subscription activated"
-                    },
-                    "400": {
-                        "description": "Payment not successful or already processed"
+                        "type": "integer",
+                        "description": "Page number"
                     },
-                    "401": {
-                        "description": "Unauthorized"
+                    {
+                        "name": "size",
+                        "in": "query",
+                        "type": "integer",
+                        "description": "Items per page"
                     }
-                }
+                ]
             }
         },
-        "/api/v2/payments/cancel": {
-            "delete": {
-                "summary": "Cancel subscription",
-                "description": "Cancel the user's active Paystack subscription",
-                "tags": ["Payments"],
+        "/api/v2/notifications/{id}": {
+            "get": {
+                "summary": "Get notification",
+                "description": "Get notification by ID",
+                "tags": ["Notifications"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
-                "responses": {
-                    "200": {
-                        "description": "Subscription cancelled successfully"
-                    },
-                    "400": {
-                        "description": "No active subscription found"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
-                    }
-                }
-            }
-        },
-        "/api/v2/payments/webhook": {
-            "post": {
-                "summary": "Paystack webhook",
-                "description": "Webhook endpoint for Paystack events (charge.success, subscription.create, etc.)",
-                "tags": ["Payments"],
-                "consumes": ["application/json"],
-                "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "x-paystack-signature",
-                        "in": "header",
+                        "name": "id",
+                        "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "HMAC SHA512 signature for verification"
+                        "description": "Notification UUID"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Webhook processed successfully"
-                    },
-                    "400": {
-                        "description": "Invalid request body"
-                    },
-                    "401": {
-                        "description": "Invalid signature"
-                    }
-                }
-            }
-        },
-        "/api/v2/payments/methods": {
-            "get": {
-                "summary": "Get payment methods",
-                "description": "Get all saved payment methods for the authenticated user",
-                "tags": ["Payment Methods"],
-                "security": [{"Bearer": []}],
-                "produces": ["application/json"],
-                "responses": {
-                    "200": {
-                        "description": "List of payment methods"
+                        "description": "Notification details"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Notification not found"
                     }
                 }
             },
-            "post": {
-                "summary": "Add payment method",
-                "description": "Initialize adding a new payment method (card)",
-                "tags": ["Payment Methods"],
+            "put": {
+                "summary": "Update notification",
+                "description": "Mark notification as read",
+                "tags": ["Notifications"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Notification UUID"
+                    },
                     {
                         "in": "body",
                         "name": "body",
+                        "required": true,
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "callback_url": {
-                                    "type": "string",
-                                    "description": "URL to redirect after card validation"
+                                "isRead": {
+                                    "type": "boolean",
+                                    "example": true
                                 }
                             }
                         }
@@ -2174,48 +2921,48 @@ const template = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Redirect URL for card validation"
+                        "description": "Notification updated"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Notification not found"
                     }
                 }
-            }
-        },
-        "/api/v2/payments/methods/{authCode}": {
+            },
             "delete": {
-                "summary": "Remove payment method",
-                "description": "Remove a saved payment method",
-                "tags": ["Payment Methods"],
+                "summary": "Delete notification",
+                "description": "Delete notification by ID",
+                "tags": ["Notifications"],
                 "security": [{"Bearer": []}],
                 "parameters": [
                     {
-                        "name": "authCode",
+                        "name": "id",
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Authorization code of the payment method"
+                        "description": "Notification UUID"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Payment method removed"
+                        "description": "Notification deleted"
                     },
                     "401": {
                         "description": "Unauthorized"
                     },
-                    "400": {
-                        "description": "Bad request"
+                    "404": {
+                        "description": "Notification not found"
                     }
                 }
             }
         },
-        "/api/v2/payments/invoices": {
+        "/api/v2/subscriptions": {
             "get": {
-                "summary": "Get invoices",
-                "description": "Get all invoices for the authenticated user",
-                "tags": ["Invoices"],
-                "security": [{"Bearer": []}],
+                "summary": "List subscription tiers",
+                "description": "Get all available subscription tiers",
+                "tags": ["Subscriptions"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
@@ -2233,227 +2980,272 @@ const template = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of invoices"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
+                        "description": "List of subscription tiers"
                     }
                 }
-            }
-        },
-        "/api/v2/payments/invoices/{id}": {
-            "get": {
-                "summary": "Get invoice",
-                "description": "Get invoice by ID",
-                "tags": ["Invoices"],
+            },
+            "post": {
+                "summary": "Create subscription tier",
+                "description": "Create a new subscription tier (requires the subscriptions:manage permission)",
+                "tags": ["Subscriptions"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "id",
-                        "in": "path",
+                        "in": "body",
+                        "name": "body",
                         "required": true,
-                        "type": "string",
-                        "description": "Invoice UUID"
+                        "schema": {
+                            "type": "object",
+                            "required": ["name", "type", "tier", "price", "billingCycleDays"],
+                            "properties": {
+                                "name": {"type": "string", "example": "Pro Plan"},
+                                "description": {"type": "string", "example": "Best for professionals"},
+                                "type": {"type": "string", "enum": ["monthly", "yearly", "lifetime"], "example": "monthly"},
+                                "tier": {"type": "string", "enum": ["free", "basic", "premium", "business"], "example": "premium"},
+                                "price": {"type": "number", "example": 9.99},
+                                "currency": {"type": "string", "example": "USD"},
+                                "billingCycleDays": {"type": "integer", "example": 30},
+                                "trialPeriodDays": {"type": "integer", "example": 14},
+                                "features": {"type": "object"},
+                                "sortOrder": {"type": "integer", "example": 1}
+                            }
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Invoice details"
-                    },
-                    "401": {
-                        "description": "Unauthorized"
+                    "201": {
+                        "description": "Subscription tier created"
                     },
-                    "404": {
-                        "description": "Invoice not found"
-                    }
-                }
-            }
-        },
-        "/api/v2/domain": {
-            "get": {
-                "summary": "Get domain configuration",
-                "description": "Get the authenticated user's domain configuration including subdomain and custom domain",
-                "tags": ["Domain"],
-                "security": [{"Bearer": []}],
-                "produces": ["application/json"],
-                "responses": {
-                    "200": {
-                        "description": "Domain configuration retrieved"
+                    "400": {
+                        "description": "Bad request"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Admin access required"
                     }
                 }
             }
         },
-        "/api/v2/domain/check/{subdomain}": {
+        "/api/v2/subscriptions/{id}": {
             "get": {
-                "summary": "Check subdomain availability",
-                "description": "Check if a subdomain is available for use",
-                "tags": ["Domain"],
+                "summary": "Get subscription tier",
+                "description": "Get subscription tier by ID",
+                "tags": ["Subscriptions"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "subdomain",
+                        "name": "id",
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Subdomain to check"
+                        "description": "Subscription UUID"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Availability status"
+                        "description": "Subscription tier details"
+                    },
+                    "404": {
+                        "description": "Subscription not found"
                     }
                 }
-            }
-        },
-        "/api/v2/domain/subdomain": {
-            "post": {
-                "summary": "Claim subdomain",
-                "description": "Claim a subdomain for your profile (e.g., username.foglio.app)",
-                "tags": ["Domain"],
+            },
+            "put": {
+                "summary": "Update subscription tier",
+                "description": "Update subscription tier by ID (requires the subscriptions:manage permission)",
+                "tags": ["Subscriptions"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Subscription UUID"
+                    },
                     {
                         "in": "body",
                         "name": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "required": ["subdomain"],
                             "properties": {
-                                "subdomain": {
-                                    "type": "string",
-                                    "example": "johndoe",
-                                    "description": "Subdomain (3-32 characters, alphanumeric)"
-                                }
+                                "name": {"type": "string"},
+                                "description": {"type": "string"},
+                                "type": {"type": "string", "enum": ["monthly", "yearly", "lifetime"]},
+                                "tier": {"type": "string", "enum": ["free", "basic", "premium", "business"]},
+                                "price": {"type": "number"},
+                                "currency": {"type": "string"},
+                                "billingCycleDays": {"type": "integer"},
+                                "trialPeriodDays": {"type": "integer"},
+                                "features": {"type": "object"},
+                                "sortOrder": {"type": "integer"}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Subdomain claimed successfully"
-                    },
-                    "400": {
-                        "description": "Subdomain taken, invalid, or reserved"
+                    "200": {
+                        "description": "Subscription tier updated"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Subscription not found"
+                    },
+                    "403": {
+                        "description": "Admin access required"
                     }
                 }
             },
-            "put": {
-                "summary": "Update subdomain",
-                "description": "Update your subdomain",
-                "tags": ["Domain"],
+            "delete": {
+                "summary": "Delete subscription tier",
+                "description": "Delete subscription tier by ID (requires the subscriptions:manage permission)",
+                "tags": ["Subscriptions"],
                 "security": [{"Bearer": []}],
-                "consumes": ["application/json"],
-                "produces": ["application/json"],
                 "parameters": [
                     {
-                        "in": "body",
-                        "name": "body",
+                        "name": "id",
+                        "in": "path",
                         "required": true,
-                        "schema": {
-                            "type": "object",
-                            "required": ["subdomain"],
-                            "properties": {
-                                "subdomain": {
-                                    "type": "string",
-                                    "example": "newsubdomain"
-                                }
-                            }
-                        }
+                        "type": "string",
+                        "description": "Subscription UUID"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Subdomain updated successfully"
-                    },
-                    "400": {
-                        "description": "Subdomain taken or invalid"
+                        "description": "Subscription tier deleted"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Subscription not found"
+                    },
+                    "403": {
+                        "description": "Admin access required"
                     }
                 }
             }
         },
-        "/api/v2/domain/custom": {
-            "post": {
-                "summary": "Set custom domain",
-                "description": "Set a custom domain for your profile (paid users only)",
-                "tags": ["Domain"],
+        "/api/v2/user/subscriptions": {
+            "get": {
+                "summary": "Get user subscriptions",
+                "description": "Get current user's subscription history",
+                "tags": ["User Subscriptions"],
                 "security": [{"Bearer": []}],
-                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "in": "body",
-                        "name": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "required": ["custom_domain"],
-                            "properties": {
-                                "custom_domain": {
-                                    "type": "string",
-                                    "example": "portfolio.example.com",
-                                    "description": "Your custom domain"
-                                }
-                            }
-                        }
+                        "name": "page",
+                        "in": "query",
+                        "type": "integer",
+                        "description": "Page number"
+                    },
+                    {
+                        "name": "limit",
+                        "in": "query",
+                        "type": "integer",
+                        "description": "Items per page"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Custom domain configured, DNS records provided for verification"
+                    "200": {
+                        "description": "User's subscription list"
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "403": {
-                        "description": "Custom domains require a paid subscription"
                     }
                 }
-            },
-            "delete": {
-                "summary": "Remove custom domain",
-                "description": "Remove your custom domain",
-                "tags": ["Domain"],
+            }
+        },
+        "/api/v2/user/subscriptions/{id}": {
+            "get": {
+                "summary": "Get user subscription",
+                "description": "Get user subscription by ID",
+                "tags": ["User Subscriptions"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "id",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "User Subscription UUID"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "Custom domain removed"
-                    },
-                    "400": {
-                        "description": "No custom domain configured"
+                        "description": "User subscription details"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Subscription not found"
                     }
                 }
             }
         },
-        "/api/v2/domain/custom/verify": {
+        "/api/v2/user/subscriptions/{tierId}/subscribe": {
             "post": {
-                "summary": "Verify custom domain",
-                "description": "Verify DNS records for custom domain",
-                "tags": ["Domain"],
+                "summary": "Subscribe to tier",
+                "description": "Subscribe current user to a subscription tier",
+                "tags": ["User Subscriptions"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "tierId",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Subscription Tier UUID"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Subscribed successfully"
+                    },
+                    "400": {
+                        "description": "User already has an active subscription"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    }
+                }
+            }
+        },
+        "/api/v2/user/subscriptions/{tierId}/upgrade": {
+            "put": {
+                "summary": "Upgrade subscription",
+                "description": "Upgrade current user's subscription to a higher tier",
+                "tags": ["User Subscriptions"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "tierId",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "New Subscription Tier UUID"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "Verification status returned"
+                        "description": "Subscription upgraded successfully"
                     },
                     "400": {
-                        "description": "No custom domain configured"
+                        "description": "No active subscription found"
                     },
                     "401": {
                         "description": "Unauthorized"
@@ -2461,29 +3253,60 @@ const template = `{
                 }
             }
         },
-        "/api/v2/portfolio": {
-            "get": {
-                "summary": "Get my portfolio",
-                "description": "Get the authenticated user's portfolio",
-                "tags": ["Portfolio"],
+        "/api/v2/user/subscriptions/{tierId}/downgrade": {
+            "put": {
+                "summary": "Downgrade subscription",
+                "description": "Downgrade current user's subscription to a lower tier",
+                "tags": ["User Subscriptions"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "tierId",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "New Subscription Tier UUID"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "Portfolio retrieved successfully"
+                        "description": "Subscription downgraded successfully"
+                    },
+                    "400": {
+                        "description": "No active subscription found"
                     },
                     "401": {
                         "description": "Unauthorized"
+                    }
+                }
+            }
+        },
+        "/api/v2/user/subscriptions/unsubscribe": {
+            "delete": {
+                "summary": "Unsubscribe",
+                "description": "Cancel current user's active subscription",
+                "tags": ["User Subscriptions"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Unsubscribed successfully"
                     },
-                    "404": {
-                        "description": "Portfolio not found"
+                    "400": {
+                        "description": "No active subscription found"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
                     }
                 }
-            },
+            }
+        },
+        "/api/v2/payments/initialize": {
             "post": {
-                "summary": "Create portfolio",
-                "description": "Create a new portfolio for the authenticated user",
-                "tags": ["Portfolio"],
+                "summary": "Initialize payment",
+                "description": "Initialize a Paystack payment transaction for subscription",
+                "tags": ["Payments"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
@@ -2494,192 +3317,149 @@ const template = `{
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "required": ["title", "slug"],
+                            "required": ["subscription_tier_id"],
                             "properties": {
-                                "title": {
+                                "subscription_tier_id": {
                                     "type": "string",
-                                    "example": "John Doe's Portfolio"
+                                    "description": "UUID of the subscription tier to purchase",
+                                    "example": "123e4567-e89b-12d3-a456-426614174000"
                                 },
-                                "slug": {
+                                "callback_url": {
                                     "type": "string",
-                                    "example": "john-doe",
-                                    "description": "URL-friendly identifier (3-50 chars, lowercase, alphanumeric with hyphens)"
-                                },
-                                "tagline": {
+                                    "description": "URL to redirect after payment",
+                                    "example": "https://yoursite.com/payment/callback"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Payment initialized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "authorization_url": {
                                     "type": "string",
-                                    "example": "Full-stack Developer"
+                                    "description": "URL to redirect user for payment"
                                 },
-                                "bio": {
-                                    "type": "string",
-                                    "example": "Passionate developer with 5 years of experience..."
+                                "access_code": {
+                                    "type": "string"
                                 },
-                                "template": {
+                                "reference": {
                                     "type": "string",
-                                    "example": "default",
-                                    "description": "Portfolio template"
-                                },
-                                "theme": {
-                                    "type": "object",
-                                    "properties": {
-                                        "primary_color": {"type": "string", "example": "#3B82F6"},
-                                        "secondary_color": {"type": "string", "example": "#1E40AF"},
-                                        "accent_color": {"type": "string", "example": "#F59E0B"},
-                                        "text_color": {"type": "string", "example": "#1F2937"},
-                                        "background_color": {"type": "string", "example": "#FFFFFF"},
-                                        "font_family": {"type": "string", "example": "Inter"},
-                                        "font_size": {"type": "string", "example": "16px"}
-                                    }
-                                },
-                                "seo": {
-                                    "type": "object",
-                                    "properties": {
-                                        "meta_title": {"type": "string"},
-                                        "meta_description": {"type": "string"},
-                                        "meta_keywords": {"type": "string"},
-                                        "og_image": {"type": "string"}
-                                    }
-                                },
-                                "settings": {
-                                    "type": "object",
-                                    "properties": {
-                                        "show_projects": {"type": "boolean", "default": true},
-                                        "show_experiences": {"type": "boolean", "default": true},
-                                        "show_education": {"type": "boolean", "default": true},
-                                        "show_skills": {"type": "boolean", "default": true},
-                                        "show_certifications": {"type": "boolean", "default": true},
-                                        "show_contact": {"type": "boolean", "default": true},
-                                        "show_social_links": {"type": "boolean", "default": true},
-                                        "enable_analytics": {"type": "boolean", "default": false},
-                                        "enable_comments": {"type": "boolean", "default": false}
-                                    }
+                                    "description": "Transaction reference for verification"
                                 }
                             }
                         }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Portfolio created successfully"
                     },
                     "400": {
-                        "description": "Portfolio already exists or invalid data"
+                        "description": "Bad request - user already has subscription or invalid tier"
                     },
                     "401": {
                         "description": "Unauthorized"
                     }
                 }
-            },
-            "put": {
-                "summary": "Update portfolio",
-                "description": "Update the authenticated user's portfolio",
-                "tags": ["Portfolio"],
+            }
+        },
+        "/api/v2/payments/verify": {
+            "get": {
+                "summary": "Verify payment",
+                "description": "Verify a Paystack payment transaction and activate subscription",
+                "tags": ["Payments"],
                 "security": [{"Bearer": []}],
-                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "in": "body",
-                        "name": "body",
+                        "name": "reference",
+                        "in": "query",
                         "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "title": {"type": "string"},
-                                "slug": {"type": "string"},
-                                "tagline": {"type": "string"},
-                                "bio": {"type": "string"},
-                                "cover_image": {"type": "string"},
-                                "logo": {"type": "string"},
-                                "template": {"type": "string"},
-                                "theme": {"type": "object"},
-                                "custom_css": {"type": "string"},
-                                "status": {"type": "string", "enum": ["draft", "published", "archived"]},
-                                "is_public": {"type": "boolean"},
-                                "seo": {"type": "object"},
-                                "settings": {"type": "object"}
-                            }
-                        }
+                        "type": "string",
+                        "description": "Payment reference from Paystack"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Portfolio updated successfully"
+                        "description": "Payment verified and subscription activated"
                     },
                     "400": {
-                        "description": "Invalid data"
+                        "description": "Payment not successful or already processed"
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Portfolio not found"
                     }
                 }
-            },
+            }
+        },
+        "/api/v2/payments/cancel": {
             "delete": {
-                "summary": "Delete portfolio",
-                "description": "Delete the authenticated user's portfolio",
-                "tags": ["Portfolio"],
+                "summary": "Cancel subscription",
+                "description": "Cancel the user's active Paystack subscription",
+                "tags": ["Payments"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "responses": {
                     "200": {
-                        "description": "Portfolio deleted successfully"
+                        "description": "Subscription cancelled successfully"
+                    },
+                    "400": {
+                        "description": "No active subscription found"
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Portfolio not found"
                     }
                 }
             }
         },
-        "/api/v2/portfolio/publish": {
+        "/api/v2/payments/webhook": {
             "post": {
-                "summary": "Publish portfolio",
-                "description": "Publish the portfolio to make it publicly accessible",
-                "tags": ["Portfolio"],
-                "security": [{"Bearer": []}],
+                "summary": "Paystack webhook",
+                "description": "Webhook endpoint for Paystack events (charge.success, subscription.create, etc.)",
+                "tags": ["Payments"],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "x-paystack-signature",
+                        "in": "header",
+                        "required": true,
+                        "type": "string",
+                        "description": "HMAC SHA512 signature for verification"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "Portfolio published successfully"
+                        "description": "Webhook processed successfully"
                     },
-                    "401": {
-                        "description": "Unauthorized"
+                    "400": {
+                        "description": "Invalid request body"
                     },
-                    "404": {
-                        "description": "Portfolio not found"
+                    "401": {
+                        "description": "Invalid signature"
                     }
                 }
             }
         },
-        "/api/v2/portfolio/unpublish": {
-            "post": {
-                "summary": "Unpublish portfolio",
-                "description": "Unpublish the portfolio (set to draft)",
-                "tags": ["Portfolio"],
+        "/api/v2/payments/methods": {
+            "get": {
+                "summary": "Get payment methods",
+                "description": "Get all saved payment methods for the authenticated user",
+                "tags": ["Payment Methods"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "responses": {
                     "200": {
-                        "description": "Portfolio unpublished successfully"
+                        "description": "List of payment methods"
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Portfolio not found"
                     }
                 }
-            }
-        },
-        "/api/v2/portfolio/sections": {
+            },
             "post": {
-                "summary": "Create portfolio section",
-                "description": "Create a new section in the portfolio",
-                "tags": ["Portfolio Sections"],
+                "summary": "Add payment method",
+                "description": "Initialize adding a new payment method (card)",
+                "tags": ["Payment Methods"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
@@ -2687,232 +3467,160 @@ const template = `{
                     {
                         "in": "body",
                         "name": "body",
-                        "required": true,
                         "schema": {
                             "type": "object",
-                            "required": ["title", "type"],
                             "properties": {
-                                "title": {
-                                    "type": "string",
-                                    "example": "About Me"
-                                },
-                                "type": {
-                                    "type": "string",
-                                    "enum": ["hero", "about", "projects", "experience", "skills", "contact", "custom"],
-                                    "example": "about"
-                                },
-                                "content": {
-                                    "type": "string",
-                                    "description": "Section content (HTML or markdown)"
-                                },
-                                "settings": {
+                                "callback_url": {
                                     "type": "string",
-                                    "description": "JSON string for section-specific settings"
-                                },
-                                "sort_order": {
-                                    "type": "integer",
-                                    "example": 1
-                                },
-                                "is_visible": {
-                                    "type": "boolean",
-                                    "default": true
+                                    "description": "URL to redirect after card validation"
                                 }
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Section created successfully"
-                    },
-                    "400": {
-                        "description": "Invalid data"
+                    "200": {
+                        "description": "Redirect URL for card validation"
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Portfolio not found"
                     }
                 }
             }
         },
-        "/api/v2/portfolio/sections/{sectionId}": {
-            "put": {
-                "summary": "Update portfolio section",
-                "description": "Update a section in the portfolio",
-                "tags": ["Portfolio Sections"],
+        "/api/v2/payments/methods/{authCode}": {
+            "delete": {
+                "summary": "Remove payment method",
+                "description": "Remove a saved payment method",
+                "tags": ["Payment Methods"],
                 "security": [{"Bearer": []}],
-                "consumes": ["application/json"],
-                "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "sectionId",
+                        "name": "authCode",
                         "in": "path",
                         "required": true,
                         "type": "string",
-                        "description": "Section UUID"
-                    },
-                    {
-                        "in": "body",
-                        "name": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "title": {"type": "string"},
-                                "type": {"type": "string"},
-                                "content": {"type": "string"},
-                                "settings": {"type": "string"},
-                                "sort_order": {"type": "integer"},
-                                "is_visible": {"type": "boolean"}
-                            }
-                        }
+                        "description": "Authorization code of the payment method"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Section updated successfully"
-                    },
-                    "400": {
-                        "description": "Invalid data"
+                        "description": "Payment method removed"
                     },
                     "401": {
                         "description": "Unauthorized"
                     },
-                    "404": {
-                        "description": "Section not found"
+                    "400": {
+                        "description": "Bad request"
                     }
                 }
-            },
-            "delete": {
-                "summary": "Delete portfolio section",
-                "description": "Delete a section from the portfolio",
-                "tags": ["Portfolio Sections"],
+            }
+        },
+        "/api/v2/payments/invoices": {
+            "get": {
+                "summary": "Get invoices",
+                "description": "Get all invoices for the authenticated user",
+                "tags": ["Invoices"],
                 "security": [{"Bearer": []}],
+                "produces": ["application/json"],
                 "parameters": [
                     {
-                        "name": "sectionId",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "description": "Section UUID"
+                        "name": "page",
+                        "in": "query",
+                        "type": "integer",
+                        "description": "Page number"
+                    },
+                    {
+                        "name": "limit",
+                        "in": "query",
+                        "type": "integer",
+                        "description": "Items per page"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Section deleted successfully"
+                        "description": "List of invoices"
                     },
                     "401": {
                         "description": "Unauthorized"
-                    },
-                    "404": {
-                        "description": "Section not found"
                     }
                 }
             }
         },
-        "/api/v2/portfolio/sections/reorder": {
-            "post": {
-                "summary": "Reorder portfolio sections",
-                "description": "Reorder sections by providing an array of section IDs in desired order",
-                "tags": ["Portfolio Sections"],
+        "/api/v2/payments/invoices/{id}": {
+            "get": {
+                "summary": "Get invoice",
+                "description": "Get invoice by ID",
+                "tags": ["Invoices"],
                 "security": [{"Bearer": []}],
-                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "in": "body",
-                        "name": "body",
+                        "name": "id",
+                        "in": "path",
                         "required": true,
-                        "schema": {
-                            "type": "object",
-                            "required": ["section_ids"],
-                            "properties": {
-                                "section_ids": {
-                                    "type": "array",
-                                    "items": {"type": "string"},
-                                    "description": "Array of section UUIDs in desired order"
-                                }
-                            }
-                        }
+                        "type": "string",
+                        "description": "Invoice UUID"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sections reordered successfully"
-                    },
-                    "400": {
-                        "description": "Invalid data"
+                        "description": "Invoice details"
                     },
                     "401": {
                         "description": "Unauthorized"
                     },
                     "404": {
-                        "description": "Portfolio not found"
+                        "description": "Invoice not found"
                     }
                 }
             }
         },
-        "/api/v2/portfolios/{slug}": {
+        "/api/v2/domain": {
             "get": {
-                "summary": "Get public portfolio",
-                "description": "Get a published portfolio by its slug (public access)",
-                "tags": ["Portfolio"],
+                "summary": "Get domain configuration",
+                "description": "Get the authenticated user's domain configuration including subdomain, custom domain and the custom domain's TLS certificate status",
+                "tags": ["Domain"],
+                "security": [{"Bearer": []}],
                 "produces": ["application/json"],
-                "parameters": [
-                    {
-                        "name": "slug",
-                        "in": "path",
-                        "required": true,
-                        "type": "string",
-                        "description": "Portfolio slug"
-                    }
-                ],
                 "responses": {
                     "200": {
-                        "description": "Portfolio retrieved successfully"
+                        "description": "Domain configuration retrieved"
                     },
-                    "404": {
-                        "description": "Portfolio not found or not published"
+                    "401": {
+                        "description": "Unauthorized"
                     }
                 }
             }
         },
-        "/api/v2/analytics/track/page-view": {
-            "post": {
-                "summary": "Track page view",
-                "description": "Track a page view event",
-                "tags": ["Analytics - Tracking"],
-                "consumes": ["application/json"],
+        "/api/v2/domain/check/{subdomain}": {
+            "get": {
+                "summary": "Check subdomain availability",
+                "description": "Check if a subdomain is available for use",
+                "tags": ["Domain"],
                 "produces": ["application/json"],
                 "parameters": [
                     {
-                        "in": "body",
-                        "name": "body",
+                        "name": "subdomain",
+                        "in": "path",
                         "required": true,
-                        "schema": {
-                            "type": "object",
-                            "required": ["path", "session_id"],
-                            "properties": {
-                                "path": {"type": "string", "example": "/jobs"},
-                                "session_id": {"type": "string", "example": "sess_abc123"},
-                                "referrer": {"type": "string"},
-                                "duration": {"type": "integer", "description": "Time spent in seconds"}
-                            }
-                        }
+                        "type": "string",
+                        "description": "Subdomain to check"
                     }
                 ],
                 "responses": {
-                    "200": {"description": "Page view tracked"}
+                    "200": {
+                        "description": "Availability status"
+                    }
                 }
             }
         },
-        "/api/v2/analytics/track/job-view": {
+        "/api/v2/domain/subdomain": {
             "post": {
-                "summary": "Track job view",
-                "description": "Track when a job listing is viewed",
-                "tags": ["Analytics - Tracking"],
+                "summary": "Claim subdomain",
+                "description": "Claim a subdomain for your profile (e.g., username.foglio.app)",
+                "tags": ["Domain"],
+                "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
@@ -2922,396 +3630,2445 @@ const template = `{
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "required": ["job_id", "session_id"],
+                            "required": ["subdomain"],
                             "properties": {
-                                "job_id": {"type": "string", "format": "uuid"},
-                                "session_id": {"type": "string"},
-                                "referrer": {"type": "string"}
+                                "subdomain": {
+                                    "type": "string",
+                                    "example": "johndoe",
+                                    "description": "Subdomain (3-32 characters, alphanumeric)"
+                                }
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "200": {"description": "Job view tracked"}
-                }
+                    "201": {
+                        "description": "Subdomain claimed successfully"
+                    },
+                    "400": {
+                        "description": "Subdomain taken, invalid, or reserved"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    }
+                }
+            },
+            "put": {
+                "summary": "Update subdomain",
+                "description": "Update your subdomain",
+                "tags": ["Domain"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["subdomain"],
+                            "properties": {
+                                "subdomain": {
+                                    "type": "string",
+                                    "example": "newsubdomain"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subdomain updated successfully"
+                    },
+                    "400": {
+                        "description": "Subdomain taken or invalid"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    }
+                }
+            }
+        },
+        "/api/v2/domain/custom": {
+            "post": {
+                "summary": "Set custom domain",
+                "description": "Set a custom domain for your profile (paid users only)",
+                "tags": ["Domain"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["custom_domain"],
+                            "properties": {
+                                "custom_domain": {
+                                    "type": "string",
+                                    "example": "portfolio.example.com",
+                                    "description": "Your custom domain"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Custom domain configured, DNS records provided for verification"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Custom domains require a paid subscription"
+                    }
+                }
+            },
+            "delete": {
+                "summary": "Remove custom domain",
+                "description": "Remove your custom domain",
+                "tags": ["Domain"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Custom domain removed"
+                    },
+                    "400": {
+                        "description": "No custom domain configured"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    }
+                }
+            }
+        },
+        "/api/v2/domain/custom/verify": {
+            "post": {
+                "summary": "Verify custom domain",
+                "description": "Verify DNS records for custom domain. Once verified, a TLS certificate is obtained for it in the background; its progress is returned as certificate.",
+                "tags": ["Domain"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Verification status returned"
+                    },
+                    "400": {
+                        "description": "No custom domain configured"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    }
+                }
+            }
+        },
+        "/api/v2/portfolio": {
+            "get": {
+                "summary": "Get my portfolio",
+                "description": "Get the authenticated user's portfolio",
+                "tags": ["Portfolio"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Portfolio retrieved successfully"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Portfolio not found"
+                    }
+                }
+            },
+            "post": {
+                "summary": "Create portfolio",
+                "description": "Create a new portfolio for the authenticated user",
+                "tags": ["Portfolio"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["title", "slug"],
+                            "properties": {
+                                "title": {
+                                    "type": "string",
+                                    "example": "John Doe's Portfolio"
+                                },
+                                "slug": {
+                                    "type": "string",
+                                    "example": "john-doe",
+                                    "description": "URL-friendly identifier (3-50 chars, lowercase, alphanumeric with hyphens)"
+                                },
+                                "tagline": {
+                                    "type": "string",
+                                    "example": "Full-stack Developer"
+                                },
+                                "bio": {
+                                    "type": "string",
+                                    "example": "Passionate developer with 5 years of experience..."
+                                },
+                                "template": {
+                                    "type": "string",
+                                    "enum": ["default", "minimal", "modern"],
+                                    "example": "default",
+                                    "description": "Site template the portfolio is rendered with at /p/{slug}"
+                                },
+                                "theme": {
+                                    "type": "object",
+                                    "properties": {
+                                        "primary_color": {"type": "string", "example": "#3B82F6"},
+                                        "secondary_color": {"type": "string", "example": "#1E40AF"},
+                                        "accent_color": {"type": "string", "example": "#F59E0B"},
+                                        "text_color": {"type": "string", "example": "#1F2937"},
+                                        "background_color": {"type": "string", "example": "#FFFFFF"},
+                                        "font_family": {"type": "string", "example": "Inter"},
+                                        "font_size": {"type": "string", "example": "16px"}
+                                    }
+                                },
+                                "seo": {
+                                    "type": "object",
+                                    "properties": {
+                                        "meta_title": {"type": "string"},
+                                        "meta_description": {"type": "string"},
+                                        "meta_keywords": {"type": "string"},
+                                        "og_image": {"type": "string"}
+                                    }
+                                },
+                                "settings": {
+                                    "type": "object",
+                                    "properties": {
+                                        "show_projects": {"type": "boolean", "default": true},
+                                        "show_experiences": {"type": "boolean", "default": true},
+                                        "show_education": {"type": "boolean", "default": true},
+                                        "show_skills": {"type": "boolean", "default": true},
+                                        "show_certifications": {"type": "boolean", "default": true},
+                                        "show_contact": {"type": "boolean", "default": true},
+                                        "show_social_links": {"type": "boolean", "default": true},
+                                        "enable_analytics": {"type": "boolean", "default": false},
+                                        "enable_comments": {"type": "boolean", "default": false}
+                                    }
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Portfolio created successfully"
+                    },
+                    "400": {
+                        "description": "Portfolio already exists, slug taken or invalid, unknown template (TEMPLATE_INVALID) or invalid data"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    }
+                }
+            },
+            "put": {
+                "summary": "Update portfolio",
+                "description": "Update the authenticated user's portfolio",
+                "tags": ["Portfolio"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "title": {"type": "string"},
+                                "slug": {"type": "string"},
+                                "tagline": {"type": "string"},
+                                "bio": {"type": "string"},
+                                "cover_image": {"type": "string"},
+                                "logo": {"type": "string"},
+                                "template": {"type": "string", "enum": ["default", "minimal", "modern"]},
+                                "theme": {"type": "object"},
+                                "custom_css": {"type": "string"},
+                                "status": {"type": "string", "enum": ["draft", "published", "archived"]},
+                                "is_public": {"type": "boolean"},
+                                "seo": {"type": "object"},
+                                "settings": {"type": "object"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Portfolio updated successfully"
+                    },
+                    "400": {
+                        "description": "Invalid data, slug taken or invalid, or unknown template (TEMPLATE_INVALID)"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Portfolio not found"
+                    }
+                }
+            },
+            "delete": {
+                "summary": "Delete portfolio",
+                "description": "Delete the authenticated user's portfolio",
+                "tags": ["Portfolio"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Portfolio deleted successfully"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Portfolio not found"
+                    }
+                }
+            }
+        },
+        "/api/v2/portfolio/publish": {
+            "post": {
+                "summary": "Publish portfolio",
+                "description": "Publish the portfolio to make it publicly accessible",
+                "tags": ["Portfolio"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Portfolio published successfully"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Portfolio not found"
+                    }
+                }
+            }
+        },
+        "/api/v2/portfolio/unpublish": {
+            "post": {
+                "summary": "Unpublish portfolio",
+                "description": "Unpublish the portfolio (set to draft)",
+                "tags": ["Portfolio"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Portfolio unpublished successfully"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Portfolio not found"
+                    }
+                }
+            }
+        },
+        "/api/v2/portfolio/sections": {
+            "post": {
+                "summary": "Create portfolio section",
+                "description": "Create a new section in the portfolio",
+                "tags": ["Portfolio Sections"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["title", "type"],
+                            "properties": {
+                                "title": {
+                                    "type": "string",
+                                    "example": "About Me"
+                                },
+                                "type": {
+                                    "type": "string",
+                                    "enum": ["hero", "about", "projects", "experience", "skills", "contact", "custom"],
+                                    "example": "about"
+                                },
+                                "content": {
+                                    "type": "string",
+                                    "description": "Section content (HTML or markdown)"
+                                },
+                                "settings": {
+                                    "type": "string",
+                                    "description": "JSON string for section-specific settings"
+                                },
+                                "sort_order": {
+                                    "type": "integer",
+                                    "example": 1
+                                },
+                                "is_visible": {
+                                    "type": "boolean",
+                                    "default": true
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Section created successfully"
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Portfolio not found"
+                    }
+                }
+            }
+        },
+        "/api/v2/portfolio/sections/{sectionId}": {
+            "put": {
+                "summary": "Update portfolio section",
+                "description": "Update a section in the portfolio",
+                "tags": ["Portfolio Sections"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "sectionId",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Section UUID"
+                    },
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "title": {"type": "string"},
+                                "type": {"type": "string"},
+                                "content": {"type": "string"},
+                                "settings": {"type": "string"},
+                                "sort_order": {"type": "integer"},
+                                "is_visible": {"type": "boolean"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Section updated successfully"
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Section not found"
+                    }
+                }
+            },
+            "delete": {
+                "summary": "Delete portfolio section",
+                "description": "Delete a section from the portfolio",
+                "tags": ["Portfolio Sections"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {
+                        "name": "sectionId",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Section UUID"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Section deleted successfully"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Section not found"
+                    }
+                }
+            }
+        },
+        "/api/v2/portfolio/sections/reorder": {
+            "post": {
+                "summary": "Reorder portfolio sections",
+                "description": "Reorder sections by providing an array of section IDs in desired order",
+                "tags": ["Portfolio Sections"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["section_ids"],
+                            "properties": {
+                                "section_ids": {
+                                    "type": "array",
+                                    "items": {"type": "string"},
+                                    "description": "Array of section UUIDs in desired order"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sections reordered successfully"
+                    },
+                    "400": {
+                        "description": "Invalid data"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Portfolio not found"
+                    }
+                }
+            }
+        },
+        "/api/v2/portfolios/templates": {
+            "get": {
+                "summary": "List portfolio templates",
+                "description": "List the built-in site templates a portfolio can be rendered with",
+                "tags": ["Portfolio"],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Template names, e.g. [\"default\", \"minimal\", \"modern\"]"}
+                }
+            }
+        },
+        "/api/v2/portfolios/{slug}": {
+            "get": {
+                "summary": "Get public portfolio",
+                "description": "Get a published portfolio by its slug (public access)",
+                "tags": ["Portfolio"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "slug",
+                        "in": "path",
+                        "required": true,
+                        "type": "string",
+                        "description": "Portfolio slug"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Portfolio retrieved successfully"
+                    },
+                    "404": {
+                        "description": "Portfolio not found or not published"
+                    }
+                }
+            }
+        },
+        "/p/{slug}": {
+            "get": {
+                "summary": "Portfolio site",
+                "description": "Serve a published portfolio as a complete HTML page, rendered with its template, theme colors and fonts, and custom CSS. Sections appear in the portfolio's order, or About, Projects, Experience, Education, Skills, Certifications, Languages and Contact when it has none, following its show_* settings. The page carries SEO meta, OpenGraph and Twitter card tags and schema.org Person JSON-LD. Served outside /api/v2 and cached for 5 minutes.",
+                "tags": ["Portfolio"],
+                "produces": ["text/html"],
+                "parameters": [
+                    {"name": "slug", "in": "path", "required": true, "type": "string", "description": "Portfolio slug"}
+                ],
+                "responses": {
+                    "200": {"description": "Portfolio page"},
+                    "404": {"description": "Branded page for portfolios that do not exist or are not published"}
+                }
+            }
+        },
+        "/.well-known/acme-challenge/{token}": {
+            "get": {
+                "summary": "ACME HTTP-01 challenge",
+                "description": "Answer the certificate authority's HTTP-01 validation request while a certificate is being obtained for a custom domain. Served on every host, outside /api/v2, and only for the domain the challenge was issued for.",
+                "tags": ["Domain"],
+                "produces": ["text/plain"],
+                "parameters": [
+                    {"name": "token", "in": "path", "required": true, "type": "string", "description": "Challenge token"}
+                ],
+                "responses": {
+                    "200": {"description": "Key authorization"},
+                    "404": {"description": "No pending challenge for this token and host"}
+                }
+            }
+        },
+        "/api/v2/analytics/track/page-view": {
+            "post": {
+                "summary": "Track page view",
+                "description": "Track a page view event",
+                "tags": ["Analytics - Tracking"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["path", "session_id"],
+                            "properties": {
+                                "path": {"type": "string", "example": "/jobs"},
+                                "session_id": {"type": "string", "example": "sess_abc123"},
+                                "referrer": {"type": "string"},
+                                "duration": {"type": "integer", "description": "Time spent in seconds"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Page view tracked"}
+                }
+            }
+        },
+        "/api/v2/analytics/track/job-view": {
+            "post": {
+                "summary": "Track job view",
+                "description": "Track when a job listing is viewed",
+                "tags": ["Analytics - Tracking"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["job_id", "session_id"],
+                            "properties": {
+                                "job_id": {"type": "string", "format": "uuid"},
+                                "session_id": {"type": "string"},
+                                "referrer": {"type": "string"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Job view tracked"}
+                }
+            }
+        },
+        "/api/v2/analytics/track/profile-view": {
+            "post": {
+                "summary": "Track profile view",
+                "description": "Track when a user profile is viewed",
+                "tags": ["Analytics - Tracking"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["profile_user_id", "session_id"],
+                            "properties": {
+                                "profile_user_id": {"type": "string", "format": "uuid"},
+                                "session_id": {"type": "string"},
+                                "referrer": {"type": "string"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Profile view tracked"}
+                }
+            }
+        },
+        "/api/v2/analytics/track/portfolio-view": {
+            "post": {
+                "summary": "Track portfolio view",
+                "description": "Track when a portfolio is viewed",
+                "tags": ["Analytics - Tracking"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["portfolio_id", "session_id"],
+                            "properties": {
+                                "portfolio_id": {"type": "string", "format": "uuid"},
+                                "session_id": {"type": "string"},
+                                "referrer": {"type": "string"},
+                                "duration": {"type": "integer"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Portfolio view tracked"}
+                }
+            }
+        },
+        "/api/v2/analytics/track/event": {
+            "post": {
+                "summary": "Track custom event",
+                "description": "Track a custom analytics event",
+                "tags": ["Analytics - Tracking"],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["event_type", "session_id"],
+                            "properties": {
+                                "event_type": {"type": "string", "example": "button_click"},
+                                "entity_id": {"type": "string", "format": "uuid"},
+                                "entity_type": {"type": "string", "example": "job"},
+                                "session_id": {"type": "string"},
+                                "properties": {"type": "object"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Event tracked"}
+                }
+            }
+        },
+        "/api/v2/analytics/admin/dashboard": {
+            "get": {
+                "summary": "Admin analytics dashboard",
+                "description": "Get comprehensive platform analytics (requires the analytics:read permission)",
+                "tags": ["Analytics - Admin"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "start_date", "in": "query", "type": "string", "format": "date", "description": "Start date (YYYY-MM-DD)"},
+                    {"name": "end_date", "in": "query", "type": "string", "format": "date", "description": "End date (YYYY-MM-DD)"},
+                    {"name": "group_by", "in": "query", "type": "string", "enum": ["day", "week", "month"], "description": "Group trend data by"}
+                ],
+                "responses": {
+                    "200": {"description": "Admin dashboard analytics"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            }
+        },
+        "/api/v2/analytics/admin/overview": {
+            "get": {
+                "summary": "Platform overview",
+                "description": "Get platform overview statistics (requires the analytics:read permission)",
+                "tags": ["Analytics - Admin"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Platform overview stats"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            }
+        },
+        "/api/v2/analytics/admin/users": {
+            "get": {
+                "summary": "User analytics",
+                "description": "Get user statistics (requires the analytics:read permission)",
+                "tags": ["Analytics - Admin"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                ],
+                "responses": {
+                    "200": {"description": "User analytics"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            }
+        },
+        "/api/v2/analytics/admin/jobs": {
+            "get": {
+                "summary": "Job analytics",
+                "description": "Get job statistics (requires the analytics:read permission)",
+                "tags": ["Analytics - Admin"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                ],
+                "responses": {
+                    "200": {"description": "Job analytics"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            }
+        },
+        "/api/v2/analytics/admin/applications": {
+            "get": {
+                "summary": "Application analytics",
+                "description": "Get application statistics (requires the analytics:read permission)",
+                "tags": ["Analytics - Admin"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                ],
+                "responses": {
+                    "200": {"description": "Application analytics"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            }
+        },
+        "/api/v2/analytics/admin/revenue": {
+            "get": {
+                "summary": "Revenue analytics",
+                "description": "Get revenue statistics (requires the analytics:read permission)",
+                "tags": ["Analytics - Admin"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                ],
+                "responses": {
+                    "200": {"description": "Revenue analytics"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            }
+        },
+        "/api/v2/analytics/recruiter/dashboard": {
+            "get": {
+                "summary": "Recruiter analytics dashboard",
+                "description": "Get analytics for recruiter's jobs and applications (recruiter only)",
+                "tags": ["Analytics - Recruiter"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "end_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "group_by", "in": "query", "type": "string", "enum": ["day", "week", "month"]}
+                ],
+                "responses": {
+                    "200": {"description": "Recruiter dashboard analytics"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Recruiter access required"}
+                }
+            }
+        },
+        "/api/v2/analytics/recruiter/jobs": {
+            "get": {
+                "summary": "Recruiter job performance",
+                "description": "Get performance metrics for recruiter's jobs",
+                "tags": ["Analytics - Recruiter"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                ],
+                "responses": {
+                    "200": {"description": "Job performance metrics"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Recruiter access required"}
+                }
+            }
+        },
+        "/api/v2/analytics/recruiter/applications": {
+            "get": {
+                "summary": "Recruiter application stats",
+                "description": "Get application statistics for recruiter's jobs",
+                "tags": ["Analytics - Recruiter"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Application statistics"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Recruiter access required"}
+                }
+            }
+        },
+        "/api/v2/analytics/talent/dashboard": {
+            "get": {
+                "summary": "Talent analytics dashboard",
+                "description": "Get analytics for talent's profile, portfolio, and applications",
+                "tags": ["Analytics - Talent"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "end_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "group_by", "in": "query", "type": "string", "enum": ["day", "week", "month"]}
+                ],
+                "responses": {
+                    "200": {"description": "Talent dashboard analytics"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/analytics/talent/profile-views": {
+            "get": {
+                "summary": "Profile views analytics",
+                "description": "Get profile views statistics for the authenticated user",
+                "tags": ["Analytics - Talent"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                ],
+                "responses": {
+                    "200": {"description": "Profile views analytics"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/analytics/talent/portfolio": {
+            "get": {
+                "summary": "Portfolio analytics",
+                "description": "Get portfolio views and engagement statistics",
+                "tags": ["Analytics - Talent"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                ],
+                "responses": {
+                    "200": {"description": "Portfolio analytics"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/analytics/talent/applications": {
+            "get": {
+                "summary": "Talent application stats",
+                "description": "Get statistics for user's job applications",
+                "tags": ["Analytics - Talent"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {"description": "Application statistics"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/analytics/talent/viewer-insights": {
+            "get": {
+                "summary": "Viewer insights",
+                "description": "Get insights about who viewed your profile (recruiters vs talents)",
+                "tags": ["Analytics - Talent"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
+                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                ],
+                "responses": {
+                    "200": {"description": "Viewer insights"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/notification-settings": {
+            "get": {
+                "summary": "Get notification settings",
+                "description": "Get current user's notification preferences",
+                "tags": ["Notification Settings"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Notification settings retrieved",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "id": {"type": "string", "format": "uuid"},
+                                "user_id": {"type": "string", "format": "uuid"},
+                                "email": {
+                                    "type": "object",
+                                    "properties": {
+                                        "app_updates": {"type": "boolean"},
+                                        "new_messages": {"type": "boolean"},
+                                        "job_recommendations": {"type": "boolean"},
+                                        "newsletter": {"type": "boolean"},
+                                        "marketing_emails": {"type": "boolean"}
+                                    }
+                                },
+                                "push": {
+                                    "type": "object",
+                                    "properties": {
+                                        "app_updates": {"type": "boolean"},
+                                        "new_messages": {"type": "boolean"},
+                                        "reminders": {"type": "boolean"}
+                                    }
+                                },
+                                "in_app": {
+                                    "type": "object",
+                                    "properties": {
+                                        "activity_updates": {"type": "boolean"},
+                                        "mentions": {"type": "boolean"},
+                                        "announcements": {"type": "boolean"}
+                                    }
+                                }
+                            }
+                        }
+                    },
+                    "401": {"description": "Unauthorized"}
+                }
+            },
+            "put": {
+                "summary": "Update all notification settings",
+                "description": "Update all notification preferences at once",
+                "tags": ["Notification Settings"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "email": {
+                                    "type": "object",
+                                    "properties": {
+                                        "app_updates": {"type": "boolean"},
+                                        "new_messages": {"type": "boolean"},
+                                        "job_recommendations": {"type": "boolean"},
+                                        "newsletter": {"type": "boolean"},
+                                        "marketing_emails": {"type": "boolean"}
+                                    }
+                                },
+                                "push": {
+                                    "type": "object",
+                                    "properties": {
+                                        "app_updates": {"type": "boolean"},
+                                        "new_messages": {"type": "boolean"},
+                                        "reminders": {"type": "boolean"}
+                                    }
+                                },
+                                "in_app": {
+                                    "type": "object",
+                                    "properties": {
+                                        "activity_updates": {"type": "boolean"},
+                                        "mentions": {"type": "boolean"},
+                                        "announcements": {"type": "boolean"}
+                                    }
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Settings updated successfully"},
+                    "400": {"description": "Invalid data"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/notification-settings/email": {
+            "put": {
+                "summary": "Update email notification settings",
+                "description": "Update email notification preferences only",
+                "tags": ["Notification Settings"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "app_updates": {"type": "boolean"},
+                                "new_messages": {"type": "boolean"},
+                                "job_recommendations": {"type": "boolean"},
+                                "newsletter": {"type": "boolean"},
+                                "marketing_emails": {"type": "boolean"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Email settings updated"},
+                    "400": {"description": "Invalid data"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/notification-settings/push": {
+            "put": {
+                "summary": "Update push notification settings",
+                "description": "Update push notification preferences only",
+                "tags": ["Notification Settings"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "app_updates": {"type": "boolean"},
+                                "new_messages": {"type": "boolean"},
+                                "reminders": {"type": "boolean"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Push settings updated"},
+                    "400": {"description": "Invalid data"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/notification-settings/in-app": {
+            "put": {
+                "summary": "Update in-app notification settings",
+                "description": "Update in-app notification preferences only",
+                "tags": ["Notification Settings"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "activity_updates": {"type": "boolean"},
+                                "mentions": {"type": "boolean"},
+                                "announcements": {"type": "boolean"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "In-app settings updated"},
+                    "400": {"description": "Invalid data"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/announcements": {
+            "get": {
+                "summary": "Get announcements",
+                "description": "Get announcements for the current user based on their role",
+                "tags": ["Announcements"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "page", "in": "query", "type": "integer", "default": 1},
+                    {"name": "limit", "in": "query", "type": "integer", "default": 10}
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Announcements retrieved",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "data": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "object",
+                                        "properties": {
+                                            "id": {"type": "string", "format": "uuid"},
+                                            "title": {"type": "string"},
+                                            "content": {"type": "string"},
+                                            "type": {"type": "string", "enum": ["ANNOUNCEMENT", "APP_UPDATE", "SYSTEM_ALERT", "MAINTENANCE"]},
+                                            "priority": {"type": "string", "enum": ["LOW", "NORMAL", "HIGH", "CRITICAL"]},
+                                            "show_as_banner": {"type": "boolean"},
+                                            "action_url": {"type": "string"},
+                                            "action_text": {"type": "string"},
+                                            "is_read": {"type": "boolean"},
+                                            "is_dismissed": {"type": "boolean"},
+                                            "published_at": {"type": "string", "format": "date-time"}
+                                        }
+                                    }
+                                },
+                                "total_items": {"type": "integer"},
+                                "total_pages": {"type": "integer"},
+                                "page": {"type": "integer"},
+                                "limit": {"type": "integer"}
+                            }
+                        }
+                    },
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/announcements/banners": {
+            "get": {
+                "summary": "Get active banners",
+                "description": "Get active announcement banners for display",
+                "tags": ["Announcements"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Active banners retrieved",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "properties": {
+                                    "id": {"type": "string", "format": "uuid"},
+                                    "title": {"type": "string"},
+                                    "content": {"type": "string"},
+                                    "type": {"type": "string"},
+                                    "priority": {"type": "string"},
+                                    "banner_color": {"type": "string"},
+                                    "action_url": {"type": "string"},
+                                    "action_text": {"type": "string"}
+                                }
+                            }
+                        }
+                    },
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/announcements/{id}/read": {
+            "put": {
+                "summary": "Mark announcement as read",
+                "description": "Mark an announcement as read for the current user",
+                "tags": ["Announcements"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Announcement marked as read"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Announcement not found"}
+                }
+            }
+        },
+        "/api/v2/announcements/{id}/dismiss": {
+            "put": {
+                "summary": "Dismiss announcement",
+                "description": "Dismiss an announcement for the current user",
+                "tags": ["Announcements"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Announcement dismissed"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Announcement not found"}
+                }
+            }
+        },
+        "/api/v2/admin/announcements": {
+            "get": {
+                "summary": "List all announcements (Admin)",
+                "description": "Get all announcements with filtering (requires the announcements:write permission)",
+                "tags": ["Announcements - Admin"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "page", "in": "query", "type": "integer", "default": 1},
+                    {"name": "limit", "in": "query", "type": "integer", "default": 10},
+                    {"name": "type", "in": "query", "type": "string", "enum": ["ANNOUNCEMENT", "APP_UPDATE", "SYSTEM_ALERT", "MAINTENANCE"]},
+                    {"name": "is_published", "in": "query", "type": "boolean"}
+                ],
+                "responses": {
+                    "200": {"description": "Announcements retrieved"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            },
+            "post": {
+                "summary": "Create announcement (Admin)",
+                "description": "Create a new announcement (requires the announcements:write permission)",
+                "tags": ["Announcements - Admin"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["title", "content", "type"],
+                            "properties": {
+                                "title": {"type": "string", "example": "New Feature Released"},
+                                "content": {"type": "string", "example": "We have released a new messaging feature!"},
+                                "type": {"type": "string", "enum": ["ANNOUNCEMENT", "APP_UPDATE", "SYSTEM_ALERT", "MAINTENANCE"]},
+                                "target_audience": {"type": "string", "enum": ["ALL_USERS", "ADMINS_ONLY", "RECRUITERS_ONLY", "PREMIUM_ONLY"], "default": "ALL_USERS"},
+                                "priority": {"type": "string", "enum": ["LOW", "NORMAL", "HIGH", "CRITICAL"], "default": "NORMAL"},
+                                "show_as_banner": {"type": "boolean", "default": false},
+                                "banner_color": {"type": "string", "example": "#3B82F6"},
+                                "action_url": {"type": "string", "example": "https://example.com/feature"},
+                                "action_text": {"type": "string", "example": "Learn More"},
+                                "scheduled_at": {"type": "string", "format": "date-time"},
+                                "expires_at": {"type": "string", "format": "date-time"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {"description": "Announcement created"},
+                    "400": {"description": "Invalid data"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            }
+        },
+        "/api/v2/admin/announcements/{id}": {
+            "get": {
+                "summary": "Get announcement (Admin)",
+                "description": "Get a single announcement by ID (requires the announcements:write permission)",
+                "tags": ["Announcements - Admin"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Announcement retrieved"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "Announcement not found"}
+                }
+            },
+            "put": {
+                "summary": "Update announcement (Admin)",
+                "description": "Update an announcement (requires the announcements:write permission)",
+                "tags": ["Announcements - Admin"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"},
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "title": {"type": "string"},
+                                "content": {"type": "string"},
+                                "type": {"type": "string", "enum": ["ANNOUNCEMENT", "APP_UPDATE", "SYSTEM_ALERT", "MAINTENANCE"]},
+                                "target_audience": {"type": "string", "enum": ["ALL_USERS", "ADMINS_ONLY", "RECRUITERS_ONLY", "PREMIUM_ONLY"]},
+                                "priority": {"type": "string", "enum": ["LOW", "NORMAL", "HIGH", "CRITICAL"]},
+                                "show_as_banner": {"type": "boolean"},
+                                "banner_color": {"type": "string"},
+                                "action_url": {"type": "string"},
+                                "action_text": {"type": "string"},
+                                "scheduled_at": {"type": "string", "format": "date-time"},
+                                "expires_at": {"type": "string", "format": "date-time"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Announcement updated"},
+                    "400": {"description": "Invalid data"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "Announcement not found"}
+                }
+            },
+            "delete": {
+                "summary": "Delete announcement (Admin)",
+                "description": "Delete an announcement (requires the announcements:write permission)",
+                "tags": ["Announcements - Admin"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Announcement deleted"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "Announcement not found"}
+                }
+            }
+        },
+        "/api/v2/admin/announcements/{id}/publish": {
+            "put": {
+                "summary": "Publish announcement (Admin)",
+                "description": "Publish an announcement (requires the announcements:write permission)",
+                "tags": ["Announcements - Admin"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Announcement published"},
+                    "400": {"description": "Announcement already published"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "Announcement not found"}
+                }
+            }
+        },
+        "/api/v2/admin/announcements/{id}/unpublish": {
+            "put": {
+                "summary": "Unpublish announcement (Admin)",
+                "description": "Unpublish an announcement (requires the announcements:write permission)",
+                "tags": ["Announcements - Admin"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Announcement unpublished"},
+                    "400": {"description": "Announcement not published"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "Announcement not found"}
+                }
+            }
+        },
+        "/api/v2/chat/conversations": {
+            "get": {
+                "summary": "Get conversations",
+                "description": "Get all conversations for the current user",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "page", "in": "query", "type": "integer", "default": 1},
+                    {"name": "limit", "in": "query", "type": "integer", "default": 20}
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conversations retrieved",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "data": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "object",
+                                        "properties": {
+                                            "id": {"type": "string", "format": "uuid"},
+                                            "other_user": {
+                                                "type": "object",
+                                                "properties": {
+                                                    "id": {"type": "string", "format": "uuid"},
+                                                    "name": {"type": "string"},
+                                                    "username": {"type": "string"},
+                                                    "image": {"type": "string"}
+                                                }
+                                            },
+                                            "last_message": {
+                                                "type": "object",
+                                                "properties": {
+                                                    "id": {"type": "string", "format": "uuid"},
+                                                    "content": {"type": "string"},
+                                                    "sender_id": {"type": "string", "format": "uuid"},
+                                                    "created_at": {"type": "string", "format": "date-time"}
+                                                }
+                                            },
+                                            "unread_count": {"type": "integer"},
+                                            "created_at": {"type": "string", "format": "date-time"},
+                                            "updated_at": {"type": "string", "format": "date-time"}
+                                        }
+                                    }
+                                },
+                                "total_items": {"type": "integer"},
+                                "total_pages": {"type": "integer"},
+                                "page": {"type": "integer"},
+                                "limit": {"type": "integer"}
+                            }
+                        }
+                    },
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/chat/conversations/{id}": {
+            "get": {
+                "summary": "Get conversation",
+                "description": "Get a single conversation by ID",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Conversation retrieved"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Conversation not found"}
+                }
+            },
+            "put": {
+                "summary": "Rename group conversation",
+                "description": "Rename a group conversation (owners only)",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"},
+                    {
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["name"],
+                            "properties": {
+                                "name": {"type": "string", "maxLength": 100}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Conversation renamed"},
+                    "400": {"description": "Not a group conversation"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant or not an owner"},
+                    "404": {"description": "Conversation not found"}
+                }
+            },
+            "delete": {
+                "summary": "Delete conversation",
+                "description": "Delete a conversation. Group conversations can only be deleted by an owner",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Conversation deleted"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Conversation not found"}
+                }
+            }
+        },
+        "/api/v2/chat/conversations/user/{userId}": {
+            "get": {
+                "summary": "Get or create conversation",
+                "description": "Get an existing conversation with a user or create a new one",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "userId", "in": "path", "required": true, "type": "string", "description": "Other user's UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Conversation retrieved or created"},
+                    "400": {"description": "Cannot message self"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Blocked or chat access suspended"},
+                    "404": {"description": "User not found"}
+                }
+            }
+        },
+        "/api/v2/chat/conversations/group": {
+            "post": {
+                "summary": "Create group conversation",
+                "description": "Create a named group conversation. The creator becomes its owner",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["name", "participant_ids"],
+                            "properties": {
+                                "name": {"type": "string", "maxLength": 100},
+                                "participant_ids": {"type": "array", "items": {"type": "string", "format": "uuid"}, "maxItems": 49}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {"description": "Group conversation created"},
+                    "400": {"description": "Invalid request body or participant limit exceeded"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "User not found"}
+                }
+            }
+        },
+        "/api/v2/chat/conversations/{id}/participants": {
+            "post": {
+                "summary": "Add participants",
+                "description": "Add members to a group conversation (owners only)",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"},
+                    {
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["user_ids"],
+                            "properties": {
+                                "user_ids": {"type": "array", "items": {"type": "string", "format": "uuid"}}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Participants added"},
+                    "400": {"description": "Not a group, already a participant or participant limit exceeded"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant or not an owner"},
+                    "404": {"description": "Conversation or user not found"}
+                }
+            }
+        },
+        "/api/v2/chat/conversations/{id}/participants/{userId}": {
+            "delete": {
+                "summary": "Remove participant",
+                "description": "Remove a member from a group conversation. Owners can remove anyone; members can only remove themselves to leave the group",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"},
+                    {"name": "userId", "in": "path", "required": true, "type": "string", "description": "User UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Participant removed"},
+                    "400": {"description": "Not a group conversation"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant or not an owner"},
+                    "404": {"description": "Conversation or participant not found"}
+                }
+            }
+        },
+        "/api/v2/chat/messages": {
+            "post": {
+                "summary": "Send message",
+                "description": "Send a direct message to another user, or a message to an existing conversation (including groups) by conversation_id. Can include text content, media attachments, or both. Messages can also be sent via WebSocket using the action 'send_message'.",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "recipient_id": {"type": "string", "format": "uuid", "description": "Recipient's user UUID (required when conversation_id is not set)"},
+                                "conversation_id": {"type": "string", "format": "uuid", "description": "Conversation UUID to post into"},
+                                "reply_to_id": {"type": "string", "format": "uuid", "description": "Message UUID being replied to (must be in the same conversation)"},
+                                "content": {"type": "string", "description": "Message content (optional if media is provided)", "example": "Hello, how are you?"},
+                                "media": {
+                                    "type": "array",
+                                    "description": "Media attachments (max 10)",
+                                    "maxItems": 10,
+                                    "items": {
+                                        "type": "object",
+                                        "required": ["type"],
+                                        "properties": {
+                                            "id": {"type": "string", "description": "ID of an attachment uploaded via /chat/attachments; its stored metadata is used"},
+                                            "type": {"type": "string", "enum": ["IMAGE", "VIDEO", "AUDIO", "DOCUMENT", "FILE"], "description": "Media type"},
+                                            "url": {"type": "string", "format": "url", "description": "URL of externally hosted media (required without an attachment id)"},
+                                            "file_name": {"type": "string", "description": "Original file name"},
+                                            "file_size": {"type": "integer", "description": "File size in bytes"},
+                                            "mime_type": {"type": "string", "description": "MIME type", "example": "image/jpeg"},
+                                            "width": {"type": "integer", "description": "Width in pixels (for images/videos)"},
+                                            "height": {"type": "integer", "description": "Height in pixels (for images/videos)"},
+                                            "duration": {"type": "integer", "description": "Duration in seconds (for audio/video)"},
+                                            "thumbnail": {"type": "string", "description": "Thumbnail URL (for videos)"}
+                                        }
+                                    }
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Message sent",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "id": {"type": "string", "format": "uuid"},
+                                "conversation_id": {"type": "string", "format": "uuid"},
+                                "sender_id": {"type": "string", "format": "uuid"},
+                                "recipient_id": {"type": "string", "format": "uuid"},
+                                "content": {"type": "string"},
+                                "media": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "object",
+                                        "properties": {
+                                            "id": {"type": "string"},
+                                            "type": {"type": "string"},
+                                            "url": {"type": "string"},
+                                            "file_name": {"type": "string"},
+                                            "file_size": {"type": "integer"},
+                                            "mime_type": {"type": "string"},
+                                            "width": {"type": "integer"},
+                                            "height": {"type": "integer"},
+                                            "duration": {"type": "integer"},
+                                            "thumbnail": {"type": "string"}
+                                        }
+                                    }
+                                },
+                                "status": {"type": "string", "enum": ["SENT", "READ"], "description": "READ once every other participant has read the message"},
+                                "read_by": {"type": "array", "items": {"type": "string", "format": "uuid"}},
+                                "created_at": {"type": "string", "format": "date-time"}
+                            }
+                        }
+                    },
+                    "400": {"description": "Cannot message self, message must have content or media, recipient_id or conversation_id is required"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant, blocked, or chat access suspended"},
+                    "404": {"description": "Recipient or conversation not found"}
+                }
+            }
+        },
+        "/api/v2/chat/messages/{messageId}": {
+            "put": {
+                "summary": "Edit message",
+                "description": "Edit the content of your own message within 15 minutes of sending. The previous content is kept in the message's edit history",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "parameters": [
+                    {"name": "messageId", "in": "path", "required": true, "type": "string", "description": "Message UUID"},
+                    {
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["content"],
+                            "properties": {
+                                "content": {"type": "string", "maxLength": 5000}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Message edited"},
+                    "400": {"description": "Edit window expired or message deleted"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant or not the sender"},
+                    "404": {"description": "Message not found"}
+                }
+            },
+            "delete": {
+                "summary": "Delete message",
+                "description": "Delete a message for yourself, or for every participant when scope is 'everyone' (sender only)",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "messageId", "in": "path", "required": true, "type": "string", "description": "Message UUID"},
+                    {"name": "scope", "in": "query", "type": "string", "enum": ["me", "everyone"], "description": "Deletion scope (default: me)"}
+                ],
+                "responses": {
+                    "200": {"description": "Message deleted"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant or not the sender"},
+                    "404": {"description": "Message not found"}
+                }
+            }
+        },
+        "/api/v2/chat/messages/{messageId}/edits": {
+            "get": {
+                "summary": "Get message edit history",
+                "description": "Get the previous versions of an edited message, oldest first",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "messageId", "in": "path", "required": true, "type": "string", "description": "Message UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Message edits retrieved"},
+                    "400": {"description": "Message deleted"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Message not found"}
+                }
+            }
+        },
+        "/api/v2/chat/messages/{messageId}/reactions": {
+            "post": {
+                "summary": "Add reaction",
+                "description": "React to a message with an emoji",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "parameters": [
+                    {"name": "messageId", "in": "path", "required": true, "type": "string", "description": "Message UUID"},
+                    {
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["emoji"],
+                            "properties": {
+                                "emoji": {"type": "string", "maxLength": 32, "example": "👍"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Reaction added; returns the message's reactions grouped by emoji"},
+                    "400": {"description": "Message deleted"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Message not found"}
+                }
+            }
+        },
+        "/api/v2/chat/messages/{messageId}/reactions/{emoji}": {
+            "delete": {
+                "summary": "Remove reaction",
+                "description": "Remove your emoji reaction from a message",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "messageId", "in": "path", "required": true, "type": "string", "description": "Message UUID"},
+                    {"name": "emoji", "in": "path", "required": true, "type": "string", "description": "URL-encoded emoji"}
+                ],
+                "responses": {
+                    "200": {"description": "Reaction removed"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Message or reaction not found"}
+                }
+            }
+        },
+        "/api/v2/chat/conversations/{id}/messages": {
+            "get": {
+                "summary": "Get messages",
+                "description": "Get messages in a conversation, newest first. Uses page offsets unless a cursor is given: before/after page relative to a message, and around returns a window of messages centred on one (e.g. to jump to a search result)",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"},
+                    {"name": "page", "in": "query", "type": "integer", "default": 1},
+                    {"name": "limit", "in": "query", "type": "integer", "default": 50, "maximum": 100},
+                    {"name": "before", "in": "query", "type": "string", "description": "Message UUID; return messages older than it"},
+                    {"name": "after", "in": "query", "type": "string", "description": "Message UUID; return messages newer than it"},
+                    {"name": "around", "in": "query", "type": "string", "description": "Message UUID; return it with the messages on either side"}
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Messages retrieved",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "data": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "object",
+                                        "properties": {
+                                            "id": {"type": "string", "format": "uuid"},
+                                            "conversation_id": {"type": "string", "format": "uuid"},
+                                            "sender_id": {"type": "string", "format": "uuid"},
+                                            "recipient_id": {"type": "string", "format": "uuid"},
+                                            "content": {"type": "string"},
+                                            "media": {
+                                                "type": "array",
+                                                "items": {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "id": {"type": "string"},
+                                                        "type": {"type": "string", "enum": ["IMAGE", "VIDEO", "AUDIO", "DOCUMENT", "FILE"]},
+                                                        "url": {"type": "string"},
+                                                        "file_name": {"type": "string"},
+                                                        "file_size": {"type": "integer"},
+                                                        "mime_type": {"type": "string"},
+                                                        "width": {"type": "integer"},
+                                                        "height": {"type": "integer"},
+                                                        "duration": {"type": "integer"},
+                                                        "thumbnail": {"type": "string"}
+                                                    }
+                                                }
+                                            },
+                                            "status": {"type": "string", "enum": ["SENT", "DELIVERED", "READ"]},
+                                            "read_at": {"type": "string", "format": "date-time"},
+                                            "created_at": {"type": "string", "format": "date-time"},
+                                            "sender": {
+                                                "type": "object",
+                                                "properties": {
+                                                    "id": {"type": "string", "format": "uuid"},
+                                                    "name": {"type": "string"},
+                                                    "username": {"type": "string"},
+                                                    "image": {"type": "string"}
+                                                }
+                                            }
+                                        }
+                                    }
+                                },
+                                "total_items": {"type": "integer"},
+                                "total_pages": {"type": "integer"},
+                                "page": {"type": "integer"},
+                                "limit": {"type": "integer"},
+                                "has_older": {"type": "boolean"},
+                                "has_newer": {"type": "boolean"},
+                                "older_cursor": {"type": "string", "description": "Pass as before to load older messages"},
+                                "newer_cursor": {"type": "string", "description": "Pass as after to load newer messages"}
+                            }
+                        }
+                    },
+                    "400": {"description": "Invalid query parameters"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Conversation or cursor message not found"}
+                }
             }
         },
-        "/api/v2/analytics/track/profile-view": {
-            "post": {
-                "summary": "Track profile view",
-                "description": "Track when a user profile is viewed",
-                "tags": ["Analytics - Tracking"],
-                "consumes": ["application/json"],
-                "produces": ["application/json"],
+        "/api/v2/chat/conversations/{id}/read": {
+            "put": {
+                "summary": "Mark messages as read",
+                "description": "Mark all messages in a conversation as read",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
                 "parameters": [
-                    {
-                        "in": "body",
-                        "name": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "required": ["profile_user_id", "session_id"],
-                            "properties": {
-                                "profile_user_id": {"type": "string", "format": "uuid"},
-                                "session_id": {"type": "string"},
-                                "referrer": {"type": "string"}
-                            }
-                        }
-                    }
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"}
                 ],
                 "responses": {
-                    "200": {"description": "Profile view tracked"}
+                    "200": {"description": "Messages marked as read"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Conversation not found"}
                 }
             }
         },
-        "/api/v2/analytics/track/portfolio-view": {
+        "/api/v2/chat/attachments": {
             "post": {
-                "summary": "Track portfolio view",
-                "description": "Track when a portfolio is viewed",
-                "tags": ["Analytics - Tracking"],
-                "consumes": ["application/json"],
-                "produces": ["application/json"],
+                "summary": "Upload chat attachment",
+                "description": "Upload a file into a conversation. Files are validated per media type (IMAGE 10MB, VIDEO 50MB, AUDIO 20MB, DOCUMENT 20MB, FILE 25MB). Images get their dimensions extracted and a thumbnail generated. Send the returned media's id in a message's media list to attach it. Uploads that no message uses within 24 hours are deleted",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "consumes": ["multipart/form-data"],
                 "parameters": [
-                    {
-                        "in": "body",
-                        "name": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "required": ["portfolio_id", "session_id"],
-                            "properties": {
-                                "portfolio_id": {"type": "string", "format": "uuid"},
-                                "session_id": {"type": "string"},
-                                "referrer": {"type": "string"},
-                                "duration": {"type": "integer"}
-                            }
-                        }
-                    }
+                    {"name": "file", "in": "formData", "required": true, "type": "file", "description": "File to upload"},
+                    {"name": "conversation_id", "in": "formData", "required": true, "type": "string", "description": "Conversation UUID"},
+                    {"name": "type", "in": "formData", "type": "string", "enum": ["IMAGE", "VIDEO", "AUDIO", "DOCUMENT", "FILE"], "description": "Media type (default: FILE)"}
                 ],
                 "responses": {
-                    "200": {"description": "Portfolio view tracked"}
+                    "201": {"description": "Attachment uploaded; returns the populated media with signed links"},
+                    "400": {"description": "Missing file, file too large or unsupported type"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Conversation not found"}
                 }
             }
         },
-        "/api/v2/analytics/track/event": {
-            "post": {
-                "summary": "Track custom event",
-                "description": "Track a custom analytics event",
-                "tags": ["Analytics - Tracking"],
-                "consumes": ["application/json"],
-                "produces": ["application/json"],
+        "/api/v2/chat/attachments/{attachmentId}": {
+            "get": {
+                "summary": "Get chat attachment",
+                "description": "Download an attachment. Only participants of the attachment's conversation can fetch it. Images, audio and video are served inline; every other type is sent as a download",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
                 "parameters": [
-                    {
-                        "in": "body",
-                        "name": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "required": ["event_type", "session_id"],
-                            "properties": {
-                                "event_type": {"type": "string", "example": "button_click"},
-                                "entity_id": {"type": "string", "format": "uuid"},
-                                "entity_type": {"type": "string", "example": "job"},
-                                "session_id": {"type": "string"},
-                                "properties": {"type": "object"}
-                            }
-                        }
-                    }
+                    {"name": "attachmentId", "in": "path", "required": true, "type": "string", "description": "Attachment UUID"}
                 ],
                 "responses": {
-                    "200": {"description": "Event tracked"}
+                    "200": {"description": "Attachment content"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Attachment not found"}
                 }
             }
         },
-        "/api/v2/analytics/admin/dashboard": {
+        "/api/v2/chat/attachments/{attachmentId}/thumbnail": {
             "get": {
-                "summary": "Admin analytics dashboard",
-                "description": "Get comprehensive platform analytics (admin only)",
-                "tags": ["Analytics - Admin"],
+                "summary": "Get chat attachment thumbnail",
+                "description": "Download the JPEG thumbnail of an image attachment. Only participants of the attachment's conversation can fetch it",
+                "tags": ["Chat"],
                 "security": [{"Bearer": []}],
-                "produces": ["application/json"],
                 "parameters": [
-                    {"name": "start_date", "in": "query", "type": "string", "format": "date", "description": "Start date (YYYY-MM-DD)"},
-                    {"name": "end_date", "in": "query", "type": "string", "format": "date", "description": "End date (YYYY-MM-DD)"},
-                    {"name": "group_by", "in": "query", "type": "string", "enum": ["day", "week", "month"], "description": "Group trend data by"}
+                    {"name": "attachmentId", "in": "path", "required": true, "type": "string", "description": "Attachment UUID"}
                 ],
                 "responses": {
-                    "200": {"description": "Admin dashboard analytics"},
+                    "200": {"description": "Thumbnail content"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"}
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Attachment or thumbnail not found"}
                 }
             }
         },
-        "/api/v2/analytics/admin/overview": {
+        "/api/v2/chat/media/{attachmentId}": {
             "get": {
-                "summary": "Platform overview",
-                "description": "Get platform overview statistics (admin only)",
-                "tags": ["Analytics - Admin"],
+                "summary": "Get chat attachment by signed link",
+                "description": "Serve an attachment without an Authorization header, so img, video and audio tags can load it. Message and upload responses carry these links in place of the authenticated URL; each works for up to 30 minutes, after which a fresh response carries a new one",
+                "tags": ["Chat"],
+                "parameters": [
+                    {"name": "attachmentId", "in": "path", "required": true, "type": "string", "description": "Attachment UUID"},
+                    {"name": "expires", "in": "query", "required": true, "type": "integer", "description": "Unix time the link expires"},
+                    {"name": "signature", "in": "query", "required": true, "type": "string", "description": "Link signature"}
+                ],
+                "responses": {
+                    "200": {"description": "Attachment content"},
+                    "403": {"description": "Link is invalid or has expired"},
+                    "404": {"description": "Attachment not found"}
+                }
+            }
+        },
+        "/api/v2/chat/media/{attachmentId}/thumbnail": {
+            "get": {
+                "summary": "Get chat attachment thumbnail by signed link",
+                "description": "Serve the JPEG thumbnail of an image attachment through the signed link carried in the media's thumbnail field",
+                "tags": ["Chat"],
+                "parameters": [
+                    {"name": "attachmentId", "in": "path", "required": true, "type": "string", "description": "Attachment UUID"},
+                    {"name": "expires", "in": "query", "required": true, "type": "integer", "description": "Unix time the link expires"},
+                    {"name": "signature", "in": "query", "required": true, "type": "string", "description": "Link signature"}
+                ],
+                "responses": {
+                    "200": {"description": "Thumbnail content"},
+                    "403": {"description": "Link is invalid or has expired"},
+                    "404": {"description": "Attachment or thumbnail not found"}
+                }
+            }
+        },
+        "/api/v2/chat/unread": {
+            "get": {
+                "summary": "Get unread count",
+                "description": "Get total unread message count for the current user",
+                "tags": ["Chat"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "responses": {
-                    "200": {"description": "Platform overview stats"},
-                    "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"}
+                    "200": {
+                        "description": "Unread count retrieved",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "unread_count": {"type": "integer"}
+                            }
+                        }
+                    },
+                    "401": {"description": "Unauthorized"}
                 }
             }
         },
-        "/api/v2/analytics/admin/users": {
+        "/api/v2/chat/search": {
             "get": {
-                "summary": "User analytics",
-                "description": "Get user statistics (admin only)",
-                "tags": ["Analytics - Admin"],
+                "summary": "Search messages",
+                "description": "Full-text search across all of the current user's conversations. Each match is returned with up to two messages before and after it and the conversation it belongs to",
+                "tags": ["Chat"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
-                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                    {"name": "q", "in": "query", "required": true, "type": "string", "minLength": 2, "maxLength": 200, "description": "Search terms; supports quoted phrases, OR and -excluded words"},
+                    {"name": "page", "in": "query", "type": "integer", "default": 1},
+                    {"name": "limit", "in": "query", "type": "integer", "default": 20, "maximum": 50}
                 ],
                 "responses": {
-                    "200": {"description": "User analytics"},
-                    "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"}
+                    "200": {
+                        "description": "Messages retrieved",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "data": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "object",
+                                        "properties": {
+                                            "message": {"type": "object", "description": "The matched message"},
+                                            "conversation": {
+                                                "type": "object",
+                                                "properties": {
+                                                    "id": {"type": "string", "format": "uuid"},
+                                                    "type": {"type": "string", "enum": ["DIRECT", "GROUP"]},
+                                                    "name": {"type": "string"},
+                                                    "other_user": {
+                                                        "type": "object",
+                                                        "properties": {
+                                                            "id": {"type": "string", "format": "uuid"},
+                                                            "name": {"type": "string"},
+                                                            "username": {"type": "string"},
+                                                            "image": {"type": "string"}
+                                                        }
+                                                    }
+                                                }
+                                            },
+                                            "before": {"type": "array", "items": {"type": "object"}, "description": "Preceding messages, oldest first"},
+                                            "after": {"type": "array", "items": {"type": "object"}, "description": "Following messages, oldest first"}
+                                        }
+                                    }
+                                },
+                                "total_items": {"type": "integer"},
+                                "total_pages": {"type": "integer"},
+                                "page": {"type": "integer"},
+                                "limit": {"type": "integer"}
+                            }
+                        }
+                    },
+                    "400": {"description": "Missing or invalid search query"},
+                    "401": {"description": "Unauthorized"}
                 }
             }
         },
-        "/api/v2/analytics/admin/jobs": {
+        "/api/v2/chat/presence/{userId}": {
             "get": {
-                "summary": "Job analytics",
-                "description": "Get job statistics (admin only)",
-                "tags": ["Analytics - Admin"],
+                "summary": "Get user presence",
+                "description": "Get a user's online/away/offline status and last-seen time. Users who hide their presence always appear offline",
+                "tags": ["Chat"],
                 "security": [{"Bearer": []}],
-                "produces": ["application/json"],
                 "parameters": [
-                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                    {"name": "userId", "in": "path", "required": true, "type": "string", "description": "User UUID"}
                 ],
                 "responses": {
-                    "200": {"description": "Job analytics"},
+                    "200": {"description": "Presence retrieved"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"}
+                    "404": {"description": "User not found"}
                 }
             }
         },
-        "/api/v2/analytics/admin/applications": {
+        "/api/v2/chat/presence/visibility": {
+            "put": {
+                "summary": "Update presence visibility",
+                "description": "Hide or reveal the current user's presence from other users",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "parameters": [
+                    {
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["hidden"],
+                            "properties": {
+                                "hidden": {"type": "boolean"}
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "Presence visibility updated"},
+                    "400": {"description": "Invalid request body"},
+                    "401": {"description": "Unauthorized"}
+                }
+            }
+        },
+        "/api/v2/chat/blocks": {
             "get": {
-                "summary": "Application analytics",
-                "description": "Get application statistics (admin only)",
-                "tags": ["Analytics - Admin"],
+                "summary": "List blocked users",
+                "description": "Get the users the current user has blocked",
+                "tags": ["Chat"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "responses": {
+                    "200": {
+                        "description": "Blocked users retrieved",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "properties": {
+                                    "user": {
+                                        "type": "object",
+                                        "properties": {
+                                            "id": {"type": "string", "format": "uuid"},
+                                            "name": {"type": "string"},
+                                            "username": {"type": "string"},
+                                            "image": {"type": "string"}
+                                        }
+                                    },
+                                    "blocked_at": {"type": "string", "format": "date-time"}
+                                }
+                            }
+                        }
+                    },
+                    "401": {"description": "Unauthorized"}
+                }
+            },
+            "post": {
+                "summary": "Block user",
+                "description": "Block a user. Neither side can start or continue a direct conversation while the block is in place, the blocked user cannot add the blocker to groups, and their group messages no longer notify the blocker",
+                "tags": ["Chat"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
-                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["user_id"],
+                            "properties": {
+                                "user_id": {"type": "string", "format": "uuid"}
+                            }
+                        }
+                    }
                 ],
                 "responses": {
-                    "200": {"description": "Application analytics"},
+                    "201": {"description": "User blocked"},
+                    "400": {"description": "Cannot block yourself"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"}
+                    "404": {"description": "User not found"}
                 }
             }
         },
-        "/api/v2/analytics/admin/revenue": {
-            "get": {
-                "summary": "Revenue analytics",
-                "description": "Get revenue statistics (admin only)",
-                "tags": ["Analytics - Admin"],
+        "/api/v2/chat/blocks/{userId}": {
+            "delete": {
+                "summary": "Unblock user",
+                "description": "Remove a block on a user",
+                "tags": ["Chat"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
-                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                    {"name": "userId", "in": "path", "required": true, "type": "string", "description": "Blocked user's UUID"}
                 ],
                 "responses": {
-                    "200": {"description": "Revenue analytics"},
+                    "200": {"description": "User unblocked"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"}
+                    "404": {"description": "User is not blocked"}
                 }
             }
         },
-        "/api/v2/analytics/recruiter/dashboard": {
-            "get": {
-                "summary": "Recruiter analytics dashboard",
-                "description": "Get analytics for recruiter's jobs and applications (recruiter only)",
-                "tags": ["Analytics - Recruiter"],
+        "/api/v2/chat/conversations/{id}/mute": {
+            "put": {
+                "summary": "Mute conversation",
+                "description": "Stop notifications for new messages in a conversation. Messages are still delivered in real time with muted set to true. Omit duration_minutes to mute until unmuted",
+                "tags": ["Chat"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
-                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "end_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "group_by", "in": "query", "type": "string", "enum": ["day", "week", "month"]}
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"},
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "duration_minutes": {"type": "integer", "minimum": 1, "maximum": 525600}
+                            }
+                        }
+                    }
                 ],
                 "responses": {
-                    "200": {"description": "Recruiter dashboard analytics"},
+                    "200": {"description": "Conversation muted"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Recruiter access required"}
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Conversation not found"}
                 }
-            }
-        },
-        "/api/v2/analytics/recruiter/jobs": {
-            "get": {
-                "summary": "Recruiter job performance",
-                "description": "Get performance metrics for recruiter's jobs",
-                "tags": ["Analytics - Recruiter"],
+            },
+            "delete": {
+                "summary": "Unmute conversation",
+                "description": "Resume notifications for a conversation",
+                "tags": ["Chat"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
-                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"}
                 ],
                 "responses": {
-                    "200": {"description": "Job performance metrics"},
+                    "200": {"description": "Conversation unmuted"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Recruiter access required"}
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "Conversation not found"}
                 }
             }
         },
-        "/api/v2/analytics/recruiter/applications": {
-            "get": {
-                "summary": "Recruiter application stats",
-                "description": "Get application statistics for recruiter's jobs",
-                "tags": ["Analytics - Recruiter"],
+        "/api/v2/chat/reports": {
+            "post": {
+                "summary": "Report user",
+                "description": "Report a user to moderators. The listed messages are copied into the report; if none are listed and a conversation is given, the user's 20 most recent messages in it are copied instead",
+                "tags": ["Chat"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
+                "parameters": [
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["user_id", "reason"],
+                            "properties": {
+                                "user_id": {"type": "string", "format": "uuid"},
+                                "conversation_id": {"type": "string", "format": "uuid"},
+                                "message_ids": {"type": "array", "maxItems": 50, "items": {"type": "string", "format": "uuid"}},
+                                "reason": {"type": "string", "enum": ["SPAM", "HARASSMENT", "INAPPROPRIATE_CONTENT", "SCAM", "OTHER"]},
+                                "details": {"type": "string", "maxLength": 1000},
+                                "block": {"type": "boolean", "description": "Also block the reported user"}
+                            }
+                        }
+                    }
+                ],
                 "responses": {
-                    "200": {"description": "Application statistics"},
+                    "201": {"description": "Report submitted"},
+                    "400": {"description": "Invalid report"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Recruiter access required"}
+                    "403": {"description": "Not a participant"},
+                    "404": {"description": "User or conversation not found"}
                 }
             }
         },
-        "/api/v2/analytics/talent/dashboard": {
+        "/api/v2/admin/chat/reports": {
             "get": {
-                "summary": "Talent analytics dashboard",
-                "description": "Get analytics for talent's profile, portfolio, and applications",
-                "tags": ["Analytics - Talent"],
+                "summary": "List chat reports (Admin)",
+                "description": "Get the chat moderation queue, oldest first (requires the users:moderate permission)",
+                "tags": ["Chat - Admin"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
-                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "end_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "group_by", "in": "query", "type": "string", "enum": ["day", "week", "month"]}
+                    {"name": "status", "in": "query", "type": "string", "enum": ["PENDING", "RESOLVED", "DISMISSED"]},
+                    {"name": "page", "in": "query", "type": "integer", "default": 1},
+                    {"name": "limit", "in": "query", "type": "integer", "default": 20}
                 ],
                 "responses": {
-                    "200": {"description": "Talent dashboard analytics"},
-                    "401": {"description": "Unauthorized"}
+                    "200": {"description": "Reports retrieved"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
                 }
             }
         },
-        "/api/v2/analytics/talent/profile-views": {
+        "/api/v2/admin/chat/reports/{id}": {
             "get": {
-                "summary": "Profile views analytics",
-                "description": "Get profile views statistics for the authenticated user",
-                "tags": ["Analytics - Talent"],
+                "summary": "Get chat report (Admin)",
+                "description": "Get a report with the messages captured when it was filed (requires the users:moderate permission)",
+                "tags": ["Chat - Admin"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
-                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Report UUID"}
                 ],
                 "responses": {
-                    "200": {"description": "Profile views analytics"},
-                    "401": {"description": "Unauthorized"}
+                    "200": {"description": "Report retrieved"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "Report not found"}
                 }
             }
         },
-        "/api/v2/analytics/talent/portfolio": {
-            "get": {
-                "summary": "Portfolio analytics",
-                "description": "Get portfolio views and engagement statistics",
-                "tags": ["Analytics - Talent"],
+        "/api/v2/admin/chat/reports/{id}/resolve": {
+            "put": {
+                "summary": "Resolve chat report (Admin)",
+                "description": "Close a pending report. WARN notifies the reported user; BAN also suspends their chat access. The reporter is notified that the report was reviewed (requires the users:moderate permission)",
+                "tags": ["Chat - Admin"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
-                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Report UUID"},
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["action"],
+                            "properties": {
+                                "action": {"type": "string", "enum": ["DISMISS", "WARN", "BAN"]},
+                                "note": {"type": "string", "maxLength": 1000}
+                            }
+                        }
+                    }
                 ],
                 "responses": {
-                    "200": {"description": "Portfolio analytics"},
-                    "401": {"description": "Unauthorized"}
-                }
-            }
-        },
-        "/api/v2/analytics/talent/applications": {
-            "get": {
-                "summary": "Talent application stats",
-                "description": "Get statistics for user's job applications",
-                "tags": ["Analytics - Talent"],
-                "security": [{"Bearer": []}],
-                "produces": ["application/json"],
-                "responses": {
-                    "200": {"description": "Application statistics"},
-                    "401": {"description": "Unauthorized"}
+                    "200": {"description": "Report resolved"},
+                    "400": {"description": "Report already resolved"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "Report not found"}
                 }
             }
         },
-        "/api/v2/analytics/talent/viewer-insights": {
-            "get": {
-                "summary": "Viewer insights",
-                "description": "Get insights about who viewed your profile (recruiters vs talents)",
-                "tags": ["Analytics - Talent"],
+        "/api/v2/admin/chat/bans/{userId}": {
+            "delete": {
+                "summary": "Lift chat ban (Admin)",
+                "description": "Restore a banned user's chat access (requires the users:moderate permission)",
+                "tags": ["Chat - Admin"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
-                    {"name": "start_date", "in": "query", "type": "string", "format": "date"},
-                    {"name": "end_date", "in": "query", "type": "string", "format": "date"}
+                    {"name": "userId", "in": "path", "required": true, "type": "string", "description": "User UUID"}
                 ],
                 "responses": {
-                    "200": {"description": "Viewer insights"},
-                    "401": {"description": "Unauthorized"}
+                    "200": {"description": "Chat ban lifted"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "User not found"}
                 }
             }
         },
-        "/api/v2/notification-settings": {
+        "/api/v2/outreach/templates": {
             "get": {
-                "summary": "Get notification settings",
-                "description": "Get current user's notification preferences",
-                "tags": ["Notification Settings"],
+                "summary": "List message templates",
+                "description": "Get the current recruiter's saved message templates",
+                "tags": ["Outreach"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "responses": {
-                    "200": {
-                        "description": "Notification settings retrieved",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "id": {"type": "string", "format": "uuid"},
-                                "user_id": {"type": "string", "format": "uuid"},
-                                "email": {
-                                    "type": "object",
-                                    "properties": {
-                                        "app_updates": {"type": "boolean"},
-                                        "new_messages": {"type": "boolean"},
-                                        "job_recommendations": {"type": "boolean"},
-                                        "newsletter": {"type": "boolean"},
-                                        "marketing_emails": {"type": "boolean"}
-                                    }
-                                },
-                                "push": {
-                                    "type": "object",
-                                    "properties": {
-                                        "app_updates": {"type": "boolean"},
-                                        "new_messages": {"type": "boolean"},
-                                        "reminders": {"type": "boolean"}
-                                    }
-                                },
-                                "in_app": {
-                                    "type": "object",
-                                    "properties": {
-                                        "activity_updates": {"type": "boolean"},
-                                        "mentions": {"type": "boolean"},
-                                        "announcements": {"type": "boolean"}
-                                    }
-                                }
-                            }
-                        }
-                    },
-                    "401": {"description": "Unauthorized"}
+                    "200": {"description": "Templates retrieved"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Recruiters only"}
                 }
             },
-            "put": {
-                "summary": "Update all notification settings",
-                "description": "Update all notification preferences at once",
-                "tags": ["Notification Settings"],
+            "post": {
+                "summary": "Create message template",
+                "description": "Save a message template. Content is a Go template and may use {{.CandidateName}}, {{.CandidateFirstName}}, {{.JobTitle}}, {{.CompanyName}} and {{.RecruiterName}}",
+                "tags": ["Outreach"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
@@ -3322,53 +6079,32 @@ const template = `{
                         "required": true,
                         "schema": {
                             "type": "object",
+                            "required": ["name", "content"],
                             "properties": {
-                                "email": {
-                                    "type": "object",
-                                    "properties": {
-                                        "app_updates": {"type": "boolean"},
-                                        "new_messages": {"type": "boolean"},
-                                        "job_recommendations": {"type": "boolean"},
-                                        "newsletter": {"type": "boolean"},
-                                        "marketing_emails": {"type": "boolean"}
-                                    }
-                                },
-                                "push": {
-                                    "type": "object",
-                                    "properties": {
-                                        "app_updates": {"type": "boolean"},
-                                        "new_messages": {"type": "boolean"},
-                                        "reminders": {"type": "boolean"}
-                                    }
-                                },
-                                "in_app": {
-                                    "type": "object",
-                                    "properties": {
-                                        "activity_updates": {"type": "boolean"},
-                                        "mentions": {"type": "boolean"},
-                                        "announcements": {"type": "boolean"}
-                                    }
-                                }
+                                "name": {"type": "string", "maxLength": 100, "example": "Interview invite"},
+                                "content": {"type": "string", "maxLength": 5000, "example": "Hi {{.CandidateFirstName}}, thanks for applying to {{.JobTitle}} at {{.CompanyName}}."}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "200": {"description": "Settings updated successfully"},
-                    "400": {"description": "Invalid data"},
-                    "401": {"description": "Unauthorized"}
+                    "201": {"description": "Template created"},
+                    "400": {"description": "Invalid template"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Recruiters only"}
                 }
             }
         },
-        "/api/v2/notification-settings/email": {
+        "/api/v2/outreach/templates/{id}": {
             "put": {
-                "summary": "Update email notification settings",
-                "description": "Update email notification preferences only",
-                "tags": ["Notification Settings"],
+                "summary": "Update message template",
+                "description": "Rename a template or change its content",
+                "tags": ["Outreach"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Template UUID"},
                     {
                         "in": "body",
                         "name": "body",
@@ -3376,61 +6112,82 @@ const template = `{
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "app_updates": {"type": "boolean"},
-                                "new_messages": {"type": "boolean"},
-                                "job_recommendations": {"type": "boolean"},
-                                "newsletter": {"type": "boolean"},
-                                "marketing_emails": {"type": "boolean"}
+                                "name": {"type": "string", "maxLength": 100},
+                                "content": {"type": "string", "maxLength": 5000}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "200": {"description": "Email settings updated"},
-                    "400": {"description": "Invalid data"},
-                    "401": {"description": "Unauthorized"}
+                    "200": {"description": "Template updated"},
+                    "400": {"description": "Invalid template"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Template not found"}
                 }
-            }
-        },
-        "/api/v2/notification-settings/push": {
-            "put": {
-                "summary": "Update push notification settings",
-                "description": "Update push notification preferences only",
-                "tags": ["Notification Settings"],
+            },
+            "delete": {
+                "summary": "Delete message template",
+                "tags": ["Outreach"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Template UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Template deleted"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Template not found"}
+                }
+            }
+        },
+        "/api/v2/outreach/templates/{id}/preview": {
+            "post": {
+                "summary": "Preview message template",
+                "description": "Render a template for one of the recruiter's applicants without sending it",
+                "tags": ["Outreach"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Template UUID"},
                     {
                         "in": "body",
                         "name": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
+                            "required": ["application_id"],
                             "properties": {
-                                "app_updates": {"type": "boolean"},
-                                "new_messages": {"type": "boolean"},
-                                "reminders": {"type": "boolean"}
+                                "application_id": {"type": "string", "format": "uuid"}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "200": {"description": "Push settings updated"},
-                    "400": {"description": "Invalid data"},
-                    "401": {"description": "Unauthorized"}
+                    "200": {
+                        "description": "Template rendered",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "content": {"type": "string"}
+                            }
+                        }
+                    },
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Template or application not found"}
                 }
             }
         },
-        "/api/v2/notification-settings/in-app": {
-            "put": {
-                "summary": "Update in-app notification settings",
-                "description": "Update in-app notification preferences only",
-                "tags": ["Notification Settings"],
+        "/api/v2/outreach/jobs/{id}/send": {
+            "post": {
+                "summary": "Bulk message applicants",
+                "description": "Send a chat message to every applicant of the recruiter's job that matches the filters (at most 500). Messages are sent one at a time, about ten per second. Each recipient uses one slot of the daily cap (free 25, basic 100, premium 300, business 1000; resets at midnight UTC). Recipients past the cap are skipped, and failed sends do not use a slot",
+                "tags": ["Outreach"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Job UUID"},
                     {
                         "in": "body",
                         "name": "body",
@@ -3438,726 +6195,774 @@ const template = `{
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "activity_updates": {"type": "boolean"},
-                                "mentions": {"type": "boolean"},
-                                "announcements": {"type": "boolean"}
+                                "template_id": {"type": "string", "format": "uuid", "description": "Saved template to send"},
+                                "content": {"type": "string", "maxLength": 5000, "description": "Template content to send when template_id is omitted"},
+                                "status": {"type": "string", "enum": ["PENDING", "REVIEWED", "ACCEPTED", "REJECTED", "HIRED"]},
+                                "submission_date": {"type": "string", "format": "date"}
                             }
                         }
                     }
                 ],
-                "responses": {
-                    "200": {"description": "In-app settings updated"},
-                    "400": {"description": "Invalid data"},
-                    "401": {"description": "Unauthorized"}
-                }
-            }
-        },
-        "/api/v2/announcements": {
-            "get": {
-                "summary": "Get announcements",
-                "description": "Get announcements for the current user based on their role",
-                "tags": ["Announcements"],
-                "security": [{"Bearer": []}],
-                "produces": ["application/json"],
-                "parameters": [
-                    {"name": "page", "in": "query", "type": "integer", "default": 1},
-                    {"name": "limit", "in": "query", "type": "integer", "default": 10}
-                ],
                 "responses": {
                     "200": {
-                        "description": "Announcements retrieved",
+                        "description": "Bulk send completed",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "data": {
+                                "total": {"type": "integer"},
+                                "sent": {"type": "integer"},
+                                "failed": {"type": "integer"},
+                                "skipped": {"type": "integer"},
+                                "quota": {
+                                    "type": "object",
+                                    "properties": {
+                                        "limit": {"type": "integer"},
+                                        "used": {"type": "integer"},
+                                        "remaining": {"type": "integer"},
+                                        "resets_at": {"type": "string", "format": "date-time"}
+                                    }
+                                },
+                                "results": {
                                     "type": "array",
                                     "items": {
                                         "type": "object",
                                         "properties": {
-                                            "id": {"type": "string", "format": "uuid"},
-                                            "title": {"type": "string"},
-                                            "content": {"type": "string"},
-                                            "type": {"type": "string", "enum": ["ANNOUNCEMENT", "APP_UPDATE", "SYSTEM_ALERT", "MAINTENANCE"]},
-                                            "priority": {"type": "string", "enum": ["LOW", "NORMAL", "HIGH", "CRITICAL"]},
-                                            "show_as_banner": {"type": "boolean"},
-                                            "action_url": {"type": "string"},
-                                            "action_text": {"type": "string"},
-                                            "is_read": {"type": "boolean"},
-                                            "is_dismissed": {"type": "boolean"},
-                                            "published_at": {"type": "string", "format": "date-time"}
+                                            "application_id": {"type": "string", "format": "uuid"},
+                                            "recipient_id": {"type": "string", "format": "uuid"},
+                                            "status": {"type": "string", "enum": ["SENT", "FAILED", "SKIPPED"]},
+                                            "message_id": {"type": "string", "format": "uuid"},
+                                            "error": {"type": "string"}
                                         }
                                     }
-                                },
-                                "total_items": {"type": "integer"},
-                                "total_pages": {"type": "integer"},
-                                "page": {"type": "integer"},
-                                "limit": {"type": "integer"}
-                            }
-                        }
-                    },
-                    "401": {"description": "Unauthorized"}
-                }
-            }
-        },
-        "/api/v2/announcements/banners": {
-            "get": {
-                "summary": "Get active banners",
-                "description": "Get active announcement banners for display",
-                "tags": ["Announcements"],
-                "security": [{"Bearer": []}],
-                "produces": ["application/json"],
-                "responses": {
-                    "200": {
-                        "description": "Active banners retrieved",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "properties": {
-                                    "id": {"type": "string", "format": "uuid"},
-                                    "title": {"type": "string"},
-                                    "content": {"type": "string"},
-                                    "type": {"type": "string"},
-                                    "priority": {"type": "string"},
-                                    "banner_color": {"type": "string"},
-                                    "action_url": {"type": "string"},
-                                    "action_text": {"type": "string"}
                                 }
                             }
                         }
                     },
-                    "401": {"description": "Unauthorized"}
-                }
-            }
-        },
-        "/api/v2/announcements/{id}/read": {
-            "put": {
-                "summary": "Mark announcement as read",
-                "description": "Mark an announcement as read for the current user",
-                "tags": ["Announcements"],
-                "security": [{"Bearer": []}],
-                "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
-                ],
-                "responses": {
-                    "200": {"description": "Announcement marked as read"},
+                    "400": {"description": "Invalid template, missing content, too many recipients or daily limit reached"},
                     "401": {"description": "Unauthorized"},
-                    "404": {"description": "Announcement not found"}
+                    "403": {"description": "Recruiters only"},
+                    "404": {"description": "Job or template not found"}
                 }
             }
         },
-        "/api/v2/announcements/{id}/dismiss": {
-            "put": {
-                "summary": "Dismiss announcement",
-                "description": "Dismiss an announcement for the current user",
-                "tags": ["Announcements"],
+        "/api/v2/outreach/quota": {
+            "get": {
+                "summary": "Get outreach quota",
+                "description": "Get the recruiter's daily bulk outreach limit and usage",
+                "tags": ["Outreach"],
                 "security": [{"Bearer": []}],
-                "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
-                ],
+                "produces": ["application/json"],
                 "responses": {
-                    "200": {"description": "Announcement dismissed"},
+                    "200": {"description": "Outreach quota retrieved"},
                     "401": {"description": "Unauthorized"},
-                    "404": {"description": "Announcement not found"}
+                    "403": {"description": "Recruiters only"}
                 }
             }
         },
-        "/api/v2/admin/announcements": {
+        "/api/v2/reviews": {
             "get": {
-                "summary": "List all announcements (Admin)",
-                "description": "Get all announcements with filtering (admin only)",
-                "tags": ["Announcements - Admin"],
-                "security": [{"Bearer": []}],
-                "produces": ["application/json"],
+                "summary": "Get all reviews",
+                "description": "Retrieve paginated list of reviews",
+                "tags": ["Reviews"],
                 "parameters": [
-                    {"name": "page", "in": "query", "type": "integer", "default": 1},
-                    {"name": "limit", "in": "query", "type": "integer", "default": 10},
-                    {"name": "type", "in": "query", "type": "string", "enum": ["ANNOUNCEMENT", "APP_UPDATE", "SYSTEM_ALERT", "MAINTENANCE"]},
-                    {"name": "is_published", "in": "query", "type": "boolean"}
+                    {"name": "page", "in": "query", "type": "integer", "description": "Page number"},
+                    {"name": "size", "in": "query", "type": "integer", "description": "Items per page"},
+                    {"name": "rating", "in": "query", "type": "integer", "description": "Filter by rating (0-5)"}
                 ],
                 "responses": {
-                    "200": {"description": "Announcements retrieved"},
-                    "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"}
+                    "200": {"description": "Reviews fetched successfully"}
                 }
             },
             "post": {
-                "summary": "Create announcement (Admin)",
-                "description": "Create a new announcement (admin only)",
-                "tags": ["Announcements - Admin"],
+                "summary": "Create a review",
+                "description": "Create a new review (one per user)",
+                "tags": ["Reviews"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
-                "produces": ["application/json"],
                 "parameters": [
                     {
-                        "in": "body",
                         "name": "body",
+                        "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "required": ["title", "content", "type"],
+                            "required": ["comment", "rating"],
                             "properties": {
-                                "title": {"type": "string", "example": "New Feature Released"},
-                                "content": {"type": "string", "example": "We have released a new messaging feature!"},
-                                "type": {"type": "string", "enum": ["ANNOUNCEMENT", "APP_UPDATE", "SYSTEM_ALERT", "MAINTENANCE"]},
-                                "target_audience": {"type": "string", "enum": ["ALL_USERS", "ADMINS_ONLY", "RECRUITERS_ONLY", "PREMIUM_ONLY"], "default": "ALL_USERS"},
-                                "priority": {"type": "string", "enum": ["LOW", "NORMAL", "HIGH", "CRITICAL"], "default": "NORMAL"},
-                                "show_as_banner": {"type": "boolean", "default": false},
-                                "banner_color": {"type": "string", "example": "#3B82F6"},
-                                "action_url": {"type": "string", "example": "https://example.com/feature"},
-                                "action_text": {"type": "string", "example": "Learn More"},
-                                "scheduled_at": {"type": "string", "format": "date-time"},
-                                "expires_at": {"type": "string", "format": "date-time"}
+                                "comment": {"type": "string", "description": "Review comment"},
+                                "rating": {"type": "integer", "minimum": 0, "maximum": 5, "description": "Rating from 0 to 5"}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "201": {"description": "Announcement created"},
-                    "400": {"description": "Invalid data"},
-                    "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"}
+                    "200": {"description": "Review created successfully"},
+                    "400": {"description": "Bad request or already submitted a review"},
+                    "401": {"description": "Unauthorized"}
                 }
             }
         },
-        "/api/v2/admin/announcements/{id}": {
+        "/api/v2/reviews/me": {
             "get": {
-                "summary": "Get announcement (Admin)",
-                "description": "Get a single announcement by ID (admin only)",
-                "tags": ["Announcements - Admin"],
+                "summary": "Get my review",
+                "description": "Get the current user's review",
+                "tags": ["Reviews"],
                 "security": [{"Bearer": []}],
+                "responses": {
+                    "200": {"description": "Review fetched successfully"},
+                    "401": {"description": "Unauthorized"},
+                    "404": {"description": "Review not found"}
+                }
+            }
+        },
+        "/api/v2/reviews/stats": {
+            "get": {
+                "summary": "Get review statistics",
+                "description": "Get average rating and total review count",
+                "tags": ["Reviews"],
+                "responses": {
+                    "200": {
+                        "description": "Statistics fetched successfully",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "average_rating": {"type": "number", "description": "Average rating"},
+                                "total_reviews": {"type": "integer", "description": "Total number of reviews"}
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/reviews/{id}": {
+            "get": {
+                "summary": "Get a review",
+                "description": "Get a specific review by ID",
+                "tags": ["Reviews"],
                 "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Review ID"}
                 ],
                 "responses": {
-                    "200": {"description": "Announcement retrieved"},
-                    "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"},
-                    "404": {"description": "Announcement not found"}
+                    "200": {"description": "Review fetched successfully"},
+                    "404": {"description": "Review not found"}
                 }
             },
             "put": {
-                "summary": "Update announcement (Admin)",
-                "description": "Update an announcement (admin only)",
-                "tags": ["Announcements - Admin"],
+                "summary": "Update a review",
+                "description": "Update your own review",
+                "tags": ["Reviews"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"},
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Review ID"},
                     {
-                        "in": "body",
                         "name": "body",
+                        "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "title": {"type": "string"},
-                                "content": {"type": "string"},
-                                "type": {"type": "string", "enum": ["ANNOUNCEMENT", "APP_UPDATE", "SYSTEM_ALERT", "MAINTENANCE"]},
-                                "target_audience": {"type": "string", "enum": ["ALL_USERS", "ADMINS_ONLY", "RECRUITERS_ONLY", "PREMIUM_ONLY"]},
-                                "priority": {"type": "string", "enum": ["LOW", "NORMAL", "HIGH", "CRITICAL"]},
-                                "show_as_banner": {"type": "boolean"},
-                                "banner_color": {"type": "string"},
-                                "action_url": {"type": "string"},
-                                "action_text": {"type": "string"},
-                                "scheduled_at": {"type": "string", "format": "date-time"},
-                                "expires_at": {"type": "string", "format": "date-time"}
+                                "comment": {"type": "string", "description": "Review comment"},
+                                "rating": {"type": "integer", "minimum": 0, "maximum": 5, "description": "Rating from 0 to 5"}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "200": {"description": "Announcement updated"},
-                    "400": {"description": "Invalid data"},
+                    "200": {"description": "Review updated successfully"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"},
-                    "404": {"description": "Announcement not found"}
+                    "403": {"description": "Forbidden - can only update your own review"},
+                    "404": {"description": "Review not found"}
                 }
             },
             "delete": {
-                "summary": "Delete announcement (Admin)",
-                "description": "Delete an announcement (admin only)",
-                "tags": ["Announcements - Admin"],
+                "summary": "Delete a review",
+                "description": "Delete your own review",
+                "tags": ["Reviews"],
                 "security": [{"Bearer": []}],
                 "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Review ID"}
                 ],
                 "responses": {
-                    "200": {"description": "Announcement deleted"},
+                    "200": {"description": "Review deleted successfully"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"},
-                    "404": {"description": "Announcement not found"}
+                    "403": {"description": "Forbidden - can only delete your own review"},
+                    "404": {"description": "Review not found"}
                 }
             }
         },
-        "/api/v2/admin/announcements/{id}/publish": {
-            "put": {
-                "summary": "Publish announcement (Admin)",
-                "description": "Publish an announcement (admin only)",
-                "tags": ["Announcements - Admin"],
+        "/api/v2/admin/audit-logs": {
+            "get": {
+                "summary": "List audit logs (Admin)",
+                "description": "List audit log entries, newest first. An actor filter also matches actions taken while impersonating; an action ending in \"*\" matches by prefix, e.g. \"admin.*\" (requires the audit:read permission)",
+                "tags": ["Audit - Admin"],
                 "security": [{"Bearer": []}],
+                "produces": ["application/json"],
                 "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
+                    {"in": "query", "name": "page", "type": "integer", "default": 1},
+                    {"in": "query", "name": "limit", "type": "integer", "default": 10},
+                    {"in": "query", "name": "actor_id", "type": "string", "format": "uuid"},
+                    {"in": "query", "name": "action", "type": "string", "example": "auth.signin_failed"},
+                    {"in": "query", "name": "entity_type", "type": "string", "example": "user"},
+                    {"in": "query", "name": "entity_id", "type": "string"},
+                    {"in": "query", "name": "ip_address", "type": "string"},
+                    {"in": "query", "name": "start_date", "type": "string", "format": "date"},
+                    {"in": "query", "name": "end_date", "type": "string", "format": "date"}
                 ],
                 "responses": {
-                    "200": {"description": "Announcement published"},
-                    "400": {"description": "Announcement already published"},
+                    "200": {"description": "Audit logs retrieved"},
+                    "400": {"description": "Invalid filters"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"},
-                    "404": {"description": "Announcement not found"}
+                    "403": {"description": "Admin access required"}
                 }
             }
         },
-        "/api/v2/admin/announcements/{id}/unpublish": {
-            "put": {
-                "summary": "Unpublish announcement (Admin)",
-                "description": "Unpublish an announcement (admin only)",
-                "tags": ["Announcements - Admin"],
+        "/api/v2/admin/audit-logs/export": {
+            "get": {
+                "summary": "Export audit logs (Admin)",
+                "description": "Download the filtered audit log as CSV, newest first and capped at 50000 rows. Accepts the same filters as the list endpoint (requires the audit:read permission)",
+                "tags": ["Audit - Admin"],
                 "security": [{"Bearer": []}],
+                "produces": ["text/csv"],
                 "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Announcement UUID"}
+                    {"in": "query", "name": "actor_id", "type": "string", "format": "uuid"},
+                    {"in": "query", "name": "action", "type": "string"},
+                    {"in": "query", "name": "entity_type", "type": "string"},
+                    {"in": "query", "name": "entity_id", "type": "string"},
+                    {"in": "query", "name": "ip_address", "type": "string"},
+                    {"in": "query", "name": "start_date", "type": "string", "format": "date"},
+                    {"in": "query", "name": "end_date", "type": "string", "format": "date"}
                 ],
                 "responses": {
-                    "200": {"description": "Announcement unpublished"},
-                    "400": {"description": "Announcement not published"},
+                    "200": {"description": "CSV file"},
+                    "400": {"description": "Invalid filters"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Admin access required"},
-                    "404": {"description": "Announcement not found"}
+                    "403": {"description": "Admin access required"}
                 }
             }
         },
-        "/api/v2/chat/conversations": {
+        "/api/v2/admin/permissions": {
             "get": {
-                "summary": "Get conversations",
-                "description": "Get all conversations for the current user",
-                "tags": ["Chat"],
+                "summary": "List permissions (Admin)",
+                "description": "List every permission that can be granted through a role (requires the roles:manage permission)",
+                "tags": ["Roles - Admin"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
-                "parameters": [
-                    {"name": "page", "in": "query", "type": "integer", "default": 1},
-                    {"name": "limit", "in": "query", "type": "integer", "default": 20}
-                ],
                 "responses": {
                     "200": {
-                        "description": "Conversations retrieved",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "data": {
-                                    "type": "array",
-                                    "items": {
-                                        "type": "object",
-                                        "properties": {
-                                            "id": {"type": "string", "format": "uuid"},
-                                            "other_user": {
-                                                "type": "object",
-                                                "properties": {
-                                                    "id": {"type": "string", "format": "uuid"},
-                                                    "name": {"type": "string"},
-                                                    "username": {"type": "string"},
-                                                    "image": {"type": "string"}
-                                                }
-                                            },
-                                            "last_message": {
-                                                "type": "object",
-                                                "properties": {
-                                                    "id": {"type": "string", "format": "uuid"},
-                                                    "content": {"type": "string"},
-                                                    "sender_id": {"type": "string", "format": "uuid"},
-                                                    "created_at": {"type": "string", "format": "date-time"}
-                                                }
-                                            },
-                                            "unread_count": {"type": "integer"},
-                                            "created_at": {"type": "string", "format": "date-time"},
-                                            "updated_at": {"type": "string", "format": "date-time"}
-                                        }
-                                    }
-                                },
-                                "total_items": {"type": "integer"},
-                                "total_pages": {"type": "integer"},
-                                "page": {"type": "integer"},
-                                "limit": {"type": "integer"}
-                            }
-                        }
+                        "description": "Permissions retrieved",
+                        "schema": {"type": "array", "items": {"type": "string", "enum": ["announcements:write", "subscriptions:manage", "users:moderate", "users:impersonate", "analytics:read", "notifications:broadcast", "roles:manage", "audit:read"]}}
                     },
-                    "401": {"description": "Unauthorized"}
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
                 }
             }
         },
-        "/api/v2/chat/conversations/{id}": {
+        "/api/v2/admin/roles": {
             "get": {
-                "summary": "Get conversation",
-                "description": "Get a single conversation by ID",
-                "tags": ["Chat"],
+                "summary": "List roles (Admin)",
+                "description": "List admin roles, system roles first (requires the roles:manage permission)",
+                "tags": ["Roles - Admin"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
-                "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"}
-                ],
                 "responses": {
-                    "200": {"description": "Conversation retrieved"},
+                    "200": {"description": "Roles retrieved"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Not a participant"},
-                    "404": {"description": "Conversation not found"}
+                    "403": {"description": "Admin access required"}
                 }
             },
-            "delete": {
-                "summary": "Delete conversation",
-                "description": "Delete a conversation",
-                "tags": ["Chat"],
-                "security": [{"Bearer": []}],
-                "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"}
-                ],
-                "responses": {
-                    "200": {"description": "Conversation deleted"},
-                    "401": {"description": "Unauthorized"},
-                    "403": {"description": "Not a participant"},
-                    "404": {"description": "Conversation not found"}
-                }
-            }
-        },
-        "/api/v2/chat/conversations/user/{userId}": {
-            "get": {
-                "summary": "Get or create conversation",
-                "description": "Get an existing conversation with a user or create a new one",
-                "tags": ["Chat"],
+            "post": {
+                "summary": "Create role (Admin)",
+                "description": "Create a custom role from a set of permissions. Names are lower-cased and spaces become underscores; \"*\" grants every permission (requires the roles:manage permission)",
+                "tags": ["Roles - Admin"],
                 "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
-                    {"name": "userId", "in": "path", "required": true, "type": "string", "description": "Other user's UUID"}
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["name", "permissions"],
+                            "properties": {
+                                "name": {"type": "string", "example": "support_agent"},
+                                "description": {"type": "string", "example": "Handles user reports"},
+                                "permissions": {"type": "array", "items": {"type": "string"}, "example": ["users:moderate", "analytics:read"]}
+                            }
+                        }
+                    }
                 ],
                 "responses": {
-                    "200": {"description": "Conversation retrieved or created"},
-                    "400": {"description": "Cannot message self"},
+                    "201": {"description": "Role created"},
+                    "400": {"description": "Invalid data, unknown permission or name already taken"},
                     "401": {"description": "Unauthorized"},
-                    "404": {"description": "User not found"}
+                    "403": {"description": "Admin access required"}
                 }
             }
         },
-        "/api/v2/chat/messages": {
-            "post": {
-                "summary": "Send message",
-                "description": "Send a direct message to another user. Can include text content, media attachments, or both. Messages can also be sent via WebSocket using the action 'send_message'.",
-                "tags": ["Chat"],
+        "/api/v2/admin/roles/{id}": {
+            "put": {
+                "summary": "Update role (Admin)",
+                "description": "Rename a custom role or replace its permissions. System roles cannot be changed (requires the roles:manage permission)",
+                "tags": ["Roles - Admin"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "produces": ["application/json"],
                 "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Role UUID"},
                     {
                         "in": "body",
                         "name": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "required": ["recipient_id"],
                             "properties": {
-                                "recipient_id": {"type": "string", "format": "uuid", "description": "Recipient's user UUID"},
-                                "content": {"type": "string", "description": "Message content (optional if media is provided)", "example": "Hello, how are you?"},
-                                "media": {
-                                    "type": "array",
-                                    "description": "Media attachments (max 10)",
-                                    "maxItems": 10,
-                                    "items": {
-                                        "type": "object",
-                                        "required": ["type", "url"],
-                                        "properties": {
-                                            "type": {"type": "string", "enum": ["IMAGE", "VIDEO", "AUDIO", "DOCUMENT", "FILE"], "description": "Media type"},
-                                            "url": {"type": "string", "format": "url", "description": "URL of the uploaded media"},
-                                            "file_name": {"type": "string", "description": "Original file name"},
-                                            "file_size": {"type": "integer", "description": "File size in bytes"},
-                                            "mime_type": {"type": "string", "description": "MIME type", "example": "image/jpeg"},
-                                            "width": {"type": "integer", "description": "Width in pixels (for images/videos)"},
-                                            "height": {"type": "integer", "description": "Height in pixels (for images/videos)"},
-                                            "duration": {"type": "integer", "description": "Duration in seconds (for audio/video)"},
-                                            "thumbnail": {"type": "string", "description": "Thumbnail URL (for videos)"}
-                                        }
-                                    }
-                                }
+                                "name": {"type": "string"},
+                                "description": {"type": "string"},
+                                "permissions": {"type": "array", "items": {"type": "string"}}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Message sent",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "id": {"type": "string", "format": "uuid"},
-                                "conversation_id": {"type": "string", "format": "uuid"},
-                                "sender_id": {"type": "string", "format": "uuid"},
-                                "recipient_id": {"type": "string", "format": "uuid"},
-                                "content": {"type": "string"},
-                                "media": {
-                                    "type": "array",
-                                    "items": {
-                                        "type": "object",
-                                        "properties": {
-                                            "id": {"type": "string"},
-                                            "type": {"type": "string"},
-                                            "url": {"type": "string"},
-                                            "file_name": {"type": "string"},
-                                            "file_size": {"type": "integer"},
-                                            "mime_type": {"type": "string"},
-                                            "width": {"type": "integer"},
-                                            "height": {"type": "integer"},
-                                            "duration": {"type": "integer"},
-                                            "thumbnail": {"type": "string"}
-                                        }
-                                    }
-                                },
-                                "status": {"type": "string", "enum": ["SENT", "DELIVERED", "READ"]},
-                                "created_at": {"type": "string", "format": "date-time"}
-                            }
-                        }
-                    },
-                    "400": {"description": "Cannot message self, message must have content or media"},
+                    "200": {"description": "Role updated"},
+                    "400": {"description": "Invalid data, unknown permission or name already taken"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required or system role"},
+                    "404": {"description": "Role not found"}
+                }
+            },
+            "delete": {
+                "summary": "Delete role (Admin)",
+                "description": "Delete a custom role and revoke it from every user. System roles cannot be deleted (requires the roles:manage permission)",
+                "tags": ["Roles - Admin"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Role UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Role deleted"},
                     "401": {"description": "Unauthorized"},
-                    "404": {"description": "Recipient not found"}
+                    "403": {"description": "Admin access required or system role"},
+                    "404": {"description": "Role not found"}
                 }
             }
         },
-        "/api/v2/chat/conversations/{id}/messages": {
+        "/api/v2/admin/users/{id}/roles": {
             "get": {
-                "summary": "Get messages",
-                "description": "Get messages in a conversation",
-                "tags": ["Chat"],
+                "summary": "List user roles (Admin)",
+                "description": "List the roles assigned to a user (requires the roles:manage permission)",
+                "tags": ["Roles - Admin"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
                 "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"},
-                    {"name": "page", "in": "query", "type": "integer", "default": 1},
-                    {"name": "limit", "in": "query", "type": "integer", "default": 50}
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Messages retrieved",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "data": {
-                                    "type": "array",
-                                    "items": {
-                                        "type": "object",
-                                        "properties": {
-                                            "id": {"type": "string", "format": "uuid"},
-                                            "conversation_id": {"type": "string", "format": "uuid"},
-                                            "sender_id": {"type": "string", "format": "uuid"},
-                                            "recipient_id": {"type": "string", "format": "uuid"},
-                                            "content": {"type": "string"},
-                                            "media": {
-                                                "type": "array",
-                                                "items": {
-                                                    "type": "object",
-                                                    "properties": {
-                                                        "id": {"type": "string"},
-                                                        "type": {"type": "string", "enum": ["IMAGE", "VIDEO", "AUDIO", "DOCUMENT", "FILE"]},
-                                                        "url": {"type": "string"},
-                                                        "file_name": {"type": "string"},
-                                                        "file_size": {"type": "integer"},
-                                                        "mime_type": {"type": "string"},
-                                                        "width": {"type": "integer"},
-                                                        "height": {"type": "integer"},
-                                                        "duration": {"type": "integer"},
-                                                        "thumbnail": {"type": "string"}
-                                                    }
-                                                }
-                                            },
-                                            "status": {"type": "string", "enum": ["SENT", "DELIVERED", "READ"]},
-                                            "read_at": {"type": "string", "format": "date-time"},
-                                            "created_at": {"type": "string", "format": "date-time"},
-                                            "sender": {
-                                                "type": "object",
-                                                "properties": {
-                                                    "id": {"type": "string", "format": "uuid"},
-                                                    "name": {"type": "string"},
-                                                    "username": {"type": "string"},
-                                                    "image": {"type": "string"}
-                                                }
-                                            }
-                                        }
-                                    }
-                                },
-                                "total_items": {"type": "integer"},
-                                "total_pages": {"type": "integer"},
-                                "page": {"type": "integer"},
-                                "limit": {"type": "integer"}
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "User UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "User roles retrieved"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "User not found"}
+                }
+            },
+            "post": {
+                "summary": "Assign role (Admin)",
+                "description": "Assign a role to a user (requires the roles:manage permission)",
+                "tags": ["Roles - Admin"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "User UUID"},
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["role_id"],
+                            "properties": {
+                                "role_id": {"type": "string", "format": "uuid"}
                             }
                         }
-                    },
+                    }
+                ],
+                "responses": {
+                    "201": {"description": "Role assigned"},
+                    "400": {"description": "Invalid data or role already assigned"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Not a participant"},
-                    "404": {"description": "Conversation not found"}
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "User or role not found"}
                 }
             }
         },
-        "/api/v2/chat/conversations/{id}/read": {
-            "put": {
-                "summary": "Mark messages as read",
-                "description": "Mark all messages in a conversation as read",
-                "tags": ["Chat"],
+        "/api/v2/admin/users/{id}/roles/{roleId}": {
+            "delete": {
+                "summary": "Revoke role (Admin)",
+                "description": "Revoke a role from a user. The last super_admin cannot be revoked (requires the roles:manage permission)",
+                "tags": ["Roles - Admin"],
                 "security": [{"Bearer": []}],
                 "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Conversation UUID"}
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "User UUID"},
+                    {"name": "roleId", "in": "path", "required": true, "type": "string", "description": "Role UUID"}
                 ],
                 "responses": {
-                    "200": {"description": "Messages marked as read"},
+                    "200": {"description": "Role revoked"},
+                    "400": {"description": "Cannot revoke the last super admin"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Not a participant"},
-                    "404": {"description": "Conversation not found"}
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "Role not found or not assigned"}
                 }
             }
         },
-        "/api/v2/chat/unread": {
+        "/api/v2/admin/users/verifications": {
             "get": {
-                "summary": "Get unread count",
-                "description": "Get total unread message count for the current user",
-                "tags": ["Chat"],
+                "summary": "Verification review queue (Admin)",
+                "description": "List identity verification submissions awaiting review with the submitting user, oldest first (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
                 "security": [{"Bearer": []}],
                 "produces": ["application/json"],
+                "parameters": [
+                    {"name": "page", "in": "query", "type": "integer", "default": 1},
+                    {"name": "size", "in": "query", "type": "integer", "default": 10},
+                    {"name": "type", "in": "query", "type": "string", "enum": ["DRIVERS_LICENSE", "INTERNATIONAL_PASSPORT", "NATIONAL_ID_CARD", "VOTERS_CARD"]}
+                ],
                 "responses": {
-                    "200": {
-                        "description": "Unread count retrieved",
+                    "200": {"description": "Verification queue retrieved"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            }
+        },
+        "/api/v2/admin/users/{id}/suspend": {
+            "put": {
+                "summary": "Suspend user (Admin)",
+                "description": "Suspend a user for a number of hours, or ban them until lifted when no duration is given. Suspended users are rejected at sign-in and on every authenticated request, and any impersonation of them is ended. Staff and your own account cannot be suspended (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "User UUID"},
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
                         "schema": {
                             "type": "object",
+                            "required": ["reason"],
                             "properties": {
-                                "unread_count": {"type": "integer"}
+                                "reason": {"type": "string", "example": "Repeated spam in job comments"},
+                                "duration_hours": {"type": "integer", "minimum": 1, "maximum": 87600, "description": "Omit for a permanent ban"}
                             }
                         }
-                    },
-                    "401": {"description": "Unauthorized"}
+                    }
+                ],
+                "responses": {
+                    "200": {"description": "User suspended"},
+                    "400": {"description": "Invalid data"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required, or the user is staff or yourself"},
+                    "404": {"description": "User not found"}
+                }
+            },
+            "delete": {
+                "summary": "Lift suspension (Admin)",
+                "description": "Restore a suspended user's access (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "User UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Suspension lifted"},
+                    "400": {"description": "User is not suspended"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "User not found"}
                 }
             }
         },
-        "/api/v2/reviews": {
+        "/api/v2/admin/users/{id}/restore": {
+            "post": {
+                "summary": "Restore deleted user (Admin)",
+                "description": "Bring back an account staff deleted, before its grace period runs out and its data is erased (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "User UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "User restored"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "No deletion by staff is pending for this user (DELETION_NOT_FOUND)"}
+                }
+            }
+        },
+        "/api/v2/admin/users/{id}/verify": {
+            "put": {
+                "summary": "Force-verify user (Admin)",
+                "description": "Mark an account verified without the email OTP (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "User UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "User verified"},
+                    "400": {"description": "User already verified"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "User not found"}
+                }
+            }
+        },
+        "/api/v2/admin/users/{id}/verification/document": {
             "get": {
-                "summary": "Get all reviews",
-                "description": "Retrieve paginated list of reviews",
-                "tags": ["Reviews"],
+                "summary": "View verification document (Admin)",
+                "description": "Stream the document from the user's most recent identity verification submission (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
+                "security": [{"Bearer": []}],
+                "produces": ["image/jpeg", "image/png", "application/pdf"],
                 "parameters": [
-                    {"name": "page", "in": "query", "type": "integer", "description": "Page number"},
-                    {"name": "size", "in": "query", "type": "integer", "description": "Items per page"},
-                    {"name": "rating", "in": "query", "type": "integer", "description": "Filter by rating (0-5)"}
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "User UUID"}
                 ],
                 "responses": {
-                    "200": {"description": "Reviews fetched successfully"}
+                    "200": {"description": "Document content"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "User not found or no submission"}
                 }
-            },
-            "post": {
-                "summary": "Create a review",
-                "description": "Create a new review (one per user)",
-                "tags": ["Reviews"],
+            }
+        },
+        "/api/v2/admin/users/{id}/verification/approve": {
+            "put": {
+                "summary": "Approve verification document (Admin)",
+                "description": "Approve a user's pending identity submission. The document type and number are copied onto the profile, the identity_verified badge is granted and the user is notified (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "User UUID"}
+                ],
+                "responses": {
+                    "200": {"description": "Verification approved"},
+                    "400": {"description": "No pending submission, identity already verified, or the number is linked to another account"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "User not found"}
+                }
+            }
+        },
+        "/api/v2/admin/users/{id}/verification/reject": {
+            "put": {
+                "summary": "Reject verification document (Admin)",
+                "description": "Reject a user's pending identity document with a reason they are notified of (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
                 "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "User UUID"},
                     {
-                        "name": "body",
                         "in": "body",
+                        "name": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "required": ["comment", "rating"],
+                            "required": ["reason"],
                             "properties": {
-                                "comment": {"type": "string", "description": "Review comment"},
-                                "rating": {"type": "integer", "minimum": 0, "maximum": 5, "description": "Rating from 0 to 5"}
+                                "reason": {"type": "string", "example": "Document is expired"}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "200": {"description": "Review created successfully"},
-                    "400": {"description": "Bad request or already submitted a review"},
-                    "401": {"description": "Unauthorized"}
+                    "200": {"description": "Verification rejected"},
+                    "400": {"description": "Invalid data or no pending submission"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "User not found"}
                 }
             }
         },
-        "/api/v2/reviews/me": {
+        "/api/v2/admin/account-recoveries": {
             "get": {
-                "summary": "Get my review",
-                "description": "Get the current user's review",
-                "tags": ["Reviews"],
+                "summary": "Account recovery queue (Admin)",
+                "description": "List 2FA account recoveries with the requesting user, open ones by default, oldest first (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
                 "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "page", "in": "query", "type": "integer", "default": 1},
+                    {"name": "size", "in": "query", "type": "integer", "default": 10},
+                    {"name": "status", "in": "query", "type": "string", "enum": ["PENDING", "APPROVED", "REJECTED", "CANCELLED", "COMPLETED"]}
+                ],
                 "responses": {
-                    "200": {"description": "Review fetched successfully"},
+                    "200": {"description": "Account recoveries retrieved"},
                     "401": {"description": "Unauthorized"},
-                    "404": {"description": "Review not found"}
+                    "403": {"description": "Admin access required"}
                 }
             }
         },
-        "/api/v2/reviews/stats": {
-            "get": {
-                "summary": "Get review statistics",
-                "description": "Get average rating and total review count",
-                "tags": ["Reviews"],
+        "/api/v2/admin/account-recoveries/{id}/approve": {
+            "put": {
+                "summary": "Approve account recovery (Admin)",
+                "description": "Waive the waiting period of a pending recovery once the requester's identity has been confirmed. The user finishes it by signing in with their password (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
+                "security": [{"Bearer": []}],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Recovery UUID"}
+                ],
                 "responses": {
-                    "200": {
-                        "description": "Statistics fetched successfully",
+                    "200": {"description": "Recovery approved"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "No pending recovery with this ID"}
+                }
+            }
+        },
+        "/api/v2/admin/account-recoveries/{id}/reject": {
+            "put": {
+                "summary": "Reject account recovery (Admin)",
+                "description": "Close a pending recovery so it cannot be completed (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "parameters": [
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Recovery UUID"},
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
                         "schema": {
                             "type": "object",
+                            "required": ["reason"],
                             "properties": {
-                                "average_rating": {"type": "number", "description": "Average rating"},
-                                "total_reviews": {"type": "integer", "description": "Total number of reviews"}
+                                "reason": {"type": "string", "example": "Owner reported they did not request it"}
                             }
                         }
                     }
+                ],
+                "responses": {
+                    "200": {"description": "Recovery rejected"},
+                    "400": {"description": "Invalid data"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "No pending recovery with this ID"}
                 }
             }
         },
-        "/api/v2/reviews/{id}": {
-            "get": {
-                "summary": "Get a review",
-                "description": "Get a specific review by ID",
-                "tags": ["Reviews"],
+        "/api/v2/admin/users/{id}/merge": {
+            "post": {
+                "summary": "Merge duplicate account (Admin)",
+                "description": "Fold the source account into this one. Profile content, jobs, applications, chats, notifications and subscriptions move over, empty profile fields are filled from the source, and the source is deleted. Roles are not carried over. Neither account may be staff or the caller's own. OAuth sign-ins through the source's provider then reach this account (requires the users:moderate permission)",
+                "tags": ["Users - Admin"],
+                "security": [{"Bearer": []}],
+                "consumes": ["application/json"],
+                "produces": ["application/json"],
                 "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Review ID"}
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "UUID of the account to keep"},
+                    {
+                        "in": "body",
+                        "name": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "required": ["source_user_id"],
+                            "properties": {
+                                "source_user_id": {"type": "string", "format": "uuid", "description": "Duplicate account to merge and delete"}
+                            }
+                        }
+                    }
                 ],
                 "responses": {
-                    "200": {"description": "Review fetched successfully"},
-                    "404": {"description": "Review not found"}
+                    "200": {"description": "Users merged"},
+                    "400": {"description": "Invalid data or both accounts have an active subscription"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required, merging an account into itself, a staff account or the caller's own account"},
+                    "404": {"description": "User not found"}
                 }
-            },
-            "put": {
-                "summary": "Update a review",
-                "description": "Update your own review",
-                "tags": ["Reviews"],
+            }
+        },
+        "/api/v2/admin/users/{id}/impersonate": {
+            "post": {
+                "summary": "Impersonate user (Admin)",
+                "description": "Open an audited support session and get a short-lived token that authenticates as the user. Staff and your own account cannot be impersonated, and impersonation tokens cannot be refreshed or used to impersonate again. Changing the account itself (password, email, 2FA, passkeys, linked identities, sessions, identity documents, data exports and deletion) is refused with 403 (requires the users:impersonate permission)",
+                "tags": ["Users - Admin"],
                 "security": [{"Bearer": []}],
                 "consumes": ["application/json"],
+                "produces": ["application/json"],
                 "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Review ID"},
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "User UUID"},
                     {
-                        "name": "body",
                         "in": "body",
+                        "name": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
+                            "required": ["reason"],
                             "properties": {
-                                "comment": {"type": "string", "description": "Review comment"},
-                                "rating": {"type": "integer", "minimum": 0, "maximum": 5, "description": "Rating from 0 to 5"}
+                                "reason": {"type": "string", "example": "Ticket #4821: user cannot see their applications"},
+                                "duration_minutes": {"type": "integer", "minimum": 5, "maximum": 120, "default": 30}
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "200": {"description": "Review updated successfully"},
+                    "201": {
+                        "description": "Impersonation started",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "token": {"type": "string"},
+                                "session": {"type": "object"}
+                            }
+                        }
+                    },
+                    "400": {"description": "Invalid data"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Forbidden - can only update your own review"},
-                    "404": {"description": "Review not found"}
+                    "403": {"description": "Admin access required, or the user is staff or yourself"},
+                    "404": {"description": "User not found"}
                 }
-            },
+            }
+        },
+        "/api/v2/admin/impersonations": {
+            "get": {
+                "summary": "List impersonation sessions (Admin)",
+                "description": "Audit trail of impersonation sessions, newest first (requires the users:impersonate permission)",
+                "tags": ["Users - Admin"],
+                "security": [{"Bearer": []}],
+                "produces": ["application/json"],
+                "parameters": [
+                    {"name": "user_id", "in": "query", "type": "string", "description": "Impersonated user UUID"},
+                    {"name": "admin_id", "in": "query", "type": "string", "description": "Admin UUID"},
+                    {"name": "active_only", "in": "query", "type": "boolean"},
+                    {"name": "page", "in": "query", "type": "integer", "default": 1},
+                    {"name": "size", "in": "query", "type": "integer", "default": 10}
+                ],
+                "responses": {
+                    "200": {"description": "Impersonation sessions retrieved"},
+                    "401": {"description": "Unauthorized"},
+                    "403": {"description": "Admin access required"}
+                }
+            }
+        },
+        "/api/v2/admin/impersonations/{id}": {
             "delete": {
-                "summary": "Delete a review",
-                "description": "Delete your own review",
-                "tags": ["Reviews"],
+                "summary": "End impersonation (Admin)",
+                "description": "End an impersonation session early; its token stops working immediately (requires the users:impersonate permission)",
+                "tags": ["Users - Admin"],
                 "security": [{"Bearer": []}],
                 "parameters": [
-                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Review ID"}
+                    {"name": "id", "in": "path", "required": true, "type": "string", "description": "Impersonation session UUID"}
                 ],
                 "responses": {
-                    "200": {"description": "Review deleted successfully"},
+                    "200": {"description": "Impersonation ended"},
+                    "400": {"description": "Session already ended"},
                     "401": {"description": "Unauthorized"},
-                    "403": {"description": "Forbidden - can only delete your own review"},
-                    "404": {"description": "Review not found"}
+                    "403": {"description": "Admin access required"},
+                    "404": {"description": "Session not found"}
                 }
             }
         }
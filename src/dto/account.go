@@ -0,0 +1,53 @@
+package dto
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password"` // Required when the account has a password
+}
+
+type ConfirmEmailChangeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RevertEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type StartRecoveryRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"` // From a password sign-in that asked for 2FA
+}
+
+type VerifyRecoveryRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type CompleteRecoveryRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type CancelRecoveryRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type RecoveryQueueParams struct {
+	Pagination
+	Status string `json:"status" form:"status" binding:"omitempty,oneof=PENDING APPROVED REJECTED CANCELLED COMPLETED"`
+}
+
+type RejectRecoveryRequest struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
+
+type RequestDeletionRequest struct {
+	Password string `json:"password"` // Required when the account has a password
+}
+
+type ConfirmDeletionRequest struct {
+	Code   string  `json:"code" binding:"required"`
+	Reason *string `json:"reason" binding:"omitempty,max=1000"`
+}
+
+type CancelDeletionRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -167,6 +167,7 @@ type ApplicantInfo struct {
 	AppliedDate  time.Time `json:"applied_date"`
 	JobTitle     string    `json:"job_title"`
 	Status       string    `json:"status"`
+	IdentityVerified bool  `json:"identity_verified"`
 }
 
 // Talent Analytics
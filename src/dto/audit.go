@@ -0,0 +1,12 @@
+package dto
+
+type AuditLogQueryParams struct {
+	Pagination
+	ActorID    string `json:"actor_id" form:"actor_id" binding:"omitempty,uuid"`
+	Action     string `json:"action" form:"action"` // Exact action, or a category prefix such as "admin.*"
+	EntityType string `json:"entity_type" form:"entity_type"`
+	EntityID   string `json:"entity_id" form:"entity_id"`
+	IPAddress  string `json:"ip_address" form:"ip_address" binding:"omitempty,ip"`
+	StartDate  string `json:"start_date" form:"start_date" binding:"omitempty,datetime=2006-01-02"`
+	EndDate    string `json:"end_date" form:"end_date" binding:"omitempty,datetime=2006-01-02"`
+}
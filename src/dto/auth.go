@@ -8,11 +8,12 @@ type OAuthCallbackDto struct {
 }
 
 type OAuthUserDto struct {
-	ID       string `json:"id"`
-	Email    string `json:"email"`
-	Name     string `json:"name"`
-	Avatar   string `json:"avatar"`
-	Provider string `json:"provider"`
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Avatar        string `json:"avatar"`
+	Provider      string `json:"provider"`
 }
 
 type SigninDto struct {
@@ -51,9 +52,8 @@ type ChangePasswordDto struct {
 }
 
 type VerificationDto struct {
-	VerificationNumber   *string                  `json:"verification_number,omitempty"`
-	VerificationType     *models.VerificationType `json:"verification_type,omitempty"`
-	VerificationDocument *string                  `json:"verification_document,omitempty"`
+	Email string `json:"email" binding:"required,email"`
+	Otp   string `json:"otp" binding:"required"`
 }
 
 type ForgotPasswordDto struct {
@@ -61,7 +61,6 @@ type ForgotPasswordDto struct {
 }
 
 type ResetPasswordDto struct {
-	CurrentPassword string `json:"current_password"`
-	NewPassword     string `json:"new_password"`
-	Token           string `json:"token"`
+	NewPassword string `json:"new_password" binding:"required"`
+	Token       string `json:"token" binding:"required"`
 }
@@ -8,7 +8,7 @@ import (
 type MediaDto struct {
 	ID        string           `json:"id,omitempty"`
 	Type      models.MediaType `json:"type" binding:"required,oneof=IMAGE VIDEO AUDIO DOCUMENT FILE"`
-	URL       string           `json:"url" binding:"required,url"`
+	URL       string           `json:"url" binding:"omitempty,url"`
 	FileName  string           `json:"file_name,omitempty"`
 	FileSize  int64            `json:"file_size,omitempty"`
 	MimeType  string           `json:"mime_type,omitempty"`
@@ -19,17 +19,51 @@ type MediaDto struct {
 }
 
 type SendMessageDto struct {
-	RecipientID string     `json:"recipient_id" binding:"required,uuid"`
-	Content     string     `json:"content" binding:"max=5000"`
-	Media       []MediaDto `json:"media,omitempty" binding:"max=10,dive"`
+	RecipientID    string     `json:"recipient_id" binding:"omitempty,uuid"`
+	ConversationID string     `json:"conversation_id" binding:"omitempty,uuid"`
+	ReplyToID      string     `json:"reply_to_id" binding:"omitempty,uuid"`
+	Content        string     `json:"content" binding:"max=5000"`
+	Media          []MediaDto `json:"media,omitempty" binding:"max=10,dive"`
+}
+
+type EditMessageDto struct {
+	Content string `json:"content" binding:"required,max=5000"`
+}
+
+type ReactionDto struct {
+	Emoji string `json:"emoji" binding:"required,max=32"`
 }
 
 type WebSocketSendMessageDto struct {
-	Action      string     `json:"action"` // "send_message", "typing", "stop_typing", "mark_read"
-	RecipientID string     `json:"recipient_id,omitempty"`
-	Content     string     `json:"content,omitempty"`
-	Media       []MediaDto `json:"media,omitempty"`
-	MessageID   string     `json:"message_id,omitempty"`
+	RecipientID    string     `json:"recipient_id,omitempty"`
+	ConversationID string     `json:"conversation_id,omitempty"`
+	ReplyToID      string     `json:"reply_to_id,omitempty"`
+	Content        string     `json:"content,omitempty"`
+	Media          []MediaDto `json:"media,omitempty"`
+}
+
+type WebSocketEditMessageDto struct {
+	MessageID string `json:"message_id"`
+	Content   string `json:"content"`
+}
+
+type WebSocketDeleteMessageDto struct {
+	MessageID   string `json:"message_id"`
+	ForEveryone bool   `json:"for_everyone,omitempty"`
+}
+
+type WebSocketReactionDto struct {
+	MessageID string `json:"message_id"`
+	Emoji     string `json:"emoji"`
+}
+
+type WebSocketTypingDto struct {
+	RecipientID    string `json:"recipient_id,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+type WebSocketMarkMessagesReadDto struct {
+	ConversationID string `json:"conversation_id"`
 }
 
 type MessageResponse struct {
@@ -37,16 +71,33 @@ type MessageResponse struct {
 	ConversationID string               `json:"conversation_id"`
 	SenderID       string               `json:"sender_id"`
 	Sender         *UserSummary         `json:"sender,omitempty"`
-	RecipientID    string               `json:"recipient_id"`
-	Recipient      *UserSummary         `json:"recipient,omitempty"`
+	RecipientID    string               `json:"recipient_id,omitempty"`
 	Content        string               `json:"content"`
 	Media          []MediaDto           `json:"media,omitempty"`
+	ReplyTo        *MessagePreview      `json:"reply_to,omitempty"`
+	Reactions      []ReactionSummary    `json:"reactions,omitempty"`
 	Status         models.MessageStatus `json:"status"`
 	ReadAt         *time.Time           `json:"read_at,omitempty"`
-	DeliveredAt    *time.Time           `json:"delivered_at,omitempty"`
+	ReadBy         []string             `json:"read_by,omitempty"`
+	IsDeleted      bool                 `json:"is_deleted"`
+	EditedAt       *time.Time           `json:"edited_at,omitempty"`
 	CreatedAt      time.Time            `json:"created_at"`
 }
 
+type MessagePreview struct {
+	ID        string `json:"id"`
+	SenderID  string `json:"sender_id"`
+	Content   string `json:"content"`
+	HasMedia  bool   `json:"has_media"`
+	IsDeleted bool   `json:"is_deleted"`
+}
+
+type ReactionSummary struct {
+	Emoji   string   `json:"emoji"`
+	Count   int      `json:"count"`
+	UserIDs []string `json:"user_ids"`
+}
+
 type UserSummary struct {
 	ID       string  `json:"id"`
 	Name     string  `json:"name"`
@@ -54,13 +105,109 @@ type UserSummary struct {
 	Image    *string `json:"image,omitempty"`
 }
 
+type PresenceResponse struct {
+	UserID     string                `json:"user_id"`
+	Status     models.PresenceStatus `json:"status"`
+	LastSeenAt *time.Time            `json:"last_seen_at,omitempty"`
+}
+
+type UpdatePresenceVisibilityDto struct {
+	Hidden *bool `json:"hidden" binding:"required"`
+}
+
+type ParticipantResponse struct {
+	User       UserSummary            `json:"user"`
+	Role       models.ParticipantRole `json:"role"`
+	LastReadAt *time.Time             `json:"last_read_at,omitempty"`
+	JoinedAt   time.Time              `json:"joined_at"`
+}
+
 type ConversationResponse struct {
-	ID          string           `json:"id"`
-	OtherUser   *UserSummary     `json:"other_user"`
-	LastMessage *MessageResponse `json:"last_message,omitempty"`
-	UnreadCount int              `json:"unread_count"`
-	CreatedAt   time.Time        `json:"created_at"`
-	UpdatedAt   time.Time        `json:"updated_at"`
+	ID           string                  `json:"id"`
+	Type         models.ConversationType `json:"type"`
+	Name         *string                 `json:"name,omitempty"`
+	OtherUser    *UserSummary            `json:"other_user,omitempty"`
+	Participants []ParticipantResponse   `json:"participants"`
+	Presence     *PresenceResponse       `json:"presence,omitempty"`
+	LastMessage  *MessageResponse        `json:"last_message,omitempty"`
+	UnreadCount  int                     `json:"unread_count"`
+	IsMuted      bool                    `json:"is_muted"`
+	MutedUntil   *time.Time              `json:"muted_until,omitempty"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+}
+
+type CreateGroupConversationDto struct {
+	Name           string   `json:"name" binding:"required,min=1,max=100"`
+	ParticipantIDs []string `json:"participant_ids" binding:"required,min=1,max=49,dive,uuid"`
+}
+
+type AddParticipantsDto struct {
+	UserIDs []string `json:"user_ids" binding:"required,min=1,max=49,dive,uuid"`
+}
+
+type RenameConversationDto struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+// MuteConversationDto mutes notifications for a conversation. Omitting the duration mutes it until unmuted.
+type MuteConversationDto struct {
+	DurationMinutes *int `json:"duration_minutes" binding:"omitempty,min=1,max=525600"`
+}
+
+type BlockUserDto struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+}
+
+type BlockedUserResponse struct {
+	User      UserSummary `json:"user"`
+	BlockedAt time.Time   `json:"blocked_at"`
+}
+
+// ReportUserDto reports a user's chat behaviour. When no message IDs are given, the user's most recent
+// messages in the conversation are attached to the report instead.
+type ReportUserDto struct {
+	UserID         string                  `json:"user_id" binding:"required,uuid"`
+	ConversationID string                  `json:"conversation_id" binding:"omitempty,uuid"`
+	MessageIDs     []string                `json:"message_ids" binding:"omitempty,max=50,dive,uuid"`
+	Reason         models.ChatReportReason `json:"reason" binding:"required,oneof=SPAM HARASSMENT INAPPROPRIATE_CONTENT SCAM OTHER"`
+	Details        string                  `json:"details" binding:"max=1000"`
+	Block          bool                    `json:"block"`
+}
+
+type ResolveChatReportDto struct {
+	Action models.ChatReportAction `json:"action" binding:"required,oneof=DISMISS WARN BAN"`
+	Note   string                  `json:"note" binding:"max=1000"`
+}
+
+type ChatReportQueryParams struct {
+	Status models.ChatReportStatus `json:"status" form:"status" binding:"omitempty,oneof=PENDING RESOLVED DISMISSED"`
+	Page   int                     `json:"page" form:"page"`
+	Limit  int                     `json:"limit" form:"limit"`
+}
+
+type ChatReportResponse struct {
+	ID             string                     `json:"id"`
+	Reporter       UserSummary                `json:"reporter"`
+	ReportedUser   UserSummary                `json:"reported_user"`
+	ConversationID *string                    `json:"conversation_id,omitempty"`
+	Reason         models.ChatReportReason    `json:"reason"`
+	Details        *string                    `json:"details,omitempty"`
+	Status         models.ChatReportStatus    `json:"status"`
+	Messages       []models.ChatReportMessage `json:"messages"`
+	Action         *models.ChatReportAction   `json:"action,omitempty"`
+	ResolutionNote *string                    `json:"resolution_note,omitempty"`
+	ResolvedByID   *string                    `json:"resolved_by_id,omitempty"`
+	ResolvedAt     *time.Time                 `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time                  `json:"created_at"`
+}
+
+type ChatReportListResponse struct {
+	Data       []ChatReportResponse `json:"data"`
+	TotalItems int                  `json:"total_items"`
+	TotalPages int                  `json:"total_pages"`
+	Page       int                  `json:"page"`
+	Limit      int                  `json:"limit"`
 }
 
 type ConversationListResponse struct {
@@ -72,11 +219,43 @@ type ConversationListResponse struct {
 }
 
 type MessageListResponse struct {
-	Data       []MessageResponse `json:"data"`
-	TotalItems int               `json:"total_items"`
-	TotalPages int               `json:"total_pages"`
-	Page       int               `json:"page"`
-	Limit      int               `json:"limit"`
+	Data        []MessageResponse `json:"data"`
+	TotalItems  int               `json:"total_items"`
+	TotalPages  int               `json:"total_pages"`
+	Page        int               `json:"page"`
+	Limit       int               `json:"limit"`
+	HasOlder    bool              `json:"has_older"`
+	HasNewer    bool              `json:"has_newer"`
+	OlderCursor string            `json:"older_cursor,omitempty"` // Pass as "before" to load older messages
+	NewerCursor string            `json:"newer_cursor,omitempty"` // Pass as "after" to load newer messages
+}
+
+type ConversationSummary struct {
+	ID        string                  `json:"id"`
+	Type      models.ConversationType `json:"type"`
+	Name      *string                 `json:"name,omitempty"`
+	OtherUser *UserSummary            `json:"other_user,omitempty"`
+}
+
+type MessageSearchParams struct {
+	Query string `json:"q" form:"q" binding:"required,min=2,max=200"`
+	Page  int    `json:"page" form:"page"`
+	Limit int    `json:"limit" form:"limit"`
+}
+
+type MessageSearchResult struct {
+	Message      MessageResponse     `json:"message"`
+	Conversation ConversationSummary `json:"conversation"`
+	Before       []MessageResponse   `json:"before"` // Preceding messages, oldest first
+	After        []MessageResponse   `json:"after"`  // Following messages, oldest first
+}
+
+type MessageSearchResponse struct {
+	Data       []MessageSearchResult `json:"data"`
+	TotalItems int                   `json:"total_items"`
+	TotalPages int                   `json:"total_pages"`
+	Page       int                   `json:"page"`
+	Limit      int                   `json:"limit"`
 }
 
 type ChatQueryParams struct {
@@ -84,6 +263,7 @@ type ChatQueryParams struct {
 	Limit  int    `json:"limit" form:"limit"`
 	Before string `json:"before" form:"before"` // Message ID to fetch messages before
 	After  string `json:"after" form:"after"`   // Message ID to fetch messages after
+	Around string `json:"around" form:"around"` // Message ID to fetch a context window around
 }
 
 type WebSocketMessageEvent struct {
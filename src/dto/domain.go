@@ -19,6 +19,7 @@ type DomainResponse struct {
 	CustomDomainStatus     models.DomainStatus  `json:"custom_domain_status,omitempty"`
 	CustomDomainVerifiedAt *time.Time           `json:"custom_domain_verified_at,omitempty"`
 	DnsRecords             []models.DnsRecord   `json:"dns_records,omitempty"`
+	Certificate            *models.DomainCertificate `json:"certificate,omitempty"`
 	CanUseCustomDomain     bool                 `json:"can_use_custom_domain"`
 }
 
@@ -0,0 +1,18 @@
+package dto
+
+import "foglio/v2/src/models"
+
+// SubmitIdentityVerificationDto is bound from the multipart form; the document itself is read from the "document" file field.
+type SubmitIdentityVerificationDto struct {
+	Type   models.VerificationType `form:"type" binding:"required,oneof=DRIVERS_LICENSE INTERNATIONAL_PASSPORT NATIONAL_ID_CARD VOTERS_CARD"`
+	Number string                  `form:"number" binding:"required,max=40"`
+}
+
+type RejectVerificationDto struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
+
+type VerificationQueueParams struct {
+	Pagination
+	Type string `json:"type" form:"type" binding:"omitempty,oneof=DRIVERS_LICENSE INTERNATIONAL_PASSPORT NATIONAL_ID_CARD VOTERS_CARD"`
+}
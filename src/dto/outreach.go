@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"foglio/v2/src/models"
+	"time"
+)
+
+type CreateMessageTemplateDto struct {
+	Name    string `json:"name" binding:"required,min=1,max=100"`
+	Content string `json:"content" binding:"required,min=1,max=5000"`
+}
+
+type UpdateMessageTemplateDto struct {
+	Name    *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Content *string `json:"content" binding:"omitempty,min=1,max=5000"`
+}
+
+// TemplateData holds the variables available to message templates.
+type TemplateData struct {
+	CandidateName      string
+	CandidateFirstName string
+	JobTitle           string
+	CompanyName        string
+	RecruiterName      string
+}
+
+type PreviewMessageTemplateDto struct {
+	ApplicationID string `json:"application_id" binding:"required,uuid"`
+}
+
+type TemplatePreviewResponse struct {
+	Content string `json:"content"`
+}
+
+// BulkSendDto messages every applicant to a job matching the filters. Exactly one of template_id or
+// content must be given; content is treated as a template too.
+type BulkSendDto struct {
+	TemplateID     string  `json:"template_id" binding:"omitempty,uuid"`
+	Content        string  `json:"content" binding:"max=5000"`
+	Status         *string `json:"status"`
+	SubmissionDate *string `json:"submission_date"`
+}
+
+type BulkSendResult struct {
+	ApplicationID string                `json:"application_id"`
+	RecipientID   string                `json:"recipient_id"`
+	Status        models.OutreachStatus `json:"status"`
+	MessageID     *string               `json:"message_id,omitempty"`
+	Error         *string               `json:"error,omitempty"`
+}
+
+type BulkSendResponse struct {
+	Total   int              `json:"total"`
+	Sent    int              `json:"sent"`
+	Failed  int              `json:"failed"`
+	Skipped int              `json:"skipped"`
+	Quota   OutreachQuota    `json:"quota"`
+	Results []BulkSendResult `json:"results"`
+}
+
+type OutreachQuota struct {
+	Limit     int       `json:"limit"`
+	Used      int       `json:"used"`
+	Remaining int       `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
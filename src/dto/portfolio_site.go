@@ -0,0 +1,104 @@
+package dto
+
+// PortfolioSite is everything a site template needs to render a published portfolio. Text is plain and is
+// escaped by the template; absent values are empty.
+type PortfolioSite struct {
+	Template    string
+	Title       string
+	Description string
+	Keywords    string
+	Canonical   string
+	Image       string
+	Name        string
+	Headline    string
+	Tagline     string
+	Bio         string
+	Location    string
+	Avatar      string
+	CoverImage  string
+	Logo        string
+	Theme       SiteTheme
+	CustomCSS   string
+	Sections    []SiteSection
+	Projects    []SiteProject
+	Experiences []SiteExperience
+	Education   []SiteEducation
+	Certs       []SiteCertification
+	Languages   []SiteLanguage
+	Skills      []string
+	Links       []SiteLink
+	JSONLD      map[string]interface{} // schema.org Person markup
+	Year        int
+}
+
+type SiteTheme struct {
+	PrimaryColor    string
+	SecondaryColor  string
+	AccentColor     string
+	TextColor       string
+	BackgroundColor string
+	FontFamily      string
+	FontSize        string
+}
+
+type SiteSection struct {
+	Anchor  string
+	Type    string // hero, about, projects, experience, education, skills, certifications, languages, contact, custom
+	Title   string
+	Content string
+}
+
+type SiteProject struct {
+	Title       string
+	Description string
+	Image       string
+	URL         string
+	Period      string
+	Stack       []string
+	Highlights  []string
+}
+
+type SiteExperience struct {
+	Role         string
+	Company      string
+	Location     string
+	Period       string
+	Description  string
+	Highlights   []string
+	Technologies []string
+}
+
+type SiteEducation struct {
+	Degree      string
+	Field       string
+	Institution string
+	Location    string
+	Period      string
+	Highlights  []string
+}
+
+type SiteCertification struct {
+	Name   string
+	Issuer string
+	Issued string
+	URL    string
+}
+
+type SiteLanguage struct {
+	Name        string
+	Proficiency string
+}
+
+type SiteLink struct {
+	Label string
+	URL   string
+}
+
+// SiteError is shown in place of a portfolio that does not exist, is not published or failed to render.
+type SiteError struct {
+	Status    int
+	Title     string
+	Message   string
+	ClientUrl string
+	Year      int
+}
@@ -0,0 +1,25 @@
+package dto
+
+import "foglio/v2/src/models"
+
+type CreateRoleDto struct {
+	Name        string   `json:"name" binding:"required,min=2,max=50"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	Permissions []string `json:"permissions" binding:"required,min=1,dive,required"`
+}
+
+type UpdateRoleDto struct {
+	Name        *string  `json:"name" binding:"omitempty,min=2,max=50"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	Permissions []string `json:"permissions" binding:"omitempty,min=1,dive,required"`
+}
+
+type AssignRoleDto struct {
+	RoleID string `json:"role_id" binding:"required,uuid"`
+}
+
+// AccessResponse describes the roles a user holds and the permissions they grant, with the wildcard expanded.
+type AccessResponse struct {
+	Roles       []string            `json:"roles"`
+	Permissions []models.Permission `json:"permissions"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+import "foglio/v2/src/models"
+
+type RefreshTokenDto struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// SessionClient identifies the device a session is signed in from.
+type SessionClient struct {
+	UserAgent          string
+	IPAddress          string
+	TrustedDeviceToken string // From the trusted device cookie, if the browser sent one
+}
+
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Seconds until the access token expires
+}
+
+type SessionResponse struct {
+	models.Session
+	Current bool `json:"current"`
+}
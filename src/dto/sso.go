@@ -0,0 +1,38 @@
+package dto
+
+import "foglio/v2/src/models"
+
+// UpdateSSOConfigRequest sets up a company's SAML IdP. The IdP metadata is given either as the XML document
+// or as a URL to fetch it from; omitted fields are left as they are.
+type UpdateSSOConfigRequest struct {
+	IdPMetadata       string `json:"idp_metadata"`
+	IdPMetadataURL    string `json:"idp_metadata_url" binding:"omitempty,url"`
+	Enabled           *bool  `json:"enabled"`
+	EnforceSSO        *bool  `json:"enforce_sso"`
+	AllowIdPInitiated *bool  `json:"allow_idp_initiated"`
+}
+
+// SSOConfigResponse is the company's SSO setup along with the service provider details to enter at the IdP.
+type SSOConfigResponse struct {
+	models.CompanySSO
+	SPEntityID  string `json:"sp_entity_id"`
+	ACSURL      string `json:"acs_url"`
+	MetadataURL string `json:"metadata_url"`
+}
+
+type AddSSODomainRequest struct {
+	Domain string `json:"domain" binding:"required,fqdn"`
+}
+
+type SSOLoginRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type SSOLoginResponse struct {
+	URL string `json:"url"` // The IdP sign-in page to send the browser to
+}
+
+// SSOExchangeRequest redeems the one-time code the client was redirected back with after signing in at the IdP.
+type SSOExchangeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
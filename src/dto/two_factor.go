@@ -5,8 +5,9 @@ type Verify2FASetupRequest struct {
 }
 
 type Verify2FALoginRequest struct {
-	UserID string `json:"user_id" binding:"required"`
-	Code   string `json:"code" binding:"required"`
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+	RememberDevice bool   `json:"remember_device"` // Skip 2FA on this browser for 30 days
 }
 
 type Disable2FARequest struct {
@@ -21,13 +22,14 @@ type Enable2FAResponse struct {
 
 type TwoFactorRequiredResponse struct {
 	RequiresTwoFactor bool   `json:"requires_two_factor"`
-	UserID            string `json:"user_id"`
+	ChallengeToken    string `json:"challenge_token"`
 	Message           string `json:"message"`
 }
 
 type TwoFactorStatusResponse struct {
 	Enabled         bool `json:"enabled"`
 	BackupCodesLeft int  `json:"backup_codes_left"`
+	Passkeys        int  `json:"passkeys"` // Registered passkeys, usable in place of a TOTP code
 }
 
 type BackupCodesResponse struct {
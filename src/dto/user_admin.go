@@ -0,0 +1,47 @@
+package dto
+
+import "foglio/v2/src/models"
+
+// SuspendUserDto suspends a user's access. Omitting the duration bans the user until the suspension is lifted.
+type SuspendUserDto struct {
+	Reason        string `json:"reason" binding:"required,min=3,max=500"`
+	DurationHours *int   `json:"duration_hours" binding:"omitempty,min=1,max=87600"`
+}
+
+type ImpersonateUserDto struct {
+	Reason          string `json:"reason" binding:"required,min=3,max=500"`
+	DurationMinutes int    `json:"duration_minutes" binding:"omitempty,min=5,max=120"`
+}
+
+type ImpersonationResponse struct {
+	Token   string                      `json:"token"`
+	Session models.ImpersonationSession `json:"session"`
+}
+
+type ImpersonationQueryParams struct {
+	Pagination
+	UserID     string `json:"user_id" form:"user_id" binding:"omitempty,uuid"`
+	AdminID    string `json:"admin_id" form:"admin_id" binding:"omitempty,uuid"`
+	ActiveOnly bool   `json:"active_only" form:"active_only"`
+}
+
+// MergeUsersDto folds the source account into the account named in the route. The source is deleted afterwards.
+type MergeUsersDto struct {
+	SourceUserID string `json:"source_user_id" binding:"required,uuid"`
+}
+
+// ModeratedUserResponse is a user as moderators see them, including the moderation notes hidden from
+// every other view of the account.
+type ModeratedUserResponse struct {
+	models.User
+	SuspensionReason *string `json:"suspension_reason,omitempty"`
+	VerificationNote *string `json:"verification_note,omitempty"`
+}
+
+func NewModeratedUserResponse(user *models.User) ModeratedUserResponse {
+	return ModeratedUserResponse{
+		User:             *user,
+		SuspensionReason: user.SuspensionReason,
+		VerificationNote: user.VerificationNote,
+	}
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "encoding/json"
+
+// WebAuthnOptions starts a ceremony. Options is passed to navigator.credentials.create or .get and the
+// ceremony ID is sent back with the browser's response.
+type WebAuthnOptions struct {
+	CeremonyID string      `json:"ceremony_id"`
+	Options    interface{} `json:"options"`
+}
+
+type RegisterPasskeyRequest struct {
+	CeremonyID string          `json:"ceremony_id" binding:"required"`
+	Name       string          `json:"name" binding:"required,max=64"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"` // PublicKeyCredential from navigator.credentials.create
+}
+
+type PasskeyLoginRequest struct {
+	CeremonyID string          `json:"ceremony_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"` // PublicKeyCredential from navigator.credentials.get
+}
+
+type Begin2FAPasskeyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type Verify2FAPasskeyRequest struct {
+	ChallengeToken string          `json:"challenge_token" binding:"required"`
+	CeremonyID     string          `json:"ceremony_id" binding:"required"`
+	Credential     json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+	RememberDevice bool            `json:"remember_device"` // Skip 2FA on this browser for 30 days
+}
+
+type RenamePasskeyRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountDeletionHandler struct {
+	service *services.AccountDeletionService
+}
+
+func NewAccountDeletionHandler() *AccountDeletionHandler {
+	return &AccountDeletionHandler{
+		service: services.NewAccountDeletionService(database.GetDatabase()),
+	}
+}
+
+// RequestDeletion godoc
+// @Summary Delete account
+// @Description Email a code to confirm the deletion of the account. Accounts with a password have to give it.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.RequestDeletionRequest true "Current password"
+// @Success 200 {object} lib.Response
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /me/deletion [post]
+func (h *AccountDeletionHandler) RequestDeletion() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.RequestDeletionRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if err := h.service.RequestDeletion(userID, payload); err != nil {
+			handleAccountDeletionError(ctx, err, "Failed to request account deletion: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditDeletionCodeSent,
+			EntityType: "user",
+			EntityID:   userID,
+		})
+
+		lib.Success(ctx, "A confirmation code has been sent to your email", nil)
+	}
+}
+
+// ConfirmDeletion godoc
+// @Summary Confirm account deletion
+// @Description Schedule the deletion with the emailed code. The account keeps working until the grace period is over and is emailed a link to cancel it.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ConfirmDeletionRequest true "Confirmation code and optional reason"
+// @Success 201 {object} models.AccountDeletion
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /me/deletion/confirm [post]
+func (h *AccountDeletionHandler) ConfirmDeletion() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.ConfirmDeletionRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		deletion, err := h.service.ConfirmDeletion(userID, payload)
+		if err != nil {
+			handleAccountDeletionError(ctx, err, "Failed to schedule account deletion: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditDeletionScheduled,
+			EntityType: "account_deletion",
+			EntityID:   deletion.ID.String(),
+			Metadata:   map[string]interface{}{"scheduled_for": deletion.ScheduledFor},
+		})
+
+		lib.Created(ctx, "Your account is scheduled for deletion", deletion)
+	}
+}
+
+// GetDeletion godoc
+// @Summary Get scheduled account deletion
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.AccountDeletion
+// @Failure 401 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /me/deletion [get]
+func (h *AccountDeletionHandler) GetDeletion() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		deletion, err := h.service.GetDeletion(userID)
+		if err != nil {
+			handleAccountDeletionError(ctx, err, "Failed to get account deletion: ")
+			return
+		}
+
+		lib.Success(ctx, "Account deletion retrieved successfully", deletion)
+	}
+}
+
+// CancelDeletion godoc
+// @Summary Cancel account deletion
+// @Description Keep the account while its deletion is still scheduled
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.AccountDeletion
+// @Failure 401 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /me/deletion [delete]
+func (h *AccountDeletionHandler) CancelDeletion() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		deletion, err := h.service.CancelDeletion(userID)
+		if err != nil {
+			handleAccountDeletionError(ctx, err, "Failed to cancel account deletion: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditDeletionCancelled,
+			EntityType: "account_deletion",
+			EntityID:   deletion.ID.String(),
+		})
+
+		lib.Success(ctx, "Account deletion cancelled", deletion)
+	}
+}
+
+// CancelDeletionWithToken godoc
+// @Summary Cancel account deletion by link
+// @Description Keep the account with the link emailed when its deletion was scheduled
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.CancelDeletionRequest true "Cancel token"
+// @Success 200 {object} models.AccountDeletion
+// @Failure 400 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /auth/deletion/cancel [post]
+func (h *AccountDeletionHandler) CancelDeletionWithToken() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.CancelDeletionRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		deletion, err := h.service.CancelDeletionWithToken(payload.Token)
+		if err != nil {
+			handleAccountDeletionError(ctx, err, "Failed to cancel account deletion: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			ActorID:    deletion.UserID.String(),
+			Action:     models.AuditDeletionCancelled,
+			EntityType: "account_deletion",
+			EntityID:   deletion.ID.String(),
+			Metadata:   map[string]interface{}{"method": "link"},
+		})
+
+		lib.Success(ctx, "Account deletion cancelled", deletion)
+	}
+}
+
+func handleAccountDeletionError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrIncorrectPassword):
+		lib.BadRequest(ctx, err.Error(), "INCORRECT_PASSWORD")
+	case errors.Is(err, services.ErrDeletionPending):
+		lib.BadRequest(ctx, err.Error(), "DELETION_PENDING")
+	case errors.Is(err, services.ErrDeletionNotFound):
+		lib.NotFound(ctx, err.Error(), "DELETION_NOT_FOUND")
+	case errors.Is(err, services.ErrUserNotFound):
+		lib.NotFound(ctx, err.Error(), "USER_NOT_FOUND")
+	default:
+		handleVerificationTokenError(ctx, err, prefix)
+	}
+}
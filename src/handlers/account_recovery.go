@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountRecoveryHandler struct {
+	service     *services.AccountRecoveryService
+	authService *services.AuthService
+}
+
+func NewAccountRecoveryHandler() *AccountRecoveryHandler {
+	db := database.GetDatabase()
+	return &AccountRecoveryHandler{
+		service:     services.NewAccountRecoveryService(db),
+		authService: services.NewAuthService(db),
+	}
+}
+
+// StartRecovery godoc
+// @Summary Start account recovery
+// @Description For users who lost their authenticator and backup codes: email a code to the account, using the challenge token from a password sign-in
+// @Tags 2FA
+// @Accept json
+// @Produce json
+// @Param request body dto.StartRecoveryRequest true "Challenge token"
+// @Success 200 {object} lib.Response
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /auth/2fa/recovery [post]
+func (h *AccountRecoveryHandler) StartRecovery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.StartRecoveryRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		user, err := h.service.Start(payload.ChallengeToken)
+		if err != nil {
+			handleRecoveryError(ctx, err, "Failed to start account recovery: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			ActorID:    user.ID.String(),
+			Action:     models.AuditRecoveryCodeSent,
+			EntityType: "user",
+			EntityID:   user.ID.String(),
+		})
+
+		lib.Success(ctx, "A recovery code has been sent to your email", nil)
+	}
+}
+
+// VerifyRecovery godoc
+// @Summary Request account recovery
+// @Description Confirm the emailed code to open the recovery. 2FA can be turned off once the waiting period is over, and the account is emailed a link to cancel it until then.
+// @Tags 2FA
+// @Accept json
+// @Produce json
+// @Param request body dto.VerifyRecoveryRequest true "Challenge token and recovery code"
+// @Success 201 {object} models.AccountRecovery
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /auth/2fa/recovery/verify [post]
+func (h *AccountRecoveryHandler) VerifyRecovery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.VerifyRecoveryRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		recovery, err := h.service.Verify(payload, sessionClient(ctx))
+		if err != nil {
+			handleRecoveryError(ctx, err, "Failed to request account recovery: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			ActorID:    recovery.UserID.String(),
+			Action:     models.AuditRecoveryRequested,
+			EntityType: "account_recovery",
+			EntityID:   recovery.ID.String(),
+			Metadata:   map[string]interface{}{"available_at": recovery.AvailableAt},
+		})
+
+		lib.Created(ctx, "Account recovery requested", recovery)
+	}
+}
+
+// CompleteRecovery godoc
+// @Summary Complete account recovery
+// @Description Once the waiting period is over or an admin approved the recovery, turn off 2FA, sign out every other session and sign in. Needs a fresh challenge token from a password sign-in.
+// @Tags 2FA
+// @Accept json
+// @Produce json
+// @Param request body dto.CompleteRecoveryRequest true "Challenge token"
+// @Success 200 {object} services.SigninResponse
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /auth/2fa/recovery/complete [post]
+func (h *AccountRecoveryHandler) CompleteRecovery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.CompleteRecoveryRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		user, recovery, err := h.service.Complete(payload.ChallengeToken)
+		if err != nil {
+			if errors.Is(err, services.ErrRecoveryNotReady) {
+				lib.BadRequest(ctx, err.Error()+", try again after "+recovery.AvailableAt.UTC().Format(time.RFC1123), "RECOVERY_NOT_READY")
+				return
+			}
+			handleRecoveryError(ctx, err, "Failed to complete account recovery: ")
+			return
+		}
+
+		setAuthCookie(ctx, trustedDeviceCookie, "", -1)
+
+		recordAudit(ctx, services.AuditEntry{
+			ActorID:    user.ID.String(),
+			Action:     models.AuditRecoveryCompleted,
+			EntityType: "account_recovery",
+			EntityID:   recovery.ID.String(),
+			Metadata:   map[string]interface{}{"approved": recovery.ReviewedAt != nil},
+		})
+
+		response, err := h.authService.CreateSession(user, sessionClient(ctx))
+		if err != nil {
+			lib.InternalServerError(ctx, "Failed to generate token")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			ActorID:    user.ID.String(),
+			Action:     models.AuditSignIn,
+			EntityType: "user",
+			EntityID:   user.ID.String(),
+			Metadata:   map[string]interface{}{"method": "recovery"},
+		})
+
+		lib.Success(ctx, "Account recovered, 2FA has been turned off", response)
+	}
+}
+
+// CancelRecovery godoc
+// @Summary Cancel account recovery
+// @Description Stop a recovery with the link emailed when it was requested
+// @Tags 2FA
+// @Accept json
+// @Produce json
+// @Param request body dto.CancelRecoveryRequest true "Cancel token"
+// @Success 200 {object} models.AccountRecovery
+// @Failure 400 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /auth/2fa/recovery/cancel [post]
+func (h *AccountRecoveryHandler) CancelRecovery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.CancelRecoveryRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		recovery, err := h.service.Cancel(payload.Token)
+		if err != nil {
+			handleRecoveryError(ctx, err, "Failed to cancel account recovery: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			ActorID:    recovery.UserID.String(),
+			Action:     models.AuditRecoveryCancelled,
+			EntityType: "account_recovery",
+			EntityID:   recovery.ID.String(),
+		})
+
+		lib.Success(ctx, "Account recovery cancelled", recovery)
+	}
+}
+
+// GetRecoveryQueue godoc
+// @Summary List account recoveries
+// @Description List account recoveries for review, open ones by default
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status"
+// @Param page query int false "Page"
+// @Param size query int false "Page size"
+// @Success 200 {object} dto.PaginatedResponse[models.AccountRecovery]
+// @Failure 403 {object} lib.Response
+// @Router /admin/account-recoveries [get]
+func (h *AccountRecoveryHandler) GetRecoveryQueue() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var params dto.RecoveryQueueParams
+		if err := ctx.ShouldBindQuery(&params); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		recoveries, err := h.service.GetRecoveryQueue(params)
+		if err != nil {
+			handleRecoveryError(ctx, err, "Failed to get account recoveries: ")
+			return
+		}
+
+		lib.Success(ctx, "Account recoveries retrieved successfully", recoveries)
+	}
+}
+
+// ApproveRecovery godoc
+// @Summary Approve account recovery
+// @Description Waive the waiting period once the requester's identity has been confirmed
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recovery ID"
+// @Success 200 {object} models.AccountRecovery
+// @Failure 403 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /admin/account-recoveries/{id}/approve [put]
+func (h *AccountRecoveryHandler) ApproveRecovery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		adminID := ctx.GetString(config.AppConfig.CurrentUserId)
+		recovery, err := h.service.ApproveRecovery(adminID, ctx.Param("id"))
+		if err != nil {
+			handleRecoveryError(ctx, err, "Failed to approve account recovery: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditRecoveryApproved,
+			EntityType: "account_recovery",
+			EntityID:   recovery.ID.String(),
+			Metadata:   map[string]interface{}{"user_id": recovery.UserID.String()},
+		})
+
+		lib.Success(ctx, "Account recovery approved", recovery)
+	}
+}
+
+// RejectRecovery godoc
+// @Summary Reject account recovery
+// @Description Close the recovery so it cannot be completed
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Recovery ID"
+// @Param request body dto.RejectRecoveryRequest true "Reason"
+// @Success 200 {object} models.AccountRecovery
+// @Failure 403 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /admin/account-recoveries/{id}/reject [put]
+func (h *AccountRecoveryHandler) RejectRecovery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.RejectRecoveryRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		adminID := ctx.GetString(config.AppConfig.CurrentUserId)
+		recovery, err := h.service.RejectRecovery(adminID, ctx.Param("id"), payload)
+		if err != nil {
+			handleRecoveryError(ctx, err, "Failed to reject account recovery: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditRecoveryRejected,
+			EntityType: "account_recovery",
+			EntityID:   recovery.ID.String(),
+			Metadata:   map[string]interface{}{"user_id": recovery.UserID.String(), "reason": payload.Reason},
+		})
+
+		lib.Success(ctx, "Account recovery rejected", recovery)
+	}
+}
+
+func handleRecoveryError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorChallenge):
+		lib.Unauthorized(ctx, err.Error())
+	case errors.Is(err, services.ErrAccountSuspended):
+		lib.Forbidden(ctx, "Your account has been suspended")
+	case errors.Is(err, services.ErrRecoveryNotNeeded):
+		lib.BadRequest(ctx, err.Error(), "RECOVERY_NOT_NEEDED")
+	case errors.Is(err, services.ErrRecoveryPending):
+		lib.BadRequest(ctx, err.Error(), "RECOVERY_PENDING")
+	case errors.Is(err, services.ErrRecoveryNotFound):
+		lib.NotFound(ctx, err.Error(), "RECOVERY_NOT_FOUND")
+	default:
+		handleVerificationTokenError(ctx, err, prefix)
+	}
+}
@@ -25,17 +25,7 @@ func NewAnnouncementHandler(hub *lib.Hub) *AnnouncementHandler {
 // ==================== ADMIN ENDPOINTS ====================
 func (h *AnnouncementHandler) CreateAnnouncement() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		user, exists := ctx.Get("current_user")
-		if !exists {
-			lib.Unauthorized(ctx, "User not authenticated")
-			return
-		}
-
-		currentUser := user.(*models.User)
-		if !currentUser.IsAdmin {
-			lib.Forbidden(ctx, "Admin access required")
-			return
-		}
+		adminID := ctx.GetString(config.AppConfig.CurrentUserId)
 
 		var payload dto.CreateAnnouncementDto
 		if err := ctx.ShouldBindJSON(&payload); err != nil {
@@ -43,30 +33,27 @@ func (h *AnnouncementHandler) CreateAnnouncement() gin.HandlerFunc {
 			return
 		}
 
-		announcement, err := h.service.CreateAnnouncement(currentUser.ID.String(), payload)
+		announcement, err := h.service.CreateAnnouncement(adminID, payload)
 		if err != nil {
 			lib.InternalServerError(ctx, "Failed to create announcement: "+err.Error())
 			return
 		}
 
+		if announcement.IsPublished {
+			recordAudit(ctx, services.AuditEntry{
+				Action:     models.AuditAnnouncementPublished,
+				EntityType: "announcement",
+				EntityID:   announcement.ID.String(),
+				After:      announcement,
+			})
+		}
+
 		lib.Created(ctx, "Announcement created successfully", announcement)
 	}
 }
 
 func (h *AnnouncementHandler) UpdateAnnouncement() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		user, exists := ctx.Get("current_user")
-		if !exists {
-			lib.Unauthorized(ctx, "User not authenticated")
-			return
-		}
-
-		currentUser := user.(*models.User)
-		if !currentUser.IsAdmin {
-			lib.Forbidden(ctx, "Admin access required")
-			return
-		}
-
 		id := ctx.Param("id")
 		var payload dto.UpdateAnnouncementDto
 
@@ -91,19 +78,8 @@ func (h *AnnouncementHandler) UpdateAnnouncement() gin.HandlerFunc {
 
 func (h *AnnouncementHandler) PublishAnnouncement() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		user, exists := ctx.Get("current_user")
-		if !exists {
-			lib.Unauthorized(ctx, "User not authenticated")
-			return
-		}
-
-		currentUser := user.(*models.User)
-		if !currentUser.IsAdmin {
-			lib.Forbidden(ctx, "Admin access required")
-			return
-		}
-
 		id := ctx.Param("id")
+		before, _ := h.service.GetAnnouncement(id)
 
 		announcement, err := h.service.PublishAnnouncement(id)
 		if err != nil {
@@ -115,25 +91,22 @@ func (h *AnnouncementHandler) PublishAnnouncement() gin.HandlerFunc {
 			return
 		}
 
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditAnnouncementPublished,
+			EntityType: "announcement",
+			EntityID:   id,
+			Before:     before,
+			After:      announcement,
+		})
+
 		lib.Success(ctx, "Announcement published successfully", announcement)
 	}
 }
 
 func (h *AnnouncementHandler) UnpublishAnnouncement() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		user, exists := ctx.Get("current_user")
-		if !exists {
-			lib.Unauthorized(ctx, "User not authenticated")
-			return
-		}
-
-		currentUser := user.(*models.User)
-		if !currentUser.IsAdmin {
-			lib.Forbidden(ctx, "Admin access required")
-			return
-		}
-
 		id := ctx.Param("id")
+		before, _ := h.service.GetAnnouncement(id)
 
 		announcement, err := h.service.UnpublishAnnouncement(id)
 		if err != nil {
@@ -145,24 +118,20 @@ func (h *AnnouncementHandler) UnpublishAnnouncement() gin.HandlerFunc {
 			return
 		}
 
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditAnnouncementUnpublished,
+			EntityType: "announcement",
+			EntityID:   id,
+			Before:     before,
+			After:      announcement,
+		})
+
 		lib.Success(ctx, "Announcement unpublished successfully", announcement)
 	}
 }
 
 func (h *AnnouncementHandler) DeleteAnnouncement() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		user, exists := ctx.Get("current_user")
-		if !exists {
-			lib.Unauthorized(ctx, "User not authenticated")
-			return
-		}
-
-		currentUser := user.(*models.User)
-		if !currentUser.IsAdmin {
-			lib.Forbidden(ctx, "Admin access required")
-			return
-		}
-
 		id := ctx.Param("id")
 
 		if err := h.service.DeleteAnnouncement(id); err != nil {
@@ -180,18 +149,6 @@ func (h *AnnouncementHandler) DeleteAnnouncement() gin.HandlerFunc {
 
 func (h *AnnouncementHandler) GetAnnouncementAdmin() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		user, exists := ctx.Get("current_user")
-		if !exists {
-			lib.Unauthorized(ctx, "User not authenticated")
-			return
-		}
-
-		currentUser := user.(*models.User)
-		if !currentUser.IsAdmin {
-			lib.Forbidden(ctx, "Admin access required")
-			return
-		}
-
 		id := ctx.Param("id")
 
 		announcement, err := h.service.GetAnnouncement(id)
@@ -210,18 +167,6 @@ func (h *AnnouncementHandler) GetAnnouncementAdmin() gin.HandlerFunc {
 
 func (h *AnnouncementHandler) GetAnnouncementsAdmin() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		user, exists := ctx.Get("current_user")
-		if !exists {
-			lib.Unauthorized(ctx, "User not authenticated")
-			return
-		}
-
-		currentUser := user.(*models.User)
-		if !currentUser.IsAdmin {
-			lib.Forbidden(ctx, "Admin access required")
-			return
-		}
-
 		var params dto.AnnouncementQueryParams
 		if err := ctx.ShouldBindQuery(&params); err != nil {
 			lib.BadRequest(ctx, err.Error(), "")
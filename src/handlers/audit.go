@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{
+		service: services.NewAuditService(database.GetDatabase()),
+	}
+}
+
+func (h *AuditHandler) GetAuditLogs() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var params dto.AuditLogQueryParams
+		if err := ctx.ShouldBindQuery(&params); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		logs, err := h.service.GetAuditLogs(params)
+		if err != nil {
+			lib.InternalServerError(ctx, "Failed to get audit logs: "+err.Error())
+			return
+		}
+
+		lib.Success(ctx, "Audit logs retrieved successfully", logs)
+	}
+}
+
+func (h *AuditHandler) ExportAuditLogs() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var params dto.AuditLogQueryParams
+		if err := ctx.ShouldBindQuery(&params); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		data, err := h.service.ExportAuditLogs(params)
+		if err != nil {
+			lib.InternalServerError(ctx, "Failed to export audit logs: "+err.Error())
+			return
+		}
+
+		SendBytesAsFile(ctx, data, fmt.Sprintf("audit-log-%s.csv", time.Now().UTC().Format("20060102-150405")), "text/csv")
+	}
+}
+
+// recordAudit logs an action taken during the current request. The actor, impersonating admin, IP address
+// and user agent are filled from the request unless the entry already sets them.
+func recordAudit(ctx *gin.Context, entry services.AuditEntry) {
+	if entry.ActorID == "" {
+		entry.ActorID = ctx.GetString(config.AppConfig.CurrentUserId)
+	}
+	if entry.ImpersonatorID == "" {
+		entry.ImpersonatorID = ctx.GetString("impersonator_id")
+	}
+	entry.IPAddress = ctx.ClientIP()
+	entry.UserAgent = ctx.Request.UserAgent()
+
+	services.NewAuditService(database.GetDatabase()).Record(entry)
+}
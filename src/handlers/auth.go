@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"foglio/v2/src/config"
 	"foglio/v2/src/database"
 	"foglio/v2/src/dto"
 	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
 	"foglio/v2/src/services"
+	"math"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -54,13 +59,25 @@ func (h *AuthHandler) Signin() gin.HandlerFunc {
 			return
 		}
 
-		user, err := h.service.Signin(payload)
+		user, err := h.service.Signin(payload, sessionClient(ctx))
 		if err != nil {
-			if err.Error() == "user not found" {
-				lib.NotFound(ctx, err.Error(), "")
+			recordAudit(ctx, services.AuditEntry{
+				Action:     models.AuditSignInFailed,
+				EntityType: "user",
+				Metadata:   map[string]interface{}{"identifier": payload.Identifier, "reason": err.Error()},
+			})
+			if handleThrottled(ctx, err) {
 				return
 			}
-			if err.Error() == "invalid password" || err.Error() == "user not verified" {
+			if errors.Is(err, services.ErrAccountSuspended) {
+				lib.Forbidden(ctx, "Your account has been suspended")
+				return
+			}
+			if errors.Is(err, services.ErrSSORequired) {
+				lib.BadRequest(ctx, err.Error(), "SSO_REQUIRED")
+				return
+			}
+			if errors.Is(err, services.ErrInvalidCredentials) {
 				lib.Unauthorized(ctx, err.Error())
 				return
 			}
@@ -68,6 +85,17 @@ func (h *AuthHandler) Signin() gin.HandlerFunc {
 			return
 		}
 
+		// Sign-ins that still need a 2FA code are recorded once the code is verified
+		if !user.RequiresTwoFactor {
+			recordAudit(ctx, services.AuditEntry{
+				ActorID:    user.User.ID.String(),
+				Action:     models.AuditSignIn,
+				EntityType: "user",
+				EntityID:   user.User.ID.String(),
+				Metadata:   map[string]interface{}{"method": "password"},
+			})
+		}
+
 		lib.Success(ctx, "User signed in successfully", user)
 	}
 }
@@ -80,14 +108,16 @@ func (h *AuthHandler) RequestVerification() gin.HandlerFunc {
 			return
 		}
 
-		user, err := h.service.RequestVerification(email)
-		if err != nil {
+		if err := h.service.RequestVerification(email, sessionClient(ctx)); err != nil {
+			if handleThrottled(ctx, err) {
+				return
+			}
 			lib.InternalServerError(ctx, err.Error())
 			return
 		}
 
-		ctx.SetCookie("verification_email", user.Email, 1800, "/", "localhost", false, true)
-		lib.Success(ctx, "", user)
+		ctx.SetCookie("verification_email", email, 1800, "/", "localhost", false, true)
+		lib.Success(ctx, "If the account needs verifying, a verification code has been sent to you", nil)
 	}
 }
 
@@ -100,9 +130,9 @@ func (h *AuthHandler) Verification() gin.HandlerFunc {
 			return
 		}
 
-		user, err := h.service.Verification(payload)
+		user, err := h.service.Verification(payload, sessionClient(ctx))
 		if err != nil {
-			lib.InternalServerError(ctx, err.Error())
+			handleVerificationTokenError(ctx, err, "Failed to verify account: ")
 			return
 		}
 
@@ -113,19 +143,25 @@ func (h *AuthHandler) Verification() gin.HandlerFunc {
 func (h *AuthHandler) ChangePassword() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var payload dto.ChangePasswordDto
-		id := ctx.Param("id")
+		id := ctx.GetString(config.AppConfig.CurrentUserId)
 
 		if err := ctx.ShouldBindJSON(&payload); err != nil {
 			lib.BadRequest(ctx, err.Error(), "400")
 			return
 		}
 
-		err := h.service.ChangePassword(id, payload)
+		err := h.service.ChangePassword(id, ctx.GetString("session_id"), payload)
 		if err != nil {
 			lib.InternalServerError(ctx, err.Error())
 			return
 		}
 
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditPasswordChanged,
+			EntityType: "user",
+			EntityID:   id,
+		})
+
 		lib.Success(ctx, "Password changed successfully", nil)
 	}
 }
@@ -139,13 +175,16 @@ func (h *AuthHandler) ForgotPassword() gin.HandlerFunc {
 			return
 		}
 
-		err := h.service.ForgotPassword(payload.Email)
+		err := h.service.ForgotPassword(payload.Email, sessionClient(ctx))
 		if err != nil {
+			if handleThrottled(ctx, err) {
+				return
+			}
 			lib.InternalServerError(ctx, err.Error())
 			return
 		}
 
-		lib.Success(ctx, "A reset mail has been sent to you", nil)
+		lib.Success(ctx, "If an account exists for this email, a reset mail has been sent to you", nil)
 
 	}
 }
@@ -159,30 +198,59 @@ func (h *AuthHandler) ResetPassword() gin.HandlerFunc {
 			return
 		}
 
-		err := h.service.ResetPassword(payload)
+		user, err := h.service.ResetPassword(payload)
 		if err != nil {
-			lib.InternalServerError(ctx, err.Error())
+			handleVerificationTokenError(ctx, err, "Failed to reset password: ")
 			return
 		}
 
+		recordAudit(ctx, services.AuditEntry{
+			ActorID:    user.ID.String(),
+			Action:     models.AuditPasswordReset,
+			EntityType: "user",
+			EntityID:   user.ID.String(),
+		})
+
 		lib.Success(ctx, "Password reset successfully", nil)
 	}
 }
 
+// GetOAuthURL godoc
+// @Summary Start signing in with a provider
+// @Description Get the provider's authorization URL. The request's state is also set in an HTTP-only cookie, which the callback must carry.
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "Provider name, see /auth/providers"
+// @Success 200 {string} string "Authorization URL"
+// @Failure 400 {object} lib.Response
+// @Router /auth/{provider} [get]
 func (h *AuthHandler) GetOAuthURL() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		provider := ctx.Param("provider")
 
-		url, err := h.service.GetOAuthURL(provider)
+		url, state, err := h.service.GetOAuthURL(provider)
 		if err != nil {
-			lib.InternalServerError(ctx, err.Error())
+			handleOAuthError(ctx, err, "Failed to start sign-in: ")
 			return
 		}
 
+		setAuthCookie(ctx, oauthStateCookie, state, int(services.OAuthStateTTL.Seconds()))
 		lib.Success(ctx, "OAuth URL generated successfully", url)
 	}
 }
 
+// HandleOAuthCallback godoc
+// @Summary Finish signing in with a provider
+// @Description Exchange the code the provider redirected back with. The state must match the one bound to this browser.
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the provider"
+// @Success 200 {object} services.SigninResponse
+// @Failure 400 {object} lib.Response
+// @Failure 403 {object} lib.Response
+// @Router /auth/{provider}/callback [get]
 func (h *AuthHandler) HandleOAuthCallback() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		provider := ctx.Param("provider")
@@ -193,12 +261,62 @@ func (h *AuthHandler) HandleOAuthCallback() gin.HandlerFunc {
 			return
 		}
 
-		response, err := h.service.HandleOAuthCallback(provider, payload)
+		browserState, _ := ctx.Cookie(oauthStateCookie)
+		setAuthCookie(ctx, oauthStateCookie, "", -1)
+
+		response, err := h.service.HandleOAuthCallback(provider, payload, browserState, sessionClient(ctx))
 		if err != nil {
-			lib.InternalServerError(ctx, "Internal server error, "+err.Error())
+			recordAudit(ctx, services.AuditEntry{
+				Action:     models.AuditSignInFailed,
+				EntityType: "user",
+				Metadata:   map[string]interface{}{"method": provider, "reason": err.Error()},
+			})
+			handleOAuthError(ctx, err, "Internal server error, ")
 			return
 		}
 
+		if !response.RequiresTwoFactor {
+			recordAudit(ctx, services.AuditEntry{
+				ActorID:    response.User.ID.String(),
+				Action:     models.AuditSignIn,
+				EntityType: "user",
+				EntityID:   response.User.ID.String(),
+				Metadata:   map[string]interface{}{"method": provider},
+			})
+		}
+
 		lib.Success(ctx, "", response)
 	}
 }
+
+func handleVerificationTokenError(ctx *gin.Context, err error, prefix string) {
+	if handleThrottled(ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrInvalidVerificationToken):
+		lib.BadRequest(ctx, err.Error(), "INVALID_TOKEN")
+	case errors.Is(err, services.ErrInvalidVerificationCode):
+		lib.BadRequest(ctx, err.Error(), "INVALID_CODE")
+	case errors.Is(err, services.ErrVerificationAttemptsExceeded):
+		lib.BadRequest(ctx, err.Error(), "TOO_MANY_ATTEMPTS")
+	case err.Error() == "invalid password" || err.Error() == "user already verified":
+		lib.BadRequest(ctx, err.Error(), "400")
+	default:
+		lib.InternalServerError(ctx, prefix+err.Error())
+	}
+}
+
+// handleThrottled answers an attempt that has to wait with 429 and the seconds to wait in Retry-After. It
+// reports whether err was such an error.
+func handleThrottled(ctx *gin.Context, err error) bool {
+	var throttled *services.ThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	lib.TooManyRequests(ctx, throttled.Error())
+	return true
+}
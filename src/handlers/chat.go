@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"foglio/v2/src/config"
 	"foglio/v2/src/database"
 	"foglio/v2/src/dto"
 	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
 	"foglio/v2/src/services"
+	"io"
+	"log"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -43,15 +50,31 @@ func (h *ChatHandler) SendMessage() gin.HandlerFunc {
 				lib.BadRequest(ctx, err.Error(), "CANNOT_MESSAGE_SELF")
 			case services.ErrEmptyMessage:
 				lib.BadRequest(ctx, err.Error(), "EMPTY_MESSAGE")
+			case services.ErrMissingMessageTarget:
+				lib.BadRequest(ctx, err.Error(), "MISSING_MESSAGE_TARGET")
 			case services.ErrRecipientNotFound:
 				lib.NotFound(ctx, err.Error(), "RECIPIENT_NOT_FOUND")
+			case services.ErrConversationNotFound:
+				lib.NotFound(ctx, err.Error(), "CONVERSATION_NOT_FOUND")
+			case services.ErrInvalidReplyTarget:
+				lib.BadRequest(ctx, err.Error(), "INVALID_REPLY_TARGET")
+			case services.ErrInvalidAttachment:
+				lib.BadRequest(ctx, err.Error(), "INVALID_ATTACHMENT")
+			case services.ErrNotParticipant, services.ErrUserBlocked, services.ErrChatBanned:
+				lib.Forbidden(ctx, err.Error())
 			default:
 				lib.InternalServerError(ctx, "Failed to send message: "+err.Error())
 			}
 			return
 		}
 
-		lib.Created(ctx, "Message sent successfully", message)
+		response, err := h.service.MessageResponse(message)
+		if err != nil {
+			lib.InternalServerError(ctx, "Failed to send message: "+err.Error())
+			return
+		}
+
+		lib.Created(ctx, "Message sent successfully", response)
 	}
 }
 
@@ -120,6 +143,8 @@ func (h *ChatHandler) GetOrCreateConversation() gin.HandlerFunc {
 				lib.BadRequest(ctx, err.Error(), "CANNOT_MESSAGE_SELF")
 			case services.ErrRecipientNotFound:
 				lib.NotFound(ctx, "User not found", "USER_NOT_FOUND")
+			case services.ErrUserBlocked, services.ErrChatBanned:
+				lib.Forbidden(ctx, err.Error())
 			default:
 				lib.InternalServerError(ctx, "Failed to get conversation: "+err.Error())
 			}
@@ -139,14 +164,20 @@ func (h *ChatHandler) GetMessages() gin.HandlerFunc {
 		}
 
 		conversationID := ctx.Param("id")
-		page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-		limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
 
-		messages, err := h.service.GetMessages(userID, conversationID, page, limit)
+		var params dto.ChatQueryParams
+		if err := ctx.ShouldBindQuery(&params); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		messages, err := h.service.GetMessages(userID, conversationID, params)
 		if err != nil {
 			switch err {
 			case services.ErrConversationNotFound:
 				lib.NotFound(ctx, err.Error(), "CONVERSATION_NOT_FOUND")
+			case services.ErrMessageNotFound:
+				lib.NotFound(ctx, err.Error(), "MESSAGE_NOT_FOUND")
 			case services.ErrNotParticipant:
 				lib.Forbidden(ctx, err.Error())
 			default:
@@ -201,7 +232,7 @@ func (h *ChatHandler) DeleteConversation() gin.HandlerFunc {
 			switch err {
 			case services.ErrConversationNotFound:
 				lib.NotFound(ctx, err.Error(), "CONVERSATION_NOT_FOUND")
-			case services.ErrNotParticipant:
+			case services.ErrNotParticipant, services.ErrNotConversationOwner:
 				lib.Forbidden(ctx, err.Error())
 			default:
 				lib.InternalServerError(ctx, "Failed to delete conversation: "+err.Error())
@@ -213,7 +244,7 @@ func (h *ChatHandler) DeleteConversation() gin.HandlerFunc {
 	}
 }
 
-func (h *ChatHandler) GetUnreadCount() gin.HandlerFunc {
+func (h *ChatHandler) CreateGroupConversation() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		userID := ctx.GetString(config.AppConfig.CurrentUserId)
 		if userID == "" {
@@ -221,12 +252,752 @@ func (h *ChatHandler) GetUnreadCount() gin.HandlerFunc {
 			return
 		}
 
-		count, err := h.service.GetUnreadCount(userID)
+		var payload dto.CreateGroupConversationDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		conversation, err := h.service.CreateGroupConversation(userID, payload)
 		if err != nil {
-			lib.InternalServerError(ctx, "Failed to get unread count: "+err.Error())
+			switch err {
+			case services.ErrGroupNeedsMembers:
+				lib.BadRequest(ctx, err.Error(), "GROUP_NEEDS_MEMBERS")
+			case services.ErrGroupParticipantLimit:
+				lib.BadRequest(ctx, err.Error(), "GROUP_PARTICIPANT_LIMIT")
+			case services.ErrRecipientNotFound:
+				lib.NotFound(ctx, "User not found", "USER_NOT_FOUND")
+			case services.ErrUserBlocked, services.ErrChatBanned:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to create group conversation: "+err.Error())
+			}
 			return
 		}
 
-		lib.Success(ctx, "Unread count retrieved", map[string]int64{"unread_count": count})
+		lib.Created(ctx, "Group conversation created successfully", conversation)
+	}
+}
+
+func (h *ChatHandler) RenameConversation() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.RenameConversationDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		conversation, err := h.service.RenameConversation(userID, ctx.Param("id"), payload)
+		if err != nil {
+			switch err {
+			case services.ErrConversationNotFound:
+				lib.NotFound(ctx, err.Error(), "CONVERSATION_NOT_FOUND")
+			case services.ErrNotGroupConversation:
+				lib.BadRequest(ctx, err.Error(), "NOT_GROUP_CONVERSATION")
+			case services.ErrNotParticipant, services.ErrNotConversationOwner:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to rename conversation: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Conversation renamed successfully", conversation)
+	}
+}
+
+func (h *ChatHandler) AddParticipants() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.AddParticipantsDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		conversation, err := h.service.AddParticipants(userID, ctx.Param("id"), payload)
+		if err != nil {
+			switch err {
+			case services.ErrConversationNotFound:
+				lib.NotFound(ctx, err.Error(), "CONVERSATION_NOT_FOUND")
+			case services.ErrRecipientNotFound:
+				lib.NotFound(ctx, "User not found", "USER_NOT_FOUND")
+			case services.ErrNotGroupConversation:
+				lib.BadRequest(ctx, err.Error(), "NOT_GROUP_CONVERSATION")
+			case services.ErrAlreadyParticipant:
+				lib.BadRequest(ctx, err.Error(), "ALREADY_PARTICIPANT")
+			case services.ErrGroupParticipantLimit:
+				lib.BadRequest(ctx, err.Error(), "GROUP_PARTICIPANT_LIMIT")
+			case services.ErrNotParticipant, services.ErrNotConversationOwner, services.ErrUserBlocked, services.ErrChatBanned:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to add participants: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Participants added successfully", conversation)
+	}
+}
+
+func (h *ChatHandler) RemoveParticipant() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		err := h.service.RemoveParticipant(userID, ctx.Param("id"), ctx.Param("userId"))
+		if err != nil {
+			switch err {
+			case services.ErrConversationNotFound:
+				lib.NotFound(ctx, err.Error(), "CONVERSATION_NOT_FOUND")
+			case services.ErrParticipantNotFound:
+				lib.NotFound(ctx, err.Error(), "PARTICIPANT_NOT_FOUND")
+			case services.ErrNotGroupConversation:
+				lib.BadRequest(ctx, err.Error(), "NOT_GROUP_CONVERSATION")
+			case services.ErrNotParticipant, services.ErrNotConversationOwner:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to remove participant: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Participant removed successfully", nil)
+	}
+}
+
+func (h *ChatHandler) EditMessage() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.EditMessageDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		message, err := h.service.EditMessage(userID, ctx.Param("messageId"), payload)
+		if err != nil {
+			switch err {
+			case services.ErrMessageNotFound:
+				lib.NotFound(ctx, err.Error(), "MESSAGE_NOT_FOUND")
+			case services.ErrEditWindowExpired:
+				lib.BadRequest(ctx, err.Error(), "EDIT_WINDOW_EXPIRED")
+			case services.ErrMessageDeleted:
+				lib.BadRequest(ctx, err.Error(), "MESSAGE_DELETED")
+			case services.ErrNotParticipant, services.ErrNotMessageSender:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to edit message: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Message edited successfully", message)
+	}
+}
+
+func (h *ChatHandler) GetMessageEdits() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		edits, err := h.service.GetMessageEdits(userID, ctx.Param("messageId"))
+		if err != nil {
+			switch err {
+			case services.ErrMessageNotFound:
+				lib.NotFound(ctx, err.Error(), "MESSAGE_NOT_FOUND")
+			case services.ErrMessageDeleted:
+				lib.BadRequest(ctx, err.Error(), "MESSAGE_DELETED")
+			case services.ErrNotParticipant:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to get message edits: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Message edits retrieved successfully", edits)
+	}
+}
+
+func (h *ChatHandler) DeleteMessage() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		forEveryone := ctx.DefaultQuery("scope", "me") == "everyone"
+
+		err := h.service.DeleteMessage(userID, ctx.Param("messageId"), forEveryone)
+		if err != nil {
+			switch err {
+			case services.ErrMessageNotFound:
+				lib.NotFound(ctx, err.Error(), "MESSAGE_NOT_FOUND")
+			case services.ErrNotParticipant, services.ErrNotMessageSender:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to delete message: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Message deleted successfully", nil)
+	}
+}
+
+func (h *ChatHandler) AddReaction() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.ReactionDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		reactions, err := h.service.AddReaction(userID, ctx.Param("messageId"), payload)
+		if err != nil {
+			switch err {
+			case services.ErrMessageNotFound:
+				lib.NotFound(ctx, err.Error(), "MESSAGE_NOT_FOUND")
+			case services.ErrMessageDeleted:
+				lib.BadRequest(ctx, err.Error(), "MESSAGE_DELETED")
+			case services.ErrNotParticipant:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to add reaction: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Reaction added successfully", reactions)
+	}
+}
+
+func (h *ChatHandler) RemoveReaction() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		reactions, err := h.service.RemoveReaction(userID, ctx.Param("messageId"), ctx.Param("emoji"))
+		if err != nil {
+			switch err {
+			case services.ErrMessageNotFound:
+				lib.NotFound(ctx, err.Error(), "MESSAGE_NOT_FOUND")
+			case services.ErrReactionNotFound:
+				lib.NotFound(ctx, err.Error(), "REACTION_NOT_FOUND")
+			case services.ErrNotParticipant:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to remove reaction: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Reaction removed successfully", reactions)
+	}
+}
+
+func (h *ChatHandler) UploadAttachment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		// Leave headroom for the other multipart fields.
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, services.MaxAttachmentSize()+1<<20)
+
+		file, header, err := ctx.Request.FormFile("file")
+		if err != nil {
+			lib.BadRequest(ctx, "file field is required", "FILE_REQUIRED")
+			return
+		}
+		defer func() {
+			if err = file.Close(); err != nil {
+				log.Printf("Error closing file: %v", err)
+			}
+		}()
+
+		conversationID := ctx.PostForm("conversation_id")
+		if conversationID == "" {
+			lib.BadRequest(ctx, "conversation_id field is required", "CONVERSATION_REQUIRED")
+			return
+		}
+
+		mediaType := models.MediaType(strings.ToUpper(ctx.DefaultPostForm("type", string(models.MediaTypeFile))))
+
+		media, err := h.service.UploadAttachment(userID, conversationID, mediaType, header)
+		if err != nil {
+			switch err {
+			case services.ErrConversationNotFound:
+				lib.NotFound(ctx, err.Error(), "CONVERSATION_NOT_FOUND")
+			case services.ErrNotParticipant:
+				lib.Forbidden(ctx, err.Error())
+			case services.ErrAttachmentTooLarge:
+				lib.BadRequest(ctx, err.Error(), "ATTACHMENT_TOO_LARGE")
+			case services.ErrUnsupportedAttachment, lib.ErrImageTooLarge:
+				lib.BadRequest(ctx, err.Error(), "UNSUPPORTED_ATTACHMENT")
+			default:
+				lib.InternalServerError(ctx, "Failed to upload attachment: "+err.Error())
+			}
+			return
+		}
+
+		lib.Created(ctx, "Attachment uploaded successfully", media)
+	}
+}
+
+func (h *ChatHandler) GetAttachment(thumbnail bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		attachment, reader, err := h.service.OpenAttachment(userID, ctx.Param("attachmentId"), thumbnail)
+		if err != nil {
+			switch err {
+			case services.ErrAttachmentNotFound:
+				lib.NotFound(ctx, err.Error(), "ATTACHMENT_NOT_FOUND")
+			case services.ErrNotParticipant:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to get attachment: "+err.Error())
+			}
+			return
+		}
+		serveAttachment(ctx, attachment, reader, thumbnail)
+	}
+}
+
+// GetSignedAttachment serves the links message responses carry, which need no Authorization header.
+func (h *ChatHandler) GetSignedAttachment(thumbnail bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		attachment, reader, err := h.service.OpenSignedAttachment(ctx.Param("attachmentId"), thumbnail, ctx.Query("expires"), ctx.Query("signature"))
+		if err != nil {
+			switch err {
+			case services.ErrAttachmentNotFound:
+				lib.NotFound(ctx, err.Error(), "ATTACHMENT_NOT_FOUND")
+			case services.ErrAttachmentLinkInvalid:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to get attachment: "+err.Error())
+			}
+			return
+		}
+		serveAttachment(ctx, attachment, reader, thumbnail)
+	}
+}
+
+// serveAttachment streams an opened attachment. Only media types that are checked on upload are shown
+// inline; anything else is sent as a download and never sniffed.
+func serveAttachment(ctx *gin.Context, attachment *models.ChatAttachment, reader io.ReadCloser, thumbnail bool) {
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("Error closing attachment: %v", err)
+		}
+	}()
+
+	contentType := attachment.MimeType
+	contentLength := attachment.FileSize
+	if thumbnail {
+		contentType = "image/jpeg"
+		contentLength = -1
+	}
+
+	disposition := "attachment"
+	if thumbnail || services.IsInlineAttachmentType(contentType) {
+		disposition = "inline"
+	}
+
+	ctx.Header("Cache-Control", "private, max-age=3600")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.DataFromReader(http.StatusOK, contentLength, contentType, reader, map[string]string{
+		"Content-Disposition": fmt.Sprintf("%s; filename=%q", disposition, attachment.FileName),
+	})
+}
+
+func (h *ChatHandler) GetUnreadCount() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		count, err := h.service.GetUnreadCount(userID)
+		if err != nil {
+			lib.InternalServerError(ctx, "Failed to get unread count: "+err.Error())
+			return
+		}
+
+		lib.Success(ctx, "Unread count retrieved", map[string]int64{"unread_count": count})
+	}
+}
+
+func (h *ChatHandler) SearchMessages() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var params dto.MessageSearchParams
+		if err := ctx.ShouldBindQuery(&params); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		results, err := h.service.SearchMessages(userID, params)
+		if err != nil {
+			lib.InternalServerError(ctx, "Failed to search messages: "+err.Error())
+			return
+		}
+
+		lib.Success(ctx, "Messages retrieved successfully", results)
+	}
+}
+
+func (h *ChatHandler) GetPresence() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		presence, err := h.service.GetPresence(ctx.Param("userId"))
+		if err != nil {
+			switch err {
+			case services.ErrRecipientNotFound:
+				lib.NotFound(ctx, "User not found", "USER_NOT_FOUND")
+			default:
+				lib.InternalServerError(ctx, "Failed to get presence: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Presence retrieved successfully", presence)
+	}
+}
+
+func (h *ChatHandler) UpdatePresenceVisibility() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.UpdatePresenceVisibilityDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		presence, err := h.service.SetPresenceVisibility(userID, *payload.Hidden)
+		if err != nil {
+			lib.InternalServerError(ctx, "Failed to update presence visibility: "+err.Error())
+			return
+		}
+
+		lib.Success(ctx, "Presence visibility updated", presence)
+	}
+}
+
+func (h *ChatHandler) BlockUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.BlockUserDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		block, err := h.service.BlockUser(userID, payload)
+		if err != nil {
+			switch err {
+			case services.ErrCannotBlockSelf:
+				lib.BadRequest(ctx, err.Error(), "CANNOT_BLOCK_SELF")
+			case services.ErrRecipientNotFound:
+				lib.NotFound(ctx, "User not found", "USER_NOT_FOUND")
+			default:
+				lib.InternalServerError(ctx, "Failed to block user: "+err.Error())
+			}
+			return
+		}
+
+		lib.Created(ctx, "User blocked successfully", block)
+	}
+}
+
+func (h *ChatHandler) UnblockUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		if err := h.service.UnblockUser(userID, ctx.Param("userId")); err != nil {
+			switch err {
+			case services.ErrBlockNotFound:
+				lib.NotFound(ctx, err.Error(), "BLOCK_NOT_FOUND")
+			default:
+				lib.InternalServerError(ctx, "Failed to unblock user: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "User unblocked successfully", nil)
+	}
+}
+
+func (h *ChatHandler) GetBlockedUsers() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		blocks, err := h.service.GetBlockedUsers(userID)
+		if err != nil {
+			lib.InternalServerError(ctx, "Failed to get blocked users: "+err.Error())
+			return
+		}
+
+		lib.Success(ctx, "Blocked users retrieved successfully", blocks)
+	}
+}
+
+func (h *ChatHandler) MuteConversation() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.MuteConversationDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil && !errors.Is(err, io.EOF) {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		conversation, err := h.service.MuteConversation(userID, ctx.Param("id"), payload)
+		if err != nil {
+			switch err {
+			case services.ErrConversationNotFound:
+				lib.NotFound(ctx, err.Error(), "CONVERSATION_NOT_FOUND")
+			case services.ErrNotParticipant:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to mute conversation: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Conversation muted", conversation)
+	}
+}
+
+func (h *ChatHandler) UnmuteConversation() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		conversation, err := h.service.UnmuteConversation(userID, ctx.Param("id"))
+		if err != nil {
+			switch err {
+			case services.ErrConversationNotFound:
+				lib.NotFound(ctx, err.Error(), "CONVERSATION_NOT_FOUND")
+			case services.ErrNotParticipant:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to unmute conversation: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Conversation unmuted", conversation)
+	}
+}
+
+func (h *ChatHandler) ReportUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.ReportUserDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		report, err := h.service.ReportUser(userID, payload)
+		if err != nil {
+			switch err {
+			case services.ErrCannotReportSelf:
+				lib.BadRequest(ctx, err.Error(), "CANNOT_REPORT_SELF")
+			case services.ErrInvalidReportMessages:
+				lib.BadRequest(ctx, err.Error(), "INVALID_REPORT_MESSAGES")
+			case services.ErrParticipantNotFound:
+				lib.BadRequest(ctx, err.Error(), "PARTICIPANT_NOT_FOUND")
+			case services.ErrRecipientNotFound:
+				lib.NotFound(ctx, "User not found", "USER_NOT_FOUND")
+			case services.ErrConversationNotFound:
+				lib.NotFound(ctx, err.Error(), "CONVERSATION_NOT_FOUND")
+			case services.ErrNotParticipant:
+				lib.Forbidden(ctx, err.Error())
+			default:
+				lib.InternalServerError(ctx, "Failed to report user: "+err.Error())
+			}
+			return
+		}
+
+		lib.Created(ctx, "Report submitted successfully", report)
+	}
+}
+
+// ==================== ADMIN ENDPOINTS ====================
+func (h *ChatHandler) GetChatReports() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var params dto.ChatReportQueryParams
+		if err := ctx.ShouldBindQuery(&params); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		reports, err := h.service.GetChatReports(params)
+		if err != nil {
+			lib.InternalServerError(ctx, "Failed to get reports: "+err.Error())
+			return
+		}
+
+		lib.Success(ctx, "Reports retrieved successfully", reports)
+	}
+}
+
+func (h *ChatHandler) GetChatReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		report, err := h.service.GetChatReport(ctx.Param("id"))
+		if err != nil {
+			switch err {
+			case services.ErrReportNotFound:
+				lib.NotFound(ctx, err.Error(), "REPORT_NOT_FOUND")
+			default:
+				lib.InternalServerError(ctx, "Failed to get report: "+err.Error())
+			}
+			return
+		}
+
+		lib.Success(ctx, "Report retrieved successfully", report)
+	}
+}
+
+func (h *ChatHandler) ResolveChatReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		adminID := ctx.GetString(config.AppConfig.CurrentUserId)
+
+		var payload dto.ResolveChatReportDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		report, err := h.service.ResolveChatReport(adminID, ctx.Param("id"), payload)
+		if err != nil {
+			switch err {
+			case services.ErrReportNotFound:
+				lib.NotFound(ctx, err.Error(), "REPORT_NOT_FOUND")
+			case services.ErrReportAlreadyResolved:
+				lib.BadRequest(ctx, err.Error(), "REPORT_ALREADY_RESOLVED")
+			default:
+				lib.InternalServerError(ctx, "Failed to resolve report: "+err.Error())
+			}
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditChatReportResolved,
+			EntityType: "chat_report",
+			EntityID:   report.ID,
+			Metadata: map[string]interface{}{
+				"reported_user_id": report.ReportedUser.ID,
+				"action":           payload.Action,
+				"note":             payload.Note,
+			},
+		})
+
+		lib.Success(ctx, "Report resolved successfully", report)
+	}
+}
+
+func (h *ChatHandler) LiftChatBan() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := h.service.LiftChatBan(ctx.Param("userId")); err != nil {
+			switch err {
+			case services.ErrRecipientNotFound:
+				lib.NotFound(ctx, "User not found", "USER_NOT_FOUND")
+			default:
+				lib.InternalServerError(ctx, "Failed to lift chat ban: "+err.Error())
+			}
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditChatBanLifted,
+			EntityType: "user",
+			EntityID:   ctx.Param("userId"),
+		})
+
+		lib.Success(ctx, "Chat ban lifted successfully", nil)
 	}
 }
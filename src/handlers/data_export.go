@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DataExportHandler struct {
+	service *services.DataExportService
+}
+
+func NewDataExportHandler() *DataExportHandler {
+	return &DataExportHandler{
+		service: services.NewDataExportService(database.GetDatabase()),
+	}
+}
+
+// RequestExport godoc
+// @Summary Request a data export
+// @Description Start building a ZIP of everything stored about the account, as JSON plus uploaded media. The account is emailed when it is ready to download.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} models.DataExport
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /me/exports [post]
+func (h *DataExportHandler) RequestExport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		export, err := h.service.RequestExport(userID)
+		if err != nil {
+			handleDataExportError(ctx, err, "Failed to request data export: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditDataExportRequested,
+			EntityType: "data_export",
+			EntityID:   export.ID.String(),
+		})
+
+		lib.Created(ctx, "Your data export is being prepared", export)
+	}
+}
+
+// GetExports godoc
+// @Summary List data exports
+// @Description List the account's most recent data exports
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.DataExport
+// @Failure 401 {object} lib.Response
+// @Router /me/exports [get]
+func (h *DataExportHandler) GetExports() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		exports, err := h.service.GetExports(userID)
+		if err != nil {
+			handleDataExportError(ctx, err, "Failed to get data exports: ")
+			return
+		}
+
+		lib.Success(ctx, "Data exports retrieved successfully", exports)
+	}
+}
+
+// DownloadExport godoc
+// @Summary Download a data export
+// @Description Download a ready export as a ZIP archive
+// @Tags Users
+// @Produce application/zip
+// @Security BearerAuth
+// @Param id path string true "Export ID"
+// @Success 200 {file} file
+// @Failure 400 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /me/exports/{id}/download [get]
+func (h *DataExportHandler) DownloadExport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		export, reader, err := h.service.OpenExport(userID, ctx.Param("id"))
+		if err != nil {
+			handleDataExportError(ctx, err, "Failed to download data export: ")
+			return
+		}
+		defer func() {
+			if err := reader.Close(); err != nil {
+				log.Printf("Error closing data export: %v", err)
+			}
+		}()
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditDataExportDownloaded,
+			EntityType: "data_export",
+			EntityID:   export.ID.String(),
+		})
+
+		name := fmt.Sprintf("foglio-export-%s.zip", export.CreatedAt.UTC().Format("2006-01-02"))
+		ctx.Header("Cache-Control", "private, no-store")
+		ctx.DataFromReader(http.StatusOK, export.FileSize, "application/zip", reader, map[string]string{
+			"Content-Disposition": fmt.Sprintf("attachment; filename=%q", name),
+		})
+	}
+}
+
+func handleDataExportError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrExportInProgress):
+		lib.BadRequest(ctx, err.Error(), "EXPORT_IN_PROGRESS")
+	case errors.Is(err, services.ErrExportTooSoon):
+		lib.BadRequest(ctx, err.Error(), "EXPORT_TOO_SOON")
+	case errors.Is(err, services.ErrExportNotReady):
+		lib.BadRequest(ctx, err.Error(), "EXPORT_NOT_READY")
+	case errors.Is(err, services.ErrExportNotFound):
+		lib.NotFound(ctx, err.Error(), "EXPORT_NOT_FOUND")
+	default:
+		lib.InternalServerError(ctx, prefix+err.Error())
+	}
+}
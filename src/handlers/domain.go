@@ -7,17 +7,21 @@ import (
 	"foglio/v2/src/dto"
 	"foglio/v2/src/lib"
 	"foglio/v2/src/services"
+	"log"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 type DomainHandler struct {
-	service *services.DomainService
+	service      *services.DomainService
+	certificates *services.CertificateService
 }
 
 func NewDomainHandler() *DomainHandler {
 	return &DomainHandler{
-		service: services.NewDomainService(database.GetDatabase()),
+		service:      services.NewDomainService(database.GetDatabase()),
+		certificates: services.NewCertificateService(database.GetDatabase()),
 	}
 }
 
@@ -170,6 +174,23 @@ func (h *DomainHandler) RemoveCustomDomain() gin.HandlerFunc {
 	}
 }
 
+// ServeACMEChallenge answers the CA's HTTP-01 validation request for a custom domain being issued a certificate.
+func (h *DomainHandler) ServeACMEChallenge() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		keyAuthorization, err := h.certificates.ChallengeResponse(ctx.Request.Host, ctx.Param("token"))
+		if err != nil {
+			if !errors.Is(err, services.ErrChallengeNotFound) {
+				log.Printf("Failed to answer ACME challenge: %v", err)
+			}
+			ctx.String(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+
+		ctx.Header("Cache-Control", "no-store")
+		ctx.String(http.StatusOK, keyAuthorization)
+	}
+}
+
 func handleDomainError(ctx *gin.Context, err error) {
 	switch {
 	case errors.Is(err, services.ErrSubdomainTaken):
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailChangeHandler struct {
+	service *services.EmailChangeService
+}
+
+func NewEmailChangeHandler() *EmailChangeHandler {
+	return &EmailChangeHandler{
+		service: services.NewEmailChangeService(database.GetDatabase()),
+	}
+}
+
+// RequestEmailChange godoc
+// @Summary Change email
+// @Description Email a confirmation code to the new address. Accounts with a password have to give it.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ChangeEmailRequest true "New email and current password"
+// @Success 200 {object} lib.Response
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /auth/email [post]
+func (h *EmailChangeHandler) RequestEmailChange() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.ChangeEmailRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if err := h.service.RequestChange(userID, payload); err != nil {
+			handleEmailChangeError(ctx, err, "Failed to change email: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditEmailChangeRequested,
+			EntityType: "user",
+			EntityID:   userID,
+			Metadata:   map[string]interface{}{"new_email": payload.NewEmail},
+		})
+
+		lib.Success(ctx, "A confirmation code has been sent to the new email", nil)
+	}
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm email change
+// @Description Switch the account to the new email with the code sent to it. The old address is emailed a link to revert the change.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ConfirmEmailChangeRequest true "Confirmation code"
+// @Success 200 {object} models.User
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /auth/email/confirm [post]
+func (h *EmailChangeHandler) ConfirmEmailChange() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.ConfirmEmailChangeRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		user, oldEmail, err := h.service.ConfirmChange(userID, payload.Code)
+		if err != nil {
+			handleEmailChangeError(ctx, err, "Failed to change email: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditEmailChanged,
+			EntityType: "user",
+			EntityID:   userID,
+			Before:     map[string]interface{}{"email": oldEmail},
+			After:      map[string]interface{}{"email": user.Email},
+		})
+
+		lib.Success(ctx, "Email changed successfully", user)
+	}
+}
+
+// RevertEmailChange godoc
+// @Summary Revert email change
+// @Description Put back the previous email with the link sent to it, and sign the account out everywhere
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.RevertEmailChangeRequest true "Revert token"
+// @Success 200 {object} lib.Response
+// @Failure 400 {object} lib.Response
+// @Router /auth/email/revert [post]
+func (h *EmailChangeHandler) RevertEmailChange() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.RevertEmailChangeRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		user, changedEmail, err := h.service.RevertChange(payload.Token)
+		if err != nil {
+			handleEmailChangeError(ctx, err, "Failed to revert email change: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			ActorID:    user.ID.String(),
+			Action:     models.AuditEmailReverted,
+			EntityType: "user",
+			EntityID:   user.ID.String(),
+			Before:     map[string]interface{}{"email": changedEmail},
+			After:      map[string]interface{}{"email": user.Email},
+		})
+
+		lib.Success(ctx, "Email restored and all sessions signed out. Reset your password if you did not make the change.", nil)
+	}
+}
+
+func handleEmailChangeError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrIncorrectPassword):
+		lib.BadRequest(ctx, err.Error(), "INCORRECT_PASSWORD")
+	case errors.Is(err, services.ErrEmailUnchanged):
+		lib.BadRequest(ctx, err.Error(), "EMAIL_UNCHANGED")
+	case errors.Is(err, services.ErrEmailInUse):
+		lib.BadRequest(ctx, err.Error(), "EMAIL_IN_USE")
+	case errors.Is(err, services.ErrEmailRevertUnavailable):
+		lib.BadRequest(ctx, err.Error(), "EMAIL_REVERT_UNAVAILABLE")
+	case errors.Is(err, services.ErrUserNotFound):
+		lib.NotFound(ctx, err.Error(), "USER_NOT_FOUND")
+	default:
+		handleVerificationTokenError(ctx, err, prefix)
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IdentityHandler struct {
+	service *services.IdentityService
+}
+
+func NewIdentityHandler() *IdentityHandler {
+	return &IdentityHandler{
+		service: services.NewIdentityService(database.GetDatabase()),
+	}
+}
+
+// GetProviders godoc
+// @Summary List sign-in providers
+// @Description List the identity providers users can sign in with or link
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} services.OAuthProvider
+// @Router /auth/providers [get]
+func (h *IdentityHandler) GetProviders() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lib.Success(ctx, "Providers retrieved successfully", services.OAuthProviders())
+	}
+}
+
+// GetIdentities godoc
+// @Summary List linked providers
+// @Description List the provider accounts linked to the account
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.UserIdentity
+// @Failure 401 {object} lib.Response
+// @Router /auth/identities [get]
+func (h *IdentityHandler) GetIdentities() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		identities, err := h.service.GetIdentities(userID)
+		if err != nil {
+			handleOAuthError(ctx, err, "Failed to get linked providers: ")
+			return
+		}
+
+		lib.Success(ctx, "Linked providers retrieved successfully", identities)
+	}
+}
+
+// BeginLink godoc
+// @Summary Start linking a provider
+// @Description Get the provider's authorization URL to link another account to this one. The state is also set in an HTTP-only cookie.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Provider name"
+// @Success 200 {string} string "Authorization URL"
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /auth/identities/{provider} [post]
+func (h *IdentityHandler) BeginLink() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, exists := ctx.Get("current_user")
+		if !exists {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		userID := user.(*models.User).ID
+		url, state, err := h.service.BeginAuthorization(ctx.Param("provider"), models.OAuthIntentLink, &userID)
+		if err != nil {
+			handleOAuthError(ctx, err, "Failed to start linking provider: ")
+			return
+		}
+
+		setAuthCookie(ctx, oauthStateCookie, state, int(services.OAuthStateTTL.Seconds()))
+		lib.Success(ctx, "OAuth URL generated successfully", url)
+	}
+}
+
+// FinishLink godoc
+// @Summary Finish linking a provider
+// @Description Exchange the code the provider redirected back with and link the provider account
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Provider name"
+// @Param request body dto.OAuthCallbackDto true "Code and state returned by the provider"
+// @Success 201 {object} models.UserIdentity
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /auth/identities/{provider}/callback [post]
+func (h *IdentityHandler) FinishLink() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, exists := ctx.Get("current_user")
+		if !exists {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.OAuthCallbackDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		browserState, _ := ctx.Cookie(oauthStateCookie)
+		setAuthCookie(ctx, oauthStateCookie, "", -1)
+
+		userID := user.(*models.User).ID
+		account, err := h.service.CompleteAuthorization(ctx.Param("provider"), payload, browserState, models.OAuthIntentLink, &userID)
+		if err != nil {
+			handleOAuthError(ctx, err, "Failed to link provider: ")
+			return
+		}
+
+		identity, err := h.service.Link(userID, account)
+		if err != nil {
+			handleOAuthError(ctx, err, "Failed to link provider: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditProviderLinked,
+			EntityType: "user_identity",
+			EntityID:   identity.ID.String(),
+			Metadata:   map[string]interface{}{"provider": identity.Provider},
+		})
+
+		lib.Created(ctx, "Provider linked successfully", identity)
+	}
+}
+
+// Unlink godoc
+// @Summary Unlink a provider
+// @Description Remove a linked provider. The account must keep a password, a passkey or another provider to sign in with.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Provider name"
+// @Success 200 {object} lib.Response
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /auth/identities/{provider} [delete]
+func (h *IdentityHandler) Unlink() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		provider := ctx.Param("provider")
+		if err := h.service.Unlink(userID, provider); err != nil {
+			handleOAuthError(ctx, err, "Failed to unlink provider: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditProviderUnlinked,
+			EntityType: "user",
+			EntityID:   userID,
+			Metadata:   map[string]interface{}{"provider": provider},
+		})
+
+		lib.Success(ctx, "Provider unlinked successfully", nil)
+	}
+}
+
+func handleOAuthError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrUnsupportedOAuthProvider):
+		lib.BadRequest(ctx, err.Error(), "UNSUPPORTED_PROVIDER")
+	case errors.Is(err, services.ErrInvalidOAuthState):
+		lib.BadRequest(ctx, err.Error(), "INVALID_STATE")
+	case errors.Is(err, services.ErrOAuthEmailInUse):
+		lib.BadRequest(ctx, err.Error(), "EMAIL_IN_USE")
+	case errors.Is(err, services.ErrIdentityLinkedElsewhere):
+		lib.BadRequest(ctx, err.Error(), "IDENTITY_LINKED_ELSEWHERE")
+	case errors.Is(err, services.ErrProviderAlreadyLinked):
+		lib.BadRequest(ctx, err.Error(), "PROVIDER_ALREADY_LINKED")
+	case errors.Is(err, services.ErrLastSignInMethod):
+		lib.BadRequest(ctx, err.Error(), "LAST_SIGN_IN_METHOD")
+	case errors.Is(err, services.ErrIdentityNotFound):
+		lib.NotFound(ctx, err.Error(), "IDENTITY_NOT_FOUND")
+	case errors.Is(err, services.ErrAccountSuspended):
+		lib.Forbidden(ctx, "Your account has been suspended")
+	default:
+		lib.InternalServerError(ctx, prefix+err.Error())
+	}
+}
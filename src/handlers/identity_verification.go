@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IdentityVerificationHandler struct {
+	service *services.IdentityVerificationService
+}
+
+func NewIdentityVerificationHandler(hub *lib.Hub) *IdentityVerificationHandler {
+	return &IdentityVerificationHandler{
+		service: services.NewIdentityVerificationService(
+			database.GetDatabase(),
+			services.NewNotificationService(database.GetDatabase(), hub),
+		),
+	}
+}
+
+func (h *IdentityVerificationHandler) SubmitVerification() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Leave headroom for the other multipart fields.
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, services.MaxIdentityDocumentSize+1<<20)
+
+		var payload dto.SubmitIdentityVerificationDto
+		if err := ctx.ShouldBind(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		file, header, err := ctx.Request.FormFile("document")
+		if err != nil {
+			lib.BadRequest(ctx, "document field is required", "DOCUMENT_REQUIRED")
+			return
+		}
+		defer func() {
+			if err = file.Close(); err != nil {
+				log.Printf("Error closing file: %v", err)
+			}
+		}()
+
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		verification, err := h.service.SubmitVerification(userID, payload, header)
+		if err != nil {
+			handleIdentityVerificationError(ctx, err, "Failed to submit verification: ")
+			return
+		}
+
+		lib.Created(ctx, "Verification submitted successfully", verification)
+	}
+}
+
+func (h *IdentityVerificationHandler) GetMyVerification() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		verification, err := h.service.GetVerification(userID)
+		if err != nil {
+			handleIdentityVerificationError(ctx, err, "Failed to get verification: ")
+			return
+		}
+
+		lib.Success(ctx, "Verification retrieved successfully", verification)
+	}
+}
+
+func (h *IdentityVerificationHandler) GetVerificationQueue() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var params dto.VerificationQueueParams
+		if err := ctx.ShouldBindQuery(&params); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		verifications, err := h.service.GetVerificationQueue(params)
+		if err != nil {
+			handleIdentityVerificationError(ctx, err, "Failed to get verification queue: ")
+			return
+		}
+
+		lib.Success(ctx, "Verification queue retrieved successfully", verifications)
+	}
+}
+
+func (h *IdentityVerificationHandler) ApproveVerification() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		adminID := ctx.GetString(config.AppConfig.CurrentUserId)
+		verification, err := h.service.ApproveVerification(adminID, ctx.Param("id"))
+		if err != nil {
+			handleIdentityVerificationError(ctx, err, "Failed to approve verification: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditIdentityApproved,
+			EntityType: "identity_verification",
+			EntityID:   verification.ID.String(),
+			Metadata:   map[string]interface{}{"user_id": verification.UserID.String(), "type": verification.Type},
+		})
+
+		lib.Success(ctx, "Verification approved successfully", verification)
+	}
+}
+
+func (h *IdentityVerificationHandler) RejectVerification() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.RejectVerificationDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		adminID := ctx.GetString(config.AppConfig.CurrentUserId)
+		verification, err := h.service.RejectVerification(adminID, ctx.Param("id"), payload)
+		if err != nil {
+			handleIdentityVerificationError(ctx, err, "Failed to reject verification: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditIdentityRejected,
+			EntityType: "identity_verification",
+			EntityID:   verification.ID.String(),
+			Metadata:   map[string]interface{}{"user_id": verification.UserID.String(), "reason": payload.Reason},
+		})
+
+		lib.Success(ctx, "Verification rejected successfully", verification)
+	}
+}
+
+func (h *IdentityVerificationHandler) GetVerificationDocument() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		verification, reader, err := h.service.OpenDocument(ctx.Param("id"))
+		if err != nil {
+			handleIdentityVerificationError(ctx, err, "Failed to get verification document: ")
+			return
+		}
+		defer func() {
+			if err := reader.Close(); err != nil {
+				log.Printf("Error closing verification document: %v", err)
+			}
+		}()
+
+		ctx.Header("Cache-Control", "private, no-store")
+		ctx.DataFromReader(http.StatusOK, verification.DocumentSize, verification.DocumentMimeType, reader, map[string]string{
+			"Content-Disposition": fmt.Sprintf("inline; filename=%q", verification.DocumentName),
+		})
+	}
+}
+
+func handleIdentityVerificationError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidVerificationNumber):
+		lib.BadRequest(ctx, err.Error(), "INVALID_VERIFICATION_NUMBER")
+	case errors.Is(err, services.ErrVerificationNumberInUse):
+		lib.BadRequest(ctx, err.Error(), "VERIFICATION_NUMBER_IN_USE")
+	case errors.Is(err, services.ErrVerificationPending):
+		lib.BadRequest(ctx, err.Error(), "VERIFICATION_PENDING")
+	case errors.Is(err, services.ErrAlreadyIdentityVerified):
+		lib.BadRequest(ctx, err.Error(), "IDENTITY_ALREADY_VERIFIED")
+	case errors.Is(err, services.ErrNoPendingVerification):
+		lib.BadRequest(ctx, err.Error(), "NO_PENDING_VERIFICATION")
+	case errors.Is(err, services.ErrAttachmentTooLarge):
+		lib.BadRequest(ctx, err.Error(), "DOCUMENT_TOO_LARGE")
+	case errors.Is(err, services.ErrUnsupportedAttachment):
+		lib.BadRequest(ctx, err.Error(), "UNSUPPORTED_DOCUMENT")
+	case errors.Is(err, services.ErrUserNotFound):
+		lib.NotFound(ctx, err.Error(), "USER_NOT_FOUND")
+	case errors.Is(err, services.ErrVerificationNotFound):
+		lib.NotFound(ctx, err.Error(), "VERIFICATION_NOT_FOUND")
+	default:
+		lib.InternalServerError(ctx, prefix+err.Error())
+	}
+}
@@ -15,9 +15,9 @@ type JobHandler struct {
 	service *services.JobService
 }
 
-func NewJobHandler() *JobHandler {
+func NewJobHandler(hub *lib.Hub) *JobHandler {
 	return &JobHandler{
-		service: services.NewJobService(database.GetDatabase(), services.NewNotificationService(database.GetDatabase(), lib.NewHub())),
+		service: services.NewJobService(database.GetDatabase(), services.NewNotificationService(database.GetDatabase(), hub)),
 	}
 }
 
@@ -225,12 +225,16 @@ func (h *JobHandler) AcceptApplication() gin.HandlerFunc {
 			return
 		}
 
+		before, _ := h.service.GetApplicationById(applicationId)
+
 		application, err := h.service.AcceptApplication(id, applicationId, payload.Reason)
 		if err != nil {
 			lib.InternalServerError(ctx, "Internal server error,"+err.Error())
 			return
 		}
 
+		auditApplicationStatus(ctx, before, application)
+
 		lib.Success(ctx, "Application accepted successfully", application)
 	}
 }
@@ -246,12 +250,16 @@ func (h *JobHandler) RejectApplication() gin.HandlerFunc {
 			return
 		}
 
+		before, _ := h.service.GetApplicationById(applicationId)
+
 		application, err := h.service.RejectApplication(id, applicationId, payload.Reason)
 		if err != nil {
 			lib.InternalServerError(ctx, "Internal server error,"+err.Error())
 			return
 		}
 
+		auditApplicationStatus(ctx, before, application)
+
 		lib.Success(ctx, "Application rejected successfully", application)
 	}
 }
@@ -267,12 +275,16 @@ func (h *JobHandler) ReviewApplication() gin.HandlerFunc {
 			return
 		}
 
+		before, _ := h.service.GetApplicationById(applicationId)
+
 		application, err := h.service.ReviewApplication(id, applicationId, payload.Reason)
 		if err != nil {
 			lib.InternalServerError(ctx, "Internal server error,"+err.Error())
 			return
 		}
 
+		auditApplicationStatus(ctx, before, application)
+
 		lib.Success(ctx, "Application marked as reviewed", application)
 	}
 }
@@ -288,12 +300,16 @@ func (h *JobHandler) HireApplication() gin.HandlerFunc {
 			return
 		}
 
+		before, _ := h.service.GetApplicationById(applicationId)
+
 		application, err := h.service.HireApplication(id, applicationId, payload.Reason)
 		if err != nil {
 			lib.InternalServerError(ctx, "Internal server error,"+err.Error())
 			return
 		}
 
+		auditApplicationStatus(ctx, before, application)
+
 		lib.Success(ctx, "Applicant hired successfully", application)
 	}
 }
@@ -382,3 +398,15 @@ func (h *JobHandler) RemoveReaction() gin.HandlerFunc {
 		lib.Success(ctx, "Reaction removed successfully", nil)
 	}
 }
+
+// auditApplicationStatus records a recruiter moving an application to a new status.
+func auditApplicationStatus(ctx *gin.Context, before, after *models.JobApplication) {
+	recordAudit(ctx, services.AuditEntry{
+		Action:     models.AuditApplicationStatusChanged,
+		EntityType: "job_application",
+		EntityID:   after.ID.String(),
+		Before:     before,
+		After:      after,
+		Metadata:   map[string]interface{}{"job_id": after.JobID.String(), "status": after.Status},
+	})
+}
@@ -14,9 +14,9 @@ type NotificationHandler struct {
 	service *services.NotificationService
 }
 
-func NewNotificationHandler() *NotificationHandler {
+func NewNotificationHandler(hub *lib.Hub) *NotificationHandler {
 	return &NotificationHandler{
-		service: services.NewNotificationService(database.GetDatabase(), lib.NewHub()),
+		service: services.NewNotificationService(database.GetDatabase(), hub),
 	}
 }
 
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OutreachHandler struct {
+	service *services.OutreachService
+}
+
+func NewOutreachHandler(hub *lib.Hub) *OutreachHandler {
+	notificationService := services.NewNotificationService(database.GetDatabase(), hub)
+	return &OutreachHandler{
+		service: services.NewOutreachService(
+			database.GetDatabase(),
+			services.NewChatService(database.GetDatabase(), hub, notificationService),
+			services.NewJobService(database.GetDatabase(), notificationService),
+		),
+	}
+}
+
+func (h *OutreachHandler) GetTemplates() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		templates, err := h.service.GetTemplates(userID)
+		if err != nil {
+			handleOutreachError(ctx, err, "Failed to get templates: ")
+			return
+		}
+
+		lib.Success(ctx, "Templates retrieved successfully", templates)
+	}
+}
+
+func (h *OutreachHandler) CreateTemplate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.CreateMessageTemplateDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		template, err := h.service.CreateTemplate(userID, payload)
+		if err != nil {
+			handleOutreachError(ctx, err, "Failed to create template: ")
+			return
+		}
+
+		lib.Created(ctx, "Template created successfully", template)
+	}
+}
+
+func (h *OutreachHandler) UpdateTemplate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.UpdateMessageTemplateDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		template, err := h.service.UpdateTemplate(userID, ctx.Param("id"), payload)
+		if err != nil {
+			handleOutreachError(ctx, err, "Failed to update template: ")
+			return
+		}
+
+		lib.Success(ctx, "Template updated successfully", template)
+	}
+}
+
+func (h *OutreachHandler) DeleteTemplate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		if err := h.service.DeleteTemplate(userID, ctx.Param("id")); err != nil {
+			handleOutreachError(ctx, err, "Failed to delete template: ")
+			return
+		}
+
+		lib.Success(ctx, "Template deleted successfully", nil)
+	}
+}
+
+func (h *OutreachHandler) PreviewTemplate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.PreviewMessageTemplateDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		preview, err := h.service.PreviewTemplate(userID, ctx.Param("id"), payload)
+		if err != nil {
+			handleOutreachError(ctx, err, "Failed to preview template: ")
+			return
+		}
+
+		lib.Success(ctx, "Template rendered successfully", preview)
+	}
+}
+
+func (h *OutreachHandler) BulkSend() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.BulkSendDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		result, err := h.service.BulkSend(userID, ctx.Param("id"), payload)
+		if err != nil {
+			handleOutreachError(ctx, err, "Failed to send messages: ")
+			return
+		}
+
+		lib.Success(ctx, "Bulk send completed", result)
+	}
+}
+
+func (h *OutreachHandler) GetQuota() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		quota, err := h.service.GetQuota(userID)
+		if err != nil {
+			handleOutreachError(ctx, err, "Failed to get outreach quota: ")
+			return
+		}
+
+		lib.Success(ctx, "Outreach quota retrieved successfully", quota)
+	}
+}
+
+func handleOutreachError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTemplate):
+		lib.BadRequest(ctx, err.Error(), "INVALID_TEMPLATE")
+	case errors.Is(err, services.ErrMissingBulkContent):
+		lib.BadRequest(ctx, err.Error(), "MISSING_CONTENT")
+	case errors.Is(err, services.ErrTooManyRecipients):
+		lib.BadRequest(ctx, err.Error(), "TOO_MANY_RECIPIENTS")
+	case errors.Is(err, services.ErrOutreachLimitReached):
+		lib.BadRequest(ctx, err.Error(), "OUTREACH_LIMIT_REACHED")
+	case err.Error() == "invalid job ID":
+		lib.BadRequest(ctx, err.Error(), "INVALID_JOB_ID")
+	case errors.Is(err, services.ErrTemplateNotFound):
+		lib.NotFound(ctx, err.Error(), "TEMPLATE_NOT_FOUND")
+	case errors.Is(err, services.ErrApplicationNotFound):
+		lib.NotFound(ctx, err.Error(), "APPLICATION_NOT_FOUND")
+	case err.Error() == "job not found or unauthorized":
+		lib.NotFound(ctx, err.Error(), "JOB_NOT_FOUND")
+	case errors.Is(err, services.ErrRecruiterOnly), err.Error() == "only recruiters can view applications":
+		lib.Forbidden(ctx, err.Error())
+	default:
+		lib.InternalServerError(ctx, prefix+err.Error())
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"foglio/v2/src/database"
 	"foglio/v2/src/dto"
 	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
 	"foglio/v2/src/services"
 
 	"github.com/gin-gonic/gin"
@@ -82,6 +83,16 @@ func (h *PaystackHandler) VerifyPayment() gin.HandlerFunc {
 			return
 		}
 
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditPaymentVerified,
+			EntityType: "payment",
+			EntityID:   txData.Reference,
+			Metadata: map[string]interface{}{
+				"amount":   float64(txData.Amount) / 100,
+				"currency": txData.Currency,
+			},
+		})
+
 		lib.Success(ctx, "Payment verified and subscription activated", map[string]interface{}{
 			"status":    txData.Status,
 			"reference": txData.Reference,
@@ -116,11 +127,23 @@ func (h *PaystackHandler) Webhook() gin.HandlerFunc {
 			return
 		}
 
+		reference, _ := event.Data["reference"].(string)
+		entry := services.AuditEntry{
+			Action:     models.AuditPaymentWebhook,
+			EntityType: "payment",
+			EntityID:   reference,
+			Metadata:   map[string]interface{}{"event": event.Event},
+		}
+
 		if err := h.service.HandleWebhook(&event); err != nil {
+			entry.Metadata["error"] = err.Error()
+			recordAudit(ctx, entry)
 			ctx.JSON(200, gin.H{"status": "error", "message": err.Error()})
 			return
 		}
 
+		recordAudit(ctx, entry)
+
 		ctx.JSON(200, gin.H{"status": "success"})
 	}
 }
@@ -138,6 +161,12 @@ func (h *PaystackHandler) CancelSubscription() gin.HandlerFunc {
 			return
 		}
 
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditSubscriptionCancelled,
+			EntityType: "user_subscription",
+			Metadata:   map[string]interface{}{"provider": "paystack"},
+		})
+
 		lib.Success(ctx, "Subscription cancelled successfully", nil)
 	}
 }
@@ -1,23 +1,29 @@
 package handlers
 
 import (
+	"bytes"
 	"errors"
 	"foglio/v2/src/config"
 	"foglio/v2/src/database"
 	"foglio/v2/src/dto"
 	"foglio/v2/src/lib"
 	"foglio/v2/src/services"
+	"log"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type PortfolioHandler struct {
-	service *services.PortfolioService
+	service   *services.PortfolioService
+	siteHosts *services.SiteHostService
 }
 
 func NewPortfolioHandler() *PortfolioHandler {
 	return &PortfolioHandler{
-		service: services.NewPortfolioService(database.GetDatabase()),
+		service:   services.NewPortfolioService(database.GetDatabase()),
+		siteHosts: services.NewSiteHostService(database.GetDatabase()),
 	}
 }
 
@@ -84,6 +90,61 @@ func (h *PortfolioHandler) GetPortfolioBySlug() gin.HandlerFunc {
 	}
 }
 
+// RenderPortfolio serves a published portfolio as a complete HTML page using its template and theme
+func (h *PortfolioHandler) RenderPortfolio() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		site, err := h.service.GetPortfolioSite(ctx.Param("slug"))
+		renderSite(ctx, site, err)
+	}
+}
+
+// RenderHostPortfolio renders the published portfolio served at the request's host, a claimed subdomain
+// or verified custom domain. Sites only have a home page; every other path is not found.
+func (h *PortfolioHandler) RenderHostPortfolio() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, err := h.siteHosts.ResolveHost(ctx.Request.Host)
+		if err != nil {
+			renderSiteError(ctx, err)
+			return
+		}
+
+		switch {
+		case ctx.Request.URL.Path == "/robots.txt":
+			ctx.Header("Cache-Control", "public, max-age=3600")
+			ctx.String(http.StatusOK, "User-agent: *\nAllow: /\n")
+		case ctx.Request.URL.Path != "/" || (ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead):
+			renderSiteError(ctx, services.ErrPortfolioNotFound)
+		default:
+			site, err := h.service.GetPortfolioSiteByUser(userID)
+			renderSite(ctx, site, err)
+		}
+	}
+}
+
+// renderSite answers with a portfolio's page, or with an error page when it could not be loaded.
+func renderSite(ctx *gin.Context, site *dto.PortfolioSite, err error) {
+	if err != nil {
+		renderSiteError(ctx, err)
+		return
+	}
+
+	var page bytes.Buffer
+	if err := lib.GetSiteRenderer().Render(&page, site.Template, site); err != nil {
+		renderSiteError(ctx, err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// GetTemplates lists the built-in portfolio site templates
+func (h *PortfolioHandler) GetTemplates() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lib.Success(ctx, "Portfolio templates retrieved successfully", lib.SiteTemplates)
+	}
+}
+
 // UpdatePortfolio updates the authenticated user's portfolio
 func (h *PortfolioHandler) UpdatePortfolio() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -279,6 +340,8 @@ func handlePortfolioError(ctx *gin.Context, err error) {
 		lib.BadRequest(ctx, "This slug is already taken", "SLUG_TAKEN")
 	case errors.Is(err, services.ErrSlugInvalid):
 		lib.BadRequest(ctx, "Slug must be 3-50 characters, lowercase letters, numbers, and hyphens only", "SLUG_INVALID")
+	case errors.Is(err, services.ErrTemplateInvalid):
+		lib.BadRequest(ctx, "Template must be one of: "+strings.Join(lib.SiteTemplates, ", "), "TEMPLATE_INVALID")
 	case errors.Is(err, services.ErrSectionNotFound):
 		lib.NotFound(ctx, "Section not found", "SECTION_NOT_FOUND")
 	case errors.Is(err, services.ErrUnauthorized):
@@ -287,3 +350,20 @@ func handlePortfolioError(ctx *gin.Context, err error) {
 		lib.InternalServerError(ctx, err.Error())
 	}
 }
+
+// renderSiteError answers a portfolio page request that failed with a branded HTML page rather than JSON.
+func renderSiteError(ctx *gin.Context, err error) {
+	site := services.NewSiteError(err)
+	if site.Status == http.StatusInternalServerError {
+		log.Printf("Failed to render portfolio site: %v", err)
+	}
+
+	var page bytes.Buffer
+	if err := lib.GetSiteRenderer().RenderError(&page, site); err != nil {
+		log.Printf("Failed to render portfolio error page: %v", err)
+		ctx.String(site.Status, http.StatusText(site.Status))
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(site.Status, "text/html; charset=utf-8", page.Bytes())
+}
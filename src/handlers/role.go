@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	service *services.RoleService
+}
+
+func NewRoleHandler() *RoleHandler {
+	return &RoleHandler{
+		service: services.NewRoleService(database.GetDatabase()),
+	}
+}
+
+func (h *RoleHandler) GetPermissions() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lib.Success(ctx, "Permissions retrieved successfully", models.AllPermissions)
+	}
+}
+
+func (h *RoleHandler) GetRoles() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		roles, err := h.service.ListRoles()
+		if err != nil {
+			handleRoleError(ctx, err, "Failed to get roles: ")
+			return
+		}
+
+		lib.Success(ctx, "Roles retrieved successfully", roles)
+	}
+}
+
+func (h *RoleHandler) CreateRole() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.CreateRoleDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		role, err := h.service.CreateRole(payload)
+		if err != nil {
+			handleRoleError(ctx, err, "Failed to create role: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditRoleCreated,
+			EntityType: "role",
+			EntityID:   role.ID.String(),
+			After:      role,
+		})
+
+		lib.Created(ctx, "Role created successfully", role)
+	}
+}
+
+func (h *RoleHandler) UpdateRole() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.UpdateRoleDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		before, _ := h.service.GetRole(ctx.Param("id"))
+
+		role, err := h.service.UpdateRole(ctx.Param("id"), payload)
+		if err != nil {
+			handleRoleError(ctx, err, "Failed to update role: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditRoleUpdated,
+			EntityType: "role",
+			EntityID:   role.ID.String(),
+			Before:     before,
+			After:      role,
+		})
+
+		lib.Success(ctx, "Role updated successfully", role)
+	}
+}
+
+func (h *RoleHandler) DeleteRole() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		before, _ := h.service.GetRole(ctx.Param("id"))
+
+		if err := h.service.DeleteRole(ctx.Param("id")); err != nil {
+			handleRoleError(ctx, err, "Failed to delete role: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditRoleDeleted,
+			EntityType: "role",
+			EntityID:   ctx.Param("id"),
+			Before:     before,
+		})
+
+		lib.Success(ctx, "Role deleted successfully", nil)
+	}
+}
+
+func (h *RoleHandler) GetUserRoles() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		assignments, err := h.service.GetUserRoles(ctx.Param("id"))
+		if err != nil {
+			handleRoleError(ctx, err, "Failed to get user roles: ")
+			return
+		}
+
+		lib.Success(ctx, "User roles retrieved successfully", assignments)
+	}
+}
+
+func (h *RoleHandler) AssignRole() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.AssignRoleDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		adminID := ctx.GetString(config.AppConfig.CurrentUserId)
+		assignment, err := h.service.AssignRole(ctx.Param("id"), payload.RoleID, adminID)
+		if err != nil {
+			handleRoleError(ctx, err, "Failed to assign role: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditRoleAssigned,
+			EntityType: "user",
+			EntityID:   ctx.Param("id"),
+			Metadata:   map[string]interface{}{"role_id": assignment.RoleID.String(), "role": assignment.Role.Name},
+		})
+
+		lib.Created(ctx, "Role assigned successfully", assignment)
+	}
+}
+
+func (h *RoleHandler) RevokeRole() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := h.service.RevokeRole(ctx.Param("id"), ctx.Param("roleId")); err != nil {
+			handleRoleError(ctx, err, "Failed to revoke role: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditRoleRevoked,
+			EntityType: "user",
+			EntityID:   ctx.Param("id"),
+			Metadata:   map[string]interface{}{"role_id": ctx.Param("roleId")},
+		})
+
+		lib.Success(ctx, "Role revoked successfully", nil)
+	}
+}
+
+func (h *RoleHandler) GetMyAccess() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userID == "" {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		access, err := h.service.GetUserAccess(userID)
+		if err != nil {
+			handleRoleError(ctx, err, "Failed to get permissions: ")
+			return
+		}
+
+		lib.Success(ctx, "Permissions retrieved successfully", access)
+	}
+}
+
+func handleRoleError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPermission):
+		lib.BadRequest(ctx, err.Error(), "INVALID_PERMISSION")
+	case errors.Is(err, services.ErrRoleNameTaken):
+		lib.BadRequest(ctx, err.Error(), "ROLE_NAME_TAKEN")
+	case errors.Is(err, services.ErrRoleAlreadyAssigned):
+		lib.BadRequest(ctx, err.Error(), "ROLE_ALREADY_ASSIGNED")
+	case errors.Is(err, services.ErrLastSuperAdmin):
+		lib.BadRequest(ctx, err.Error(), "LAST_SUPER_ADMIN")
+	case errors.Is(err, services.ErrSystemRoleImmutable):
+		lib.Forbidden(ctx, err.Error())
+	case errors.Is(err, services.ErrRoleNotFound):
+		lib.NotFound(ctx, err.Error(), "ROLE_NOT_FOUND")
+	case errors.Is(err, services.ErrRoleNotAssigned):
+		lib.NotFound(ctx, err.Error(), "ROLE_NOT_ASSIGNED")
+	case errors.Is(err, services.ErrUserNotFound):
+		lib.NotFound(ctx, err.Error(), "USER_NOT_FOUND")
+	default:
+		lib.InternalServerError(ctx, prefix+err.Error())
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	service *services.SessionService
+}
+
+func NewSessionHandler() *SessionHandler {
+	return &SessionHandler{
+		service: services.NewSessionService(database.GetDatabase()),
+	}
+}
+
+func (h *SessionHandler) RefreshToken() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.RefreshTokenDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		session, tokens, err := h.service.Refresh(payload.RefreshToken, sessionClient(ctx))
+		if err != nil {
+			if errors.Is(err, services.ErrRefreshTokenReused) {
+				recordAudit(ctx, services.AuditEntry{
+					ActorID:    session.UserID.String(),
+					Action:     models.AuditRefreshTokenReused,
+					EntityType: "session",
+					EntityID:   session.ID.String(),
+				})
+			}
+			handleSessionError(ctx, err, "Failed to refresh token: ")
+			return
+		}
+
+		lib.Success(ctx, "Token refreshed successfully", tokens)
+	}
+}
+
+func (h *SessionHandler) GetSessions() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		sessions, err := h.service.ListSessions(userID, ctx.GetString("session_id"))
+		if err != nil {
+			handleSessionError(ctx, err, "Failed to get sessions: ")
+			return
+		}
+
+		lib.Success(ctx, "Sessions retrieved successfully", sessions)
+	}
+}
+
+func (h *SessionHandler) RevokeSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		sessionID := ctx.Param("id")
+		if err := h.service.RevokeSession(userID, sessionID); err != nil {
+			handleSessionError(ctx, err, "Failed to revoke session: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditSessionRevoked,
+			EntityType: "session",
+			EntityID:   sessionID,
+		})
+
+		lib.Success(ctx, "Session revoked successfully", nil)
+	}
+}
+
+// Logout ends the session the request was made with.
+func (h *SessionHandler) Logout() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sessionID := ctx.GetString("session_id")
+		if sessionID == "" {
+			lib.BadRequest(ctx, "Request is not signed in with a session", "NO_SESSION")
+			return
+		}
+
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if err := h.service.RevokeSession(userID, sessionID); err != nil {
+			handleSessionError(ctx, err, "Failed to sign out: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditSignedOut,
+			EntityType: "session",
+			EntityID:   sessionID,
+		})
+
+		lib.Success(ctx, "Signed out successfully", nil)
+	}
+}
+
+// LogoutEverywhere ends every session of the user, including the current one.
+func (h *SessionHandler) LogoutEverywhere() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetString("session_id") == "" {
+			lib.BadRequest(ctx, "Request is not signed in with a session", "NO_SESSION")
+			return
+		}
+
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		revoked, err := h.service.RevokeAllSessions(userID, "")
+		if err != nil {
+			handleSessionError(ctx, err, "Failed to sign out: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditSignedOutEverywhere,
+			EntityType: "user",
+			EntityID:   userID,
+			Metadata:   map[string]interface{}{"sessions_revoked": revoked},
+		})
+
+		lib.Success(ctx, "Signed out of all sessions successfully", map[string]interface{}{"sessions_revoked": revoked})
+	}
+}
+
+// trustedDeviceCookie holds the token of a browser remembered at the 2FA step.
+const trustedDeviceCookie = "foglio_trusted_device"
+
+// sessionClient describes the device making the request, recorded on the sessions it signs in.
+func sessionClient(ctx *gin.Context) dto.SessionClient {
+	trustedDevice, _ := ctx.Cookie(trustedDeviceCookie)
+	return dto.SessionClient{
+		UserAgent:          ctx.Request.UserAgent(),
+		IPAddress:          ctx.ClientIP(),
+		TrustedDeviceToken: trustedDevice,
+	}
+}
+
+// oauthStateCookie binds an OAuth authorization request to the browser that started it.
+const oauthStateCookie = "foglio_oauth_state"
+
+// setAuthCookie stores the value in an HTTP-only cookie. A negative maxAge clears it.
+func setAuthCookie(ctx *gin.Context, name, value string, maxAge int) {
+	// The client app is served from another origin, so the cookie has to be sent on cross-site requests
+	sameSite := http.SameSiteNoneMode
+	if config.AppConfig.IsDevMode {
+		sameSite = http.SameSiteLaxMode
+	}
+	ctx.SetSameSite(sameSite)
+	ctx.SetCookie(name, value, maxAge, "/", config.AppConfig.CookieDomain, !config.AppConfig.IsDevMode, true)
+}
+
+func handleSessionError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRefreshToken):
+		lib.Unauthorized(ctx, err.Error())
+	case errors.Is(err, services.ErrRefreshTokenReused):
+		lib.Unauthorized(ctx, "Refresh token has already been used; the session has been revoked")
+	case errors.Is(err, services.ErrAccountSuspended):
+		lib.Forbidden(ctx, "Your account has been suspended")
+	case errors.Is(err, services.ErrSessionNotFound):
+		lib.NotFound(ctx, err.Error(), "SESSION_NOT_FOUND")
+	default:
+		lib.InternalServerError(ctx, prefix+err.Error())
+	}
+}
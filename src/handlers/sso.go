@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SSOHandler struct {
+	service     *services.SSOService
+	authService *services.AuthService
+}
+
+func NewSSOHandler() *SSOHandler {
+	db := database.GetDatabase()
+	return &SSOHandler{
+		service:     services.NewSSOService(db),
+		authService: services.NewAuthService(db),
+	}
+}
+
+// GetConfig godoc
+// @Summary Get company SSO setup
+// @Description Get the SAML setup of the recruiter's company, with the entity ID and ACS URL to enter at the IdP
+// @Tags SSO
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.SSOConfigResponse
+// @Failure 401 {object} lib.Response
+// @Failure 403 {object} lib.Response
+// @Router /sso/config [get]
+func (h *SSOHandler) GetConfig() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		sso, err := h.service.GetConfig(userID)
+		if err != nil {
+			handleSSOError(ctx, err, "Failed to get SSO setup: ")
+			return
+		}
+
+		lib.Success(ctx, "SSO setup retrieved successfully", sso)
+	}
+}
+
+// UpdateConfig godoc
+// @Summary Update company SSO setup
+// @Description Upload the IdP metadata, as XML or a URL, and turn SSO and its enforcement on or off
+// @Tags SSO
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.UpdateSSOConfigRequest true "IdP metadata and settings"
+// @Success 200 {object} dto.SSOConfigResponse
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Failure 403 {object} lib.Response
+// @Router /sso/config [put]
+func (h *SSOHandler) UpdateConfig() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.UpdateSSOConfigRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		sso, err := h.service.UpdateConfig(userID, payload)
+		if err != nil {
+			handleSSOError(ctx, err, "Failed to update SSO setup: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditSSOUpdated,
+			EntityType: "company",
+			EntityID:   sso.CompanyID.String(),
+			Metadata: map[string]interface{}{
+				"enabled":       sso.Enabled,
+				"enforce_sso":   sso.EnforceSSO,
+				"idp_entity_id": sso.IdPEntityID,
+			},
+		})
+
+		lib.Success(ctx, "SSO setup updated successfully", sso)
+	}
+}
+
+// DeleteConfig godoc
+// @Summary Remove company SSO
+// @Description Turn SSO off and forget the IdP and the company's SSO domains
+// @Tags SSO
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Failure 403 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /sso/config [delete]
+func (h *SSOHandler) DeleteConfig() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, exists := ctx.Get("current_user")
+		if !exists {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		if err := h.service.DeleteConfig(user.(*models.User).ID.String()); err != nil {
+			handleSSOError(ctx, err, "Failed to remove SSO setup: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditSSORemoved,
+			EntityType: "company",
+			EntityID:   user.(*models.User).CompanyID.String(),
+		})
+
+		lib.Success(ctx, "SSO removed successfully", nil)
+	}
+}
+
+// AddDomain godoc
+// @Summary Claim an SSO email domain
+// @Description Claim an email domain for the company and get the TXT record that proves ownership
+// @Tags SSO
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.AddSSODomainRequest true "Email domain"
+// @Success 201 {object} models.CompanySSODomain
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Failure 403 {object} lib.Response
+// @Router /sso/domains [post]
+func (h *SSOHandler) AddDomain() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.AddSSODomainRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		domain, err := h.service.AddDomain(userID, payload.Domain)
+		if err != nil {
+			handleSSOError(ctx, err, "Failed to add domain: ")
+			return
+		}
+
+		lib.Created(ctx, "Domain added, create the DNS record and verify it", domain)
+	}
+}
+
+// VerifyDomain godoc
+// @Summary Verify an SSO email domain
+// @Description Check the domain's TXT record. Only verified domains route sign-ins to the IdP.
+// @Tags SSO
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Domain ID"
+// @Success 200 {object} models.CompanySSODomain
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /sso/domains/{id}/verify [post]
+func (h *SSOHandler) VerifyDomain() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		domain, err := h.service.VerifyDomain(userID, ctx.Param("id"))
+		if err != nil {
+			handleSSOError(ctx, err, "Failed to verify domain: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditSSODomainVerified,
+			EntityType: "company",
+			EntityID:   domain.CompanyID.String(),
+			Metadata:   map[string]interface{}{"domain": domain.Domain},
+		})
+
+		lib.Success(ctx, "Domain verified successfully", domain)
+	}
+}
+
+// DeleteDomain godoc
+// @Summary Remove an SSO email domain
+// @Tags SSO
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Domain ID"
+// @Success 200 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /sso/domains/{id} [delete]
+func (h *SSOHandler) DeleteDomain() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if err := h.service.DeleteDomain(userID, ctx.Param("id")); err != nil {
+			handleSSOError(ctx, err, "Failed to remove domain: ")
+			return
+		}
+
+		lib.Success(ctx, "Domain removed successfully", nil)
+	}
+}
+
+// Metadata godoc
+// @Summary Service provider metadata
+// @Description SAML metadata of the company's service provider, to upload to the IdP
+// @Tags SSO
+// @Produce xml
+// @Param companyId path string true "Company ID"
+// @Success 200 {string} string "SAML metadata"
+// @Failure 404 {object} lib.Response
+// @Router /sso/{companyId}/metadata [get]
+func (h *SSOHandler) Metadata() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		metadata, err := h.service.Metadata(ctx.Param("companyId"))
+		if err != nil {
+			handleSSOError(ctx, err, "Failed to get metadata: ")
+			return
+		}
+
+		ctx.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+	}
+}
+
+// BeginLogin godoc
+// @Summary Start signing in with SSO
+// @Description Get the IdP sign-in URL for the company that verified the email's domain
+// @Tags SSO
+// @Accept json
+// @Produce json
+// @Param request body dto.SSOLoginRequest true "Work email"
+// @Success 200 {object} dto.SSOLoginResponse
+// @Failure 400 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /sso/login [post]
+func (h *SSOHandler) BeginLogin() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.SSOLoginRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		redirect, err := h.service.BeginLogin(payload.Email)
+		if err != nil {
+			handleSSOError(ctx, err, "Failed to start SSO sign-in: ")
+			return
+		}
+
+		lib.Success(ctx, "SSO sign-in started", dto.SSOLoginResponse{URL: redirect})
+	}
+}
+
+// AssertionConsumer godoc
+// @Summary SAML assertion consumer service
+// @Description Receives the IdP's response through the browser. Redirects to the client's /auth/sso/callback with a one-time code, or with an error.
+// @Tags SSO
+// @Accept x-www-form-urlencoded
+// @Param companyId path string true "Company ID"
+// @Param SAMLResponse formData string true "Base64 SAML response"
+// @Success 302
+// @Router /sso/{companyId}/acs [post]
+func (h *SSOHandler) AssertionConsumer() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		callback := strings.TrimSuffix(config.AppConfig.ClientUrl, "/") + "/auth/sso/callback?"
+
+		code, user, err := h.service.FinishLogin(ctx.Param("companyId"), ctx.PostForm("SAMLResponse"))
+		if err != nil {
+			recordAudit(ctx, services.AuditEntry{
+				Action:     models.AuditSignInFailed,
+				EntityType: "user",
+				Metadata:   map[string]interface{}{"method": "saml", "company_id": ctx.Param("companyId"), "reason": err.Error()},
+			})
+			ctx.Redirect(http.StatusFound, callback+url.Values{"error": {err.Error()}}.Encode())
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			ActorID:    user.ID.String(),
+			Action:     models.AuditSSOAssertionAccepted,
+			EntityType: "user",
+			EntityID:   user.ID.String(),
+			Metadata:   map[string]interface{}{"company_id": ctx.Param("companyId")},
+		})
+
+		ctx.Redirect(http.StatusFound, callback+url.Values{"code": {code}}.Encode())
+	}
+}
+
+// Exchange godoc
+// @Summary Finish signing in with SSO
+// @Description Exchange the one-time code from the SSO callback for the auth tokens, or a 2FA challenge
+// @Tags SSO
+// @Accept json
+// @Produce json
+// @Param request body dto.SSOExchangeRequest true "One-time code"
+// @Success 200 {object} services.SigninResponse
+// @Failure 400 {object} lib.Response
+// @Failure 403 {object} lib.Response
+// @Router /sso/exchange [post]
+func (h *SSOHandler) Exchange() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.SSOExchangeRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		response, err := h.authService.SigninWithSSO(payload.Code, sessionClient(ctx))
+		if err != nil {
+			handleSSOError(ctx, err, "Failed to sign in with SSO: ")
+			return
+		}
+
+		if !response.RequiresTwoFactor {
+			recordAudit(ctx, services.AuditEntry{
+				ActorID:    response.User.ID.String(),
+				Action:     models.AuditSignIn,
+				EntityType: "user",
+				EntityID:   response.User.ID.String(),
+				Metadata:   map[string]interface{}{"method": "saml"},
+			})
+		}
+
+		lib.Success(ctx, "User signed in successfully", response)
+	}
+}
+
+func handleSSOError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrNotCompanyRecruiter):
+		lib.Forbidden(ctx, err.Error())
+	case errors.Is(err, services.ErrSSONotConfigured):
+		lib.NotFound(ctx, err.Error(), "SSO_NOT_CONFIGURED")
+	case errors.Is(err, services.ErrSSONotAvailable):
+		lib.NotFound(ctx, err.Error(), "SSO_NOT_AVAILABLE")
+	case errors.Is(err, services.ErrSSODomainNotFound):
+		lib.NotFound(ctx, err.Error(), "DOMAIN_NOT_FOUND")
+	case errors.Is(err, services.ErrInvalidIdPMetadata):
+		lib.BadRequest(ctx, err.Error(), "INVALID_IDP_METADATA")
+	case errors.Is(err, services.ErrIdPMetadataRequired):
+		lib.BadRequest(ctx, err.Error(), "IDP_METADATA_REQUIRED")
+	case errors.Is(err, services.ErrNoVerifiedSSODomain):
+		lib.BadRequest(ctx, err.Error(), "NO_VERIFIED_DOMAIN")
+	case errors.Is(err, services.ErrSSODomainTaken):
+		lib.BadRequest(ctx, err.Error(), "DOMAIN_TAKEN")
+	case errors.Is(err, services.ErrSSODomainNotVerified):
+		lib.BadRequest(ctx, err.Error(), "DOMAIN_NOT_VERIFIED")
+	case errors.Is(err, services.ErrInvalidVerificationToken):
+		lib.BadRequest(ctx, err.Error(), "INVALID_CODE")
+	case errors.Is(err, services.ErrAccountSuspended):
+		lib.Forbidden(ctx, "Your account has been suspended")
+	default:
+		lib.InternalServerError(ctx, prefix+err.Error())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"foglio/v2/src/database"
 	"foglio/v2/src/dto"
 	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
 	"foglio/v2/src/services"
 
 	"github.com/gin-gonic/gin"
@@ -35,6 +36,13 @@ func (h *SubscriptionHandler) CreateSubscription() gin.HandlerFunc {
 			return
 		}
 
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditTierCreated,
+			EntityType: "subscription",
+			EntityID:   subscription.ID.String(),
+			After:      subscription,
+		})
+
 		lib.Created(ctx, "Subscription created successfully", subscription)
 	}
 }
@@ -82,12 +90,22 @@ func (h *SubscriptionHandler) UpdateSubscription() gin.HandlerFunc {
 			return
 		}
 
+		before, _ := h.service.GetSubscriptionById(id)
+
 		subscription, err := h.service.UpdateSubscriptionTier(id, payload)
 		if err != nil {
 			lib.InternalServerError(ctx, "Failed to update subscription: "+err.Error())
 			return
 		}
 
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditTierUpdated,
+			EntityType: "subscription",
+			EntityID:   id,
+			Before:     before,
+			After:      subscription,
+		})
+
 		lib.Success(ctx, "Subscription updated successfully", subscription)
 	}
 }
@@ -96,11 +114,20 @@ func (h *SubscriptionHandler) DeleteSubscription() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
 
+		before, _ := h.service.GetSubscriptionById(id)
+
 		if err := h.service.DeleteSubscriptionTier(id); err != nil {
 			lib.InternalServerError(ctx, "Failed to delete subscription: "+err.Error())
 			return
 		}
 
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditTierDeleted,
+			EntityType: "subscription",
+			EntityID:   id,
+			Before:     before,
+		})
+
 		lib.Success(ctx, "Subscription deleted successfully", nil)
 	}
 }
@@ -158,6 +185,8 @@ func (h *SubscriptionHandler) Subscribe() gin.HandlerFunc {
 			return
 		}
 
+		h.auditUserSubscription(ctx, models.AuditSubscriptionStarted, userId, nil)
+
 		lib.Created(ctx, "Subscribed successfully", nil)
 	}
 }
@@ -171,12 +200,15 @@ func (h *SubscriptionHandler) Upgrade() gin.HandlerFunc {
 		}
 
 		newTierId := ctx.Param("tierId")
+		before, _ := h.service.GetUserSubscriptionByUser(userId)
 
 		if err := h.service.UpgradeUserSubscription(userId, newTierId); err != nil {
 			lib.BadRequest(ctx, err.Error(), "")
 			return
 		}
 
+		h.auditUserSubscription(ctx, models.AuditSubscriptionUpgraded, userId, before)
+
 		lib.Success(ctx, "Subscription upgraded successfully", nil)
 	}
 }
@@ -190,12 +222,15 @@ func (h *SubscriptionHandler) Downgrade() gin.HandlerFunc {
 		}
 
 		newTierId := ctx.Param("tierId")
+		before, _ := h.service.GetUserSubscriptionByUser(userId)
 
 		if err := h.service.DowngradeUserSubscription(userId, newTierId); err != nil {
 			lib.BadRequest(ctx, err.Error(), "")
 			return
 		}
 
+		h.auditUserSubscription(ctx, models.AuditSubscriptionDowngraded, userId, before)
+
 		lib.Success(ctx, "Subscription downgraded successfully", nil)
 	}
 }
@@ -208,11 +243,31 @@ func (h *SubscriptionHandler) Unsubscribe() gin.HandlerFunc {
 			return
 		}
 
+		before, _ := h.service.GetUserSubscriptionByUser(userId)
+
 		if err := h.service.UnsubscribeUser(userId); err != nil {
 			lib.BadRequest(ctx, err.Error(), "")
 			return
 		}
 
+		h.auditUserSubscription(ctx, models.AuditSubscriptionCancelled, userId, before)
+
 		lib.Success(ctx, "Unsubscribed successfully", nil)
 	}
 }
+
+// auditUserSubscription records a change to the user's subscription, reloading it for the after state.
+func (h *SubscriptionHandler) auditUserSubscription(ctx *gin.Context, action models.AuditAction, userId string, before *models.UserSubscription) {
+	after, _ := h.service.GetUserSubscriptionByUser(userId)
+
+	entry := services.AuditEntry{
+		Action:     action,
+		EntityType: "user_subscription",
+		Before:     before,
+		After:      after,
+	}
+	if after != nil {
+		entry.EntityID = after.ID.String()
+	}
+	recordAudit(ctx, entry)
+}
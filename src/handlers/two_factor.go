@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"foglio/v2/src/config"
 	"foglio/v2/src/database"
 	"foglio/v2/src/dto"
 	"foglio/v2/src/lib"
@@ -11,15 +13,17 @@ import (
 )
 
 type TwoFactorHandler struct {
-	service     *services.TwoFactorService
-	authService *services.AuthService
+	service         *services.TwoFactorService
+	authService     *services.AuthService
+	webAuthnService *services.WebAuthnService
 }
 
 func NewTwoFactorHandler() *TwoFactorHandler {
 	db := database.GetDatabase()
 	return &TwoFactorHandler{
-		service:     services.NewTwoFactorService(db),
-		authService: services.NewAuthService(db),
+		service:         services.NewTwoFactorService(db),
+		authService:     services.NewAuthService(db),
+		webAuthnService: services.NewWebAuthnService(db),
 	}
 }
 
@@ -91,20 +95,27 @@ func (h *TwoFactorHandler) VerifySetup2FA() gin.HandlerFunc {
 			return
 		}
 
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditTwoFactorEnabled,
+			EntityType: "user",
+			EntityID:   currentUser.ID.String(),
+		})
+
 		lib.Success(ctx, "2FA has been enabled successfully", nil)
 	}
 }
 
 // Verify2FALogin godoc
 // @Summary Verify 2FA during login
-// @Description Verify the TOTP code or backup code during login to get the auth token
+// @Description Exchange the sign-in challenge token and a TOTP or backup code for the auth tokens
 // @Tags 2FA
 // @Accept json
 // @Produce json
-// @Param request body dto.Verify2FALoginRequest true "User ID and verification code"
+// @Param request body dto.Verify2FALoginRequest true "Challenge token and verification code"
 // @Success 200 {object} dto.SigninResponse
 // @Failure 400 {object} lib.Response
 // @Failure 401 {object} lib.Response
+// @Failure 429 {object} lib.Response
 // @Router /auth/2fa/verify [post]
 func (h *TwoFactorHandler) Verify2FALogin() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -114,27 +125,150 @@ func (h *TwoFactorHandler) Verify2FALogin() gin.HandlerFunc {
 			return
 		}
 
-		user, err := h.service.VerifyCode(payload.UserID, payload.Code)
+		challengeUser, err := h.service.ResolveChallenge(payload.ChallengeToken)
 		if err != nil {
+			handleChallengeError(ctx, err)
+			return
+		}
+
+		user, err := h.service.VerifyLoginCode(challengeUser, payload.Code, sessionClient(ctx))
+		if err != nil {
+			recordAudit(ctx, services.AuditEntry{
+				Action:     models.AuditSignInFailed,
+				EntityType: "user",
+				EntityID:   challengeUser.ID.String(),
+				Metadata:   map[string]interface{}{"method": "2fa", "reason": err.Error()},
+			})
+			if handleThrottled(ctx, err) {
+				return
+			}
+			if errors.Is(err, services.ErrTwoFactorLocked) {
+				lib.TooManyRequests(ctx, err.Error())
+				return
+			}
 			lib.Unauthorized(ctx, err.Error())
 			return
 		}
 
-		// Generate token
-		token, err := lib.GenerateToken(user.ID)
+		h.completeSignin(ctx, user, payload.RememberDevice, "2fa")
+	}
+}
+
+// Begin2FAPasskey godoc
+// @Summary Start passkey 2FA during login
+// @Description Get the options for navigator.credentials.get to finish a password sign-in with a passkey instead of a TOTP code
+// @Tags 2FA
+// @Accept json
+// @Produce json
+// @Param request body dto.Begin2FAPasskeyRequest true "Challenge token"
+// @Success 200 {object} dto.WebAuthnOptions
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /auth/2fa/passkey/begin [post]
+func (h *TwoFactorHandler) Begin2FAPasskey() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.Begin2FAPasskeyRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, "Invalid request body", "INVALID_PAYLOAD")
+			return
+		}
+
+		challengeUser, err := h.service.ResolveChallenge(payload.ChallengeToken)
 		if err != nil {
-			lib.InternalServerError(ctx, "Failed to generate token")
+			handleChallengeError(ctx, err)
 			return
 		}
 
-		// Clear sensitive fields
-		user.Password = ""
-		user.Otp = ""
+		options, err := h.webAuthnService.BeginSecondFactor(challengeUser)
+		if err != nil {
+			handleWebAuthnError(ctx, err, "Failed to start passkey verification: ")
+			return
+		}
 
-		lib.Success(ctx, "2FA verification successful", services.SigninResponse{
-			User:  *user,
-			Token: token,
-		})
+		lib.Success(ctx, "Passkey verification started", options)
+	}
+}
+
+// Verify2FAPasskey godoc
+// @Summary Verify 2FA during login with a passkey
+// @Description Exchange the sign-in challenge token and a passkey assertion for the auth tokens
+// @Tags 2FA
+// @Accept json
+// @Produce json
+// @Param request body dto.Verify2FAPasskeyRequest true "Challenge token, ceremony ID and credential"
+// @Success 200 {object} services.SigninResponse
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /auth/2fa/passkey/verify [post]
+func (h *TwoFactorHandler) Verify2FAPasskey() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.Verify2FAPasskeyRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, "Invalid request body", "INVALID_PAYLOAD")
+			return
+		}
+
+		challengeUser, err := h.service.ResolveChallenge(payload.ChallengeToken)
+		if err != nil {
+			handleChallengeError(ctx, err)
+			return
+		}
+
+		user, err := h.service.VerifyLoginPasskey(challengeUser, payload.CeremonyID, payload.Credential)
+		if err != nil {
+			recordAudit(ctx, services.AuditEntry{
+				Action:     models.AuditSignInFailed,
+				EntityType: "user",
+				EntityID:   challengeUser.ID.String(),
+				Metadata:   map[string]interface{}{"method": "2fa_passkey", "reason": err.Error()},
+			})
+			handleWebAuthnError(ctx, err, "Failed to verify 2FA: ")
+			return
+		}
+
+		h.completeSignin(ctx, user, payload.RememberDevice, "2fa_passkey")
+	}
+}
+
+// completeSignin creates the session once the second factor is verified, remembering the browser if asked.
+func (h *TwoFactorHandler) completeSignin(ctx *gin.Context, user *models.User, rememberDevice bool, method string) {
+	if rememberDevice {
+		token, err := h.service.TrustDevice(user.ID, sessionClient(ctx))
+		if err != nil {
+			lib.InternalServerError(ctx, "Failed to remember device: "+err.Error())
+			return
+		}
+		setAuthCookie(ctx, trustedDeviceCookie, token, int(services.TrustedDeviceTTL.Seconds()))
+	}
+
+	// Clear sensitive fields
+	user.Password = ""
+
+	response, err := h.authService.CreateSession(user, sessionClient(ctx))
+	if err != nil {
+		lib.InternalServerError(ctx, "Failed to generate token")
+		return
+	}
+
+	recordAudit(ctx, services.AuditEntry{
+		ActorID:    user.ID.String(),
+		Action:     models.AuditSignIn,
+		EntityType: "user",
+		EntityID:   user.ID.String(),
+		Metadata:   map[string]interface{}{"method": method},
+	})
+
+	lib.Success(ctx, "2FA verification successful", response)
+}
+
+func handleChallengeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorChallenge):
+		lib.Unauthorized(ctx, err.Error())
+	case errors.Is(err, services.ErrAccountSuspended):
+		lib.Forbidden(ctx, "Your account has been suspended")
+	default:
+		lib.InternalServerError(ctx, "Failed to verify 2FA: "+err.Error())
 	}
 }
 
@@ -170,11 +304,19 @@ func (h *TwoFactorHandler) Disable2FA() gin.HandlerFunc {
 			return
 		}
 
-		if err := h.service.Disable2FA(currentUser.ID.String(), payload.Password); err != nil {
+		if err := h.service.Disable2FA(currentUser.ID.String(), ctx.GetString("session_id"), payload.Password); err != nil {
 			lib.BadRequest(ctx, err.Error(), "DISABLE_FAILED")
 			return
 		}
 
+		setAuthCookie(ctx, trustedDeviceCookie, "", -1)
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditTwoFactorDisabled,
+			EntityType: "user",
+			EntityID:   currentUser.ID.String(),
+		})
+
 		lib.Success(ctx, "2FA has been disabled successfully", nil)
 	}
 }
@@ -213,6 +355,12 @@ func (h *TwoFactorHandler) RegenerateBackupCodes() gin.HandlerFunc {
 			return
 		}
 
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditBackupCodesRegenerated,
+			EntityType: "user",
+			EntityID:   currentUser.ID.String(),
+		})
+
 		lib.Success(ctx, "New backup codes generated. Store them securely.", dto.BackupCodesResponse{
 			BackupCodes: codes,
 			Message:     "These are your new backup codes. Each code can only be used once.",
@@ -220,6 +368,77 @@ func (h *TwoFactorHandler) RegenerateBackupCodes() gin.HandlerFunc {
 	}
 }
 
+// GetTrustedDevices godoc
+// @Summary List trusted devices
+// @Description List the browsers that skip 2FA at sign-in
+// @Tags 2FA
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.TrustedDevice
+// @Failure 401 {object} lib.Response
+// @Router /auth/2fa/trusted-devices [get]
+func (h *TwoFactorHandler) GetTrustedDevices() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		devices, err := h.service.GetTrustedDevices(userID)
+		if err != nil {
+			lib.InternalServerError(ctx, "Failed to get trusted devices: "+err.Error())
+			return
+		}
+
+		lib.Success(ctx, "Trusted devices retrieved successfully", devices)
+	}
+}
+
+// RemoveTrustedDevice godoc
+// @Summary Remove a trusted device
+// @Description Make a remembered browser ask for 2FA again
+// @Tags 2FA
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Trusted device ID"
+// @Success 200 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /auth/2fa/trusted-devices/{id} [delete]
+func (h *TwoFactorHandler) RemoveTrustedDevice() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if err := h.service.RemoveTrustedDevice(userID, ctx.Param("id")); err != nil {
+			if errors.Is(err, services.ErrTrustedDeviceNotFound) {
+				lib.NotFound(ctx, err.Error(), "TRUSTED_DEVICE_NOT_FOUND")
+				return
+			}
+			lib.InternalServerError(ctx, "Failed to remove trusted device: "+err.Error())
+			return
+		}
+
+		lib.Success(ctx, "Trusted device removed successfully", nil)
+	}
+}
+
+// RemoveTrustedDevices godoc
+// @Summary Remove all trusted devices
+// @Description Make every remembered browser ask for 2FA again
+// @Tags 2FA
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /auth/2fa/trusted-devices [delete]
+func (h *TwoFactorHandler) RemoveTrustedDevices() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		if err := h.service.RemoveTrustedDevices(userID); err != nil {
+			lib.InternalServerError(ctx, "Failed to remove trusted devices: "+err.Error())
+			return
+		}
+
+		setAuthCookie(ctx, trustedDeviceCookie, "", -1)
+		lib.Success(ctx, "Trusted devices removed successfully", nil)
+	}
+}
+
 // GetStatus godoc
 // @Summary Get 2FA status
 // @Description Get the current 2FA status for the authenticated user
@@ -5,6 +5,7 @@ import (
 	"foglio/v2/src/database"
 	"foglio/v2/src/dto"
 	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
 	"foglio/v2/src/services"
 	"log"
 
@@ -12,12 +13,14 @@ import (
 )
 
 type UserHandler struct {
-	service *services.UserService
+	service    *services.UserService
+	moderation *services.UserAdminService
 }
 
 func NewUserHandler() *UserHandler {
 	return &UserHandler{
-		service: services.NewUserService(database.GetDatabase()),
+		service:    services.NewUserService(database.GetDatabase()),
+		moderation: services.NewUserAdminService(database.GetDatabase()),
 	}
 }
 
@@ -83,12 +86,29 @@ func (h *UserHandler) UpdateUser() gin.HandlerFunc {
 			return
 		}
 
+		// Edits made by moderators to someone else's profile are audited
+		moderated := id != ctx.GetString(config.AppConfig.CurrentUserId)
+		var before *models.User
+		if moderated {
+			before, _ = h.service.GetUser(id)
+		}
+
 		user, err := h.service.UpdateUser(id, payload)
 		if err != nil {
 			lib.InternalServerError(ctx, "Internal server error,"+err.Error())
 			return
 		}
 
+		if moderated {
+			recordAudit(ctx, services.AuditEntry{
+				Action:     models.AuditUserUpdated,
+				EntityType: "user",
+				EntityID:   id,
+				Before:     before,
+				After:      user,
+			})
+		}
+
 		lib.Success(ctx, "User updated successfully", user)
 	}
 }
@@ -127,13 +147,26 @@ func (h *UserHandler) UpdateAvatar() gin.HandlerFunc {
 func (h *UserHandler) DeleteUser() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		id := ctx.Param("id")
+		if id == ctx.GetString(config.AppConfig.CurrentUserId) {
+			// Owners go through the confirmed flow with its grace period instead
+			lib.BadRequest(ctx, "Confirm the deletion of your own account through /me/deletion", "DELETION_CONFIRMATION_REQUIRED")
+			return
+		}
 
-		err := h.service.DeleteUser(id)
+		// Staff take the account down right away; its data is only erased once the grace period is over
+		deletion, err := h.moderation.DeleteUser(ctx.GetString(config.AppConfig.CurrentUserId), id)
 		if err != nil {
-			lib.InternalServerError(ctx, "Internal server error,"+err.Error())
+			handleUserAdminError(ctx, err, "Failed to delete user: ")
 			return
 		}
 
-		lib.Success(ctx, "user deleted successfully", nil)
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditUserDeleted,
+			EntityType: "user",
+			EntityID:   id,
+			Metadata:   map[string]interface{}{"deletion_id": deletion.ID.String(), "scheduled_for": deletion.ScheduledFor},
+		})
+
+		lib.Success(ctx, "user deleted successfully", deletion)
 	}
 }
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserAdminHandler struct {
+	service *services.UserAdminService
+}
+
+func NewUserAdminHandler() *UserAdminHandler {
+	return &UserAdminHandler{
+		service: services.NewUserAdminService(database.GetDatabase()),
+	}
+}
+
+func (h *UserAdminHandler) SuspendUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.SuspendUserDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		adminID := ctx.GetString(config.AppConfig.CurrentUserId)
+		user, err := h.service.SuspendUser(adminID, ctx.Param("id"), payload)
+		if err != nil {
+			handleUserAdminError(ctx, err, "Failed to suspend user: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditUserSuspended,
+			EntityType: "user",
+			EntityID:   user.ID.String(),
+			After:      suspensionSnapshot(user),
+		})
+
+		lib.Success(ctx, "User suspended successfully", dto.NewModeratedUserResponse(user))
+	}
+}
+
+func (h *UserAdminHandler) UnsuspendUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := h.service.UnsuspendUser(ctx.Param("id"))
+		if err != nil {
+			handleUserAdminError(ctx, err, "Failed to lift suspension: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditUserUnsuspended,
+			EntityType: "user",
+			EntityID:   user.ID.String(),
+		})
+
+		lib.Success(ctx, "Suspension lifted successfully", dto.NewModeratedUserResponse(user))
+	}
+}
+
+// RestoreUser brings back an account staff deleted before its grace period runs out.
+func (h *UserAdminHandler) RestoreUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := h.service.RestoreUser(ctx.Param("id"))
+		if err != nil {
+			handleUserAdminError(ctx, err, "Failed to restore user: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditUserRestored,
+			EntityType: "user",
+			EntityID:   user.ID.String(),
+		})
+
+		lib.Success(ctx, "User restored successfully", dto.NewModeratedUserResponse(user))
+	}
+}
+
+func (h *UserAdminHandler) VerifyUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := h.service.VerifyUser(ctx.Param("id"))
+		if err != nil {
+			handleUserAdminError(ctx, err, "Failed to verify user: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditUserVerified,
+			EntityType: "user",
+			EntityID:   user.ID.String(),
+		})
+
+		lib.Success(ctx, "User verified successfully", dto.NewModeratedUserResponse(user))
+	}
+}
+
+func (h *UserAdminHandler) Impersonate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.ImpersonateUserDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		adminID := ctx.GetString(config.AppConfig.CurrentUserId)
+		session, err := h.service.Impersonate(adminID, ctx.GetString("impersonator_id"), ctx.Param("id"), payload)
+		if err != nil {
+			handleUserAdminError(ctx, err, "Failed to start impersonation: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditImpersonationStarted,
+			EntityType: "user",
+			EntityID:   session.Session.UserID.String(),
+			Metadata: map[string]interface{}{
+				"session_id": session.Session.ID.String(),
+				"reason":     session.Session.Reason,
+				"expires_at": session.Session.ExpiresAt,
+			},
+		})
+
+		lib.Created(ctx, "Impersonation started successfully", session)
+	}
+}
+
+func (h *UserAdminHandler) GetImpersonationSessions() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var params dto.ImpersonationQueryParams
+		if err := ctx.ShouldBindQuery(&params); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		sessions, err := h.service.GetImpersonationSessions(params)
+		if err != nil {
+			handleUserAdminError(ctx, err, "Failed to get impersonation sessions: ")
+			return
+		}
+
+		lib.Success(ctx, "Impersonation sessions retrieved successfully", sessions)
+	}
+}
+
+func (h *UserAdminHandler) EndImpersonation() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session, err := h.service.EndImpersonation(ctx.Param("id"))
+		if err != nil {
+			handleUserAdminError(ctx, err, "Failed to end impersonation: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditImpersonationEnded,
+			EntityType: "user",
+			EntityID:   session.UserID.String(),
+			Metadata:   map[string]interface{}{"session_id": session.ID.String()},
+		})
+
+		lib.Success(ctx, "Impersonation ended successfully", session)
+	}
+}
+
+func (h *UserAdminHandler) MergeUsers() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.MergeUsersDto
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		adminID := ctx.GetString(config.AppConfig.CurrentUserId)
+		user, err := h.service.MergeUsers(adminID, ctx.Param("id"), payload)
+		if err != nil {
+			handleUserAdminError(ctx, err, "Failed to merge users: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditUsersMerged,
+			EntityType: "user",
+			EntityID:   user.ID.String(),
+			Metadata:   map[string]interface{}{"source_user_id": payload.SourceUserID},
+		})
+
+		lib.Success(ctx, "Users merged successfully", dto.NewModeratedUserResponse(user))
+	}
+}
+
+// suspensionSnapshot limits a suspension's audit entry to the suspension fields rather than the whole profile.
+func suspensionSnapshot(user *models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"suspended_at":      user.SuspendedAt,
+		"suspended_until":   user.SuspendedUntil,
+		"suspension_reason": user.SuspensionReason,
+	}
+}
+
+func handleUserAdminError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrUserNotSuspended):
+		lib.BadRequest(ctx, err.Error(), "USER_NOT_SUSPENDED")
+	case errors.Is(err, services.ErrUserAlreadyVerified):
+		lib.BadRequest(ctx, err.Error(), "USER_ALREADY_VERIFIED")
+	case errors.Is(err, services.ErrImpersonationAlreadyEnded):
+		lib.BadRequest(ctx, err.Error(), "IMPERSONATION_ALREADY_ENDED")
+	case errors.Is(err, services.ErrMergeSubscriptionConflict):
+		lib.BadRequest(ctx, err.Error(), "SUBSCRIPTION_CONFLICT")
+	case errors.Is(err, services.ErrCannotModerateSelf),
+		errors.Is(err, services.ErrCannotModerateStaff),
+		errors.Is(err, services.ErrNestedImpersonation):
+		lib.Forbidden(ctx, err.Error())
+	case errors.Is(err, services.ErrUserNotFound):
+		lib.NotFound(ctx, err.Error(), "USER_NOT_FOUND")
+	case errors.Is(err, services.ErrDeletionNotFound):
+		lib.NotFound(ctx, err.Error(), "DELETION_NOT_FOUND")
+	case errors.Is(err, services.ErrImpersonationNotFound):
+		lib.NotFound(ctx, err.Error(), "IMPERSONATION_NOT_FOUND")
+	default:
+		lib.InternalServerError(ctx, prefix+err.Error())
+	}
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebAuthnHandler struct {
+	service     *services.WebAuthnService
+	authService *services.AuthService
+}
+
+func NewWebAuthnHandler() *WebAuthnHandler {
+	db := database.GetDatabase()
+	return &WebAuthnHandler{
+		service:     services.NewWebAuthnService(db),
+		authService: services.NewAuthService(db),
+	}
+}
+
+// BeginPasskeyRegistration godoc
+// @Summary Start passkey registration
+// @Description Get the options for navigator.credentials.create to add a passkey to the account
+// @Tags Passkeys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.WebAuthnOptions
+// @Failure 401 {object} lib.Response
+// @Failure 500 {object} lib.Response
+// @Router /auth/passkeys/register/begin [post]
+func (h *WebAuthnHandler) BeginPasskeyRegistration() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, exists := ctx.Get("current_user")
+		if !exists {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		options, err := h.service.BeginRegistration(user.(*models.User))
+		if err != nil {
+			handleWebAuthnError(ctx, err, "Failed to start passkey registration: ")
+			return
+		}
+
+		lib.Success(ctx, "Passkey registration started", options)
+	}
+}
+
+// FinishPasskeyRegistration godoc
+// @Summary Finish passkey registration
+// @Description Verify the new credential from the browser and save it under a name
+// @Tags Passkeys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.RegisterPasskeyRequest true "Ceremony ID, passkey name and credential"
+// @Success 201 {object} models.WebAuthnCredential
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Router /auth/passkeys/register/finish [post]
+func (h *WebAuthnHandler) FinishPasskeyRegistration() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, exists := ctx.Get("current_user")
+		if !exists {
+			lib.Unauthorized(ctx, "User not authenticated")
+			return
+		}
+
+		var payload dto.RegisterPasskeyRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		credential, err := h.service.FinishRegistration(user.(*models.User), payload)
+		if err != nil {
+			handleWebAuthnError(ctx, err, "Failed to register passkey: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditPasskeyRegistered,
+			EntityType: "passkey",
+			EntityID:   credential.ID.String(),
+			Metadata:   map[string]interface{}{"name": credential.Name},
+		})
+
+		lib.Created(ctx, "Passkey registered successfully", credential)
+	}
+}
+
+// GetPasskeys godoc
+// @Summary List passkeys
+// @Description List the passkeys registered to the account
+// @Tags Passkeys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.WebAuthnCredential
+// @Failure 401 {object} lib.Response
+// @Router /auth/passkeys [get]
+func (h *WebAuthnHandler) GetPasskeys() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		credentials, err := h.service.GetCredentials(userID)
+		if err != nil {
+			handleWebAuthnError(ctx, err, "Failed to get passkeys: ")
+			return
+		}
+
+		lib.Success(ctx, "Passkeys retrieved successfully", credentials)
+	}
+}
+
+// RenamePasskey godoc
+// @Summary Rename a passkey
+// @Tags Passkeys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Passkey ID"
+// @Param request body dto.RenamePasskeyRequest true "New name"
+// @Success 200 {object} models.WebAuthnCredential
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /auth/passkeys/{id} [patch]
+func (h *WebAuthnHandler) RenamePasskey() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.RenamePasskeyRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		credential, err := h.service.RenameCredential(userID, ctx.Param("id"), payload.Name)
+		if err != nil {
+			handleWebAuthnError(ctx, err, "Failed to rename passkey: ")
+			return
+		}
+
+		lib.Success(ctx, "Passkey renamed successfully", credential)
+	}
+}
+
+// DeletePasskey godoc
+// @Summary Remove a passkey
+// @Tags Passkeys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Passkey ID"
+// @Success 200 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Failure 404 {object} lib.Response
+// @Router /auth/passkeys/{id} [delete]
+func (h *WebAuthnHandler) DeletePasskey() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString(config.AppConfig.CurrentUserId)
+		credentialID := ctx.Param("id")
+		if err := h.service.DeleteCredential(userID, credentialID); err != nil {
+			handleWebAuthnError(ctx, err, "Failed to remove passkey: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			Action:     models.AuditPasskeyRemoved,
+			EntityType: "passkey",
+			EntityID:   credentialID,
+		})
+
+		lib.Success(ctx, "Passkey removed successfully", nil)
+	}
+}
+
+// BeginPasskeyLogin godoc
+// @Summary Start passkey sign-in
+// @Description Get the options for navigator.credentials.get to sign in without a password
+// @Tags Passkeys
+// @Produce json
+// @Success 200 {object} dto.WebAuthnOptions
+// @Failure 500 {object} lib.Response
+// @Router /auth/passkeys/login/begin [post]
+func (h *WebAuthnHandler) BeginPasskeyLogin() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		options, err := h.service.BeginLogin()
+		if err != nil {
+			handleWebAuthnError(ctx, err, "Failed to start passkey sign-in: ")
+			return
+		}
+
+		lib.Success(ctx, "Passkey sign-in started", options)
+	}
+}
+
+// PasskeyLogin godoc
+// @Summary Sign in with a passkey
+// @Description Verify the passkey assertion from the browser and return the auth tokens. No 2FA step follows.
+// @Tags Passkeys
+// @Accept json
+// @Produce json
+// @Param request body dto.PasskeyLoginRequest true "Ceremony ID and credential"
+// @Success 200 {object} services.SigninResponse
+// @Failure 400 {object} lib.Response
+// @Failure 401 {object} lib.Response
+// @Failure 403 {object} lib.Response
+// @Router /auth/passkeys/login/finish [post]
+func (h *WebAuthnHandler) PasskeyLogin() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var payload dto.PasskeyLoginRequest
+		if err := ctx.ShouldBindJSON(&payload); err != nil {
+			lib.BadRequest(ctx, err.Error(), "")
+			return
+		}
+
+		response, err := h.authService.SigninWithPasskey(payload, sessionClient(ctx))
+		if err != nil {
+			recordAudit(ctx, services.AuditEntry{
+				Action:     models.AuditSignInFailed,
+				EntityType: "user",
+				Metadata:   map[string]interface{}{"method": "passkey", "reason": err.Error()},
+			})
+			handleWebAuthnError(ctx, err, "Failed to sign in with passkey: ")
+			return
+		}
+
+		recordAudit(ctx, services.AuditEntry{
+			ActorID:    response.User.ID.String(),
+			Action:     models.AuditSignIn,
+			EntityType: "user",
+			EntityID:   response.User.ID.String(),
+			Metadata:   map[string]interface{}{"method": "passkey"},
+		})
+
+		lib.Success(ctx, "User signed in successfully", response)
+	}
+}
+
+func handleWebAuthnError(ctx *gin.Context, err error, prefix string) {
+	switch {
+	case errors.Is(err, services.ErrWebAuthnUnavailable):
+		lib.InternalServerError(ctx, err.Error())
+	case errors.Is(err, services.ErrInvalidWebAuthnCeremony):
+		lib.BadRequest(ctx, err.Error(), "INVALID_CEREMONY")
+	case errors.Is(err, services.ErrWebAuthnVerificationFailed):
+		lib.Unauthorized(ctx, err.Error())
+	case errors.Is(err, services.ErrWebAuthnCredentialExists):
+		lib.BadRequest(ctx, err.Error(), "PASSKEY_ALREADY_REGISTERED")
+	case errors.Is(err, services.ErrWebAuthnCredentialNotFound):
+		lib.NotFound(ctx, err.Error(), "PASSKEY_NOT_FOUND")
+	case errors.Is(err, services.ErrNoWebAuthnCredentials):
+		lib.BadRequest(ctx, err.Error(), "NO_PASSKEYS")
+	case errors.Is(err, services.ErrAccountSuspended):
+		lib.Forbidden(ctx, "Your account has been suspended")
+	default:
+		lib.InternalServerError(ctx, prefix+err.Error())
+	}
+}
@@ -0,0 +1,147 @@
+package lib
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/models"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+const (
+	BrokerMemory   = "memory"
+	BrokerRedis    = "redis"
+	BrokerPostgres = "postgres"
+
+	BrokerTargetUser     = "user"
+	BrokerTargetAll      = "all"
+	BrokerTargetSessions = "sessions" // Closes the user's connections opened with one of SessionIDs
+
+	brokerChannel = "foglio_hub"
+)
+
+var ErrUnknownBroker = errors.New("unknown hub broker")
+
+// BrokerMessage is the envelope exchanged between hub replicas.
+type BrokerMessage struct {
+	Target       string              `json:"target"`
+	UserID       string              `json:"user_id,omitempty"`
+	Notification models.Notification `json:"notification"`
+	SessionIDs   []string            `json:"session_ids,omitempty"`
+	Origin       string              `json:"origin"`
+}
+
+// BrokerStats holds cluster-wide connection counts.
+type BrokerStats struct {
+	Clients int `json:"clients"`
+	Users   int `json:"users"`
+	Nodes   int `json:"nodes"`
+}
+
+// Broker fans hub messages out to every replica and tracks cluster-wide presence.
+type Broker interface {
+	Name() string
+	Publish(message BrokerMessage) error
+	Subscribe(handler func(BrokerMessage)) error
+	Register(nodeID, userID string) error
+	Unregister(nodeID, userID string) error
+	Stats() (*BrokerStats, error)
+	Connections(userIDs []string) (map[string]int, error)
+	Close() error
+}
+
+// NewBroker builds the broker configured by HUB_BROKER, falling back to memory.
+func NewBroker(kind string, database *gorm.DB) (Broker, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", BrokerMemory:
+		return NewMemoryBroker(), nil
+	case BrokerRedis:
+		return NewRedisBroker(config.AppConfig.RedisUrl)
+	case BrokerPostgres:
+		return NewPostgresBroker(database, config.AppConfig.PostgresUrl)
+	default:
+		return nil, ErrUnknownBroker
+	}
+}
+
+type MemoryBroker struct {
+	handlers []func(BrokerMessage)
+	presence map[string]int
+	mu       sync.RWMutex
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		presence: make(map[string]int),
+	}
+}
+
+func (b *MemoryBroker) Name() string {
+	return BrokerMemory
+}
+
+func (b *MemoryBroker) Publish(message BrokerMessage) error {
+	b.mu.RLock()
+	handlers := append([]func(BrokerMessage){}, b.handlers...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(handler func(BrokerMessage)) error {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handler)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *MemoryBroker) Register(_, userID string) error {
+	b.mu.Lock()
+	b.presence[userID]++
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *MemoryBroker) Unregister(_, userID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.presence[userID]--
+	if b.presence[userID] <= 0 {
+		delete(b.presence, userID)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Stats() (*BrokerStats, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := &BrokerStats{Users: len(b.presence), Nodes: 1}
+	for _, count := range b.presence {
+		stats.Clients += count
+	}
+	return stats, nil
+}
+
+func (b *MemoryBroker) Connections(userIDs []string) (map[string]int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	connections := make(map[string]int, len(userIDs))
+	for _, userID := range userIDs {
+		if count := b.presence[userID]; count > 0 {
+			connections[userID] = count
+		}
+	}
+	return connections, nil
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
@@ -0,0 +1,262 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"foglio/v2/src/models"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Postgres rejects NOTIFY payloads of 8000 bytes or more.
+	postgresNotifyLimit   = 7900
+	postgresPayloadPrefix = "ref:"
+	postgresPresenceTTL   = 60 * time.Second
+	postgresHeartbeatTick = 20 * time.Second
+	postgresReconnectWait = 2 * time.Second
+)
+
+type PostgresBroker struct {
+	database *gorm.DB
+	url      string
+	nodes    map[string]bool
+	mu       sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+func NewPostgresBroker(database *gorm.DB, url string) (*PostgresBroker, error) {
+	if database == nil {
+		return nil, errors.New("postgres broker requires a database")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	broker := &PostgresBroker{
+		database: database,
+		url:      url,
+		nodes:    make(map[string]bool),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	go broker.heartbeat()
+
+	return broker, nil
+}
+
+func (b *PostgresBroker) Name() string {
+	return BrokerPostgres
+}
+
+func (b *PostgresBroker) Publish(message BrokerMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	notify := string(payload)
+	if len(payload) > postgresNotifyLimit {
+		record := models.HubPayload{Payload: notify, CreatedAt: time.Now()}
+		if err := b.database.Create(&record).Error; err != nil {
+			return err
+		}
+		notify = postgresPayloadPrefix + record.ID.String()
+	}
+
+	return b.database.Exec("SELECT pg_notify(?, ?)", brokerChannel, notify).Error
+}
+
+func (b *PostgresBroker) Subscribe(handler func(BrokerMessage)) error {
+	conn, err := b.listen()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			if conn == nil {
+				select {
+				case <-b.ctx.Done():
+					return
+				case <-time.After(postgresReconnectWait):
+				}
+				if conn, err = b.listen(); err != nil {
+					log.Printf("Error reconnecting hub listener: %v", err)
+					conn = nil
+					continue
+				}
+			}
+
+			notification, err := conn.WaitForNotification(b.ctx)
+			if err != nil {
+				if b.ctx.Err() != nil {
+					_ = conn.Close(context.Background())
+					return
+				}
+				log.Printf("Hub listener error: %v", err)
+				_ = conn.Close(context.Background())
+				conn = nil
+				continue
+			}
+
+			message, err := b.decode(notification.Payload)
+			if err != nil {
+				log.Printf("Error decoding hub message: %v", err)
+				continue
+			}
+			handler(*message)
+		}
+	}()
+
+	return nil
+}
+
+func (b *PostgresBroker) Register(nodeID, userID string) error {
+	b.mu.Lock()
+	b.nodes[nodeID] = true
+	b.mu.Unlock()
+
+	connection := models.HubConnection{
+		NodeID:      nodeID,
+		UserID:      userID,
+		Connections: 1,
+		UpdatedAt:   time.Now(),
+	}
+	return b.database.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "node_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"connections": gorm.Expr("hub_connections.connections + 1"),
+			"updated_at":  time.Now(),
+		}),
+	}).Create(&connection).Error
+}
+
+func (b *PostgresBroker) Unregister(nodeID, userID string) error {
+	return b.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.HubConnection{}).
+			Where("node_id = ? AND user_id = ?", nodeID, userID).
+			Update("connections", gorm.Expr("connections - 1")).Error; err != nil {
+			return err
+		}
+		return tx.Where("node_id = ? AND user_id = ? AND connections <= 0", nodeID, userID).
+			Delete(&models.HubConnection{}).Error
+	})
+}
+
+func (b *PostgresBroker) Stats() (*BrokerStats, error) {
+	var result struct {
+		Clients int
+		Users   int
+		Nodes   int
+	}
+
+	err := b.database.Model(&models.HubConnection{}).
+		Select("COALESCE(SUM(connections), 0) AS clients, COUNT(DISTINCT user_id) AS users, COUNT(DISTINCT node_id) AS nodes").
+		Where("connections > 0 AND updated_at > ?", time.Now().Add(-postgresPresenceTTL)).
+		Scan(&result).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &BrokerStats{Clients: result.Clients, Users: result.Users, Nodes: result.Nodes}, nil
+}
+
+func (b *PostgresBroker) Connections(userIDs []string) (map[string]int, error) {
+	connections := make(map[string]int, len(userIDs))
+	if len(userIDs) == 0 {
+		return connections, nil
+	}
+
+	var rows []struct {
+		UserID      string
+		Connections int
+	}
+	err := b.database.Model(&models.HubConnection{}).
+		Select("user_id, SUM(connections) AS connections").
+		Where("user_id IN ? AND connections > 0 AND updated_at > ?", userIDs, time.Now().Add(-postgresPresenceTTL)).
+		Group("user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		connections[row.UserID] = row.Connections
+	}
+	return connections, nil
+}
+
+func (b *PostgresBroker) Close() error {
+	b.cancel()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for nodeID := range b.nodes {
+		if err := b.database.Where("node_id = ?", nodeID).Delete(&models.HubConnection{}).Error; err != nil {
+			log.Printf("Error clearing hub presence: %v", err)
+		}
+	}
+	return nil
+}
+
+func (b *PostgresBroker) listen() (*pgx.Conn, error) {
+	ctx, cancel := context.WithTimeout(b.ctx, 10*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, b.url)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{brokerChannel}.Sanitize()); err != nil {
+		_ = conn.Close(context.Background())
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (b *PostgresBroker) decode(payload string) (*BrokerMessage, error) {
+	if strings.HasPrefix(payload, postgresPayloadPrefix) {
+		var record models.HubPayload
+		if err := b.database.Where("id = ?", strings.TrimPrefix(payload, postgresPayloadPrefix)).First(&record).Error; err != nil {
+			return nil, err
+		}
+		payload = record.Payload
+	}
+
+	var message BrokerMessage
+	if err := json.Unmarshal([]byte(payload), &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// heartbeat refreshes this replica's presence rows and prunes rows left by dead replicas.
+func (b *PostgresBroker) heartbeat() {
+	ticker := time.NewTicker(postgresHeartbeatTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+
+			b.mu.Lock()
+			for nodeID := range b.nodes {
+				b.database.Model(&models.HubConnection{}).Where("node_id = ?", nodeID).Update("updated_at", now)
+			}
+			b.mu.Unlock()
+
+			b.database.Where("updated_at < ?", now.Add(-postgresPresenceTTL)).Delete(&models.HubConnection{})
+			b.database.Where("created_at < ?", now.Add(-5*time.Minute)).Delete(&models.HubPayload{})
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisNodesKey      = "foglio:hub:nodes"
+	redisPresencePref  = "foglio:hub:presence:"
+	redisPresenceTTL   = 60 * time.Second
+	redisHeartbeatTick = 20 * time.Second
+)
+
+type RedisBroker struct {
+	client *redis.Client
+	pubsub *redis.PubSub
+	nodes  map[string]bool
+	mu     sync.Mutex
+	done   chan struct{}
+}
+
+func NewRedisBroker(url string) (*RedisBroker, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(options)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+
+	broker := &RedisBroker{
+		client: client,
+		nodes:  make(map[string]bool),
+		done:   make(chan struct{}),
+	}
+	go broker.heartbeat()
+
+	return broker, nil
+}
+
+func (b *RedisBroker) Name() string {
+	return BrokerRedis
+}
+
+func (b *RedisBroker) Publish(message BrokerMessage) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(context.Background(), brokerChannel, payload).Err()
+}
+
+func (b *RedisBroker) Subscribe(handler func(BrokerMessage)) error {
+	ctx := context.Background()
+	b.pubsub = b.client.Subscribe(ctx, brokerChannel)
+	if _, err := b.pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		for msg := range b.pubsub.Channel() {
+			var message BrokerMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				log.Printf("Error decoding hub message: %v", err)
+				continue
+			}
+			handler(message)
+		}
+	}()
+
+	return nil
+}
+
+func (b *RedisBroker) Register(nodeID, userID string) error {
+	ctx := context.Background()
+	key := redisPresencePref + nodeID
+
+	b.mu.Lock()
+	b.nodes[nodeID] = true
+	b.mu.Unlock()
+
+	pipe := b.client.TxPipeline()
+	pipe.SAdd(ctx, redisNodesKey, nodeID)
+	pipe.HIncrBy(ctx, key, userID, 1)
+	pipe.Expire(ctx, key, redisPresenceTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (b *RedisBroker) Unregister(nodeID, userID string) error {
+	ctx := context.Background()
+	key := redisPresencePref + nodeID
+
+	count, err := b.client.HIncrBy(ctx, key, userID, -1).Result()
+	if err != nil {
+		return err
+	}
+	if count <= 0 {
+		return b.client.HDel(ctx, key, userID).Err()
+	}
+	return nil
+}
+
+func (b *RedisBroker) Stats() (*BrokerStats, error) {
+	ctx := context.Background()
+
+	nodes, err := b.client.SMembers(ctx, redisNodesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &BrokerStats{}
+	users := make(map[string]bool)
+	for _, nodeID := range nodes {
+		presence, err := b.client.HGetAll(ctx, redisPresencePref+nodeID).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(presence) == 0 {
+			exists, err := b.client.Exists(ctx, redisPresencePref+nodeID).Result()
+			if err == nil && exists == 0 {
+				b.client.SRem(ctx, redisNodesKey, nodeID)
+				continue
+			}
+		}
+
+		stats.Nodes++
+		for userID, value := range presence {
+			count, _ := strconv.Atoi(value)
+			if count <= 0 {
+				continue
+			}
+			users[userID] = true
+			stats.Clients += count
+		}
+	}
+	stats.Users = len(users)
+
+	return stats, nil
+}
+
+func (b *RedisBroker) Connections(userIDs []string) (map[string]int, error) {
+	connections := make(map[string]int, len(userIDs))
+	if len(userIDs) == 0 {
+		return connections, nil
+	}
+
+	ctx := context.Background()
+	nodes, err := b.client.SMembers(ctx, redisNodesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, nodeID := range nodes {
+		values, err := b.client.HMGet(ctx, redisPresencePref+nodeID, userIDs...).Result()
+		if err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			raw, ok := value.(string)
+			if !ok {
+				continue
+			}
+			if count, _ := strconv.Atoi(raw); count > 0 {
+				connections[userIDs[i]] += count
+			}
+		}
+	}
+
+	return connections, nil
+}
+
+func (b *RedisBroker) Close() error {
+	close(b.done)
+
+	b.mu.Lock()
+	for nodeID := range b.nodes {
+		b.client.Del(context.Background(), redisPresencePref+nodeID)
+		b.client.SRem(context.Background(), redisNodesKey, nodeID)
+	}
+	b.mu.Unlock()
+
+	if b.pubsub != nil {
+		if err := b.pubsub.Close(); err != nil {
+			log.Printf("Error closing redis subscription: %v", err)
+		}
+	}
+	return b.client.Close()
+}
+
+// heartbeat keeps this replica's presence hash alive so crashed replicas expire.
+func (b *RedisBroker) heartbeat() {
+	ticker := time.NewTicker(redisHeartbeatTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			for nodeID := range b.nodes {
+				ctx := context.Background()
+				b.client.SAdd(ctx, redisNodesKey, nodeID)
+				b.client.Expire(ctx, redisPresencePref+nodeID, redisPresenceTTL)
+			}
+			b.mu.Unlock()
+		}
+	}
+}
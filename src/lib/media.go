@@ -0,0 +1,81 @@
+package lib
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+
+	_ "image/gif"
+	_ "image/png"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	ThumbnailSize = 320
+
+	// Images above this pixel count are rejected before decoding to avoid decompression bombs.
+	maxImagePixels = 40_000_000
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image format")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+type ImageInfo struct {
+	Width  int
+	Height int
+	Format string
+}
+
+// ProbeImage reads an image's dimensions and format without decoding its pixels.
+func ProbeImage(data []byte) (*ImageInfo, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	return &ImageInfo{Width: config.Width, Height: config.Height, Format: format}, nil
+}
+
+// GenerateThumbnail scales an image to fit within size x size and encodes it as JPEG.
+// Transparent areas are flattened onto white.
+func GenerateThumbnail(data []byte, size int) ([]byte, error) {
+	if _, err := ProbeImage(data); err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width >= height {
+			height = max(1, height*size/width)
+			width = size
+		} else {
+			width = max(1, width*size/height)
+			height = size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
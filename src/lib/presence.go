@@ -0,0 +1,165 @@
+package lib
+
+import (
+	"foglio/v2/src/models"
+	"log"
+	"time"
+)
+
+const (
+	SocketTypePresence = "presence"
+
+	awayAfter           = 5 * time.Minute
+	presenceSweepEvery  = 30 * time.Second
+	presenceBufferSize  = 256
+	brokerOpsBufferSize = 4096
+)
+
+// PresenceHandler is notified whenever a user's derived presence changes.
+type PresenceHandler interface {
+	HandlePresenceChange(userID string, status models.PresenceStatus)
+}
+
+// SocketPresencePayload lets a client declare itself away (e.g. tab hidden) or back online.
+type SocketPresencePayload struct {
+	Status models.PresenceStatus `json:"status"`
+}
+
+type presenceEvent struct {
+	userID string
+	status models.PresenceStatus
+}
+
+type brokerOpKind int
+
+const (
+	brokerOpRegister brokerOpKind = iota
+	brokerOpUnregister
+	brokerOpPresence
+)
+
+// brokerOp is work the hub loop hands to runBroker so that it never waits on the broker itself.
+type brokerOp struct {
+	kind   brokerOpKind
+	userID string
+	status models.PresenceStatus
+}
+
+func (h *Hub) SetPresenceHandler(handler PresenceHandler) {
+	h.presenceHandler = handler
+}
+
+// Presence reports the cluster-wide connection count for each of the given users.
+func (h *Hub) Presence(userIDs []string) (map[string]int, error) {
+	return h.broker.Connections(userIDs)
+}
+
+// runBroker applies the hub's presence bookkeeping to the broker in the order the hub loop produced it.
+// Broker calls are network or database round trips, so a slow broker only delays this goroutine, not
+// the registration and delivery of every client. A user who went offline here is only reported offline
+// once the broker confirms no other replica still holds one of their sockets.
+func (h *Hub) runBroker() {
+	for op := range h.brokerOps {
+		switch op.kind {
+		case brokerOpRegister:
+			if err := h.broker.Register(h.nodeID, op.userID); err != nil {
+				log.Printf("Failed to register presence for user %s: %v", op.userID, err)
+			}
+		case brokerOpUnregister:
+			if err := h.broker.Unregister(h.nodeID, op.userID); err != nil {
+				log.Printf("Failed to unregister presence for user %s: %v", op.userID, err)
+			}
+		case brokerOpPresence:
+			if op.status == models.PresenceOffline {
+				connections, err := h.broker.Connections([]string{op.userID})
+				if err != nil {
+					log.Printf("Failed to check presence for user %s: %v", op.userID, err)
+				} else if connections[op.userID] > 0 {
+					// Another replica still holds a socket for this user and owns their status.
+					continue
+				}
+			}
+			select {
+			case h.presenceEvents <- presenceEvent{userID: op.userID, status: op.status}:
+			default:
+				log.Printf("Dropping presence change for user %s: buffer full", op.userID)
+			}
+		}
+	}
+}
+
+// runPresence delivers presence changes in order, outside the hub loop so handlers may publish freely.
+func (h *Hub) runPresence() {
+	for event := range h.presenceEvents {
+		if h.presenceHandler != nil {
+			h.presenceHandler.HandlePresenceChange(event.userID, event.status)
+		}
+	}
+}
+
+// refreshPresence derives a user's status from their local clients and emits it when it changes.
+// It must only be called from the hub loop.
+func (h *Hub) refreshPresence(userID string) {
+	status := h.localPresence(userID)
+
+	if status == models.PresenceOffline {
+		if _, ok := h.presence[userID]; !ok {
+			return
+		}
+		delete(h.presence, userID)
+	} else {
+		if h.presence[userID] == status {
+			return
+		}
+		h.presence[userID] = status
+	}
+
+	h.brokerOps <- brokerOp{kind: brokerOpPresence, userID: userID, status: status}
+}
+
+func (h *Hub) localPresence(userID string) models.PresenceStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	userClients, ok := h.clients[userID]
+	if !ok || len(userClients) == 0 {
+		return models.PresenceOffline
+	}
+
+	for client := range userClients {
+		if client.isActive() {
+			return models.PresenceOnline
+		}
+	}
+	return models.PresenceAway
+}
+
+func (h *Hub) sweepPresence() {
+	h.mu.RLock()
+	userIDs := make([]string, 0, len(h.clients))
+	for userID := range h.clients {
+		userIDs = append(userIDs, userID)
+	}
+	h.mu.RUnlock()
+
+	for _, userID := range userIDs {
+		h.refreshPresence(userID)
+	}
+}
+
+// touch records client activity, reporting whether the client's active state changed.
+func (c *Client) touch(away bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wasActive := !c.away && time.Since(c.lastActivity) < awayAfter
+	c.lastActivity = time.Now()
+	c.away = away
+	return wasActive == away
+}
+
+func (c *Client) isActive() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.away && time.Since(c.lastActivity) < awayAfter
+}
@@ -36,6 +36,7 @@ const (
 	InternalServerErrorCode = "INTERNAL_SERVER_ERROR"
 	UnauthorizedCode        = "UNAUTHORIZED"
 	ForbiddenCode           = "FORBIDDEN"
+	TooManyRequestsCode     = "TOO_MANY_REQUESTS"
 )
 
 func GlobalNotFound() gin.HandlerFunc {
@@ -181,6 +182,25 @@ func Forbidden(ctx *gin.Context, message string) {
 	ctx.Abort()
 }
 
+func TooManyRequests(ctx *gin.Context, message string) {
+	if message == "" {
+		message = "Too many requests"
+	}
+
+	response := ErrorResponse{
+		Success:   false,
+		Error:     "Too Many Requests",
+		Message:   message,
+		Code:      TooManyRequestsCode,
+		Path:      ctx.Request.URL.Path,
+		Method:    ctx.Request.Method,
+		Timestamp: time.Now().UTC(),
+	}
+
+	ctx.JSON(http.StatusTooManyRequests, response)
+	ctx.Abort()
+}
+
 func ErrorHandler() gin.HandlerFunc {
 	return gin.CustomRecovery(func(ctx *gin.Context, recovered interface{}) {
 		if recovered != nil {
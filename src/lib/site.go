@@ -0,0 +1,157 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// SiteTemplates are the built-in portfolio site templates, the first being the fallback for unknown names.
+var SiteTemplates = []string{"default", "minimal", "modern"}
+
+// siteError is the standalone page shown when a portfolio cannot be shown.
+const siteError = "error"
+
+var (
+	siteRenderer     *SiteRenderer
+	siteRendererOnce sync.Once
+
+	// cssValuePattern allows colors such as #0f172a, rgb(15 23 42 / 80%) and font stacks like
+	// "Inter", sans-serif, but nothing that could close the declaration or load a resource.
+	cssValuePattern  = regexp.MustCompile(`^[a-zA-Z0-9#%.,/()'" -]{1,100}$`)
+	cssImportPattern = regexp.MustCompile(`(?i)@import[^;]*;?`)
+	paragraphBreak   = regexp.MustCompile(`\n\s*\n`)
+)
+
+// SiteRenderer renders public portfolio sites from the templates in templates/sites. Each site template
+// is parsed together with the shared layout and sections and cached on first use.
+type SiteRenderer struct {
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+	dir       string
+}
+
+func GetSiteRenderer() *SiteRenderer {
+	siteRendererOnce.Do(func() {
+		siteRenderer = &SiteRenderer{
+			templates: make(map[string]*template.Template),
+			dir:       filepath.Join(getTemplatesDir(), "sites"),
+		}
+	})
+	return siteRenderer
+}
+
+// IsSiteTemplate reports whether name is a built-in site template.
+func IsSiteTemplate(name string) bool {
+	for _, template := range SiteTemplates {
+		if template == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Render writes the page for a site template, falling back to the default template for unknown names.
+// Nothing is written if rendering fails.
+func (r *SiteRenderer) Render(w io.Writer, name string, data interface{}) error {
+	if !IsSiteTemplate(name) {
+		name = SiteTemplates[0]
+	}
+	return r.execute(w, name, data)
+}
+
+// RenderError writes the page shown in place of a portfolio that cannot be shown.
+func (r *SiteRenderer) RenderError(w io.Writer, data interface{}) error {
+	return r.execute(w, siteError, data)
+}
+
+func (r *SiteRenderer) execute(w io.Writer, name string, data interface{}) error {
+	tmpl, err := r.getTemplate(name)
+	if err != nil {
+		return err
+	}
+
+	entry := "layout"
+	if name == siteError {
+		entry = siteError + ".html"
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, entry, data); err != nil {
+		return fmt.Errorf("failed to render site template %s: %w", name, err)
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+func (r *SiteRenderer) getTemplate(name string) (*template.Template, error) {
+	r.mu.RLock()
+	tmpl, exists := r.templates[name]
+	r.mu.RUnlock()
+
+	if exists {
+		return tmpl, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tmpl, exists = r.templates[name]; exists {
+		return tmpl, nil
+	}
+
+	files := []string{filepath.Join(r.dir, name+".html")}
+	if name != siteError {
+		files = append(files, filepath.Join(r.dir, "layout.html"), filepath.Join(r.dir, "sections.html"))
+	}
+	tmpl, err := template.New(name).Funcs(siteFuncs).ParseFiles(files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse site template %s: %w", name, err)
+	}
+
+	r.templates[name] = tmpl
+	return tmpl, nil
+}
+
+var siteFuncs = template.FuncMap{
+	"cssValue":   cssValue,
+	"customCSS":  customCSS,
+	"paragraphs": paragraphs,
+	"section": func(site interface{}, section interface{}) map[string]interface{} {
+		return map[string]interface{}{"Site": site, "Section": section}
+	},
+}
+
+// cssValue lets a theme value into a stylesheet when it is a plain color, size or font stack.
+func cssValue(value string) template.CSS {
+	value = strings.TrimSpace(value)
+	lower := strings.ToLower(value)
+	if !cssValuePattern.MatchString(value) || strings.Contains(lower, "url") || strings.Contains(lower, "expression") ||
+		strings.Contains(value, "/*") || strings.Count(value, "'")%2 != 0 || strings.Count(value, `"`)%2 != 0 {
+		return ""
+	}
+	return template.CSS(value)
+}
+
+// customCSS trusts a portfolio's own stylesheet once it can no longer close the style element or pull in
+// other stylesheets. A "<" is only valid inside CSS strings, where its escape means the same.
+func customCSS(css string) template.CSS {
+	css = strings.ReplaceAll(css, "<", `\3c `)
+	return template.CSS(cssImportPattern.ReplaceAllString(css, ""))
+}
+
+// paragraphs splits plain text on blank lines so it can be shown as separate paragraphs.
+func paragraphs(text string) []string {
+	var result []string
+	for _, paragraph := range paragraphBreak.Split(strings.ReplaceAll(text, "\r\n", "\n"), -1) {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			result = append(result, paragraph)
+		}
+	}
+	return result
+}
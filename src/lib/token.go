@@ -1,7 +1,12 @@
 package lib
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"foglio/v2/src/config"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,8 +20,13 @@ var (
 	ErrMissingSecretKey = errors.New("missing JWT secret key")
 )
 
+// TokenPurposeTwoFactorChallenge marks a token proving the password step of a 2FA sign-in.
+const TokenPurposeTwoFactorChallenge = "2fa_challenge"
+
 type Claims struct {
-	UserId uuid.UUID `json:"user_id"`
+	UserId         uuid.UUID  `json:"user_id"`
+	ImpersonatorId *uuid.UUID `json:"impersonator_id,omitempty"` // Set on tokens issued to an admin acting as the user
+	Purpose        string     `json:"purpose,omitempty"`         // Empty on access tokens
 	jwt.RegisteredClaims
 }
 
@@ -26,7 +36,9 @@ func InitialiseJWT(secret string) {
 	jwtSecret = []byte(secret)
 }
 
-func GenerateToken(id uuid.UUID) (string, error) {
+// GenerateToken issues a short-lived access token for a sign-in session. The token ID is the session ID so
+// the token stops working once the session is revoked.
+func GenerateToken(id, sessionId uuid.UUID) (string, error) {
 	if len(jwtSecret) == 0 {
 		return "", ErrMissingSecretKey
 	}
@@ -34,7 +46,48 @@ func GenerateToken(id uuid.UUID) (string, error) {
 	claims := Claims{
 		UserId: id,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(30 * 24 * time.Hour)),
+			ID:        sessionId.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.AppConfig.AccessTokenExpiresIn)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "foglio",
+			Subject:   id.String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// GenerateSecureToken returns an opaque random token for refresh tokens and emailed links. Only its hash
+// should be stored.
+func GenerateSecureToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex SHA-256 of an opaque token for storage and lookup.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateImpersonationToken issues a short-lived token for the user on behalf of an admin. The token ID
+// is the impersonation session ID so the session can be checked and revoked.
+func GenerateImpersonationToken(id, impersonatorId, sessionId uuid.UUID, expiresAt time.Time) (string, error) {
+	if len(jwtSecret) == 0 {
+		return "", ErrMissingSecretKey
+	}
+
+	claims := Claims{
+		UserId:         id,
+		ImpersonatorId: &impersonatorId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionId.String(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "foglio",
@@ -46,7 +99,55 @@ func GenerateToken(id uuid.UUID) (string, error) {
 	return token.SignedString(jwtSecret)
 }
 
+// GenerateChallengeToken issues a token that lets the user finish signing in with a 2FA code. It cannot be
+// used as an access token.
+func GenerateChallengeToken(id uuid.UUID, expiresAt time.Time) (string, error) {
+	if len(jwtSecret) == 0 {
+		return "", ErrMissingSecretKey
+	}
+
+	claims := Claims{
+		UserId:  id,
+		Purpose: TokenPurposeTwoFactorChallenge,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "foglio",
+			Subject:   id.String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// ValidateToken validates an access token.
 func ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// ValidateChallengeToken validates a token issued by GenerateChallengeToken.
+func ValidateChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != TokenPurposeTwoFactorChallenge {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func parseToken(tokenString string) (*Claims, error) {
 	if len(jwtSecret) == 0 {
 		return nil, ErrMissingSecretKey
 	}
@@ -75,25 +176,3 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
-
-func RefreshToken(tokenString string) (string, error) {
-	claims, err := ValidateToken(tokenString)
-	if err != nil && !errors.Is(err, ErrTokenExpired) {
-		return "", err
-	}
-
-	if claims == nil {
-		return "", ErrInvalidToken
-	}
-
-	return GenerateToken(claims.UserId)
-}
-
-func ExtractUserID(tokenString string) (uuid.UUID, error) {
-	claims, err := ValidateToken(tokenString)
-	if err != nil {
-		return uuid.Nil, err
-	}
-
-	return claims.UserId, nil
-}
@@ -2,11 +2,21 @@ package lib
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"foglio/v2/src/config"
+	"io"
 	"log"
 	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
 
+	"github.com/cloudinary/cloudinary-go/v2/api"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/cloudinary/cloudinary-go/v2/asset"
 )
 
 func UploadMultiple(files []*multipart.FileHeader, path string) ([]string, error) {
@@ -74,3 +84,191 @@ func UploadSingle(fileHeader *multipart.FileHeader, path string) (string, error)
 
 	return res.SecureURL, nil
 }
+
+// StoredFile identifies an asset uploaded with authenticated delivery. It can only be
+// fetched through a signed URL, so callers must check access before calling OpenPrivate.
+type StoredFile struct {
+	PublicID     string
+	ResourceType string
+	Format       string
+	Version      int
+}
+
+func UploadPrivate(reader io.Reader, path string) (*StoredFile, error) {
+	ctx := context.Background()
+	cld, err := config.UseCloudinary()
+	if err != nil {
+		return nil, err
+	}
+
+	params := uploader.UploadParams{
+		ResourceType: "auto",
+		Type:         api.Authenticated,
+	}
+	if path != "" {
+		params.Folder = path
+	}
+
+	res, err := cld.Upload.Upload(ctx, reader, params)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error.Message != "" {
+		return nil, errors.New(res.Error.Message)
+	}
+
+	return &StoredFile{
+		PublicID:     res.PublicID,
+		ResourceType: res.ResourceType,
+		Format:       res.Format,
+		Version:      res.Version,
+	}, nil
+}
+
+// OpenPrivate streams an asset stored with UploadPrivate. The caller must close the reader.
+func OpenPrivate(file StoredFile) (io.ReadCloser, error) {
+	cld, err := config.UseCloudinary()
+	if err != nil {
+		return nil, err
+	}
+
+	publicID := file.PublicID
+	var stored *asset.Asset
+	switch file.ResourceType {
+	case "video":
+		stored, err = cld.Video(publicID)
+	case "raw":
+		stored, err = cld.File(publicID)
+	default:
+		if file.Format != "" {
+			publicID += "." + file.Format
+		}
+		stored, err = cld.Image(publicID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stored.DeliveryType = api.Authenticated
+	stored.Version = file.Version
+	stored.Config.URL.Secure = true
+	stored.Config.URL.SignURL = true
+
+	url, err := stored.String()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("failed to fetch stored file: %s", res.Status)
+	}
+
+	return res.Body, nil
+}
+
+// ErrNotUploaded is returned for URLs that were not uploaded to this Cloudinary account.
+var ErrNotUploaded = errors.New("file was not uploaded to this storage")
+
+// ParseUploadURL returns the asset behind a URL returned by UploadSingle or UploadMultiple. It reports false
+// for anything else, such as links users typed in themselves.
+func ParseUploadURL(rawURL string) (*StoredFile, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host != "res.cloudinary.com" {
+		return nil, false
+	}
+
+	// /<cloud>/<resource type>/upload/v<version>/<public id>
+	segments := strings.Split(strings.TrimPrefix(parsed.Path, "/"), "/")
+	if len(segments) < 5 || segments[0] != config.AppConfig.CloudinaryName || segments[2] != string(api.Upload) {
+		return nil, false
+	}
+	if !strings.HasPrefix(segments[3], "v") {
+		return nil, false
+	}
+	version, err := strconv.Atoi(segments[3][1:])
+	if err != nil {
+		return nil, false
+	}
+
+	file := &StoredFile{
+		PublicID:     strings.Join(segments[4:], "/"),
+		ResourceType: segments[1],
+		Version:      version,
+	}
+	// Raw files keep their extension in the public ID
+	if file.ResourceType != "raw" {
+		if ext := path.Ext(file.PublicID); ext != "" {
+			file.PublicID = strings.TrimSuffix(file.PublicID, ext)
+			file.Format = strings.TrimPrefix(ext, ".")
+		}
+	}
+	return file, true
+}
+
+// OpenUploaded streams an asset uploaded with UploadSingle or UploadMultiple. The caller must close the reader.
+func OpenUploaded(rawURL string) (io.ReadCloser, error) {
+	if _, ok := ParseUploadURL(rawURL); !ok {
+		return nil, ErrNotUploaded
+	}
+
+	res, err := http.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("failed to fetch stored file: %s", res.Status)
+	}
+
+	return res.Body, nil
+}
+
+// DeleteUploaded removes an asset uploaded with UploadSingle or UploadMultiple. URLs that point elsewhere are
+// left alone.
+func DeleteUploaded(rawURL string) error {
+	file, ok := ParseUploadURL(rawURL)
+	if !ok {
+		return nil
+	}
+	return destroy(*file, api.Upload)
+}
+
+// DeletePrivate removes an asset stored with UploadPrivate.
+func DeletePrivate(file StoredFile) error {
+	return destroy(file, api.Authenticated)
+}
+
+func destroy(file StoredFile, deliveryType api.DeliveryType) error {
+	cld, err := config.UseCloudinary()
+	if err != nil {
+		return err
+	}
+
+	resourceType := file.ResourceType
+	if resourceType == "" {
+		resourceType = "image"
+	}
+	invalidate := true
+	res, err := cld.Upload.Destroy(context.Background(), uploader.DestroyParams{
+		PublicID:     file.PublicID,
+		Type:         string(deliveryType),
+		ResourceType: resourceType,
+		Invalidate:   &invalidate,
+	})
+	if err != nil {
+		return err
+	}
+	if res.Error.Message != "" {
+		return errors.New(res.Error.Message)
+	}
+	// "not found" means it is already gone
+	if res.Result != "ok" && res.Result != "not found" {
+		return fmt.Errorf("failed to delete stored file: %s", res.Result)
+	}
+	return nil
+}
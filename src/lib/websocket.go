@@ -2,10 +2,11 @@ package lib
 
 import (
 	"encoding/json"
-	"foglio/v2/src/config"
 	"foglio/v2/src/models"
 	"log"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,9 +16,26 @@ import (
 )
 
 type ChatMessageHandler interface {
-	HandleWebSocketMessage(senderID string, payload map[string]interface{}) (interface{}, error)
+	HandleWebSocketMessage(senderID string, message SocketEnvelope) (interface{}, error)
 }
 
+// SocketAuthenticator resolves an access token to the ID of the user it belongs to and the sign-in
+// session it was issued for.
+type SocketAuthenticator func(token string) (userID string, sessionID string, err error)
+
+const (
+	writeWait        = 10 * time.Second
+	pongWait         = 60 * time.Second
+	pingPeriod       = (pongWait * 9) / 10
+	authWait         = 10 * time.Second
+	maxMessageSize   = 64 << 10 // 64 KB
+	sendBufferSize   = 256
+	actionQueueSize  = 16
+	actionsPerSecond = 5
+	actionBurst      = 20
+	maxRateViolation = 50
+)
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true
@@ -25,27 +43,92 @@ var upgrader = websocket.Upgrader{
 }
 
 type Client struct {
-	conn   *websocket.Conn
-	send   chan models.Notification
-	hub    *Hub
-	userID string
+	conn         *websocket.Conn
+	send         chan SocketEnvelope
+	actions      chan SocketEnvelope
+	hub          *Hub
+	userID       string
+	sessionID    string
+	limiter      *socketRateLimiter
+	violations   int
+	lastActivity time.Time
+	away         bool
+	closed       bool
+	mu           sync.Mutex
+}
+
+func newClient(conn *websocket.Conn, hub *Hub, userID, sessionID string) *Client {
+	return &Client{
+		conn:         conn,
+		send:         make(chan SocketEnvelope, sendBufferSize),
+		actions:      make(chan SocketEnvelope, actionQueueSize),
+		hub:          hub,
+		userID:       userID,
+		sessionID:    sessionID,
+		limiter:      newSocketRateLimiter(actionsPerSecond, actionBurst),
+		lastActivity: time.Now(),
+	}
+}
+
+// enqueue hands an envelope to the writer goroutine without blocking, reporting false if the client is gone or saturated.
+func (c *Client) enqueue(envelope SocketEnvelope) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	select {
+	case c.send <- envelope:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
 }
 
 type Hub struct {
 	clients            map[string]map[*Client]bool // userID -> clients map
-	broadcast          chan models.Notification
+	broadcast          chan BrokerMessage
 	register           chan *Client
 	unregister         chan *Client
 	mu                 sync.RWMutex
 	chatMessageHandler ChatMessageHandler
+	broker             Broker
+	nodeID             string
+	authenticator      SocketAuthenticator
+	presenceHandler    PresenceHandler
+	presence           map[string]models.PresenceStatus
+	presenceEvents     chan presenceEvent
+	brokerOps          chan brokerOp
+	activity           chan string
 }
 
-func NewHub() *Hub {
+func NewHub(broker Broker) *Hub {
+	if broker == nil {
+		broker = NewMemoryBroker()
+	}
+
 	return &Hub{
-		clients:    make(map[string]map[*Client]bool),
-		broadcast:  make(chan models.Notification),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		clients:        make(map[string]map[*Client]bool),
+		broadcast:      make(chan BrokerMessage),
+		register:       make(chan *Client),
+		unregister:     make(chan *Client),
+		broker:         broker,
+		nodeID:         uuid.New().String(),
+		presence:       make(map[string]models.PresenceStatus),
+		presenceEvents: make(chan presenceEvent, presenceBufferSize),
+		brokerOps:      make(chan brokerOp, brokerOpsBufferSize),
+		activity:       make(chan string, presenceBufferSize),
 	}
 }
 
@@ -57,7 +140,32 @@ func (h *Hub) GetChatMessageHandler() ChatMessageHandler {
 	return h.chatMessageHandler
 }
 
+func (h *Hub) SetAuthenticator(authenticator SocketAuthenticator) {
+	h.authenticator = authenticator
+}
+
+func (h *Hub) authenticate(token string) (string, string, error) {
+	if h.authenticator != nil {
+		return h.authenticator(token)
+	}
+
+	claims, err := ValidateToken(token)
+	if err != nil {
+		return "", "", err
+	}
+	return claims.UserId.String(), claims.ID, nil
+}
+
 func (h *Hub) Run() {
+	if err := h.broker.Subscribe(h.deliver); err != nil {
+		log.Printf("Failed to subscribe hub to %s broker: %v", h.broker.Name(), err)
+	}
+	go h.runBroker()
+	go h.runPresence()
+
+	sweep := time.NewTicker(presenceSweepEvery)
+	defer sweep.Stop()
+
 	for {
 		select {
 		case client := <-h.register:
@@ -67,60 +175,148 @@ func (h *Hub) Run() {
 			}
 			h.clients[client.userID][client] = true
 			h.mu.Unlock()
-			log.Printf("Client connected for user %s. Total users: %d", client.userID, len(h.clients))
+			h.brokerOps <- brokerOp{kind: brokerOpRegister, userID: client.userID}
+			h.refreshPresence(client.userID)
+			log.Printf("Client connected for user %s. Total users: %d", client.userID, h.GetUserCount())
 
 		case client := <-h.unregister:
-			h.mu.Lock()
-			if userClients, ok := h.clients[client.userID]; ok {
-				if _, ok := userClients[client]; ok {
-					delete(userClients, client)
-					close(client.send)
-					if len(userClients) == 0 {
-						delete(h.clients, client.userID)
-					}
-				}
+			if h.removeClient(client) {
+				h.brokerOps <- brokerOp{kind: brokerOpUnregister, userID: client.userID}
+				h.refreshPresence(client.userID)
 			}
-			h.mu.Unlock()
-			log.Printf("Client disconnected for user %s. Total users: %d", client.userID, len(h.clients))
+			log.Printf("Client disconnected for user %s. Total users: %d", client.userID, h.GetUserCount())
 
-		case notification := <-h.broadcast:
+		case message := <-h.broadcast:
+			if message.Target == BrokerTargetSessions {
+				h.dropSessions(message.UserID, message.SessionIDs)
+				continue
+			}
+
+			var stale []*Client
 			h.mu.RLock()
-			if userClients, ok := h.clients[notification.OwnerID.String()]; ok {
+			for userID, userClients := range h.clients {
+				if message.Target != BrokerTargetAll && userID != message.UserID {
+					continue
+				}
+				notification := message.Notification
+				if message.Target == BrokerTargetAll {
+					notification.OwnerID = uuid.Must(uuid.Parse(userID))
+				}
+				envelope := NewSocketEnvelope(SocketTypeNotification, "", notification)
 				for client := range userClients {
-					select {
-					case client.send <- notification:
-					default:
-						close(client.send)
-						delete(userClients, client)
+					if !client.enqueue(envelope) {
+						stale = append(stale, client)
 					}
 				}
 			}
 			h.mu.RUnlock()
+
+			for _, client := range stale {
+				if h.removeClient(client) {
+					h.brokerOps <- brokerOp{kind: brokerOpUnregister, userID: client.userID}
+					h.refreshPresence(client.userID)
+				}
+			}
+
+		case userID := <-h.activity:
+			h.refreshPresence(userID)
+
+		case <-sweep.C:
+			h.sweepPresence()
+		}
+	}
+}
+
+// dropSessions closes the user's local connections that were opened with one of the revoked sessions.
+func (h *Hub) dropSessions(userID string, sessionIDs []string) {
+	var revoked []*Client
+	h.mu.RLock()
+	for client := range h.clients[userID] {
+		if slices.Contains(sessionIDs, client.sessionID) {
+			revoked = append(revoked, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range revoked {
+		client.enqueue(NewSocketError(SocketTypeError, "", SocketErrUnauthorized, "session has been revoked"))
+		if h.removeClient(client) {
+			h.brokerOps <- brokerOp{kind: brokerOpUnregister, userID: client.userID}
+			h.refreshPresence(client.userID)
 		}
 	}
 }
 
+// removeClient drops a client from the local registry, reporting whether it was still registered.
+func (h *Hub) removeClient(client *Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	userClients, ok := h.clients[client.userID]
+	if !ok {
+		return false
+	}
+	if _, ok := userClients[client]; !ok {
+		return false
+	}
+
+	delete(userClients, client)
+	client.close()
+	if len(userClients) == 0 {
+		delete(h.clients, client.userID)
+	}
+	return true
+}
+
+// deliver receives messages from the broker and hands them to the local clients.
+func (h *Hub) deliver(message BrokerMessage) {
+	h.broadcast <- message
+}
+
 func (h *Hub) SendToUser(userID string, notification models.Notification) {
 	notification.OwnerID = uuid.Must(uuid.Parse(userID))
 	notification.CreatedAt = time.Now()
-	h.broadcast <- notification
+
+	err := h.broker.Publish(BrokerMessage{
+		Target:       BrokerTargetUser,
+		UserID:       userID,
+		Notification: notification,
+		Origin:       h.nodeID,
+	})
+	if err != nil {
+		log.Printf("Failed to publish notification for user %s: %v", userID, err)
+	}
+}
+
+// RevokeSessions closes the user's connections, on every replica, that were opened with one of the given
+// sign-in sessions.
+func (h *Hub) RevokeSessions(userID string, sessionIDs []string) {
+	if len(sessionIDs) == 0 {
+		return
+	}
+
+	err := h.broker.Publish(BrokerMessage{
+		Target:     BrokerTargetSessions,
+		UserID:     userID,
+		SessionIDs: sessionIDs,
+		Origin:     h.nodeID,
+	})
+	if err != nil {
+		log.Printf("Failed to publish session revocation for user %s: %v", userID, err)
+	}
 }
 
 func (h *Hub) BroadcastToAll(notification models.Notification) {
-	h.mu.RLock()
-	for userID := range h.clients {
-		notification.OwnerID = uuid.Must(uuid.Parse(userID))
-		notification.CreatedAt = time.Now()
-		for client := range h.clients[userID] {
-			select {
-			case client.send <- notification:
-			default:
-				close(client.send)
-				delete(h.clients[userID], client)
-			}
-		}
+	notification.CreatedAt = time.Now()
+
+	err := h.broker.Publish(BrokerMessage{
+		Target:       BrokerTargetAll,
+		Notification: notification,
+		Origin:       h.nodeID,
+	})
+	if err != nil {
+		log.Printf("Failed to publish broadcast: %v", err)
 	}
-	h.mu.RUnlock()
 }
 
 func (h *Hub) GetClientCount() int {
@@ -140,16 +336,42 @@ func (h *Hub) GetUserCount() int {
 	return len(h.clients)
 }
 
+// GetClusterStats returns connection counts across every replica sharing the broker.
+func (h *Hub) GetClusterStats() (*BrokerStats, error) {
+	return h.broker.Stats()
+}
+
+func (h *Hub) Close() error {
+	return h.broker.Close()
+}
+
 func (c *Client) readPump() {
+	go c.actionPump()
+
 	defer func() {
-		c.hub.unregister <- c
+		close(c.actions)
+		if c.userID != "" {
+			c.hub.unregister <- c
+		} else {
+			c.close()
+		}
 		if err := c.conn.Close(); err != nil {
 			log.Printf("Error closing WebSocket connection: %v", err)
 		}
 	}()
 
+	c.conn.SetReadLimit(maxMessageSize)
+	deadline := pongWait
+	if c.userID == "" {
+		deadline = authWait
+	}
+	_ = c.conn.SetReadDeadline(time.Now().Add(deadline))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
 	for {
-		_, message, err := c.conn.ReadMessage()
+		_, data, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error: %v", err)
@@ -157,66 +379,147 @@ func (c *Client) readPump() {
 			break
 		}
 
-		var msg map[string]interface{}
-		if err := json.Unmarshal(message, &msg); err == nil {
-			if action, ok := msg["action"].(string); ok {
-				switch action {
-				case "mark_read":
-					if notificationID, ok := msg["notification_id"].(string); ok {
-						log.Printf("Marking notification %s as read for user %s", notificationID, c.userID)
-					}
-				case "send_message", "typing", "stop_typing", "mark_messages_read":
-					if c.hub.chatMessageHandler != nil {
-						go func(payload map[string]interface{}) {
-							result, err := c.hub.chatMessageHandler.HandleWebSocketMessage(c.userID, payload)
-							if err != nil {
-								c.sendResponse(map[string]interface{}{
-									"success": false,
-									"type":    action + "_response",
-									"error":   err.Error(),
-								})
-							} else {
-								c.sendResponse(map[string]interface{}{
-									"success": true,
-									"type":    action + "_response",
-									"data":    result,
-								})
-							}
-						}(msg)
-					}
-				case "ping":
-					c.sendResponse(map[string]interface{}{
-						"type": "pong",
-						"time": time.Now().Unix(),
-					})
-				}
+		var message SocketEnvelope
+		if err := json.Unmarshal(data, &message); err != nil || message.Type == "" {
+			c.enqueue(NewSocketError(SocketTypeError, "", SocketErrInvalidMessage, "message must be a JSON envelope with a type"))
+			continue
+		}
+		if message.Version != 0 && message.Version != SocketProtocolVersion {
+			c.enqueue(NewSocketError(SocketTypeError, message.ID, SocketErrUnsupportedVersion, "unsupported protocol version"))
+			continue
+		}
+
+		if c.userID == "" {
+			if !c.authenticate(message) {
+				break
 			}
+			_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+			continue
+		}
+
+		if !c.limiter.Allow() {
+			c.violations++
+			c.enqueue(NewSocketError(message.Type+"_response", message.ID, SocketErrRateLimited, "too many messages, slow down"))
+			if c.violations >= maxRateViolation {
+				log.Printf("Closing WebSocket for user %s after repeated rate limit violations", c.userID)
+				break
+			}
+			continue
+		}
+
+		away := false
+		if message.Type == SocketTypePresence {
+			var payload SocketPresencePayload
+			_ = message.Decode(&payload)
+			away = payload.Status == models.PresenceAway
 		}
+		if c.touch(away) {
+			c.hub.activity <- c.userID
+		}
+
+		c.handle(message)
 	}
 }
 
-func (c *Client) sendResponse(response map[string]interface{}) {
-	data, err := json.Marshal(response)
+// authenticate accepts the first message of an unauthenticated connection, which must carry an access token.
+func (c *Client) authenticate(message SocketEnvelope) bool {
+	if message.Type != SocketTypeAuth {
+		c.enqueue(NewSocketError(SocketTypeError, message.ID, SocketErrUnauthorized, "authenticate before sending other messages"))
+		return false
+	}
+
+	var payload SocketAuthPayload
+	if err := message.Decode(&payload); err != nil || payload.Token == "" {
+		c.enqueue(NewSocketError(SocketTypeAuth+"_response", message.ID, SocketErrUnauthorized, "token is required"))
+		return false
+	}
+
+	userID, sessionID, err := c.hub.authenticate(payload.Token)
 	if err != nil {
-		log.Printf("Error marshaling response: %v", err)
-		return
+		c.enqueue(NewSocketError(SocketTypeAuth+"_response", message.ID, SocketErrUnauthorized, "invalid auth token"))
+		return false
 	}
-	if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
-		log.Printf("Error sending response: %v", err)
+
+	c.userID = userID
+	c.sessionID = sessionID
+	c.hub.register <- c
+	c.enqueue(NewSocketEnvelope(SocketTypeAuthenticated, message.ID, SocketAuthenticatedPayload{UserID: userID}))
+	return true
+}
+
+func (c *Client) handle(message SocketEnvelope) {
+	switch message.Type {
+	case SocketTypePing:
+		c.enqueue(NewSocketEnvelope(SocketTypePong, message.ID, SocketPongPayload{Time: time.Now().Unix()}))
+
+	case SocketTypePresence:
+		c.enqueue(NewSocketEnvelope(SocketTypePresence+"_response", message.ID, map[string]bool{"updated": true}))
+
+	case SocketTypeMarkRead:
+		var payload SocketMarkReadPayload
+		if err := message.Decode(&payload); err != nil || payload.NotificationID == "" {
+			c.enqueue(NewSocketError(message.Type+"_response", message.ID, SocketErrInvalidMessage, "notification_id is required"))
+			return
+		}
+		log.Printf("Marking notification %s as read for user %s", payload.NotificationID, c.userID)
+
+	case SocketTypeSendMessage, SocketTypeTyping, SocketTypeStopTyping, SocketTypeMarkMessagesRead,
+		SocketTypeEditMessage, SocketTypeDeleteMessage, SocketTypeAddReaction, SocketTypeRemoveReaction:
+		if c.hub.chatMessageHandler == nil {
+			c.enqueue(NewSocketError(message.Type+"_response", message.ID, SocketErrUnknownType, "chat is not available"))
+			return
+		}
+		select {
+		case c.actions <- message:
+		default:
+			c.enqueue(NewSocketError(message.Type+"_response", message.ID, SocketErrRateLimited, "too many pending actions, slow down"))
+		}
+
+	default:
+		c.enqueue(NewSocketError(SocketTypeError, message.ID, SocketErrUnknownType, "unknown message type: "+message.Type))
 	}
 }
 
+// actionPump runs the client's chat actions one at a time, in the order they arrived, so a slow
+// action delays only the connection that sent it. It exits once readPump closes the queue.
+func (c *Client) actionPump() {
+	for message := range c.actions {
+		result, err := c.hub.chatMessageHandler.HandleWebSocketMessage(c.userID, message)
+		if err != nil {
+			c.enqueue(NewSocketError(message.Type+"_response", message.ID, SocketErrActionFailed, err.Error()))
+			continue
+		}
+		c.enqueue(NewSocketEnvelope(message.Type+"_response", message.ID, result))
+	}
+}
+
+// writePump is the only goroutine allowed to write to the connection.
 func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
 	defer func() {
+		ticker.Stop()
 		if err := c.conn.Close(); err != nil {
 			log.Printf("Error closing WebSocket connection: %v", err)
 		}
 	}()
 
-	for notification := range c.send {
-		if err := c.conn.WriteJSON(notification); err != nil {
-			log.Printf("Write error: %v", err)
-			return
+	for {
+		select {
+		case envelope, ok := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				_ = c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteJSON(envelope); err != nil {
+				log.Printf("Write error: %v", err)
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
 		}
 	}
 }
@@ -229,7 +532,23 @@ func NewWebSocketHandler(hub *Hub) *WebSocketHandler {
 	return &WebSocketHandler{hub: hub}
 }
 
+// HandleWebSocket upgrades the connection. Browsers cannot set headers on the upgrade request,
+// so the token may come from the Authorization header, the token query param, or an auth message.
 func (wsh *WebSocketHandler) HandleWebSocket(c *gin.Context) {
+	userID, sessionID := "", ""
+	token := c.Query("token")
+	if header := c.GetHeader("Authorization"); token == "" && strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+	if token != "" {
+		id, session, err := wsh.hub.authenticate(token)
+		if err != nil {
+			Unauthorized(c, "Invalid auth token")
+			return
+		}
+		userID, sessionID = id, session
+	}
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
@@ -237,34 +556,43 @@ func (wsh *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	userID, exists := c.Get(config.AppConfig.CurrentUserId)
-	if !exists {
-		userID = "anonymous"
-	}
-
-	client := &Client{
-		conn:   conn,
-		send:   make(chan models.Notification, 256),
-		hub:    wsh.hub,
-		userID: userID.(string),
+	client := newClient(conn, wsh.hub, userID, sessionID)
+	if userID != "" {
+		wsh.hub.register <- client
+		client.enqueue(NewSocketEnvelope(SocketTypeAuthenticated, "", SocketAuthenticatedPayload{UserID: userID}))
 	}
 
-	wsh.hub.register <- client
-
 	go client.writePump()
 	go client.readPump()
 }
 
 func (wsh *WebSocketHandler) GetStats(c *gin.Context) {
+	stats, err := wsh.hub.GetClusterStats()
+	if err != nil {
+		log.Printf("Error fetching cluster stats: %v", err)
+		c.JSON(http.StatusOK, gin.H{
+			"connected_clients": wsh.hub.GetClientCount(),
+			"connected_users":   wsh.hub.GetUserCount(),
+			"local_clients":     wsh.hub.GetClientCount(),
+			"local_users":       wsh.hub.GetUserCount(),
+			"broker":            wsh.hub.broker.Name(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"connected_clients": wsh.hub.GetClientCount(),
-		"connected_users":   wsh.hub.GetUserCount(),
+		"connected_clients": stats.Clients,
+		"connected_users":   stats.Users,
+		"nodes":             stats.Nodes,
+		"local_clients":     wsh.hub.GetClientCount(),
+		"local_users":       wsh.hub.GetUserCount(),
+		"broker":            wsh.hub.broker.Name(),
 	})
 }
 
 func (wsh *WebSocketHandler) SendNotification(c *gin.Context) {
 	var req struct {
-		UserID  string                 `json:"user_id" binding:"required"`
+		UserID  string                 `json:"user_id" binding:"required,uuid"`
 		Type    string                 `json:"type" binding:"required"`
 		Title   string                 `json:"title" binding:"required"`
 		Message string                 `json:"message" binding:"required"`
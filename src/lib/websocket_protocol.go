@@ -0,0 +1,134 @@
+package lib
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	SocketProtocolVersion = 1
+
+	// Inbound message types
+	SocketTypeAuth             = "auth"
+	SocketTypePing             = "ping"
+	SocketTypeMarkRead         = "mark_read"
+	SocketTypeSendMessage      = "send_message"
+	SocketTypeTyping           = "typing"
+	SocketTypeStopTyping       = "stop_typing"
+	SocketTypeMarkMessagesRead = "mark_messages_read"
+	SocketTypeEditMessage      = "edit_message"
+	SocketTypeDeleteMessage    = "delete_message"
+	SocketTypeAddReaction      = "add_reaction"
+	SocketTypeRemoveReaction   = "remove_reaction"
+
+	// Outbound message types
+	SocketTypeAuthenticated = "authenticated"
+	SocketTypePong          = "pong"
+	SocketTypeNotification  = "notification"
+	SocketTypeError         = "error"
+
+	SocketErrUnauthorized       = "UNAUTHORIZED"
+	SocketErrInvalidMessage     = "INVALID_MESSAGE"
+	SocketErrUnsupportedVersion = "UNSUPPORTED_VERSION"
+	SocketErrUnknownType        = "UNKNOWN_TYPE"
+	SocketErrRateLimited        = "RATE_LIMITED"
+	SocketErrActionFailed       = "ACTION_FAILED"
+)
+
+// SocketEnvelope is the versioned frame used for every WebSocket message in both directions.
+type SocketEnvelope struct {
+	Version int             `json:"v"`
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Error   *SocketError    `json:"error,omitempty"`
+}
+
+type SocketError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type SocketAuthPayload struct {
+	Token string `json:"token"`
+}
+
+type SocketAuthenticatedPayload struct {
+	UserID string `json:"user_id"`
+}
+
+type SocketPongPayload struct {
+	Time int64 `json:"time"`
+}
+
+type SocketMarkReadPayload struct {
+	NotificationID string `json:"notification_id"`
+}
+
+// Decode unmarshals the envelope payload into target.
+func (e SocketEnvelope) Decode(target interface{}) error {
+	if len(e.Payload) == 0 {
+		return json.Unmarshal([]byte("{}"), target)
+	}
+	return json.Unmarshal(e.Payload, target)
+}
+
+func NewSocketEnvelope(messageType, id string, payload interface{}) SocketEnvelope {
+	envelope := SocketEnvelope{
+		Version: SocketProtocolVersion,
+		Type:    messageType,
+		ID:      id,
+	}
+	if payload != nil {
+		if data, err := json.Marshal(payload); err == nil {
+			envelope.Payload = data
+		}
+	}
+	return envelope
+}
+
+func NewSocketError(messageType, id, code, message string) SocketEnvelope {
+	return SocketEnvelope{
+		Version: SocketProtocolVersion,
+		Type:    messageType,
+		ID:      id,
+		Error:   &SocketError{Code: code, Message: message},
+	}
+}
+
+// socketRateLimiter is a token bucket guarding inbound actions on a single connection.
+type socketRateLimiter struct {
+	tokens   float64
+	burst    float64
+	rate     float64
+	lastSeen time.Time
+	mu       sync.Mutex
+}
+
+func newSocketRateLimiter(perSecond float64, burst int) *socketRateLimiter {
+	return &socketRateLimiter{
+		tokens:   float64(burst),
+		burst:    float64(burst),
+		rate:     perSecond,
+		lastSeen: time.Now(),
+	}
+}
+
+func (l *socketRateLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.lastSeen).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.lastSeen = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Audit records the action once the route's handler has responded successfully. It is meant for handlers
+// that cannot record entries themselves, so the entry carries only the route, not a before/after diff.
+func Audit(action models.AuditAction, entityType string) gin.HandlerFunc {
+	auditService := services.NewAuditService(database.GetDatabase())
+
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if ctx.Writer.Status() >= http.StatusBadRequest || len(ctx.Errors) > 0 {
+			return
+		}
+
+		auditService.Record(services.AuditEntry{
+			ActorID:        ctx.GetString(config.AppConfig.CurrentUserId),
+			ImpersonatorID: ctx.GetString("impersonator_id"),
+			Action:         action,
+			EntityType:     entityType,
+			Metadata:       map[string]interface{}{"route": ctx.FullPath()},
+			IPAddress:      ctx.ClientIP(),
+			UserAgent:      ctx.Request.UserAgent(),
+		})
+	}
+}
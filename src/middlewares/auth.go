@@ -1,12 +1,15 @@
 package middlewares
 
 import (
+	"errors"
 	"foglio/v2/src/config"
 	"foglio/v2/src/database"
 	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
 	"foglio/v2/src/services"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,9 +19,18 @@ const (
 )
 
 func isOpenRoute(path, method string) bool {
+	return matchAnyRoute(config.AppConfig.NonAuthRoutes, path, method)
+}
+
+// isSelfOnlyRoute reports whether the route changes the account itself, which an impersonating admin may not do.
+func isSelfOnlyRoute(path, method string) bool {
+	return matchAnyRoute(config.AppConfig.SelfOnlyRoutes, path, method)
+}
+
+func matchAnyRoute(routes []config.APIRoute, path, method string) bool {
 	path = strings.TrimSuffix(path, "/")
-	for _, openRoute := range config.AppConfig.NonAuthRoutes {
-		if (openRoute.Method == "*" || openRoute.Method == method) && matchRoute(openRoute.Endpoint, path) {
+	for _, route := range routes {
+		if (route.Method == "*" || route.Method == method) && matchRoute(route.Endpoint, path) {
 			return true
 		}
 	}
@@ -102,6 +114,12 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		claims, err := lib.ValidateToken(token)
+		if errors.Is(err, lib.ErrTokenExpired) {
+			// Clients exchange their refresh token for a new access token on this response
+			_ = ctx.Error(lib.NewApiErrror("Auth token has expired", http.StatusUnauthorized))
+			ctx.Abort()
+			return
+		}
 		if err != nil {
 			_ = ctx.Error(lib.NewApiErrror("Invalid auth token", http.StatusUnauthorized))
 			ctx.Abort()
@@ -115,8 +133,45 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if err := authService.CheckAccess(user, claims); err != nil {
+			switch {
+			case errors.Is(err, services.ErrAccountSuspended):
+				_ = ctx.Error(lib.NewApiErrror(suspensionMessage(user), http.StatusForbidden))
+			case errors.Is(err, services.ErrImpersonationEnded):
+				_ = ctx.Error(lib.NewApiErrror("Impersonation session has ended", http.StatusUnauthorized))
+			case errors.Is(err, services.ErrSessionRevoked):
+				_ = ctx.Error(lib.NewApiErrror("Session has been revoked", http.StatusUnauthorized))
+			default:
+				_ = ctx.Error(lib.NewApiErrror("Failed to authorize request", http.StatusInternalServerError))
+			}
+			ctx.Abort()
+			return
+		}
+
+		if claims.ImpersonatorId != nil && isSelfOnlyRoute(path, method) {
+			_ = ctx.Error(lib.NewApiErrror("This action is not available while impersonating a user", http.StatusForbidden))
+			ctx.Abort()
+			return
+		}
+
 		ctx.Set(config.AppConfig.CurrentUserId, user.ID.String())
 		ctx.Set("current_user", user)
+		if claims.ImpersonatorId != nil {
+			ctx.Set("impersonator_id", claims.ImpersonatorId.String())
+		} else {
+			ctx.Set("session_id", claims.ID)
+		}
 		ctx.Next()
 	}
 }
+
+func suspensionMessage(user *models.User) string {
+	message := "Your account has been suspended"
+	if user.SuspendedUntil != nil {
+		message += " until " + user.SuspendedUntil.UTC().Format(time.RFC3339)
+	}
+	if user.SuspensionReason != nil && *user.SuspensionReason != "" {
+		message += ": " + *user.SuspensionReason
+	}
+	return message
+}
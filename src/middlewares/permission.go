@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"foglio/v2/src/config"
+	"foglio/v2/src/database"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const permissionsContextKey = "current_permissions"
+
+// RequirePermission lets the request through only when the current user holds at least one of the
+// given permissions through an assigned role. It must run after AuthMiddleware.
+func RequirePermission(permissions ...models.Permission) gin.HandlerFunc {
+	roleService := services.NewRoleService(database.GetDatabase())
+
+	return func(ctx *gin.Context) {
+		granted, ok := loadPermissions(ctx, roleService)
+		if !ok {
+			return
+		}
+
+		if !hasAnyPermission(granted, permissions) {
+			_ = ctx.Error(lib.NewApiErrror("Admin access required", http.StatusForbidden))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequireSelfOrPermission lets users act on their own record, identified by the named route parameter,
+// and otherwise behaves like RequirePermission.
+func RequireSelfOrPermission(param string, permissions ...models.Permission) gin.HandlerFunc {
+	requirePermission := RequirePermission(permissions...)
+
+	return func(ctx *gin.Context) {
+		userId := ctx.GetString(config.AppConfig.CurrentUserId)
+		if userId != "" && ctx.Param(param) == userId {
+			ctx.Next()
+			return
+		}
+
+		requirePermission(ctx)
+	}
+}
+
+// loadPermissions resolves the current user's permissions once per request and caches them on the context.
+func loadPermissions(ctx *gin.Context, roleService *services.RoleService) ([]string, bool) {
+	if cached, exists := ctx.Get(permissionsContextKey); exists {
+		return cached.([]string), true
+	}
+
+	userId := ctx.GetString(config.AppConfig.CurrentUserId)
+	if userId == "" {
+		_ = ctx.Error(lib.NewApiErrror("User not authenticated", http.StatusUnauthorized))
+		ctx.Abort()
+		return nil, false
+	}
+
+	granted, err := roleService.GetUserPermissions(userId)
+	if err != nil {
+		_ = ctx.Error(lib.NewApiErrror("Failed to resolve permissions", http.StatusInternalServerError))
+		ctx.Abort()
+		return nil, false
+	}
+
+	ctx.Set(permissionsContextKey, granted)
+	return granted, true
+}
+
+func hasAnyPermission(granted []string, required []models.Permission) bool {
+	for _, permission := range required {
+		if models.HasPermission(granted, permission) {
+			return true
+		}
+	}
+	return false
+}
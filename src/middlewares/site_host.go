@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"foglio/v2/src/services"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SiteHostMiddleware answers requests for portfolio hosts, subdomains of the sites domain and known custom
+// domains, with site instead of the API. Requests for any other host, and ACME challenges for custom
+// domains, carry on as usual.
+func SiteHostMiddleware(siteHosts *services.SiteHostService, site gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if strings.HasPrefix(ctx.Request.URL.Path, services.ACMEChallengePrefix) || !siteHosts.IsSiteHost(ctx.Request.Host) {
+			ctx.Next()
+			return
+		}
+
+		site(ctx)
+		ctx.Abort()
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AccountRecoveryStatus string
+
+const (
+	RecoveryPending   AccountRecoveryStatus = "PENDING"
+	RecoveryApproved  AccountRecoveryStatus = "APPROVED" // An admin waived the waiting period
+	RecoveryRejected  AccountRecoveryStatus = "REJECTED"
+	RecoveryCancelled AccountRecoveryStatus = "CANCELLED"
+	RecoveryCompleted AccountRecoveryStatus = "COMPLETED"
+)
+
+// AccountRecovery is a request to turn off 2FA for a user who lost their authenticator and backup codes.
+// The requester proved the password and access to the email; the request can still only be completed once
+// the waiting period has passed, giving the owner time to cancel it, unless an admin approves it sooner.
+type AccountRecovery struct {
+	ID           uuid.UUID             `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID       uuid.UUID             `gorm:"type:uuid;not null;index" json:"user_id"`
+	User         *User                 `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Status       AccountRecoveryStatus `gorm:"not null;default:PENDING;index" json:"status"`
+	AvailableAt  time.Time             `gorm:"not null" json:"available_at"` // When the request can be completed
+	IPAddress    string                `json:"ip_address"`
+	UserAgent    string                `json:"user_agent"`
+	ReviewNote   *string               `gorm:"type:text" json:"review_note,omitempty"`
+	ReviewedAt   *time.Time            `json:"reviewed_at,omitempty"`
+	ReviewedByID *uuid.UUID            `gorm:"type:uuid" json:"-"`
+	CancelledAt  *time.Time            `json:"cancelled_at,omitempty"`
+	CompletedAt  *time.Time            `json:"completed_at,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+}
+
+// IsOpen reports whether the request can still be completed, cancelled or reviewed.
+func (r *AccountRecovery) IsOpen() bool {
+	return r.Status == RecoveryPending || r.Status == RecoveryApproved
+}
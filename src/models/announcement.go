@@ -93,8 +93,8 @@ func (a *Announcement) IsActive() bool {
 	return true
 }
 
-// IsVisibleToUser checks if the announcement should be shown to a specific user
-func (a *Announcement) IsVisibleToUser(user *User) bool {
+// IsVisibleToUser checks if the announcement should be shown to a specific user. Staff are users holding any admin role.
+func (a *Announcement) IsVisibleToUser(user *User, isStaff bool) bool {
 	if !a.IsActive() {
 		return false
 	}
@@ -103,7 +103,7 @@ func (a *Announcement) IsVisibleToUser(user *User) bool {
 	case TargetAllUsers:
 		return true
 	case TargetAdminsOnly:
-		return user.IsAdmin
+		return isStaff
 	case TargetRecruitersOnly:
 		return user.IsRecruiter
 	case TargetPremiumOnly:
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditAction string
+
+const (
+	AuditSignIn                   AuditAction = "auth.signin"
+	AuditSignInFailed             AuditAction = "auth.signin_failed"
+	AuditSignedOut                AuditAction = "auth.signout"
+	AuditSignedOutEverywhere      AuditAction = "auth.signout_everywhere"
+	AuditSessionRevoked           AuditAction = "auth.session_revoked"
+	AuditRefreshTokenReused       AuditAction = "auth.refresh_token_reused"
+	AuditPasswordChanged          AuditAction = "auth.password_changed"
+	AuditPasswordReset            AuditAction = "auth.password_reset"
+	AuditTwoFactorEnabled         AuditAction = "auth.2fa_enabled"
+	AuditTwoFactorDisabled        AuditAction = "auth.2fa_disabled"
+	AuditBackupCodesRegenerated   AuditAction = "auth.2fa_backup_codes_regenerated"
+	AuditPasskeyRegistered        AuditAction = "auth.passkey_registered"
+	AuditPasskeyRemoved           AuditAction = "auth.passkey_removed"
+	AuditProviderLinked           AuditAction = "auth.provider_linked"
+	AuditProviderUnlinked         AuditAction = "auth.provider_unlinked"
+	AuditSSOAssertionAccepted     AuditAction = "auth.sso_assertion_accepted"
+	AuditEmailChangeRequested     AuditAction = "auth.email_change_requested"
+	AuditEmailChanged             AuditAction = "auth.email_changed"
+	AuditEmailReverted            AuditAction = "auth.email_reverted"
+	AuditRecoveryCodeSent         AuditAction = "auth.recovery_code_sent"
+	AuditRecoveryRequested        AuditAction = "auth.recovery_requested"
+	AuditRecoveryCancelled        AuditAction = "auth.recovery_cancelled"
+	AuditRecoveryCompleted        AuditAction = "auth.recovery_completed"
+	AuditDataExportRequested      AuditAction = "account.export_requested"
+	AuditDataExportDownloaded     AuditAction = "account.export_downloaded"
+	AuditDeletionCodeSent         AuditAction = "account.deletion_code_sent"
+	AuditDeletionScheduled        AuditAction = "account.deletion_scheduled"
+	AuditDeletionCancelled        AuditAction = "account.deletion_cancelled"
+	AuditSSOUpdated               AuditAction = "company.sso_updated"
+	AuditSSORemoved               AuditAction = "company.sso_removed"
+	AuditSSODomainVerified        AuditAction = "company.sso_domain_verified"
+	AuditSubscriptionStarted      AuditAction = "subscription.started"
+	AuditSubscriptionUpgraded     AuditAction = "subscription.upgraded"
+	AuditSubscriptionDowngraded   AuditAction = "subscription.downgraded"
+	AuditSubscriptionCancelled    AuditAction = "subscription.cancelled"
+	AuditPaymentVerified          AuditAction = "payment.verified"
+	AuditPaymentWebhook           AuditAction = "payment.webhook"
+	AuditTierCreated              AuditAction = "tier.created"
+	AuditTierUpdated              AuditAction = "tier.updated"
+	AuditTierDeleted              AuditAction = "tier.deleted"
+	AuditAnnouncementPublished    AuditAction = "announcement.published"
+	AuditAnnouncementUnpublished  AuditAction = "announcement.unpublished"
+	AuditApplicationStatusChanged AuditAction = "application.status_changed"
+	AuditRoleCreated              AuditAction = "admin.role_created"
+	AuditRoleUpdated              AuditAction = "admin.role_updated"
+	AuditRoleDeleted              AuditAction = "admin.role_deleted"
+	AuditRoleAssigned             AuditAction = "admin.role_assigned"
+	AuditRoleRevoked              AuditAction = "admin.role_revoked"
+	AuditUserSuspended            AuditAction = "admin.user_suspended"
+	AuditUserUnsuspended          AuditAction = "admin.user_unsuspended"
+	AuditUserVerified             AuditAction = "admin.user_verified"
+	AuditUserUpdated              AuditAction = "admin.user_updated"
+	AuditUserDeleted              AuditAction = "admin.user_deleted"
+	AuditUserRestored             AuditAction = "admin.user_restored"
+	AuditUsersMerged              AuditAction = "admin.users_merged"
+	AuditIdentityApproved         AuditAction = "admin.identity_approved"
+	AuditIdentityRejected         AuditAction = "admin.identity_rejected"
+	AuditImpersonationStarted     AuditAction = "admin.impersonation_started"
+	AuditImpersonationEnded       AuditAction = "admin.impersonation_ended"
+	AuditRecoveryApproved         AuditAction = "admin.recovery_approved"
+	AuditRecoveryRejected         AuditAction = "admin.recovery_rejected"
+	AuditChatReportResolved       AuditAction = "admin.chat_report_resolved"
+	AuditChatBanLifted            AuditAction = "admin.chat_ban_lifted"
+	AuditNotificationBroadcast    AuditAction = "admin.notification_broadcast"
+)
+
+// AuditChange is one field's value before and after an action. Either side is nil when the entity was
+// created or deleted.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditLog is an append-only record of a security- or money-relevant action. Rows are never updated or
+// deleted, and actor columns carry no foreign key so entries outlive the accounts they mention.
+type AuditLog struct {
+	ID             uuid.UUID              `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ActorID        *uuid.UUID             `gorm:"type:uuid;index" json:"actor_id,omitempty"` // Nil for system actions such as payment webhooks
+	ImpersonatorID *uuid.UUID             `gorm:"type:uuid;index" json:"impersonator_id,omitempty"`
+	Action         AuditAction            `gorm:"not null;index" json:"action"`
+	EntityType     string                 `gorm:"not null;index:idx_audit_logs_entity" json:"entity_type"`
+	EntityID       string                 `gorm:"index:idx_audit_logs_entity" json:"entity_id,omitempty"`
+	Changes        map[string]AuditChange `gorm:"type:jsonb;serializer:json" json:"changes,omitempty"`
+	Metadata       map[string]interface{} `gorm:"type:jsonb;serializer:json" json:"metadata,omitempty"`
+	IPAddress      string                 `json:"ip_address,omitempty"`
+	UserAgent      string                 `json:"user_agent,omitempty"`
+	CreatedAt      time.Time              `gorm:"index" json:"created_at"`
+}
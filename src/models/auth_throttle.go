@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Authentication actions whose failed attempts are throttled.
+const (
+	AuthActionSignin        = "signin"
+	AuthActionPasswordReset = "password_reset"
+	AuthActionVerification  = "verification"
+	AuthActionTwoFactor     = "two_factor"
+)
+
+// AuthThrottle counts recent failed attempts at an authentication action for one account or one IP address.
+// Past a few failures every further attempt has to wait longer, until the key is locked out altogether.
+type AuthThrottle struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Action        string     `gorm:"not null;uniqueIndex:idx_auth_throttles_action_key" json:"action"`
+	Key           string     `gorm:"not null;uniqueIndex:idx_auth_throttles_action_key" json:"-"` // Hash of the account or IP key
+	Failures      int        `gorm:"default:0" json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	BlockedUntil  *time.Time `gorm:"index" json:"blocked_until,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
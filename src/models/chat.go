@@ -17,6 +17,46 @@ const (
 	MessageStatusRead      MessageStatus = "READ"
 )
 
+type ConversationType string
+
+const (
+	ConversationTypeDirect ConversationType = "DIRECT"
+	ConversationTypeGroup  ConversationType = "GROUP"
+)
+
+type ParticipantRole string
+
+const (
+	ParticipantRoleOwner  ParticipantRole = "OWNER"
+	ParticipantRoleMember ParticipantRole = "MEMBER"
+)
+
+type ChatReportReason string
+
+const (
+	ChatReportReasonSpam          ChatReportReason = "SPAM"
+	ChatReportReasonHarassment    ChatReportReason = "HARASSMENT"
+	ChatReportReasonInappropriate ChatReportReason = "INAPPROPRIATE_CONTENT"
+	ChatReportReasonScam          ChatReportReason = "SCAM"
+	ChatReportReasonOther         ChatReportReason = "OTHER"
+)
+
+type ChatReportStatus string
+
+const (
+	ChatReportStatusPending   ChatReportStatus = "PENDING"
+	ChatReportStatusResolved  ChatReportStatus = "RESOLVED"
+	ChatReportStatusDismissed ChatReportStatus = "DISMISSED"
+)
+
+type ChatReportAction string
+
+const (
+	ChatReportActionDismiss ChatReportAction = "DISMISS"
+	ChatReportActionWarn    ChatReportAction = "WARN"
+	ChatReportActionBan     ChatReportAction = "BAN"
+)
+
 type MediaType string
 
 const (
@@ -63,44 +103,194 @@ func (m *MessageMediaList) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, m)
 }
 
+// Conversation is either a direct chat between two users or a named group. Participant1 and
+// Participant2 hold the sorted pair for direct chats and are null for groups.
 type Conversation struct {
-	ID           uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	Participant1 uuid.UUID      `gorm:"type:uuid;not null;index" json:"participant_1"`
-	Participant2 uuid.UUID      `gorm:"type:uuid;not null;index" json:"participant_2"`
-	User1        User           `gorm:"foreignKey:Participant1;references:ID;constraint:OnDelete:CASCADE" json:"user_1,omitempty"`
-	User2        User           `gorm:"foreignKey:Participant2;references:ID;constraint:OnDelete:CASCADE" json:"user_2,omitempty"`
-	LastMessage  *Message       `gorm:"-" json:"last_message,omitempty"`
-	Messages     []Message      `gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE" json:"messages,omitempty"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uuid.UUID                 `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Type         ConversationType          `gorm:"not null;default:'DIRECT'" json:"type"`
+	Name         *string                   `json:"name,omitempty"`
+	Participant1 *uuid.UUID                `gorm:"type:uuid;index" json:"participant_1,omitempty"`
+	Participant2 *uuid.UUID                `gorm:"type:uuid;index" json:"participant_2,omitempty"`
+	CreatedByID  *uuid.UUID                `gorm:"type:uuid" json:"created_by_id,omitempty"`
+	Participants []ConversationParticipant `gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE" json:"participants,omitempty"`
+	LastMessage  *Message                  `gorm:"-" json:"last_message,omitempty"`
+	Messages     []Message                 `gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE" json:"messages,omitempty"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt            `gorm:"index" json:"-"`
+}
+
+// ConversationParticipant is a user's membership in a conversation along with their own read and
+// mute state. A muted participant with no MutedUntil stays muted until they unmute.
+type ConversationParticipant struct {
+	ID                uuid.UUID       `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ConversationID    uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_conversation_participant" json:"conversation_id"`
+	Conversation      Conversation    `gorm:"foreignKey:ConversationID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	UserID            uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_conversation_participant;index" json:"user_id"`
+	User              User            `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Role              ParticipantRole `gorm:"not null;default:'MEMBER'" json:"role"`
+	LastReadAt        *time.Time      `json:"last_read_at,omitempty"`
+	LastReadMessageID *uuid.UUID      `gorm:"type:uuid" json:"last_read_message_id,omitempty"`
+	MutedAt           *time.Time      `json:"muted_at,omitempty"`
+	MutedUntil        *time.Time      `json:"muted_until,omitempty"`
+	JoinedAt          time.Time       `gorm:"not null" json:"joined_at"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
 }
 
+// Message is a chat message. A message deleted for everyone keeps its row, so replies still resolve,
+// but has its content and media cleared.
 type Message struct {
-	ID             uuid.UUID        `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	ConversationID uuid.UUID        `gorm:"type:uuid;not null;index" json:"conversation_id"`
-	Conversation   Conversation     `gorm:"foreignKey:ConversationID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
-	SenderID       uuid.UUID        `gorm:"type:uuid;not null;index" json:"sender_id"`
-	Sender         User             `gorm:"foreignKey:SenderID;references:ID;constraint:OnDelete:CASCADE" json:"sender,omitempty"`
-	RecipientID    uuid.UUID        `gorm:"type:uuid;not null;index" json:"recipient_id"`
-	Recipient      User             `gorm:"foreignKey:RecipientID;references:ID;constraint:OnDelete:CASCADE" json:"recipient,omitempty"`
-	Content        string           `gorm:"type:text" json:"content"`
-	Media          MessageMediaList `gorm:"type:jsonb" json:"media,omitempty"`
-	Status         MessageStatus    `gorm:"not null;default:'SENT'" json:"status"`
-	ReadAt         *time.Time       `json:"read_at,omitempty"`
-	DeliveredAt    *time.Time       `json:"delivered_at,omitempty"`
-	CreatedAt      time.Time        `json:"created_at"`
-	UpdatedAt      time.Time        `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt   `gorm:"index" json:"-"`
+	ID                   uuid.UUID         `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ConversationID       uuid.UUID         `gorm:"type:uuid;not null;index" json:"conversation_id"`
+	Conversation         Conversation      `gorm:"foreignKey:ConversationID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	SenderID             uuid.UUID         `gorm:"type:uuid;not null;index" json:"sender_id"`
+	Sender               User              `gorm:"foreignKey:SenderID;references:ID;constraint:OnDelete:CASCADE" json:"sender,omitempty"`
+	Content              string            `gorm:"type:text" json:"content"`
+	Media                MessageMediaList  `gorm:"type:jsonb" json:"media,omitempty"`
+	ReplyToID            *uuid.UUID        `gorm:"type:uuid;index" json:"reply_to_id,omitempty"`
+	ReplyTo              *Message          `gorm:"foreignKey:ReplyToID;references:ID;constraint:OnDelete:SET NULL" json:"reply_to,omitempty"`
+	Reactions            []MessageReaction `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE" json:"reactions,omitempty"`
+	EditedAt             *time.Time        `json:"edited_at,omitempty"`
+	DeletedForEveryoneAt *time.Time        `json:"deleted_for_everyone_at,omitempty"`
+	CreatedAt            time.Time         `gorm:"index" json:"created_at"`
+	UpdatedAt            time.Time         `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt    `gorm:"index" json:"-"`
+}
+
+// MessageEdit keeps the content a message had before each edit.
+type MessageEdit struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	MessageID uuid.UUID `gorm:"type:uuid;not null;index" json:"message_id"`
+	Message   Message   `gorm:"foreignKey:MessageID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Content   string    `gorm:"type:text" json:"content"`
+	EditedAt  time.Time `gorm:"not null" json:"edited_at"`
+}
+
+// MessageDeletion hides a message from a single user's view of the conversation.
+type MessageDeletion struct {
+	MessageID uuid.UUID `gorm:"type:uuid;primaryKey" json:"message_id"`
+	Message   Message   `gorm:"foreignKey:MessageID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MessageReaction struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	MessageID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_message_reaction" json:"message_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_message_reaction" json:"user_id"`
+	User      User      `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Emoji     string    `gorm:"not null;size:32;uniqueIndex:idx_message_reaction" json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserBlock stops the blocked user from starting or continuing a direct conversation with the blocker.
+type UserBlock struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	BlockerID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_block" json:"blocker_id"`
+	Blocker   User      `gorm:"foreignKey:BlockerID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	BlockedID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_block;index" json:"blocked_id"`
+	Blocked   User      `gorm:"foreignKey:BlockedID;references:ID;constraint:OnDelete:CASCADE" json:"blocked,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatReport is a user's complaint about another user's chat behaviour, queued for admin review.
+type ChatReport struct {
+	ID             uuid.UUID           `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ReporterID     uuid.UUID           `gorm:"type:uuid;not null;index" json:"reporter_id"`
+	Reporter       User                `gorm:"foreignKey:ReporterID;references:ID;constraint:OnDelete:CASCADE" json:"reporter,omitempty"`
+	ReportedUserID uuid.UUID           `gorm:"type:uuid;not null;index" json:"reported_user_id"`
+	ReportedUser   User                `gorm:"foreignKey:ReportedUserID;references:ID;constraint:OnDelete:CASCADE" json:"reported_user,omitempty"`
+	ConversationID *uuid.UUID          `gorm:"type:uuid;index" json:"conversation_id,omitempty"`
+	Reason         ChatReportReason    `gorm:"not null" json:"reason"`
+	Details        *string             `gorm:"type:text" json:"details,omitempty"`
+	Status         ChatReportStatus    `gorm:"not null;default:'PENDING';index" json:"status"`
+	Messages       []ChatReportMessage `gorm:"foreignKey:ReportID;constraint:OnDelete:CASCADE" json:"messages,omitempty"`
+	Action         *ChatReportAction   `json:"action,omitempty"`
+	ResolutionNote *string             `gorm:"type:text" json:"resolution_note,omitempty"`
+	ResolvedByID   *uuid.UUID          `gorm:"type:uuid" json:"resolved_by_id,omitempty"`
+	ResolvedAt     *time.Time          `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time           `gorm:"index" json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+// ChatReportMessage is a copy of a reported message taken when the report was filed, so later edits
+// or deletions do not remove the evidence.
+type ChatReportMessage struct {
+	ID        uuid.UUID        `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ReportID  uuid.UUID        `gorm:"type:uuid;not null;index" json:"report_id"`
+	MessageID uuid.UUID        `gorm:"type:uuid;not null" json:"message_id"`
+	SenderID  uuid.UUID        `gorm:"type:uuid;not null" json:"sender_id"`
+	Content   string           `gorm:"type:text" json:"content"`
+	Media     MessageMediaList `gorm:"type:jsonb" json:"media,omitempty"`
+	SentAt    time.Time        `gorm:"not null" json:"sent_at"`
+	EditedAt  *time.Time       `json:"edited_at,omitempty"`
+}
+
+func (m *Message) IsDeletedForEveryone() bool {
+	return m.DeletedForEveryoneAt != nil
+}
+
+func (p *ConversationParticipant) IsMuted(now time.Time) bool {
+	return p.MutedAt != nil && (p.MutedUntil == nil || now.Before(*p.MutedUntil))
+}
+
+func (c *Conversation) IsGroup() bool {
+	return c.Type == ConversationTypeGroup
 }
 
 func (c *Conversation) IsParticipant(userID uuid.UUID) bool {
-	return c.Participant1 == userID || c.Participant2 == userID
+	for _, participant := range c.Participants {
+		if participant.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Conversation) GetParticipant(userID uuid.UUID) *ConversationParticipant {
+	for i := range c.Participants {
+		if c.Participants[i].UserID == userID {
+			return &c.Participants[i]
+		}
+	}
+	return nil
 }
 
-func (c *Conversation) GetOtherParticipant(userID uuid.UUID) uuid.UUID {
-	if c.Participant1 == userID {
-		return c.Participant2
+// GetOtherParticipant returns the counterpart in a direct conversation.
+func (c *Conversation) GetOtherParticipant(userID uuid.UUID) *ConversationParticipant {
+	for i := range c.Participants {
+		if c.Participants[i].UserID != userID {
+			return &c.Participants[i]
+		}
 	}
-	return c.Participant1
+	return nil
+}
+
+// ChatAttachment is a file uploaded into a conversation. The file itself is stored privately and
+// served through the API so only participants of the conversation can fetch it.
+type ChatAttachment struct {
+	ID                uuid.UUID    `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	ConversationID    uuid.UUID    `gorm:"type:uuid;not null;index" json:"conversation_id"`
+	Conversation      Conversation `gorm:"foreignKey:ConversationID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	UploaderID        uuid.UUID    `gorm:"type:uuid;not null;index" json:"uploader_id"`
+	Uploader          User         `gorm:"foreignKey:UploaderID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Type              MediaType    `gorm:"not null" json:"type"`
+	FileName          string       `json:"file_name"`
+	MimeType          string       `json:"mime_type"`
+	FileSize          int64        `json:"file_size"`
+	Width             int          `json:"width,omitempty"`
+	Height            int          `json:"height,omitempty"`
+	StoragePublicID   string       `gorm:"not null" json:"-"`
+	StorageResource   string       `json:"-"`
+	StorageFormat     string       `json:"-"`
+	StorageVersion    int          `json:"-"`
+	ThumbnailPublicID *string      `json:"-"`
+	ThumbnailFormat   string       `json:"-"`
+	ThumbnailVersion  int          `json:"-"`
+	CreatedAt         time.Time    `json:"created_at"`
+}
+
+func (a *ChatAttachment) HasThumbnail() bool {
+	return a.ThumbnailPublicID != nil
 }
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CompanySSO is a company's SAML 2.0 single sign-on setup. Foglio is the service provider, with its own
+// entity ID and key pair per company so each IdP trusts only the company it was set up for.
+type CompanySSO struct {
+	ID                uuid.UUID          `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	CompanyID         uuid.UUID          `gorm:"type:uuid;not null;uniqueIndex" json:"company_id"`
+	Company           Company            `gorm:"foreignKey:CompanyID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Enabled           bool               `gorm:"default:false" json:"enabled"`
+	EnforceSSO        bool               `gorm:"default:false" json:"enforce_sso"` // Blocks password sign-in for the company's verified domains
+	AllowIdPInitiated bool               `gorm:"default:true" json:"allow_idp_initiated"`
+	IdPEntityID       string             `json:"idp_entity_id"`
+	IdPSSOURL         string             `json:"idp_sso_url"`
+	IdPMetadata       string             `gorm:"type:text" json:"-"`
+	SPCertificate     string             `gorm:"type:text;not null" json:"sp_certificate"` // PEM, given to the IdP for encrypting assertions
+	SPPrivateKey      string             `gorm:"type:text;not null" json:"-"`
+	Domains           []CompanySSODomain `gorm:"foreignKey:CompanyID;references:CompanyID" json:"domains,omitempty"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+}
+
+// CompanySSODomain is an email domain a company claims for SSO. It only routes sign-ins to the company's
+// IdP, and only accepts the IdP's users, once the company has proven it owns the domain through DNS.
+type CompanySSODomain struct {
+	ID                uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	CompanyID         uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_company_sso_domains_company_domain" json:"company_id"`
+	Company           Company    `gorm:"foreignKey:CompanyID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Domain            string     `gorm:"not null;index;uniqueIndex:idx_company_sso_domains_company_domain" json:"domain"`
+	VerificationToken string     `gorm:"not null" json:"-"`
+	DnsRecord         DnsRecord  `gorm:"-" json:"dns_record"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// SSODomainRecordPrefix is prepended to the domain to get the name of its verification TXT record.
+const SSODomainRecordPrefix = "_foglio-sso."
+
+// VerificationRecord is the TXT record that proves the company owns the domain.
+func (d *CompanySSODomain) VerificationRecord() DnsRecord {
+	status := DomainStatusPending
+	if d.VerifiedAt != nil {
+		status = DomainStatusVerified
+	}
+	return DnsRecord{
+		Type:   "TXT",
+		Name:   SSODomainRecordPrefix + d.Domain,
+		Value:  "foglio-sso-verification=" + d.VerificationToken,
+		Status: status,
+	}
+}
+
+// SAMLRequest is an SP-initiated authentication request waiting for the IdP's response.
+type SAMLRequest struct {
+	ID        string    `gorm:"primaryKey" json:"id"` // The AuthnRequest ID, echoed in InResponseTo
+	CompanyID uuid.UUID `gorm:"type:uuid;not null;index" json:"company_id"`
+	Company   Company   `gorm:"foreignKey:CompanyID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SAMLAssertion records an assertion that was used to sign in, until it expires, so it cannot be replayed.
+type SAMLAssertion struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	CompanyID uuid.UUID `gorm:"type:uuid;not null" json:"company_id"`
+	Company   Company   `gorm:"foreignKey:CompanyID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CertificateStatus string
+
+const (
+	CertificatePending    CertificateStatus = "PENDING"
+	CertificateProcessing CertificateStatus = "PROCESSING"
+	CertificateIssued     CertificateStatus = "ISSUED"
+	CertificateFailed     CertificateStatus = "FAILED"
+)
+
+// DomainCertificate is the TLS certificate for a verified custom domain, obtained from the ACME CA and kept
+// in the database so every server can answer for the domain. A renewal replaces it in place; until then
+// the previous certificate keeps being served.
+type DomainCertificate struct {
+	ID            uuid.UUID         `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID        uuid.UUID         `gorm:"type:uuid;not null;index" json:"user_id"`
+	Domain        string            `gorm:"not null;uniqueIndex" json:"domain"`
+	Status        CertificateStatus `gorm:"not null;default:PENDING;index" json:"status"`
+	Certificate   string            `gorm:"type:text" json:"-"` // PEM chain, leaf first
+	PrivateKey    string            `gorm:"type:text" json:"-"` // PEM
+	Attempts      int               `gorm:"default:0" json:"attempts"`
+	Error         *string           `gorm:"type:text" json:"error,omitempty"`
+	NextAttemptAt *time.Time        `gorm:"index" json:"next_attempt_at,omitempty"`
+	StartedAt     *time.Time        `json:"started_at,omitempty"`
+	IssuedAt      *time.Time        `json:"issued_at,omitempty"`
+	RenewAt       *time.Time        `gorm:"index" json:"renew_at,omitempty"`
+	ExpiresAt     *time.Time        `json:"expires_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// ACMEChallenge is the answer to a pending HTTP-01 challenge. It is stored rather than held in memory
+// because the CA's validation request can reach any server.
+type ACMEChallenge struct {
+	Token            string    `gorm:"primaryKey" json:"-"`
+	Domain           string    `gorm:"not null;index" json:"domain"`
+	KeyAuthorization string    `gorm:"not null" json:"-"`
+	ExpiresAt        time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ACMEAccount is the account certificates are ordered with, one per ACME directory, shared by all servers.
+type ACMEAccount struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	DirectoryURL string    `gorm:"not null;uniqueIndex" json:"directory_url"`
+	URI          string    `json:"uri"`
+	Email        string    `json:"email"`
+	PrivateKey   string    `gorm:"type:text;not null" json:"-"` // PEM
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HubConnection tracks how many sockets a user holds on a single API replica.
+type HubConnection struct {
+	NodeID      string    `gorm:"primaryKey;size:64" json:"node_id"`
+	UserID      string    `gorm:"primaryKey;size:64" json:"user_id"`
+	Connections int       `gorm:"not null;default:0" json:"connections"`
+	UpdatedAt   time.Time `gorm:"index" json:"updated_at"`
+}
+
+// HubPayload stores hub messages too large for a single NOTIFY payload.
+type HubPayload struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Payload   string    `gorm:"type:text;not null" json:"payload"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
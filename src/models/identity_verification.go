@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdentityVerification is one identity document submitted for review. The document is stored privately and
+// only streamed to moderators; approving a submission copies its type and number onto the user.
+type IdentityVerification struct {
+	ID               uuid.UUID          `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID           uuid.UUID          `gorm:"type:uuid;not null;index" json:"user_id"`
+	User             *User              `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Type             VerificationType   `gorm:"type:verification_type;not null" json:"type"`
+	Number           string             `gorm:"not null" json:"number"`
+	Status           VerificationStatus `gorm:"not null;default:PENDING;index" json:"status"`
+	ReviewNote       *string            `gorm:"type:text" json:"review_note,omitempty"` // Reviewer's reason when rejected
+	ReviewedAt       *time.Time         `json:"reviewed_at,omitempty"`
+	ReviewedByID     *uuid.UUID         `gorm:"type:uuid" json:"-"`
+	DocumentName     string             `json:"document_name"`
+	DocumentMimeType string             `json:"document_mime_type"`
+	DocumentSize     int64              `json:"document_size"`
+	StoragePublicID  string             `gorm:"not null" json:"-"`
+	StorageResource  string             `json:"-"`
+	StorageFormat    string             `json:"-"`
+	StorageVersion   int                `json:"-"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
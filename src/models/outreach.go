@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OutreachStatus string
+
+const (
+	OutreachStatusPending OutreachStatus = "PENDING"
+	OutreachStatusSent    OutreachStatus = "SENT"
+	OutreachStatusFailed  OutreachStatus = "FAILED"
+	OutreachStatusSkipped OutreachStatus = "SKIPPED"
+)
+
+// MessageTemplate is a recruiter's saved message. Content is a Go text/template rendered per recipient.
+type MessageTemplate struct {
+	ID        uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	OwnerID   uuid.UUID      `gorm:"type:uuid;not null;index" json:"owner_id"`
+	Owner     User           `gorm:"foreignKey:OwnerID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Name      string         `gorm:"not null" json:"name"`
+	Content   string         `gorm:"type:text;not null" json:"content"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// OutreachMessage records one recipient of a bulk send. Pending and sent rows count towards the
+// sender's daily cap.
+type OutreachMessage struct {
+	ID            uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	SenderID      uuid.UUID      `gorm:"type:uuid;not null;index:idx_outreach_sender_created" json:"sender_id"`
+	Sender        User           `gorm:"foreignKey:SenderID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	JobID         uuid.UUID      `gorm:"type:uuid;not null;index" json:"job_id"`
+	ApplicationID uuid.UUID      `gorm:"type:uuid;not null" json:"application_id"`
+	RecipientID   uuid.UUID      `gorm:"type:uuid;not null" json:"recipient_id"`
+	TemplateID    *uuid.UUID     `gorm:"type:uuid" json:"template_id,omitempty"`
+	MessageID     *uuid.UUID     `gorm:"type:uuid" json:"message_id,omitempty"`
+	Status        OutreachStatus `gorm:"not null;default:'PENDING'" json:"status"`
+	Error         *string        `json:"error,omitempty"`
+	CreatedAt     time.Time      `gorm:"index:idx_outreach_sender_created" json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DataExportStatus string
+
+const (
+	ExportPending    DataExportStatus = "PENDING"
+	ExportProcessing DataExportStatus = "PROCESSING"
+	ExportReady      DataExportStatus = "READY"
+	ExportFailed     DataExportStatus = "FAILED"
+	ExportExpired    DataExportStatus = "EXPIRED" // The archive was removed after its download window
+)
+
+// DataExport is a user's request for a copy of their data. The archive is built in the background, stored
+// privately and can only be downloaded by the user until it expires.
+type DataExport struct {
+	ID              uuid.UUID        `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID          uuid.UUID        `gorm:"type:uuid;not null;index" json:"user_id"`
+	Status          DataExportStatus `gorm:"not null;default:PENDING;index" json:"status"`
+	FileSize        int64            `json:"file_size,omitempty"`
+	StoragePublicID *string          `json:"-"`
+	StorageResource string           `json:"-"`
+	StorageFormat   string           `json:"-"`
+	StorageVersion  int              `json:"-"`
+	Error           *string          `gorm:"type:text" json:"error,omitempty"`
+	StartedAt       *time.Time       `json:"started_at,omitempty"`
+	CompletedAt     *time.Time       `json:"completed_at,omitempty"`
+	ExpiresAt       *time.Time       `json:"expires_at,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+type AccountDeletionStatus string
+
+const (
+	DeletionScheduled AccountDeletionStatus = "SCHEDULED"
+	DeletionCancelled AccountDeletionStatus = "CANCELLED"
+	DeletionCompleted AccountDeletionStatus = "COMPLETED"
+)
+
+// AccountDeletion is a confirmed request to delete an account. The account stays usable until the grace
+// period ends so the owner can change their mind; after that its data is erased or anonymized. A deletion
+// staff schedule takes the account down right away instead, and only staff can restore it before it is erased.
+type AccountDeletion struct {
+	ID            uuid.UUID             `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID        uuid.UUID             `gorm:"type:uuid;not null;index" json:"user_id"`
+	Status        AccountDeletionStatus `gorm:"not null;default:SCHEDULED;index" json:"status"`
+	Reason        *string               `gorm:"type:text" json:"reason,omitempty"`
+	RequestedByID *uuid.UUID            `gorm:"type:uuid" json:"requested_by_id,omitempty"` // Staff member who deleted the account; nil when the owner did
+	ScheduledFor  time.Time             `gorm:"not null;index" json:"scheduled_for"`
+	CancelledAt   *time.Time            `json:"cancelled_at,omitempty"`
+	CompletedAt   *time.Time            `json:"completed_at,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Permission is a fine-grained admin capability granted to users through roles.
+type Permission string
+
+const (
+	PermissionAll                    Permission = "*" // Grants every permission, including ones added later
+	PermissionAnnouncementsWrite     Permission = "announcements:write"
+	PermissionSubscriptionsManage    Permission = "subscriptions:manage"
+	PermissionUsersModerate          Permission = "users:moderate"
+	PermissionUsersImpersonate       Permission = "users:impersonate"
+	PermissionAnalyticsRead          Permission = "analytics:read"
+	PermissionNotificationsBroadcast Permission = "notifications:broadcast"
+	PermissionRolesManage            Permission = "roles:manage"
+	PermissionAuditRead              Permission = "audit:read"
+)
+
+// AllPermissions lists every assignable permission, excluding the wildcard.
+var AllPermissions = []Permission{
+	PermissionAnnouncementsWrite,
+	PermissionSubscriptionsManage,
+	PermissionUsersModerate,
+	PermissionUsersImpersonate,
+	PermissionAnalyticsRead,
+	PermissionNotificationsBroadcast,
+	PermissionRolesManage,
+	PermissionAuditRead,
+}
+
+// SuperAdminRole is the seeded role holding the wildcard permission.
+const SuperAdminRole = "super_admin"
+
+func IsValidPermission(permission string) bool {
+	return Permission(permission) == PermissionAll || slices.Contains(AllPermissions, Permission(permission))
+}
+
+// HasPermission reports whether the granted permissions include the required one, either directly or through the wildcard.
+func HasPermission(granted []string, required Permission) bool {
+	return slices.Contains(granted, string(PermissionAll)) || slices.Contains(granted, string(required))
+}
+
+type Role struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Name        string         `gorm:"uniqueIndex;not null" json:"name"`
+	Description *string        `json:"description,omitempty"`
+	Permissions pq.StringArray `gorm:"type:text[]" json:"permissions"`
+	IsSystem    bool           `gorm:"default:false" json:"is_system"` // Seeded roles cannot be edited or deleted
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type UserRole struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_user_role" json:"user_id"`
+	User         User       `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	RoleID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_user_role;index" json:"role_id"`
+	Role         Role       `gorm:"foreignKey:RoleID;references:ID;constraint:OnDelete:CASCADE" json:"role"`
+	AssignedByID *uuid.UUID `gorm:"type:uuid" json:"assigned_by_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a signed-in device. Access tokens carry the session ID and stop working once it is revoked;
+// the refresh token is rotated on every use and only its hash is stored.
+type Session struct {
+	ID                   uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID               uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	User                 User       `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	RefreshTokenHash     string     `gorm:"not null;uniqueIndex" json:"-"`
+	PreviousRefreshToken string     `gorm:"index" json:"-"` // Hash of the token rotated out, kept to detect reuse
+	UserAgent            string     `json:"user_agent"`
+	IPAddress            string     `json:"ip_address"`
+	LastUsedAt           time.Time  `json:"last_used_at"`
+	ExpiresAt            time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt            *time.Time `gorm:"index" json:"revoked_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && s.ExpiresAt.After(time.Now())
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrustedDevice skips the 2FA step for sign-ins from a browser the user chose to remember. The browser holds
+// the token in a cookie; only its hash is stored.
+type TrustedDevice struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User       User      `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	TokenHash  string    `gorm:"not null;uniqueIndex" json:"-"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
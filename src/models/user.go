@@ -19,47 +19,76 @@ const (
 	VotersCard            VerificationType = "VOTERS_CARD"
 )
 
+type VerificationStatus string
+
+const (
+	VerificationPending  VerificationStatus = "PENDING"
+	VerificationApproved VerificationStatus = "APPROVED"
+	VerificationRejected VerificationStatus = "REJECTED"
+)
+
+type PresenceStatus string
+
+const (
+	PresenceOnline  PresenceStatus = "ONLINE"
+	PresenceAway    PresenceStatus = "AWAY"
+	PresenceOffline PresenceStatus = "OFFLINE"
+)
+
 type User struct {
-	ID                   uuid.UUID          `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	Name                 string             `gorm:"not null" json:"name"`
-	Username             string             `gorm:"uniqueIndex;not null" json:"username"`
-	Email                string             `gorm:"uniqueIndex;not null" json:"email"`
-	Password             string             `gorm:"null" json:"-"`                   // Nullable for OAuth users
-	Provider             string             `gorm:"default:'local'" json:"provider"` // local, google, github
-	ProviderID           string             `gorm:"null" json:"-"`                   // Provider's user ID
-	Role                 *string            `json:"role"`
-	Headline             *string            `json:"headline"`
-	Phone                *string            `gorm:"index" json:"phone"`
-	Location             *string            `gorm:"index" json:"location"`
-	Image                *string            `json:"image"`
-	Domain               *Domain            `gorm:"type:jsonb;serializer:json" json:"domain,omitempty"`
-	Portfolio            *Portfolio         `gorm:"foreignKey:UserID" json:"portfolio,omitempty"`
-	Summary              *string            `gorm:"null" json:"summary"`
-	SocialMedia          *SocialMedia       `gorm:"type:jsonb;serializer:json" json:"social_media,omitempty"`
-	CompanyID            *uuid.UUID         `gorm:"type:uuid;index" json:"company_id,omitempty"`
-	Company              *Company           `gorm:"foreignKey:CompanyID;references:ID" json:"company,omitempty"`
-	CurrentSubscription  *UserSubscription  `gorm:"foreignKey:UserID" json:"current_subscription,omitempty"`
-	SubscriptionHistory  []UserSubscription `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"subscription_history,omitempty"`
-	Skills               pq.StringArray     `gorm:"type:text[]" json:"skills"`
-	Projects             []Project          `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"projects,"`
-	Experiences          []Experience       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"experiences,"`
-	Education            []Education        `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"education,"`
-	Certifications       []Certification    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"certifications,"`
-	Languages            []Language         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"languages,"`
-	IsTwoFactorEnabled   bool               `json:"is_two_factor_enabled"`
-	TwoFactorSecret      *string            `gorm:"column:two_factor_secret" json:"-"`
-	TwoFactorBackupCodes pq.StringArray     `gorm:"type:text[];column:two_factor_backup_codes" json:"-"`
-	IsAdmin              bool               `json:"is_admin"`
-	IsRecruiter          bool               `json:"is_recruiter"`
-	IsPremium            bool               `json:"is_premium"`
-	CreatedAt            time.Time          `json:"created_at"`
-	UpdatedAt            time.Time          `json:"updated_at"`
-	DeletedAt            gorm.DeletedAt     `gorm:"index" json:"-"`
-	VerificationNumber   *string            `gorm:"uniqueIndex" json:"verification_number,omitempty"`
-	VerificationType     *VerificationType  `gorm:"type:verification_type" json:"verification_type,omitempty"`
-	VerificationDocument *string            `gorm:"type:text" json:"verification_document,omitempty"`
-	Verified             bool               `json:"verified"`
-	Otp                  string             `json:"otp"`
+	ID                       uuid.UUID           `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Name                     string              `gorm:"not null" json:"name"`
+	Username                 string              `gorm:"uniqueIndex;not null" json:"username"`
+	Email                    string              `gorm:"uniqueIndex;not null" json:"email"`
+	Password                 string              `gorm:"null" json:"-"`                   // Nullable for OAuth users
+	Provider                 string              `gorm:"default:'local'" json:"provider"` // How the account signed up; linked providers are in user_identities
+	ProviderID               string              `gorm:"null" json:"-"`                   // Legacy, superseded by user_identities
+	Role                     *string             `json:"role"`
+	Headline                 *string             `json:"headline"`
+	Phone                    *string             `gorm:"index" json:"phone"`
+	Location                 *string             `gorm:"index" json:"location"`
+	Image                    *string             `json:"image"`
+	Domain                   *Domain             `gorm:"type:jsonb;serializer:json" json:"domain,omitempty"`
+	Portfolio                *Portfolio          `gorm:"foreignKey:UserID" json:"portfolio,omitempty"`
+	Summary                  *string             `gorm:"null" json:"summary"`
+	SocialMedia              *SocialMedia        `gorm:"type:jsonb;serializer:json" json:"social_media,omitempty"`
+	CompanyID                *uuid.UUID          `gorm:"type:uuid;index" json:"company_id,omitempty"`
+	Company                  *Company            `gorm:"foreignKey:CompanyID;references:ID" json:"company,omitempty"`
+	CurrentSubscription      *UserSubscription   `gorm:"foreignKey:UserID" json:"current_subscription,omitempty"`
+	SubscriptionHistory      []UserSubscription  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"subscription_history,omitempty"`
+	Skills                   pq.StringArray      `gorm:"type:text[]" json:"skills"`
+	Projects                 []Project           `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"projects,"`
+	Experiences              []Experience        `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"experiences,"`
+	Education                []Education         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"education,"`
+	Certifications           []Certification     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"certifications,"`
+	Languages                []Language          `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"languages,"`
+	IsTwoFactorEnabled       bool                `json:"is_two_factor_enabled"`
+	TwoFactorSecret          *string             `gorm:"column:two_factor_secret" json:"-"`
+	TwoFactorBackupCodes     pq.StringArray      `gorm:"type:text[];column:two_factor_backup_codes" json:"-"`
+	TwoFactorFailedAttempts  int                 `gorm:"not null;default:0" json:"-"`
+	TwoFactorLockedUntil     *time.Time          `json:"-"` // Set after too many wrong 2FA codes in a row
+	IsRecruiter              bool                `json:"is_recruiter"`
+	IsPremium                bool                `json:"is_premium"`
+	CreatedAt                time.Time           `json:"created_at"`
+	UpdatedAt                time.Time           `json:"updated_at"`
+	DeletedAt                gorm.DeletedAt      `gorm:"index" json:"-"`
+	VerificationNumber       *string             `gorm:"uniqueIndex" json:"-"` // Identity document details are only shown to moderators on the verification endpoints
+	VerificationType         *VerificationType   `gorm:"type:verification_type" json:"-"`
+	VerificationDocument     *string             `gorm:"type:text" json:"-"`
+	Verified                 bool                `json:"verified"`
+	PresenceStatus           PresenceStatus      `gorm:"default:'OFFLINE'" json:"-"`
+	LastSeenAt               *time.Time          `json:"-"`
+	HidePresence             bool                `gorm:"default:false" json:"hide_presence"`
+	ChatBannedAt             *time.Time          `json:"chat_banned_at,omitempty"`
+	SuspendedAt              *time.Time          `json:"suspended_at,omitempty"`
+	SuspendedUntil           *time.Time          `json:"suspended_until,omitempty"` // Nil while suspended means a permanent ban
+	SuspensionReason         *string             `json:"-"`                         // Shown to moderators and in the suspended user's sign-in error
+	SuspendedByID            *uuid.UUID          `gorm:"type:uuid" json:"-"`
+	VerificationStatus       *VerificationStatus `json:"verification_status,omitempty"`
+	VerificationNote         *string             `json:"-"` // Reviewer's reason when rejected; the user sees it on their submission
+	VerificationReviewedAt   *time.Time          `json:"verification_reviewed_at,omitempty"`
+	VerificationReviewedByID *uuid.UUID          `gorm:"type:uuid" json:"-"`
+	IdentityVerified         bool                `gorm:"default:false" json:"identity_verified"` // Set once a moderator approves an identity document
 }
 
 type Company struct {
@@ -288,3 +317,24 @@ func (u *User) CanUseCustomDomain() bool {
 	tier := u.GetSubscriptionTier()
 	return tier == TierBasic || tier == TierPremium || tier == TierBusiness
 }
+
+// DailyOutreachLimit is how many bulk outreach messages the user may send per UTC day.
+func (u *User) DailyOutreachLimit() int {
+	switch u.GetSubscriptionTier() {
+	case TierBusiness:
+		return 1000
+	case TierPremium:
+		return 300
+	case TierBasic:
+		return 100
+	}
+	if u.IsPremium {
+		return 300
+	}
+	return 25
+}
+
+// IsSuspended reports whether the user is currently banned or within a timed suspension.
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil && (u.SuspendedUntil == nil || u.SuspendedUntil.After(time.Now()))
+}
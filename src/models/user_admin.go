@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImpersonationSession audits a support admin acting as another user. Tokens issued for a session stop
+// working once it expires or is ended.
+type ImpersonationSession struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	AdminID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"admin_id"`
+	Admin     User       `gorm:"foreignKey:AdminID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	User      User       `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Reason    string     `gorm:"type:text;not null" json:"reason"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (s *ImpersonationSession) IsActive() bool {
+	return s.EndedAt == nil && s.ExpiresAt.After(time.Now())
+}
+
+// AccountMerge records a duplicate account folded into another. The source's provider identity is kept
+// so OAuth sign-ins through it resolve to the surviving account.
+type AccountMerge struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	TargetUserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"target_user_id"`
+	SourceUserID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"source_user_id"`
+	SourceEmail      string    `gorm:"not null" json:"source_email"`
+	SourceUsername   string    `gorm:"not null" json:"source_username"`
+	SourceProvider   string    `gorm:"index:idx_account_merge_provider" json:"source_provider"`
+	SourceProviderID string    `gorm:"index:idx_account_merge_provider" json:"-"`
+	MergedByID       uuid.UUID `gorm:"type:uuid;not null" json:"merged_by_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links an account at an external identity provider to a user, who can then sign in through
+// it. A user links at most one account per provider.
+type UserIdentity struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_user_identities_user_provider" json:"user_id"`
+	User       User      `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Provider   string    `gorm:"not null;uniqueIndex:idx_user_identities_user_provider;uniqueIndex:idx_user_identities_subject" json:"provider"`
+	Subject    string    `gorm:"not null;uniqueIndex:idx_user_identities_subject" json:"-"` // The provider's ID for the account
+	Email      string    `json:"email"`
+	Name       string    `json:"name"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type OAuthIntent string
+
+const (
+	OAuthIntentSignin OAuthIntent = "SIGNIN"
+	OAuthIntentLink   OAuthIntent = "LINK"
+)
+
+// OAuthState is an authorization request waiting for the provider's callback. The state parameter is only
+// stored hashed, and the PKCE verifier never leaves the server.
+type OAuthState struct {
+	ID           uuid.UUID   `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	StateHash    string      `gorm:"not null;uniqueIndex" json:"-"`
+	Provider     string      `gorm:"not null" json:"provider"`
+	Intent       OAuthIntent `gorm:"not null" json:"intent"`
+	UserID       *uuid.UUID  `gorm:"type:uuid;index" json:"user_id,omitempty"` // The user linking the provider
+	User         *User       `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	CodeVerifier string      `gorm:"not null" json:"-"`
+	ExpiresAt    time.Time   `gorm:"not null;index" json:"expires_at"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
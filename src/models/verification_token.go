@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type VerificationPurpose string
+
+const (
+	PurposePasswordReset     VerificationPurpose = "PASSWORD_RESET"
+	PurposeEmailVerification VerificationPurpose = "EMAIL_VERIFICATION"
+	PurposeEmailChange       VerificationPurpose = "EMAIL_CHANGE"
+	PurposeEmailRevert       VerificationPurpose = "EMAIL_REVERT" // Sent to the old address after a change, to undo it
+	PurposeSSOLogin          VerificationPurpose = "SSO_LOGIN"    // Hands a SAML sign-in from the API back to the client
+	PurposeAccountRecovery   VerificationPurpose = "ACCOUNT_RECOVERY"
+	PurposeRecoveryCancel    VerificationPurpose = "RECOVERY_CANCEL" // Lets the owner stop a recovery they did not start
+	PurposeAccountDeletion   VerificationPurpose = "ACCOUNT_DELETION"
+	PurposeDeletionCancel    VerificationPurpose = "DELETION_CANCEL" // Lets the owner keep the account during the grace period
+)
+
+// VerificationToken is a single-use secret emailed to a user, either as a link token or a short code. Only
+// its hash is stored, and it only proves the purpose it was issued for.
+type VerificationToken struct {
+	ID         uuid.UUID           `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID     uuid.UUID           `gorm:"type:uuid;not null;index:idx_verification_tokens_user_purpose" json:"user_id"`
+	User       User                `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Purpose    VerificationPurpose `gorm:"not null;index:idx_verification_tokens_user_purpose" json:"purpose"`
+	TokenHash  string              `gorm:"not null;index" json:"-"`
+	Target     string              `json:"-"` // The address being confirmed, for purposes that change the email
+	Attempts   int                 `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt  time.Time           `gorm:"not null" json:"expires_at"`
+	ConsumedAt *time.Time          `json:"consumed_at,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+func (t *VerificationToken) IsActive() bool {
+	return t.ConsumedAt == nil && t.ExpiresAt.After(time.Now())
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// WebAuthnCredential is a passkey or security key registered by a user. It signs the user in on its own and
+// also works as a second factor alongside TOTP.
+type WebAuthnCredential struct {
+	ID              uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID          uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	User            User           `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Name            string         `gorm:"not null" json:"name"`
+	CredentialID    []byte         `gorm:"not null;uniqueIndex" json:"-"`
+	PublicKey       []byte         `gorm:"not null" json:"-"`
+	AttestationType string         `json:"-"`
+	AAGUID          []byte         `json:"-"`
+	SignCount       int64          `gorm:"not null;default:0" json:"-"`
+	Flags           uint8          `gorm:"not null;default:0" json:"-"` // Authenticator data flags from the last ceremony
+	Transports      pq.StringArray `gorm:"type:text[]" json:"transports"`
+	Synced          bool           `gorm:"not null;default:false" json:"synced"` // Backed up to a passkey provider
+	LastUsedAt      *time.Time     `json:"last_used_at,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+type WebAuthnCeremonyType string
+
+const (
+	WebAuthnCeremonyRegistration WebAuthnCeremonyType = "REGISTRATION"
+	WebAuthnCeremonyLogin        WebAuthnCeremonyType = "LOGIN"
+	WebAuthnCeremonySecondFactor WebAuthnCeremonyType = "SECOND_FACTOR"
+)
+
+// WebAuthnCeremony holds the challenge between the begin and finish steps of a WebAuthn ceremony. It is
+// deleted when the ceremony finishes so a signed response cannot be replayed.
+type WebAuthnCeremony struct {
+	ID          uuid.UUID            `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	UserID      *uuid.UUID           `gorm:"type:uuid;index" json:"user_id,omitempty"` // Nil for passkey sign-in, where the user is not known yet
+	User        *User                `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Type        WebAuthnCeremonyType `gorm:"not null" json:"type"`
+	SessionData webauthn.SessionData `gorm:"type:jsonb;serializer:json;not null" json:"-"`
+	ExpiresAt   time.Time            `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"foglio/v2/src/handlers"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AdminRoutes(router *gin.RouterGroup, hub *lib.Hub) *gin.RouterGroup {
+	handler := handlers.NewRoleHandler()
+	users := handlers.NewUserAdminHandler()
+	verifications := handlers.NewIdentityVerificationHandler(hub)
+	audit := handlers.NewAuditHandler()
+	recoveries := handlers.NewAccountRecoveryHandler()
+
+	admin := router.Group("/admin")
+
+	access := admin.Group("", middlewares.RequirePermission(models.PermissionRolesManage))
+	access.GET("/permissions", handler.GetPermissions())
+
+	roles := access.Group("/roles")
+	roles.GET("", handler.GetRoles())
+	roles.POST("", handler.CreateRole())
+	roles.PUT("/:id", handler.UpdateRole())
+	roles.DELETE("/:id", handler.DeleteRole())
+
+	userRoles := access.Group("/users/:id/roles")
+	userRoles.GET("", handler.GetUserRoles())
+	userRoles.POST("", handler.AssignRole())
+	userRoles.DELETE("/:roleId", handler.RevokeRole())
+
+	moderation := admin.Group("/users", middlewares.RequirePermission(models.PermissionUsersModerate))
+	moderation.GET("/verifications", verifications.GetVerificationQueue())
+	moderation.PUT("/:id/suspend", users.SuspendUser())
+	moderation.DELETE("/:id/suspend", users.UnsuspendUser())
+	moderation.POST("/:id/restore", users.RestoreUser())
+	moderation.PUT("/:id/verify", users.VerifyUser())
+	moderation.GET("/:id/verification/document", verifications.GetVerificationDocument())
+	moderation.PUT("/:id/verification/approve", verifications.ApproveVerification())
+	moderation.PUT("/:id/verification/reject", verifications.RejectVerification())
+	moderation.POST("/:id/merge", users.MergeUsers())
+
+	accountRecoveries := admin.Group("/account-recoveries", middlewares.RequirePermission(models.PermissionUsersModerate))
+	accountRecoveries.GET("", recoveries.GetRecoveryQueue())
+	accountRecoveries.PUT("/:id/approve", recoveries.ApproveRecovery())
+	accountRecoveries.PUT("/:id/reject", recoveries.RejectRecovery())
+
+	impersonation := admin.Group("", middlewares.RequirePermission(models.PermissionUsersImpersonate))
+	impersonation.POST("/users/:id/impersonate", users.Impersonate())
+	impersonation.GET("/impersonations", users.GetImpersonationSessions())
+	impersonation.DELETE("/impersonations/:id", users.EndImpersonation())
+
+	auditLogs := admin.Group("/audit-logs", middlewares.RequirePermission(models.PermissionAuditRead))
+	auditLogs.GET("", audit.GetAuditLogs())
+	auditLogs.GET("/export", audit.ExportAuditLogs())
+
+	return admin
+}
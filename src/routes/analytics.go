@@ -2,6 +2,8 @@ package routes
 
 import (
 	"foglio/v2/src/handlers"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,7 +23,7 @@ func AnalyticsRoutes(router *gin.RouterGroup) *gin.RouterGroup {
 	}
 
 	// ==================== ADMIN ANALYTICS (admin only) ====================
-	admin := analytics.Group("/admin")
+	admin := analytics.Group("/admin", middlewares.RequirePermission(models.PermissionAnalyticsRead))
 	{
 		admin.GET("/dashboard", handler.GetAdminDashboard())
 		admin.GET("/overview", handler.GetPlatformOverview())
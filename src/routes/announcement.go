@@ -3,6 +3,8 @@ package routes
 import (
 	"foglio/v2/src/handlers"
 	"foglio/v2/src/lib"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,7 +20,7 @@ func AnnouncementRoutes(router *gin.RouterGroup, hub *lib.Hub) *gin.RouterGroup
 	announcements.PUT("/:id/dismiss", handler.DismissAnnouncement())
 
 	// Admins
-	admin := router.Group("/admin/announcements")
+	admin := router.Group("/admin/announcements", middlewares.RequirePermission(models.PermissionAnnouncementsWrite))
 	admin.GET("", handler.GetAnnouncementsAdmin())
 	admin.GET("/:id", handler.GetAnnouncementAdmin())
 	admin.POST("", handler.CreateAnnouncement())
@@ -10,18 +10,51 @@ func AuthRoutes(router *gin.RouterGroup) *gin.RouterGroup {
 	auth := router.Group("/auth")
 	handler := handlers.NewAuthHandler()
 	twoFactorHandler := handlers.NewTwoFactorHandler()
+	sessionHandler := handlers.NewSessionHandler()
+	webAuthnHandler := handlers.NewWebAuthnHandler()
+	identityHandler := handlers.NewIdentityHandler()
+	emailChangeHandler := handlers.NewEmailChangeHandler()
+	recoveryHandler := handlers.NewAccountRecoveryHandler()
+	deletionHandler := handlers.NewAccountDeletionHandler()
 
 	auth.POST("/signup", handler.CreateUser())
 	auth.POST("/signin", handler.Signin())
+	auth.POST("/refresh", sessionHandler.RefreshToken())
+	auth.POST("/logout", sessionHandler.Logout())
+	auth.POST("/logout-all", sessionHandler.LogoutEverywhere())
 	auth.POST("/request-verification", handler.RequestVerification())
 	auth.POST("/verification", handler.Verification())
 	auth.POST("/update-password", handler.ChangePassword())
 	auth.POST("/forgot-password", handler.ForgotPassword())
 	auth.POST("/reset-password", handler.ResetPassword())
+	auth.POST("/email", emailChangeHandler.RequestEmailChange())
+	auth.POST("/email/confirm", emailChangeHandler.ConfirmEmailChange())
+	auth.POST("/email/revert", emailChangeHandler.RevertEmailChange())
+	auth.POST("/deletion/cancel", deletionHandler.CancelDeletionWithToken())
+	auth.GET("/sessions", sessionHandler.GetSessions())
+	auth.DELETE("/sessions/:id", sessionHandler.RevokeSession())
+	auth.GET("/passkeys", webAuthnHandler.GetPasskeys())
+	auth.POST("/passkeys/register/begin", webAuthnHandler.BeginPasskeyRegistration())
+	auth.POST("/passkeys/register/finish", webAuthnHandler.FinishPasskeyRegistration())
+	auth.POST("/passkeys/login/begin", webAuthnHandler.BeginPasskeyLogin())
+	auth.POST("/passkeys/login/finish", webAuthnHandler.PasskeyLogin())
+	auth.PATCH("/passkeys/:id", webAuthnHandler.RenamePasskey())
+	auth.DELETE("/passkeys/:id", webAuthnHandler.DeletePasskey())
+	auth.GET("/providers", identityHandler.GetProviders())
+	auth.GET("/identities", identityHandler.GetIdentities())
+	auth.POST("/identities/:provider", identityHandler.BeginLink())
+	auth.POST("/identities/:provider/callback", identityHandler.FinishLink())
+	auth.DELETE("/identities/:provider", identityHandler.Unlink())
 	auth.GET("/:provider", handler.GetOAuthURL())
 	auth.GET("/:provider/callback", handler.HandleOAuthCallback())
 
 	auth.POST("/2fa/verify", twoFactorHandler.Verify2FALogin())
+	auth.POST("/2fa/passkey/begin", twoFactorHandler.Begin2FAPasskey())
+	auth.POST("/2fa/passkey/verify", twoFactorHandler.Verify2FAPasskey())
+	auth.POST("/2fa/recovery", recoveryHandler.StartRecovery())
+	auth.POST("/2fa/recovery/verify", recoveryHandler.VerifyRecovery())
+	auth.POST("/2fa/recovery/complete", recoveryHandler.CompleteRecovery())
+	auth.POST("/2fa/recovery/cancel", recoveryHandler.CancelRecovery())
 	twoFactor := auth.Group("/2fa")
 	{
 		twoFactor.GET("/status", twoFactorHandler.GetStatus())
@@ -29,6 +62,9 @@ func AuthRoutes(router *gin.RouterGroup) *gin.RouterGroup {
 		twoFactor.POST("/verify-setup", twoFactorHandler.VerifySetup2FA())
 		twoFactor.POST("/disable", twoFactorHandler.Disable2FA())
 		twoFactor.POST("/backup-codes", twoFactorHandler.RegenerateBackupCodes())
+		twoFactor.GET("/trusted-devices", twoFactorHandler.GetTrustedDevices())
+		twoFactor.DELETE("/trusted-devices", twoFactorHandler.RemoveTrustedDevices())
+		twoFactor.DELETE("/trusted-devices/:id", twoFactorHandler.RemoveTrustedDevice())
 	}
 
 	return auth
@@ -3,6 +3,8 @@ package routes
 import (
 	"foglio/v2/src/handlers"
 	"foglio/v2/src/lib"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,12 +17,45 @@ func ChatRoutes(router *gin.RouterGroup, hub *lib.Hub) *gin.RouterGroup {
 	chat.GET("/conversations", handler.GetConversations())
 	chat.GET("/conversations/:id", handler.GetConversation())
 	chat.GET("/conversations/user/:userId", handler.GetOrCreateConversation())
+	chat.POST("/conversations/group", handler.CreateGroupConversation())
+	chat.PUT("/conversations/:id", handler.RenameConversation())
 	chat.DELETE("/conversations/:id", handler.DeleteConversation())
+	chat.POST("/conversations/:id/participants", handler.AddParticipants())
+	chat.DELETE("/conversations/:id/participants/:userId", handler.RemoveParticipant())
 	chat.POST("/messages", handler.SendMessage())
+	chat.PUT("/messages/:messageId", handler.EditMessage())
+	chat.DELETE("/messages/:messageId", handler.DeleteMessage())
+	chat.GET("/messages/:messageId/edits", handler.GetMessageEdits())
+	chat.POST("/messages/:messageId/reactions", handler.AddReaction())
+	chat.DELETE("/messages/:messageId/reactions/:emoji", handler.RemoveReaction())
 	chat.GET("/conversations/:id/messages", handler.GetMessages())
 	chat.PUT("/conversations/:id/read", handler.MarkAsRead())
 
+	chat.POST("/attachments", handler.UploadAttachment())
+	chat.GET("/attachments/:attachmentId", handler.GetAttachment(false))
+	chat.GET("/attachments/:attachmentId/thumbnail", handler.GetAttachment(true))
+	chat.GET("/media/:attachmentId", handler.GetSignedAttachment(false))
+	chat.GET("/media/:attachmentId/thumbnail", handler.GetSignedAttachment(true))
+
 	chat.GET("/unread", handler.GetUnreadCount())
+	chat.GET("/search", handler.SearchMessages())
+
+	chat.GET("/presence/:userId", handler.GetPresence())
+	chat.PUT("/presence/visibility", handler.UpdatePresenceVisibility())
+
+	chat.GET("/blocks", handler.GetBlockedUsers())
+	chat.POST("/blocks", handler.BlockUser())
+	chat.DELETE("/blocks/:userId", handler.UnblockUser())
+	chat.PUT("/conversations/:id/mute", handler.MuteConversation())
+	chat.DELETE("/conversations/:id/mute", handler.UnmuteConversation())
+	chat.POST("/reports", handler.ReportUser())
+
+	// Admins
+	admin := router.Group("/admin/chat", middlewares.RequirePermission(models.PermissionUsersModerate))
+	admin.GET("/reports", handler.GetChatReports())
+	admin.GET("/reports/:id", handler.GetChatReport())
+	admin.PUT("/reports/:id/resolve", handler.ResolveChatReport())
+	admin.DELETE("/bans/:userId", handler.LiftChatBan())
 
 	return chat
 }
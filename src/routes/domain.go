@@ -20,3 +20,10 @@ func DomainRoutes(router *gin.RouterGroup) *gin.RouterGroup {
 
 	return domain
 }
+
+// ACMEChallengeRoutes serves HTTP-01 challenge responses at the path the CA expects on every custom domain.
+func ACMEChallengeRoutes(app *gin.Engine) {
+	handler := handlers.NewDomainHandler()
+
+	app.GET("/.well-known/acme-challenge/:token", handler.ServeACMEChallenge())
+}
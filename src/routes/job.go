@@ -2,13 +2,14 @@ package routes
 
 import (
 	"foglio/v2/src/handlers"
+	"foglio/v2/src/lib"
 
 	"github.com/gin-gonic/gin"
 )
 
-func JobRoutes(router *gin.RouterGroup) *gin.RouterGroup {
+func JobRoutes(router *gin.RouterGroup, hub *lib.Hub) *gin.RouterGroup {
 	jobs := router.Group("/jobs")
-	handler := handlers.NewJobHandler()
+	handler := handlers.NewJobHandler(hub)
 
 	jobs.POST("", handler.CreateJob())
 	jobs.GET("", handler.GetJobs())
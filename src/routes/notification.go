@@ -2,13 +2,14 @@ package routes
 
 import (
 	"foglio/v2/src/handlers"
+	"foglio/v2/src/lib"
 
 	"github.com/gin-gonic/gin"
 )
 
-func NotificationRoutes(router *gin.RouterGroup) *gin.RouterGroup {
+func NotificationRoutes(router *gin.RouterGroup, hub *lib.Hub) *gin.RouterGroup {
 	notifications := router.Group("/notifications")
-	handler := handlers.NewNotificationHandler()
+	handler := handlers.NewNotificationHandler(hub)
 
 	notifications.GET("", handler.GetNotifications())
 	notifications.GET("/:id", handler.GetNotification())
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"foglio/v2/src/handlers"
+	"foglio/v2/src/lib"
+
+	"github.com/gin-gonic/gin"
+)
+
+func OutreachRoutes(router *gin.RouterGroup, hub *lib.Hub) *gin.RouterGroup {
+	handler := handlers.NewOutreachHandler(hub)
+
+	outreach := router.Group("/outreach")
+
+	outreach.GET("/templates", handler.GetTemplates())
+	outreach.POST("/templates", handler.CreateTemplate())
+	outreach.PUT("/templates/:id", handler.UpdateTemplate())
+	outreach.DELETE("/templates/:id", handler.DeleteTemplate())
+	outreach.POST("/templates/:id/preview", handler.PreviewTemplate())
+	outreach.POST("/jobs/:id/send", handler.BulkSend())
+	outreach.GET("/quota", handler.GetQuota())
+
+	return outreach
+}
@@ -24,7 +24,16 @@ func PortfolioRoutes(router *gin.RouterGroup) *gin.RouterGroup {
 	portfolio.POST("/sections/reorder", handler.ReorderSections())
 
 	portfolios := router.Group("/portfolios")
+	portfolios.GET("/templates", handler.GetTemplates())
 	portfolios.GET("/:slug", handler.GetPortfolioBySlug())
 
 	return portfolio
 }
+
+// SiteRoutes serves published portfolios as HTML pages, outside the versioned API.
+func SiteRoutes(app *gin.Engine) {
+	handler := handlers.NewPortfolioHandler()
+
+	app.GET("/p/:slug", handler.RenderPortfolio())
+	app.HEAD("/p/:slug", handler.RenderPortfolio())
+}
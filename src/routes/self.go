@@ -2,17 +2,32 @@ package routes
 
 import (
 	"foglio/v2/src/handlers"
+	"foglio/v2/src/lib"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SelfRoutes(router *gin.RouterGroup) *gin.RouterGroup {
+func SelfRoutes(router *gin.RouterGroup, hub *lib.Hub) *gin.RouterGroup {
 	self := router.Group("/")
 	user := handlers.NewUserHandler()
-	job := handlers.NewJobHandler()
+	job := handlers.NewJobHandler(hub)
+	role := handlers.NewRoleHandler()
+	verification := handlers.NewIdentityVerificationHandler(hub)
+	export := handlers.NewDataExportHandler()
+	deletion := handlers.NewAccountDeletionHandler()
 
 	self.GET("/me", user.GetMe())
 	self.GET("/me/jobs", job.GetJobsByUser())
+	self.GET("/me/permissions", role.GetMyAccess())
+	self.GET("/me/verification", verification.GetMyVerification())
+	self.POST("/me/verification", verification.SubmitVerification())
+	self.GET("/me/exports", export.GetExports())
+	self.POST("/me/exports", export.RequestExport())
+	self.GET("/me/exports/:id/download", export.DownloadExport())
+	self.GET("/me/deletion", deletion.GetDeletion())
+	self.POST("/me/deletion", deletion.RequestDeletion())
+	self.POST("/me/deletion/confirm", deletion.ConfirmDeletion())
+	self.DELETE("/me/deletion", deletion.CancelDeletion())
 
 	return self
 }
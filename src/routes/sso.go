@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"foglio/v2/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SSORoutes(router *gin.RouterGroup) *gin.RouterGroup {
+	sso := router.Group("/sso")
+	handler := handlers.NewSSOHandler()
+
+	sso.GET("/config", handler.GetConfig())
+	sso.PUT("/config", handler.UpdateConfig())
+	sso.DELETE("/config", handler.DeleteConfig())
+	sso.POST("/domains", handler.AddDomain())
+	sso.POST("/domains/:id/verify", handler.VerifyDomain())
+	sso.DELETE("/domains/:id", handler.DeleteDomain())
+	sso.POST("/login", handler.BeginLogin())
+	sso.POST("/exchange", handler.Exchange())
+	sso.GET("/:companyId/metadata", handler.Metadata())
+	sso.POST("/:companyId/acs", handler.AssertionConsumer())
+
+	return sso
+}
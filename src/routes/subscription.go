@@ -2,6 +2,8 @@ package routes
 
 import (
 	"foglio/v2/src/handlers"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,11 +12,13 @@ func SubscriptionRoutes(router *gin.RouterGroup) *gin.RouterGroup {
 	handler := handlers.NewSubscriptionHandler()
 
 	subscriptions := router.Group("/subscriptions")
-	subscriptions.POST("", handler.CreateSubscription())
 	subscriptions.GET("", handler.GetSubscriptions())
 	subscriptions.GET("/:id", handler.GetSubscription())
-	subscriptions.PUT("/:id", handler.UpdateSubscription())
-	subscriptions.DELETE("/:id", handler.DeleteSubscription())
+
+	manage := middlewares.RequirePermission(models.PermissionSubscriptionsManage)
+	subscriptions.POST("", manage, handler.CreateSubscription())
+	subscriptions.PUT("/:id", manage, handler.UpdateSubscription())
+	subscriptions.DELETE("/:id", manage, handler.DeleteSubscription())
 
 	userSubs := router.Group("/user/subscriptions")
 	userSubs.GET("", handler.GetUserSubscriptions())
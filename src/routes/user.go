@@ -2,6 +2,8 @@ package routes
 
 import (
 	"foglio/v2/src/handlers"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,9 +14,11 @@ func UserRoutes(router *gin.RouterGroup) *gin.RouterGroup {
 
 	users.GET("", handler.GetUsers())
 	users.GET("/:id", handler.GetUser())
-	users.PUT("/:id", handler.UpdateUser())
-	users.PUT("/:id/avatar", handler.UpdateAvatar())
-	users.DELETE("/:id", handler.DeleteUser())
+
+	selfOrModerator := middlewares.RequireSelfOrPermission("id", models.PermissionUsersModerate)
+	users.PUT("/:id", selfOrModerator, handler.UpdateUser())
+	users.PUT("/:id/avatar", selfOrModerator, handler.UpdateAvatar())
+	users.DELETE("/:id", selfOrModerator, handler.DeleteUser())
 
 	user := router.Group("/user")
 	user.GET("/profile", handler.GetMe())
@@ -0,0 +1,543 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"foglio/v2/src/config"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDeletionPending  = errors.New("your account is already scheduled for deletion")
+	ErrDeletionNotFound = errors.New("no account deletion is scheduled")
+)
+
+// AccountDeletionGracePeriod is how long a confirmed deletion waits before the account is erased. The account
+// keeps working until then and the owner can cancel at any time.
+const AccountDeletionGracePeriod = 14 * 24 * time.Hour
+
+// erasedRecords are deleted outright when an account is erased. Conditions refer to the user as @user;
+// child rows such as project highlights go with their parent through their foreign keys. Jobs cannot
+// outlive the recruiter who posted them, so they go too, along with everything other users attached to them.
+var erasedRecords = []struct {
+	model interface{}
+	where string
+}{
+	{model: &models.Project{}, where: "user_id = @user"},
+	{model: &models.Experience{}, where: "user_id = @user"},
+	{model: &models.Education{}, where: "user_id = @user"},
+	{model: &models.Certification{}, where: "user_id = @user"},
+	{model: &models.Language{}, where: "user_id = @user"},
+	{model: &models.PortfolioView{}, where: "portfolio_id IN (SELECT id FROM portfolios WHERE user_id = @user)"},
+	{model: &models.PortfolioSection{}, where: "portfolio_id IN (SELECT id FROM portfolios WHERE user_id = @user)"},
+	{model: &models.Portfolio{}, where: "user_id = @user"},
+	{model: &models.ProfileView{}, where: "profile_user_id = @user"},
+	{model: &models.DailyStats{}, where: "entity_id = @user"},
+	{model: &models.OutreachMessage{}, where: "sender_id = @user OR recipient_id = @user OR job_id IN (SELECT id FROM jobs WHERE created_by = @user)"},
+	{model: &models.JobView{}, where: "job_id IN (SELECT id FROM jobs WHERE created_by = @user)"},
+	{model: &models.JobApplication{}, where: "applicant_id = @user OR job_id IN (SELECT id FROM jobs WHERE created_by = @user)"},
+	{model: &models.Comment{}, where: "created_by = @user OR job_id IN (SELECT id FROM jobs WHERE created_by = @user)"},
+	{model: &models.Reaction{}, where: "created_by = @user OR job_id IN (SELECT id FROM jobs WHERE created_by = @user)"},
+	{model: &models.Job{}, where: "created_by = @user"},
+	{model: &models.Review{}, where: "user_id = @user"},
+	{model: &models.Notification{}, where: "owner_id = @user"},
+	{model: &models.NotificationSettings{}, where: "user_id = @user"},
+	{model: &models.UserAnnouncementStatus{}, where: "user_id = @user"},
+	{model: &models.MessageEdit{}, where: "message_id IN (SELECT id FROM messages WHERE sender_id = @user)"},
+	{model: &models.MessageReaction{}, where: "user_id = @user OR message_id IN (SELECT id FROM messages WHERE sender_id = @user)"},
+	{model: &models.MessageDeletion{}, where: "user_id = @user"},
+	{model: &models.ChatAttachment{}, where: "uploader_id = @user"},
+	{model: &models.UserBlock{}, where: "blocker_id = @user OR blocked_id = @user"},
+	{model: &models.MessageTemplate{}, where: "owner_id = @user"},
+	{model: &models.UserRole{}, where: "user_id = @user"},
+	{model: &models.IdentityVerification{}, where: "user_id = @user"},
+	{model: &models.UserIdentity{}, where: "user_id = @user"},
+	{model: &models.OAuthState{}, where: "user_id = @user"},
+	{model: &models.WebAuthnCredential{}, where: "user_id = @user"},
+	{model: &models.WebAuthnCeremony{}, where: "user_id = @user"},
+	{model: &models.Session{}, where: "user_id = @user"},
+	{model: &models.TrustedDevice{}, where: "user_id = @user"},
+	{model: &models.VerificationToken{}, where: "user_id = @user"},
+	{model: &models.AccountRecovery{}, where: "user_id = @user"},
+	{model: &models.DataExport{}, where: "user_id = @user"},
+	{model: &models.DomainCertificate{}, where: "user_id = @user"},
+	{model: &models.ImpersonationSession{}, where: "user_id = @user"},
+}
+
+// anonymizedRecords keep their rows, which other people's conversations, moderation and the platform
+// statistics rely on, with whatever ties them to the user cleared. Messages, and the copies of them kept as
+// evidence in chat reports, are blanked the same way as a message deleted for everyone.
+//
+// Audit logs are append-only and are kept as they are. So are records that only name the user as the staff
+// member who acted, such as impersonations they ran or reports they resolved, and conversation memberships,
+// which now point at the anonymous placeholder.
+var anonymizedRecords = []struct {
+	model   interface{}
+	where   string
+	updates map[string]interface{}
+}{
+	{model: &models.PageView{}, where: "user_id = @user", updates: map[string]interface{}{"user_id": nil, "ip_address": "", "user_agent": "", "session_id": ""}},
+	{model: &models.JobView{}, where: "user_id = @user", updates: map[string]interface{}{"user_id": nil, "ip_address": "", "user_agent": "", "session_id": ""}},
+	{model: &models.ProfileView{}, where: "viewer_user_id = @user", updates: map[string]interface{}{"viewer_user_id": nil, "ip_address": "", "user_agent": "", "session_id": ""}},
+	{model: &models.PortfolioView{}, where: "viewer_user_id = @user", updates: map[string]interface{}{"viewer_user_id": nil, "ip_address": "", "user_agent": "", "session_id": ""}},
+	{model: &models.AnalyticsEvent{}, where: "user_id = @user", updates: map[string]interface{}{"user_id": nil, "session_id": ""}},
+	{model: &models.Message{}, where: "sender_id = @user AND deleted_for_everyone_at IS NULL", updates: map[string]interface{}{"content": "", "media": nil, "deleted_for_everyone_at": gorm.Expr("NOW()")}},
+	{model: &models.ChatReportMessage{}, where: "sender_id = @user", updates: map[string]interface{}{"content": "", "media": nil}},
+	{model: &models.ChatReport{}, where: "reporter_id = @user", updates: map[string]interface{}{"details": nil}},
+	{model: &models.AccountMerge{}, where: "target_user_id = @user", updates: map[string]interface{}{"source_email": "", "source_username": "", "source_provider_id": ""}},
+}
+
+// AccountDeletionService deletes accounts at their owner's request. The request is confirmed with the
+// password and a code sent to the email, then waits out a grace period before the data is erased.
+type AccountDeletionService struct {
+	database *gorm.DB
+	tokens   *VerificationTokenService
+	paystack *PaystackService
+}
+
+func NewAccountDeletionService(database *gorm.DB) *AccountDeletionService {
+	return &AccountDeletionService{
+		database: database,
+		tokens:   NewVerificationTokenService(database),
+		paystack: NewPaystackService(database),
+	}
+}
+
+// RequestDeletion emails a code to confirm the deletion. Accounts with a password have to give it.
+func (s *AccountDeletionService) RequestDeletion(userID string, payload dto.RequestDeletionRequest) error {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.Password != "" && lib.ComparePassword(payload.Password, user.Password) != nil {
+		return ErrIncorrectPassword
+	}
+	if _, err := s.GetDeletion(userID); err == nil {
+		return ErrDeletionPending
+	} else if !errors.Is(err, ErrDeletionNotFound) {
+		return err
+	}
+
+	code, err := s.tokens.Issue(user.ID, models.PurposeAccountDeletion, "")
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+			To:       []string{user.Email},
+			Subject:  "Confirm Account Deletion",
+			Template: "account-deletion-code",
+			Data: map[string]interface{}{
+				"Name":      user.Name,
+				"Email":     user.Email,
+				"Otp":       code,
+				"GraceDays": int(AccountDeletionGracePeriod.Hours() / 24),
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send account deletion code: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// ConfirmDeletion checks the emailed code and schedules the deletion for the end of the grace period. The
+// account is emailed a link to cancel it.
+func (s *AccountDeletionService) ConfirmDeletion(userID string, payload dto.ConfirmDeletionRequest) (*models.AccountDeletion, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.tokens.ConsumeCode(user.ID, models.PurposeAccountDeletion, payload.Code); err != nil {
+		return nil, err
+	}
+
+	deletion := models.AccountDeletion{
+		UserID:       user.ID,
+		Status:       models.DeletionScheduled,
+		Reason:       payload.Reason,
+		ScheduledFor: time.Now().Add(AccountDeletionGracePeriod),
+	}
+	var cancelToken string
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		if _, err := NewAccountDeletionService(tx).GetDeletion(userID); err == nil {
+			return ErrDeletionPending
+		} else if !errors.Is(err, ErrDeletionNotFound) {
+			return err
+		}
+		if err := tx.Create(&deletion).Error; err != nil {
+			return err
+		}
+
+		cancelToken, err = NewVerificationTokenService(tx).Issue(user.ID, models.PurposeDeletionCancel, deletion.ID.String())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := lib.GenerateUrl(config.AppConfig.ClientUrl+"/cancel-deletion", cancelToken)
+	go func() {
+		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+			To:       []string{user.Email},
+			Subject:  "Account Deletion Scheduled",
+			Template: "account-deletion-scheduled",
+			Data: map[string]interface{}{
+				"Name":         user.Name,
+				"Email":        user.Email,
+				"ScheduledFor": deletion.ScheduledFor.UTC().Format("Jan 2, 2006 15:04 MST"),
+				"Url":          url,
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send account deletion notice: %v", err)
+		}
+	}()
+
+	return &deletion, nil
+}
+
+// GetDeletion returns the user's scheduled deletion.
+func (s *AccountDeletionService) GetDeletion(userID string) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	if err := s.database.
+		Where("user_id = ? AND status = ?", userID, models.DeletionScheduled).
+		Order("created_at DESC").
+		First(&deletion).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeletionNotFound
+		}
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// CancelDeletion keeps the account of a signed-in user.
+func (s *AccountDeletionService) CancelDeletion(userID string) (*models.AccountDeletion, error) {
+	deletion, err := s.GetDeletion(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.cancel(deletion)
+}
+
+// CancelDeletionWithToken keeps the account the emailed cancel link was issued for.
+func (s *AccountDeletionService) CancelDeletionWithToken(token string) (*models.AccountDeletion, error) {
+	record, err := s.tokens.ConsumeToken(models.PurposeDeletionCancel, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var deletion models.AccountDeletion
+	if err := s.database.First(&deletion, "id = ? AND user_id = ?", record.Target, record.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeletionNotFound
+		}
+		return nil, err
+	}
+	if deletion.Status != models.DeletionScheduled {
+		return nil, ErrDeletionNotFound
+	}
+	return s.cancel(&deletion)
+}
+
+// ProcessDueDeletions erases the accounts whose grace period is over. A failed erasure is retried on the
+// next run.
+func (s *AccountDeletionService) ProcessDueDeletions() error {
+	var deletions []models.AccountDeletion
+	if err := s.database.
+		Where("status = ? AND scheduled_for <= ?", models.DeletionScheduled, time.Now()).
+		Find(&deletions).Error; err != nil {
+		return err
+	}
+
+	for _, deletion := range deletions {
+		if err := s.Erase(deletion.UserID.String()); err != nil && !errors.Is(err, ErrUserNotFound) {
+			log.Printf("Failed to erase account %s: %v", deletion.UserID, err)
+			continue
+		}
+
+		if err := s.database.Model(&deletion).Updates(map[string]interface{}{
+			"status":       models.DeletionCompleted,
+			"completed_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		metadata := map[string]interface{}{"deletion_id": deletion.ID.String(), "requested_by": "owner"}
+		if deletion.RequestedByID != nil {
+			metadata["requested_by"] = "staff"
+			metadata["requested_by_id"] = deletion.RequestedByID.String()
+		}
+		NewAuditService(s.database).Record(AuditEntry{
+			Action:     models.AuditUserDeleted,
+			EntityType: "user",
+			EntityID:   deletion.UserID.String(),
+			Metadata:   metadata,
+		})
+	}
+	return nil
+}
+
+// Erase cancels the user's subscription, deletes their records and uploads, and anonymizes what other
+// records depend on. The user row is kept as an anonymous, soft-deleted placeholder so that conversations
+// and audit logs still resolve, and the email and username become free to use again.
+func (s *AccountDeletionService) Erase(userID string) error {
+	// Accounts deleted by staff are already soft-deleted while they wait out the grace period
+	user, err := lookupUser(s.database.Unscoped(), userID)
+	if err != nil {
+		return err
+	}
+
+	// Billing has to stop before the data goes, since a failure here should leave everything for a retry
+	if err := s.cancelSubscriptions(user.ID); err != nil {
+		return fmt.Errorf("failed to cancel subscription: %w", err)
+	}
+
+	publicFiles, privateFiles, err := s.collectFiles(user)
+	if err != nil {
+		return err
+	}
+
+	email, name, domain := user.Email, user.Name, user.Domain
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		for _, record := range erasedRecords {
+			if err := tx.Unscoped().Where(record.where, sql.Named("user", user.ID.String())).Delete(record.model).Error; err != nil {
+				return err
+			}
+		}
+		for _, record := range anonymizedRecords {
+			if err := tx.Unscoped().Model(record.model).Where(record.where, sql.Named("user", user.ID.String())).Updates(record.updates).Error; err != nil {
+				return err
+			}
+		}
+
+		placeholder := "deleted-" + user.ID.String()
+		if err := tx.Unscoped().Model(user).Updates(map[string]interface{}{
+			"name":                     "Deleted User",
+			"username":                 placeholder,
+			"email":                    placeholder + "@deleted.invalid",
+			"password":                 "",
+			"provider_id":              "",
+			"role":                     nil,
+			"headline":                 nil,
+			"phone":                    nil,
+			"location":                 nil,
+			"image":                    nil,
+			"domain":                   gorm.Expr("NULL"),
+			"summary":                  nil,
+			"social_media":             gorm.Expr("NULL"),
+			"company_id":               nil,
+			"skills":                   gorm.Expr("NULL"),
+			"is_two_factor_enabled":    false,
+			"two_factor_secret":        nil,
+			"two_factor_backup_codes":  gorm.Expr("NULL"),
+			"verification_number":      nil,
+			"verification_type":        nil,
+			"verification_document":    nil,
+			"verification_status":      nil,
+			"verification_note":        nil,
+			"identity_verified":        false,
+			"verified":                 false,
+			"presence_status":          models.PresenceOffline,
+			"last_seen_at":             nil,
+			"hide_presence":            true,
+			"is_premium":               false,
+			"is_recruiter":             false,
+			"two_factor_locked_until":  nil,
+			"verification_reviewed_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(user).Error
+	})
+	if err != nil {
+		return err
+	}
+	invalidateDomain(domain)
+
+	// The records are gone either way; a file that fails to delete is only logged
+	for _, url := range publicFiles {
+		if err := lib.DeleteUploaded(url); err != nil {
+			log.Printf("Failed to delete upload %s of erased user %s: %v", url, user.ID, err)
+		}
+	}
+	for _, file := range privateFiles {
+		if err := lib.DeletePrivate(file); err != nil {
+			log.Printf("Failed to delete file %s of erased user %s: %v", file.PublicID, user.ID, err)
+		}
+	}
+
+	go func() {
+		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+			To:       []string{email},
+			Subject:  "Your Account Was Deleted",
+			Template: "account-deleted",
+			Data: map[string]interface{}{
+				"Name":  name,
+				"Email": email,
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send account deleted email: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *AccountDeletionService) cancel(deletion *models.AccountDeletion) (*models.AccountDeletion, error) {
+	now := time.Now()
+	result := s.database.Model(deletion).
+		Where("status = ?", models.DeletionScheduled).
+		Updates(map[string]interface{}{"status": models.DeletionCancelled, "cancelled_at": now})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrDeletionNotFound
+	}
+	if err := s.tokens.Revoke(deletion.UserID, models.PurposeDeletionCancel); err != nil {
+		log.Printf("Failed to revoke deletion cancel links for user %s: %v", deletion.UserID, err)
+	}
+
+	deletion.Status = models.DeletionCancelled
+	deletion.CancelledAt = &now
+	return deletion, nil
+}
+
+// cancelSubscriptions stops every Paystack subscription that could still charge the user and closes the
+// local records. Invoices are kept for accounting.
+func (s *AccountDeletionService) cancelSubscriptions(userID uuid.UUID) error {
+	var subscriptions []models.UserSubscription
+	if err := s.database.Where("user_id = ? AND status <> ?", userID, "cancelled").Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if subscription.PaystackSubscriptionID != nil && *subscription.PaystackSubscriptionID != "" {
+			remote, err := s.paystack.GetSubscription(*subscription.PaystackSubscriptionID)
+			if err != nil {
+				return err
+			}
+			if remote.Status == "active" || remote.Status == "attention" || remote.Status == "non-renewing" {
+				if err := s.paystack.DisableSubscription(remote.SubscriptionCode, remote.EmailToken); err != nil {
+					return err
+				}
+			}
+		}
+
+		now := time.Now()
+		if err := s.database.Model(&subscription).Updates(map[string]interface{}{
+			"status":               "cancelled",
+			"is_active":            false,
+			"cancel_at_period_end": false,
+			"cancelled_at":         now,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectFiles gathers the user's uploads before their records are deleted: public images by URL and
+// private files such as chat attachments, identity documents and data exports.
+func (s *AccountDeletionService) collectFiles(user *models.User) ([]string, []lib.StoredFile, error) {
+	var publicFiles []string
+	addURL := func(url *string) {
+		if url != nil && *url != "" {
+			publicFiles = append(publicFiles, *url)
+		}
+	}
+	addURL(user.Image)
+
+	var projects []models.Project
+	if err := s.database.Unscoped().Where("user_id = ?", user.ID).Find(&projects).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, project := range projects {
+		addURL(project.Image)
+	}
+
+	var portfolios []models.Portfolio
+	if err := s.database.Unscoped().Where("user_id = ?", user.ID).Find(&portfolios).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, portfolio := range portfolios {
+		addURL(portfolio.CoverImage)
+		addURL(portfolio.Logo)
+	}
+
+	var privateFiles []lib.StoredFile
+	var attachments []models.ChatAttachment
+	if err := s.database.Where("uploader_id = ?", user.ID).Find(&attachments).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, attachment := range attachments {
+		privateFiles = append(privateFiles, lib.StoredFile{
+			PublicID:     attachment.StoragePublicID,
+			ResourceType: attachment.StorageResource,
+			Format:       attachment.StorageFormat,
+			Version:      attachment.StorageVersion,
+		})
+		if attachment.HasThumbnail() {
+			privateFiles = append(privateFiles, lib.StoredFile{
+				PublicID:     *attachment.ThumbnailPublicID,
+				ResourceType: "image",
+				Format:       attachment.ThumbnailFormat,
+				Version:      attachment.ThumbnailVersion,
+			})
+		}
+	}
+
+	var verifications []models.IdentityVerification
+	if err := s.database.Where("user_id = ?", user.ID).Find(&verifications).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, verification := range verifications {
+		privateFiles = append(privateFiles, lib.StoredFile{
+			PublicID:     verification.StoragePublicID,
+			ResourceType: verification.StorageResource,
+			Format:       verification.StorageFormat,
+			Version:      verification.StorageVersion,
+		})
+	}
+
+	var exports []models.DataExport
+	if err := s.database.Where("user_id = ? AND storage_public_id IS NOT NULL", user.ID).Find(&exports).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, export := range exports {
+		privateFiles = append(privateFiles, exportFile(&export))
+	}
+
+	return publicFiles, privateFiles, nil
+}
+
+func (s *AccountDeletionService) findUser(userID string) (*models.User, error) {
+	return lookupUser(s.database, userID)
+}
+
+func lookupUser(database *gorm.DB, userID string) (*models.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	var user models.User
+	if err := database.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
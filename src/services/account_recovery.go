@@ -0,0 +1,349 @@
+package services
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRecoveryNotNeeded = errors.New("account recovery is only needed for accounts with 2FA turned on")
+	ErrRecoveryPending   = errors.New("an account recovery is already in progress")
+	ErrRecoveryNotFound  = errors.New("account recovery not found")
+	ErrRecoveryNotReady  = errors.New("account recovery is still in its waiting period")
+)
+
+// AccountRecoveryDelay is how long a recovery waits before it can be completed, unless an admin approves it
+// sooner. It gives the owner time to see the emails and cancel a recovery they did not start.
+const AccountRecoveryDelay = 72 * time.Hour
+
+// AccountRecoveryService lets users who lost both their authenticator and their backup codes turn off 2FA.
+// Every step starts from a 2FA sign-in challenge, so the requester has always proven the password.
+type AccountRecoveryService struct {
+	database  *gorm.DB
+	tokens    *VerificationTokenService
+	twoFactor *TwoFactorService
+}
+
+func NewAccountRecoveryService(database *gorm.DB) *AccountRecoveryService {
+	return &AccountRecoveryService{
+		database:  database,
+		tokens:    NewVerificationTokenService(database),
+		twoFactor: NewTwoFactorService(database),
+	}
+}
+
+// Start emails a code to the account to prove the requester can read its email.
+func (s *AccountRecoveryService) Start(challengeToken string) (*models.User, error) {
+	user, err := s.challengeUser(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureNoOpenRecovery(user.ID); err != nil {
+		return nil, err
+	}
+
+	code, err := s.tokens.Issue(user.ID, models.PurposeAccountRecovery, "")
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+			To:       []string{user.Email},
+			Subject:  "Account Recovery Code",
+			Template: "account-recovery-code",
+			Data: map[string]interface{}{
+				"Name":  user.Name,
+				"Email": user.Email,
+				"Otp":   code,
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send account recovery code: %v", err)
+		}
+	}()
+
+	return user, nil
+}
+
+// Verify checks the emailed code and opens the recovery, which can be completed once the waiting period
+// is over. The account is emailed a link to cancel it.
+func (s *AccountRecoveryService) Verify(payload dto.VerifyRecoveryRequest, client dto.SessionClient) (*models.AccountRecovery, error) {
+	user, err := s.challengeUser(payload.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.tokens.ConsumeCode(user.ID, models.PurposeAccountRecovery, payload.Code); err != nil {
+		return nil, err
+	}
+
+	recovery := models.AccountRecovery{
+		UserID:      user.ID,
+		Status:      models.RecoveryPending,
+		AvailableAt: time.Now().Add(AccountRecoveryDelay),
+		IPAddress:   client.IPAddress,
+		UserAgent:   client.UserAgent,
+	}
+	var cancelToken string
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		if err := NewAccountRecoveryService(tx).ensureNoOpenRecovery(user.ID); err != nil {
+			return err
+		}
+		if err := tx.Create(&recovery).Error; err != nil {
+			return err
+		}
+
+		cancelToken, err = NewVerificationTokenService(tx).Issue(user.ID, models.PurposeRecoveryCancel, recovery.ID.String())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := lib.GenerateUrl(config.AppConfig.ClientUrl+"/cancel-recovery", cancelToken)
+	go func() {
+		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+			To:       []string{user.Email},
+			Subject:  "Account Recovery Requested",
+			Template: "account-recovery-requested",
+			Data: map[string]interface{}{
+				"Name":        user.Name,
+				"Email":       user.Email,
+				"Browser":     detectBrowser(client.UserAgent),
+				"OS":          detectOS(client.UserAgent),
+				"IPAddress":   client.IPAddress,
+				"AvailableAt": recovery.AvailableAt.UTC().Format("Jan 2, 2006 15:04 MST"),
+				"Url":         url,
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send account recovery notice: %v", err)
+		}
+	}()
+
+	return &recovery, nil
+}
+
+// Complete turns off 2FA once the recovery's waiting period is over or an admin approved it, and signs out
+// every session and trusted device. The caller signs the user in.
+func (s *AccountRecoveryService) Complete(challengeToken string) (*models.User, *models.AccountRecovery, error) {
+	user, err := s.challengeUser(challengeToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recovery, err := s.findOpenRecovery(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if recovery.Status != models.RecoveryApproved && recovery.AvailableAt.After(time.Now()) {
+		return nil, recovery, ErrRecoveryNotReady
+	}
+
+	now := time.Now()
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(recovery).
+			Where("status IN ?", []models.AccountRecoveryStatus{models.RecoveryPending, models.RecoveryApproved}).
+			Updates(map[string]interface{}{"status": models.RecoveryCompleted, "completed_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecoveryNotFound
+		}
+
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"is_two_factor_enabled":      false,
+			"two_factor_secret":          nil,
+			"two_factor_backup_codes":    nil,
+			"two_factor_failed_attempts": 0,
+			"two_factor_locked_until":    nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := NewVerificationTokenService(tx).Revoke(user.ID, models.PurposeRecoveryCancel); err != nil {
+			return err
+		}
+		if err := NewTwoFactorService(tx).RemoveTrustedDevices(user.ID.String()); err != nil {
+			return err
+		}
+		_, err := NewSessionService(tx).RevokeAllSessions(user.ID.String(), "")
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fullUser, err := loadSigninUser(s.database, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	recovery.Status = models.RecoveryCompleted
+	recovery.CompletedAt = &now
+	return fullUser, recovery, nil
+}
+
+// Cancel stops the recovery the emailed cancel link was issued for.
+func (s *AccountRecoveryService) Cancel(token string) (*models.AccountRecovery, error) {
+	record, err := s.tokens.ConsumeToken(models.PurposeRecoveryCancel, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var recovery models.AccountRecovery
+	if err := s.database.First(&recovery, "id = ? AND user_id = ?", record.Target, record.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecoveryNotFound
+		}
+		return nil, err
+	}
+	if !recovery.IsOpen() {
+		return nil, ErrRecoveryNotFound
+	}
+
+	now := time.Now()
+	if err := s.database.Model(&recovery).Updates(map[string]interface{}{
+		"status":       models.RecoveryCancelled,
+		"cancelled_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	recovery.Status = models.RecoveryCancelled
+	recovery.CancelledAt = &now
+	return &recovery, nil
+}
+
+// GetRecoveryQueue lists recoveries for admins, open ones by default, oldest first.
+func (s *AccountRecoveryService) GetRecoveryQueue(params dto.RecoveryQueueParams) (*dto.PaginatedResponse[models.AccountRecovery], error) {
+	params.Pagination = normalizePagination(params.Pagination)
+
+	query := s.database.Model(&models.AccountRecovery{})
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	} else {
+		query = query.Where("status IN ?", []models.AccountRecoveryStatus{models.RecoveryPending, models.RecoveryApproved})
+	}
+
+	var totalItems int64
+	if err := query.Count(&totalItems).Error; err != nil {
+		return nil, err
+	}
+
+	var recoveries []models.AccountRecovery
+	if err := query.Preload("User").
+		Order("created_at ASC").
+		Offset((params.Page - 1) * params.Limit).
+		Limit(params.Limit).
+		Find(&recoveries).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedResponse[models.AccountRecovery]{
+		Data:       recoveries,
+		Limit:      params.Limit,
+		Page:       params.Page,
+		TotalItems: int(totalItems),
+		TotalPages: int(math.Ceil(float64(totalItems) / float64(params.Limit))),
+	}, nil
+}
+
+// ApproveRecovery waives the waiting period, after an admin has confirmed the requester's identity some
+// other way. The user still completes the recovery by signing in with their password.
+func (s *AccountRecoveryService) ApproveRecovery(adminID, recoveryID string) (*models.AccountRecovery, error) {
+	return s.review(adminID, recoveryID, models.RecoveryApproved, nil)
+}
+
+// RejectRecovery closes the recovery, for example when the owner reports they did not start it.
+func (s *AccountRecoveryService) RejectRecovery(adminID, recoveryID string, payload dto.RejectRecoveryRequest) (*models.AccountRecovery, error) {
+	recovery, err := s.review(adminID, recoveryID, models.RecoveryRejected, &payload.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.Revoke(recovery.UserID, models.PurposeRecoveryCancel); err != nil {
+		log.Printf("Failed to revoke recovery cancel links for user %s: %v", recovery.UserID, err)
+	}
+	return recovery, nil
+}
+
+func (s *AccountRecoveryService) review(adminID, recoveryID string, status models.AccountRecoveryStatus, note *string) (*models.AccountRecovery, error) {
+	if _, err := uuid.Parse(recoveryID); err != nil {
+		return nil, ErrRecoveryNotFound
+	}
+
+	var recovery models.AccountRecovery
+	if err := s.database.First(&recovery, "id = ?", recoveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecoveryNotFound
+		}
+		return nil, err
+	}
+	if recovery.Status != models.RecoveryPending {
+		return nil, ErrRecoveryNotFound
+	}
+
+	now := time.Now()
+	reviewerID := uuid.MustParse(adminID)
+	updates := map[string]interface{}{
+		"status":         status,
+		"review_note":    note,
+		"reviewed_at":    now,
+		"reviewed_by_id": reviewerID,
+	}
+	if status == models.RecoveryApproved {
+		updates["available_at"] = now
+	}
+	if err := s.database.Model(&recovery).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.database.Preload("User").First(&recovery, "id = ?", recovery.ID).Error; err != nil {
+		return nil, err
+	}
+	return &recovery, nil
+}
+
+// challengeUser resolves the sign-in challenge to a user who actually has 2FA to recover from.
+func (s *AccountRecoveryService) challengeUser(challengeToken string) (*models.User, error) {
+	user, err := s.twoFactor.ResolveChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsTwoFactorEnabled {
+		return nil, ErrRecoveryNotNeeded
+	}
+	return user, nil
+}
+
+func (s *AccountRecoveryService) findOpenRecovery(userID uuid.UUID) (*models.AccountRecovery, error) {
+	var recovery models.AccountRecovery
+	if err := s.database.
+		Where("user_id = ? AND status IN ?", userID, []models.AccountRecoveryStatus{models.RecoveryPending, models.RecoveryApproved}).
+		Order("created_at DESC").
+		First(&recovery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecoveryNotFound
+		}
+		return nil, err
+	}
+	return &recovery, nil
+}
+
+func (s *AccountRecoveryService) ensureNoOpenRecovery(userID uuid.UUID) error {
+	_, err := s.findOpenRecovery(userID)
+	if err == nil {
+		return ErrRecoveryPending
+	}
+	if errors.Is(err, ErrRecoveryNotFound) {
+		return nil
+	}
+	return err
+}
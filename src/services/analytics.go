@@ -658,7 +658,7 @@ func (s *AnalyticsService) getTopApplicants(userID uuid.UUID, limit int) []dto.A
 
 	s.database.Raw(`
 		SELECT u.id as user_id, u.name, u.email, ja.submission_date as applied_date,
-			j.title as job_title, ja.status
+			j.title as job_title, ja.status, u.identity_verified
 		FROM job_applications ja
 		JOIN users u ON u.id = ja.applicant_id
 		JOIN jobs j ON j.id = ja.job_id
@@ -15,12 +15,14 @@ import (
 type AnnouncementService struct {
 	database *gorm.DB
 	hub      *lib.Hub
+	roles    *RoleService
 }
 
 func NewAnnouncementService(database *gorm.DB, hub *lib.Hub) *AnnouncementService {
 	return &AnnouncementService{
 		database: database,
 		hub:      hub,
+		roles:    NewRoleService(database),
 	}
 }
 
@@ -261,16 +263,9 @@ func (s *AnnouncementService) GetAnnouncementsForUser(user *models.User, page, l
 	var announcements []models.Announcement
 	var totalItems int64
 
-	// Build audience filter based on user type
-	audienceFilter := []models.TargetAudience{models.TargetAllUsers}
-	if user.IsAdmin {
-		audienceFilter = append(audienceFilter, models.TargetAdminsOnly)
-	}
-	if user.IsRecruiter {
-		audienceFilter = append(audienceFilter, models.TargetRecruitersOnly)
-	}
-	if user.IsPremium {
-		audienceFilter = append(audienceFilter, models.TargetPremiumOnly)
+	audienceFilter, err := s.audienceFor(user)
+	if err != nil {
+		return nil, err
 	}
 
 	query := s.database.Model(&models.Announcement{}).
@@ -333,23 +328,38 @@ func (s *AnnouncementService) GetAnnouncementsForUser(user *models.User, page, l
 	}, nil
 }
 
-// GetActiveBanners returns active banner announcements for the user
-func (s *AnnouncementService) GetActiveBanners(user *models.User) ([]dto.BannerAnnouncementResponse, error) {
-	now := time.Now()
-	var announcements []models.Announcement
+// audienceFor builds the audiences a user belongs to. Staff are users holding any admin role.
+func (s *AnnouncementService) audienceFor(user *models.User) ([]models.TargetAudience, error) {
+	audiences := []models.TargetAudience{models.TargetAllUsers}
 
-	audienceFilter := []models.TargetAudience{models.TargetAllUsers}
-	if user.IsAdmin {
-		audienceFilter = append(audienceFilter, models.TargetAdminsOnly)
+	isStaff, err := s.roles.IsStaff(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if isStaff {
+		audiences = append(audiences, models.TargetAdminsOnly)
 	}
 	if user.IsRecruiter {
-		audienceFilter = append(audienceFilter, models.TargetRecruitersOnly)
+		audiences = append(audiences, models.TargetRecruitersOnly)
 	}
 	if user.IsPremium {
-		audienceFilter = append(audienceFilter, models.TargetPremiumOnly)
+		audiences = append(audiences, models.TargetPremiumOnly)
 	}
 
-	err := s.database.
+	return audiences, nil
+}
+
+// GetActiveBanners returns active banner announcements for the user
+func (s *AnnouncementService) GetActiveBanners(user *models.User) ([]dto.BannerAnnouncementResponse, error) {
+	now := time.Now()
+	var announcements []models.Announcement
+
+	audienceFilter, err := s.audienceFor(user)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.database.
 		Where("is_published = ?", true).
 		Where("show_as_banner = ?", true).
 		Where("target_audience IN ?", audienceFilter).
@@ -469,7 +479,7 @@ func (s *AnnouncementService) broadcastAnnouncement(announcement *models.Announc
 	case models.TargetAllUsers:
 		// No filter needed
 	case models.TargetAdminsOnly:
-		query = query.Where("is_admin = ?", true)
+		query = query.Where("id IN (?)", s.database.Model(&models.UserRole{}).Select("user_id"))
 	case models.TargetRecruitersOnly:
 		query = query.Where("is_recruiter = ?", true)
 	case models.TargetPremiumOnly:
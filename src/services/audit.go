@@ -0,0 +1,286 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"log"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxAuditExportRows caps a CSV export; narrow the filters to export older entries.
+const maxAuditExportRows = 50000
+
+// auditIgnoredFields change on every write and would drown out the meaningful differences.
+var auditIgnoredFields = map[string]bool{"created_at": true, "updated_at": true}
+
+// auditRedactedFields are recorded as changed without their values.
+var auditRedactedFields = map[string]bool{
+	"password": true, "otp": true, "token": true, "two_factor_secret": true, "two_factor_backup_codes": true,
+}
+
+// AuditEntry describes an action to record. Before and After are snapshots of the entity, typically the
+// model itself; only the fields that differ between them are stored.
+type AuditEntry struct {
+	ActorID        string
+	ImpersonatorID string
+	Action         models.AuditAction
+	EntityType     string
+	EntityID       string
+	Before         interface{}
+	After          interface{}
+	Metadata       map[string]interface{}
+	IPAddress      string
+	UserAgent      string
+}
+
+type AuditService struct {
+	database *gorm.DB
+}
+
+func NewAuditService(database *gorm.DB) *AuditService {
+	return &AuditService{database: database}
+}
+
+// Record appends an entry to the audit log. Failures are logged rather than returned so auditing never
+// undoes an action that has already succeeded.
+func (s *AuditService) Record(entry AuditEntry) {
+	changes, err := diffAuditSnapshots(entry.Before, entry.After)
+	if err != nil {
+		log.Printf("Failed to diff audit entry %s: %v", entry.Action, err)
+	}
+
+	record := models.AuditLog{
+		ActorID:        parseAuditUUID(entry.ActorID),
+		ImpersonatorID: parseAuditUUID(entry.ImpersonatorID),
+		Action:         entry.Action,
+		EntityType:     entry.EntityType,
+		EntityID:       entry.EntityID,
+		Changes:        changes,
+		Metadata:       entry.Metadata,
+		IPAddress:      entry.IPAddress,
+		UserAgent:      entry.UserAgent,
+	}
+	if err := s.database.Create(&record).Error; err != nil {
+		log.Printf("Failed to record audit entry %s for %s %s: %v", entry.Action, entry.EntityType, entry.EntityID, err)
+	}
+}
+
+func (s *AuditService) GetAuditLogs(params dto.AuditLogQueryParams) (*dto.PaginatedResponse[models.AuditLog], error) {
+	params.Pagination = normalizePagination(params.Pagination)
+	query := s.filterAuditLogs(params)
+
+	var totalItems int64
+	if err := query.Count(&totalItems).Error; err != nil {
+		return nil, err
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("created_at DESC").
+		Offset((params.Page - 1) * params.Limit).
+		Limit(params.Limit).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedResponse[models.AuditLog]{
+		Data:       logs,
+		Limit:      params.Limit,
+		Page:       params.Page,
+		TotalItems: int(totalItems),
+		TotalPages: int(math.Ceil(float64(totalItems) / float64(params.Limit))),
+	}, nil
+}
+
+// ExportAuditLogs renders the filtered entries as CSV, newest first.
+func (s *AuditService) ExportAuditLogs(params dto.AuditLogQueryParams) ([]byte, error) {
+	var logs []models.AuditLog
+	if err := s.filterAuditLogs(params).
+		Order("created_at DESC").
+		Limit(maxAuditExportRows).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.Write([]string{
+		"id", "created_at", "action", "actor_id", "impersonator_id", "entity_type", "entity_id",
+		"ip_address", "user_agent", "changes", "metadata",
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range logs {
+		var changes, metadata string
+		if len(entry.Changes) > 0 {
+			data, err := json.Marshal(entry.Changes)
+			if err != nil {
+				return nil, err
+			}
+			changes = string(data)
+		}
+		if len(entry.Metadata) > 0 {
+			data, err := json.Marshal(entry.Metadata)
+			if err != nil {
+				return nil, err
+			}
+			metadata = string(data)
+		}
+
+		if err := writer.Write([]string{
+			entry.ID.String(),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			string(entry.Action),
+			formatAuditUUID(entry.ActorID),
+			formatAuditUUID(entry.ImpersonatorID),
+			csvSafe(entry.EntityType),
+			csvSafe(entry.EntityID),
+			csvSafe(entry.IPAddress),
+			csvSafe(entry.UserAgent),
+			csvSafe(changes),
+			csvSafe(metadata),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (s *AuditService) filterAuditLogs(params dto.AuditLogQueryParams) *gorm.DB {
+	query := s.database.Model(&models.AuditLog{})
+
+	if params.ActorID != "" {
+		query = query.Where("actor_id = ? OR impersonator_id = ?", params.ActorID, params.ActorID)
+	}
+	if prefix, ok := strings.CutSuffix(params.Action, "*"); ok {
+		query = query.Where("action LIKE ?", strings.ReplaceAll(prefix, "%", `\%`)+"%")
+	} else if params.Action != "" {
+		query = query.Where("action = ?", params.Action)
+	}
+	if params.EntityType != "" {
+		query = query.Where("entity_type = ?", params.EntityType)
+	}
+	if params.EntityID != "" {
+		query = query.Where("entity_id = ?", params.EntityID)
+	}
+	if params.IPAddress != "" {
+		query = query.Where("ip_address = ?", params.IPAddress)
+	}
+	if start, err := time.Parse("2006-01-02", params.StartDate); err == nil {
+		query = query.Where("created_at >= ?", start)
+	}
+	if end, err := time.Parse("2006-01-02", params.EndDate); err == nil {
+		query = query.Where("created_at < ?", end.AddDate(0, 0, 1))
+	}
+
+	return query
+}
+
+// diffAuditSnapshots compares the JSON form of two snapshots field by field.
+func diffAuditSnapshots(before, after interface{}) (map[string]models.AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for key, value := range afterFields {
+		previous, existed := beforeFields[key]
+		if !existed || !reflect.DeepEqual(previous, value) {
+			changes[key] = models.AuditChange{Before: previous, After: value}
+		}
+	}
+	for key, previous := range beforeFields {
+		if _, exists := afterFields[key]; !exists {
+			changes[key] = models.AuditChange{Before: previous}
+		}
+	}
+
+	for key, change := range changes {
+		if auditIgnoredFields[key] {
+			delete(changes, key)
+		} else if auditRedactedFields[key] {
+			changes[key] = models.AuditChange{Before: redactAuditValue(change.Before), After: redactAuditValue(change.After)}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes, nil
+}
+
+// auditFields flattens a snapshot to its top-level JSON fields. Snapshots that are not objects are kept
+// under a single "value" field.
+func auditFields(snapshot interface{}) (map[string]interface{}, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+
+	switch value := decoded.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return value, nil
+	default:
+		return map[string]interface{}{"value": value}, nil
+	}
+}
+
+func redactAuditValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return "[REDACTED]"
+}
+
+// csvSafe stops spreadsheet apps from evaluating user-controlled values as formulas.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func parseAuditUUID(id string) *uuid.UUID {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+func formatAuditUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
@@ -1,7 +1,6 @@
 package services
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"foglio/v2/src/config"
@@ -9,51 +8,44 @@ import (
 	"foglio/v2/src/lib"
 	"foglio/v2/src/models"
 	"log"
-	"net/http"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrAccountSuspended   = errors.New("account suspended")
+	ErrImpersonationEnded = errors.New("impersonation session has ended")
+	ErrInvalidCredentials = errors.New("invalid email, username or password")
+)
+
+// dummyPasswordHash is compared against when the account does not exist, so a failed sign-in takes as long
+// whether or not it does.
+var dummyPasswordHash, _ = lib.HashPassword("Foglio-dummy-password-1")
+
 type AuthService struct {
-	database *gorm.DB
+	database   *gorm.DB
+	sessions   *SessionService
+	tokens     *VerificationTokenService
+	twoFactor  *TwoFactorService
+	webAuthn   *WebAuthnService
+	identities *IdentityService
+	sso        *SSOService
+	throttle   *AuthThrottleService
 }
 
 func NewAuthService(database *gorm.DB) *AuthService {
 	return &AuthService{
-		database: database,
-	}
-}
-
-type OAuthConfig struct {
-	ClientID     string
-	ClientSecret string
-	RedirectURL  string
-	AuthURL      string
-	TokenURL     string
-	UserInfoURL  string
-}
-
-func (s *AuthService) getGoogleOAuthConfig() OAuthConfig {
-	return OAuthConfig{
-		ClientID:     config.AppConfig.GoogleClientId,
-		ClientSecret: config.AppConfig.GoogleClientSecret,
-		RedirectURL:  config.AppConfig.GoogleRedirectUrl,
-		AuthURL:      "https://accounts.google.com/o/oauth2/auth",
-		TokenURL:     "https://oauth2.googleapis.com/token",
-		UserInfoURL:  "https://www.googleapis.com/oauth2/v3/userinfo",
-	}
-}
-
-func (s *AuthService) getGitHubOAuthConfig() OAuthConfig {
-	return OAuthConfig{
-		ClientID:     config.AppConfig.GithubClientId,
-		ClientSecret: config.AppConfig.GithubClientSecret,
-		RedirectURL:  config.AppConfig.GithubRedirectUrl,
-		AuthURL:      "https://github.com/login/oauth/authorize",
-		TokenURL:     "https://github.com/login/oauth/access_token",
-		UserInfoURL:  "https://api.github.com/user",
+		database:   database,
+		sessions:   NewSessionService(database),
+		tokens:     NewVerificationTokenService(database),
+		twoFactor:  NewTwoFactorService(database),
+		webAuthn:   NewWebAuthnService(database),
+		identities: NewIdentityService(database),
+		sso:        NewSSOService(database),
+		throttle:   NewAuthThrottleService(database),
 	}
 }
 
@@ -62,13 +54,17 @@ type OAuthTokenResponse struct {
 	TokenType   string `json:"token_type"`
 	Scope       string `json:"scope"`
 	ExpiresIn   int    `json:"expires_in"`
+	Error       string `json:"error"`
 }
 
 type SigninResponse struct {
 	User              models.User `json:"user,omitempty"`
 	Token             string      `json:"token,omitempty"`
+	RefreshToken      string      `json:"refresh_token,omitempty"`
+	ExpiresIn         int         `json:"expires_in,omitempty"`
 	RequiresTwoFactor bool        `json:"requires_two_factor"`
-	UserID            string      `json:"user_id,omitempty"`
+	ChallengeToken    string      `json:"challenge_token,omitempty"`    // Exchanged with a 2FA code at /auth/2fa/verify
+	TwoFactorMethods  []string    `json:"two_factor_methods,omitempty"` // "totp", plus "passkey" when the user has one
 }
 
 func (s *AuthService) CreateUser(payload dto.CreateUserDto) (*models.User, error) {
@@ -90,12 +86,9 @@ func (s *AuthService) CreateUser(payload dto.CreateUserDto) (*models.User, error
 		return nil, errors.New("failed to hash password")
 	}
 
-	otp := lib.GenerateOtp()
-
 	user := models.User{
 		Name:     payload.Name,
 		Email:    payload.Email,
-		Otp:      otp,
 		Password: hashed,
 		Username: payload.Username,
 	}
@@ -104,144 +97,193 @@ func (s *AuthService) CreateUser(payload dto.CreateUserDto) (*models.User, error
 		return nil, err
 	}
 
-	go func() {
-		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
-			To:       []string{user.Email},
-			Subject:  "Verify Your Email",
-			Template: "verification",
-			Data: map[string]interface{}{
-				"Name":  user.Name,
-				"Email": user.Email,
-				"Otp":   otp,
-			},
-		})
-		if err != nil {
-			log.Printf("Failed to send verification email: %v", err)
-		} else {
-			log.Printf("Verification email sent to: %v", user.Email)
-		}
-	}()
+	// The account exists either way; the user can request a new code if this one was not sent
+	if err := s.sendVerificationCode(&user, "Verify Your Email"); err != nil {
+		log.Printf("Failed to issue verification code for %s: %v", user.Email, err)
+	}
 
 	return &user, nil
 }
 
-func (s *AuthService) Signin(payload dto.SigninDto) (*SigninResponse, error) {
-	user, err := s.FindUserByEmailOrUsername(payload.Identifier)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+// Signin checks the password. Unknown accounts and wrong passwords fail the same way, and failures slow
+// down and then lock out further attempts for the account and for the client's IP address.
+func (s *AuthService) Signin(payload dto.SigninDto, client dto.SessionClient) (*SigninResponse, error) {
+	// Enforced SSO depends only on the email's domain, so it can be checked before looking up the account
+	if strings.Contains(payload.Identifier, "@") {
+		required, err := s.sso.RequiresSSO(payload.Identifier)
+		if err != nil {
+			return nil, err
 		}
+		if required {
+			return nil, ErrSSORequired
+		}
+	}
+
+	user, err := s.FindUserByEmailOrUsername(payload.Identifier)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
 
-	err = lib.ComparePassword(payload.Password, user.Password)
+	account := accountThrottleKey(payload.Identifier)
+	ip := ipThrottleKey(client.IPAddress)
+	locked, err := s.throttle.Attempt(models.AuthActionSignin, account, ip)
 	if err != nil {
-		return nil, errors.New("invalid password")
+		return nil, err
 	}
 
-	if user.IsTwoFactorEnabled {
-		return &SigninResponse{
-			RequiresTwoFactor: true,
-			UserID:            user.ID.String(),
-		}, nil
+	// Accounts without a password (OAuth-only) and unknown ones still pay for a bcrypt comparison, so the
+	// response time does not tell them apart from a wrong password
+	if user == nil || user.Password == "" {
+		_ = lib.ComparePassword(payload.Password, dummyPasswordHash)
+		return nil, s.signinFailed(user, locked)
+	}
+	if lib.ComparePassword(payload.Password, user.Password) != nil {
+		return nil, s.signinFailed(user, locked)
+	}
+	if err := s.throttle.Reset(models.AuthActionSignin, account); err != nil {
+		log.Printf("Failed to reset sign-in attempts for user %s: %v", user.ID, err)
+	}
+	if err := s.throttle.Release(models.AuthActionSignin, ip); err != nil {
+		log.Printf("Failed to release sign-in attempt for user %s: %v", user.ID, err)
 	}
 
-	token, err := lib.GenerateToken(user.ID)
+	required, err := s.sso.RequiresSSO(user.Email)
 	if err != nil {
 		return nil, err
 	}
+	if required {
+		return nil, ErrSSORequired
+	}
 
-	var fullUser models.User
-	if err := s.database.
-		Preload("Projects").
-		Preload("Projects.Stack").
-		Preload("Projects.Highlights").
-		Preload("Experiences").
-		Preload("Experiences.Highlights").
-		Preload("Experiences.Technologies").
-		Preload("Education").
-		Preload("Education.Highlights").
-		Preload("Certifications").
-		Preload("Languages").
-		Preload("Company").
-		Preload("CurrentSubscription").
-		Preload("CurrentSubscription.Subscription").
-		Preload("Portfolio").
-		First(&fullUser, "id = ?", user.ID).Error; err != nil {
+	if user.IsSuspended() {
+		return nil, ErrAccountSuspended
+	}
+
+	if user.IsTwoFactorEnabled && !s.twoFactor.IsTrustedDevice(user.ID, client.TrustedDeviceToken) {
+		return s.twoFactorChallenge(user)
+	}
+
+	fullUser, err := loadSigninUser(s.database, user.ID)
+	if err != nil {
 		return nil, err
 	}
 
-	fullUser.Password = ""
-	fullUser.Otp = ""
+	return s.CreateSession(fullUser, client)
+}
 
-	return &SigninResponse{
-		User:  fullUser,
-		Token: token,
-	}, nil
+// signinFailed emails the owner when the failed sign-in was the attempt that locked their account.
+func (s *AuthService) signinFailed(user *models.User, locked bool) error {
+	if locked && user != nil {
+		policy := accountThrottlePolicies[models.AuthActionSignin]
+		go func() {
+			err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+				To:       []string{user.Email},
+				Subject:  "Sign-In Locked",
+				Template: "signin-locked",
+				Data: map[string]interface{}{
+					"Name":        user.Name,
+					"Email":       user.Email,
+					"Attempts":    policy.maxAttempts,
+					"LockedUntil": time.Now().Add(policy.lockout).UTC().Format("Jan 2, 2006 15:04 MST"),
+				},
+			})
+			if err != nil {
+				log.Printf("Failed to send sign-in lockout email: %v", err)
+			}
+		}()
+	}
+
+	return ErrInvalidCredentials
 }
 
-func (s *AuthService) RequestVerification(email string) (*models.User, error) {
-	user, err := s.FindUserByEmail(email)
+// SigninWithPasskey signs the user in with a passkey assertion. The passkey verified the user itself, so no
+// 2FA challenge follows.
+func (s *AuthService) SigninWithPasskey(payload dto.PasskeyLoginRequest, client dto.SessionClient) (*SigninResponse, error) {
+	user, err := s.webAuthn.FinishLogin(payload)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
 		return nil, err
 	}
 
-	if user.Verified {
-		return nil, errors.New("user already verified")
+	fullUser, err := loadSigninUser(s.database, user.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	otp := lib.GenerateOtp()
-	user.Otp = otp
-	if err = s.database.Save(&user).Error; err != nil {
+	return s.CreateSession(fullUser, client)
+}
+
+// SigninWithSSO redeems the one-time code issued when the user signed in at their company's IdP.
+func (s *AuthService) SigninWithSSO(code string, client dto.SessionClient) (*SigninResponse, error) {
+	token, err := s.tokens.ConsumeToken(models.PurposeSSOLogin, code)
+	if err != nil {
 		return nil, err
 	}
 
-	go func() {
-		err = lib.GetEmailService().SendEmailSimple(lib.EmailDto{
-			To:       []string{user.Email},
-			Subject:  "Verification",
-			Template: "verification",
-			Data: map[string]interface{}{
-				"Name":  user.Name,
-				"Email": user.Email,
-				"Otp":   otp,
-			},
-		})
-		if err != nil {
-			log.Printf("Failed to send email: %v", err)
-		} else {
-			log.Printf("Email sent to: %v", user.Email)
-		}
-	}()
+	user, err := loadSigninUser(s.database, token.UserID)
+	if err != nil {
+		return nil, err
+	}
 
-	return nil, nil
-}
+	if user.IsTwoFactorEnabled && !s.twoFactor.IsTrustedDevice(user.ID, client.TrustedDeviceToken) {
+		return s.twoFactorChallenge(user)
+	}
 
-func (s *AuthService) Verification(payload dto.VerificationDto) (*SigninResponse, error) {
-	var user models.User
+	return s.CreateSession(user, client)
+}
 
-	if payload.VerificationNumber == nil || payload.VerificationType == nil || payload.VerificationDocument == nil {
-		return nil, errors.New("missing verification fields")
+// RequestVerification emails a new verification code, replacing any code sent before. It succeeds quietly
+// for unknown and already verified emails so it cannot be used to find accounts.
+func (s *AuthService) RequestVerification(email string, client dto.SessionClient) error {
+	// Every request sends an email, so each one counts
+	keys := []throttleKey{accountThrottleKey(email), ipThrottleKey(client.IPAddress)}
+	if _, err := s.throttle.Attempt(models.AuthActionVerification, keys...); err != nil {
+		return err
 	}
 
-	if err := s.database.Where("verification_number = ? AND verification_type = ? AND verification_document = ?", payload.VerificationNumber, payload.VerificationType, payload.VerificationDocument).First(&user).Error; err != nil {
+	user, err := s.FindUserByEmail(email)
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil
 		}
-		return nil, err
+		return err
 	}
 
 	if user.Verified {
-		return nil, errors.New("user already verified")
+		return nil
+	}
+
+	return s.sendVerificationCode(user, "Verification")
+}
+
+// Verification confirms the user's email with the code sent to it and signs them in.
+func (s *AuthService) Verification(payload dto.VerificationDto, client dto.SessionClient) (*SigninResponse, error) {
+	account, ip := accountThrottleKey(payload.Email), ipThrottleKey(client.IPAddress)
+	if _, err := s.throttle.Attempt(models.AuthActionVerification, account, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.FindUserByEmail(payload.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if user == nil || user.Verified {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	if _, err := s.tokens.ConsumeCode(user.ID, models.PurposeEmailVerification, payload.Otp); err != nil {
+		return nil, err
 	}
 
 	user.Verified = true
-	if err := s.database.Save(&user).Error; err != nil {
+	if err := s.database.Model(user).Update("verified", true).Error; err != nil {
 		return nil, err
 	}
+	if err := s.throttle.Reset(models.AuthActionVerification, account); err != nil {
+		log.Printf("Failed to reset verification attempts for user %s: %v", user.ID, err)
+	}
+	if err := s.throttle.Release(models.AuthActionVerification, ip); err != nil {
+		log.Printf("Failed to release verification attempt for user %s: %v", user.ID, err)
+	}
 
 	go func() {
 		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
@@ -260,21 +302,13 @@ func (s *AuthService) Verification(payload dto.VerificationDto) (*SigninResponse
 		}
 	}()
 
-	token, err := lib.GenerateToken(user.ID)
-	if err != nil {
-		return nil, err
-	}
-
 	user.Password = ""
-	user.Otp = ""
 
-	return &SigninResponse{
-		User:  user,
-		Token: token,
-	}, nil
+	return s.CreateSession(user, client)
 }
 
-func (s *AuthService) ChangePassword(id string, payload dto.ChangePasswordDto) error {
+// ChangePassword updates the password and signs the user out of every other session.
+func (s *AuthService) ChangePassword(id, sessionID string, payload dto.ChangePasswordDto) error {
 	user, err := s.FindUserById(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -298,29 +332,38 @@ func (s *AuthService) ChangePassword(id string, payload dto.ChangePasswordDto) e
 	}
 
 	user.Password = hashed
-	if err := s.database.Save(&user).Error; err != nil {
+	return s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		_, err := NewSessionService(tx).RevokeAllSessions(id, sessionID)
 		return err
-	}
-
-	return nil
+	})
 }
 
-func (s *AuthService) ForgotPassword(email string) error {
+// ForgotPassword emails a password reset link. It succeeds quietly for unknown emails so it cannot be used to
+// find accounts.
+func (s *AuthService) ForgotPassword(email string, client dto.SessionClient) error {
+	// Every request sends an email, so each one counts
+	keys := []throttleKey{accountThrottleKey(email), ipThrottleKey(client.IPAddress)}
+	if _, err := s.throttle.Attempt(models.AuthActionPasswordReset, keys...); err != nil {
+		return err
+	}
+
 	user, err := s.FindUserByEmail(email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user not found")
+			return nil
 		}
 		return err
 	}
 
-	token, err := lib.GenerateToken(user.ID)
+	token, err := s.tokens.Issue(user.ID, models.PurposePasswordReset, "")
 	if err != nil {
 		return err
 	}
 
-	client := config.AppConfig.ClientUrl + "/reset-password"
-	url := lib.GenerateUrl(client, token)
+	url := lib.GenerateUrl(config.AppConfig.ClientUrl+"/reset-password", token)
 
 	go func() {
 		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
@@ -343,32 +386,44 @@ func (s *AuthService) ForgotPassword(email string) error {
 	return nil
 }
 
-func (s *AuthService) ResetPassword(payload dto.ResetPasswordDto) error {
-	id, err := lib.ExtractUserID(payload.Token)
-	if err != nil {
-		return err
-	}
-
-	user, err := s.FindUserById(id.String())
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user not found")
-		}
-		return err
-	}
-
+// ResetPassword sets a new password using the emailed reset token and signs the user out everywhere.
+func (s *AuthService) ResetPassword(payload dto.ResetPasswordDto) (*models.User, error) {
+	// Checked first so a rejected password does not use up the token
 	if !lib.ValidatePassword(payload.NewPassword) {
-		return errors.New("invalid password")
+		return nil, errors.New("invalid password")
 	}
 
 	hashed, err := lib.HashPassword(payload.NewPassword)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	user.Password = hashed
-	if err := s.database.Save(&user).Error; err != nil {
+	var user *models.User
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		token, err := NewVerificationTokenService(tx).ConsumeToken(models.PurposePasswordReset, payload.Token)
+		if err != nil {
+			return err
+		}
+
+		user, err = s.FindUserById(token.UserID.String())
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidVerificationToken
+			}
+			return err
+		}
+
+		if err := tx.Model(user).Update("password", hashed).Error; err != nil {
+			return err
+		}
+		if err := NewTwoFactorService(tx).RemoveTrustedDevices(user.ID.String()); err != nil {
+			return err
+		}
+		_, err = NewSessionService(tx).RevokeAllSessions(user.ID.String(), "")
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	go func() {
@@ -388,107 +443,126 @@ func (s *AuthService) ResetPassword(payload dto.ResetPasswordDto) error {
 		}
 	}()
 
-	return nil
+	return user, nil
 }
 
-func (s *AuthService) FindUserByEmail(email string) (*models.User, error) {
-	var user models.User
-
-	if err := s.database.Where("email = ?", email).First(&user).Error; err != nil {
-		return nil, err
+// AuthenticateToken validates an access token and loads the user it was issued to, along with its claims.
+func (s *AuthService) AuthenticateToken(token string) (*models.User, *lib.Claims, error) {
+	claims, err := lib.ValidateToken(token)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return &user, nil
-}
-
-func (s *AuthService) FindUserById(id string) (*models.User, error) {
-	var user models.User
+	user, err := s.FindUserById(claims.UserId.String())
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if err := s.database.Where("id = ?", id).First(&user).Error; err != nil {
-		return nil, err
+	if err := s.CheckAccess(user, claims); err != nil {
+		return nil, nil, err
 	}
 
-	return &user, nil
+	return user, claims, nil
 }
 
-func (s *AuthService) FindUserByUsername(username string) (*models.User, error) {
-	var user models.User
-
-	if err := s.database.Where("username = ?", username).First(&user).Error; err != nil {
+func (s *AuthService) twoFactorChallenge(user *models.User) (*SigninResponse, error) {
+	challenge, err := s.twoFactor.IssueChallenge(user)
+	if err != nil {
 		return nil, err
 	}
 
-	return &user, nil
-}
-
-func (s *AuthService) FindUserByEmailOrUsername(identifier string) (*models.User, error) {
-	var user models.User
-
-	if err := s.database.Where("email = ? OR username = ?", identifier, identifier).First(&user).Error; err != nil {
-		return nil, err
+	methods := []string{"totp"}
+	if s.webAuthn.HasCredentials(user.ID) {
+		methods = append(methods, "passkey")
 	}
 
-	return &user, nil
+	return &SigninResponse{
+		RequiresTwoFactor: true,
+		ChallengeToken:    challenge,
+		TwoFactorMethods:  methods,
+	}, nil
 }
 
-func (s *AuthService) GetOAuthURL(provider string) (string, error) {
-	var config OAuthConfig
-	var scope string
-
-	switch provider {
-	case "google":
-		config = s.getGoogleOAuthConfig()
-		scope = "https://www.googleapis.com/auth/userinfo.profile https://www.googleapis.com/auth/userinfo.email"
-	case "github":
-		config = s.getGitHubOAuthConfig()
-		scope = "user:email"
-	default:
-		return "", errors.New("unsupported OAuth provider")
+// sendVerificationCode issues an email verification code and mails it to the user.
+func (s *AuthService) sendVerificationCode(user *models.User, subject string) error {
+	code, err := s.tokens.Issue(user.ID, models.PurposeEmailVerification, user.Email)
+	if err != nil {
+		return err
 	}
 
-	state := lib.GenerateRandomString(32)
-
-	authURL := fmt.Sprintf("%s?client_id=%s&redirect_uri=%s&response_type=code&scope=%s&state=%s",
-		config.AuthURL,
-		config.ClientID,
-		config.RedirectURL,
-		scope,
-		state,
-	)
+	go func() {
+		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+			To:       []string{user.Email},
+			Subject:  subject,
+			Template: "verification",
+			Data: map[string]interface{}{
+				"Name":  user.Name,
+				"Email": user.Email,
+				"Otp":   code,
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send verification email: %v", err)
+		} else {
+			log.Printf("Verification email sent to: %v", user.Email)
+		}
+	}()
 
-	return authURL, nil
+	return nil
 }
 
-func (s *AuthService) HandleOAuthCallback(provider string, payload dto.OAuthCallbackDto) (*SigninResponse, error) {
-	accessToken, err := s.exchangeCodeForToken(provider, payload.Code)
+// CreateSession signs the user in on a new device and returns the user with its token pair. The user is
+// emailed when the device or network has not signed in to the account before.
+func (s *AuthService) CreateSession(user *models.User, client dto.SessionClient) (*SigninResponse, error) {
+	known, err := s.sessions.IsKnownDevice(user.ID, client)
 	if err != nil {
-		return nil, err
+		log.Printf("Failed to check sign-in device for user %s: %v", user.ID, err)
+		known = true
 	}
 
-	oauthUser, err := s.getUserInfo(provider, accessToken)
+	pair, err := s.sessions.CreateSession(user.ID, client)
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := s.findOrCreateOAuthUser(oauthUser)
-	if err != nil {
-		return nil, err
+	if !known {
+		s.sendNewDeviceAlert(user, client)
 	}
 
-	if user.IsTwoFactorEnabled {
-		return &SigninResponse{
-			RequiresTwoFactor: true,
-			UserID:            user.ID.String(),
-		}, nil
-	}
+	return &SigninResponse{
+		User:         *user,
+		Token:        pair.Token,
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    pair.ExpiresIn,
+	}, nil
+}
 
-	token, err := lib.GenerateToken(user.ID)
-	if err != nil {
-		return nil, err
-	}
+// sendNewDeviceAlert tells the user their account was signed in to from a new device or location.
+func (s *AuthService) sendNewDeviceAlert(user *models.User, client dto.SessionClient) {
+	go func() {
+		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+			To:       []string{user.Email},
+			Subject:  "New Sign-In to Your Account",
+			Template: "new-device-signin",
+			Data: map[string]interface{}{
+				"Name":      user.Name,
+				"Email":     user.Email,
+				"Browser":   detectBrowser(client.UserAgent),
+				"OS":        detectOS(client.UserAgent),
+				"IPAddress": client.IPAddress,
+				"Time":      time.Now().UTC().Format("Jan 2, 2006 15:04 MST"),
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send new sign-in email: %v", err)
+		}
+	}()
+}
 
-	var fullUser models.User
-	if err := s.database.
+// loadSigninUser loads the user with the profile returned on sign-in.
+func loadSigninUser(database *gorm.DB, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := database.
 		Preload("Projects").
 		Preload("Projects.Stack").
 		Preload("Projects.Highlights").
@@ -503,229 +577,190 @@ func (s *AuthService) HandleOAuthCallback(provider string, payload dto.OAuthCall
 		Preload("CurrentSubscription").
 		Preload("CurrentSubscription.Subscription").
 		Preload("Portfolio").
-		First(&fullUser, "id = ?", user.ID).Error; err != nil {
+		First(&user, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 
-	fullUser.Password = ""
-	fullUser.Otp = ""
-
-	return &SigninResponse{
-		User:  fullUser,
-		Token: token,
-	}, nil
+	user.Password = ""
+	return &user, nil
 }
 
-func (s *AuthService) exchangeCodeForToken(provider string, code string) (string, error) {
-	var config OAuthConfig
-	switch provider {
-	case "google":
-		config = s.getGoogleOAuthConfig()
-	case "github":
-		config = s.getGitHubOAuthConfig()
-	default:
-		return "", errors.New("unsupported OAuth provider")
-	}
-
-	body := strings.NewReader(fmt.Sprintf(
-		"client_id=%s&client_secret=%s&code=%s&grant_type=authorization_code&redirect_uri=%s",
-		config.ClientID,
-		config.ClientSecret,
-		code,
-		config.RedirectURL,
-	))
-
-	req, err := http.NewRequest("POST", config.TokenURL, body)
-	if err != nil {
-		return "", err
+// CheckAccess rejects tokens belonging to suspended users, tokens whose sign-in session has been revoked
+// and impersonation tokens whose session has expired or been ended.
+func (s *AuthService) CheckAccess(user *models.User, claims *lib.Claims) error {
+	if user.IsSuspended() {
+		return ErrAccountSuspended
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Accept", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Error closing response body: %v", err)
+	if claims.ImpersonatorId == nil {
+		if claims.ID == "" {
+			return ErrSessionRevoked
+		}
+		var session models.Session
+		if err := s.database.Where("id = ? AND user_id = ?", claims.ID, user.ID).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSessionRevoked
+			}
+			return err
+		}
+		if !session.IsActive() {
+			return ErrSessionRevoked
+		}
+	} else {
+		var session models.ImpersonationSession
+		if err := s.database.
+			Where("id = ? AND user_id = ? AND admin_id = ?", claims.ID, user.ID, *claims.ImpersonatorId).
+			First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrImpersonationEnded
+			}
+			return err
+		}
+		if !session.IsActive() {
+			return ErrImpersonationEnded
 		}
-	}()
-
-	var tokenResp OAuthTokenResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return "", err
 	}
 
-	if tokenResp.AccessToken == "" {
-		return "", errors.New("failed to get access token")
+	return nil
+}
+
+func (s *AuthService) FindUserByEmail(email string) (*models.User, error) {
+	var user models.User
+
+	if err := s.database.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, err
 	}
 
-	return tokenResp.AccessToken, nil
+	return &user, nil
 }
 
-func (s *AuthService) getUserInfo(provider string, accessToken string) (*dto.OAuthUserDto, error) {
-	var config OAuthConfig
-	switch provider {
-	case "google":
-		config = s.getGoogleOAuthConfig()
-	case "github":
-		config = s.getGitHubOAuthConfig()
-	default:
-		return nil, errors.New("unsupported OAuth provider")
-	}
+func (s *AuthService) FindUserById(id string) (*models.User, error) {
+	var user models.User
 
-	req, err := http.NewRequest("GET", config.UserInfoURL, nil)
-	if err != nil {
+	if err := s.database.Where("id = ?", id).First(&user).Error; err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/json")
+	return &user, nil
+}
+
+func (s *AuthService) FindUserByUsername(username string) (*models.User, error) {
+	var user models.User
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
+	if err := s.database.Where("username = ?", username).First(&user).Error; err != nil {
 		return nil, err
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Error closing response body: %v", err)
-		}
-	}()
 
-	var oauthUser dto.OAuthUserDto
-	oauthUser.Provider = provider
+	return &user, nil
+}
 
-	switch provider {
-	case "google":
-		var googleUser struct {
-			Sub     string `json:"sub"`
-			Email   string `json:"email"`
-			Name    string `json:"name"`
-			Picture string `json:"picture"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&googleUser); err != nil {
-			return nil, err
-		}
-		oauthUser.ID = googleUser.Sub
-		oauthUser.Email = googleUser.Email
-		oauthUser.Name = googleUser.Name
-		oauthUser.Avatar = googleUser.Picture
-
-	case "github":
-		var githubUser struct {
-			ID        int    `json:"id"`
-			Email     string `json:"email"`
-			Name      string `json:"name"`
-			AvatarURL string `json:"avatar_url"`
-			Login     string `json:"login"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&githubUser); err != nil {
-			return nil, err
-		}
-		oauthUser.ID = fmt.Sprintf("%d", githubUser.ID)
-		oauthUser.Email = githubUser.Email
-		oauthUser.Name = githubUser.Name
-		oauthUser.Avatar = githubUser.AvatarURL
-
-		if oauthUser.Email == "" {
-			email, err := s.getGitHubPrimaryEmail(accessToken)
-			if err == nil && email != "" {
-				oauthUser.Email = email
-			}
-		}
+func (s *AuthService) FindUserByEmailOrUsername(identifier string) (*models.User, error) {
+	var user models.User
 
-		if oauthUser.Name == "" {
-			oauthUser.Name = githubUser.Login
-		}
+	if err := s.database.Where("email = ? OR username = ?", identifier, identifier).First(&user).Error; err != nil {
+		return nil, err
 	}
 
-	if oauthUser.Email == "" {
-		return nil, errors.New("could not get user email from OAuth provider")
-	}
+	return &user, nil
+}
 
-	return &oauthUser, nil
+// GetOAuthURL starts signing in with the provider and returns the URL to send the user to, along with the
+// state to bind to the browser.
+func (s *AuthService) GetOAuthURL(provider string) (string, string, error) {
+	return s.identities.BeginAuthorization(provider, models.OAuthIntentSignin, nil)
 }
 
-func (s *AuthService) getGitHubPrimaryEmail(accessToken string) (string, error) {
-	req, err := http.NewRequest("GET", "https://api.github.com/user/emails", nil)
+func (s *AuthService) HandleOAuthCallback(provider string, payload dto.OAuthCallbackDto, browserState string, client dto.SessionClient) (*SigninResponse, error) {
+	oauthUser, err := s.identities.CompleteAuthorization(provider, payload, browserState, models.OAuthIntentSignin, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	user, err := s.findOrCreateOAuthUser(oauthUser)
 	if err != nil {
-		return "", err
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Error closing response body: %v", err)
-		}
-	}()
-
-	var emails []struct {
-		Email    string `json:"email"`
-		Primary  bool   `json:"primary"`
-		Verified bool   `json:"verified"`
+		return nil, err
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&emails); err != nil {
-		return "", err
+	if user.IsTwoFactorEnabled && !s.twoFactor.IsTrustedDevice(user.ID, client.TrustedDeviceToken) {
+		return s.twoFactorChallenge(user)
 	}
 
-	for _, email := range emails {
-		if email.Primary && email.Verified {
-			return email.Email, nil
-		}
+	fullUser, err := loadSigninUser(s.database, user.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	return "", errors.New("no primary email found")
+	return s.CreateSession(fullUser, client)
 }
 
+// findOrCreateOAuthUser resolves the provider account to a user through its linked identity. An unknown
+// account is linked to the user with the same email only when the provider has verified that email;
+// otherwise the owner has to sign in and link it themselves.
 func (s *AuthService) findOrCreateOAuthUser(oauthUser *dto.OAuthUserDto) (*models.User, error) {
-	var user models.User
+	user, err := s.identities.FindUser(oauthUser)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
 
-	err := s.database.Where("provider = ? AND provider_id = ?", oauthUser.Provider, oauthUser.ID).First(&user).Error
+	// Identities of accounts merged away sign in to the account they were merged into
+	var merge models.AccountMerge
+	err = s.database.Where("source_provider = ? AND source_provider_id = ?", oauthUser.Provider, oauthUser.ID).
+		Order("created_at DESC").
+		First(&merge).Error
 	if err == nil {
-		return &user, nil
+		var target models.User
+		if err := s.database.Where("id = ?", merge.TargetUserID).First(&target).Error; err == nil {
+			if _, err := s.identities.Link(target.ID, oauthUser); err != nil {
+				log.Printf("Failed to link merged identity to user %s: %v", target.ID, err)
+			}
+			return &target, nil
+		}
 	}
 
-	err = s.database.Where("email = ?", oauthUser.Email).First(&user).Error
+	var existing models.User
+	err = s.database.Where("email = ?", oauthUser.Email).First(&existing).Error
 	if err == nil {
-		user.Provider = oauthUser.Provider
-		user.ProviderID = oauthUser.ID
-		if user.Image == nil || *user.Image == "" {
-			user.Image = &oauthUser.Avatar
+		if !oauthUser.EmailVerified {
+			return nil, ErrOAuthEmailInUse
 		}
-		if err := s.database.Save(&user).Error; err != nil {
+		if _, err := s.identities.Link(existing.ID, oauthUser); err != nil {
 			return nil, err
 		}
-		return &user, nil
+		if (existing.Image == nil || *existing.Image == "") && oauthUser.Avatar != "" {
+			if err := s.database.Model(&existing).Update("image", oauthUser.Avatar).Error; err != nil {
+				log.Printf("Failed to set avatar for user %s: %v", existing.ID, err)
+			}
+		}
+		return &existing, nil
 	}
-
-	user = models.User{
-		Name:       oauthUser.Name,
-		Email:      oauthUser.Email,
-		Username:   lib.GenerateUsername(oauthUser.Name),
-		Provider:   oauthUser.Provider,
-		ProviderID: oauthUser.ID,
-		Verified:   true,
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
 	}
 
+	newUser := models.User{
+		Name:     oauthUser.Name,
+		Email:    oauthUser.Email,
+		Username: lib.GenerateUsername(oauthUser.Name),
+		Provider: oauthUser.Provider,
+		Verified: oauthUser.EmailVerified,
+	}
 	if oauthUser.Avatar != "" {
-		user.Image = &oauthUser.Avatar
+		newUser.Image = &oauthUser.Avatar
 	}
 
-	if err := s.database.Create(&user).Error; err != nil {
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newUser).Error; err != nil {
+			return err
+		}
+		_, err := NewIdentityService(tx).Link(newUser.ID, oauthUser)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return &user, nil
+	return &newUser, nil
 }
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTooManyAttempts = errors.New("too many attempts")
+
+// ThrottledError is returned while an account or IP address has to wait before trying an action again.
+type ThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool // Locked out rather than just slowed down
+}
+
+func (e *ThrottledError) Error() string {
+	wait := e.RetryAfter.Round(time.Second)
+	if wait < time.Second {
+		wait = time.Second
+	}
+	if e.Locked {
+		return fmt.Sprintf("too many failed attempts, try again in %s", wait)
+	}
+	return fmt.Sprintf("too many attempts, wait %s before trying again", wait)
+}
+
+func (e *ThrottledError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+type throttlePolicy struct {
+	freeAttempts int           // Failures allowed before attempts are delayed
+	maxAttempts  int           // Failures that lock the key out
+	window       time.Duration // Failures older than this are forgotten
+	lockout      time.Duration
+}
+
+const (
+	baseThrottleDelay = time.Second
+	maxThrottleDelay  = 5 * time.Minute
+)
+
+// Accounts are slowed down quickly since only their owner should be trying; IP addresses get more headroom
+// for offices and carrier NATs but still catch one client stuffing credentials across many accounts. 2FA
+// codes have their own per-account lockout, see TwoFactorService.
+var (
+	accountThrottlePolicies = map[string]throttlePolicy{
+		models.AuthActionSignin:        {freeAttempts: 5, maxAttempts: 10, window: time.Hour, lockout: 15 * time.Minute},
+		models.AuthActionPasswordReset: {freeAttempts: 3, maxAttempts: 5, window: time.Hour, lockout: time.Hour},
+		models.AuthActionVerification:  {freeAttempts: 5, maxAttempts: 10, window: time.Hour, lockout: 15 * time.Minute},
+	}
+	ipThrottlePolicies = map[string]throttlePolicy{
+		models.AuthActionSignin:        {freeAttempts: 20, maxAttempts: 100, window: time.Hour, lockout: time.Hour},
+		models.AuthActionPasswordReset: {freeAttempts: 10, maxAttempts: 30, window: time.Hour, lockout: time.Hour},
+		models.AuthActionVerification:  {freeAttempts: 20, maxAttempts: 100, window: time.Hour, lockout: time.Hour},
+		models.AuthActionTwoFactor:     {freeAttempts: 10, maxAttempts: 50, window: time.Hour, lockout: time.Hour},
+	}
+)
+
+// throttleKey is an account or IP address attempts are counted for.
+type throttleKey struct {
+	value    string
+	policies map[string]throttlePolicy
+}
+
+// accountThrottleKey counts attempts against the identifier that was tried, whether or not an account has
+// it, so the throttle behaves the same for unknown accounts as for real ones and does not reveal which exist.
+func accountThrottleKey(identifier string) throttleKey {
+	return throttleKey{value: "account:" + strings.ToLower(strings.TrimSpace(identifier)), policies: accountThrottlePolicies}
+}
+
+func ipThrottleKey(ip string) throttleKey {
+	return throttleKey{value: "ip:" + ip, policies: ipThrottlePolicies}
+}
+
+type AuthThrottleService struct {
+	database *gorm.DB
+}
+
+func NewAuthThrottleService(database *gorm.DB) *AuthThrottleService {
+	return &AuthThrottleService{database: database}
+}
+
+// Attempt counts an attempt at the action for each key before it is made, so a burst of concurrent requests
+// cannot all slip past the limit before any failure is recorded. It returns a ThrottledError, counting
+// nothing, while one of the keys has to wait. Otherwise it reports whether this attempt has just locked a key
+// out, so the caller can let the account owner know if it fails. A successful attempt is undone with Reset
+// or Release.
+func (s *AuthThrottleService) Attempt(action string, keys ...throttleKey) (bool, error) {
+	policies := map[string]throttlePolicy{}
+	for _, key := range keys {
+		if policy, ok := key.policies[action]; ok && key.value != "" {
+			policies[lib.HashToken(key.value)] = policy
+		}
+	}
+	if len(policies) == 0 {
+		return false, nil
+	}
+	hashes := make([]string, 0, len(policies))
+	for hash := range policies {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	locked := false
+	err := s.database.Transaction(func(tx *gorm.DB) error {
+		rows := make([]models.AuthThrottle, 0, len(hashes))
+		for _, hash := range hashes {
+			rows = append(rows, models.AuthThrottle{Action: action, Key: hash})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+			return err
+		}
+
+		// Rows are created and locked in key order so requests sharing an IP address cannot deadlock
+		var throttles []models.AuthThrottle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("action = ? AND key IN ?", action, hashes).
+			Order("key").
+			Find(&throttles).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var throttled *ThrottledError
+		for _, throttle := range throttles {
+			if throttle.BlockedUntil == nil || !throttle.BlockedUntil.After(now) {
+				continue
+			}
+			wait := throttle.BlockedUntil.Sub(now)
+			if throttled == nil || wait > throttled.RetryAfter {
+				throttled = &ThrottledError{RetryAfter: wait, Locked: throttle.Failures >= policies[throttle.Key].maxAttempts}
+			}
+		}
+		if throttled != nil {
+			return throttled
+		}
+
+		for i := range throttles {
+			throttle := &throttles[i]
+			policy := policies[throttle.Key]
+			if now.Sub(throttle.LastFailureAt) > policy.window {
+				throttle.Failures = 0
+			}
+			throttle.Failures++
+			throttle.LastFailureAt = now
+
+			switch {
+			case throttle.Failures >= policy.maxAttempts:
+				blockedUntil := now.Add(policy.lockout)
+				throttle.BlockedUntil = &blockedUntil
+				locked = locked || throttle.Failures == policy.maxAttempts
+			case throttle.Failures > policy.freeAttempts:
+				blockedUntil := now.Add(throttleDelay(throttle.Failures - policy.freeAttempts))
+				throttle.BlockedUntil = &blockedUntil
+			}
+
+			if err := tx.Save(throttle).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return locked, err
+}
+
+// Reset forgets the attempts of the keys, once the action has succeeded for the account they belong to.
+func (s *AuthThrottleService) Reset(action string, keys ...throttleKey) error {
+	hashes := s.hashes(action, keys)
+	if len(hashes) == 0 {
+		return nil
+	}
+	return s.database.Where("action = ? AND key IN ?", action, hashes).Delete(&models.AuthThrottle{}).Error
+}
+
+// Release gives back the attempt a successful action was counted for, without forgetting earlier failures.
+// It is used for IP addresses, which many accounts share and where one success says nothing about the rest.
+func (s *AuthThrottleService) Release(action string, keys ...throttleKey) error {
+	hashes := s.hashes(action, keys)
+	if len(hashes) == 0 {
+		return nil
+	}
+	return s.database.Model(&models.AuthThrottle{}).
+		Where("action = ? AND key IN ? AND failures > 0", action, hashes).
+		Update("failures", gorm.Expr("failures - 1")).Error
+}
+
+// throttleDelay doubles the wait with every failure past the free attempts.
+func throttleDelay(excess int) time.Duration {
+	delay := float64(baseThrottleDelay) * math.Pow(2, float64(excess-1))
+	if delay > float64(maxThrottleDelay) {
+		return maxThrottleDelay
+	}
+	return time.Duration(delay)
+}
+
+func (s *AuthThrottleService) hashes(action string, keys []throttleKey) []string {
+	hashes := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := key.policies[action]; ok && key.value != "" {
+			hashes = append(hashes, lib.HashToken(key.value))
+		}
+	}
+	return hashes
+}
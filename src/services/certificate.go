@@ -0,0 +1,599 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"foglio/v2/src/config"
+	"foglio/v2/src/models"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/acme"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrChallengeNotFound = errors.New("acme challenge not found")
+
+// ACMEChallengePrefix is the path the CA fetches HTTP-01 challenge responses from, on the domain itself.
+const ACMEChallengePrefix = "/.well-known/acme-challenge/"
+
+const (
+	certificateRenewBefore = 30 * 24 * time.Hour // or a third of the lifetime, for shorter-lived certificates
+	certificateStaleAfter  = 15 * time.Minute
+	certificateTimeout     = 5 * time.Minute
+	certificateMaxAttempts = 8
+	acmeChallengeTTL       = time.Hour
+)
+
+// certificateCacheTTL is how long a loaded certificate is reused before the database is read again, which
+// is how renewals made by another server reach this one.
+const certificateCacheTTL = 5 * time.Minute
+
+const certificateCacheSize = 10000
+
+type cachedCertificate struct {
+	certificate *tls.Certificate // nil when the domain has no certificate
+	cachedAt    time.Time
+}
+
+var certificateCache = struct {
+	sync.Mutex
+	certificates map[string]cachedCertificate
+}{certificates: map[string]cachedCertificate{}}
+
+type CertificateService struct {
+	database *gorm.DB
+}
+
+func NewCertificateService(database *gorm.DB) *CertificateService {
+	return &CertificateService{
+		database: database,
+	}
+}
+
+// CertificatesEnabled reports whether certificates are obtained for custom domains, which takes an ACME
+// directory in ACME_DIRECTORY_URL.
+func CertificatesEnabled() bool {
+	return config.AppConfig.AcmeDirectoryUrl != ""
+}
+
+// RequestCertificate queues a certificate for a custom domain that has just been verified and starts
+// obtaining it in the background. A domain that already has one, or is being issued one, is left alone.
+func (s *CertificateService) RequestCertificate(userID uuid.UUID, domain string) error {
+	if !CertificatesEnabled() {
+		return nil
+	}
+	domain = normalizeHost(domain)
+
+	var certificate models.DomainCertificate
+	err := s.database.Where("domain = ?", domain).First(&certificate).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		certificate = models.DomainCertificate{
+			UserID: userID,
+			Domain: domain,
+			Status: models.CertificatePending,
+		}
+		result := s.database.Clauses(clause.OnConflict{DoNothing: true}).Create(&certificate)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+	case err != nil:
+		return err
+	case certificate.UserID == userID && certificate.Status != models.CertificateFailed:
+		return nil
+	default:
+		// Verifying again retries a certificate that failed, and a domain that moved to another account
+		// starts over without the previous owner's key
+		if err := s.database.Model(&certificate).Updates(map[string]interface{}{
+			"user_id":         userID,
+			"status":          models.CertificatePending,
+			"attempts":        0,
+			"error":           nil,
+			"next_attempt_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+		if certificate.UserID != userID {
+			if err := s.database.Model(&certificate).Updates(map[string]interface{}{
+				"certificate": "",
+				"private_key": "",
+				"issued_at":   nil,
+				"renew_at":    nil,
+				"expires_at":  nil,
+			}).Error; err != nil {
+				return err
+			}
+			invalidateCertificate(domain)
+		}
+	}
+
+	go s.process(certificate.ID)
+	return nil
+}
+
+// GetDomainCertificate returns the certificate for a custom domain, or nil when none was requested.
+func (s *CertificateService) GetDomainCertificate(domain string) (*models.DomainCertificate, error) {
+	if domain == "" {
+		return nil, nil
+	}
+
+	var certificate models.DomainCertificate
+	if err := s.database.Where("domain = ?", normalizeHost(domain)).First(&certificate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// RemoveCertificate deletes a custom domain's certificate once the domain is removed or replaced.
+func (s *CertificateService) RemoveCertificate(domain string) error {
+	domain = normalizeHost(domain)
+	if domain == "" {
+		return nil
+	}
+	if err := s.database.Where("domain = ?", domain).Delete(&models.DomainCertificate{}).Error; err != nil {
+		return err
+	}
+	invalidateCertificate(domain)
+	return nil
+}
+
+// ProcessCertificates obtains certificates that are queued, due another attempt or close to expiring, and
+// deletes those whose domain is no longer a verified custom domain of the account.
+func (s *CertificateService) ProcessCertificates() error {
+	if !CertificatesEnabled() {
+		return nil
+	}
+
+	if err := s.removeUnverifiedCertificates(); err != nil {
+		return err
+	}
+	if err := s.database.Where("expires_at < ?", time.Now()).Delete(&models.ACMEChallenge{}).Error; err != nil {
+		return err
+	}
+
+	var ids []uuid.UUID
+	if err := dueCertificates(s.database.Model(&models.DomainCertificate{}), time.Now()).
+		Order("created_at ASC").
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		s.process(id)
+	}
+	return nil
+}
+
+// ChallengeResponse returns the key authorization that answers an HTTP-01 challenge for host.
+func (s *CertificateService) ChallengeResponse(host, token string) (string, error) {
+	var challenge models.ACMEChallenge
+	if err := s.database.Where("token = ? AND expires_at > ?", token, time.Now()).First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrChallengeNotFound
+		}
+		return "", err
+	}
+	if challenge.Domain != normalizeHost(host) {
+		return "", ErrChallengeNotFound
+	}
+	return challenge.KeyAuthorization, nil
+}
+
+// TLSConfig is the configuration for the HTTPS listener. Custom domains get the certificate issued for
+// them; every other name gets the server's own certificate from TLS_CERT_FILE and TLS_KEY_FILE, if set.
+func (s *CertificateService) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: s.GetCertificate,
+	}
+	if config.AppConfig.TLSCertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.AppConfig.TLSCertFile, config.AppConfig.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+// GetCertificate is the tls.Config.GetCertificate lookup. It returns nil for names without an issued
+// certificate so the handshake falls back to the configured certificates.
+func (s *CertificateService) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	domain := normalizeHost(hello.ServerName)
+	if !isCustomDomainCandidate(domain) {
+		return nil, nil
+	}
+
+	certificateCache.Lock()
+	cached, ok := certificateCache.certificates[domain]
+	certificateCache.Unlock()
+	if ok && time.Since(cached.cachedAt) < certificateCacheTTL {
+		return cached.certificate, nil
+	}
+
+	certificate, err := s.loadCertificate(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	certificateCache.Lock()
+	if len(certificateCache.certificates) >= certificateCacheSize {
+		for cachedDomain, entry := range certificateCache.certificates {
+			if time.Since(entry.cachedAt) >= certificateCacheTTL {
+				delete(certificateCache.certificates, cachedDomain)
+			}
+		}
+		if len(certificateCache.certificates) >= certificateCacheSize {
+			certificateCache.certificates = map[string]cachedCertificate{}
+		}
+	}
+	certificateCache.certificates[domain] = cachedCertificate{certificate: certificate, cachedAt: time.Now()}
+	certificateCache.Unlock()
+	return certificate, nil
+}
+
+func (s *CertificateService) loadCertificate(domain string) (*tls.Certificate, error) {
+	var stored models.DomainCertificate
+	if err := s.database.Where("domain = ? AND certificate <> ''", domain).First(&stored).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	certificate, err := tls.X509KeyPair([]byte(stored.Certificate), []byte(stored.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate for %s: %w", domain, err)
+	}
+	return &certificate, nil
+}
+
+func (s *CertificateService) removeUnverifiedCertificates() error {
+	var domains []string
+	if err := s.database.Model(&models.DomainCertificate{}).
+		Where("NOT EXISTS (SELECT 1 FROM users WHERE users.id = domain_certificates.user_id AND users.domain->>'custom_domain' = domain_certificates.domain AND users.domain->>'custom_domain_status' = ?)", models.DomainStatusVerified).
+		Pluck("domain", &domains).Error; err != nil {
+		return err
+	}
+
+	for _, domain := range domains {
+		if err := s.RemoveCertificate(domain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dueCertificates narrows query to certificates that should be obtained now: queued ones, failed ones
+// whose retry is due, issued ones due for renewal and ones whose issuance was abandoned midway.
+func dueCertificates(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("status = ? OR (status = ? AND next_attempt_at <= ?) OR (status = ? AND renew_at <= ?) OR (status = ? AND started_at < ?)",
+		models.CertificatePending,
+		models.CertificateFailed, now,
+		models.CertificateIssued, now,
+		models.CertificateProcessing, now.Add(-certificateStaleAfter))
+}
+
+func (s *CertificateService) process(certificateID uuid.UUID) {
+	// Claiming the certificate first keeps servers running the cron at the same time from ordering it twice
+	now := time.Now()
+	result := dueCertificates(s.database.Model(&models.DomainCertificate{}).Where("id = ?", certificateID), now).
+		Updates(map[string]interface{}{"status": models.CertificateProcessing, "started_at": now})
+	if result.Error != nil {
+		log.Printf("Failed to start certificate %s: %v", certificateID, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	var certificate models.DomainCertificate
+	if err := s.database.First(&certificate, "id = ?", certificateID).Error; err != nil {
+		log.Printf("Failed to load certificate %s: %v", certificateID, err)
+		return
+	}
+
+	issued, err := s.obtain(certificate.Domain)
+	if err != nil {
+		log.Printf("Failed to obtain certificate for %s: %v", certificate.Domain, err)
+
+		message := err.Error()
+		updates := map[string]interface{}{
+			"status":          models.CertificateFailed,
+			"attempts":        certificate.Attempts + 1,
+			"error":           &message,
+			"next_attempt_at": nil,
+		}
+		// Retries back off from an hour to a day, then stop until the domain is verified again
+		if certificate.Attempts+1 < certificateMaxAttempts {
+			delay := min(time.Hour<<certificate.Attempts, 24*time.Hour)
+			updates["next_attempt_at"] = time.Now().Add(delay)
+		}
+		if err := s.database.Model(&certificate).Updates(updates).Error; err != nil {
+			log.Printf("Failed to record certificate failure for %s: %v", certificate.Domain, err)
+		}
+		return
+	}
+
+	issuedAt := time.Now()
+	if err := s.database.Model(&certificate).Updates(map[string]interface{}{
+		"status":          models.CertificateIssued,
+		"certificate":     issued.certificate,
+		"private_key":     issued.privateKey,
+		"attempts":        0,
+		"error":           nil,
+		"next_attempt_at": nil,
+		"issued_at":       issuedAt,
+		"renew_at":        issued.renewAt,
+		"expires_at":      issued.expiresAt,
+	}).Error; err != nil {
+		log.Printf("Failed to save certificate for %s: %v", certificate.Domain, err)
+		return
+	}
+	invalidateCertificate(certificate.Domain)
+	log.Printf("Issued certificate for %s, valid until %s", certificate.Domain, issued.expiresAt.Format(time.RFC3339))
+}
+
+type issuedCertificate struct {
+	certificate string
+	privateKey  string
+	renewAt     time.Time
+	expiresAt   time.Time
+}
+
+// obtain orders a certificate for domain, proving control of it with HTTP-01 challenges answered by
+// ChallengeResponse.
+func (s *CertificateService) obtain(domain string) (*issuedCertificate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), certificateTimeout)
+	defer cancel()
+
+	client, err := s.acmeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(domain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	for _, authorizationURL := range order.AuthzURLs {
+		if err := s.authorize(ctx, client, domain, authorizationURL); err != nil {
+			return nil, err
+		}
+	}
+
+	orderURL := order.URI
+	order, err = client.WaitOrder(ctx, orderURL)
+	if err != nil {
+		return nil, fmt.Errorf("order was not ready: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domain},
+		DNSNames: []string{domain},
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		// The client can only wait for issuance when the CA answers the finalize request with the order's
+		// URL, which Pebble doesn't, so the order is polled here before giving up
+		finalized, waitErr := client.WaitOrder(ctx, orderURL)
+		if waitErr != nil || finalized.CertURL == "" {
+			return nil, fmt.Errorf("failed to finalize order: %w", err)
+		}
+		if chain, err = client.FetchCert(ctx, finalized.CertURL, true); err != nil {
+			return nil, fmt.Errorf("failed to fetch certificate: %w", err)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("the CA returned no certificate")
+	}
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, fmt.Errorf("the CA returned an invalid certificate: %w", err)
+	}
+
+	var certificatePEM strings.Builder
+	for _, der := range chain {
+		if err := pem.Encode(&certificatePEM, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+			return nil, err
+		}
+	}
+	privateKeyPEM, err := encodeECKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	renewBefore := min(certificateRenewBefore, leaf.NotAfter.Sub(leaf.NotBefore)/3)
+	return &issuedCertificate{
+		certificate: certificatePEM.String(),
+		privateKey:  privateKeyPEM,
+		renewAt:     leaf.NotAfter.Add(-renewBefore),
+		expiresAt:   leaf.NotAfter,
+	}, nil
+}
+
+// authorize completes one authorization of an order with its HTTP-01 challenge. The response is stored
+// for as long as the CA might ask for it.
+func (s *CertificateService) authorize(ctx context.Context, client *acme.Client, domain, authorizationURL string) error {
+	authorization, err := client.GetAuthorization(ctx, authorizationURL)
+	if err != nil {
+		return fmt.Errorf("failed to get authorization: %w", err)
+	}
+	if authorization.Status == acme.StatusValid {
+		return nil
+	}
+
+	var challenge *acme.Challenge
+	for _, offered := range authorization.Challenges {
+		if offered.Type == "http-01" {
+			challenge = offered
+			break
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("the CA offered no http-01 challenge for %s", domain)
+	}
+
+	keyAuthorization, err := client.HTTP01ChallengeResponse(challenge.Token)
+	if err != nil {
+		return err
+	}
+	if err := s.database.Create(&models.ACMEChallenge{
+		Token:            challenge.Token,
+		Domain:           domain,
+		KeyAuthorization: keyAuthorization,
+		ExpiresAt:        time.Now().Add(acmeChallengeTTL),
+	}).Error; err != nil {
+		return err
+	}
+	defer func() {
+		if err := s.database.Delete(&models.ACMEChallenge{}, "token = ?", challenge.Token).Error; err != nil {
+			log.Printf("Failed to delete ACME challenge for %s: %v", domain, err)
+		}
+	}()
+
+	if _, err := client.Accept(ctx, challenge); err != nil {
+		return fmt.Errorf("failed to accept challenge: %w", err)
+	}
+	if _, err := client.WaitAuthorization(ctx, authorization.URI); err != nil {
+		return fmt.Errorf("failed to validate %s: %w", domain, err)
+	}
+	return nil
+}
+
+// acmeClient returns a client for the configured directory, signed with the account every server shares.
+// The account is registered the first time it is needed.
+func (s *CertificateService) acmeClient(ctx context.Context) (*acme.Client, error) {
+	httpClient, err := acmeHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	client := &acme.Client{
+		DirectoryURL: config.AppConfig.AcmeDirectoryUrl,
+		HTTPClient:   httpClient,
+		UserAgent:    "foglio",
+	}
+
+	var account models.ACMEAccount
+	err = s.database.Where("directory_url = ?", client.DirectoryURL).First(&account).Error
+	if err == nil {
+		block, _ := pem.Decode([]byte(account.PrivateKey))
+		if block == nil {
+			return nil, errors.New("invalid ACME account key")
+		}
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ACME account key: %w", err)
+		}
+		client.Key = key
+		return client, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	client.Key = key
+
+	registration := &acme.Account{}
+	if config.AppConfig.AcmeEmail != "" {
+		registration.Contact = []string{"mailto:" + config.AppConfig.AcmeEmail}
+	}
+	registered, err := client.Register(ctx, registration, acme.AcceptTOS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register ACME account: %w", err)
+	}
+
+	privateKeyPEM, err := encodeECKey(key)
+	if err != nil {
+		return nil, err
+	}
+	account = models.ACMEAccount{
+		DirectoryURL: client.DirectoryURL,
+		URI:          registered.URI,
+		Email:        config.AppConfig.AcmeEmail,
+		PrivateKey:   privateKeyPEM,
+	}
+	// Another server may have registered at the same moment; every server uses whichever was stored first
+	result := s.database.Clauses(clause.OnConflict{DoNothing: true}).Create(&account)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return s.acmeClient(ctx)
+	}
+	return client, nil
+}
+
+// acmeHTTPClient trusts the CA bundle in ACME_CA_FILE for talking to the directory, as a local Pebble
+// server needs. Without one the system roots are used.
+func acmeHTTPClient() (*http.Client, error) {
+	if config.AppConfig.AcmeCaFile == "" {
+		return nil, nil
+	}
+
+	bundle, err := os.ReadFile(config.AppConfig.AcmeCaFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ACME CA file: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(bundle) {
+		return nil, errors.New("ACME CA file has no certificates")
+	}
+
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+		},
+	}, nil
+}
+
+func encodeECKey(key *ecdsa.PrivateKey) (string, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})), nil
+}
+
+// invalidateCertificate drops the cached certificate for a domain so the next handshake reads the database.
+func invalidateCertificate(domain string) {
+	certificateCache.Lock()
+	defer certificateCache.Unlock()
+	delete(certificateCache.certificates, normalizeHost(domain))
+}
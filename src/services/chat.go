@@ -6,19 +6,38 @@ import (
 	"foglio/v2/src/lib"
 	"foglio/v2/src/models"
 	"log"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxGroupParticipants = 50
+	messageEditWindow    = 15 * time.Minute
 )
 
 var (
-	ErrConversationNotFound = errors.New("conversation not found")
-	ErrMessageNotFound      = errors.New("message not found")
-	ErrNotParticipant       = errors.New("you are not a participant in this conversation")
-	ErrCannotMessageSelf    = errors.New("you cannot send a message to yourself")
-	ErrRecipientNotFound    = errors.New("recipient not found")
-	ErrEmptyMessage         = errors.New("message must have content or media")
+	ErrConversationNotFound  = errors.New("conversation not found")
+	ErrMessageNotFound       = errors.New("message not found")
+	ErrNotParticipant        = errors.New("you are not a participant in this conversation")
+	ErrCannotMessageSelf     = errors.New("you cannot send a message to yourself")
+	ErrRecipientNotFound     = errors.New("recipient not found")
+	ErrEmptyMessage          = errors.New("message must have content or media")
+	ErrMissingMessageTarget  = errors.New("recipient_id or conversation_id is required")
+	ErrNotGroupConversation  = errors.New("this action is only available for group conversations")
+	ErrNotConversationOwner  = errors.New("only a group owner can perform this action")
+	ErrParticipantNotFound   = errors.New("user is not a participant in this conversation")
+	ErrAlreadyParticipant    = errors.New("user is already a participant in this conversation")
+	ErrGroupParticipantLimit = errors.New("group conversations are limited to 50 participants")
+	ErrGroupNeedsMembers     = errors.New("a group conversation needs at least one other participant")
+	ErrNotMessageSender      = errors.New("you can only modify your own messages")
+	ErrEditWindowExpired     = errors.New("messages can only be edited within 15 minutes of sending")
+	ErrMessageDeleted        = errors.New("this message has been deleted")
+	ErrInvalidReplyTarget    = errors.New("replies must reference a message in the same conversation")
+	ErrReactionNotFound      = errors.New("reaction not found")
 )
 
 type ChatService struct {
@@ -37,30 +56,54 @@ func NewChatService(database *gorm.DB, hub *lib.Hub, notificationService *Notifi
 
 func (s *ChatService) SendMessage(senderID string, payload dto.SendMessageDto) (*models.Message, error) {
 	senderUUID := uuid.Must(uuid.Parse(senderID))
-	recipientUUID, err := uuid.Parse(payload.RecipientID)
-	if err != nil {
-		return nil, errors.New("invalid recipient ID")
-	}
-
-	if senderUUID == recipientUUID {
-		return nil, ErrCannotMessageSelf
-	}
 
 	if payload.Content == "" && len(payload.Media) == 0 {
 		return nil, ErrEmptyMessage
 	}
 
-	var recipient models.User
-	if err := s.database.Where("id = ?", recipientUUID).First(&recipient).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrRecipientNotFound
+	var conversation *models.Conversation
+	switch {
+	case payload.ConversationID != "":
+		var err error
+		if conversation, err = s.loadConversation(payload.ConversationID, senderUUID); err != nil {
+			return nil, err
 		}
-		return nil, err
+		if err := s.ensureCanMessage(senderUUID, conversation); err != nil {
+			return nil, err
+		}
+	case payload.RecipientID != "":
+		recipientUUID, err := uuid.Parse(payload.RecipientID)
+		if err != nil {
+			return nil, errors.New("invalid recipient ID")
+		}
+		if senderUUID == recipientUUID {
+			return nil, ErrCannotMessageSelf
+		}
+
+		var recipient models.User
+		if err := s.database.Where("id = ?", recipientUUID).First(&recipient).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrRecipientNotFound
+			}
+			return nil, err
+		}
+
+		if err := s.ensureCanMessageUser(senderUUID, recipientUUID); err != nil {
+			return nil, err
+		}
+		if conversation, err = s.findOrCreateConversation(senderUUID, recipientUUID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrMissingMessageTarget
 	}
 
-	conversation, err := s.findOrCreateConversation(senderUUID, recipientUUID)
-	if err != nil {
-		return nil, err
+	var replyToID *uuid.UUID
+	if payload.ReplyToID != "" {
+		var err error
+		if replyToID, err = s.resolveReplyTarget(conversation.ID, payload.ReplyToID); err != nil {
+			return nil, err
+		}
 	}
 
 	var media models.MessageMediaList
@@ -79,13 +122,22 @@ func (s *ChatService) SendMessage(senderID string, payload dto.SendMessageDto) (
 		})
 	}
 
+	media, err := s.resolveMedia(senderUUID, conversation.ID, media)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range media {
+		if m.URL == "" {
+			return nil, ErrInvalidAttachment
+		}
+	}
+
 	message := &models.Message{
 		ConversationID: conversation.ID,
 		SenderID:       senderUUID,
-		RecipientID:    recipientUUID,
 		Content:        payload.Content,
 		Media:          media,
-		Status:         models.MessageStatusSent,
+		ReplyToID:      replyToID,
 	}
 
 	if err := s.database.Create(message).Error; err != nil {
@@ -94,9 +146,17 @@ func (s *ChatService) SendMessage(senderID string, payload dto.SendMessageDto) (
 
 	s.database.Model(conversation).Update("updated_at", time.Now())
 
-	s.database.Preload("Sender").First(message, "id = ?", message.ID)
+	// The sender has implicitly read everything up to their own message.
+	s.database.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", conversation.ID, senderUUID).
+		Updates(map[string]interface{}{
+			"last_read_at":         message.CreatedAt,
+			"last_read_message_id": message.ID,
+		})
 
-	go s.sendMessageNotification(message, &recipient)
+	s.database.Preload("Sender").Preload("ReplyTo").First(message, "id = ?", message.ID)
+
+	go s.sendMessageNotification(message, conversation)
 
 	return message, nil
 }
@@ -114,7 +174,7 @@ func (s *ChatService) GetConversations(userID string, page, limit int) (*dto.Con
 	var totalItems int64
 
 	query := s.database.Model(&models.Conversation{}).
-		Where("participant1 = ? OR participant2 = ?", userUUID, userUUID)
+		Joins("JOIN conversation_participants cp ON cp.conversation_id = conversations.id AND cp.user_id = ?", userUUID)
 
 	if err := query.Count(&totalItems).Error; err != nil {
 		return nil, err
@@ -122,18 +182,25 @@ func (s *ChatService) GetConversations(userID string, page, limit int) (*dto.Con
 
 	offset := (page - 1) * limit
 	if err := query.
-		Preload("User1").
-		Preload("User2").
-		Order("updated_at DESC").
+		Preload("Participants.User").
+		Order("conversations.updated_at DESC").
 		Offset(offset).
 		Limit(limit).
 		Find(&conversations).Error; err != nil {
 		return nil, err
 	}
 
+	var otherUsers []models.User
+	for _, conv := range conversations {
+		if other := conv.GetOtherParticipant(userUUID); !conv.IsGroup() && other != nil {
+			otherUsers = append(otherUsers, other.User)
+		}
+	}
+	presence := s.resolvePresence(otherUsers)
+
 	responses := make([]dto.ConversationResponse, len(conversations))
 	for i, conv := range conversations {
-		responses[i] = s.toConversationResponse(&conv, userUUID)
+		responses[i] = s.toConversationResponse(&conv, userUUID, presence)
 	}
 
 	totalPages := 0
@@ -153,23 +220,12 @@ func (s *ChatService) GetConversations(userID string, page, limit int) (*dto.Con
 func (s *ChatService) GetConversation(userID, conversationID string) (*dto.ConversationResponse, error) {
 	userUUID := uuid.Must(uuid.Parse(userID))
 
-	var conversation models.Conversation
-	if err := s.database.
-		Preload("User1").
-		Preload("User2").
-		Where("id = ?", conversationID).
-		First(&conversation).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrConversationNotFound
-		}
+	conversation, err := s.loadConversation(conversationID, userUUID)
+	if err != nil {
 		return nil, err
 	}
 
-	if !conversation.IsParticipant(userUUID) {
-		return nil, ErrNotParticipant
-	}
-
-	response := s.toConversationResponse(&conversation, userUUID)
+	response := s.toConversationResponse(conversation, userUUID, nil)
 	return &response, nil
 }
 
@@ -192,385 +248,1302 @@ func (s *ChatService) GetOrCreateConversation(userID, otherUserID string) (*dto.
 		return nil, err
 	}
 
+	if err := s.ensureCanMessageUser(userUUID, otherUUID); err != nil {
+		return nil, err
+	}
+
 	conversation, err := s.findOrCreateConversation(userUUID, otherUUID)
 	if err != nil {
 		return nil, err
 	}
 
-	s.database.Preload("User1").Preload("User2").First(conversation, "id = ?", conversation.ID)
-
-	response := s.toConversationResponse(conversation, userUUID)
+	response := s.toConversationResponse(conversation, userUUID, nil)
 	return &response, nil
 }
 
-func (s *ChatService) GetMessages(userID, conversationID string, page, limit int) (*dto.MessageListResponse, error) {
-	if limit <= 0 {
-		limit = 50
+func (s *ChatService) CreateGroupConversation(ownerID string, payload dto.CreateGroupConversationDto) (*dto.ConversationResponse, error) {
+	ownerUUID := uuid.Must(uuid.Parse(ownerID))
+
+	if err := s.ensureCanChat(ownerUUID); err != nil {
+		return nil, err
 	}
-	if page <= 0 {
-		page = 1
+
+	memberIDs, err := s.resolveNewParticipants(ownerUUID, payload.ParticipantIDs, map[uuid.UUID]bool{ownerUUID: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(memberIDs) == 0 {
+		return nil, ErrGroupNeedsMembers
+	}
+	if len(memberIDs)+1 > maxGroupParticipants {
+		return nil, ErrGroupParticipantLimit
 	}
 
-	userUUID := uuid.Must(uuid.Parse(userID))
+	now := time.Now()
+	name := payload.Name
+	conversation := models.Conversation{
+		Type:        models.ConversationTypeGroup,
+		Name:        &name,
+		CreatedByID: &ownerUUID,
+	}
 
-	var conversation models.Conversation
-	if err := s.database.Where("id = ?", conversationID).First(&conversation).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrConversationNotFound
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&conversation).Error; err != nil {
+			return err
+		}
+
+		participants := []models.ConversationParticipant{{
+			ConversationID: conversation.ID,
+			UserID:         ownerUUID,
+			Role:           models.ParticipantRoleOwner,
+			LastReadAt:     &now,
+			JoinedAt:       now,
+		}}
+		for _, memberID := range memberIDs {
+			participants = append(participants, models.ConversationParticipant{
+				ConversationID: conversation.ID,
+				UserID:         memberID,
+				Role:           models.ParticipantRoleMember,
+				LastReadAt:     &now,
+				JoinedAt:       now,
+			})
 		}
+		return tx.Create(&participants).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	if !conversation.IsParticipant(userUUID) {
-		return nil, ErrNotParticipant
+	loaded, err := s.loadConversation(conversation.ID.String(), ownerUUID)
+	if err != nil {
+		return nil, err
 	}
 
-	var messages []models.Message
-	var totalItems int64
+	go s.notifyParticipants(loaded, ownerUUID, "conversation_created", "New Group Conversation", map[string]interface{}{
+		"conversation_id": loaded.ID.String(),
+		"name":            name,
+		"created_by":      ownerID,
+	})
+
+	response := s.toConversationResponse(loaded, ownerUUID, nil)
+	return &response, nil
+}
 
-	query := s.database.Model(&models.Message{}).Where("conversation_id = ?", conversationID)
+func (s *ChatService) AddParticipants(userID, conversationID string, payload dto.AddParticipantsDto) (*dto.ConversationResponse, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
 
-	if err := query.Count(&totalItems).Error; err != nil {
+	conversation, err := s.loadGroupAsOwner(conversationID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureCanChat(userUUID); err != nil {
 		return nil, err
 	}
 
-	offset := (page - 1) * limit
-	if err := query.
-		Preload("Sender").
-		Order("created_at DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&messages).Error; err != nil {
+	existing := make(map[uuid.UUID]bool, len(conversation.Participants))
+	for _, participant := range conversation.Participants {
+		existing[participant.UserID] = true
+	}
+
+	memberIDs, err := s.resolveNewParticipants(userUUID, payload.UserIDs, existing)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberIDs) == 0 {
+		return nil, ErrAlreadyParticipant
+	}
+	if len(conversation.Participants)+len(memberIDs) > maxGroupParticipants {
+		return nil, ErrGroupParticipantLimit
+	}
+
+	now := time.Now()
+	participants := make([]models.ConversationParticipant, len(memberIDs))
+	for i, memberID := range memberIDs {
+		participants[i] = models.ConversationParticipant{
+			ConversationID: conversation.ID,
+			UserID:         memberID,
+			Role:           models.ParticipantRoleMember,
+			LastReadAt:     &now,
+			JoinedAt:       now,
+		}
+	}
+	if err := s.database.Create(&participants).Error; err != nil {
 		return nil, err
 	}
+	s.database.Model(conversation).Update("updated_at", now)
 
-	responses := make([]dto.MessageResponse, len(messages))
-	for i, msg := range messages {
-		responses[i] = s.toMessageResponse(&msg)
+	if conversation, err = s.loadConversation(conversationID, userUUID); err != nil {
+		return nil, err
 	}
 
-	totalPages := 0
-	if limit > 0 {
-		totalPages = int((totalItems + int64(limit) - 1) / int64(limit))
+	added := make([]string, len(memberIDs))
+	for i, memberID := range memberIDs {
+		added[i] = memberID.String()
 	}
+	go s.notifyParticipants(conversation, userUUID, "participants_added", "Participants Added", map[string]interface{}{
+		"conversation_id": conversation.ID.String(),
+		"user_ids":        added,
+		"added_by":        userID,
+	})
 
-	return &dto.MessageListResponse{
-		Data:       responses,
-		TotalItems: int(totalItems),
-		TotalPages: totalPages,
-		Page:       page,
-		Limit:      limit,
-	}, nil
+	response := s.toConversationResponse(conversation, userUUID, nil)
+	return &response, nil
 }
 
-func (s *ChatService) MarkMessagesAsRead(userID, conversationID string) error {
+// RemoveParticipant lets an owner remove any member, or any member leave the group. When the last owner
+// leaves, the longest-standing member is promoted; when nobody is left the group is deleted.
+func (s *ChatService) RemoveParticipant(userID, conversationID, targetID string) error {
 	userUUID := uuid.Must(uuid.Parse(userID))
+	targetUUID, err := uuid.Parse(targetID)
+	if err != nil {
+		return ErrParticipantNotFound
+	}
 
-	var conversation models.Conversation
-	if err := s.database.Where("id = ?", conversationID).First(&conversation).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ErrConversationNotFound
-		}
+	conversation, err := s.loadConversation(conversationID, userUUID)
+	if err != nil {
 		return err
 	}
+	if !conversation.IsGroup() {
+		return ErrNotGroupConversation
+	}
 
-	if !conversation.IsParticipant(userUUID) {
-		return ErrNotParticipant
+	actor := conversation.GetParticipant(userUUID)
+	target := conversation.GetParticipant(targetUUID)
+	if target == nil {
+		return ErrParticipantNotFound
+	}
+	if userUUID != targetUUID && actor.Role != models.ParticipantRoleOwner {
+		return ErrNotConversationOwner
 	}
 
-	now := time.Now()
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(target).Error; err != nil {
+			return err
+		}
 
-	result := s.database.Model(&models.Message{}).
-		Where("conversation_id = ? AND recipient_id = ? AND status != ?", conversationID, userUUID, models.MessageStatusRead).
-		Updates(map[string]interface{}{
-			"status":  models.MessageStatusRead,
-			"read_at": now,
-		})
+		var remaining []models.ConversationParticipant
+		if err := tx.Where("conversation_id = ?", conversation.ID).Order("joined_at ASC").Find(&remaining).Error; err != nil {
+			return err
+		}
+		if len(remaining) == 0 {
+			return tx.Delete(conversation).Error
+		}
 
-	if result.Error != nil {
-		return result.Error
+		for _, participant := range remaining {
+			if participant.Role == models.ParticipantRoleOwner {
+				return tx.Model(conversation).Update("updated_at", time.Now()).Error
+			}
+		}
+		if err := tx.Model(&remaining[0]).Update("role", models.ParticipantRoleOwner).Error; err != nil {
+			return err
+		}
+		return tx.Model(conversation).Update("updated_at", time.Now()).Error
+	})
+	if err != nil {
+		return err
 	}
 
-	if result.RowsAffected > 0 {
-		otherUserID := conversation.GetOtherParticipant(userUUID)
-		go s.sendReadReceipt(conversationID, userID, otherUserID.String())
+	data := map[string]interface{}{
+		"conversation_id": conversation.ID.String(),
+		"user_id":         targetID,
+		"removed_by":      userID,
 	}
+	go s.notifyParticipants(conversation, userUUID, "participant_removed", "Participant Removed", data)
 
 	return nil
 }
 
-func (s *ChatService) DeleteConversation(userID, conversationID string) error {
+func (s *ChatService) RenameConversation(userID, conversationID string, payload dto.RenameConversationDto) (*dto.ConversationResponse, error) {
 	userUUID := uuid.Must(uuid.Parse(userID))
 
-	var conversation models.Conversation
-	if err := s.database.Where("id = ?", conversationID).First(&conversation).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ErrConversationNotFound
-		}
-		return err
+	conversation, err := s.loadGroupAsOwner(conversationID, userUUID)
+	if err != nil {
+		return nil, err
 	}
 
-	if !conversation.IsParticipant(userUUID) {
-		return ErrNotParticipant
+	name := payload.Name
+	if err := s.database.Model(conversation).Update("name", name).Error; err != nil {
+		return nil, err
 	}
+	conversation.Name = &name
 
-	return s.database.Delete(&conversation).Error
-}
-
-func (s *ChatService) GetUnreadCount(userID string) (int64, error) {
-	userUUID := uuid.Must(uuid.Parse(userID))
-	var count int64
-
-	err := s.database.Model(&models.Message{}).
-		Where("recipient_id = ? AND status != ?", userUUID, models.MessageStatusRead).
-		Count(&count).Error
+	go s.notifyParticipants(conversation, userUUID, "conversation_renamed", "Conversation Renamed", map[string]interface{}{
+		"conversation_id": conversation.ID.String(),
+		"name":            name,
+		"renamed_by":      userID,
+	})
 
-	return count, err
+	response := s.toConversationResponse(conversation, userUUID, nil)
+	return &response, nil
 }
 
-func (s *ChatService) findOrCreateConversation(user1, user2 uuid.UUID) (*models.Conversation, error) {
-	var conversation models.Conversation
-
-	p1, p2 := user1, user2
-	if p1.String() > p2.String() {
-		p1, p2 = p2, p1
+// GetMessages lists a conversation's messages newest first. Offset pages are used unless a cursor is
+// given: before/after page relative to a message ID, and around returns a window centred on one.
+func (s *ChatService) GetMessages(userID, conversationID string, params dto.ChatQueryParams) (*dto.MessageListResponse, error) {
+	limit := params.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 50
 	}
 
-	err := s.database.Where(
-		"(participant1 = ? AND participant2 = ?) OR (participant1 = ? AND participant2 = ?)",
-		p1, p2, p2, p1,
-	).First(&conversation).Error
+	userUUID := uuid.Must(uuid.Parse(userID))
 
+	conversation, err := s.loadConversation(conversationID, userUUID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
 
-			conversation = models.Conversation{
-				Participant1: p1,
-				Participant2: p2,
-			}
-			if err := s.database.Create(&conversation).Error; err != nil {
-				return nil, err
-			}
-			return &conversation, nil
-		}
+	var totalItems int64
+	if err := s.visibleMessages(userUUID).Where("conversation_id = ?", conversation.ID).Count(&totalItems).Error; err != nil {
 		return nil, err
 	}
 
-	return &conversation, nil
-}
+	response := &dto.MessageListResponse{TotalItems: int(totalItems), Limit: limit}
+	var messages []models.Message
 
-func (s *ChatService) toConversationResponse(conv *models.Conversation, userID uuid.UUID) dto.ConversationResponse {
-	var otherUser *models.User
-	if conv.Participant1 == userID {
-		otherUser = &conv.User2
-	} else {
-		otherUser = &conv.User1
-	}
+	switch {
+	case params.Around != "":
+		pivot, err := s.loadCursor(userUUID, conversation.ID, params.Around)
+		if err != nil {
+			return nil, err
+		}
+		older, hasOlder, err := s.pageMessages(userUUID, conversation.ID, pivot, true, true, limit-limit/2)
+		if err != nil {
+			return nil, err
+		}
+		newer, hasNewer, err := s.pageMessages(userUUID, conversation.ID, pivot, false, false, limit/2)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(newer, older...)
+		response.HasOlder, response.HasNewer = hasOlder, hasNewer
 
-	var lastMessage models.Message
-	s.database.Where("conversation_id = ?", conv.ID).Order("created_at DESC").First(&lastMessage)
+	case params.Before != "":
+		pivot, err := s.loadCursor(userUUID, conversation.ID, params.Before)
+		if err != nil {
+			return nil, err
+		}
+		if messages, response.HasOlder, err = s.pageMessages(userUUID, conversation.ID, pivot, true, false, limit); err != nil {
+			return nil, err
+		}
+		response.HasNewer = true
 
-	var unreadCount int64
-	s.database.Model(&models.Message{}).
-		Where("conversation_id = ? AND recipient_id = ? AND status != ?", conv.ID, userID, models.MessageStatusRead).
-		Count(&unreadCount)
+	case params.After != "":
+		pivot, err := s.loadCursor(userUUID, conversation.ID, params.After)
+		if err != nil {
+			return nil, err
+		}
+		if messages, response.HasNewer, err = s.pageMessages(userUUID, conversation.ID, pivot, false, false, limit); err != nil {
+			return nil, err
+		}
+		response.HasOlder = true
 
-	response := dto.ConversationResponse{
-		ID: conv.ID.String(),
-		OtherUser: &dto.UserSummary{
-			ID:       otherUser.ID.String(),
-			Name:     otherUser.Name,
-			Username: otherUser.Username,
-			Image:    otherUser.Image,
-		},
-		UnreadCount: int(unreadCount),
-		CreatedAt:   conv.CreatedAt,
-		UpdatedAt:   conv.UpdatedAt,
+	default:
+		page := params.Page
+		if page <= 0 {
+			page = 1
+		}
+
+		offset := (page - 1) * limit
+		if err := s.preloadMessageRelations(s.visibleMessages(userUUID)).
+			Where("conversation_id = ?", conversation.ID).
+			Order("created_at DESC, id DESC").
+			Offset(offset).
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return nil, err
+		}
+
+		response.Page = page
+		response.TotalPages = int((totalItems + int64(limit) - 1) / int64(limit))
+		response.HasOlder = int64(offset+len(messages)) < totalItems
+		response.HasNewer = page > 1
 	}
 
-	if lastMessage.ID != uuid.Nil {
-		msgResp := s.toMessageResponse(&lastMessage)
-		response.LastMessage = &msgResp
+	response.Data = s.toMessageResponses(messages, conversation)
+
+	if len(messages) > 0 {
+		if response.HasOlder {
+			response.OlderCursor = messages[len(messages)-1].ID.String()
+		}
+		if response.HasNewer {
+			response.NewerCursor = messages[0].ID.String()
+		}
 	}
 
-	return response
+	return response, nil
 }
 
-func (s *ChatService) toMessageResponse(msg *models.Message) dto.MessageResponse {
-	response := dto.MessageResponse{
-		ID:             msg.ID.String(),
-		ConversationID: msg.ConversationID.String(),
-		SenderID:       msg.SenderID.String(),
-		RecipientID:    msg.RecipientID.String(),
-		Content:        msg.Content,
-		Status:         msg.Status,
-		ReadAt:         msg.ReadAt,
-		DeliveredAt:    msg.DeliveredAt,
-		CreatedAt:      msg.CreatedAt,
+func (s *ChatService) MarkMessagesAsRead(userID, conversationID string) error {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	conversation, err := s.loadConversation(conversationID, userUUID)
+	if err != nil {
+		return err
 	}
+	participant := conversation.GetParticipant(userUUID)
 
-	if len(msg.Media) > 0 {
-		response.Media = make([]dto.MediaDto, len(msg.Media))
-		for i, m := range msg.Media {
-			response.Media[i] = dto.MediaDto{
-				ID:        m.ID,
-				Type:      m.Type,
-				URL:       m.URL,
-				FileName:  m.FileName,
-				FileSize:  m.FileSize,
-				MimeType:  m.MimeType,
-				Width:     m.Width,
-				Height:    m.Height,
-				Duration:  m.Duration,
-				Thumbnail: m.Thumbnail,
-			}
+	var latest models.Message
+	if err := s.database.Where("conversation_id = ?", conversation.ID).Order("created_at DESC").First(&latest).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
 		}
+		return err
 	}
 
-	if msg.Sender.ID != uuid.Nil {
-		response.Sender = &dto.UserSummary{
-			ID:       msg.Sender.ID.String(),
-			Name:     msg.Sender.Name,
-			Username: msg.Sender.Username,
-			Image:    msg.Sender.Image,
-		}
+	if participant.LastReadAt != nil && !latest.CreatedAt.After(*participant.LastReadAt) {
+		return nil
 	}
 
-	return response
-}
+	now := time.Now()
+	if err := s.database.Model(participant).Updates(map[string]interface{}{
+		"last_read_at":         now,
+		"last_read_message_id": latest.ID,
+	}).Error; err != nil {
+		return err
+	}
 
-func (s *ChatService) sendMessageNotification(message *models.Message, recipient *models.User) {
-	var sender models.User
-	s.database.Where("id = ?", message.SenderID).First(&sender)
+	go s.sendReadReceipt(conversation, userID, now)
 
-	msgResp := s.toMessageResponse(message)
+	return nil
+}
 
-	if s.hub != nil {
-		notification := models.Notification{
-			ID:      uuid.New(),
-			Title:   "New Message",
-			Content: sender.Name + " sent you a message",
-			Type:    models.NewMessage,
-			OwnerID: recipient.ID,
-			IsRead:  false,
-			Data: map[string]interface{}{
-				"event_type":      "new_message",
-				"conversation_id": message.ConversationID.String(),
-				"message_id":      message.ID.String(),
-				"message":         msgResp,
-				"sender_id":       sender.ID.String(),
-				"sender_name":     sender.Name,
-			},
-		}
-		s.hub.SendToUser(recipient.ID.String(), notification)
+func (s *ChatService) DeleteConversation(userID, conversationID string) error {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	conversation, err := s.loadConversation(conversationID, userUUID)
+	if err != nil {
+		return err
 	}
 
-	if s.notificationService != nil {
-		err := s.notificationService.SendRealTimeNotification(
-			recipient.ID.String(),
-			"New Message",
-			sender.Name+" sent you a message",
-			models.NewMessage,
-			map[string]interface{}{
-				"conversation_id": message.ConversationID.String(),
-				"message_id":      message.ID.String(),
-				"sender_id":       sender.ID.String(),
-				"sender_name":     sender.Name,
-			},
-		)
-		if err != nil {
-			log.Printf("Failed to send message notification: %v", err)
-		}
+	if conversation.IsGroup() && conversation.GetParticipant(userUUID).Role != models.ParticipantRoleOwner {
+		return ErrNotConversationOwner
 	}
+
+	return s.database.Delete(conversation).Error
 }
 
-func (s *ChatService) sendReadReceipt(conversationID, readerID, recipientID string) {
-	if s.hub != nil {
-		notification := models.Notification{
+func (s *ChatService) EditMessage(userID, messageID string, payload dto.EditMessageDto) (*dto.MessageResponse, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	message, conversation, err := s.loadMessage(messageID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if message.SenderID != userUUID {
+		return nil, ErrNotMessageSender
+	}
+	if message.IsDeletedForEveryone() {
+		return nil, ErrMessageDeleted
+	}
+	if time.Since(message.CreatedAt) > messageEditWindow {
+		return nil, ErrEditWindowExpired
+	}
+	if payload.Content == message.Content {
+		response := s.toMessageResponse(message, conversation)
+		return &response, nil
+	}
+
+	now := time.Now()
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		edit := models.MessageEdit{
+			MessageID: message.ID,
+			Content:   message.Content,
+			EditedAt:  now,
+		}
+		if err := tx.Create(&edit).Error; err != nil {
+			return err
+		}
+		return tx.Model(message).Updates(map[string]interface{}{
+			"content":   payload.Content,
+			"edited_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	message.Content = payload.Content
+	message.EditedAt = &now
+
+	response := s.toMessageResponse(message, conversation)
+	go s.notifyParticipants(conversation, userUUID, "message_edited", "Message Edited", map[string]interface{}{
+		"conversation_id": conversation.ID.String(),
+		"message_id":      message.ID.String(),
+		"message":         response,
+	})
+
+	return &response, nil
+}
+
+func (s *ChatService) GetMessageEdits(userID, messageID string) ([]models.MessageEdit, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	message, _, err := s.loadMessage(messageID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if message.IsDeletedForEveryone() {
+		return nil, ErrMessageDeleted
+	}
+
+	var edits []models.MessageEdit
+	if err := s.database.Where("message_id = ?", message.ID).Order("edited_at ASC").Find(&edits).Error; err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+// DeleteMessage hides a message for the user, or retracts it for every participant when forEveryone is set.
+// Only the sender may delete for everyone.
+func (s *ChatService) DeleteMessage(userID, messageID string, forEveryone bool) error {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	message, conversation, err := s.loadMessage(messageID, userUUID)
+	if err != nil {
+		return err
+	}
+
+	if !forEveryone {
+		deletion := models.MessageDeletion{MessageID: message.ID, UserID: userUUID}
+		return s.database.Clauses(clause.OnConflict{DoNothing: true}).Create(&deletion).Error
+	}
+
+	if message.SenderID != userUUID {
+		return ErrNotMessageSender
+	}
+	if message.IsDeletedForEveryone() {
+		return nil
+	}
+
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", message.ID).Delete(&models.MessageReaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("message_id = ?", message.ID).Delete(&models.MessageEdit{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(message).Updates(map[string]interface{}{
+			"content":                 "",
+			"media":                   nil,
+			"deleted_for_everyone_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	go s.notifyParticipants(conversation, userUUID, "message_deleted", "Message Deleted", map[string]interface{}{
+		"conversation_id": conversation.ID.String(),
+		"message_id":      message.ID.String(),
+	})
+
+	return nil
+}
+
+func (s *ChatService) AddReaction(userID, messageID string, payload dto.ReactionDto) ([]dto.ReactionSummary, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	message, conversation, err := s.loadMessage(messageID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if message.IsDeletedForEveryone() {
+		return nil, ErrMessageDeleted
+	}
+
+	reaction := models.MessageReaction{
+		MessageID: message.ID,
+		UserID:    userUUID,
+		Emoji:     payload.Emoji,
+	}
+	result := s.database.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	reactions, err := s.reactionSummary(message.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.RowsAffected > 0 {
+		go s.notifyParticipants(conversation, userUUID, "reaction_added", "Reaction Added", map[string]interface{}{
+			"conversation_id": conversation.ID.String(),
+			"message_id":      message.ID.String(),
+			"user_id":         userID,
+			"emoji":           payload.Emoji,
+			"reactions":       reactions,
+		})
+	}
+
+	return reactions, nil
+}
+
+func (s *ChatService) RemoveReaction(userID, messageID, emoji string) ([]dto.ReactionSummary, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	message, conversation, err := s.loadMessage(messageID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.database.
+		Where("message_id = ? AND user_id = ? AND emoji = ?", message.ID, userUUID, emoji).
+		Delete(&models.MessageReaction{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrReactionNotFound
+	}
+
+	reactions, err := s.reactionSummary(message.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.notifyParticipants(conversation, userUUID, "reaction_removed", "Reaction Removed", map[string]interface{}{
+		"conversation_id": conversation.ID.String(),
+		"message_id":      message.ID.String(),
+		"user_id":         userID,
+		"emoji":           emoji,
+		"reactions":       reactions,
+	})
+
+	return reactions, nil
+}
+
+func (s *ChatService) GetUnreadCount(userID string) (int64, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+	var count int64
+
+	err := s.unreadMessages(userUUID).Count(&count).Error
+
+	return count, err
+}
+
+// unreadMessages scopes messages to those the user has not read across all of their conversations.
+func (s *ChatService) unreadMessages(userID uuid.UUID) *gorm.DB {
+	return s.database.Model(&models.Message{}).
+		Joins("JOIN conversation_participants cp ON cp.conversation_id = messages.conversation_id AND cp.user_id = ?", userID).
+		Joins("JOIN conversations c ON c.id = messages.conversation_id AND c.deleted_at IS NULL").
+		Where("messages.sender_id <> ?", userID).
+		Where("cp.last_read_at IS NULL OR messages.created_at > cp.last_read_at")
+}
+
+// visibleMessages scopes messages to those the user has not deleted for themselves. Members added to a group
+// only see what was sent from when they joined, not the history before it.
+func (s *ChatService) visibleMessages(userID uuid.UUID) *gorm.DB {
+	return s.database.Model(&models.Message{}).
+		Where("NOT EXISTS (SELECT 1 FROM message_deletions md WHERE md.message_id = messages.id AND md.user_id = ?)", userID).
+		Where(`NOT EXISTS (SELECT 1 FROM conversation_participants cp JOIN conversations c ON c.id = cp.conversation_id
+			WHERE cp.conversation_id = messages.conversation_id AND cp.user_id = ? AND c.type = ? AND messages.created_at < cp.joined_at)`,
+			userID, models.ConversationTypeGroup)
+}
+
+// pageMessages loads up to limit visible messages older or newer than the pivot, newest first, and
+// reports whether more remain beyond them. A nil pivot starts from the latest message.
+func (s *ChatService) pageMessages(userID, conversationID uuid.UUID, pivot *models.Message, older, inclusive bool, limit int) ([]models.Message, bool, error) {
+	operator, order := "<", "created_at DESC, id DESC"
+	if !older {
+		operator, order = ">", "created_at ASC, id ASC"
+	}
+	if inclusive {
+		operator += "="
+	}
+
+	query := s.preloadMessageRelations(s.visibleMessages(userID)).Where("conversation_id = ?", conversationID)
+	if pivot != nil {
+		query = query.Where("(created_at, id) "+operator+" (?, ?)", pivot.CreatedAt, pivot.ID)
+	}
+
+	var messages []models.Message
+	if limit <= 0 {
+		return messages, false, nil
+	}
+	if err := query.Order(order).Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, false, err
+	}
+
+	more := len(messages) > limit
+	if more {
+		messages = messages[:limit]
+	}
+	if !older {
+		slices.Reverse(messages)
+	}
+	return messages, more, nil
+}
+
+// loadCursor resolves a message ID used for pagination, which must be visible to the user in the conversation.
+func (s *ChatService) loadCursor(userID, conversationID uuid.UUID, messageID string) (*models.Message, error) {
+	if _, err := uuid.Parse(messageID); err != nil {
+		return nil, ErrMessageNotFound
+	}
+
+	var message models.Message
+	if err := s.visibleMessages(userID).
+		Where("id = ? AND conversation_id = ?", messageID, conversationID).
+		First(&message).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (s *ChatService) preloadMessageRelations(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Sender").
+		Preload("ReplyTo").
+		Preload("Reactions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		})
+}
+
+// loadMessage fetches a message and its conversation, ensuring the user belongs to the conversation.
+func (s *ChatService) loadMessage(messageID string, userID uuid.UUID) (*models.Message, *models.Conversation, error) {
+	if _, err := uuid.Parse(messageID); err != nil {
+		return nil, nil, ErrMessageNotFound
+	}
+
+	var message models.Message
+	if err := s.preloadMessageRelations(s.database).Where("id = ?", messageID).First(&message).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrMessageNotFound
+		}
+		return nil, nil, err
+	}
+
+	conversation, err := s.loadConversation(message.ConversationID.String(), userID)
+	if err != nil {
+		if err == ErrConversationNotFound {
+			return nil, nil, ErrMessageNotFound
+		}
+		return nil, nil, err
+	}
+
+	return &message, conversation, nil
+}
+
+func (s *ChatService) resolveReplyTarget(conversationID uuid.UUID, replyToID string) (*uuid.UUID, error) {
+	id, err := uuid.Parse(replyToID)
+	if err != nil {
+		return nil, ErrInvalidReplyTarget
+	}
+
+	var count int64
+	if err := s.database.Model(&models.Message{}).
+		Where("id = ? AND conversation_id = ?", id, conversationID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrInvalidReplyTarget
+	}
+
+	return &id, nil
+}
+
+func (s *ChatService) reactionSummary(messageID uuid.UUID) ([]dto.ReactionSummary, error) {
+	var reactions []models.MessageReaction
+	if err := s.database.Where("message_id = ?", messageID).Order("created_at ASC").Find(&reactions).Error; err != nil {
+		return nil, err
+	}
+	return summarizeReactions(reactions), nil
+}
+
+// summarizeReactions groups reactions by emoji in the order each emoji was first used.
+func summarizeReactions(reactions []models.MessageReaction) []dto.ReactionSummary {
+	summaries := []dto.ReactionSummary{}
+	index := make(map[string]int)
+	for _, reaction := range reactions {
+		i, ok := index[reaction.Emoji]
+		if !ok {
+			i = len(summaries)
+			index[reaction.Emoji] = i
+			summaries = append(summaries, dto.ReactionSummary{Emoji: reaction.Emoji, UserIDs: []string{}})
+		}
+		summaries[i].Count++
+		summaries[i].UserIDs = append(summaries[i].UserIDs, reaction.UserID.String())
+	}
+	return summaries
+}
+
+// loadConversation fetches a conversation with its participants, ensuring the user belongs to it.
+func (s *ChatService) loadConversation(conversationID string, userID uuid.UUID) (*models.Conversation, error) {
+	if _, err := uuid.Parse(conversationID); err != nil {
+		return nil, ErrConversationNotFound
+	}
+
+	var conversation models.Conversation
+	if err := s.database.
+		Preload("Participants", func(db *gorm.DB) *gorm.DB {
+			return db.Order("joined_at ASC")
+		}).
+		Preload("Participants.User").
+		Where("id = ?", conversationID).
+		First(&conversation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrConversationNotFound
+		}
+		return nil, err
+	}
+
+	if !conversation.IsParticipant(userID) {
+		return nil, ErrNotParticipant
+	}
+
+	return &conversation, nil
+}
+
+func (s *ChatService) loadGroupAsOwner(conversationID string, userID uuid.UUID) (*models.Conversation, error) {
+	conversation, err := s.loadConversation(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !conversation.IsGroup() {
+		return nil, ErrNotGroupConversation
+	}
+	if conversation.GetParticipant(userID).Role != models.ParticipantRoleOwner {
+		return nil, ErrNotConversationOwner
+	}
+	return conversation, nil
+}
+
+// resolveNewParticipants validates user IDs, dropping duplicates and anyone already in the excluded set.
+func (s *ChatService) resolveNewParticipants(actorID uuid.UUID, userIDs []string, exclude map[uuid.UUID]bool) ([]uuid.UUID, error) {
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	var ids []uuid.UUID
+	for _, raw := range userIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, ErrRecipientNotFound
+		}
+		if seen[id] || exclude[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	var count int64
+	if err := s.database.Model(&models.User{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if int(count) != len(ids) {
+		return nil, ErrRecipientNotFound
+	}
+
+	// Users who blocked the actor cannot be pulled into a group by them.
+	if err := s.database.Model(&models.UserBlock{}).
+		Where("blocker_id IN ? AND blocked_id = ?", ids, actorID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrUserBlocked
+	}
+
+	return ids, nil
+}
+
+func (s *ChatService) findOrCreateConversation(user1, user2 uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+
+	p1, p2 := user1, user2
+	if p1.String() > p2.String() {
+		p1, p2 = p2, p1
+	}
+
+	err := s.database.Where(
+		"type = ? AND ((participant1 = ? AND participant2 = ?) OR (participant1 = ? AND participant2 = ?))",
+		models.ConversationTypeDirect, p1, p2, p2, p1,
+	).First(&conversation).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		now := time.Now()
+		conversation = models.Conversation{
+			Type:         models.ConversationTypeDirect,
+			Participant1: &p1,
+			Participant2: &p2,
+		}
+		err = s.database.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&conversation).Error; err != nil {
+				return err
+			}
+			return tx.Create(&[]models.ConversationParticipant{
+				{ConversationID: conversation.ID, UserID: p1, Role: models.ParticipantRoleMember, JoinedAt: now},
+				{ConversationID: conversation.ID, UserID: p2, Role: models.ParticipantRoleMember, JoinedAt: now},
+			}).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.loadConversation(conversation.ID.String(), user1)
+}
+
+// toConversationResponse builds the user's view of a conversation. Presence is looked up when not supplied.
+func (s *ChatService) toConversationResponse(conv *models.Conversation, userID uuid.UUID, presence map[uuid.UUID]*dto.PresenceResponse) dto.ConversationResponse {
+	var lastMessage models.Message
+	s.visibleMessages(userID).Where("conversation_id = ?", conv.ID).Order("created_at DESC").First(&lastMessage)
+
+	var unreadCount int64
+	s.unreadMessages(userID).
+		Where("messages.conversation_id = ?", conv.ID).
+		Count(&unreadCount)
+
+	response := dto.ConversationResponse{
+		ID:           conv.ID.String(),
+		Type:         conv.Type,
+		Name:         conv.Name,
+		Participants: make([]dto.ParticipantResponse, len(conv.Participants)),
+		UnreadCount:  int(unreadCount),
+		CreatedAt:    conv.CreatedAt,
+		UpdatedAt:    conv.UpdatedAt,
+	}
+
+	for i, participant := range conv.Participants {
+		response.Participants[i] = dto.ParticipantResponse{
+			User:       toUserSummary(&participant.User),
+			Role:       participant.Role,
+			LastReadAt: participant.LastReadAt,
+			JoinedAt:   participant.JoinedAt,
+		}
+	}
+
+	if participant := conv.GetParticipant(userID); participant != nil && participant.IsMuted(time.Now()) {
+		response.IsMuted = true
+		response.MutedUntil = participant.MutedUntil
+	}
+
+	if other := conv.GetOtherParticipant(userID); !conv.IsGroup() && other != nil {
+		summary := toUserSummary(&other.User)
+		response.OtherUser = &summary
+
+		if presence == nil {
+			presence = s.resolvePresence([]models.User{other.User})
+		}
+		response.Presence = presence[other.UserID]
+	}
+
+	if lastMessage.ID != uuid.Nil {
+		msgResp := s.toMessageResponse(&lastMessage, conv)
+		response.LastMessage = &msgResp
+	}
+
+	return response
+}
+
+// MessageResponse builds the sender's view of a message, including its read status.
+func (s *ChatService) MessageResponse(message *models.Message) (*dto.MessageResponse, error) {
+	conversation, err := s.loadConversation(message.ConversationID.String(), message.SenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := s.toMessageResponse(message, conversation)
+	return &response, nil
+}
+
+// toMessageResponse derives the message's read status from the read state of everyone except its sender.
+func (s *ChatService) toMessageResponse(msg *models.Message, conv *models.Conversation) dto.MessageResponse {
+	response := dto.MessageResponse{
+		ID:             msg.ID.String(),
+		ConversationID: msg.ConversationID.String(),
+		SenderID:       msg.SenderID.String(),
+		Content:        msg.Content,
+		Status:         models.MessageStatusSent,
+		IsDeleted:      msg.IsDeletedForEveryone(),
+		EditedAt:       msg.EditedAt,
+		CreatedAt:      msg.CreatedAt,
+	}
+
+	if msg.ReplyTo != nil {
+		response.ReplyTo = &dto.MessagePreview{
+			ID:        msg.ReplyTo.ID.String(),
+			SenderID:  msg.ReplyTo.SenderID.String(),
+			Content:   msg.ReplyTo.Content,
+			HasMedia:  len(msg.ReplyTo.Media) > 0,
+			IsDeleted: msg.ReplyTo.IsDeletedForEveryone(),
+		}
+	}
+
+	if len(msg.Reactions) > 0 {
+		response.Reactions = summarizeReactions(msg.Reactions)
+	}
+
+	if conv != nil {
+		readers, others := 0, 0
+		for _, participant := range conv.Participants {
+			if participant.UserID == msg.SenderID {
+				continue
+			}
+			others++
+			if !conv.IsGroup() {
+				response.RecipientID = participant.UserID.String()
+			}
+			if participant.LastReadAt != nil && !participant.LastReadAt.Before(msg.CreatedAt) {
+				readers++
+				response.ReadBy = append(response.ReadBy, participant.UserID.String())
+				if !conv.IsGroup() {
+					response.ReadAt = participant.LastReadAt
+				}
+			}
+		}
+		if others > 0 && readers == others {
+			response.Status = models.MessageStatusRead
+		}
+	}
+
+	if len(msg.Media) > 0 {
+		response.Media = make([]dto.MediaDto, len(msg.Media))
+		for i, m := range msg.Media {
+			m = SignAttachmentMedia(m)
+			response.Media[i] = dto.MediaDto{
+				ID:        m.ID,
+				Type:      m.Type,
+				URL:       m.URL,
+				FileName:  m.FileName,
+				FileSize:  m.FileSize,
+				MimeType:  m.MimeType,
+				Width:     m.Width,
+				Height:    m.Height,
+				Duration:  m.Duration,
+				Thumbnail: m.Thumbnail,
+			}
+		}
+	}
+
+	if msg.Sender.ID != uuid.Nil {
+		summary := toUserSummary(&msg.Sender)
+		response.Sender = &summary
+	}
+
+	return response
+}
+
+func toUserSummary(user *models.User) dto.UserSummary {
+	return dto.UserSummary{
+		ID:       user.ID.String(),
+		Name:     user.Name,
+		Username: user.Username,
+		Image:    user.Image,
+	}
+}
+
+func (s *ChatService) sendMessageNotification(message *models.Message, conversation *models.Conversation) {
+	var sender models.User
+	s.database.Where("id = ?", message.SenderID).First(&sender)
+
+	msgResp := s.toMessageResponse(message, conversation)
+
+	content := sender.Name + " sent you a message"
+	if conversation.IsGroup() && conversation.Name != nil {
+		content = sender.Name + " sent a message in " + *conversation.Name
+	}
+
+	now := time.Now()
+	blockers := s.blockersOf(message.SenderID)
+
+	for _, participant := range conversation.Participants {
+		if participant.UserID == message.SenderID || blockers[participant.UserID] {
+			continue
+		}
+		muted := participant.IsMuted(now)
+
+		if s.hub != nil {
+			notification := models.Notification{
+				ID:      uuid.New(),
+				Title:   "New Message",
+				Content: content,
+				Type:    models.NewMessage,
+				OwnerID: participant.UserID,
+				IsRead:  false,
+				Data: map[string]interface{}{
+					"event_type":      "new_message",
+					"conversation_id": message.ConversationID.String(),
+					"message_id":      message.ID.String(),
+					"message":         msgResp,
+					"sender_id":       sender.ID.String(),
+					"sender_name":     sender.Name,
+					"muted":           muted,
+				},
+			}
+			s.hub.SendToUser(participant.UserID.String(), notification)
+		}
+
+		if s.notificationService != nil && !muted {
+			err := s.notificationService.SendRealTimeNotification(
+				participant.UserID.String(),
+				"New Message",
+				content,
+				models.NewMessage,
+				map[string]interface{}{
+					"conversation_id": message.ConversationID.String(),
+					"message_id":      message.ID.String(),
+					"sender_id":       sender.ID.String(),
+					"sender_name":     sender.Name,
+				},
+			)
+			if err != nil {
+				log.Printf("Failed to send message notification: %v", err)
+			}
+		}
+	}
+}
+
+func (s *ChatService) sendReadReceipt(conversation *models.Conversation, readerID string, readAt time.Time) {
+	s.notifyParticipants(conversation, uuid.Must(uuid.Parse(readerID)), "messages_read", "Messages Read", map[string]interface{}{
+		"conversation_id": conversation.ID.String(),
+		"reader_id":       readerID,
+		"read_at":         readAt,
+	})
+}
+
+// notifyParticipants pushes a realtime chat event to every participant except the actor.
+func (s *ChatService) notifyParticipants(conversation *models.Conversation, actorID uuid.UUID, eventType, title string, data map[string]interface{}) {
+	if s.hub == nil {
+		return
+	}
+
+	data["event_type"] = eventType
+	for _, participant := range conversation.Participants {
+		if participant.UserID == actorID {
+			continue
+		}
+		s.hub.SendToUser(participant.UserID.String(), models.Notification{
+			ID:      uuid.New(),
+			Title:   title,
+			Content: "",
+			Type:    models.System,
+			OwnerID: participant.UserID,
+			IsRead:  false,
+			Data:    data,
+		})
+	}
+}
+
+// HandlePresenceChange persists a user's presence and tells their conversation partners about it.
+func (s *ChatService) HandlePresenceChange(userID string, status models.PresenceStatus) {
+	now := time.Now()
+	if err := s.database.Model(&models.User{}).
+		Where("id = ?", userID).
+		UpdateColumns(map[string]interface{}{
+			"presence_status": status,
+			"last_seen_at":    now,
+		}).Error; err != nil {
+		log.Printf("Failed to persist presence for user %s: %v", userID, err)
+		return
+	}
+
+	var user models.User
+	if err := s.database.Where("id = ?", userID).First(&user).Error; err != nil {
+		return
+	}
+	if user.HidePresence {
+		return
+	}
+
+	s.broadcastPresence(&user, &dto.PresenceResponse{
+		UserID:     userID,
+		Status:     status,
+		LastSeenAt: &now,
+	})
+}
+
+// SetPresenceVisibility hides or reveals a user's presence from everyone else.
+func (s *ChatService) SetPresenceVisibility(userID string, hidden bool) (*dto.PresenceResponse, error) {
+	var user models.User
+	if err := s.database.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecipientNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.database.Model(&models.User{}).Where("id = ?", userID).UpdateColumn("hide_presence", hidden).Error; err != nil {
+		return nil, err
+	}
+	user.HidePresence = hidden
+
+	presence := s.resolvePresence([]models.User{user})[user.ID]
+	s.broadcastPresence(&user, presence)
+
+	return presence, nil
+}
+
+func (s *ChatService) GetPresence(userID string) (*dto.PresenceResponse, error) {
+	var user models.User
+	if err := s.database.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecipientNotFound
+		}
+		return nil, err
+	}
+
+	return s.resolvePresence([]models.User{user})[user.ID], nil
+}
+
+// resolvePresence combines cluster-wide hub connections with persisted status, reporting hidden users as offline.
+func (s *ChatService) resolvePresence(users []models.User) map[uuid.UUID]*dto.PresenceResponse {
+	result := make(map[uuid.UUID]*dto.PresenceResponse, len(users))
+
+	userIDs := make([]string, 0, len(users))
+	for _, user := range users {
+		if !user.HidePresence {
+			userIDs = append(userIDs, user.ID.String())
+		}
+	}
+
+	connections := map[string]int{}
+	if s.hub != nil && len(userIDs) > 0 {
+		var err error
+		if connections, err = s.hub.Presence(userIDs); err != nil {
+			log.Printf("Failed to resolve presence: %v", err)
+			connections = map[string]int{}
+		}
+	}
+
+	for _, user := range users {
+		presence := &dto.PresenceResponse{
+			UserID: user.ID.String(),
+			Status: models.PresenceOffline,
+		}
+		if !user.HidePresence {
+			presence.LastSeenAt = user.LastSeenAt
+			if connections[user.ID.String()] > 0 {
+				presence.Status = models.PresenceOnline
+				if user.PresenceStatus == models.PresenceAway {
+					presence.Status = models.PresenceAway
+				}
+			}
+		}
+		result[user.ID] = presence
+	}
+
+	return result
+}
+
+func (s *ChatService) broadcastPresence(user *models.User, presence *dto.PresenceResponse) {
+	if s.hub == nil || presence == nil {
+		return
+	}
+
+	for _, partnerID := range s.conversationPartners(user.ID) {
+		s.hub.SendToUser(partnerID.String(), models.Notification{
 			ID:      uuid.New(),
-			Title:   "Messages Read",
-			Content: "Your messages have been read",
+			Title:   "Presence",
+			Content: "",
 			Type:    models.System,
-			OwnerID: uuid.Must(uuid.Parse(recipientID)),
+			OwnerID: partnerID,
 			IsRead:  false,
 			Data: map[string]interface{}{
-				"event_type":      "messages_read",
-				"conversation_id": conversationID,
-				"reader_id":       readerID,
+				"event_type":   "presence",
+				"user_id":      presence.UserID,
+				"status":       presence.Status,
+				"last_seen_at": presence.LastSeenAt,
 			},
-		}
-		s.hub.SendToUser(recipientID, notification)
+		})
 	}
 }
 
-func (s *ChatService) HandleWebSocketMessage(senderID string, payload map[string]interface{}) (interface{}, error) {
-	action, ok := payload["action"].(string)
-	if !ok {
-		return nil, errors.New("action is required")
+func (s *ChatService) conversationPartners(userID uuid.UUID) []uuid.UUID {
+	var partners []uuid.UUID
+	if err := s.database.Model(&models.ConversationParticipant{}).
+		Distinct("others.user_id").
+		Joins("JOIN conversation_participants others ON others.conversation_id = conversation_participants.conversation_id AND others.user_id <> ?", userID).
+		Joins("JOIN conversations c ON c.id = conversation_participants.conversation_id AND c.deleted_at IS NULL").
+		Where("conversation_participants.user_id = ?", userID).
+		Pluck("others.user_id", &partners).Error; err != nil {
+		log.Printf("Failed to load conversation partners for user %s: %v", userID, err)
+		return nil
 	}
+	return partners
+}
 
-	switch action {
-	case "send_message":
+func (s *ChatService) HandleWebSocketMessage(senderID string, message lib.SocketEnvelope) (interface{}, error) {
+	switch message.Type {
+	case lib.SocketTypeSendMessage:
+		var payload dto.WebSocketSendMessageDto
+		if err := message.Decode(&payload); err != nil {
+			return nil, errors.New("invalid send_message payload")
+		}
 		return s.handleSendMessage(senderID, payload)
-	case "typing":
-		return s.handleTyping(senderID, payload, true)
-	case "stop_typing":
-		return s.handleTyping(senderID, payload, false)
-	case "mark_messages_read":
+	case lib.SocketTypeTyping, lib.SocketTypeStopTyping:
+		var payload dto.WebSocketTypingDto
+		if err := message.Decode(&payload); err != nil {
+			return nil, errors.New("invalid typing payload")
+		}
+		return s.handleTyping(senderID, payload, message.Type == lib.SocketTypeTyping)
+	case lib.SocketTypeMarkMessagesRead:
+		var payload dto.WebSocketMarkMessagesReadDto
+		if err := message.Decode(&payload); err != nil {
+			return nil, errors.New("invalid mark_messages_read payload")
+		}
 		return s.handleMarkMessagesRead(senderID, payload)
+	case lib.SocketTypeEditMessage:
+		var payload dto.WebSocketEditMessageDto
+		if err := message.Decode(&payload); err != nil || payload.MessageID == "" || payload.Content == "" {
+			return nil, errors.New("invalid edit_message payload")
+		}
+		return s.EditMessage(senderID, payload.MessageID, dto.EditMessageDto{Content: payload.Content})
+	case lib.SocketTypeDeleteMessage:
+		var payload dto.WebSocketDeleteMessageDto
+		if err := message.Decode(&payload); err != nil || payload.MessageID == "" {
+			return nil, errors.New("invalid delete_message payload")
+		}
+		if err := s.DeleteMessage(senderID, payload.MessageID, payload.ForEveryone); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"deleted": true}, nil
+	case lib.SocketTypeAddReaction, lib.SocketTypeRemoveReaction:
+		var payload dto.WebSocketReactionDto
+		if err := message.Decode(&payload); err != nil || payload.MessageID == "" || payload.Emoji == "" {
+			return nil, errors.New("invalid reaction payload")
+		}
+		if message.Type == lib.SocketTypeAddReaction {
+			return s.AddReaction(senderID, payload.MessageID, dto.ReactionDto{Emoji: payload.Emoji})
+		}
+		return s.RemoveReaction(senderID, payload.MessageID, payload.Emoji)
 	default:
-		return nil, errors.New("unknown action: " + action)
+		return nil, errors.New("unknown action: " + message.Type)
 	}
 }
 
-func (s *ChatService) handleSendMessage(senderID string, payload map[string]interface{}) (interface{}, error) {
-	recipientID, ok := payload["recipient_id"].(string)
-	if !ok || recipientID == "" {
-		return nil, errors.New("recipient_id is required")
+func (s *ChatService) handleSendMessage(senderID string, payload dto.WebSocketSendMessageDto) (interface{}, error) {
+	if payload.RecipientID == "" && payload.ConversationID == "" {
+		return nil, ErrMissingMessageTarget
 	}
 
-	content, _ := payload["content"].(string)
-
 	var mediaList []dto.MediaDto
-	if mediaRaw, ok := payload["media"].([]interface{}); ok {
-		for _, m := range mediaRaw {
-			if mediaMap, ok := m.(map[string]interface{}); ok {
-				media := dto.MediaDto{
-					Type: models.MediaType(getStringValue(mediaMap, "type")),
-					URL:  getStringValue(mediaMap, "url"),
-				}
-				if media.Type == "" || media.URL == "" {
-					continue
-				}
-				media.ID = getStringValue(mediaMap, "id")
-				media.FileName = getStringValue(mediaMap, "file_name")
-				media.MimeType = getStringValue(mediaMap, "mime_type")
-				media.Thumbnail = getStringValue(mediaMap, "thumbnail")
-				if size, ok := mediaMap["file_size"].(float64); ok {
-					media.FileSize = int64(size)
-				}
-				if width, ok := mediaMap["width"].(float64); ok {
-					media.Width = int(width)
-				}
-				if height, ok := mediaMap["height"].(float64); ok {
-					media.Height = int(height)
-				}
-				if duration, ok := mediaMap["duration"].(float64); ok {
-					media.Duration = int(duration)
-				}
-				mediaList = append(mediaList, media)
-			}
+	for _, media := range payload.Media {
+		if media.Type == "" || (media.URL == "" && media.ID == "") {
+			continue
 		}
+		mediaList = append(mediaList, media)
 	}
 
-	if content == "" && len(mediaList) == 0 {
+	if payload.Content == "" && len(mediaList) == 0 {
 		return nil, errors.New("message must have content or media")
 	}
 
 	messageDto := dto.SendMessageDto{
-		RecipientID: recipientID,
-		Content:     content,
-		Media:       mediaList,
+		RecipientID:    payload.RecipientID,
+		ConversationID: payload.ConversationID,
+		ReplyToID:      payload.ReplyToID,
+		Content:        payload.Content,
+		Media:          mediaList,
 	}
 
 	message, err := s.SendMessage(senderID, messageDto)
@@ -578,21 +1551,45 @@ func (s *ChatService) handleSendMessage(senderID string, payload map[string]inte
 		return nil, err
 	}
 
-	return s.toMessageResponse(message), nil
+	return s.MessageResponse(message)
 }
 
-func (s *ChatService) handleTyping(senderID string, payload map[string]interface{}, isTyping bool) (interface{}, error) {
-	recipientID, ok := payload["recipient_id"].(string)
-	if !ok || recipientID == "" {
-		return nil, errors.New("recipient_id is required")
-	}
-
-	conversationID, _ := payload["conversation_id"].(string)
-
+// handleTyping relays typing indicators under the same rules as messages, so a blocked user or one banned
+// from chat cannot reach the other side with them either.
+func (s *ChatService) handleTyping(senderID string, payload dto.WebSocketTypingDto, isTyping bool) (interface{}, error) {
 	eventType := "typing"
 	if !isTyping {
 		eventType = "stop_typing"
 	}
+	senderUUID := uuid.Must(uuid.Parse(senderID))
+
+	if payload.ConversationID != "" {
+		conversation, err := s.loadConversation(payload.ConversationID, senderUUID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.ensureCanMessage(senderUUID, conversation); err != nil {
+			return nil, err
+		}
+
+		s.notifyParticipants(conversation, senderUUID, eventType, "Typing", map[string]interface{}{
+			"conversation_id": payload.ConversationID,
+			"user_id":         senderID,
+		})
+		return map[string]interface{}{"sent": true}, nil
+	}
+
+	recipientID := payload.RecipientID
+	if recipientID == "" {
+		return nil, ErrMissingMessageTarget
+	}
+	recipientUUID, err := uuid.Parse(recipientID)
+	if err != nil {
+		return nil, errors.New("invalid recipient ID")
+	}
+	if err := s.ensureCanMessageUser(senderUUID, recipientUUID); err != nil {
+		return nil, err
+	}
 
 	if s.hub != nil {
 		notification := models.Notification{
@@ -600,11 +1597,11 @@ func (s *ChatService) handleTyping(senderID string, payload map[string]interface
 			Title:   "Typing",
 			Content: "",
 			Type:    models.System,
-			OwnerID: uuid.Must(uuid.Parse(recipientID)),
+			OwnerID: recipientUUID,
 			IsRead:  false,
 			Data: map[string]interface{}{
 				"event_type":      eventType,
-				"conversation_id": conversationID,
+				"conversation_id": payload.ConversationID,
 				"user_id":         senderID,
 			},
 		}
@@ -614,23 +1611,15 @@ func (s *ChatService) handleTyping(senderID string, payload map[string]interface
 	return map[string]interface{}{"sent": true}, nil
 }
 
-func (s *ChatService) handleMarkMessagesRead(senderID string, payload map[string]interface{}) (interface{}, error) {
-	conversationID, ok := payload["conversation_id"].(string)
-	if !ok || conversationID == "" {
+func (s *ChatService) handleMarkMessagesRead(senderID string, payload dto.WebSocketMarkMessagesReadDto) (interface{}, error) {
+	if payload.ConversationID == "" {
 		return nil, errors.New("conversation_id is required")
 	}
 
-	err := s.MarkMessagesAsRead(senderID, conversationID)
+	err := s.MarkMessagesAsRead(senderID, payload.ConversationID)
 	if err != nil {
 		return nil, err
 	}
 
 	return map[string]interface{}{"marked_read": true}, nil
 }
-
-func getStringValue(m map[string]interface{}, key string) string {
-	if v, ok := m[key].(string); ok {
-		return v
-	}
-	return ""
-}
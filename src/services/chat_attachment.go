@@ -0,0 +1,444 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"foglio/v2/src/config"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAttachmentNotFound    = errors.New("attachment not found")
+	ErrAttachmentTooLarge    = errors.New("file exceeds the size limit for this media type")
+	ErrUnsupportedAttachment = errors.New("file type is not allowed for this media type")
+	ErrInvalidAttachment     = errors.New("attachments must be uploaded to the same conversation")
+	ErrAttachmentLinkInvalid = errors.New("attachment link is invalid or has expired")
+)
+
+const (
+	attachmentPath       = "/api/v2/chat/attachments/"
+	signedAttachmentPath = "/api/v2/chat/media/"
+	// attachmentLinkWindow is how long a signed link stays the same; attachmentLinkTTL is how long it works.
+	attachmentLinkWindow = 15 * time.Minute
+	attachmentLinkTTL    = 30 * time.Minute
+	// unattachedAttachmentTTL is how long an upload may wait to be sent before it is purged.
+	unattachedAttachmentTTL = 24 * time.Hour
+	attachmentPurgeBatch    = 500
+)
+
+// unattachedCondition matches attachments that no message, deleted or not, lists in its media. Reports keep
+// their snapshot's attachments as evidence after the message itself is deleted for everyone.
+const unattachedCondition = `NOT EXISTS (SELECT 1 FROM messages
+	WHERE messages.media @> jsonb_build_array(jsonb_build_object('id', chat_attachments.id::text)))
+	AND NOT EXISTS (SELECT 1 FROM chat_report_messages
+	WHERE chat_report_messages.media @> jsonb_build_array(jsonb_build_object('id', chat_attachments.id::text)))`
+
+type attachmentRule struct {
+	maxSize int64
+	// extensions maps each allowed extension to its MIME type. A nil map allows any extension.
+	extensions map[string]string
+	// sniff requires the file content, not just its name, to match an allowed MIME type.
+	sniff bool
+}
+
+var attachmentRules = map[models.MediaType]attachmentRule{
+	models.MediaTypeImage: {
+		maxSize: 10 << 20,
+		extensions: map[string]string{
+			".jpg": "image/jpeg", ".jpeg": "image/jpeg", ".png": "image/png", ".gif": "image/gif", ".webp": "image/webp",
+		},
+		sniff: true,
+	},
+	models.MediaTypeVideo: {
+		maxSize: 50 << 20,
+		extensions: map[string]string{
+			".mp4": "video/mp4", ".mov": "video/quicktime", ".webm": "video/webm",
+		},
+	},
+	models.MediaTypeAudio: {
+		maxSize: 20 << 20,
+		extensions: map[string]string{
+			".mp3": "audio/mpeg", ".m4a": "audio/mp4", ".ogg": "audio/ogg", ".wav": "audio/wav", ".aac": "audio/aac", ".webm": "audio/webm",
+		},
+	},
+	models.MediaTypeDocument: {
+		maxSize: 20 << 20,
+		extensions: map[string]string{
+			".pdf":  "application/pdf",
+			".doc":  "application/msword",
+			".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			".xls":  "application/vnd.ms-excel",
+			".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			".ppt":  "application/vnd.ms-powerpoint",
+			".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+			".txt":  "text/plain",
+			".csv":  "text/csv",
+			".rtf":  "application/rtf",
+			".odt":  "application/vnd.oasis.opendocument.text",
+		},
+	},
+	models.MediaTypeFile: {
+		maxSize: 25 << 20,
+	},
+}
+
+var blockedAttachmentExtensions = map[string]bool{
+	".exe": true, ".msi": true, ".bat": true, ".cmd": true, ".com": true, ".scr": true,
+	".dll": true, ".sh": true, ".ps1": true, ".vbs": true, ".jar": true, ".apk": true,
+}
+
+// IsInlineAttachmentType reports whether a stored attachment of this MIME type may be shown in the browser.
+// Only the image, video and audio types uploads are checked against qualify; anything else is served as a download.
+func IsInlineAttachmentType(mimeType string) bool {
+	for _, mediaType := range []models.MediaType{models.MediaTypeImage, models.MediaTypeVideo, models.MediaTypeAudio} {
+		for _, allowed := range attachmentRules[mediaType].extensions {
+			if allowed == mimeType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MaxAttachmentSize is the largest file accepted for any media type.
+func MaxAttachmentSize() int64 {
+	var size int64
+	for _, rule := range attachmentRules {
+		size = max(size, rule.maxSize)
+	}
+	return size
+}
+
+// UploadAttachment validates and stores a file for a conversation, returning media metadata the
+// client can attach to a message by ID.
+func (s *ChatService) UploadAttachment(userID, conversationID string, mediaType models.MediaType, header *multipart.FileHeader) (*models.MessageMedia, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	conversation, err := s.loadConversation(conversationID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, ok := attachmentRules[mediaType]
+	if !ok {
+		return nil, ErrUnsupportedAttachment
+	}
+	if header.Size > rule.maxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, rule.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > rule.maxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	mimeType, err := detectAttachmentType(rule, header.Filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := models.ChatAttachment{
+		ConversationID: conversation.ID,
+		UploaderID:     userUUID,
+		Type:           mediaType,
+		FileName:       filepath.Base(header.Filename),
+		MimeType:       mimeType,
+		FileSize:       int64(len(data)),
+	}
+
+	var thumbnail []byte
+	if mediaType == models.MediaTypeImage {
+		info, err := lib.ProbeImage(data)
+		if err != nil {
+			return nil, ErrUnsupportedAttachment
+		}
+		attachment.Width = info.Width
+		attachment.Height = info.Height
+
+		if thumbnail, err = lib.GenerateThumbnail(data, lib.ThumbnailSize); err != nil {
+			return nil, err
+		}
+	}
+
+	folder := "foglio-chat/" + conversation.ID.String()
+	stored, err := lib.UploadPrivate(bytes.NewReader(data), folder)
+	if err != nil {
+		return nil, err
+	}
+	attachment.StoragePublicID = stored.PublicID
+	attachment.StorageResource = stored.ResourceType
+	attachment.StorageFormat = stored.Format
+	attachment.StorageVersion = stored.Version
+
+	if thumbnail != nil {
+		storedThumbnail, err := lib.UploadPrivate(bytes.NewReader(thumbnail), folder+"/thumbnails")
+		if err != nil {
+			return nil, err
+		}
+		attachment.ThumbnailPublicID = &storedThumbnail.PublicID
+		attachment.ThumbnailFormat = storedThumbnail.Format
+		attachment.ThumbnailVersion = storedThumbnail.Version
+	}
+
+	if err := s.database.Create(&attachment).Error; err != nil {
+		return nil, err
+	}
+
+	media := SignAttachmentMedia(toAttachmentMedia(&attachment))
+	return &media, nil
+}
+
+// OpenAttachment streams an attachment, or its thumbnail, to a participant of its conversation.
+func (s *ChatService) OpenAttachment(userID, attachmentID string, thumbnail bool) (*models.ChatAttachment, io.ReadCloser, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	attachment, err := s.findAttachment(attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := s.loadConversation(attachment.ConversationID.String(), userUUID); err != nil {
+		if err == ErrConversationNotFound {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+
+	return openAttachment(attachment, thumbnail)
+}
+
+// OpenSignedAttachment streams an attachment, or its thumbnail, to whoever holds an unexpired link from
+// SignAttachmentMedia. Browsers load media without the Authorization header, so the link is the credential.
+func (s *ChatService) OpenSignedAttachment(attachmentID string, thumbnail bool, expires, signature string) (*models.ChatAttachment, io.ReadCloser, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, nil, ErrAttachmentLinkInvalid
+	}
+	if !hmac.Equal([]byte(signature), []byte(attachmentSignature(attachmentID, thumbnail, expiresAt))) {
+		return nil, nil, ErrAttachmentLinkInvalid
+	}
+
+	attachment, err := s.findAttachment(attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return openAttachment(attachment, thumbnail)
+}
+
+// PurgeUnattachedAttachments deletes uploads that no message has referenced within unattachedAttachmentTTL,
+// along with their stored files.
+func (s *ChatService) PurgeUnattachedAttachments() error {
+	var attachments []models.ChatAttachment
+	if err := s.database.
+		Where("created_at < ?", time.Now().Add(-unattachedAttachmentTTL)).
+		Where(unattachedCondition).
+		Limit(attachmentPurgeBatch).
+		Find(&attachments).Error; err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		// Checked again as the row goes, in case a message picked the upload up since it was listed
+		result := s.database.Where("id = ?", attachment.ID).Where(unattachedCondition).Delete(&models.ChatAttachment{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		// The record is gone either way; a file that fails to delete is only logged
+		if err := lib.DeletePrivate(attachmentFile(&attachment, false)); err != nil {
+			log.Printf("Failed to delete unattached attachment %s: %v", attachment.ID, err)
+		}
+		if attachment.HasThumbnail() {
+			if err := lib.DeletePrivate(attachmentFile(&attachment, true)); err != nil {
+				log.Printf("Failed to delete thumbnail of unattached attachment %s: %v", attachment.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *ChatService) findAttachment(attachmentID string) (*models.ChatAttachment, error) {
+	if _, err := uuid.Parse(attachmentID); err != nil {
+		return nil, ErrAttachmentNotFound
+	}
+
+	var attachment models.ChatAttachment
+	if err := s.database.Where("id = ?", attachmentID).First(&attachment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func openAttachment(attachment *models.ChatAttachment, thumbnail bool) (*models.ChatAttachment, io.ReadCloser, error) {
+	if thumbnail && !attachment.HasThumbnail() {
+		return nil, nil, ErrAttachmentNotFound
+	}
+
+	reader, err := lib.OpenPrivate(attachmentFile(attachment, thumbnail))
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, reader, nil
+}
+
+func attachmentFile(attachment *models.ChatAttachment, thumbnail bool) lib.StoredFile {
+	if thumbnail {
+		return lib.StoredFile{
+			PublicID:     *attachment.ThumbnailPublicID,
+			ResourceType: "image",
+			Format:       attachment.ThumbnailFormat,
+			Version:      attachment.ThumbnailVersion,
+		}
+	}
+	return lib.StoredFile{
+		PublicID:     attachment.StoragePublicID,
+		ResourceType: attachment.StorageResource,
+		Format:       attachment.StorageFormat,
+		Version:      attachment.StorageVersion,
+	}
+}
+
+// resolveMedia replaces client-supplied metadata for uploaded attachments with the stored values,
+// rejecting attachments uploaded by someone else or to another conversation.
+func (s *ChatService) resolveMedia(senderID, conversationID uuid.UUID, media models.MessageMediaList) (models.MessageMediaList, error) {
+	for i, m := range media {
+		attachmentID, err := uuid.Parse(m.ID)
+		if err != nil {
+			continue
+		}
+
+		var attachment models.ChatAttachment
+		if err := s.database.Where("id = ?", attachmentID).First(&attachment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if attachment.ConversationID != conversationID || attachment.UploaderID != senderID {
+			return nil, ErrInvalidAttachment
+		}
+
+		media[i] = toAttachmentMedia(&attachment)
+	}
+	return media, nil
+}
+
+func detectAttachmentType(rule attachmentRule, fileName string, data []byte) (string, error) {
+	extension := strings.ToLower(filepath.Ext(fileName))
+	if blockedAttachmentExtensions[extension] {
+		return "", ErrUnsupportedAttachment
+	}
+
+	sniffed := strings.TrimSpace(strings.Split(http.DetectContentType(data), ";")[0])
+
+	if rule.extensions == nil {
+		return sniffed, nil
+	}
+
+	mimeType, ok := rule.extensions[extension]
+	if !ok {
+		return "", ErrUnsupportedAttachment
+	}
+
+	if rule.sniff {
+		for _, allowed := range rule.extensions {
+			if allowed == sniffed {
+				return sniffed, nil
+			}
+		}
+		return "", ErrUnsupportedAttachment
+	}
+
+	return mimeType, nil
+}
+
+// toAttachmentMedia describes a stored attachment by its authenticated API URL, which is what messages keep.
+// Responses swap it for a signed link with SignAttachmentMedia.
+func toAttachmentMedia(attachment *models.ChatAttachment) models.MessageMedia {
+	link := apiURL(attachmentPath + attachment.ID.String())
+
+	media := models.MessageMedia{
+		ID:       attachment.ID.String(),
+		Type:     attachment.Type,
+		URL:      link,
+		FileName: attachment.FileName,
+		FileSize: attachment.FileSize,
+		MimeType: attachment.MimeType,
+		Width:    attachment.Width,
+		Height:   attachment.Height,
+	}
+	if attachment.HasThumbnail() {
+		media.Thumbnail = link + "/thumbnail"
+	}
+	return media
+}
+
+// SignAttachmentMedia points media uploaded through the API at signed links that an <img> or <video> tag can
+// load without the Authorization header. Links stay the same for attachmentLinkWindow, so browser caches keep
+// working, and expire by attachmentLinkTTL. Media hosted elsewhere is returned unchanged.
+func SignAttachmentMedia(media models.MessageMedia) models.MessageMedia {
+	if media.ID == "" || !strings.HasSuffix(media.URL, attachmentPath+media.ID) {
+		return media
+	}
+
+	expires := time.Now().Truncate(attachmentLinkWindow).Add(attachmentLinkTTL).Unix()
+	media.URL = signedAttachmentURL(media.ID, false, expires)
+	if media.Thumbnail != "" {
+		media.Thumbnail = signedAttachmentURL(media.ID, true, expires)
+	}
+	return media
+}
+
+func signedAttachmentURL(attachmentID string, thumbnail bool, expires int64) string {
+	path := signedAttachmentPath + attachmentID
+	if thumbnail {
+		path += "/thumbnail"
+	}
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expires, 10)},
+		"signature": {attachmentSignature(attachmentID, thumbnail, expires)},
+	}
+	return apiURL(path) + "?" + query.Encode()
+}
+
+func attachmentSignature(attachmentID string, thumbnail bool, expires int64) string {
+	mac := hmac.New(sha256.New, config.AppConfig.JWTSecret)
+	fmt.Fprintf(mac, "chat-attachment:%s:%t:%d", attachmentID, thumbnail, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func apiURL(path string) string {
+	return strings.TrimSuffix(config.AppConfig.ApiUrl, "/") + path
+}
@@ -0,0 +1,528 @@
+package services
+
+import (
+	"errors"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// reportSnapshotSize is how many of the reported user's recent messages are attached to a report
+// that does not name specific messages.
+const reportSnapshotSize = 20
+
+var (
+	ErrUserBlocked           = errors.New("you cannot message this user")
+	ErrCannotBlockSelf       = errors.New("you cannot block yourself")
+	ErrBlockNotFound         = errors.New("user is not blocked")
+	ErrChatBanned            = errors.New("your chat access has been suspended")
+	ErrCannotReportSelf      = errors.New("you cannot report yourself")
+	ErrInvalidReportMessages = errors.New("reported messages must be sent by the reported user in a conversation you belong to")
+	ErrReportNotFound        = errors.New("report not found")
+	ErrReportAlreadyResolved = errors.New("report has already been resolved")
+)
+
+func (s *ChatService) BlockUser(userID string, payload dto.BlockUserDto) (*dto.BlockedUserResponse, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+	blockedUUID, err := uuid.Parse(payload.UserID)
+	if err != nil {
+		return nil, ErrRecipientNotFound
+	}
+	if userUUID == blockedUUID {
+		return nil, ErrCannotBlockSelf
+	}
+
+	var blocked models.User
+	if err := s.database.Where("id = ?", blockedUUID).First(&blocked).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecipientNotFound
+		}
+		return nil, err
+	}
+
+	block := models.UserBlock{BlockerID: userUUID, BlockedID: blockedUUID}
+	if err := s.database.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+		return nil, err
+	}
+	if err := s.database.Where("blocker_id = ? AND blocked_id = ?", userUUID, blockedUUID).First(&block).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.BlockedUserResponse{User: toUserSummary(&blocked), BlockedAt: block.CreatedAt}, nil
+}
+
+func (s *ChatService) UnblockUser(userID, blockedID string) error {
+	userUUID := uuid.Must(uuid.Parse(userID))
+	if _, err := uuid.Parse(blockedID); err != nil {
+		return ErrBlockNotFound
+	}
+
+	result := s.database.Where("blocker_id = ? AND blocked_id = ?", userUUID, blockedID).Delete(&models.UserBlock{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBlockNotFound
+	}
+	return nil
+}
+
+func (s *ChatService) GetBlockedUsers(userID string) ([]dto.BlockedUserResponse, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	var blocks []models.UserBlock
+	if err := s.database.Preload("Blocked").
+		Where("blocker_id = ?", userUUID).
+		Order("created_at DESC").
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.BlockedUserResponse, len(blocks))
+	for i, block := range blocks {
+		responses[i] = dto.BlockedUserResponse{User: toUserSummary(&block.Blocked), BlockedAt: block.CreatedAt}
+	}
+	return responses, nil
+}
+
+// MuteConversation silences notifications for a conversation. Messages are still delivered live.
+func (s *ChatService) MuteConversation(userID, conversationID string, payload dto.MuteConversationDto) (*dto.ConversationResponse, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	conversation, err := s.loadConversation(conversationID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var mutedUntil *time.Time
+	if payload.DurationMinutes != nil {
+		until := now.Add(time.Duration(*payload.DurationMinutes) * time.Minute)
+		mutedUntil = &until
+	}
+
+	participant := conversation.GetParticipant(userUUID)
+	if err := s.database.Model(participant).Updates(map[string]interface{}{
+		"muted_at":    now,
+		"muted_until": mutedUntil,
+	}).Error; err != nil {
+		return nil, err
+	}
+	participant.MutedAt, participant.MutedUntil = &now, mutedUntil
+
+	response := s.toConversationResponse(conversation, userUUID, nil)
+	return &response, nil
+}
+
+func (s *ChatService) UnmuteConversation(userID, conversationID string) (*dto.ConversationResponse, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	conversation, err := s.loadConversation(conversationID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	participant := conversation.GetParticipant(userUUID)
+	if err := s.database.Model(participant).Updates(map[string]interface{}{
+		"muted_at":    nil,
+		"muted_until": nil,
+	}).Error; err != nil {
+		return nil, err
+	}
+	participant.MutedAt, participant.MutedUntil = nil, nil
+
+	response := s.toConversationResponse(conversation, userUUID, nil)
+	return &response, nil
+}
+
+// ReportUser files a report against another user, copying the offending messages into the report so
+// moderators see them as they were when reported.
+func (s *ChatService) ReportUser(userID string, payload dto.ReportUserDto) (*dto.ChatReportResponse, error) {
+	userUUID := uuid.Must(uuid.Parse(userID))
+	reportedUUID, err := uuid.Parse(payload.UserID)
+	if err != nil {
+		return nil, ErrRecipientNotFound
+	}
+	if userUUID == reportedUUID {
+		return nil, ErrCannotReportSelf
+	}
+
+	var reported models.User
+	if err := s.database.Where("id = ?", reportedUUID).First(&reported).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecipientNotFound
+		}
+		return nil, err
+	}
+
+	var conversationID *uuid.UUID
+	if payload.ConversationID != "" {
+		conversation, err := s.loadConversation(payload.ConversationID, userUUID)
+		if err != nil {
+			return nil, err
+		}
+		if !conversation.IsParticipant(reportedUUID) {
+			return nil, ErrParticipantNotFound
+		}
+		conversationID = &conversation.ID
+	}
+
+	messages, err := s.reportedMessages(userUUID, reportedUUID, conversationID, payload.MessageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.ChatReport{
+		ReporterID:     userUUID,
+		ReportedUserID: reportedUUID,
+		ConversationID: conversationID,
+		Reason:         payload.Reason,
+		Status:         models.ChatReportStatusPending,
+	}
+	if payload.Details != "" {
+		report.Details = &payload.Details
+	}
+	for _, message := range messages {
+		report.Messages = append(report.Messages, models.ChatReportMessage{
+			MessageID: message.ID,
+			SenderID:  message.SenderID,
+			Content:   message.Content,
+			Media:     message.Media,
+			SentAt:    message.CreatedAt,
+			EditedAt:  message.EditedAt,
+		})
+	}
+
+	if err := s.database.Create(&report).Error; err != nil {
+		return nil, err
+	}
+
+	if payload.Block {
+		if _, err := s.BlockUser(userID, dto.BlockUserDto{UserID: payload.UserID}); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetChatReport(report.ID.String())
+}
+
+// reportedMessages loads the messages to snapshot for a report. Named messages must have been sent by
+// the reported user in a conversation the reporter belongs to.
+func (s *ChatService) reportedMessages(reporterID, reportedID uuid.UUID, conversationID *uuid.UUID, messageIDs []string) ([]models.Message, error) {
+	query := s.database.Model(&models.Message{}).
+		Joins("JOIN conversation_participants cp ON cp.conversation_id = messages.conversation_id AND cp.user_id = ?", reporterID).
+		Where("messages.sender_id = ?", reportedID)
+
+	var messages []models.Message
+	switch {
+	case len(messageIDs) > 0:
+		if conversationID != nil {
+			query = query.Where("messages.conversation_id = ?", *conversationID)
+		}
+		if err := query.Where("messages.id IN ?", messageIDs).Order("messages.created_at ASC").Find(&messages).Error; err != nil {
+			return nil, err
+		}
+
+		unique := make(map[string]bool, len(messageIDs))
+		for _, id := range messageIDs {
+			unique[id] = true
+		}
+		if len(messages) != len(unique) {
+			return nil, ErrInvalidReportMessages
+		}
+	case conversationID != nil:
+		if err := query.Where("messages.conversation_id = ?", *conversationID).
+			Order("messages.created_at DESC").
+			Limit(reportSnapshotSize).
+			Find(&messages).Error; err != nil {
+			return nil, err
+		}
+		slices.Reverse(messages)
+	}
+
+	return messages, nil
+}
+
+func (s *ChatService) GetChatReports(params dto.ChatReportQueryParams) (*dto.ChatReportListResponse, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	page := params.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	query := s.database.Model(&models.ChatReport{})
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+
+	var totalItems int64
+	if err := query.Count(&totalItems).Error; err != nil {
+		return nil, err
+	}
+
+	var reports []models.ChatReport
+	if err := s.preloadReportRelations(query).
+		Order("created_at ASC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&reports).Error; err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.ChatReportResponse, len(reports))
+	for i := range reports {
+		responses[i] = toChatReportResponse(&reports[i])
+	}
+
+	return &dto.ChatReportListResponse{
+		Data:       responses,
+		TotalItems: int(totalItems),
+		TotalPages: int((totalItems + int64(limit) - 1) / int64(limit)),
+		Page:       page,
+		Limit:      limit,
+	}, nil
+}
+
+func (s *ChatService) GetChatReport(reportID string) (*dto.ChatReportResponse, error) {
+	report, err := s.loadReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toChatReportResponse(report)
+	return &response, nil
+}
+
+// ResolveChatReport closes a pending report. Warnings notify the reported user and bans also revoke
+// their chat access. The reporter is told the report was reviewed either way.
+func (s *ChatService) ResolveChatReport(adminID, reportID string, payload dto.ResolveChatReportDto) (*dto.ChatReportResponse, error) {
+	adminUUID := uuid.Must(uuid.Parse(adminID))
+
+	report, err := s.loadReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != models.ChatReportStatusPending {
+		return nil, ErrReportAlreadyResolved
+	}
+
+	now := time.Now()
+	status := models.ChatReportStatusResolved
+	if payload.Action == models.ChatReportActionDismiss {
+		status = models.ChatReportStatusDismissed
+	}
+	updates := map[string]interface{}{
+		"status":         status,
+		"action":         payload.Action,
+		"resolved_by_id": adminUUID,
+		"resolved_at":    now,
+	}
+	if payload.Note != "" {
+		updates["resolution_note"] = payload.Note
+	}
+
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(report).Updates(updates).Error; err != nil {
+			return err
+		}
+		if payload.Action == models.ChatReportActionBan {
+			return tx.Model(&models.User{}).
+				Where("id = ? AND chat_banned_at IS NULL", report.ReportedUserID).
+				Update("chat_banned_at", now).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go s.notifyReportOutcome(report, payload.Action)
+
+	return s.GetChatReport(reportID)
+}
+
+// LiftChatBan restores a banned user's chat access.
+func (s *ChatService) LiftChatBan(userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return ErrRecipientNotFound
+	}
+
+	result := s.database.Model(&models.User{}).Where("id = ?", userID).Update("chat_banned_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecipientNotFound
+	}
+	return nil
+}
+
+// isBlocked reports whether either user has blocked the other.
+func (s *ChatService) isBlocked(userID, otherID uuid.UUID) (bool, error) {
+	var count int64
+	err := s.database.Model(&models.UserBlock{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", userID, otherID, otherID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ensureCanChat refuses users banned from chat, and suspended users whose WebSocket is still open.
+func (s *ChatService) ensureCanChat(userID uuid.UUID) error {
+	var count int64
+	if err := s.database.Model(&models.User{}).
+		Where("id = ? AND (chat_banned_at IS NOT NULL OR (suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > ?)))",
+			userID, time.Now()).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrChatBanned
+	}
+	return nil
+}
+
+// ensureCanMessage checks that the sender may chat and, for direct conversations, that neither side
+// has blocked the other.
+func (s *ChatService) ensureCanMessage(senderID uuid.UUID, conversation *models.Conversation) error {
+	other := conversation.GetOtherParticipant(senderID)
+	if conversation.IsGroup() || other == nil {
+		return s.ensureCanChat(senderID)
+	}
+	return s.ensureCanMessageUser(senderID, other.UserID)
+}
+
+func (s *ChatService) ensureCanMessageUser(senderID, recipientID uuid.UUID) error {
+	if err := s.ensureCanChat(senderID); err != nil {
+		return err
+	}
+
+	blocked, err := s.isBlocked(senderID, recipientID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrUserBlocked
+	}
+	return nil
+}
+
+// blockersOf returns the users who have blocked the given user.
+func (s *ChatService) blockersOf(userID uuid.UUID) map[uuid.UUID]bool {
+	var blockerIDs []uuid.UUID
+	if err := s.database.Model(&models.UserBlock{}).Where("blocked_id = ?", userID).Pluck("blocker_id", &blockerIDs).Error; err != nil {
+		log.Printf("Failed to load blocks for user %s: %v", userID, err)
+	}
+
+	blockers := make(map[uuid.UUID]bool, len(blockerIDs))
+	for _, id := range blockerIDs {
+		blockers[id] = true
+	}
+	return blockers
+}
+
+func (s *ChatService) loadReport(reportID string) (*models.ChatReport, error) {
+	if _, err := uuid.Parse(reportID); err != nil {
+		return nil, ErrReportNotFound
+	}
+
+	var report models.ChatReport
+	if err := s.preloadReportRelations(s.database).Where("id = ?", reportID).First(&report).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReportNotFound
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (s *ChatService) preloadReportRelations(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Reporter").
+		Preload("ReportedUser").
+		Preload("Messages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sent_at ASC")
+		})
+}
+
+func (s *ChatService) notifyReportOutcome(report *models.ChatReport, action models.ChatReportAction) {
+	if s.notificationService == nil {
+		return
+	}
+
+	data := map[string]interface{}{"report_id": report.ID.String()}
+
+	if err := s.notificationService.SendRealTimeNotification(
+		report.ReporterID.String(),
+		"Report Reviewed",
+		"Thanks for your report. Our moderators have reviewed it and taken appropriate action.",
+		models.System,
+		data,
+	); err != nil {
+		log.Printf("Failed to notify reporter of report outcome: %v", err)
+	}
+
+	var title, content string
+	switch action {
+	case models.ChatReportActionWarn:
+		title, content = "Chat Warning", "A message you sent was reported and found to break our community guidelines. Further violations may lead to your chat access being suspended."
+	case models.ChatReportActionBan:
+		title, content = "Chat Access Suspended", "Your chat access has been suspended for breaking our community guidelines."
+	default:
+		return
+	}
+
+	if err := s.notificationService.SendRealTimeNotification(
+		report.ReportedUserID.String(),
+		title,
+		content,
+		models.System,
+		data,
+	); err != nil {
+		log.Printf("Failed to notify reported user of report outcome: %v", err)
+	}
+}
+
+func toChatReportResponse(report *models.ChatReport) dto.ChatReportResponse {
+	response := dto.ChatReportResponse{
+		ID:             report.ID.String(),
+		Reporter:       toUserSummary(&report.Reporter),
+		ReportedUser:   toUserSummary(&report.ReportedUser),
+		Reason:         report.Reason,
+		Details:        report.Details,
+		Status:         report.Status,
+		Action:         report.Action,
+		ResolutionNote: report.ResolutionNote,
+		ResolvedAt:     report.ResolvedAt,
+		CreatedAt:      report.CreatedAt,
+	}
+	// Moderators are not in the conversation, so they view the snapshot's attachments through signed links
+	response.Messages = make([]models.ChatReportMessage, len(report.Messages))
+	for i, message := range report.Messages {
+		if len(message.Media) > 0 {
+			media := make(models.MessageMediaList, len(message.Media))
+			for j, m := range message.Media {
+				media[j] = SignAttachmentMedia(m)
+			}
+			message.Media = media
+		}
+		response.Messages[i] = message
+	}
+	if report.ConversationID != nil {
+		id := report.ConversationID.String()
+		response.ConversationID = &id
+	}
+	if report.ResolvedByID != nil {
+		id := report.ResolvedByID.String()
+		response.ResolvedByID = &id
+	}
+	return response
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// searchContextSize is the number of messages returned on each side of a search hit.
+const searchContextSize = 2
+
+// SearchMessages runs a full-text search over every conversation the user belongs to, returning each
+// match with the messages around it and the conversation it was sent in.
+func (s *ChatService) SearchMessages(userID string, params dto.MessageSearchParams) (*dto.MessageSearchResponse, error) {
+	limit := params.Limit
+	if limit <= 0 || limit > 50 {
+		limit = 20
+	}
+	page := params.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	userUUID := uuid.Must(uuid.Parse(userID))
+
+	query := s.visibleMessages(userUUID).
+		Joins("JOIN conversation_participants cp ON cp.conversation_id = messages.conversation_id AND cp.user_id = ?", userUUID).
+		Joins("JOIN conversations c ON c.id = messages.conversation_id AND c.deleted_at IS NULL").
+		Where("messages.deleted_for_everyone_at IS NULL").
+		Where("messages.search_vector @@ websearch_to_tsquery('simple', ?)", params.Query)
+
+	var totalItems int64
+	if err := query.Count(&totalItems).Error; err != nil {
+		return nil, err
+	}
+
+	var messages []models.Message
+	if err := s.preloadMessageRelations(query).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank(messages.search_vector, websearch_to_tsquery('simple', ?)) DESC, messages.created_at DESC",
+			Vars: []interface{}{params.Query},
+		}}).
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	conversations, err := s.searchConversations(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]dto.MessageSearchResult, 0, len(messages))
+	for _, msg := range messages {
+		conversation := conversations[msg.ConversationID]
+		if conversation == nil {
+			continue
+		}
+
+		before, _, err := s.pageMessages(userUUID, conversation.ID, &msg, true, false, searchContextSize)
+		if err != nil {
+			return nil, err
+		}
+		slices.Reverse(before)
+
+		after, _, err := s.pageMessages(userUUID, conversation.ID, &msg, false, false, searchContextSize)
+		if err != nil {
+			return nil, err
+		}
+		slices.Reverse(after)
+
+		results = append(results, dto.MessageSearchResult{
+			Message:      s.toMessageResponse(&msg, conversation),
+			Conversation: toConversationSummary(conversation, userUUID),
+			Before:       s.toMessageResponses(before, conversation),
+			After:        s.toMessageResponses(after, conversation),
+		})
+	}
+
+	return &dto.MessageSearchResponse{
+		Data:       results,
+		TotalItems: int(totalItems),
+		TotalPages: int((totalItems + int64(limit) - 1) / int64(limit)),
+		Page:       page,
+		Limit:      limit,
+	}, nil
+}
+
+func (s *ChatService) searchConversations(messages []models.Message) (map[uuid.UUID]*models.Conversation, error) {
+	conversations := make(map[uuid.UUID]*models.Conversation)
+	if len(messages) == 0 {
+		return conversations, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ConversationID)
+	}
+
+	var loaded []models.Conversation
+	if err := s.database.
+		Preload("Participants", func(db *gorm.DB) *gorm.DB {
+			return db.Order("joined_at ASC")
+		}).
+		Preload("Participants.User").
+		Where("id IN ?", ids).
+		Find(&loaded).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range loaded {
+		conversations[loaded[i].ID] = &loaded[i]
+	}
+	return conversations, nil
+}
+
+func (s *ChatService) toMessageResponses(messages []models.Message, conversation *models.Conversation) []dto.MessageResponse {
+	responses := make([]dto.MessageResponse, len(messages))
+	for i, msg := range messages {
+		responses[i] = s.toMessageResponse(&msg, conversation)
+	}
+	return responses
+}
+
+func toConversationSummary(conversation *models.Conversation, userID uuid.UUID) dto.ConversationSummary {
+	summary := dto.ConversationSummary{
+		ID:   conversation.ID.String(),
+		Type: conversation.Type,
+		Name: conversation.Name,
+	}
+	if other := conversation.GetOtherParticipant(userID); !conversation.IsGroup() && other != nil {
+		user := toUserSummary(&other.User)
+		summary.OtherUser = &user
+	}
+	return summary
+}
@@ -0,0 +1,448 @@
+package services
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"foglio/v2/src/config"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"io"
+	"log"
+	"os"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExportInProgress = errors.New("a data export is already being prepared")
+	ErrExportTooSoon    = errors.New("you can request one data export per day")
+	ErrExportNotFound   = errors.New("data export not found")
+	ErrExportNotReady   = errors.New("data export is not ready for download")
+)
+
+const (
+	// dataExportInterval is how often a user can request an export.
+	dataExportInterval = 24 * time.Hour
+	// dataExportTTL is how long a finished archive can be downloaded before it is removed.
+	dataExportTTL = 7 * 24 * time.Hour
+	// dataExportStaleAfter is when an export still processing is assumed abandoned, e.g. by a restart, and retried.
+	dataExportStaleAfter = time.Hour
+)
+
+// exportedRecords lists what goes into an export besides the profile, one JSON file each. Conditions
+// refer to the user as @user.
+var exportedRecords = []struct {
+	file    string
+	model   interface{}
+	where   string
+	preload []string
+}{
+	{file: "portfolio.json", model: &models.Portfolio{}, where: "user_id = @user", preload: []string{"Sections"}},
+	{file: "subscriptions.json", model: &models.UserSubscription{}, where: "user_id = @user", preload: []string{"Subscription"}},
+	{file: "invoices.json", model: &models.SubscriptionInvoice{}, where: "user_subscription_id IN (SELECT id FROM user_subscriptions WHERE user_id = @user)"},
+	{file: "jobs.json", model: &models.Job{}, where: "created_by = @user"},
+	{file: "job_applications.json", model: &models.JobApplication{}, where: "applicant_id = @user", preload: []string{"Job"}},
+	{file: "job_comments.json", model: &models.Comment{}, where: "created_by = @user"},
+	{file: "job_reactions.json", model: &models.Reaction{}, where: "created_by = @user"},
+	{file: "reviews.json", model: &models.Review{}, where: "user_id = @user"},
+	{file: "notifications.json", model: &models.Notification{}, where: "owner_id = @user"},
+	{file: "notification_settings.json", model: &models.NotificationSettings{}, where: "user_id = @user"},
+	{file: "announcements_read.json", model: &models.UserAnnouncementStatus{}, where: "user_id = @user"},
+	{file: "conversations.json", model: &models.ConversationParticipant{}, where: "user_id = @user"},
+	{file: "messages.json", model: &models.Message{}, where: "conversation_id IN (SELECT conversation_id FROM conversation_participants WHERE user_id = @user)"},
+	{file: "message_reactions.json", model: &models.MessageReaction{}, where: "user_id = @user"},
+	{file: "chat_attachments.json", model: &models.ChatAttachment{}, where: "uploader_id = @user"},
+	{file: "blocked_users.json", model: &models.UserBlock{}, where: "blocker_id = @user"},
+	{file: "chat_reports.json", model: &models.ChatReport{}, where: "reporter_id = @user"},
+	{file: "message_templates.json", model: &models.MessageTemplate{}, where: "owner_id = @user"},
+	{file: "outreach_messages.json", model: &models.OutreachMessage{}, where: "sender_id = @user OR recipient_id = @user"},
+	{file: "roles.json", model: &models.UserRole{}, where: "user_id = @user", preload: []string{"Role"}},
+	{file: "identity_verifications.json", model: &models.IdentityVerification{}, where: "user_id = @user"},
+	{file: "linked_accounts.json", model: &models.UserIdentity{}, where: "user_id = @user"},
+	{file: "passkeys.json", model: &models.WebAuthnCredential{}, where: "user_id = @user"},
+	{file: "sessions.json", model: &models.Session{}, where: "user_id = @user"},
+	{file: "trusted_devices.json", model: &models.TrustedDevice{}, where: "user_id = @user"},
+	{file: "account_recoveries.json", model: &models.AccountRecovery{}, where: "user_id = @user"},
+	{file: "account_deletions.json", model: &models.AccountDeletion{}, where: "user_id = @user"},
+	{file: "page_views.json", model: &models.PageView{}, where: "user_id = @user"},
+	{file: "job_views.json", model: &models.JobView{}, where: "user_id = @user"},
+	{file: "profile_views.json", model: &models.ProfileView{}, where: "profile_user_id = @user OR viewer_user_id = @user"},
+	{file: "portfolio_views.json", model: &models.PortfolioView{}, where: "viewer_user_id = @user"},
+	{file: "analytics_events.json", model: &models.AnalyticsEvent{}, where: "user_id = @user"},
+	{file: "activity_log.json", model: &models.AuditLog{}, where: "actor_id = @user"},
+}
+
+// DataExportService builds the "download my data" archive: a ZIP with the user's records as JSON and the
+// files they uploaded.
+type DataExportService struct {
+	database *gorm.DB
+}
+
+func NewDataExportService(database *gorm.DB) *DataExportService {
+	return &DataExportService{database: database}
+}
+
+// RequestExport queues an export and starts building it in the background. The user is emailed when it is
+// ready.
+func (s *DataExportService) RequestExport(userID string) (*models.DataExport, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	var latest models.DataExport
+	err = s.database.Where("user_id = ?", userUUID).Order("created_at DESC").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		if latest.Status == models.ExportPending || latest.Status == models.ExportProcessing {
+			return nil, ErrExportInProgress
+		}
+		// A failed export can be retried right away
+		if latest.Status != models.ExportFailed && latest.CreatedAt.After(time.Now().Add(-dataExportInterval)) {
+			return nil, ErrExportTooSoon
+		}
+	}
+
+	export := models.DataExport{UserID: userUUID, Status: models.ExportPending}
+	if err := s.database.Create(&export).Error; err != nil {
+		return nil, err
+	}
+
+	go s.process(export.ID)
+
+	return &export, nil
+}
+
+// GetExports lists the user's recent exports, newest first.
+func (s *DataExportService) GetExports(userID string) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	if err := s.database.Where("user_id = ?", userID).Order("created_at DESC").Limit(10).Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// OpenExport streams a ready archive to its owner. The caller must close the reader.
+func (s *DataExportService) OpenExport(userID, exportID string) (*models.DataExport, io.ReadCloser, error) {
+	if _, err := uuid.Parse(exportID); err != nil {
+		return nil, nil, ErrExportNotFound
+	}
+
+	var export models.DataExport
+	if err := s.database.First(&export, "id = ? AND user_id = ?", exportID, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrExportNotFound
+		}
+		return nil, nil, err
+	}
+	if export.Status != models.ExportReady || export.StoragePublicID == nil ||
+		export.ExpiresAt == nil || export.ExpiresAt.Before(time.Now()) {
+		return nil, nil, ErrExportNotReady
+	}
+
+	reader, err := lib.OpenPrivate(exportFile(&export))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &export, reader, nil
+}
+
+// ProcessPendingExports builds exports that were queued but never started, or abandoned midway, for example
+// because the server restarted.
+func (s *DataExportService) ProcessPendingExports() error {
+	var ids []uuid.UUID
+	if err := s.database.Model(&models.DataExport{}).
+		Where("status = ? OR (status = ? AND started_at < ?)", models.ExportPending, models.ExportProcessing, time.Now().Add(-dataExportStaleAfter)).
+		Order("created_at ASC").
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		s.process(id)
+	}
+	return nil
+}
+
+// ExpireExports removes archives whose download window has passed.
+func (s *DataExportService) ExpireExports() error {
+	var exports []models.DataExport
+	if err := s.database.Where("status = ? AND expires_at < ?", models.ExportReady, time.Now()).Find(&exports).Error; err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if export.StoragePublicID != nil {
+			if err := lib.DeletePrivate(exportFile(&export)); err != nil {
+				log.Printf("Failed to delete data export %s: %v", export.ID, err)
+				continue
+			}
+		}
+		if err := s.database.Model(&export).Updates(map[string]interface{}{
+			"status":            models.ExportExpired,
+			"storage_public_id": nil,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DataExportService) process(exportID uuid.UUID) {
+	// Claiming the export first keeps the background job and the retry cron from building it twice
+	now := time.Now()
+	result := s.database.Model(&models.DataExport{}).
+		Where("id = ? AND (status = ? OR (status = ? AND started_at < ?))", exportID, models.ExportPending, models.ExportProcessing, now.Add(-dataExportStaleAfter)).
+		Updates(map[string]interface{}{"status": models.ExportProcessing, "started_at": now})
+	if result.Error != nil {
+		log.Printf("Failed to start data export %s: %v", exportID, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	var export models.DataExport
+	if err := s.database.First(&export, "id = ?", exportID).Error; err != nil {
+		log.Printf("Failed to load data export %s: %v", exportID, err)
+		return
+	}
+
+	if err := s.build(&export); err != nil {
+		log.Printf("Failed to build data export %s: %v", exportID, err)
+		message := "We couldn't prepare your data, please request a new export"
+		if err := s.database.Model(&export).Updates(map[string]interface{}{
+			"status": models.ExportFailed,
+			"error":  message,
+		}).Error; err != nil {
+			log.Printf("Failed to mark data export %s as failed: %v", exportID, err)
+		}
+	}
+}
+
+// accountExport is the user's own account as written to the archive, including the fields API responses hide.
+type accountExport struct {
+	models.User
+	VerificationNumber   *string                  `json:"verification_number,omitempty"`
+	VerificationType     *models.VerificationType `json:"verification_type,omitempty"`
+	VerificationDocument *string                  `json:"verification_document,omitempty"`
+	VerificationNote     *string                  `json:"verification_note,omitempty"`
+	SuspensionReason     *string                  `json:"suspension_reason,omitempty"`
+}
+
+func newAccountExport(user *models.User) accountExport {
+	return accountExport{
+		User:                 *user,
+		VerificationNumber:   user.VerificationNumber,
+		VerificationType:     user.VerificationType,
+		VerificationDocument: user.VerificationDocument,
+		VerificationNote:     user.VerificationNote,
+		SuspensionReason:     user.SuspensionReason,
+	}
+}
+
+func (s *DataExportService) build(export *models.DataExport) error {
+	user, err := loadSigninUser(s.database, export.UserID)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp("", "foglio-export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		if err := os.Remove(file.Name()); err != nil {
+			log.Printf("Failed to remove temporary export %s: %v", file.Name(), err)
+		}
+	}()
+
+	archive := zip.NewWriter(file)
+	if err := writeExportJSON(archive, "account.json", newAccountExport(user)); err != nil {
+		return err
+	}
+	for _, record := range exportedRecords {
+		rows := reflect.New(reflect.SliceOf(reflect.TypeOf(record.model).Elem()))
+		query := s.database.Model(record.model).Where(record.where, sql.Named("user", user.ID.String()))
+		for _, relation := range record.preload {
+			query = query.Preload(relation)
+		}
+		if err := query.Find(rows.Interface()).Error; err != nil {
+			return fmt.Errorf("%s: %w", record.file, err)
+		}
+		if err := writeExportJSON(archive, record.file, rows.Interface()); err != nil {
+			return err
+		}
+	}
+	if err := s.writeMedia(archive, user); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	stored, err := lib.UploadPrivate(file, "foglio-exports/"+user.ID.String())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(dataExportTTL)
+	if err := s.database.Model(export).Updates(map[string]interface{}{
+		"status":            models.ExportReady,
+		"file_size":         info.Size(),
+		"storage_public_id": stored.PublicID,
+		"storage_resource":  stored.ResourceType,
+		"storage_format":    stored.Format,
+		"storage_version":   stored.Version,
+		"completed_at":      now,
+		"expires_at":        expiresAt,
+	}).Error; err != nil {
+		return err
+	}
+
+	go func() {
+		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+			To:       []string{user.Email},
+			Subject:  "Your Data Is Ready",
+			Template: "data-export-ready",
+			Data: map[string]interface{}{
+				"Name":      user.Name,
+				"Url":       config.AppConfig.ClientUrl + "/settings/privacy",
+				"ExpiresAt": expiresAt.UTC().Format("Jan 2, 2006"),
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send data export email: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// writeMedia adds the files the user uploaded under media/. Links the user typed in to other sites are
+// already in the JSON and are not fetched.
+func (s *DataExportService) writeMedia(archive *zip.Writer, user *models.User) error {
+	var images []struct{ name, url string }
+	addImage := func(name string, url *string) {
+		if url != nil && *url != "" {
+			images = append(images, struct{ name, url string }{name, *url})
+		}
+	}
+	addImage("avatar", user.Image)
+	for _, project := range user.Projects {
+		addImage("projects/"+project.ID.String(), project.Image)
+	}
+	if user.Portfolio != nil {
+		addImage("portfolio/cover", user.Portfolio.CoverImage)
+		addImage("portfolio/logo", user.Portfolio.Logo)
+	}
+
+	for _, image := range images {
+		stored, ok := lib.ParseUploadURL(image.url)
+		if !ok {
+			continue
+		}
+		name := "media/" + image.name
+		if stored.Format != "" {
+			name += "." + stored.Format
+		}
+		if err := copyExportFile(archive, name, func() (io.ReadCloser, error) { return lib.OpenUploaded(image.url) }); err != nil {
+			return err
+		}
+	}
+
+	var attachments []models.ChatAttachment
+	if err := s.database.Where("uploader_id = ?", user.ID).Find(&attachments).Error; err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		name := "media/chat/" + attachment.ID.String() + "-" + path.Base(attachment.FileName)
+		stored := lib.StoredFile{
+			PublicID:     attachment.StoragePublicID,
+			ResourceType: attachment.StorageResource,
+			Format:       attachment.StorageFormat,
+			Version:      attachment.StorageVersion,
+		}
+		if err := copyExportFile(archive, name, func() (io.ReadCloser, error) { return lib.OpenPrivate(stored) }); err != nil {
+			return err
+		}
+	}
+
+	var verifications []models.IdentityVerification
+	if err := s.database.Where("user_id = ?", user.ID).Find(&verifications).Error; err != nil {
+		return err
+	}
+	for _, verification := range verifications {
+		name := "media/identity/" + verification.ID.String() + "-" + path.Base(verification.DocumentName)
+		stored := lib.StoredFile{
+			PublicID:     verification.StoragePublicID,
+			ResourceType: verification.StorageResource,
+			Format:       verification.StorageFormat,
+			Version:      verification.StorageVersion,
+		}
+		if err := copyExportFile(archive, name, func() (io.ReadCloser, error) { return lib.OpenPrivate(stored) }); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeExportJSON(archive *zip.Writer, name string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+func copyExportFile(archive *zip.Writer, name string, open func() (io.ReadCloser, error)) error {
+	reader, err := open()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("Error closing %s: %v", name, err)
+		}
+	}()
+
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, reader)
+	return err
+}
+
+func exportFile(export *models.DataExport) lib.StoredFile {
+	return lib.StoredFile{
+		PublicID:     *export.StoragePublicID,
+		ResourceType: export.StorageResource,
+		Format:       export.StorageFormat,
+		Version:      export.StorageVersion,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"foglio/v2/src/dto"
 	"foglio/v2/src/models"
+	"log"
 	"net"
 	"regexp"
 	"strings"
@@ -137,6 +138,7 @@ func (s *DomainService) ClaimSubdomain(userId string, payload dto.ClaimSubdomain
 	if err := s.database.Save(&user).Error; err != nil {
 		return nil, err
 	}
+	invalidateDomain(domain)
 
 	return &dto.DomainResponse{
 		Subdomain:          domain.Subdomain,
@@ -155,12 +157,18 @@ func (s *DomainService) GetDomain(userId string) (*dto.DomainResponse, error) {
 		}, nil
 	}
 
+	certificate, err := NewCertificateService(s.database).GetDomainCertificate(user.Domain.CustomDomain)
+	if err != nil {
+		return nil, err
+	}
+
 	return &dto.DomainResponse{
 		Subdomain:              user.Domain.Subdomain,
 		CustomDomain:           user.Domain.CustomDomain,
 		CustomDomainStatus:     user.Domain.CustomDomainStatus,
 		CustomDomainVerifiedAt: user.Domain.CustomDomainVerifiedAt,
 		DnsRecords:             user.Domain.DnsRecords,
+		Certificate:            certificate,
 		CanUseCustomDomain:     user.CanUseCustomDomain(),
 	}, nil
 }
@@ -182,6 +190,7 @@ func (s *DomainService) SetCustomDomain(userId string, payload dto.SetCustomDoma
 
 	verificationToken := models.GenerateVerificationToken()
 	dnsRecords := models.GenerateDnsRecords(customDomain, verificationToken)
+	previousDomain := user.Domain.CustomDomain
 
 	user.Domain.CustomDomain = customDomain
 	user.Domain.CustomDomainStatus = models.DomainStatusPending
@@ -191,6 +200,12 @@ func (s *DomainService) SetCustomDomain(userId string, payload dto.SetCustomDoma
 	if err := s.database.Save(&user).Error; err != nil {
 		return nil, err
 	}
+	InvalidateSiteHosts(previousDomain, customDomain)
+	if previousDomain != customDomain {
+		if err := NewCertificateService(s.database).RemoveCertificate(previousDomain); err != nil {
+			log.Printf("Failed to remove certificate for %s: %v", previousDomain, err)
+		}
+	}
 
 	return &dto.DomainResponse{
 		Subdomain:          user.Domain.Subdomain,
@@ -232,6 +247,19 @@ func (s *DomainService) VerifyCustomDomain(userId string) (*dto.DomainResponse,
 	if err := s.database.Save(&user).Error; err != nil {
 		return nil, err
 	}
+	InvalidateSiteHosts(user.Domain.CustomDomain)
+
+	certificates := NewCertificateService(s.database)
+	if allVerified {
+		// The domain works over HTTP already, so a certificate that cannot be requested is retried by the cron
+		if err := certificates.RequestCertificate(user.ID, user.Domain.CustomDomain); err != nil {
+			log.Printf("Failed to request certificate for %s: %v", user.Domain.CustomDomain, err)
+		}
+	}
+	certificate, err := certificates.GetDomainCertificate(user.Domain.CustomDomain)
+	if err != nil {
+		return nil, err
+	}
 
 	return &dto.DomainResponse{
 		Subdomain:              user.Domain.Subdomain,
@@ -239,6 +267,7 @@ func (s *DomainService) VerifyCustomDomain(userId string) (*dto.DomainResponse,
 		CustomDomainStatus:     user.Domain.CustomDomainStatus,
 		CustomDomainVerifiedAt: user.Domain.CustomDomainVerifiedAt,
 		DnsRecords:             user.Domain.DnsRecords,
+		Certificate:            certificate,
 		CanUseCustomDomain:     user.CanUseCustomDomain(),
 	}, nil
 }
@@ -252,6 +281,7 @@ func (s *DomainService) RemoveCustomDomain(userId string) (*dto.DomainResponse,
 		return nil, ErrNoCustomDomain
 	}
 
+	removedDomain := user.Domain.CustomDomain
 	user.Domain.CustomDomain = ""
 	user.Domain.CustomDomainStatus = ""
 	user.Domain.CustomDomainVerifiedAt = nil
@@ -260,6 +290,10 @@ func (s *DomainService) RemoveCustomDomain(userId string) (*dto.DomainResponse,
 	if err := s.database.Save(&user).Error; err != nil {
 		return nil, err
 	}
+	InvalidateSiteHosts(removedDomain)
+	if err := NewCertificateService(s.database).RemoveCertificate(removedDomain); err != nil {
+		log.Printf("Failed to remove certificate for %s: %v", removedDomain, err)
+	}
 
 	return &dto.DomainResponse{
 		Subdomain:          user.Domain.Subdomain,
@@ -294,11 +328,16 @@ func (s *DomainService) UpdateSubdomain(userId string, payload dto.ClaimSubdomai
 		user.Domain = &models.Domain{}
 	}
 
+	previousSubdomain := user.Domain.Subdomain
 	user.Domain.Subdomain = subdomain
 
 	if err := s.database.Save(&user).Error; err != nil {
 		return nil, err
 	}
+	if previousSubdomain != "" {
+		InvalidateSiteHosts(SubdomainHost(previousSubdomain))
+	}
+	InvalidateSiteHosts(SubdomainHost(subdomain))
 
 	return &dto.DomainResponse{
 		Subdomain:              user.Domain.Subdomain,
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrIncorrectPassword      = errors.New("incorrect password")
+	ErrEmailUnchanged         = errors.New("new email is the same as the current one")
+	ErrEmailInUse             = errors.New("this email has been used")
+	ErrEmailRevertUnavailable = errors.New("the previous email now belongs to another account, contact support")
+)
+
+// EmailChangeService moves an account to a new email address. The new address has to be confirmed with a
+// code sent to it, and the old address gets a link to undo the change in case the account was taken over.
+type EmailChangeService struct {
+	database *gorm.DB
+	tokens   *VerificationTokenService
+}
+
+func NewEmailChangeService(database *gorm.DB) *EmailChangeService {
+	return &EmailChangeService{
+		database: database,
+		tokens:   NewVerificationTokenService(database),
+	}
+}
+
+// RequestChange emails a confirmation code to the new address. Accounts with a password have to confirm it.
+func (s *EmailChangeService) RequestChange(userID string, payload dto.ChangeEmailRequest) error {
+	var user models.User
+	if err := s.database.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if user.Password != "" && lib.ComparePassword(payload.Password, user.Password) != nil {
+		return ErrIncorrectPassword
+	}
+
+	newEmail := strings.TrimSpace(payload.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrEmailUnchanged
+	}
+	if err := s.ensureEmailAvailable(newEmail, &user); err != nil {
+		return err
+	}
+
+	code, err := s.tokens.Issue(user.ID, models.PurposeEmailChange, newEmail)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+			To:       []string{newEmail},
+			Subject:  "Confirm Your New Email",
+			Template: "email-change",
+			Data: map[string]interface{}{
+				"Name":     user.Name,
+				"Email":    user.Email,
+				"NewEmail": newEmail,
+				"Otp":      code,
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send email change code: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// ConfirmChange switches the account to the address the code was sent to and emails the old address a link
+// to revert the change. It returns the updated user and the old email.
+func (s *EmailChangeService) ConfirmChange(userID string, code string) (*models.User, string, error) {
+	var user models.User
+	if err := s.database.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrUserNotFound
+		}
+		return nil, "", err
+	}
+
+	token, err := s.tokens.ConsumeCode(user.ID, models.PurposeEmailChange, code)
+	if err != nil {
+		return nil, "", err
+	}
+	// Another account may have taken the address since the code was sent
+	if err := s.ensureEmailAvailable(token.Target, &user); err != nil {
+		return nil, "", err
+	}
+
+	oldEmail := user.Email
+	var revertToken string
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"email":    token.Target,
+			"verified": true,
+		}).Error; err != nil {
+			return err
+		}
+		user.Email = token.Target
+		user.Verified = true
+
+		revertToken, err = NewVerificationTokenService(tx).Issue(user.ID, models.PurposeEmailRevert, oldEmail)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	url := lib.GenerateUrl(config.AppConfig.ClientUrl+"/revert-email", revertToken)
+	go func() {
+		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+			To:       []string{oldEmail},
+			Subject:  "Your Email Was Changed",
+			Template: "email-changed",
+			Data: map[string]interface{}{
+				"Name":     user.Name,
+				"Email":    oldEmail,
+				"NewEmail": user.Email,
+				"Url":      url,
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send email change notice: %v", err)
+		}
+	}()
+
+	user.Password = ""
+	return &user, oldEmail, nil
+}
+
+// RevertChange puts back the address the revert link was sent to. Whoever changed it may still be signed in
+// or have set up their own 2FA device, so every session and trusted device is signed out, and the pending
+// email changes and reset links are dropped.
+func (s *EmailChangeService) RevertChange(token string) (*models.User, string, error) {
+	var (
+		user         models.User
+		changedEmail string
+	)
+	err := s.database.Transaction(func(tx *gorm.DB) error {
+		tokens := NewVerificationTokenService(tx)
+		record, err := tokens.ConsumeToken(models.PurposeEmailRevert, token)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, "id = ?", record.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidVerificationToken
+			}
+			return err
+		}
+		if err := NewEmailChangeService(tx).ensureEmailAvailable(record.Target, &user); err != nil {
+			if errors.Is(err, ErrEmailInUse) {
+				return ErrEmailRevertUnavailable
+			}
+			return err
+		}
+
+		changedEmail = user.Email
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"email":    record.Target,
+			"verified": true,
+		}).Error; err != nil {
+			return err
+		}
+		user.Email = record.Target
+		user.Verified = true
+
+		for _, purpose := range []models.VerificationPurpose{
+			models.PurposeEmailRevert, models.PurposeEmailChange, models.PurposePasswordReset,
+		} {
+			if err := tokens.Revoke(user.ID, purpose); err != nil {
+				return err
+			}
+		}
+		if err := NewTwoFactorService(tx).RemoveTrustedDevices(user.ID.String()); err != nil {
+			return err
+		}
+		_, err = NewSessionService(tx).RevokeAllSessions(user.ID.String(), "")
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	user.Password = ""
+	return &user, changedEmail, nil
+}
+
+func (s *EmailChangeService) ensureEmailAvailable(email string, user *models.User) error {
+	var count int64
+	if err := s.database.Model(&models.User{}).
+		Where("email = ? AND id <> ?", email, user.ID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEmailInUse
+	}
+	return nil
+}
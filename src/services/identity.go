@@ -0,0 +1,224 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidOAuthState       = errors.New("sign-in request is invalid or has expired, try again")
+	ErrOAuthEmailInUse         = errors.New("an account with this email already exists, sign in to it and link the provider from your account settings")
+	ErrIdentityLinkedElsewhere = errors.New("this provider account is already linked to another user")
+	ErrProviderAlreadyLinked   = errors.New("another account from this provider is already linked")
+	ErrIdentityNotFound        = errors.New("provider is not linked to this account")
+	ErrLastSignInMethod        = errors.New("set a password, add a passkey or link another provider before unlinking this one")
+)
+
+// OAuthStateTTL is how long the user has to finish signing in at the provider.
+const OAuthStateTTL = 10 * time.Minute
+
+type IdentityService struct {
+	database *gorm.DB
+}
+
+func NewIdentityService(database *gorm.DB) *IdentityService {
+	return &IdentityService{database: database}
+}
+
+// BeginAuthorization starts an authorization code flow with PKCE and returns the provider URL and the
+// state, which the caller also binds to the browser. userID is set when a signed-in user links a provider.
+func (s *IdentityService) BeginAuthorization(providerName string, intent models.OAuthIntent, userID *uuid.UUID) (string, string, error) {
+	provider, err := findOAuthProvider(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := lib.GenerateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := lib.GenerateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	authURL, err := provider.AuthorizationURL(state, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.database.Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{}).Error; err != nil {
+		log.Printf("Failed to clear expired OAuth states: %v", err)
+	}
+
+	if err := s.database.Create(&models.OAuthState{
+		StateHash:    lib.HashToken(state),
+		Provider:     provider.Name,
+		Intent:       intent,
+		UserID:       userID,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(OAuthStateTTL),
+	}).Error; err != nil {
+		return "", "", err
+	}
+
+	return authURL, state, nil
+}
+
+// CompleteAuthorization checks the callback's state against the one bound to the browser, redeems it once
+// and returns the provider account the code belongs to.
+func (s *IdentityService) CompleteAuthorization(providerName string, payload dto.OAuthCallbackDto, browserState string, intent models.OAuthIntent, userID *uuid.UUID) (*dto.OAuthUserDto, error) {
+	provider, err := findOAuthProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.State == "" || subtle.ConstantTimeCompare([]byte(payload.State), []byte(browserState)) != 1 {
+		return nil, ErrInvalidOAuthState
+	}
+
+	var state models.OAuthState
+	if err := s.database.
+		Where("state_hash = ? AND provider = ? AND intent = ?", lib.HashToken(payload.State), provider.Name, intent).
+		First(&state).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidOAuthState
+		}
+		return nil, err
+	}
+
+	result := s.database.Where("id = ?", state.ID).Delete(&models.OAuthState{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || state.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidOAuthState
+	}
+	if (userID == nil) != (state.UserID == nil) || (userID != nil && *userID != *state.UserID) {
+		return nil, ErrInvalidOAuthState
+	}
+
+	accessToken, err := provider.Exchange(payload.Code, state.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.Profile(accessToken)
+}
+
+// FindUser returns the user the provider account is linked to, or gorm.ErrRecordNotFound.
+func (s *IdentityService) FindUser(account *dto.OAuthUserDto) (*models.User, error) {
+	var identity models.UserIdentity
+	if err := s.database.
+		Where("provider = ? AND subject = ?", account.Provider, account.ID).
+		First(&identity).Error; err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.database.Where("id = ?", identity.UserID).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.database.Model(&identity).Updates(map[string]interface{}{
+		"email":        account.Email,
+		"name":         account.Name,
+		"last_used_at": time.Now(),
+	}).Error; err != nil {
+		log.Printf("Failed to update identity %s: %v", identity.ID, err)
+	}
+
+	return &user, nil
+}
+
+// Link attaches the provider account to the user.
+func (s *IdentityService) Link(userID uuid.UUID, account *dto.OAuthUserDto) (*models.UserIdentity, error) {
+	var existing models.UserIdentity
+	err := s.database.Where("provider = ? AND subject = ?", account.Provider, account.ID).First(&existing).Error
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrIdentityLinkedElsewhere
+		}
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var linked int64
+	if err := s.database.Model(&models.UserIdentity{}).
+		Where("user_id = ? AND provider = ?", userID, account.Provider).
+		Count(&linked).Error; err != nil {
+		return nil, err
+	}
+	if linked > 0 {
+		return nil, ErrProviderAlreadyLinked
+	}
+
+	identity := models.UserIdentity{
+		UserID:     userID,
+		Provider:   account.Provider,
+		Subject:    account.ID,
+		Email:      account.Email,
+		Name:       account.Name,
+		LastUsedAt: time.Now(),
+	}
+	if err := s.database.Create(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (s *IdentityService) GetIdentities(userID string) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	if err := s.database.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// Unlink removes the provider from the user's account, as long as the user can still sign in another way.
+func (s *IdentityService) Unlink(userID, provider string) error {
+	var user models.User
+	if err := s.database.Where("id = ?", userID).First(&user).Error; err != nil {
+		return err
+	}
+
+	var identity models.UserIdentity
+	if err := s.database.Where("user_id = ? AND provider = ?", userID, provider).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrIdentityNotFound
+		}
+		return err
+	}
+
+	if user.Password == "" {
+		var others, passkeys int64
+		if err := s.database.Model(&models.UserIdentity{}).
+			Where("user_id = ? AND id <> ?", userID, identity.ID).
+			Count(&others).Error; err != nil {
+			return err
+		}
+		if err := s.database.Model(&models.WebAuthnCredential{}).
+			Where("user_id = ?", userID).
+			Count(&passkeys).Error; err != nil {
+			return err
+		}
+		if others == 0 && passkeys == 0 {
+			return ErrLastSignInMethod
+		}
+	}
+
+	return s.database.Delete(&identity).Error
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"io"
+	"log"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrVerificationNotFound      = errors.New("no identity verification has been submitted")
+	ErrVerificationPending       = errors.New("an identity verification is already awaiting review")
+	ErrNoPendingVerification     = errors.New("user has no identity verification awaiting review")
+	ErrAlreadyIdentityVerified   = errors.New("identity is already verified")
+	ErrInvalidVerificationNumber = errors.New("document number does not match the format for this document type")
+	ErrVerificationNumberInUse   = errors.New("document number is already linked to another account")
+)
+
+// MaxIdentityDocumentSize is the largest identity document accepted for review.
+const MaxIdentityDocumentSize int64 = 10 << 20
+
+// identityDocumentRule accepts scans and photos of an identity document.
+var identityDocumentRule = attachmentRule{
+	maxSize: MaxIdentityDocumentSize,
+	extensions: map[string]string{
+		".jpg": "image/jpeg", ".jpeg": "image/jpeg", ".png": "image/png", ".pdf": "application/pdf",
+	},
+	sniff: true,
+}
+
+// verificationNumberFormats holds the accepted shape of each document number after normalization.
+var verificationNumberFormats = map[models.VerificationType]*regexp.Regexp{
+	models.DriversLicense:        regexp.MustCompile(`^[A-Z0-9]{5,20}$`),
+	models.InternationalPassport: regexp.MustCompile(`^[A-Z][A-Z0-9]{5,8}$`),
+	models.NationalIdCard:        regexp.MustCompile(`^[A-Z0-9]{6,20}$`),
+	models.VotersCard:            regexp.MustCompile(`^[A-Z0-9]{10,20}$`),
+}
+
+type IdentityVerificationService struct {
+	database            *gorm.DB
+	notificationService *NotificationService
+}
+
+func NewIdentityVerificationService(database *gorm.DB, notificationService *NotificationService) *IdentityVerificationService {
+	return &IdentityVerificationService{
+		database:            database,
+		notificationService: notificationService,
+	}
+}
+
+// NormalizeVerificationNumber uppercases a document number and drops the spaces and separators people type.
+func NormalizeVerificationNumber(number string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '/':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(number)))
+}
+
+// SubmitVerification stores an identity document privately and queues it for moderator review.
+func (s *IdentityVerificationService) SubmitVerification(userID string, payload dto.SubmitIdentityVerificationDto, header *multipart.FileHeader) (*models.IdentityVerification, error) {
+	var user models.User
+	if err := s.database.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.IdentityVerified {
+		return nil, ErrAlreadyIdentityVerified
+	}
+
+	var pending int64
+	if err := s.database.Model(&models.IdentityVerification{}).
+		Where("user_id = ? AND status = ?", user.ID, models.VerificationPending).
+		Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, ErrVerificationPending
+	}
+
+	number := NormalizeVerificationNumber(payload.Number)
+	format, ok := verificationNumberFormats[payload.Type]
+	if !ok || !format.MatchString(number) {
+		return nil, ErrInvalidVerificationNumber
+	}
+	if err := s.ensureNumberAvailable(user.ID, number); err != nil {
+		return nil, err
+	}
+
+	if header.Size > identityDocumentRule.maxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, identityDocumentRule.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > identityDocumentRule.maxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	mimeType, err := detectAttachmentType(identityDocumentRule, header.Filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := lib.UploadPrivate(bytes.NewReader(data), "foglio-verifications/"+user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	verification := models.IdentityVerification{
+		UserID:           user.ID,
+		Type:             payload.Type,
+		Number:           number,
+		Status:           models.VerificationPending,
+		DocumentName:     filepath.Base(header.Filename),
+		DocumentMimeType: mimeType,
+		DocumentSize:     int64(len(data)),
+		StoragePublicID:  stored.PublicID,
+		StorageResource:  stored.ResourceType,
+		StorageFormat:    stored.Format,
+		StorageVersion:   stored.Version,
+	}
+
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&verification).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"verification_status":         models.VerificationPending,
+			"verification_note":           nil,
+			"verification_reviewed_at":    nil,
+			"verification_reviewed_by_id": nil,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &verification, nil
+}
+
+// GetVerification returns the user's most recent submission.
+func (s *IdentityVerificationService) GetVerification(userID string) (*models.IdentityVerification, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	var verification models.IdentityVerification
+	if err := s.database.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		First(&verification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVerificationNotFound
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// GetVerificationQueue lists submissions awaiting review, oldest first.
+func (s *IdentityVerificationService) GetVerificationQueue(params dto.VerificationQueueParams) (*dto.PaginatedResponse[models.IdentityVerification], error) {
+	params.Pagination = normalizePagination(params.Pagination)
+
+	query := s.database.Model(&models.IdentityVerification{}).Where("status = ?", models.VerificationPending)
+	if params.Type != "" {
+		query = query.Where("type = ?", params.Type)
+	}
+
+	var totalItems int64
+	if err := query.Count(&totalItems).Error; err != nil {
+		return nil, err
+	}
+
+	var verifications []models.IdentityVerification
+	if err := query.Preload("User").
+		Order("created_at ASC").
+		Offset((params.Page - 1) * params.Limit).
+		Limit(params.Limit).
+		Find(&verifications).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedResponse[models.IdentityVerification]{
+		Data:       verifications,
+		Limit:      params.Limit,
+		Page:       params.Page,
+		TotalItems: int(totalItems),
+		TotalPages: int(math.Ceil(float64(totalItems) / float64(params.Limit))),
+	}, nil
+}
+
+// ApproveVerification accepts the user's pending submission, copying the document onto the profile and
+// granting the identity verified badge.
+func (s *IdentityVerificationService) ApproveVerification(adminID, userID string) (*models.IdentityVerification, error) {
+	verification, err := s.findPendingVerification(userID)
+	if err != nil {
+		return nil, err
+	}
+	if verification.User.IdentityVerified {
+		return nil, ErrAlreadyIdentityVerified
+	}
+	// Another account may have been approved with the same number while this one waited
+	if err := s.ensureNumberAvailable(verification.UserID, verification.Number); err != nil {
+		return nil, err
+	}
+
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		if err := s.review(tx, verification, adminID, models.VerificationApproved, nil); err != nil {
+			return err
+		}
+		return tx.Model(verification.User).Updates(map[string]interface{}{
+			"verification_number": verification.Number,
+			"verification_type":   verification.Type,
+			"identity_verified":   true,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go s.notify(verification.UserID.String(), "Identity Verified", "Your identity document has been reviewed and approved.")
+
+	return s.GetVerification(userID)
+}
+
+func (s *IdentityVerificationService) RejectVerification(adminID, userID string, payload dto.RejectVerificationDto) (*models.IdentityVerification, error) {
+	verification, err := s.findPendingVerification(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		return s.review(tx, verification, adminID, models.VerificationRejected, &payload.Reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go s.notify(verification.UserID.String(), "Identity Verification Rejected", "Your identity document could not be verified: "+payload.Reason)
+
+	return s.GetVerification(userID)
+}
+
+// OpenDocument streams the document from the user's most recent submission.
+func (s *IdentityVerificationService) OpenDocument(userID string) (*models.IdentityVerification, io.ReadCloser, error) {
+	verification, err := s.GetVerification(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := lib.OpenPrivate(lib.StoredFile{
+		PublicID:     verification.StoragePublicID,
+		ResourceType: verification.StorageResource,
+		Format:       verification.StorageFormat,
+		Version:      verification.StorageVersion,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return verification, reader, nil
+}
+
+// review records the decision on the submission and mirrors it onto the user's verification status.
+func (s *IdentityVerificationService) review(tx *gorm.DB, verification *models.IdentityVerification, adminID string, status models.VerificationStatus, note *string) error {
+	now := time.Now()
+	reviewerID := uuid.MustParse(adminID)
+
+	if err := tx.Model(verification).Updates(map[string]interface{}{
+		"status":         status,
+		"review_note":    note,
+		"reviewed_at":    now,
+		"reviewed_by_id": reviewerID,
+	}).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(verification.User).Updates(map[string]interface{}{
+		"verification_status":         status,
+		"verification_note":           note,
+		"verification_reviewed_at":    now,
+		"verification_reviewed_by_id": reviewerID,
+	}).Error
+}
+
+func (s *IdentityVerificationService) findPendingVerification(userID string) (*models.IdentityVerification, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	var verification models.IdentityVerification
+	if err := s.database.Preload("User").
+		Where("user_id = ? AND status = ?", userID, models.VerificationPending).
+		Order("created_at DESC").
+		First(&verification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoPendingVerification
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// ensureNumberAvailable rejects a document number already approved for a different account.
+func (s *IdentityVerificationService) ensureNumberAvailable(userID uuid.UUID, number string) error {
+	var count int64
+	// Soft-deleted accounts still hold their number in the unique index
+	if err := s.database.Unscoped().Model(&models.User{}).
+		Where("verification_number = ? AND id <> ?", number, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrVerificationNumberInUse
+	}
+	return nil
+}
+
+func (s *IdentityVerificationService) notify(userID, title, message string) {
+	if s.notificationService == nil {
+		return
+	}
+	if err := s.notificationService.SendRealTimeNotification(userID, title, message, models.System, nil); err != nil {
+		log.Printf("Failed to notify user %s: %v", userID, err)
+	}
+}
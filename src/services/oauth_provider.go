@@ -0,0 +1,332 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"foglio/v2/src/config"
+	"foglio/v2/src/dto"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrUnsupportedOAuthProvider = errors.New("unsupported OAuth provider")
+
+// OAuthProvider is an identity provider users can sign in with. OpenID Connect providers only need an
+// issuer; their endpoints are discovered and profiles come from the standard userinfo claims.
+type OAuthProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	issuer       string
+	authURL      string
+	tokenURL     string
+	userInfoURL  string
+	profile      func(p *OAuthProvider, accessToken string) (*dto.OAuthUserDto, error)
+}
+
+var oauthClient = &http.Client{Timeout: 30 * time.Second}
+
+// OAuthProviders returns the providers with credentials configured, built-in ones first.
+func OAuthProviders() []*OAuthProvider {
+	cfg := config.AppConfig
+	providers := []*OAuthProvider{
+		{
+			Name:         "google",
+			DisplayName:  "Google",
+			clientID:     cfg.GoogleClientId,
+			clientSecret: cfg.GoogleClientSecret,
+			redirectURL:  cfg.GoogleRedirectUrl,
+			scopes:       []string{"openid", "email", "profile"},
+			authURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+			tokenURL:     "https://oauth2.googleapis.com/token",
+			userInfoURL:  "https://openidconnect.googleapis.com/v1/userinfo",
+			profile:      oidcProfile,
+		},
+		{
+			Name:         "github",
+			DisplayName:  "GitHub",
+			clientID:     cfg.GithubClientId,
+			clientSecret: cfg.GithubClientSecret,
+			redirectURL:  cfg.GithubRedirectUrl,
+			scopes:       []string{"read:user", "user:email"},
+			authURL:      "https://github.com/login/oauth/authorize",
+			tokenURL:     "https://github.com/login/oauth/access_token",
+			userInfoURL:  "https://api.github.com/user",
+			profile:      githubProfile,
+		},
+	}
+
+	for _, oidc := range cfg.OIDCProviders {
+		providers = append(providers, &OAuthProvider{
+			Name:         oidc.Name,
+			DisplayName:  oidc.DisplayName,
+			clientID:     oidc.ClientId,
+			clientSecret: oidc.ClientSecret,
+			redirectURL:  oidc.RedirectUrl,
+			scopes:       oidc.Scopes,
+			issuer:       oidc.Issuer,
+			profile:      oidcProfile,
+		})
+	}
+
+	configured := providers[:0]
+	for _, provider := range providers {
+		if provider.clientID != "" && provider.redirectURL != "" && (provider.authURL != "" || provider.issuer != "") {
+			configured = append(configured, provider)
+		}
+	}
+	return configured
+}
+
+func findOAuthProvider(name string) (*OAuthProvider, error) {
+	for _, provider := range OAuthProviders() {
+		if provider.Name == name {
+			return provider, nil
+		}
+	}
+	return nil, ErrUnsupportedOAuthProvider
+}
+
+// AuthorizationURL builds the URL the user is sent to. The PKCE challenge is always sent; providers that
+// do not support it ignore it.
+func (p *OAuthProvider) AuthorizationURL(state, codeChallenge string) (string, error) {
+	if err := p.discover(); err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("client_id", p.clientID)
+	query.Set("redirect_uri", p.redirectURL)
+	query.Set("response_type", "code")
+	query.Set("scope", strings.Join(p.scopes, " "))
+	query.Set("state", state)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(p.authURL, "?") {
+		separator = "&"
+	}
+	return p.authURL + separator + query.Encode(), nil
+}
+
+// Exchange trades the authorization code and PKCE verifier for an access token.
+func (p *OAuthProvider) Exchange(code, codeVerifier string) (string, error) {
+	if err := p.discover(); err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("client_id", p.clientID)
+	form.Set("client_secret", p.clientSecret)
+	form.Set("code", code)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("grant_type", "authorization_code")
+	form.Set("redirect_uri", p.redirectURL)
+
+	req, err := http.NewRequest(http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokenResp OAuthTokenResponse
+	err = doOAuthRequest(req, &tokenResp)
+	if tokenResp.Error != "" {
+		return "", fmt.Errorf("failed to get access token: %s", tokenResp.Error)
+	}
+	if err != nil {
+		return "", err
+	}
+	if tokenResp.AccessToken == "" {
+		return "", errors.New("failed to get access token")
+	}
+
+	return tokenResp.AccessToken, nil
+}
+
+// Profile fetches the signed-in account from the provider.
+func (p *OAuthProvider) Profile(accessToken string) (*dto.OAuthUserDto, error) {
+	if err := p.discover(); err != nil {
+		return nil, err
+	}
+
+	user, err := p.profile(p, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	user.Provider = p.Name
+
+	if user.ID == "" {
+		return nil, errors.New("could not get user ID from OAuth provider")
+	}
+	if user.Email == "" {
+		return nil, errors.New("could not get user email from OAuth provider")
+	}
+	return user, nil
+}
+
+func (p *OAuthProvider) getJSON(endpoint, accessToken string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	return doOAuthRequest(req, out)
+}
+
+func doOAuthRequest(req *http.Request, out interface{}) error {
+	resp, err := oauthClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}()
+
+	// Failures are still decoded, since token endpoints describe the error in the body
+	decodeErr := json.NewDecoder(resp.Body).Decode(out)
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("OAuth provider returned %s", resp.Status)
+	}
+	return decodeErr
+}
+
+// oidcProfile reads the standard OpenID Connect userinfo claims.
+func oidcProfile(p *OAuthProvider, accessToken string) (*dto.OAuthUserDto, error) {
+	var claims struct {
+		Sub               string      `json:"sub"`
+		Email             string      `json:"email"`
+		EmailVerified     interface{} `json:"email_verified"` // Some providers send it as a string
+		Name              string      `json:"name"`
+		PreferredUsername string      `json:"preferred_username"`
+		Picture           string      `json:"picture"`
+	}
+	if err := p.getJSON(p.userInfoURL, accessToken, &claims); err != nil {
+		return nil, err
+	}
+
+	user := &dto.OAuthUserDto{
+		ID:            claims.Sub,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified == true || claims.EmailVerified == "true",
+		Name:          claims.Name,
+		Avatar:        claims.Picture,
+	}
+	if user.Name == "" {
+		user.Name = claims.PreferredUsername
+	}
+	return user, nil
+}
+
+// githubProfile reads the GitHub user. GitHub is not an OpenID provider, and the email on the profile is
+// only the public one, so the verified primary address is looked up separately.
+func githubProfile(p *OAuthProvider, accessToken string) (*dto.OAuthUserDto, error) {
+	var githubUser struct {
+		ID        int    `json:"id"`
+		Email     string `json:"email"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+		Login     string `json:"login"`
+	}
+	if err := p.getJSON(p.userInfoURL, accessToken, &githubUser); err != nil {
+		return nil, err
+	}
+
+	user := &dto.OAuthUserDto{
+		Email:  githubUser.Email,
+		Name:   githubUser.Name,
+		Avatar: githubUser.AvatarURL,
+	}
+	if githubUser.ID != 0 {
+		user.ID = fmt.Sprintf("%d", githubUser.ID)
+	}
+	if user.Name == "" {
+		user.Name = githubUser.Login
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.getJSON("https://api.github.com/user/emails", accessToken, &emails); err != nil {
+		log.Printf("Failed to get GitHub emails: %v", err)
+	}
+	for _, email := range emails {
+		if email.Primary && email.Verified {
+			user.Email = email.Email
+			user.EmailVerified = true
+			break
+		}
+	}
+
+	return user, nil
+}
+
+type oidcDiscovery struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	fetchedAt             time.Time
+}
+
+const oidcDiscoveryTTL = time.Hour
+
+var oidcDiscoveryCache = struct {
+	sync.Mutex
+	documents map[string]oidcDiscovery
+}{documents: map[string]oidcDiscovery{}}
+
+// discover fills in the endpoints of an OpenID Connect provider from its discovery document, which is
+// cached for an hour. Providers with fixed endpoints are left alone.
+func (p *OAuthProvider) discover() error {
+	if p.issuer == "" || p.authURL != "" {
+		return nil
+	}
+
+	oidcDiscoveryCache.Lock()
+	document, ok := oidcDiscoveryCache.documents[p.issuer]
+	oidcDiscoveryCache.Unlock()
+
+	if !ok || time.Since(document.fetchedAt) > oidcDiscoveryTTL {
+		req, err := http.NewRequest(http.MethodGet, p.issuer+"/.well-known/openid-configuration", nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/json")
+
+		var fetched oidcDiscovery
+		if err := doOAuthRequest(req, &fetched); err != nil {
+			return fmt.Errorf("OpenID discovery for %s failed: %w", p.Name, err)
+		}
+		if fetched.AuthorizationEndpoint == "" || fetched.TokenEndpoint == "" || fetched.UserinfoEndpoint == "" {
+			return fmt.Errorf("OpenID discovery for %s is missing endpoints", p.Name)
+		}
+
+		fetched.fetchedAt = time.Now()
+		oidcDiscoveryCache.Lock()
+		oidcDiscoveryCache.documents[p.issuer] = fetched
+		oidcDiscoveryCache.Unlock()
+		document = fetched
+	}
+
+	p.authURL = document.AuthorizationEndpoint
+	p.tokenURL = document.TokenEndpoint
+	p.userInfoURL = document.UserinfoEndpoint
+	return nil
+}
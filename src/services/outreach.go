@@ -0,0 +1,432 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// outreachSendInterval spaces out bulk messages so a large send does not flood chat delivery.
+	outreachSendInterval  = 100 * time.Millisecond
+	outreachPageSize      = 100
+	maxOutreachRecipients = 500
+)
+
+var (
+	ErrTemplateNotFound     = errors.New("message template not found")
+	ErrInvalidTemplate      = errors.New("message template is invalid")
+	ErrRecruiterOnly        = errors.New("only recruiters can send outreach messages")
+	ErrMissingBulkContent   = errors.New("template_id or content is required")
+	ErrTooManyRecipients    = fmt.Errorf("bulk sends are limited to %d recipients; narrow the filters", maxOutreachRecipients)
+	ErrApplicationNotFound  = errors.New("application not found")
+	ErrOutreachLimitReached = errors.New("daily outreach limit reached")
+)
+
+type OutreachService struct {
+	database *gorm.DB
+	chat     *ChatService
+	jobs     *JobService
+}
+
+func NewOutreachService(database *gorm.DB, chat *ChatService, jobs *JobService) *OutreachService {
+	return &OutreachService{
+		database: database,
+		chat:     chat,
+		jobs:     jobs,
+	}
+}
+
+func (s *OutreachService) GetTemplates(userID string) ([]models.MessageTemplate, error) {
+	if _, err := s.loadRecruiter(userID); err != nil {
+		return nil, err
+	}
+
+	templates := []models.MessageTemplate{}
+	if err := s.database.Where("owner_id = ?", userID).Order("name ASC").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (s *OutreachService) CreateTemplate(userID string, payload dto.CreateMessageTemplateDto) (*models.MessageTemplate, error) {
+	recruiter, err := s.loadRecruiter(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMessageTemplate(payload.Content); err != nil {
+		return nil, err
+	}
+
+	messageTemplate := models.MessageTemplate{
+		OwnerID: recruiter.ID,
+		Name:    strings.TrimSpace(payload.Name),
+		Content: payload.Content,
+	}
+	if err := s.database.Create(&messageTemplate).Error; err != nil {
+		return nil, err
+	}
+	return &messageTemplate, nil
+}
+
+func (s *OutreachService) UpdateTemplate(userID, templateID string, payload dto.UpdateMessageTemplateDto) (*models.MessageTemplate, error) {
+	messageTemplate, err := s.loadTemplate(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if payload.Name != nil {
+		updates["name"] = strings.TrimSpace(*payload.Name)
+	}
+	if payload.Content != nil {
+		if err := validateMessageTemplate(*payload.Content); err != nil {
+			return nil, err
+		}
+		updates["content"] = *payload.Content
+	}
+
+	if len(updates) > 0 {
+		if err := s.database.Model(messageTemplate).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+	return messageTemplate, nil
+}
+
+func (s *OutreachService) DeleteTemplate(userID, templateID string) error {
+	messageTemplate, err := s.loadTemplate(userID, templateID)
+	if err != nil {
+		return err
+	}
+	return s.database.Delete(messageTemplate).Error
+}
+
+// PreviewTemplate renders a template for one of the recruiter's applicants without sending it.
+func (s *OutreachService) PreviewTemplate(userID, templateID string, payload dto.PreviewMessageTemplateDto) (*dto.TemplatePreviewResponse, error) {
+	messageTemplate, err := s.loadTemplate(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	var application models.JobApplication
+	if err := s.database.
+		Joins("JOIN jobs ON jobs.id = job_applications.job_id AND jobs.deleted_at IS NULL").
+		Preload("Applicant").
+		Preload("Job.Company").
+		Preload("Job.CreatedByUser").
+		Where("job_applications.id = ? AND jobs.created_by = ?", payload.ApplicationID, messageTemplate.OwnerID).
+		First(&application).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrApplicationNotFound
+		}
+		return nil, err
+	}
+
+	content, err := renderMessageTemplate(messageTemplate.Content, templateData(&application.Applicant, &application.Job, &application.Job.CreatedByUser))
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TemplatePreviewResponse{Content: content}, nil
+}
+
+// BulkSend messages every applicant to the recruiter's job that matches the filters, one chat message
+// each. Every recipient reserves a slot in the daily cap before sending; recipients beyond the cap are
+// skipped and failed sends give their slot back.
+func (s *OutreachService) BulkSend(userID, jobID string, payload dto.BulkSendDto) (*dto.BulkSendResponse, error) {
+	recruiter, err := s.loadRecruiter(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	content := payload.Content
+	var templateID *uuid.UUID
+	switch {
+	case payload.TemplateID != "":
+		messageTemplate, err := s.loadTemplate(userID, payload.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		content, templateID = messageTemplate.Content, &messageTemplate.ID
+	case strings.TrimSpace(content) == "":
+		return nil, ErrMissingBulkContent
+	}
+	if err := validateMessageTemplate(content); err != nil {
+		return nil, err
+	}
+
+	applications, err := s.filteredApplications(userID, jobID, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var job models.Job
+	if err := s.database.Preload("Company").First(&job, "id = ?", jobID).Error; err != nil {
+		return nil, err
+	}
+
+	quota, err := s.quota(recruiter)
+	if err != nil {
+		return nil, err
+	}
+	if quota.Remaining == 0 && len(applications) > 0 {
+		return nil, ErrOutreachLimitReached
+	}
+
+	response := &dto.BulkSendResponse{Results: []dto.BulkSendResult{}}
+	throttle := time.NewTicker(outreachSendInterval)
+	defer throttle.Stop()
+
+	seen := make(map[uuid.UUID]bool, len(applications))
+	for i := range applications {
+		application := &applications[i]
+		if seen[application.ApplicantID] {
+			continue
+		}
+		seen[application.ApplicantID] = true
+
+		record := models.OutreachMessage{
+			SenderID:      recruiter.ID,
+			JobID:         application.JobID,
+			ApplicationID: application.ID,
+			RecipientID:   application.ApplicantID,
+			TemplateID:    templateID,
+		}
+		reserved, err := s.reserve(recruiter, &record)
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			<-throttle.C
+			s.deliver(recruiter, application, templateData(&application.Applicant, &job, recruiter), content, &record)
+		}
+
+		response.Results = append(response.Results, toBulkSendResult(&record))
+		switch record.Status {
+		case models.OutreachStatusSent:
+			response.Sent++
+		case models.OutreachStatusFailed:
+			response.Failed++
+		case models.OutreachStatusSkipped:
+			response.Skipped++
+		}
+	}
+	response.Total = len(response.Results)
+
+	if quota, err = s.quota(recruiter); err != nil {
+		return nil, err
+	}
+	response.Quota = *quota
+
+	return response, nil
+}
+
+func (s *OutreachService) GetQuota(userID string) (*dto.OutreachQuota, error) {
+	recruiter, err := s.loadRecruiter(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.quota(recruiter)
+}
+
+// filteredApplications walks every page of the job's applications that match the bulk send filters.
+func (s *OutreachService) filteredApplications(userID, jobID string, payload dto.BulkSendDto) ([]models.JobApplication, error) {
+	params := dto.JobApplicationPagination{
+		Pagination:     dto.Pagination{Page: 1, Limit: outreachPageSize},
+		Status:         payload.Status,
+		SubmissionDate: payload.SubmissionDate,
+	}
+
+	var applications []models.JobApplication
+	for {
+		page, err := s.jobs.GetApplicationsByJob(userID, jobID, params)
+		if err != nil {
+			return nil, err
+		}
+		if page.TotalItems > maxOutreachRecipients {
+			return nil, ErrTooManyRecipients
+		}
+
+		applications = append(applications, page.Data...)
+		if params.Page >= page.TotalPages {
+			return applications, nil
+		}
+		params.Page++
+	}
+}
+
+// reserve records the recipient, claiming a slot in the sender's daily cap if one is left. The sender's
+// row is locked so concurrent bulk sends cannot overshoot the cap.
+func (s *OutreachService) reserve(sender *models.User, record *models.OutreachMessage) (bool, error) {
+	reserved := false
+	err := s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", sender.ID).
+			First(&models.User{}).Error; err != nil {
+			return err
+		}
+
+		used, err := s.usedToday(tx, sender.ID)
+		if err != nil {
+			return err
+		}
+
+		if used < sender.DailyOutreachLimit() {
+			record.Status = models.OutreachStatusPending
+			reserved = true
+		} else {
+			reason := ErrOutreachLimitReached.Error()
+			record.Status = models.OutreachStatusSkipped
+			record.Error = &reason
+		}
+		return tx.Create(record).Error
+	})
+	return reserved, err
+}
+
+func (s *OutreachService) deliver(sender *models.User, application *models.JobApplication, data dto.TemplateData, content string, record *models.OutreachMessage) {
+	updates := map[string]interface{}{}
+
+	message, err := renderMessageTemplate(content, data)
+	if err == nil {
+		var sent *models.Message
+		if sent, err = s.chat.SendMessage(sender.ID.String(), dto.SendMessageDto{
+			RecipientID: application.ApplicantID.String(),
+			Content:     message,
+		}); err == nil {
+			record.Status, record.MessageID = models.OutreachStatusSent, &sent.ID
+			updates["message_id"] = sent.ID
+		}
+	}
+	if err != nil {
+		reason := err.Error()
+		record.Status, record.Error = models.OutreachStatusFailed, &reason
+		updates["error"] = reason
+	}
+
+	updates["status"] = record.Status
+	s.database.Model(record).Updates(updates)
+}
+
+func (s *OutreachService) quota(sender *models.User) (*dto.OutreachQuota, error) {
+	used, err := s.usedToday(s.database, sender.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := sender.DailyOutreachLimit()
+	return &dto.OutreachQuota{
+		Limit:     limit,
+		Used:      used,
+		Remaining: max(limit-used, 0),
+		ResetsAt:  startOfOutreachDay().Add(24 * time.Hour),
+	}, nil
+}
+
+func (s *OutreachService) usedToday(db *gorm.DB, senderID uuid.UUID) (int, error) {
+	var used int64
+	err := db.Model(&models.OutreachMessage{}).
+		Where("sender_id = ? AND created_at >= ?", senderID, startOfOutreachDay()).
+		Where("status IN ?", []models.OutreachStatus{models.OutreachStatusPending, models.OutreachStatusSent}).
+		Count(&used).Error
+	return int(used), err
+}
+
+func (s *OutreachService) loadRecruiter(userID string) (*models.User, error) {
+	var user models.User
+	if err := s.database.Preload("CurrentSubscription.Subscription").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	if !user.IsRecruiter {
+		return nil, ErrRecruiterOnly
+	}
+	return &user, nil
+}
+
+func (s *OutreachService) loadTemplate(userID, templateID string) (*models.MessageTemplate, error) {
+	if _, err := uuid.Parse(templateID); err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	var messageTemplate models.MessageTemplate
+	if err := s.database.Where("id = ? AND owner_id = ?", templateID, userID).First(&messageTemplate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	return &messageTemplate, nil
+}
+
+func startOfOutreachDay() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+func templateData(applicant *models.User, job *models.Job, recruiter *models.User) dto.TemplateData {
+	firstName := applicant.Name
+	if fields := strings.Fields(firstName); len(fields) > 0 {
+		firstName = fields[0]
+	}
+
+	return dto.TemplateData{
+		CandidateName:      applicant.Name,
+		CandidateFirstName: firstName,
+		JobTitle:           job.Title,
+		CompanyName:        job.Company.Name,
+		RecruiterName:      recruiter.Name,
+	}
+}
+
+// validateMessageTemplate parses the template and renders it against sample data so unknown variables
+// are rejected when the template is saved rather than when it is sent.
+func validateMessageTemplate(content string) error {
+	_, err := renderMessageTemplate(content, dto.TemplateData{
+		CandidateName:      "Ada Lovelace",
+		CandidateFirstName: "Ada",
+		JobTitle:           "Software Engineer",
+		CompanyName:        "Foglio",
+		RecruiterName:      "Grace Hopper",
+	})
+	return err
+}
+
+func renderMessageTemplate(content string, data dto.TemplateData) (string, error) {
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	message := strings.TrimSpace(rendered.String())
+	if message == "" {
+		return "", fmt.Errorf("%w: message is empty", ErrInvalidTemplate)
+	}
+	return message, nil
+}
+
+func toBulkSendResult(record *models.OutreachMessage) dto.BulkSendResult {
+	result := dto.BulkSendResult{
+		ApplicationID: record.ApplicationID.String(),
+		RecipientID:   record.RecipientID.String(),
+		Status:        record.Status,
+		Error:         record.Error,
+	}
+	if record.MessageID != nil {
+		id := record.MessageID.String()
+		result.MessageID = &id
+	}
+	return result
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
 	"foglio/v2/src/models"
 	"regexp"
 	"strings"
@@ -59,6 +60,9 @@ func (s *PortfolioService) CreatePortfolio(userId string, payload dto.CreatePort
 
 	template := "default"
 	if payload.Template != "" {
+		if !lib.IsSiteTemplate(payload.Template) {
+			return nil, ErrTemplateInvalid
+		}
 		template = payload.Template
 	}
 
@@ -174,6 +178,9 @@ func (s *PortfolioService) UpdatePortfolio(userId string, payload dto.UpdatePort
 		portfolio.Logo = payload.Logo
 	}
 	if payload.Template != nil {
+		if !lib.IsSiteTemplate(*payload.Template) {
+			return nil, ErrTemplateInvalid
+		}
 		portfolio.Template = *payload.Template
 	}
 	if payload.Theme != nil {
@@ -0,0 +1,484 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"foglio/v2/src/config"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrTemplateInvalid = errors.New("unknown portfolio template")
+
+// siteDescriptionLength is how much of the bio is used as the page description when none is set.
+const siteDescriptionLength = 160
+
+var siteAnchorPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// defaultSiteSections are shown, in this order, for portfolios that have not laid out their own sections.
+var defaultSiteSections = []struct {
+	Type  string
+	Title string
+}{
+	{"hero", ""},
+	{"about", "About"},
+	{"projects", "Projects"},
+	{"experience", "Experience"},
+	{"education", "Education"},
+	{"skills", "Skills"},
+	{"certifications", "Certifications"},
+	{"languages", "Languages"},
+	{"contact", "Contact"},
+}
+
+// GetPortfolioSite loads a published portfolio with its owner's profile for rendering as a public site.
+func (s *PortfolioService) GetPortfolioSite(slug string) (*dto.PortfolioSite, error) {
+	portfolio, _, err := s.GetPortfolioBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.loadSiteUser(portfolio)
+	if err != nil {
+		return nil, err
+	}
+	return buildPortfolioSite(portfolio, user, siteURL(portfolio, user)), nil
+}
+
+// GetPortfolioSiteByUser loads the published portfolio of a user, for a site served on their own domain.
+func (s *PortfolioService) GetPortfolioSiteByUser(userID uuid.UUID) (*dto.PortfolioSite, error) {
+	var portfolio models.Portfolio
+	if err := s.database.Preload("Sections", func(db *gorm.DB) *gorm.DB {
+		return db.Where("is_visible = ?", true).Order("sort_order ASC")
+	}).Where("user_id = ? AND status = ? AND is_public = ?", userID, models.PortfolioStatusPublished, true).First(&portfolio).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPortfolioNotFound
+		}
+		return nil, err
+	}
+	s.database.Model(&portfolio).UpdateColumn("view_count", gorm.Expr("view_count + ?", 1))
+
+	user, err := s.loadSiteUser(&portfolio)
+	if err != nil {
+		return nil, err
+	}
+	return buildPortfolioSite(&portfolio, user, siteURL(&portfolio, user)), nil
+}
+
+// siteURL is where a portfolio is primarily served: its verified custom domain, then its subdomain, then
+// its /p/ page. Other addresses point search engines here.
+func siteURL(portfolio *models.Portfolio, user *models.User) string {
+	if domain := user.Domain; domain != nil {
+		if domain.CustomDomain != "" && domain.CustomDomainStatus == models.DomainStatusVerified && user.CanUseCustomDomain() {
+			return "https://" + domain.CustomDomain + "/"
+		}
+		if domain.Subdomain != "" {
+			return "https://" + SubdomainHost(domain.Subdomain) + "/"
+		}
+	}
+	return strings.TrimSuffix(config.AppConfig.ApiUrl, "/") + "/p/" + url.PathEscape(portfolio.Slug)
+}
+
+// loadSiteUser loads the profile shown on a portfolio site. Portfolios of suspended accounts are not shown.
+func (s *PortfolioService) loadSiteUser(portfolio *models.Portfolio) (*models.User, error) {
+	byStartDate := func(db *gorm.DB) *gorm.DB { return db.Order("start_date DESC") }
+
+	var user models.User
+	if err := s.database.
+		Preload("Projects", byStartDate).
+		Preload("Projects.Stack").
+		Preload("Projects.Highlights").
+		Preload("Experiences", byStartDate).
+		Preload("Experiences.Highlights").
+		Preload("Experiences.Technologies").
+		Preload("Education", byStartDate).
+		Preload("Education.Highlights").
+		Preload("Certifications", func(db *gorm.DB) *gorm.DB { return db.Order("issue_date DESC") }).
+		Preload("Languages").
+		Preload("CurrentSubscription.Subscription").
+		First(&user, "id = ?", portfolio.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPortfolioNotFound
+		}
+		return nil, err
+	}
+	if user.IsSuspended() {
+		return nil, ErrPortfolioNotFound
+	}
+	return &user, nil
+}
+
+func buildPortfolioSite(portfolio *models.Portfolio, user *models.User, canonicalURL string) *dto.PortfolioSite {
+	settings := portfolio.Settings
+	if settings == nil {
+		settings = &models.PortfolioSettings{
+			ShowProjects:       true,
+			ShowExperiences:    true,
+			ShowEducation:      true,
+			ShowSkills:         true,
+			ShowCertifications: true,
+			ShowContact:        true,
+			ShowSocialLinks:    true,
+		}
+	}
+
+	site := &dto.PortfolioSite{
+		Template:   portfolio.Template,
+		Title:      portfolio.Title,
+		Canonical:  canonicalURL,
+		Name:       user.Name,
+		Headline:   siteText(user.Headline),
+		Tagline:    siteText(portfolio.Tagline),
+		Bio:        siteText(portfolio.Bio),
+		Location:   siteText(user.Location),
+		Avatar:     siteText(user.Image),
+		CoverImage: siteText(portfolio.CoverImage),
+		Logo:       siteText(portfolio.Logo),
+		CustomCSS:  siteText(portfolio.CustomCSS),
+		Year:       time.Now().Year(),
+	}
+	if !lib.IsSiteTemplate(site.Template) {
+		site.Template = lib.SiteTemplates[0]
+	}
+	if site.Bio == "" {
+		site.Bio = siteText(user.Summary)
+	}
+	if theme := portfolio.Theme; theme != nil {
+		site.Theme = dto.SiteTheme{
+			PrimaryColor:    theme.PrimaryColor,
+			SecondaryColor:  theme.SecondaryColor,
+			AccentColor:     theme.AccentColor,
+			TextColor:       theme.TextColor,
+			BackgroundColor: theme.BackgroundColor,
+			FontFamily:      theme.FontFamily,
+			FontSize:        theme.FontSize,
+		}
+	}
+
+	if settings.ShowProjects {
+		for _, project := range user.Projects {
+			item := dto.SiteProject{
+				Title:       project.Title,
+				Description: project.Description,
+				Image:       siteText(project.Image),
+				URL:         siteText(project.URL),
+				Period:      sitePeriod(project.StartDate, project.EndDate),
+			}
+			for _, stack := range project.Stack {
+				item.Stack = append(item.Stack, stack.Name)
+			}
+			for _, highlight := range project.Highlights {
+				item.Highlights = append(item.Highlights, highlight.Text)
+			}
+			site.Projects = append(site.Projects, item)
+		}
+	}
+	if settings.ShowExperiences {
+		for _, experience := range user.Experiences {
+			startDate := experience.StartDate
+			item := dto.SiteExperience{
+				Role:        experience.Role,
+				Company:     experience.CompanyName,
+				Location:    siteText(experience.Location),
+				Period:      sitePeriod(&startDate, experience.EndDate),
+				Description: experience.Description,
+			}
+			for _, highlight := range experience.Highlights {
+				item.Highlights = append(item.Highlights, highlight.Text)
+			}
+			for _, technology := range experience.Technologies {
+				item.Technologies = append(item.Technologies, technology.Name)
+			}
+			site.Experiences = append(site.Experiences, item)
+		}
+	}
+	if settings.ShowEducation {
+		for _, education := range user.Education {
+			startDate := education.StartDate
+			item := dto.SiteEducation{
+				Degree:      education.Degree,
+				Field:       education.Field,
+				Institution: education.Institution,
+				Location:    siteText(education.Location),
+				Period:      sitePeriod(&startDate, education.EndDate),
+			}
+			for _, highlight := range education.Highlights {
+				item.Highlights = append(item.Highlights, highlight.Text)
+			}
+			site.Education = append(site.Education, item)
+		}
+	}
+	if settings.ShowCertifications {
+		for _, certification := range user.Certifications {
+			site.Certs = append(site.Certs, dto.SiteCertification{
+				Name:   certification.Name,
+				Issuer: certification.Issuer,
+				Issued: certification.IssueDate.Format("Jan 2006"),
+				URL:    siteText(certification.URL),
+			})
+		}
+	}
+	if settings.ShowSkills {
+		site.Skills = user.Skills
+		for _, language := range user.Languages {
+			site.Languages = append(site.Languages, dto.SiteLanguage{Name: language.Name, Proficiency: language.Proficiency})
+		}
+	}
+	if settings.ShowSocialLinks {
+		site.Links = siteLinks(user.SocialMedia)
+	}
+
+	site.Sections = siteSections(portfolio.Sections, site, settings)
+	applySiteSEO(site, portfolio.SEO)
+	site.JSONLD = personJSONLD(site, user)
+	return site
+}
+
+// siteSections lays out the portfolio's visible sections, or the default layout when it has none. Sections
+// that would be empty, or that the portfolio's settings hide, are left out.
+func siteSections(sections []models.PortfolioSection, site *dto.PortfolioSite, settings *models.PortfolioSettings) []dto.SiteSection {
+	var layout []dto.SiteSection
+	if len(sections) == 0 {
+		for _, section := range defaultSiteSections {
+			layout = append(layout, dto.SiteSection{Type: section.Type, Title: section.Title})
+		}
+	}
+	for _, section := range sections {
+		if !section.IsVisible {
+			continue
+		}
+		layout = append(layout, dto.SiteSection{Type: section.Type, Title: section.Title, Content: siteText(section.Content)})
+	}
+	// The hero holds the page's heading, so every page gets one
+	hasHero := false
+	for _, section := range layout {
+		hasHero = hasHero || section.Type == "hero"
+	}
+	if !hasHero {
+		layout = append([]dto.SiteSection{{Type: "hero"}}, layout...)
+	}
+
+	var result []dto.SiteSection
+	used := map[string]int{}
+	for _, section := range layout {
+		switch section.Type {
+		case "hero":
+		case "about":
+			if section.Content == "" {
+				section.Content = site.Bio
+			}
+			if section.Content == "" {
+				continue
+			}
+		case "projects":
+			if len(site.Projects) == 0 && section.Content == "" {
+				continue
+			}
+		case "experience":
+			if len(site.Experiences) == 0 && section.Content == "" {
+				continue
+			}
+		case "education":
+			if len(site.Education) == 0 && section.Content == "" {
+				continue
+			}
+		case "skills":
+			if len(site.Skills) == 0 && section.Content == "" {
+				continue
+			}
+		case "certifications":
+			if len(site.Certs) == 0 && section.Content == "" {
+				continue
+			}
+		case "languages":
+			if len(site.Languages) == 0 && section.Content == "" {
+				continue
+			}
+		case "contact":
+			if !settings.ShowContact || (len(site.Links) == 0 && site.Location == "" && section.Content == "") {
+				continue
+			}
+		default:
+			section.Type = "custom"
+			if section.Content == "" {
+				continue
+			}
+		}
+
+		// Anchors come from the title so links to a section survive reordering
+		anchor := strings.Trim(siteAnchorPattern.ReplaceAllString(strings.ToLower(section.Title), "-"), "-")
+		if anchor == "" {
+			anchor = section.Type
+		}
+		if used[anchor]++; used[anchor] > 1 {
+			anchor = fmt.Sprintf("%s-%d", anchor, used[anchor])
+		}
+		section.Anchor = anchor
+		result = append(result, section)
+	}
+	return result
+}
+
+// applySiteSEO fills in the page title, description and share image, preferring what the portfolio sets.
+func applySiteSEO(site *dto.PortfolioSite, seo *models.PortfolioSEO) {
+	if seo == nil {
+		seo = &models.PortfolioSEO{}
+	}
+
+	if title := siteText(seo.MetaTitle); title != "" {
+		site.Title = title
+	} else if site.Name != "" && !strings.Contains(site.Title, site.Name) {
+		site.Title = site.Title + " | " + site.Name
+	}
+
+	site.Description = siteText(seo.MetaDescription)
+	if site.Description == "" {
+		site.Description = site.Tagline
+	}
+	if site.Description == "" {
+		site.Description = truncateText(strings.Join(strings.Fields(site.Bio), " "), siteDescriptionLength)
+	}
+
+	site.Keywords = siteText(seo.MetaKeywords)
+	if canonical := siteText(seo.Canonical); canonical != "" {
+		site.Canonical = canonical
+	}
+
+	for _, image := range []string{siteText(seo.OgImage), site.CoverImage, site.Avatar} {
+		if image != "" {
+			site.Image = image
+			break
+		}
+	}
+}
+
+// personJSONLD describes the portfolio owner as a schema.org Person.
+func personJSONLD(site *dto.PortfolioSite, user *models.User) map[string]interface{} {
+	person := map[string]interface{}{
+		"@context": "https://schema.org",
+		"@type":    "Person",
+		"name":     site.Name,
+		"url":      site.Canonical,
+	}
+	if site.Avatar != "" {
+		person["image"] = site.Avatar
+	}
+	if site.Headline != "" {
+		person["jobTitle"] = site.Headline
+	}
+	if site.Description != "" {
+		person["description"] = site.Description
+	}
+	if site.Location != "" {
+		person["address"] = map[string]interface{}{"@type": "PostalAddress", "addressLocality": site.Location}
+	}
+	if len(site.Skills) > 0 {
+		person["knowsAbout"] = site.Skills
+	}
+	if len(site.Links) > 0 {
+		var sameAs []string
+		for _, link := range site.Links {
+			sameAs = append(sameAs, link.URL)
+		}
+		person["sameAs"] = sameAs
+	}
+	for _, experience := range user.Experiences {
+		if experience.EndDate == nil {
+			person["worksFor"] = map[string]interface{}{"@type": "Organization", "name": experience.CompanyName}
+			break
+		}
+	}
+	var alumniOf []map[string]interface{}
+	for _, education := range site.Education {
+		alumniOf = append(alumniOf, map[string]interface{}{"@type": "EducationalOrganization", "name": education.Institution})
+	}
+	if len(alumniOf) > 0 {
+		person["alumniOf"] = alumniOf
+	}
+	return person
+}
+
+func siteLinks(socialMedia *models.SocialMedia) []dto.SiteLink {
+	if socialMedia == nil {
+		return nil
+	}
+
+	var links []dto.SiteLink
+	for _, link := range []struct {
+		label string
+		url   *string
+	}{
+		{"LinkedIn", socialMedia.LinkedIn},
+		{"GitHub", socialMedia.GitHub},
+		{"Twitter", socialMedia.Twitter},
+		{"Instagram", socialMedia.Instagram},
+		{"Facebook", socialMedia.Facebook},
+		{"Medium", socialMedia.Medium},
+		{"YouTube", socialMedia.YouTube},
+		{"Blog", socialMedia.Blog},
+	} {
+		// Only web links are shown; they are also listed as the person's profiles in the page's markup
+		if url := siteText(link.url); strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") {
+			links = append(links, dto.SiteLink{Label: link.label, URL: url})
+		}
+	}
+	return links
+}
+
+func sitePeriod(start, end *time.Time) string {
+	if start == nil || start.IsZero() {
+		return ""
+	}
+	if end == nil {
+		return start.Format("Jan 2006") + " – Present"
+	}
+	return start.Format("Jan 2006") + " – " + end.Format("Jan 2006")
+}
+
+// NewSiteError describes the page shown in place of a portfolio that cannot be shown: a 404 when it does
+// not exist, is not published or no portfolio is served at the host, and a 500 when err is anything else.
+func NewSiteError(err error) *dto.SiteError {
+	page := &dto.SiteError{
+		Status:    http.StatusNotFound,
+		Title:     "Portfolio not found",
+		Message:   "This portfolio doesn't exist or isn't published yet.",
+		ClientUrl: config.AppConfig.ClientUrl,
+		Year:      time.Now().Year(),
+	}
+	if !errors.Is(err, ErrPortfolioNotFound) && !errors.Is(err, ErrSiteHostNotFound) {
+		page.Status = http.StatusInternalServerError
+		page.Title = "Something went wrong"
+		page.Message = "This portfolio can't be shown right now. Please try again in a moment."
+	}
+	return page
+}
+
+func siteText(text *string) string {
+	if text == nil {
+		return ""
+	}
+	return strings.TrimSpace(*text)
+}
+
+// truncateText shortens text to at most limit characters, breaking between words and marking the cut.
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	cut := string(runes[:limit-1])
+	if i := strings.LastIndex(cut, " "); i > limit/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"errors"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRoleNotFound        = errors.New("role not found")
+	ErrRoleNameTaken       = errors.New("a role with this name already exists")
+	ErrSystemRoleImmutable = errors.New("system roles cannot be modified or deleted")
+	ErrInvalidPermission   = errors.New("unknown permission")
+	ErrRoleAlreadyAssigned = errors.New("user already has this role")
+	ErrRoleNotAssigned     = errors.New("user does not have this role")
+	ErrLastSuperAdmin      = errors.New("cannot revoke the last super admin")
+	ErrUserNotFound        = errors.New("user not found")
+)
+
+type RoleService struct {
+	database *gorm.DB
+}
+
+func NewRoleService(database *gorm.DB) *RoleService {
+	return &RoleService{
+		database: database,
+	}
+}
+
+func (s *RoleService) ListRoles() ([]models.Role, error) {
+	var roles []models.Role
+	if err := s.database.Order("is_system DESC, name ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (s *RoleService) GetRole(id string) (*models.Role, error) {
+	return s.findRole(id)
+}
+
+func (s *RoleService) CreateRole(payload dto.CreateRoleDto) (*models.Role, error) {
+	if err := validatePermissions(payload.Permissions); err != nil {
+		return nil, err
+	}
+
+	name := normalizeRoleName(payload.Name)
+	if err := s.ensureRoleNameAvailable(name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	role := models.Role{
+		Name:        name,
+		Description: payload.Description,
+		Permissions: payload.Permissions,
+	}
+	if err := s.database.Create(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (s *RoleService) UpdateRole(id string, payload dto.UpdateRoleDto) (*models.Role, error) {
+	role, err := s.findRole(id)
+	if err != nil {
+		return nil, err
+	}
+	if role.IsSystem {
+		return nil, ErrSystemRoleImmutable
+	}
+
+	updates := map[string]interface{}{}
+	if payload.Name != nil {
+		name := normalizeRoleName(*payload.Name)
+		if err := s.ensureRoleNameAvailable(name, role.ID); err != nil {
+			return nil, err
+		}
+		updates["name"] = name
+	}
+	if payload.Description != nil {
+		updates["description"] = *payload.Description
+	}
+	if payload.Permissions != nil {
+		if err := validatePermissions(payload.Permissions); err != nil {
+			return nil, err
+		}
+		updates["permissions"] = pq.StringArray(payload.Permissions)
+	}
+
+	if len(updates) > 0 {
+		if err := s.database.Model(role).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return s.findRole(id)
+}
+
+func (s *RoleService) DeleteRole(id string) error {
+	role, err := s.findRole(id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return ErrSystemRoleImmutable
+	}
+
+	return s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(role).Error
+	})
+}
+
+func (s *RoleService) GetUserRoles(userID string) ([]models.UserRole, error) {
+	if err := s.ensureUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	var assignments []models.UserRole
+	if err := s.database.Preload("Role").
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+func (s *RoleService) AssignRole(userID, roleID, assignedByID string) (*models.UserRole, error) {
+	if err := s.ensureUserExists(userID); err != nil {
+		return nil, err
+	}
+	role, err := s.findRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.database.Model(&models.UserRole{}).
+		Where("user_id = ? AND role_id = ?", userID, role.ID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrRoleAlreadyAssigned
+	}
+
+	assignment := models.UserRole{
+		UserID: uuid.MustParse(userID),
+		RoleID: role.ID,
+	}
+	if assignedBy, err := uuid.Parse(assignedByID); err == nil {
+		assignment.AssignedByID = &assignedBy
+	}
+	if err := s.database.Create(&assignment).Error; err != nil {
+		return nil, err
+	}
+
+	assignment.Role = *role
+	return &assignment, nil
+}
+
+func (s *RoleService) RevokeRole(userID, roleID string) error {
+	role, err := s.findRole(roleID)
+	if err != nil {
+		return err
+	}
+
+	return s.database.Transaction(func(tx *gorm.DB) error {
+		var assignment models.UserRole
+		if err := tx.Where("user_id = ? AND role_id = ?", userID, role.ID).First(&assignment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRoleNotAssigned
+			}
+			return err
+		}
+
+		// The holders are locked so two admins revoking each other at once cannot both see the other remain
+		if role.Name == models.SuperAdminRole {
+			var holders []models.UserRole
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("role_id = ?", role.ID).
+				Find(&holders).Error; err != nil {
+				return err
+			}
+			if len(holders) <= 1 {
+				return ErrLastSuperAdmin
+			}
+		}
+
+		return tx.Delete(&assignment).Error
+	})
+}
+
+// GetUserPermissions returns the raw permissions granted by every role assigned to the user, wildcard included.
+func (s *RoleService) GetUserPermissions(userID string) ([]string, error) {
+	var permissions []string
+	if err := s.database.Raw(`
+		SELECT DISTINCT unnest(r.permissions)
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = ?`, userID).
+		Scan(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (s *RoleService) GetUserAccess(userID string) (*dto.AccessResponse, error) {
+	assignments, err := s.GetUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &dto.AccessResponse{
+		Roles:       make([]string, 0, len(assignments)),
+		Permissions: []models.Permission{},
+	}
+	var granted []string
+	for _, assignment := range assignments {
+		access.Roles = append(access.Roles, assignment.Role.Name)
+		granted = append(granted, assignment.Role.Permissions...)
+	}
+	for _, permission := range models.AllPermissions {
+		if models.HasPermission(granted, permission) {
+			access.Permissions = append(access.Permissions, permission)
+		}
+	}
+	return access, nil
+}
+
+// IsStaff reports whether the user holds any admin role.
+func (s *RoleService) IsStaff(userID uuid.UUID) (bool, error) {
+	var count int64
+	if err := s.database.Model(&models.UserRole{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *RoleService) findRole(id string) (*models.Role, error) {
+	roleID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrRoleNotFound
+	}
+
+	var role models.Role
+	if err := s.database.First(&role, "id = ?", roleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (s *RoleService) ensureRoleNameAvailable(name string, excludeID uuid.UUID) error {
+	var count int64
+	if err := s.database.Model(&models.Role{}).
+		Where("name = ? AND id <> ?", name, excludeID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleNameTaken
+	}
+	return nil
+}
+
+func (s *RoleService) ensureUserExists(userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return ErrUserNotFound
+	}
+	if err := s.database.Select("id").First(&models.User{}, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func normalizeRoleName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "_")
+}
+
+func validatePermissions(permissions []string) error {
+	if slices.ContainsFunc(permissions, func(p string) bool { return !models.IsValidPermission(p) }) {
+		return ErrInvalidPermission
+	}
+	return nil
+}
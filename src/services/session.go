@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionRevoked      = errors.New("session has been revoked")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+)
+
+// sessionHub drops the live WebSocket connections of revoked sessions; set once at startup.
+var sessionHub *lib.Hub
+
+func SetSessionHub(hub *lib.Hub) {
+	sessionHub = hub
+}
+
+// dropSessionSockets closes the user's sockets that authenticated with one of the revoked sessions. Sockets
+// are only authenticated when they connect, so without this they would outlive the session.
+func dropSessionSockets(userID string, sessionIDs ...string) {
+	if sessionHub != nil {
+		sessionHub.RevokeSessions(userID, sessionIDs)
+	}
+}
+
+type SessionService struct {
+	database *gorm.DB
+}
+
+func NewSessionService(database *gorm.DB) *SessionService {
+	return &SessionService{database: database}
+}
+
+// CreateSession signs the user in on a new device and issues its first token pair.
+func (s *SessionService) CreateSession(userID uuid.UUID, client dto.SessionClient) (*dto.TokenPair, error) {
+	refreshToken, err := lib.GenerateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		UserID:           userID,
+		RefreshTokenHash: lib.HashToken(refreshToken),
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(config.AppConfig.RefreshTokenExpiresIn),
+	}
+	if err := s.database.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	return s.tokenPair(&session, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new token pair, rotating the refresh token. Presenting a token
+// that was already rotated out means it was copied, so the whole session is revoked.
+func (s *SessionService) Refresh(refreshToken string, client dto.SessionClient) (*models.Session, *dto.TokenPair, error) {
+	hash := lib.HashToken(refreshToken)
+
+	var session models.Session
+	if err := s.database.Where("refresh_token_hash = ?", hash).First(&session).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+		return s.detectReuse(hash)
+	}
+	if !session.IsActive() {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	var user models.User
+	if err := s.database.First(&user, "id = ?", session.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+	if user.IsSuspended() {
+		return nil, nil, ErrAccountSuspended
+	}
+
+	newToken, err := lib.GenerateSecureToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	// Matching on the old hash makes concurrent refreshes with the same token succeed only once
+	result := s.database.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":     lib.HashToken(newToken),
+			"previous_refresh_token": hash,
+			"user_agent":             client.UserAgent,
+			"ip_address":             client.IPAddress,
+			"last_used_at":           now,
+			"expires_at":             now.Add(config.AppConfig.RefreshTokenExpiresIn),
+		})
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	pair, err := s.tokenPair(&session, newToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &session, pair, nil
+}
+
+// ListSessions returns the user's signed-in devices, most recently used first.
+func (s *SessionService) ListSessions(userID, currentSessionID string) ([]dto.SessionResponse, error) {
+	var sessions []models.Session
+	if err := s.database.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = dto.SessionResponse{Session: session, Current: session.ID.String() == currentSessionID}
+	}
+	return response, nil
+}
+
+func (s *SessionService) RevokeSession(userID, sessionID string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return ErrSessionNotFound
+	}
+
+	result := s.database.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+
+	dropSessionSockets(userID, sessionID)
+	return nil
+}
+
+// RevokeAllSessions signs the user out everywhere except the given session, which may be empty.
+func (s *SessionService) RevokeAllSessions(userID, exceptSessionID string) (int64, error) {
+	var revoked []models.Session
+	query := s.database.Model(&revoked).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptSessionID != "" {
+		query = query.Where("id <> ?", exceptSessionID)
+	}
+
+	result := query.Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	sessionIDs := make([]string, 0, len(revoked))
+	for _, session := range revoked {
+		sessionIDs = append(sessionIDs, session.ID.String())
+	}
+	dropSessionSockets(userID, sessionIDs...)
+	return result.RowsAffected, nil
+}
+
+// knownDeviceLookback is how many of the user's latest sessions a sign-in is compared against.
+const knownDeviceLookback = 50
+
+// IsKnownDevice reports whether the user has signed in before with the same browser and OS from the same
+// network. A user signing in for the first time has nothing to compare against, so their device counts as
+// known.
+func (s *SessionService) IsKnownDevice(userID uuid.UUID, client dto.SessionClient) (bool, error) {
+	var sessions []models.Session
+	if err := s.database.Select("user_agent", "ip_address").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(knownDeviceLookback).
+		Find(&sessions).Error; err != nil {
+		return false, err
+	}
+	if len(sessions) == 0 {
+		return true, nil
+	}
+
+	browser, os, network := detectBrowser(client.UserAgent), detectOS(client.UserAgent), ipNetwork(client.IPAddress)
+	for _, session := range sessions {
+		if detectBrowser(session.UserAgent) == browser && detectOS(session.UserAgent) == os &&
+			ipNetwork(session.IPAddress) == network {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ipNetwork stands in for the location of an IP address: its /24 for IPv4 and /48 for IPv6, so a new lease
+// from the same provider is not a new location.
+func ipNetwork(address string) string {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return address
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+func (s *SessionService) detectReuse(hash string) (*models.Session, *dto.TokenPair, error) {
+	var session models.Session
+	if err := s.database.Where("previous_refresh_token = ?", hash).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+	if !session.IsActive() {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	if err := s.database.Model(&session).Update("revoked_at", time.Now()).Error; err != nil {
+		return nil, nil, err
+	}
+	dropSessionSockets(session.UserID.String(), session.ID.String())
+	return &session, nil, ErrRefreshTokenReused
+}
+
+func (s *SessionService) tokenPair(session *models.Session, refreshToken string) (*dto.TokenPair, error) {
+	token, err := lib.GenerateToken(session.UserID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TokenPair{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.AppConfig.AccessTokenExpiresIn.Seconds()),
+	}, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/models"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrSiteHostNotFound = errors.New("no portfolio is served at this host")
+
+// siteHostTTL is how long a host lookup is reused. Changes made through DomainService clear the entry on
+// this server straight away; other servers pick them up once it expires.
+const siteHostTTL = time.Minute
+
+// siteHostCacheSize bounds the cache, which also remembers hosts that serve nothing.
+const siteHostCacheSize = 10000
+
+type siteHost struct {
+	userID   uuid.UUID
+	found    bool
+	cachedAt time.Time
+}
+
+var siteHostCache = struct {
+	sync.Mutex
+	hosts map[string]siteHost
+}{hosts: map[string]siteHost{}}
+
+type SiteHostService struct {
+	database *gorm.DB
+}
+
+func NewSiteHostService(database *gorm.DB) *SiteHostService {
+	return &SiteHostService{
+		database: database,
+	}
+}
+
+// SubdomainHost is the host a claimed subdomain is served at.
+func SubdomainHost(subdomain string) string {
+	return subdomain + "." + config.AppConfig.SitesDomain
+}
+
+// IsSiteHost reports whether requests for host should be answered with a portfolio site rather than the
+// API: a subdomain of the sites domain that is not reserved, or a custom domain ResolveHost serves a
+// portfolio at. Every other host reaches the API.
+func (s *SiteHostService) IsSiteHost(host string) bool {
+	host = normalizeHost(host)
+	if isLocalHost(host) || isAPIHost(host) {
+		return false
+	}
+	if subdomain, ok := strings.CutSuffix(host, "."+config.AppConfig.SitesDomain); ok {
+		return !reservedSubdomains[subdomain]
+	}
+	if host == config.AppConfig.SitesDomain {
+		return false
+	}
+
+	_, err := s.ResolveHost(host)
+	if err != nil && !errors.Is(err, ErrSiteHostNotFound) {
+		log.Printf("Failed to resolve site host %s: %v", host, err)
+	}
+	return err == nil
+}
+
+// isCustomDomainCandidate reports whether host could be a custom domain: any host except the API's own,
+// the sites domain and its subdomains, and local addresses.
+func isCustomDomainCandidate(host string) bool {
+	host = normalizeHost(host)
+	domain := config.AppConfig.SitesDomain
+	return !isLocalHost(host) && !isAPIHost(host) && host != domain && !strings.HasSuffix(host, "."+domain)
+}
+
+func isLocalHost(host string) bool {
+	return host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") || isIPAddress(host)
+}
+
+func isAPIHost(host string) bool {
+	for _, apiHost := range config.AppConfig.ApiHosts {
+		if host == apiHost {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveHost returns the user whose portfolio is served at host: the owner of a claimed subdomain of the
+// sites domain, or of a verified custom domain their plan still allows.
+func (s *SiteHostService) ResolveHost(host string) (uuid.UUID, error) {
+	host = normalizeHost(host)
+
+	siteHostCache.Lock()
+	cached, ok := siteHostCache.hosts[host]
+	siteHostCache.Unlock()
+	if ok && time.Since(cached.cachedAt) < siteHostTTL {
+		if !cached.found {
+			return uuid.Nil, ErrSiteHostNotFound
+		}
+		return cached.userID, nil
+	}
+
+	userID, err := s.lookupHost(host)
+	if err != nil && !errors.Is(err, ErrSiteHostNotFound) {
+		return uuid.Nil, err
+	}
+
+	siteHostCache.Lock()
+	if len(siteHostCache.hosts) >= siteHostCacheSize {
+		for cachedHost, entry := range siteHostCache.hosts {
+			if time.Since(entry.cachedAt) >= siteHostTTL {
+				delete(siteHostCache.hosts, cachedHost)
+			}
+		}
+		if len(siteHostCache.hosts) >= siteHostCacheSize {
+			siteHostCache.hosts = map[string]siteHost{}
+		}
+	}
+	siteHostCache.hosts[host] = siteHost{userID: userID, found: err == nil, cachedAt: time.Now()}
+	siteHostCache.Unlock()
+	return userID, err
+}
+
+func (s *SiteHostService) lookupHost(host string) (uuid.UUID, error) {
+	subdomain, isSubdomain := strings.CutSuffix(host, "."+config.AppConfig.SitesDomain)
+	if isSubdomain && strings.Contains(subdomain, ".") {
+		return uuid.Nil, ErrSiteHostNotFound
+	}
+
+	query := s.database.Preload("CurrentSubscription.Subscription")
+	if isSubdomain {
+		query = query.Where("domain->>'subdomain' = ?", subdomain)
+	} else {
+		query = query.Where("domain->>'custom_domain' = ? AND domain->>'custom_domain_status' = ?", host, models.DomainStatusVerified)
+	}
+
+	var user models.User
+	if err := query.First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, ErrSiteHostNotFound
+		}
+		return uuid.Nil, err
+	}
+	// Custom domains stop working when the plan that allowed them lapses
+	if !isSubdomain && !user.CanUseCustomDomain() {
+		return uuid.Nil, ErrSiteHostNotFound
+	}
+	return user.ID, nil
+}
+
+// InvalidateSiteHosts drops cached lookups so the next request for these hosts reads the database.
+func InvalidateSiteHosts(hosts ...string) {
+	siteHostCache.Lock()
+	defer siteHostCache.Unlock()
+	for _, host := range hosts {
+		if host != "" {
+			delete(siteHostCache.hosts, normalizeHost(host))
+		}
+	}
+}
+
+// invalidateDomain drops the cached lookups for a user's subdomain and custom domain.
+func invalidateDomain(domain *models.Domain) {
+	if domain == nil {
+		return
+	}
+	if domain.Subdomain != "" {
+		InvalidateSiteHosts(SubdomainHost(domain.Subdomain))
+	}
+	InvalidateSiteHosts(domain.CustomDomain)
+}
+
+// normalizeHost lowercases a Host header value and drops its port and trailing dot.
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if i := strings.LastIndex(host, ":"); i != -1 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+	return strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+}
+
+func isIPAddress(host string) bool {
+	return net.ParseIP(host) != nil
+}
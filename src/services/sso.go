@@ -0,0 +1,835 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"foglio/v2/src/config"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/google/uuid"
+	dsig "github.com/russellhaering/goxmldsig"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotCompanyRecruiter   = errors.New("only recruiters of a company can manage its SSO")
+	ErrSSONotConfigured      = errors.New("SSO is not set up for this company")
+	ErrSSONotAvailable       = errors.New("SSO is not available for this email domain")
+	ErrInvalidIdPMetadata    = errors.New("IdP metadata is invalid")
+	ErrIdPMetadataRequired   = errors.New("add the IdP metadata before enabling SSO")
+	ErrNoVerifiedSSODomain   = errors.New("verify an email domain before enforcing SSO")
+	ErrSSODomainNotFound     = errors.New("domain not found")
+	ErrSSODomainTaken        = errors.New("domain is already verified by another company")
+	ErrSSODomainNotVerified  = errors.New("verification record not found, DNS changes can take a while to appear")
+	ErrInvalidSAMLResponse   = errors.New("SSO response is invalid or has expired")
+	ErrSSOEmailNotAllowed    = errors.New("the IdP signed in a user outside the company's verified domains")
+	ErrSSOUserInOtherCompany = errors.New("this account belongs to another company")
+	ErrSSORequired           = errors.New("your organization requires signing in with SSO")
+)
+
+// samlRequestTTL is how long the user has to sign in at the IdP after an SP-initiated request.
+const samlRequestTTL = 10 * time.Minute
+
+// maxIdPMetadataSize caps fetched IdP metadata, which is usually a few kilobytes.
+const maxIdPMetadataSize = 1 << 20
+
+var metadataClient = &http.Client{Timeout: 30 * time.Second}
+
+// SAMLAccount is the user an IdP signed in, read from the assertion.
+type SAMLAccount struct {
+	Subject string
+	Email   string
+	Name    string
+}
+
+type SSOService struct {
+	database   *gorm.DB
+	tokens     *VerificationTokenService
+	identities *IdentityService
+}
+
+func NewSSOService(database *gorm.DB) *SSOService {
+	return &SSOService{
+		database:   database,
+		tokens:     NewVerificationTokenService(database),
+		identities: NewIdentityService(database),
+	}
+}
+
+// GetConfig returns the SSO setup of the user's company. The service provider side is created on first
+// use, since the IdP needs its entity ID and ACS URL before it can produce metadata.
+func (s *SSOService) GetConfig(userID string) (*dto.SSOConfigResponse, error) {
+	companyID, err := s.managedCompany(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sso, err := s.ensureConfig(companyID)
+	if err != nil {
+		return nil, err
+	}
+	return ssoConfigResponse(sso), nil
+}
+
+func (s *SSOService) UpdateConfig(userID string, payload dto.UpdateSSOConfigRequest) (*dto.SSOConfigResponse, error) {
+	companyID, err := s.managedCompany(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sso, err := s.ensureConfig(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := []byte(payload.IdPMetadata)
+	if payload.IdPMetadataURL != "" {
+		metadata, err = fetchIdPMetadata(payload.IdPMetadataURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(metadata) > 0 {
+		entity, err := parseIdPMetadata(metadata)
+		if err != nil {
+			return nil, err
+		}
+		sso.IdPEntityID = entity.EntityID
+		sso.IdPSSOURL = idpSSOURL(entity)
+		sso.IdPMetadata = string(metadata)
+	}
+
+	if payload.Enabled != nil {
+		sso.Enabled = *payload.Enabled
+	}
+	if payload.EnforceSSO != nil {
+		sso.EnforceSSO = *payload.EnforceSSO
+	}
+	if payload.AllowIdPInitiated != nil {
+		sso.AllowIdPInitiated = *payload.AllowIdPInitiated
+	}
+
+	if sso.Enabled && sso.IdPMetadata == "" {
+		return nil, ErrIdPMetadataRequired
+	}
+	if sso.EnforceSSO {
+		if !sso.Enabled {
+			return nil, ErrIdPMetadataRequired
+		}
+		if !s.hasVerifiedDomain(companyID) {
+			return nil, ErrNoVerifiedSSODomain
+		}
+	}
+
+	if err := s.database.Omit(clause.Associations).Save(sso).Error; err != nil {
+		return nil, err
+	}
+	return ssoConfigResponse(sso), nil
+}
+
+// DeleteConfig turns SSO off for the user's company and forgets the IdP and the claimed domains.
+func (s *SSOService) DeleteConfig(userID string) error {
+	companyID, err := s.managedCompany(userID)
+	if err != nil {
+		return err
+	}
+
+	return s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("company_id = ?", companyID).Delete(&models.CompanySSODomain{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("company_id = ?", companyID).Delete(&models.SAMLRequest{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("company_id = ?", companyID).Delete(&models.CompanySSO{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSSONotConfigured
+		}
+		return nil
+	})
+}
+
+// AddDomain claims an email domain for the user's company. It does nothing until verified.
+func (s *SSOService) AddDomain(userID string, domain string) (*models.CompanySSODomain, error) {
+	companyID, err := s.managedCompany(userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ensureConfig(companyID); err != nil {
+		return nil, err
+	}
+
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+
+	var record models.CompanySSODomain
+	err = s.database.Where("company_id = ? AND domain = ?", companyID, domain).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		record = models.CompanySSODomain{
+			CompanyID:         companyID,
+			Domain:            domain,
+			VerificationToken: models.GenerateVerificationToken(),
+		}
+		err = s.database.Create(&record).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record.DnsRecord = record.VerificationRecord()
+	return &record, nil
+}
+
+// VerifyDomain checks the domain's TXT record. A domain can only be verified by one company.
+func (s *SSOService) VerifyDomain(userID, domainID string) (*models.CompanySSODomain, error) {
+	record, err := s.findDomain(userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.VerifiedAt == nil {
+		var taken int64
+		if err := s.database.Model(&models.CompanySSODomain{}).
+			Where("domain = ? AND company_id <> ? AND verified_at IS NOT NULL", record.Domain, record.CompanyID).
+			Count(&taken).Error; err != nil {
+			return nil, err
+		}
+		if taken > 0 {
+			return nil, ErrSSODomainTaken
+		}
+
+		dnsRecord := record.VerificationRecord()
+		if !verifyTxtRecord(dnsRecord.Name, dnsRecord.Value) {
+			return nil, ErrSSODomainNotVerified
+		}
+
+		now := time.Now()
+		if err := s.database.Model(record).Update("verified_at", now).Error; err != nil {
+			return nil, err
+		}
+		record.VerifiedAt = &now
+	}
+
+	record.DnsRecord = record.VerificationRecord()
+	return record, nil
+}
+
+// DeleteDomain gives up the domain. Enforcement stops once no verified domain is left.
+func (s *SSOService) DeleteDomain(userID, domainID string) error {
+	record, err := s.findDomain(userID, domainID)
+	if err != nil {
+		return err
+	}
+
+	return s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(record).Error; err != nil {
+			return err
+		}
+		if !NewSSOService(tx).hasVerifiedDomain(record.CompanyID) {
+			return tx.Model(&models.CompanySSO{}).
+				Where("company_id = ?", record.CompanyID).
+				Update("enforce_sso", false).Error
+		}
+		return nil
+	})
+}
+
+// Metadata returns the service provider metadata to upload to the company's IdP.
+func (s *SSOService) Metadata(companyID string) ([]byte, error) {
+	var sso models.CompanySSO
+	if err := s.database.Where("company_id = ?", companyID).First(&sso).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSSONotConfigured
+		}
+		return nil, err
+	}
+
+	sp, err := serviceProvider(&sso)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := xml.MarshalIndent(sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), metadata...), nil
+}
+
+// BeginLogin starts an SP-initiated sign-in for the company that verified the email's domain and returns
+// the IdP URL to send the browser to.
+func (s *SSOService) BeginLogin(email string) (string, error) {
+	sso, err := s.configForEmail(email)
+	if err != nil {
+		return "", err
+	}
+	if sso == nil || !sso.Enabled {
+		return "", ErrSSONotAvailable
+	}
+
+	sp, err := serviceProvider(sso)
+	if err != nil {
+		return "", err
+	}
+
+	request, err := sp.MakeAuthenticationRequest(sp.GetSSOBindingLocation(saml.HTTPRedirectBinding), saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return "", err
+	}
+	redirect, err := request.Redirect("", sp)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.database.Where("expires_at < ?", time.Now()).Delete(&models.SAMLRequest{}).Error; err != nil {
+		log.Printf("Failed to clear expired SAML requests: %v", err)
+	}
+	if err := s.database.Create(&models.SAMLRequest{
+		ID:        request.ID,
+		CompanyID: sso.CompanyID,
+		ExpiresAt: time.Now().Add(samlRequestTTL),
+	}).Error; err != nil {
+		return "", err
+	}
+
+	return redirect.String(), nil
+}
+
+// FinishLogin validates the IdP's response posted to the company's ACS URL, provisions the user into the
+// company and returns a one-time code the client exchanges for a session.
+func (s *SSOService) FinishLogin(companyID string, samlResponse string) (string, *models.User, error) {
+	var sso models.CompanySSO
+	if err := s.database.Where("company_id = ? AND enabled = ?", companyID, true).First(&sso).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, ErrSSONotConfigured
+		}
+		return "", nil, err
+	}
+
+	sp, err := serviceProvider(&sso)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var requestIDs []string
+	if err := s.database.Model(&models.SAMLRequest{}).
+		Where("company_id = ? AND expires_at > ?", sso.CompanyID, time.Now()).
+		Pluck("id", &requestIDs).Error; err != nil {
+		return "", nil, err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return "", nil, ErrInvalidSAMLResponse
+	}
+	assertion, err := sp.ParseXMLResponse(raw, requestIDs, sp.AcsURL)
+	if err != nil {
+		var invalid *saml.InvalidResponseError
+		if errors.As(err, &invalid) {
+			log.Printf("Rejected SAML response for company %s: %v", sso.CompanyID, invalid.PrivateErr)
+		}
+		return "", nil, ErrInvalidSAMLResponse
+	}
+
+	if err := s.redeemAssertion(&sso, assertion); err != nil {
+		return "", nil, err
+	}
+
+	account := samlAccount(assertion)
+	if account.Subject == "" || account.Email == "" {
+		return "", nil, ErrInvalidSAMLResponse
+	}
+	if !s.ownsEmailDomain(sso.CompanyID, account.Email) {
+		return "", nil, ErrSSOEmailNotAllowed
+	}
+
+	user, err := s.provision(sso.CompanyID, account)
+	if err != nil {
+		return "", nil, err
+	}
+
+	code, err := s.tokens.Issue(user.ID, models.PurposeSSOLogin, sso.CompanyID.String())
+	if err != nil {
+		return "", nil, err
+	}
+	return code, user, nil
+}
+
+// RequiresSSO reports whether the email's domain belongs to a company that enforces SSO, in which case the
+// user cannot sign in with a password.
+func (s *SSOService) RequiresSSO(email string) (bool, error) {
+	sso, err := s.configForEmail(email)
+	if err != nil || sso == nil {
+		return false, err
+	}
+	return sso.Enabled && sso.EnforceSSO, nil
+}
+
+// redeemAssertion makes sure the assertion signs in only once, and that a response to an SP-initiated
+// request uses up that request.
+func (s *SSOService) redeemAssertion(sso *models.CompanySSO, assertion *saml.Assertion) error {
+	expiresAt := time.Now().Add(saml.MaxIssueDelay)
+	if assertion.Conditions != nil && assertion.Conditions.NotOnOrAfter.After(expiresAt) {
+		expiresAt = assertion.Conditions.NotOnOrAfter
+	}
+	expiresAt = expiresAt.Add(saml.MaxClockSkew)
+
+	if err := s.database.Where("expires_at < ?", time.Now()).Delete(&models.SAMLAssertion{}).Error; err != nil {
+		log.Printf("Failed to clear expired SAML assertions: %v", err)
+	}
+	result := s.database.
+		Where(models.SAMLAssertion{ID: assertion.ID}).
+		Attrs(models.SAMLAssertion{CompanyID: sso.CompanyID, ExpiresAt: expiresAt}).
+		FirstOrCreate(&models.SAMLAssertion{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidSAMLResponse
+	}
+
+	var inResponseTo string
+	if assertion.Subject != nil {
+		for _, confirmation := range assertion.Subject.SubjectConfirmations {
+			if confirmation.SubjectConfirmationData != nil && confirmation.SubjectConfirmationData.InResponseTo != "" {
+				inResponseTo = confirmation.SubjectConfirmationData.InResponseTo
+			}
+		}
+	}
+	if inResponseTo == "" {
+		return nil
+	}
+
+	result = s.database.Where("id = ? AND company_id = ?", inResponseTo, sso.CompanyID).Delete(&models.SAMLRequest{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 && !sso.AllowIdPInitiated {
+		return ErrInvalidSAMLResponse
+	}
+	return nil
+}
+
+// provision finds the IdP's user, creating a recruiter account just in time, and makes sure it belongs to
+// the company.
+func (s *SSOService) provision(companyID uuid.UUID, account SAMLAccount) (*models.User, error) {
+	identity := &dto.OAuthUserDto{
+		ID:            account.Subject,
+		Email:         account.Email,
+		EmailVerified: true,
+		Name:          account.Name,
+		Provider:      "saml:" + companyID.String(),
+	}
+
+	user, err := s.identities.FindUser(identity)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if user == nil {
+		var existing models.User
+		err := s.database.Where("email = ?", account.Email).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		if err == nil {
+			if existing.CompanyID != nil && *existing.CompanyID != companyID {
+				return nil, ErrSSOUserInOtherCompany
+			}
+			if _, err := s.identities.Link(existing.ID, identity); err != nil {
+				return nil, err
+			}
+			user = &existing
+		} else {
+			newUser := models.User{
+				Name:        account.Name,
+				Email:       account.Email,
+				Username:    lib.GenerateUsername(account.Name),
+				Provider:    "saml",
+				Verified:    true,
+				IsRecruiter: true,
+				CompanyID:   &companyID,
+			}
+			err := s.database.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&newUser).Error; err != nil {
+					return err
+				}
+				_, err := NewIdentityService(tx).Link(newUser.ID, identity)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &newUser, nil
+		}
+	}
+
+	if user.CompanyID != nil && *user.CompanyID != companyID {
+		return nil, ErrSSOUserInOtherCompany
+	}
+	if user.CompanyID == nil || !user.IsRecruiter {
+		if err := s.database.Model(user).Updates(map[string]interface{}{
+			"company_id":   companyID,
+			"is_recruiter": true,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+func (s *SSOService) managedCompany(userID string) (uuid.UUID, error) {
+	var user models.User
+	if err := s.database.Where("id = ?", userID).First(&user).Error; err != nil {
+		return uuid.Nil, err
+	}
+	if !user.IsRecruiter || user.CompanyID == nil {
+		return uuid.Nil, ErrNotCompanyRecruiter
+	}
+	return *user.CompanyID, nil
+}
+
+func (s *SSOService) ensureConfig(companyID uuid.UUID) (*models.CompanySSO, error) {
+	var sso models.CompanySSO
+	err := s.database.Preload("Domains").Where("company_id = ?", companyID).First(&sso).Error
+	if err == nil {
+		return &sso, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	certificate, key, err := generateSPKeyPair(companyID)
+	if err != nil {
+		return nil, err
+	}
+	sso = models.CompanySSO{
+		CompanyID:         companyID,
+		AllowIdPInitiated: true,
+		SPCertificate:     certificate,
+		SPPrivateKey:      key,
+	}
+	if err := s.database.Create(&sso).Error; err != nil {
+		return nil, err
+	}
+	return &sso, nil
+}
+
+func (s *SSOService) findDomain(userID, domainID string) (*models.CompanySSODomain, error) {
+	companyID, err := s.managedCompany(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var record models.CompanySSODomain
+	if err := s.database.Where("id = ? AND company_id = ?", domainID, companyID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSSODomainNotFound
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *SSOService) hasVerifiedDomain(companyID uuid.UUID) bool {
+	var count int64
+	s.database.Model(&models.CompanySSODomain{}).
+		Where("company_id = ? AND verified_at IS NOT NULL", companyID).
+		Count(&count)
+	return count > 0
+}
+
+func (s *SSOService) ownsEmailDomain(companyID uuid.UUID, email string) bool {
+	var count int64
+	s.database.Model(&models.CompanySSODomain{}).
+		Where("company_id = ? AND domain = ? AND verified_at IS NOT NULL", companyID, emailDomain(email)).
+		Count(&count)
+	return count > 0
+}
+
+// configForEmail returns the SSO setup of the company that verified the email's domain, or nil.
+func (s *SSOService) configForEmail(email string) (*models.CompanySSO, error) {
+	domain := emailDomain(email)
+	if domain == "" {
+		return nil, nil
+	}
+
+	var sso models.CompanySSO
+	err := s.database.
+		Joins("JOIN company_sso_domains ON company_sso_domains.company_id = company_ssos.company_id").
+		Where("company_sso_domains.domain = ? AND company_sso_domains.verified_at IS NOT NULL", domain).
+		First(&sso).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sso, nil
+}
+
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+// ssoBaseURL is where the company's service provider endpoints live.
+func ssoBaseURL(companyID uuid.UUID) string {
+	return strings.TrimSuffix(config.AppConfig.ApiUrl, "/") + "/api/v2/sso/" + companyID.String()
+}
+
+func ssoConfigResponse(sso *models.CompanySSO) *dto.SSOConfigResponse {
+	for i := range sso.Domains {
+		sso.Domains[i].DnsRecord = sso.Domains[i].VerificationRecord()
+	}
+	base := ssoBaseURL(sso.CompanyID)
+	return &dto.SSOConfigResponse{
+		CompanySSO:  *sso,
+		SPEntityID:  base + "/metadata",
+		ACSURL:      base + "/acs",
+		MetadataURL: base + "/metadata",
+	}
+}
+
+// serviceProvider builds the SAML service provider for the company. Its entity ID is the metadata URL.
+func serviceProvider(sso *models.CompanySSO) (*saml.ServiceProvider, error) {
+	certBlock, _ := pem.Decode([]byte(sso.SPCertificate))
+	keyBlock, _ := pem.Decode([]byte(sso.SPPrivateKey))
+	if certBlock == nil || keyBlock == nil {
+		return nil, errors.New("SSO service provider key pair is corrupt")
+	}
+	certificate, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	base := ssoBaseURL(sso.CompanyID)
+	metadataURL, err := url.Parse(base + "/metadata")
+	if err != nil {
+		return nil, err
+	}
+	acsURL, err := url.Parse(base + "/acs")
+	if err != nil {
+		return nil, err
+	}
+
+	sp := &saml.ServiceProvider{
+		EntityID:          metadataURL.String(),
+		Key:               key,
+		Certificate:       certificate,
+		MetadataURL:       *metadataURL,
+		AcsURL:            *acsURL,
+		AuthnNameIDFormat: saml.UnspecifiedNameIDFormat,
+		SignatureMethod:   dsig.RSASHA256SignatureMethod,
+		AllowIDPInitiated: sso.AllowIdPInitiated,
+	}
+	if sso.IdPMetadata != "" {
+		entity, err := parseIdPMetadata([]byte(sso.IdPMetadata))
+		if err != nil {
+			return nil, err
+		}
+		sp.IDPMetadata = entity
+	}
+	return sp, nil
+}
+
+// generateSPKeyPair creates the PEM certificate and private key the company's service provider signs
+// requests and receives encrypted assertions with.
+func generateSPKeyPair(companyID uuid.UUID) (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "Foglio SSO " + companyID.String()},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return string(certificate), string(privateKey), nil
+}
+
+func fetchIdPMetadata(metadataURL string) ([]byte, error) {
+	if !strings.HasPrefix(metadataURL, "https://") {
+		return nil, fmt.Errorf("%w: metadata URL must use https", ErrInvalidIdPMetadata)
+	}
+
+	resp, err := metadataClient.Get(metadataURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIdPMetadata, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: metadata URL returned %s", ErrInvalidIdPMetadata, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxIdPMetadataSize))
+}
+
+// parseIdPMetadata reads an IdP's metadata, which may be a single entity or a federation listing several,
+// in which case the first IdP is used.
+func parseIdPMetadata(data []byte) (*saml.EntityDescriptor, error) {
+	var entity saml.EntityDescriptor
+	if err := xml.Unmarshal(data, &entity); err != nil {
+		var entities saml.EntitiesDescriptor
+		if err := xml.Unmarshal(data, &entities); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidIdPMetadata, err)
+		}
+		for _, candidate := range entities.EntityDescriptors {
+			if len(candidate.IDPSSODescriptors) > 0 {
+				entity = candidate
+				break
+			}
+		}
+	}
+
+	if entity.EntityID == "" || len(entity.IDPSSODescriptors) == 0 {
+		return nil, fmt.Errorf("%w: no identity provider found", ErrInvalidIdPMetadata)
+	}
+	if idpSSOURL(&entity) == "" {
+		return nil, fmt.Errorf("%w: no HTTP-Redirect single sign-on service", ErrInvalidIdPMetadata)
+	}
+
+	hasSigningKey := false
+	for _, descriptor := range entity.IDPSSODescriptors {
+		for _, key := range descriptor.KeyDescriptors {
+			if (key.Use == "" || key.Use == "signing") && len(key.KeyInfo.X509Data.X509Certificates) > 0 {
+				hasSigningKey = true
+			}
+		}
+	}
+	if !hasSigningKey {
+		return nil, fmt.Errorf("%w: no signing certificate", ErrInvalidIdPMetadata)
+	}
+
+	return &entity, nil
+}
+
+func idpSSOURL(entity *saml.EntityDescriptor) string {
+	for _, descriptor := range entity.IDPSSODescriptors {
+		for _, service := range descriptor.SingleSignOnServices {
+			if service.Binding == saml.HTTPRedirectBinding {
+				return service.Location
+			}
+		}
+	}
+	return ""
+}
+
+// samlAttributeNames are the attribute names IdPs commonly use, checked in order.
+var samlAttributeNames = map[string][]string{
+	"email": {
+		"email", "mail", "emailaddress",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress",
+		"urn:oid:0.9.2342.19200300.100.1.3",
+	},
+	"name": {
+		"name", "displayname", "cn",
+		"http://schemas.microsoft.com/identity/claims/displayname",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/name",
+		"urn:oid:2.16.840.1.113730.3.1.241",
+		"urn:oid:2.5.4.3",
+	},
+	"first_name": {
+		"firstname", "givenname",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname",
+		"urn:oid:2.5.4.42",
+	},
+	"last_name": {
+		"lastname", "surname", "sn",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/surname",
+		"urn:oid:2.5.4.4",
+	},
+}
+
+// samlAccount reads the user from the assertion. The email falls back to the NameID when the IdP uses
+// email NameIDs, and the name falls back to the email.
+func samlAccount(assertion *saml.Assertion) SAMLAccount {
+	attributes := map[string]string{}
+	for _, statement := range assertion.AttributeStatements {
+		for _, attribute := range statement.Attributes {
+			if len(attribute.Values) == 0 {
+				continue
+			}
+			for _, name := range []string{attribute.Name, attribute.FriendlyName} {
+				key := strings.ToLower(name)
+				if _, ok := attributes[key]; !ok && key != "" {
+					attributes[key] = strings.TrimSpace(attribute.Values[0].Value)
+				}
+			}
+		}
+	}
+	lookup := func(field string) string {
+		for _, name := range samlAttributeNames[field] {
+			if value := attributes[strings.ToLower(name)]; value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	var account SAMLAccount
+	if assertion.Subject != nil && assertion.Subject.NameID != nil {
+		account.Subject = strings.TrimSpace(assertion.Subject.NameID.Value)
+		if assertion.Subject.NameID.Format == string(saml.EmailAddressNameIDFormat) {
+			account.Email = account.Subject
+		}
+	}
+	if email := lookup("email"); email != "" {
+		account.Email = email
+	}
+
+	account.Name = lookup("name")
+	if account.Name == "" {
+		account.Name = strings.TrimSpace(lookup("first_name") + " " + lookup("last_name"))
+	}
+	if account.Name == "" {
+		account.Name = strings.Split(account.Email, "@")[0]
+	}
+	return account
+}
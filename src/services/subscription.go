@@ -184,6 +184,16 @@ func (s *SubscriptionService) GetUserSubscriptionById(id string) (*models.UserSu
 	return &sub, nil
 }
 
+// GetUserSubscriptionByUser returns the user's subscription record, whatever its status.
+func (s *SubscriptionService) GetUserSubscriptionByUser(userId string) (*models.UserSubscription, error) {
+	var sub models.UserSubscription
+	err := s.database.Where("user_id = ?", userId).Order("updated_at DESC").First(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
 func (s *SubscriptionService) SubscribeUser(userId string, tierId string) error {
 	tx := s.database.Begin()
 	defer func() {
@@ -7,19 +7,40 @@ import (
 	"foglio/v2/src/dto"
 	"foglio/v2/src/lib"
 	"foglio/v2/src/models"
+	"log"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/pquerna/otp"
 	"github.com/pquerna/otp/totp"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var (
+	ErrInvalidTwoFactorChallenge = errors.New("sign-in challenge is invalid or has expired, sign in again")
+	ErrInvalidTwoFactorCode      = errors.New("invalid verification code")
+	ErrTwoFactorLocked           = errors.New("too many incorrect codes, 2FA sign-in is temporarily locked")
+	ErrTrustedDeviceNotFound     = errors.New("trusted device not found")
+)
+
+const (
+	twoFactorChallengeTTL = 5 * time.Minute
+	maxTwoFactorAttempts  = 5
+	twoFactorLockout      = 15 * time.Minute
+)
+
+// TrustedDeviceTTL is how long a remembered browser skips 2FA.
+const TrustedDeviceTTL = 30 * 24 * time.Hour
+
 type TwoFactorService struct {
 	database *gorm.DB
+	throttle *AuthThrottleService
 }
 
 func NewTwoFactorService(database *gorm.DB) *TwoFactorService {
-	return &TwoFactorService{database: database}
+	return &TwoFactorService{database: database, throttle: NewAuthThrottleService(database)}
 }
 
 func (s *TwoFactorService) GenerateSecret(user *models.User) (*dto.Enable2FAResponse, error) {
@@ -77,53 +98,167 @@ func (s *TwoFactorService) VerifyAndEnable(userID string, code string) error {
 	return nil
 }
 
-func (s *TwoFactorService) VerifyCode(userID string, code string) (*models.User, error) {
+// IssueChallenge returns a short-lived token the user exchanges, with a 2FA code, for a session once their
+// password has been checked.
+func (s *TwoFactorService) IssueChallenge(user *models.User) (string, error) {
+	return lib.GenerateChallengeToken(user.ID, time.Now().Add(twoFactorChallengeTTL))
+}
+
+// ResolveChallenge returns the user a challenge token was issued to.
+func (s *TwoFactorService) ResolveChallenge(challengeToken string) (*models.User, error) {
+	claims, err := lib.ValidateChallengeToken(challengeToken)
+	if err != nil {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
 	var user models.User
-	if err := s.database.Where("id = ?", userID).First(&user).Error; err != nil {
-		return nil, errors.New("user not found")
+	if err := s.database.Where("id = ?", claims.UserId).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidTwoFactorChallenge
+		}
+		return nil, err
+	}
+	if user.IsSuspended() {
+		return nil, ErrAccountSuspended
 	}
+	return &user, nil
+}
 
+// VerifyLoginCode checks a TOTP or backup code for the user. Each code reserves one of the user's attempts
+// before it is checked, so concurrent guesses share the limit; the attempt that reaches it locks 2FA for a
+// while and emails the user. Attempts also count towards slowing down the client's IP address so it cannot
+// guess codes across many accounts.
+func (s *TwoFactorService) VerifyLoginCode(user *models.User, code string, client dto.SessionClient) (*models.User, error) {
 	if !user.IsTwoFactorEnabled || user.TwoFactorSecret == nil {
 		return nil, errors.New("2FA is not enabled for this user")
 	}
+	ip := ipThrottleKey(client.IPAddress)
+	if _, err := s.throttle.Attempt(models.AuthActionTwoFactor, ip); err != nil {
+		return nil, err
+	}
+	attempts, err := s.reserveAttempt(user)
+	if err != nil {
+		return nil, err
+	}
 
 	// Verify TOTP code or backup code
-	verified := false
-	if totp.Validate(code, *user.TwoFactorSecret) {
-		verified = true
-	} else if s.verifyAndConsumeBackupCode(&user, code) {
-		verified = true
+	verified := totp.Validate(code, *user.TwoFactorSecret)
+	if !verified {
+		if verified, err = s.verifyAndConsumeBackupCode(user, code); err != nil {
+			return nil, err
+		}
 	}
 
 	if !verified {
-		return nil, errors.New("invalid verification code")
+		if attempts < maxTwoFactorAttempts {
+			return nil, ErrInvalidTwoFactorCode
+		}
+		return nil, s.lockedOut(user)
+	}
+	if err := s.throttle.Release(models.AuthActionTwoFactor, ip); err != nil {
+		log.Printf("Failed to release 2FA attempt for user %s: %v", user.ID, err)
+	}
+
+	return s.completeLogin(user)
+}
+
+// VerifyLoginPasskey accepts a passkey assertion in place of a TOTP code. A lockout from wrong codes does not
+// apply, since a passkey cannot be guessed and the user may be locked out by someone else's attempts.
+func (s *TwoFactorService) VerifyLoginPasskey(user *models.User, ceremonyID string, response []byte) (*models.User, error) {
+	if !user.IsTwoFactorEnabled {
+		return nil, errors.New("2FA is not enabled for this user")
+	}
+
+	if err := NewWebAuthnService(s.database).FinishSecondFactor(user, ceremonyID, response); err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(user)
+}
+
+// completeLogin clears failed 2FA attempts and loads the user for the sign-in response.
+func (s *TwoFactorService) completeLogin(user *models.User) (*models.User, error) {
+	if err := s.database.Model(user).Updates(map[string]interface{}{
+		"two_factor_failed_attempts": 0,
+		"two_factor_locked_until":    nil,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return loadSigninUser(s.database, user.ID)
+}
+
+// TrustDevice remembers the browser so sign-ins from it skip 2FA. The returned token goes in a cookie.
+func (s *TwoFactorService) TrustDevice(userID uuid.UUID, client dto.SessionClient) (string, error) {
+	token, err := lib.GenerateSecureToken()
+	if err != nil {
+		return "", err
 	}
 
-	// Load user with all associations
-	var fullUser models.User
+	now := time.Now()
+	if err := s.database.Create(&models.TrustedDevice{
+		UserID:     userID,
+		TokenHash:  lib.HashToken(token),
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(TrustedDeviceTTL),
+	}).Error; err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// IsTrustedDevice reports whether the cookie token belongs to an unexpired trusted device of the user.
+func (s *TwoFactorService) IsTrustedDevice(userID uuid.UUID, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	result := s.database.Model(&models.TrustedDevice{}).
+		Where("user_id = ? AND token_hash = ? AND expires_at > ?", userID, lib.HashToken(token), time.Now()).
+		Update("last_used_at", time.Now())
+	if result.Error != nil {
+		log.Printf("Failed to check trusted device for user %s: %v", userID, result.Error)
+		return false
+	}
+	return result.RowsAffected > 0
+}
+
+func (s *TwoFactorService) GetTrustedDevices(userID string) ([]models.TrustedDevice, error) {
+	var devices []models.TrustedDevice
 	if err := s.database.
-		Preload("Projects").
-		Preload("Projects.Stack").
-		Preload("Projects.Highlights").
-		Preload("Experiences").
-		Preload("Experiences.Highlights").
-		Preload("Experiences.Technologies").
-		Preload("Education").
-		Preload("Education.Highlights").
-		Preload("Certifications").
-		Preload("Languages").
-		Preload("Company").
-		Preload("CurrentSubscription").
-		Preload("CurrentSubscription.Subscription").
-		Preload("Portfolio").
-		First(&fullUser, "id = ?", userID).Error; err != nil {
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&devices).Error; err != nil {
 		return nil, err
 	}
+	return devices, nil
+}
+
+func (s *TwoFactorService) RemoveTrustedDevice(userID, deviceID string) error {
+	if _, err := uuid.Parse(deviceID); err != nil {
+		return ErrTrustedDeviceNotFound
+	}
 
-	return &fullUser, nil
+	result := s.database.Where("id = ? AND user_id = ?", deviceID, userID).Delete(&models.TrustedDevice{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTrustedDeviceNotFound
+	}
+	return nil
 }
 
-func (s *TwoFactorService) Disable2FA(userID string, password string) error {
+// RemoveTrustedDevices forgets every trusted device of the user so their next sign-in asks for 2FA again.
+func (s *TwoFactorService) RemoveTrustedDevices(userID string) error {
+	return s.database.Where("user_id = ?", userID).Delete(&models.TrustedDevice{}).Error
+}
+
+// Disable2FA turns off two-factor authentication and signs the user out of every other session.
+func (s *TwoFactorService) Disable2FA(userID, sessionID, password string) error {
 	var user models.User
 	if err := s.database.Where("id = ?", userID).First(&user).Error; err != nil {
 		return errors.New("user not found")
@@ -137,11 +272,16 @@ func (s *TwoFactorService) Disable2FA(userID string, password string) error {
 	user.TwoFactorSecret = nil
 	user.TwoFactorBackupCodes = nil
 
-	if err := s.database.Save(&user).Error; err != nil {
+	return s.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		if err := NewTwoFactorService(tx).RemoveTrustedDevices(userID); err != nil {
+			return err
+		}
+		_, err := NewSessionService(tx).RevokeAllSessions(userID, sessionID)
 		return err
-	}
-
-	return nil
+	})
 }
 
 func (s *TwoFactorService) RegenerateBackupCodes(userID string, password string) ([]string, error) {
@@ -186,9 +326,15 @@ func (s *TwoFactorService) GetStatus(userID string) (*dto.TwoFactorStatusRespons
 		}
 	}
 
+	var passkeys int64
+	if err := s.database.Model(&models.WebAuthnCredential{}).Where("user_id = ?", user.ID).Count(&passkeys).Error; err != nil {
+		return nil, err
+	}
+
 	return &dto.TwoFactorStatusResponse{
 		Enabled:         user.IsTwoFactorEnabled,
 		BackupCodesLeft: backupCodesLeft,
+		Passkeys:        int(passkeys),
 	}, nil
 }
 
@@ -204,24 +350,77 @@ func (s *TwoFactorService) generateBackupCodes() ([]string, error) {
 	return codes, nil
 }
 
-func (s *TwoFactorService) verifyAndConsumeBackupCode(user *models.User, code string) bool {
-	if user.TwoFactorBackupCodes == nil {
-		return false
-	}
-
+// verifyAndConsumeBackupCode marks a matching backup code used. The update only applies while the code is
+// still unused, so two concurrent sign-ins cannot both redeem it.
+func (s *TwoFactorService) verifyAndConsumeBackupCode(user *models.User, code string) (bool, error) {
 	normalizedCode := strings.ToUpper(strings.ReplaceAll(code, "-", ""))
 
-	for i, hashedCode := range user.TwoFactorBackupCodes {
-		if hashedCode != "" && hashedCode != "USED" {
-			// Use bcrypt comparison for secure backup code verification
-			if lib.ComparePassword(normalizedCode, hashedCode) == nil {
-				user.TwoFactorBackupCodes[i] = "USED"
-				s.database.Save(user)
-				return true
-			}
+	for _, hashedCode := range user.TwoFactorBackupCodes {
+		if hashedCode == "" || hashedCode == "USED" {
+			continue
+		}
+		// Use bcrypt comparison for secure backup code verification
+		if lib.ComparePassword(normalizedCode, hashedCode) != nil {
+			continue
 		}
+
+		result := s.database.Model(&models.User{}).
+			Where("id = ? AND ? = ANY(two_factor_backup_codes)", user.ID, hashedCode).
+			Update("two_factor_backup_codes", gorm.Expr("array_replace(two_factor_backup_codes, ?, 'USED')", hashedCode))
+		if result.Error != nil {
+			return false, result.Error
+		}
+		return result.RowsAffected == 1, nil
+	}
+	return false, nil
+}
+
+// reserveAttempt counts a 2FA attempt before the code is checked and returns the user's attempts so far. The
+// attempt that reaches the limit locks 2FA straight away, and a successful sign-in clears both; once a lockout
+// has expired the count starts again. While locked, nothing is counted and ErrTwoFactorLocked is returned.
+func (s *TwoFactorService) reserveAttempt(user *models.User) (int, error) {
+	now := time.Now()
+	attempts := "CASE WHEN two_factor_locked_until IS NULL THEN two_factor_failed_attempts + 1 ELSE 1 END"
+
+	var counted models.User
+	result := s.database.Model(&counted).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "two_factor_failed_attempts"}}}).
+		Where("id = ? AND (two_factor_locked_until IS NULL OR two_factor_locked_until <= ?)", user.ID, now).
+		Updates(map[string]interface{}{
+			"two_factor_failed_attempts": gorm.Expr(attempts),
+			"two_factor_locked_until": gorm.Expr("CASE WHEN "+attempts+" >= ? THEN ?::timestamptz END",
+				maxTwoFactorAttempts, now.Add(twoFactorLockout)),
+		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrTwoFactorLocked
 	}
-	return false
+	return counted.TwoFactorFailedAttempts, nil
+}
+
+// lockedOut emails the user that the failed attempt they just made locked 2FA sign-in.
+func (s *TwoFactorService) lockedOut(user *models.User) error {
+	lockedUntil := time.Now().Add(twoFactorLockout)
+	go func() {
+		err := lib.GetEmailService().SendEmailSimple(lib.EmailDto{
+			To:       []string{user.Email},
+			Subject:  "Two-Factor Sign-In Locked",
+			Template: "two-factor-locked",
+			Data: map[string]interface{}{
+				"Name":        user.Name,
+				"Email":       user.Email,
+				"Attempts":    maxTwoFactorAttempts,
+				"LockedUntil": lockedUntil.UTC().Format("Jan 2, 2006 15:04 MST"),
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to send 2FA lockout email: %v", err)
+		}
+	}()
+
+	return ErrTwoFactorLocked
 }
 
 func generateRandomCode(length int) (string, error) {
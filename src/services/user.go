@@ -244,22 +244,6 @@ func (s *UserService) UpdateAvatar(id, imageUrl string) (*models.User, error) {
 	return user, nil
 }
 
-func (s *UserService) DeleteUser(id string) error {
-	user, err := NewAuthService(s.database).FindUserById(id)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user not found")
-		}
-		return err
-	}
-
-	if err := s.database.Delete(&user).Error; err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func normalizeUserQuery(q dto.UserPagination) dto.UserPagination {
 	if q.Limit <= 0 {
 		q.Limit = 10
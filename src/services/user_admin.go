@@ -0,0 +1,584 @@
+package services
+
+import (
+	"errors"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// defaultImpersonationMinutes is how long an impersonation token lasts when the admin does not pick a duration.
+const defaultImpersonationMinutes = 30
+
+var (
+	ErrCannotModerateSelf        = errors.New("you cannot perform this action on your own account")
+	ErrCannotModerateStaff       = errors.New("revoke the user's admin roles before performing this action")
+	ErrUserNotSuspended          = errors.New("user is not suspended")
+	ErrUserAlreadyVerified       = errors.New("user already verified")
+	ErrImpersonationNotFound     = errors.New("impersonation session not found")
+	ErrImpersonationAlreadyEnded = errors.New("impersonation session has already ended")
+	ErrNestedImpersonation       = errors.New("cannot start an impersonation while impersonating")
+	ErrMergeSubscriptionConflict = errors.New("both accounts have an active subscription; cancel one before merging")
+)
+
+type UserAdminService struct {
+	database *gorm.DB
+	roles    *RoleService
+}
+
+func NewUserAdminService(database *gorm.DB) *UserAdminService {
+	return &UserAdminService{
+		database: database,
+		roles:    NewRoleService(database),
+	}
+}
+
+func (s *UserAdminService) SuspendUser(adminID, userID string, payload dto.SuspendUserDto) (*models.User, error) {
+	user, err := s.findModeratableUser(adminID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	adminUUID := uuid.MustParse(adminID)
+	user.SuspendedAt = &now
+	user.SuspendedUntil = nil
+	if payload.DurationHours != nil {
+		until := now.Add(time.Duration(*payload.DurationHours) * time.Hour)
+		user.SuspendedUntil = &until
+	}
+	user.SuspensionReason = &payload.Reason
+	user.SuspendedByID = &adminUUID
+
+	if err := s.database.Model(user).Select("suspended_at", "suspended_until", "suspension_reason", "suspended_by_id").
+		Updates(user).Error; err != nil {
+		return nil, err
+	}
+
+	// Cut any live impersonation of the suspended user short as well
+	if err := s.database.Model(&models.ImpersonationSession{}).
+		Where("user_id = ? AND ended_at IS NULL", user.ID).
+		Update("ended_at", now).Error; err != nil {
+		return nil, err
+	}
+
+	// Signing the user out everywhere also closes their open WebSockets
+	if _, err := NewSessionService(s.database).RevokeAllSessions(user.ID.String(), ""); err != nil {
+		log.Printf("Failed to revoke sessions of suspended user %s: %v", user.ID, err)
+	}
+
+	return user, nil
+}
+
+// DeleteUser takes the account down right away and schedules its erasure for the end of the grace period,
+// during which staff can still restore it. The account is soft-deleted, so it can no longer sign in or be
+// found, and its sessions are revoked.
+func (s *UserAdminService) DeleteUser(adminID, userID string) (*models.AccountDeletion, error) {
+	user, err := s.findModeratableUser(adminID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	adminUUID := uuid.MustParse(adminID)
+	deletion := models.AccountDeletion{
+		UserID:        user.ID,
+		Status:        models.DeletionScheduled,
+		RequestedByID: &adminUUID,
+		ScheduledFor:  now.Add(AccountDeletionGracePeriod),
+	}
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		// A deletion the owner scheduled is superseded; they could not cancel it any more anyway
+		if err := tx.Model(&models.AccountDeletion{}).
+			Where("user_id = ? AND status = ?", user.ID, models.DeletionScheduled).
+			Updates(map[string]interface{}{"status": models.DeletionCancelled, "cancelled_at": now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&deletion).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ImpersonationSession{}).
+			Where("user_id = ? AND ended_at IS NULL", user.ID).
+			Update("ended_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Delete(user).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := NewSessionService(s.database).RevokeAllSessions(user.ID.String(), ""); err != nil {
+		log.Printf("Failed to revoke sessions of deleted user %s: %v", user.ID, err)
+	}
+
+	return &deletion, nil
+}
+
+// RestoreUser brings back an account staff deleted, as long as its grace period has not run out yet.
+func (s *UserAdminService) RestoreUser(userID string) (*models.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	err := s.database.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.AccountDeletion{}).
+			Where("user_id = ? AND status = ? AND requested_by_id IS NOT NULL", userID, models.DeletionScheduled).
+			Updates(map[string]interface{}{"status": models.DeletionCancelled, "cancelled_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrDeletionNotFound
+		}
+		return tx.Unscoped().Model(&models.User{}).Where("id = ?", userID).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findUser(userID)
+}
+
+func (s *UserAdminService) UnsuspendUser(userID string) (*models.User, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsSuspended() {
+		return nil, ErrUserNotSuspended
+	}
+
+	if err := s.database.Model(user).Updates(map[string]interface{}{
+		"suspended_at":      nil,
+		"suspended_until":   nil,
+		"suspension_reason": nil,
+		"suspended_by_id":   nil,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return s.findUser(userID)
+}
+
+// VerifyUser marks the account verified without the email OTP, for users who cannot receive it.
+func (s *UserAdminService) VerifyUser(userID string) (*models.User, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Verified {
+		return nil, ErrUserAlreadyVerified
+	}
+
+	if err := s.database.Model(user).Updates(map[string]interface{}{
+		"verified": true,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return s.findUser(userID)
+}
+
+// Impersonate opens an audited session and issues a short-lived token that authenticates as the user.
+func (s *UserAdminService) Impersonate(adminID, impersonatorID, userID string, payload dto.ImpersonateUserDto) (*dto.ImpersonationResponse, error) {
+	if impersonatorID != "" {
+		return nil, ErrNestedImpersonation
+	}
+
+	user, err := s.findModeratableUser(adminID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := payload.DurationMinutes
+	if duration <= 0 {
+		duration = defaultImpersonationMinutes
+	}
+
+	session := models.ImpersonationSession{
+		AdminID:   uuid.MustParse(adminID),
+		UserID:    user.ID,
+		Reason:    payload.Reason,
+		ExpiresAt: time.Now().Add(time.Duration(duration) * time.Minute),
+	}
+	if err := s.database.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	token, err := lib.GenerateImpersonationToken(user.ID, session.AdminID, session.ID, session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Admin %s started impersonating user %s (session %s): %s", adminID, user.ID, session.ID, payload.Reason)
+
+	return &dto.ImpersonationResponse{Token: token, Session: session}, nil
+}
+
+func (s *UserAdminService) GetImpersonationSessions(params dto.ImpersonationQueryParams) (*dto.PaginatedResponse[models.ImpersonationSession], error) {
+	params.Pagination = normalizePagination(params.Pagination)
+
+	query := s.database.Model(&models.ImpersonationSession{})
+	if params.UserID != "" {
+		query = query.Where("user_id = ?", params.UserID)
+	}
+	if params.AdminID != "" {
+		query = query.Where("admin_id = ?", params.AdminID)
+	}
+	if params.ActiveOnly {
+		query = query.Where("ended_at IS NULL AND expires_at > ?", time.Now())
+	}
+
+	var totalItems int64
+	if err := query.Count(&totalItems).Error; err != nil {
+		return nil, err
+	}
+
+	var sessions []models.ImpersonationSession
+	if err := query.Order("created_at DESC").
+		Offset((params.Page - 1) * params.Limit).
+		Limit(params.Limit).
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.PaginatedResponse[models.ImpersonationSession]{
+		Data:       sessions,
+		Limit:      params.Limit,
+		Page:       params.Page,
+		TotalItems: int(totalItems),
+		TotalPages: int(math.Ceil(float64(totalItems) / float64(params.Limit))),
+	}, nil
+}
+
+func (s *UserAdminService) EndImpersonation(sessionID string) (*models.ImpersonationSession, error) {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return nil, ErrImpersonationNotFound
+	}
+
+	var session models.ImpersonationSession
+	if err := s.database.First(&session, "id = ?", sessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImpersonationNotFound
+		}
+		return nil, err
+	}
+	if !session.IsActive() {
+		return nil, ErrImpersonationAlreadyEnded
+	}
+
+	now := time.Now()
+	session.EndedAt = &now
+	if err := s.database.Model(&session).Update("ended_at", now).Error; err != nil {
+		return nil, err
+	}
+	dropSessionSockets(session.UserID.String(), session.ID.String())
+
+	return &session, nil
+}
+
+// MergeUsers folds a duplicate account into the target: the source's content and activity move to the
+// target, empty profile fields are filled from the source, and the source is deleted. Both accounts must
+// be ones the admin may moderate, so staff accounts and the admin's own account are refused. Roles are
+// not carried over; granting them stays with the role management endpoints. Direct conversations the
+// target already has with the same person stay with the deleted source.
+func (s *UserAdminService) MergeUsers(adminID, targetID string, payload dto.MergeUsersDto) (*models.User, error) {
+	if targetID == payload.SourceUserID {
+		return nil, ErrCannotModerateSelf
+	}
+
+	target, err := s.findModeratableUser(adminID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.findModeratableUser(adminID, payload.SourceUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.database.Transaction(func(tx *gorm.DB) error {
+		var activeSubscriptions int64
+		if err := tx.Model(&models.UserSubscription{}).
+			Where("user_id IN ? AND status = ?", []uuid.UUID{source.ID, target.ID}, "active").
+			Distinct("user_id").
+			Count(&activeSubscriptions).Error; err != nil {
+			return err
+		}
+		if activeSubscriptions > 1 {
+			return ErrMergeSubscriptionConflict
+		}
+
+		if err := mergeUserReferences(tx, source.ID, target.ID); err != nil {
+			return err
+		}
+		if err := mergeConversations(tx, source.ID, target.ID); err != nil {
+			return err
+		}
+		if err := mergeProfile(tx, source, target); err != nil {
+			return err
+		}
+
+		merge := models.AccountMerge{
+			TargetUserID:     target.ID,
+			SourceUserID:     source.ID,
+			SourceEmail:      source.Email,
+			SourceUsername:   source.Username,
+			SourceProvider:   source.Provider,
+			SourceProviderID: source.ProviderID,
+			MergedByID:       uuid.MustParse(adminID),
+		}
+		if err := tx.Create(&merge).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(source).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findUser(targetID)
+}
+
+// userReference is a column pointing at a user. Rows are moved to the surviving account on merge; when
+// keys are given, source rows that would duplicate a target row on those columns are dropped first.
+type userReference struct {
+	model  interface{}
+	column string
+	keys   []string
+}
+
+var userReferences = []userReference{
+	{model: &models.Project{}, column: "user_id"},
+	{model: &models.Experience{}, column: "user_id"},
+	{model: &models.Education{}, column: "user_id"},
+	{model: &models.Certification{}, column: "user_id"},
+	{model: &models.Language{}, column: "user_id"},
+	{model: &models.Job{}, column: "created_by"},
+	{model: &models.Comment{}, column: "created_by"},
+	{model: &models.Reaction{}, column: "created_by"},
+	{model: &models.JobApplication{}, column: "applicant_id"},
+	{model: &models.Notification{}, column: "owner_id"},
+	{model: &models.Announcement{}, column: "created_by"},
+	{model: &models.UserAnnouncementStatus{}, column: "user_id", keys: []string{"announcement_id"}},
+	{model: &models.Message{}, column: "sender_id"},
+	{model: &models.MessageReaction{}, column: "user_id", keys: []string{"message_id", "emoji"}},
+	{model: &models.MessageDeletion{}, column: "user_id", keys: []string{"message_id"}},
+	{model: &models.ChatAttachment{}, column: "uploader_id"},
+	{model: &models.UserBlock{}, column: "blocker_id", keys: []string{"blocked_id"}},
+	{model: &models.UserBlock{}, column: "blocked_id", keys: []string{"blocker_id"}},
+	{model: &models.ChatReport{}, column: "reporter_id"},
+	{model: &models.ChatReport{}, column: "reported_user_id"},
+	{model: &models.ChatReportMessage{}, column: "sender_id"},
+	{model: &models.MessageTemplate{}, column: "owner_id"},
+	{model: &models.OutreachMessage{}, column: "sender_id"},
+	{model: &models.OutreachMessage{}, column: "recipient_id"},
+	{model: &models.Review{}, column: "user_id"},
+	{model: &models.UserSubscription{}, column: "user_id"},
+	{model: &models.IdentityVerification{}, column: "user_id"},
+	{model: &models.UserIdentity{}, column: "user_id", keys: []string{"provider"}},
+	{model: &models.PageView{}, column: "user_id"},
+	{model: &models.JobView{}, column: "user_id"},
+	{model: &models.ProfileView{}, column: "profile_user_id"},
+	{model: &models.ProfileView{}, column: "viewer_user_id"},
+	{model: &models.PortfolioView{}, column: "viewer_user_id"},
+	{model: &models.AnalyticsEvent{}, column: "user_id"},
+}
+
+func mergeUserReferences(tx *gorm.DB, sourceID, targetID uuid.UUID) error {
+	// Blocks between the two accounts would become self-blocks
+	if err := tx.Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)",
+		sourceID, targetID, targetID, sourceID).
+		Delete(&models.UserBlock{}).Error; err != nil {
+		return err
+	}
+
+	for _, ref := range userReferences {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(ref.model); err != nil {
+			return err
+		}
+		table := stmt.Schema.Table
+
+		if len(ref.keys) > 0 {
+			conditions := "t." + ref.column + " = ?"
+			for _, key := range ref.keys {
+				conditions += " AND t." + key + " = s." + key
+			}
+			if err := tx.Exec("DELETE FROM "+table+" s WHERE s."+ref.column+" = ? AND EXISTS (SELECT 1 FROM "+table+" t WHERE "+conditions+")",
+				sourceID, targetID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec("UPDATE "+table+" SET "+ref.column+" = ? WHERE "+ref.column+" = ?", targetID, sourceID).Error; err != nil {
+			return err
+		}
+	}
+
+	// One-per-user records only move when the target has none of its own
+	for _, model := range []interface{}{&models.Portfolio{}, &models.NotificationSettings{}} {
+		var count int64
+		if err := tx.Model(model).Where("user_id = ?", targetID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := tx.Model(model).Where("user_id = ?", sourceID).Update("user_id", targetID).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeConversations moves the source's chats to the target. A direct conversation is left behind when the
+// target already has one with the same person, or when it is between the two merged accounts.
+func mergeConversations(tx *gorm.DB, sourceID, targetID uuid.UUID) error {
+	for _, column := range []string{"participant1", "participant2"} {
+		other := "participant2"
+		if column == "participant2" {
+			other = "participant1"
+		}
+		if err := tx.Exec(`
+			UPDATE conversations c SET `+column+` = ?
+			WHERE c.`+column+` = ? AND c.`+other+` IS DISTINCT FROM ?
+			AND NOT EXISTS (
+				SELECT 1 FROM conversations d
+				WHERE d.deleted_at IS NULL AND d.id <> c.id
+				AND LEAST(d.participant1, d.participant2) = LEAST(?::uuid, c.`+other+`)
+				AND GREATEST(d.participant1, d.participant2) = GREATEST(?::uuid, c.`+other+`)
+			)`, targetID, sourceID, targetID, targetID, targetID).Error; err != nil {
+			return err
+		}
+	}
+
+	leftBehind := tx.Model(&models.Conversation{}).Unscoped().Select("id").
+		Where("participant1 = ? OR participant2 = ?", sourceID, sourceID)
+
+	if err := tx.Where("user_id = ? AND conversation_id NOT IN (?)", sourceID, leftBehind).
+		Where("conversation_id IN (?)", tx.Model(&models.ConversationParticipant{}).Select("conversation_id").Where("user_id = ?", targetID)).
+		Delete(&models.ConversationParticipant{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.ConversationParticipant{}).
+		Where("user_id = ? AND conversation_id NOT IN (?)", sourceID, leftBehind).
+		Update("user_id", targetID).Error
+}
+
+// mergeProfile fills the target's empty profile fields from the source.
+func mergeProfile(tx *gorm.DB, source, target *models.User) error {
+	fillString := func(dst **string, src *string) {
+		if (*dst == nil || **dst == "") && src != nil && *src != "" {
+			*dst = src
+		}
+	}
+	fillString(&target.Role, source.Role)
+	fillString(&target.Headline, source.Headline)
+	fillString(&target.Phone, source.Phone)
+	fillString(&target.Location, source.Location)
+	fillString(&target.Image, source.Image)
+	fillString(&target.Summary, source.Summary)
+
+	if target.CompanyID == nil {
+		target.CompanyID = source.CompanyID
+	}
+	for _, skill := range source.Skills {
+		if !slices.Contains(target.Skills, skill) {
+			target.Skills = append(target.Skills, skill)
+		}
+	}
+	target.Verified = target.Verified || source.Verified
+	target.IsRecruiter = target.IsRecruiter || source.IsRecruiter
+	target.IsPremium = target.IsPremium || source.IsPremium
+
+	updates := map[string]interface{}{
+		"role":         target.Role,
+		"headline":     target.Headline,
+		"phone":        target.Phone,
+		"location":     target.Location,
+		"image":        target.Image,
+		"summary":      target.Summary,
+		"company_id":   target.CompanyID,
+		"skills":       target.Skills,
+		"verified":     target.Verified,
+		"is_recruiter": target.IsRecruiter,
+		"is_premium":   target.IsPremium,
+	}
+
+	// Identity verification moves only when the target has none; the number is unique so the source's is cleared
+	if target.VerificationNumber == nil && source.VerificationNumber != nil {
+		if err := tx.Model(source).Update("verification_number", nil).Error; err != nil {
+			return err
+		}
+		updates["verification_number"] = source.VerificationNumber
+		updates["verification_type"] = source.VerificationType
+		updates["verification_document"] = source.VerificationDocument
+		updates["verification_status"] = source.VerificationStatus
+		updates["verification_note"] = source.VerificationNote
+		updates["verification_reviewed_at"] = source.VerificationReviewedAt
+		updates["verification_reviewed_by_id"] = source.VerificationReviewedByID
+		updates["identity_verified"] = source.IdentityVerified
+	}
+
+	return tx.Model(target).Updates(updates).Error
+}
+
+func (s *UserAdminService) findUser(userID string) (*models.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	var user models.User
+	if err := s.database.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// findModeratableUser loads a user the admin may suspend or impersonate: never themselves or other staff.
+func (s *UserAdminService) findModeratableUser(adminID, userID string) (*models.User, error) {
+	if adminID == userID {
+		return nil, ErrCannotModerateSelf
+	}
+
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	isStaff, err := s.roles.IsStaff(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if isStaff {
+		return nil, ErrCannotModerateStaff
+	}
+
+	return user, nil
+}
+
+func normalizePagination(params dto.Pagination) dto.Pagination {
+	if params.Limit <= 0 {
+		params.Limit = 10
+	}
+	if params.Limit > 100 {
+		params.Limit = 100
+	}
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+	return params
+}
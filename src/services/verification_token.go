@@ -0,0 +1,184 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidVerificationToken     = errors.New("invalid or expired token")
+	ErrInvalidVerificationCode      = errors.New("invalid or expired code")
+	ErrVerificationAttemptsExceeded = errors.New("too many incorrect attempts, request a new code")
+)
+
+// maxVerificationAttempts is how many wrong codes are accepted before the code stops working.
+const maxVerificationAttempts = 5
+
+// verificationPolicy controls how tokens for a purpose are issued. Codes are short enough to type from an
+// email; link tokens are long random strings that can only be guessed by brute force.
+type verificationPolicy struct {
+	ttl  time.Duration
+	code bool
+	keep bool // Earlier tokens stay valid when a new one is issued
+}
+
+var verificationPolicies = map[models.VerificationPurpose]verificationPolicy{
+	models.PurposePasswordReset:     {ttl: 30 * time.Minute},
+	models.PurposeEmailVerification: {ttl: 15 * time.Minute, code: true},
+	models.PurposeEmailChange:       {ttl: 15 * time.Minute, code: true},
+	// Changing the email again must not take away the previous address's way back
+	models.PurposeEmailRevert:     {ttl: 7 * 24 * time.Hour, keep: true},
+	models.PurposeSSOLogin:        {ttl: 2 * time.Minute},
+	models.PurposeAccountRecovery: {ttl: 15 * time.Minute, code: true},
+	models.PurposeRecoveryCancel:  {ttl: 7 * 24 * time.Hour},
+	models.PurposeAccountDeletion: {ttl: 15 * time.Minute, code: true},
+	models.PurposeDeletionCancel:  {ttl: AccountDeletionGracePeriod},
+}
+
+type VerificationTokenService struct {
+	database *gorm.DB
+}
+
+func NewVerificationTokenService(database *gorm.DB) *VerificationTokenService {
+	return &VerificationTokenService{database: database}
+}
+
+// Issue creates a token for the purpose and returns it in plain text for emailing. Tokens previously
+// issued to the user for the same purpose stop working.
+func (s *VerificationTokenService) Issue(userID uuid.UUID, purpose models.VerificationPurpose, target string) (string, error) {
+	policy := verificationPolicies[purpose]
+
+	var secret string
+	if policy.code {
+		secret = lib.GenerateOtp()
+	} else {
+		token, err := lib.GenerateSecureToken()
+		if err != nil {
+			return "", err
+		}
+		secret = token
+	}
+
+	now := time.Now()
+	err := s.database.Transaction(func(tx *gorm.DB) error {
+		if !policy.keep {
+			if err := tx.Model(&models.VerificationToken{}).
+				Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", userID, purpose).
+				Update("consumed_at", now).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&models.VerificationToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: lib.HashToken(secret),
+			Target:    target,
+			ExpiresAt: now.Add(policy.ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// ConsumeToken redeems a link token. The token is looked up by its hash, so it identifies the user itself.
+func (s *VerificationTokenService) ConsumeToken(purpose models.VerificationPurpose, token string) (*models.VerificationToken, error) {
+	var record models.VerificationToken
+	if err := s.database.
+		Where("token_hash = ? AND purpose = ?", lib.HashToken(strings.TrimSpace(token)), purpose).
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidVerificationToken
+		}
+		return nil, err
+	}
+	if !record.IsActive() {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	if err := s.consume(&record); err != nil {
+		if errors.Is(err, ErrInvalidVerificationCode) {
+			return nil, ErrInvalidVerificationToken
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ConsumeCode redeems the user's latest code for the purpose. Every guess reserves one of the code's
+// maxVerificationAttempts before it is compared, so concurrent requests cannot get more guesses between them.
+func (s *VerificationTokenService) ConsumeCode(userID uuid.UUID, purpose models.VerificationPurpose, code string) (*models.VerificationToken, error) {
+	var record models.VerificationToken
+	if err := s.database.
+		Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", userID, purpose).
+		Order("created_at DESC").
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidVerificationCode
+		}
+		return nil, err
+	}
+	if !record.IsActive() {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	var counted models.VerificationToken
+	result := s.database.Model(&counted).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).
+		Where("id = ? AND attempts < ?", record.ID, maxVerificationAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrVerificationAttemptsExceeded
+	}
+	record.Attempts = counted.Attempts
+
+	hash := lib.HashToken(strings.TrimSpace(code))
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(record.TokenHash)) != 1 {
+		if record.Attempts >= maxVerificationAttempts {
+			return nil, ErrVerificationAttemptsExceeded
+		}
+		return nil, ErrInvalidVerificationCode
+	}
+
+	if err := s.consume(&record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Revoke stops every unused token issued to the user for the purpose.
+func (s *VerificationTokenService) Revoke(userID uuid.UUID, purpose models.VerificationPurpose) error {
+	return s.database.Model(&models.VerificationToken{}).
+		Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", userID, purpose).
+		Update("consumed_at", time.Now()).Error
+}
+
+// consume marks the token used. Matching on consumed_at stops two concurrent requests redeeming it twice.
+func (s *VerificationTokenService) consume(record *models.VerificationToken) error {
+	now := time.Now()
+	result := s.database.Model(&models.VerificationToken{}).
+		Where("id = ? AND consumed_at IS NULL", record.ID).
+		Update("consumed_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidVerificationCode
+	}
+
+	record.ConsumedAt = &now
+	return nil
+}
@@ -0,0 +1,451 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"foglio/v2/src/config"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWebAuthnUnavailable        = errors.New("passkeys are not available")
+	ErrInvalidWebAuthnCeremony    = errors.New("passkey request is invalid or has expired, try again")
+	ErrWebAuthnVerificationFailed = errors.New("passkey could not be verified")
+	ErrWebAuthnCredentialExists   = errors.New("this passkey is already registered")
+	ErrWebAuthnCredentialNotFound = errors.New("passkey not found")
+	ErrNoWebAuthnCredentials      = errors.New("no passkeys are registered for this account")
+)
+
+const webAuthnCeremonyTTL = 5 * time.Minute
+
+type WebAuthnService struct {
+	database *gorm.DB
+}
+
+func NewWebAuthnService(database *gorm.DB) *WebAuthnService {
+	return &WebAuthnService{database: database}
+}
+
+// webAuthnUser adapts a user and their registered credentials to the library's User interface. The user
+// handle is the user ID, so a passkey assertion names the account it belongs to.
+type webAuthnUser struct {
+	user        *models.User
+	credentials []models.WebAuthnCredential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	if u.user.Name != "" {
+		return u.user.Name
+	}
+	return u.user.Username
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, len(u.credentials))
+	for i, stored := range u.credentials {
+		transports := make([]protocol.AuthenticatorTransport, len(stored.Transports))
+		for j, transport := range stored.Transports {
+			transports[j] = protocol.AuthenticatorTransport(transport)
+		}
+
+		credentials[i] = webauthn.Credential{
+			ID:              stored.CredentialID,
+			PublicKey:       stored.PublicKey,
+			AttestationType: stored.AttestationType,
+			Transport:       transports,
+			Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(stored.Flags)),
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    stored.AAGUID,
+				SignCount: uint32(stored.SignCount),
+			},
+		}
+	}
+	return credentials
+}
+
+// relyingParty builds the WebAuthn relying party from config. Passkeys stay unavailable until an RP ID and
+// at least one origin are configured.
+func (s *WebAuthnService) relyingParty() (*webauthn.WebAuthn, error) {
+	cfg := config.AppConfig
+	if cfg.WebAuthnRPID == "" || len(cfg.WebAuthnOrigins) == 0 {
+		return nil, ErrWebAuthnUnavailable
+	}
+
+	name := cfg.WebAuthnRPName
+	if name == "" {
+		name = "Foglio"
+	}
+
+	timeout := webauthn.TimeoutConfig{Enforce: true, Timeout: webAuthnCeremonyTTL, TimeoutUVD: webAuthnCeremonyTTL}
+	rp, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthnRPID,
+		RPDisplayName: name,
+		RPOrigins:     cfg.WebAuthnOrigins,
+		Timeouts:      webauthn.TimeoutsConfig{Login: timeout, Registration: timeout},
+	})
+	if err != nil {
+		log.Printf("Invalid WebAuthn configuration: %v", err)
+		return nil, ErrWebAuthnUnavailable
+	}
+	return rp, nil
+}
+
+func (s *WebAuthnService) loadUser(user *models.User) (*webAuthnUser, error) {
+	var credentials []models.WebAuthnCredential
+	if err := s.database.Where("user_id = ?", user.ID).Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+	return &webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+// BeginRegistration starts adding a passkey to the user's account. Keys the user already registered are
+// excluded so the same authenticator is not added twice.
+func (s *WebAuthnService) BeginRegistration(user *models.User) (*dto.WebAuthnOptions, error) {
+	rp, err := s.relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	wu, err := s.loadUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	creation, session, err := rp.BeginRegistration(wu,
+		webauthn.WithExclusions(webauthn.Credentials(wu.WebAuthnCredentials()).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startCeremony(&user.ID, models.WebAuthnCeremonyRegistration, session, creation.Response)
+}
+
+// FinishRegistration verifies the authenticator's attestation and stores the new credential under the
+// given name.
+func (s *WebAuthnService) FinishRegistration(user *models.User, payload dto.RegisterPasskeyRequest) (*models.WebAuthnCredential, error) {
+	rp, err := s.relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	ceremony, err := s.finishCeremony(payload.CeremonyID, &user.ID, models.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(payload.Credential)
+	if err != nil {
+		return nil, ErrWebAuthnVerificationFailed
+	}
+
+	wu, err := s.loadUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := rp.CreateCredential(wu, ceremony.SessionData, parsed)
+	if err != nil {
+		log.Printf("Passkey registration failed for user %s: %v", user.ID, err)
+		return nil, ErrWebAuthnVerificationFailed
+	}
+
+	var existing int64
+	if err := s.database.Model(&models.WebAuthnCredential{}).
+		Where("credential_id = ?", credential.ID).
+		Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, ErrWebAuthnCredentialExists
+	}
+
+	transports := make([]string, len(credential.Transport))
+	for i, transport := range credential.Transport {
+		transports[i] = string(transport)
+	}
+
+	stored := models.WebAuthnCredential{
+		UserID:          user.ID,
+		Name:            strings.TrimSpace(payload.Name),
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		Flags:           uint8(credential.Flags.ProtocolValue()),
+		Transports:      transports,
+		Synced:          credential.Flags.BackupState,
+	}
+	if err := s.database.Create(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+// BeginLogin starts a passwordless sign-in. The browser offers whichever passkeys it holds for this site,
+// so the user does not have to be identified first.
+func (s *WebAuthnService) BeginLogin() (*dto.WebAuthnOptions, error) {
+	rp, err := s.relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, session, err := rp.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startCeremony(nil, models.WebAuthnCeremonyLogin, session, assertion.Response)
+}
+
+// FinishLogin verifies a passkey assertion and returns the user it belongs to. The passkey must have
+// verified the user (PIN or biometrics), so it stands in for both the password and the second factor.
+func (s *WebAuthnService) FinishLogin(payload dto.PasskeyLoginRequest) (*models.User, error) {
+	rp, err := s.relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	ceremony, err := s.finishCeremony(payload.CeremonyID, nil, models.WebAuthnCeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(payload.Credential)
+	if err != nil {
+		return nil, ErrWebAuthnVerificationFailed
+	}
+
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		var stored models.WebAuthnCredential
+		if err := s.database.Where("credential_id = ?", rawID).First(&stored).Error; err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(stored.UserID[:], userHandle) {
+			return nil, ErrWebAuthnCredentialNotFound
+		}
+
+		var user models.User
+		if err := s.database.Where("id = ?", stored.UserID).First(&user).Error; err != nil {
+			return nil, err
+		}
+		return s.loadUser(&user)
+	}
+
+	user, credential, err := rp.ValidatePasskeyLogin(handler, ceremony.SessionData, parsed)
+	if err != nil {
+		log.Printf("Passkey sign-in failed: %v", err)
+		return nil, ErrWebAuthnVerificationFailed
+	}
+
+	wu := user.(*webAuthnUser)
+	if err := s.recordUse(wu, credential); err != nil {
+		return nil, err
+	}
+	if wu.user.IsSuspended() {
+		return nil, ErrAccountSuspended
+	}
+
+	return wu.user, nil
+}
+
+// BeginSecondFactor starts an assertion limited to the user's registered passkeys, for finishing a
+// password sign-in instead of entering a TOTP code.
+func (s *WebAuthnService) BeginSecondFactor(user *models.User) (*dto.WebAuthnOptions, error) {
+	rp, err := s.relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	wu, err := s.loadUser(user)
+	if err != nil {
+		return nil, err
+	}
+	if len(wu.credentials) == 0 {
+		return nil, ErrNoWebAuthnCredentials
+	}
+
+	assertion, session, err := rp.BeginLogin(wu)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startCeremony(&user.ID, models.WebAuthnCeremonySecondFactor, session, assertion.Response)
+}
+
+// FinishSecondFactor verifies an assertion started by BeginSecondFactor.
+func (s *WebAuthnService) FinishSecondFactor(user *models.User, ceremonyID string, response []byte) error {
+	rp, err := s.relyingParty()
+	if err != nil {
+		return err
+	}
+
+	ceremony, err := s.finishCeremony(ceremonyID, &user.ID, models.WebAuthnCeremonySecondFactor)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return ErrWebAuthnVerificationFailed
+	}
+
+	wu, err := s.loadUser(user)
+	if err != nil {
+		return err
+	}
+
+	credential, err := rp.ValidateLogin(wu, ceremony.SessionData, parsed)
+	if err != nil {
+		log.Printf("Passkey 2FA failed for user %s: %v", user.ID, err)
+		return ErrWebAuthnVerificationFailed
+	}
+
+	return s.recordUse(wu, credential)
+}
+
+func (s *WebAuthnService) GetCredentials(userID string) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	if err := s.database.
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func (s *WebAuthnService) RenameCredential(userID, credentialID, name string) (*models.WebAuthnCredential, error) {
+	if _, err := uuid.Parse(credentialID); err != nil {
+		return nil, ErrWebAuthnCredentialNotFound
+	}
+
+	var credential models.WebAuthnCredential
+	if err := s.database.Where("id = ? AND user_id = ?", credentialID, userID).First(&credential).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebAuthnCredentialNotFound
+		}
+		return nil, err
+	}
+
+	credential.Name = strings.TrimSpace(name)
+	if err := s.database.Model(&credential).Update("name", credential.Name).Error; err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (s *WebAuthnService) DeleteCredential(userID, credentialID string) error {
+	if _, err := uuid.Parse(credentialID); err != nil {
+		return ErrWebAuthnCredentialNotFound
+	}
+
+	result := s.database.Where("id = ? AND user_id = ?", credentialID, userID).Delete(&models.WebAuthnCredential{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebAuthnCredentialNotFound
+	}
+	return nil
+}
+
+// HasCredentials reports whether the user can use a passkey as their second factor.
+func (s *WebAuthnService) HasCredentials(userID uuid.UUID) bool {
+	var count int64
+	if err := s.database.Model(&models.WebAuthnCredential{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		log.Printf("Failed to count passkeys for user %s: %v", userID, err)
+		return false
+	}
+	return count > 0
+}
+
+// recordUse stores the signature counter and flags from a successful assertion. A counter that did not
+// move forward means the key may have been cloned, so the sign-in is refused.
+func (s *WebAuthnService) recordUse(wu *webAuthnUser, credential *webauthn.Credential) error {
+	if credential.Authenticator.CloneWarning {
+		log.Printf("Passkey counter for user %s went backwards, possible cloned authenticator", wu.user.ID)
+		return ErrWebAuthnVerificationFailed
+	}
+
+	return s.database.Model(&models.WebAuthnCredential{}).
+		Where("user_id = ? AND credential_id = ?", wu.user.ID, credential.ID).
+		Updates(map[string]interface{}{
+			"sign_count":   int64(credential.Authenticator.SignCount),
+			"flags":        uint8(credential.Flags.ProtocolValue()),
+			"synced":       credential.Flags.BackupState,
+			"last_used_at": time.Now(),
+		}).Error
+}
+
+// startCeremony stores the session data for the finish step and returns the options for the browser.
+// Ceremonies that were never finished are cleared out at the same time.
+func (s *WebAuthnService) startCeremony(userID *uuid.UUID, ceremonyType models.WebAuthnCeremonyType, session *webauthn.SessionData, options interface{}) (*dto.WebAuthnOptions, error) {
+	if err := s.database.Where("expires_at < ?", time.Now()).Delete(&models.WebAuthnCeremony{}).Error; err != nil {
+		log.Printf("Failed to clear expired WebAuthn ceremonies: %v", err)
+	}
+
+	ceremony := models.WebAuthnCeremony{
+		UserID:      userID,
+		Type:        ceremonyType,
+		SessionData: *session,
+		ExpiresAt:   time.Now().Add(webAuthnCeremonyTTL),
+	}
+	if err := s.database.Create(&ceremony).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.WebAuthnOptions{CeremonyID: ceremony.ID.String(), Options: options}, nil
+}
+
+// finishCeremony deletes the ceremony and returns it. Deleting first means each challenge is answered at
+// most once, even by concurrent requests.
+func (s *WebAuthnService) finishCeremony(ceremonyID string, userID *uuid.UUID, ceremonyType models.WebAuthnCeremonyType) (*models.WebAuthnCeremony, error) {
+	if _, err := uuid.Parse(ceremonyID); err != nil {
+		return nil, ErrInvalidWebAuthnCeremony
+	}
+
+	var ceremony models.WebAuthnCeremony
+	query := s.database.Where("id = ? AND type = ?", ceremonyID, ceremonyType)
+	if userID == nil {
+		query = query.Where("user_id IS NULL")
+	} else {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.First(&ceremony).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidWebAuthnCeremony
+		}
+		return nil, err
+	}
+
+	result := s.database.Where("id = ?", ceremony.ID).Delete(&models.WebAuthnCeremony{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || ceremony.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidWebAuthnCeremony
+	}
+
+	return &ceremony, nil
+}
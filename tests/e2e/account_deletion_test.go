@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type AccountDeletionTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.AccountDeletionService
+}
+
+func (suite *AccountDeletionTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewAccountDeletionService(suite.db)
+}
+
+func (suite *AccountDeletionTestSuite) create(value interface{}) {
+	suite.Require().NoError(suite.db.Create(value).Error)
+}
+
+// gone asserts that no row of the model, soft-deleted or not, matches the condition.
+func (suite *AccountDeletionTestSuite) gone(model interface{}, query string, args ...interface{}) {
+	var count int64
+	suite.Require().NoError(suite.db.Unscoped().Model(model).Where(query, args...).Count(&count).Error)
+	suite.Zero(count, "expected %T rows matching %q to be erased", model, query)
+}
+
+func (suite *AccountDeletionTestSuite) TestEraseCoversJobsOutreachReportsAndImpersonations() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	admin := utils.CreateTestUser(suite.T(), suite.db, "")
+
+	company := &models.Company{Name: "Test Company"}
+	suite.create(company)
+	posted := &models.Job{Title: "Engineer", CompanyId: company.ID, Location: "Remote", Description: "Build things",
+		PostedDate: time.Now(), EmploymentType: models.FullTime, CreatedBy: user.ID}
+	suite.create(posted)
+	otherJob := &models.Job{Title: "Designer", CompanyId: company.ID, Location: "Remote", Description: "Draw things",
+		PostedDate: time.Now(), EmploymentType: models.FullTime, CreatedBy: other.ID}
+	suite.create(otherJob)
+
+	// Someone applied to the user's job, and the user applied to someone else's
+	received := &models.JobApplication{JobID: posted.ID, ApplicantID: other.ID}
+	suite.create(received)
+	sent := &models.JobApplication{JobID: otherJob.ID, ApplicantID: user.ID}
+	suite.create(sent)
+
+	suite.create(&models.OutreachMessage{SenderID: user.ID, JobID: posted.ID, ApplicationID: received.ID,
+		RecipientID: other.ID, Status: models.OutreachStatusSent})
+	suite.create(&models.OutreachMessage{SenderID: other.ID, JobID: otherJob.ID, ApplicationID: sent.ID,
+		RecipientID: user.ID, Status: models.OutreachStatusSent})
+
+	conversation := utils.CreateDirectConversation(suite.T(), suite.db, user, other)
+	message := &models.Message{ConversationID: conversation.ID, SenderID: user.ID, Content: "something offensive"}
+	suite.create(message)
+	report := &models.ChatReport{ReporterID: other.ID, ReportedUserID: user.ID, ConversationID: &conversation.ID,
+		Reason: models.ChatReportReasonHarassment}
+	suite.create(report)
+	snapshot := &models.ChatReportMessage{ReportID: report.ID, MessageID: message.ID, SenderID: user.ID,
+		Content: message.Content, SentAt: time.Now()}
+	suite.create(snapshot)
+	details := "They keep messaging me about my address"
+	filed := &models.ChatReport{ReporterID: user.ID, ReportedUserID: other.ID, ConversationID: &conversation.ID,
+		Reason: models.ChatReportReasonHarassment, Details: &details}
+	suite.create(filed)
+
+	impersonation := &models.ImpersonationSession{AdminID: admin.ID, UserID: user.ID, Reason: "Support ticket about billing",
+		ExpiresAt: time.Now().Add(time.Hour)}
+	suite.create(impersonation)
+
+	suite.Require().NoError(suite.service.Erase(user.ID.String()))
+
+	suite.gone(&models.Job{}, "created_by = ?", user.ID)
+	suite.gone(&models.JobApplication{}, "id IN ?", []interface{}{received.ID, sent.ID})
+	suite.gone(&models.OutreachMessage{}, "sender_id = ? OR recipient_id = ?", user.ID, user.ID)
+	suite.gone(&models.ImpersonationSession{}, "id = ?", impersonation.ID)
+
+	var evidence models.ChatReportMessage
+	suite.Require().NoError(suite.db.First(&evidence, "id = ?", snapshot.ID).Error)
+	suite.Empty(evidence.Content)
+	var kept models.ChatReport
+	suite.Require().NoError(suite.db.First(&kept, "id = ?", filed.ID).Error)
+	suite.Nil(kept.Details)
+
+	var blanked models.Message
+	suite.Require().NoError(suite.db.Unscoped().First(&blanked, "id = ?", message.ID).Error)
+	suite.Empty(blanked.Content)
+	suite.NotNil(blanked.DeletedForEveryoneAt)
+
+	// The other user's job stays up
+	suite.NoError(suite.db.First(&models.Job{}, "id = ?", otherJob.ID).Error)
+}
+
+func (suite *AccountDeletionTestSuite) TestEraseFreesTheEmailAndUsername() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+
+	suite.Require().NoError(suite.service.Erase(user.ID.String()))
+
+	var placeholder models.User
+	suite.Require().NoError(suite.db.Unscoped().First(&placeholder, "id = ?", user.ID).Error)
+	suite.Equal("deleted-"+user.ID.String(), placeholder.Username)
+	suite.NotEqual(user.Email, placeholder.Email)
+	suite.True(placeholder.DeletedAt.Valid)
+	suite.gone(&models.User{}, "email = ? OR username = ?", user.Email, user.Username)
+}
+
+func TestAccountDeletionTestSuite(t *testing.T) {
+	suite.Run(t, new(AccountDeletionTestSuite))
+}
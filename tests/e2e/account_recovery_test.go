@@ -0,0 +1,205 @@
+package e2e
+
+import (
+	"testing"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+const recoveryTestPassword = "Password123!"
+
+type AccountRecoveryTestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	auth       *services.AuthService
+	tokens     *services.VerificationTokenService
+	emails     *services.EmailChangeService
+	recoveries *services.AccountRecoveryService
+	client     dto.SessionClient
+}
+
+func (suite *AccountRecoveryTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.auth = services.NewAuthService(suite.db)
+	suite.tokens = services.NewVerificationTokenService(suite.db)
+	suite.emails = services.NewEmailChangeService(suite.db)
+	suite.recoveries = services.NewAccountRecoveryService(suite.db)
+	suite.client = dto.SessionClient{UserAgent: "test", IPAddress: "198.51.100.46"}
+}
+
+// emailed stands in for the message the service sent: it issues a fresh secret for the same purpose and
+// target, which replaces the one in the email.
+func (suite *AccountRecoveryTestSuite) emailed(user *models.User, purpose models.VerificationPurpose, target string) string {
+	secret, err := suite.tokens.Issue(user.ID, purpose, target)
+	suite.Require().NoError(err)
+	return secret
+}
+
+func (suite *AccountRecoveryTestSuite) reload(user *models.User) *models.User {
+	var fresh models.User
+	suite.Require().NoError(suite.db.First(&fresh, "id = ?", user.ID).Error)
+	return &fresh
+}
+
+func (suite *AccountRecoveryTestSuite) activeSessions(user *models.User) int64 {
+	var count int64
+	suite.Require().NoError(suite.db.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&count).Error)
+	return count
+}
+
+func (suite *AccountRecoveryTestSuite) newEmail() string {
+	return "moved_" + uuid.NewString()[:8] + "@example.com"
+}
+
+// challenge signs a user with 2FA in with their password and returns the 2FA challenge.
+func (suite *AccountRecoveryTestSuite) challenge() (*models.User, string) {
+	user := utils.CreateTestUser(suite.T(), suite.db, recoveryTestPassword)
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "Foglio", AccountName: user.Email})
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.db.Model(user).Updates(map[string]interface{}{
+		"is_two_factor_enabled": true,
+		"two_factor_secret":     key.Secret(),
+	}).Error)
+
+	response, err := suite.auth.Signin(dto.SigninDto{Identifier: user.Email, Password: recoveryTestPassword}, suite.client)
+	suite.Require().NoError(err)
+	suite.Require().True(response.RequiresTwoFactor)
+	return user, response.ChallengeToken
+}
+
+// openRecovery takes a user with 2FA through to a recovery in its waiting period.
+func (suite *AccountRecoveryTestSuite) openRecovery() (*models.User, string, *models.AccountRecovery) {
+	user, challenge := suite.challenge()
+	_, err := suite.recoveries.Start(challenge)
+	suite.Require().NoError(err)
+
+	recovery, err := suite.recoveries.Verify(dto.VerifyRecoveryRequest{
+		ChallengeToken: challenge,
+		Code:           suite.emailed(user, models.PurposeAccountRecovery, ""),
+	}, suite.client)
+	suite.Require().NoError(err)
+	suite.Equal(models.RecoveryPending, recovery.Status)
+	return user, challenge, recovery
+}
+
+func (suite *AccountRecoveryTestSuite) TestEmailChangeNeedsThePasswordAndAFreeAddress() {
+	user := utils.CreateTestUser(suite.T(), suite.db, recoveryTestPassword)
+	taken := utils.CreateTestUser(suite.T(), suite.db, "")
+
+	err := suite.emails.RequestChange(user.ID.String(), dto.ChangeEmailRequest{NewEmail: suite.newEmail(), Password: "wrong"})
+	suite.ErrorIs(err, services.ErrIncorrectPassword)
+	err = suite.emails.RequestChange(user.ID.String(), dto.ChangeEmailRequest{NewEmail: taken.Email, Password: recoveryTestPassword})
+	suite.ErrorIs(err, services.ErrEmailInUse)
+	err = suite.emails.RequestChange(user.ID.String(), dto.ChangeEmailRequest{NewEmail: user.Email, Password: recoveryTestPassword})
+	suite.ErrorIs(err, services.ErrEmailUnchanged)
+}
+
+func (suite *AccountRecoveryTestSuite) TestEmailChangeIsConfirmedFromTheNewAddress() {
+	user := utils.CreateTestUser(suite.T(), suite.db, recoveryTestPassword)
+	newEmail := suite.newEmail()
+	suite.Require().NoError(suite.emails.RequestChange(user.ID.String(), dto.ChangeEmailRequest{NewEmail: newEmail, Password: recoveryTestPassword}))
+	suite.Equal(user.Email, suite.reload(user).Email)
+
+	_, _, err := suite.emails.ConfirmChange(user.ID.String(), "not-the-code")
+	suite.ErrorIs(err, services.ErrInvalidVerificationCode)
+
+	changed, oldEmail, err := suite.emails.ConfirmChange(user.ID.String(), suite.emailed(user, models.PurposeEmailChange, newEmail))
+	suite.Require().NoError(err)
+	suite.Equal(user.Email, oldEmail)
+	suite.Equal(newEmail, changed.Email)
+	suite.Equal(newEmail, suite.reload(user).Email)
+}
+
+func (suite *AccountRecoveryTestSuite) TestRevertingAnEmailChangeSignsEveryoneOut() {
+	user := utils.CreateTestUser(suite.T(), suite.db, recoveryTestPassword)
+	newEmail := suite.newEmail()
+	_, _, err := suite.emails.ConfirmChange(user.ID.String(), suite.emailed(user, models.PurposeEmailChange, newEmail))
+	suite.Require().NoError(err)
+	utils.SignIn(suite.T(), suite.db, user)
+	suite.Equal(int64(1), suite.activeSessions(user))
+
+	link := suite.emailed(user, models.PurposeEmailRevert, user.Email)
+	reverted, changedEmail, err := suite.emails.RevertChange(link)
+	suite.Require().NoError(err)
+	suite.Equal(newEmail, changedEmail)
+	suite.Equal(user.Email, reverted.Email)
+	suite.Equal(user.Email, suite.reload(user).Email)
+	suite.Zero(suite.activeSessions(user))
+
+	_, _, err = suite.emails.RevertChange(link)
+	suite.ErrorIs(err, services.ErrInvalidVerificationToken)
+}
+
+func (suite *AccountRecoveryTestSuite) TestRevertIsRefusedOnceTheOldAddressIsTaken() {
+	user := utils.CreateTestUser(suite.T(), suite.db, recoveryTestPassword)
+	oldEmail := user.Email
+	_, _, err := suite.emails.ConfirmChange(user.ID.String(), suite.emailed(user, models.PurposeEmailChange, suite.newEmail()))
+	suite.Require().NoError(err)
+
+	squatter := utils.CreateTestUser(suite.T(), suite.db, "")
+	suite.Require().NoError(suite.db.Model(squatter).Update("email", oldEmail).Error)
+
+	_, _, err = suite.emails.RevertChange(suite.emailed(user, models.PurposeEmailRevert, oldEmail))
+	suite.ErrorIs(err, services.ErrEmailRevertUnavailable)
+}
+
+func (suite *AccountRecoveryTestSuite) TestRecoveryWaitsOutItsDelay() {
+	user, challenge, _ := suite.openRecovery()
+
+	_, err := suite.recoveries.Start(challenge)
+	suite.ErrorIs(err, services.ErrRecoveryPending)
+	_, _, err = suite.recoveries.Complete(challenge)
+	suite.ErrorIs(err, services.ErrRecoveryNotReady)
+	suite.True(suite.reload(user).IsTwoFactorEnabled)
+}
+
+func (suite *AccountRecoveryTestSuite) TestApprovedRecoveryTurnsOffTwoFactor() {
+	user, challenge, recovery := suite.openRecovery()
+	utils.SignIn(suite.T(), suite.db, user)
+	admin := utils.CreateTestUser(suite.T(), suite.db, "")
+
+	_, err := suite.recoveries.ApproveRecovery(admin.ID.String(), recovery.ID.String())
+	suite.Require().NoError(err)
+
+	recovered, completed, err := suite.recoveries.Complete(challenge)
+	suite.Require().NoError(err)
+	suite.Equal(models.RecoveryCompleted, completed.Status)
+	suite.False(recovered.IsTwoFactorEnabled)
+
+	fresh := suite.reload(user)
+	suite.False(fresh.IsTwoFactorEnabled)
+	suite.Nil(fresh.TwoFactorSecret)
+	suite.Zero(suite.activeSessions(user))
+}
+
+func (suite *AccountRecoveryTestSuite) TestOwnerCanCancelARecoveryTheyDidNotStart() {
+	user, challenge, recovery := suite.openRecovery()
+
+	cancelled, err := suite.recoveries.Cancel(suite.emailed(user, models.PurposeRecoveryCancel, recovery.ID.String()))
+	suite.Require().NoError(err)
+	suite.Equal(models.RecoveryCancelled, cancelled.Status)
+
+	_, _, err = suite.recoveries.Complete(challenge)
+	suite.ErrorIs(err, services.ErrRecoveryNotFound)
+	suite.True(suite.reload(user).IsTwoFactorEnabled)
+}
+
+func (suite *AccountRecoveryTestSuite) TestAccountsWithoutTwoFactorHaveNothingToRecover() {
+	user, challenge := suite.challenge()
+	suite.Require().NoError(suite.db.Model(user).Update("is_two_factor_enabled", false).Error)
+
+	_, err := suite.recoveries.Start(challenge)
+	suite.ErrorIs(err, services.ErrRecoveryNotNeeded)
+}
+
+func TestAccountRecoveryTestSuite(t *testing.T) {
+	suite.Run(t, new(AccountRecoveryTestSuite))
+}
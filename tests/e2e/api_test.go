@@ -27,16 +27,13 @@ func (suite *E2ETestSuite) SetupSuite() {
 	routes.HealthRoutes(router)
 	routes.AuthRoutes(router)
 	routes.UserRoutes(router)
-	routes.JobRoutes(router)
-	routes.NotificationRoutes(router)
+	hub := lib.NewHub(nil)
+	routes.JobRoutes(router, hub)
+	routes.NotificationRoutes(router, hub)
 
 	suite.server.Router.NoRoute(lib.GlobalNotFound())
 }
 
-func (suite *E2ETestSuite) TearDownSuite() {
-	suite.server.Cleanup()
-}
-
 func (suite *E2ETestSuite) TestRootEndpoint() {
 	w := utils.MakeRequest(suite.server.Router, "GET", "/", nil)
 	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
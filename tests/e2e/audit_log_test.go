@@ -0,0 +1,134 @@
+package e2e
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+
+	"foglio/v2/src/lib"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
+	"foglio/v2/src/routes"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type AuditLogTestSuite struct {
+	suite.Suite
+	db        *gorm.DB
+	router    *gin.Engine
+	service   *services.AuditService
+	moderator *models.User
+	token     string
+}
+
+func (suite *AuditLogTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewAuditService(suite.db)
+
+	suite.moderator = utils.CreateTestUser(suite.T(), suite.db, "")
+	utils.GrantSuperAdmin(suite.T(), suite.db, suite.moderator)
+	suite.token = utils.SignIn(suite.T(), suite.db, suite.moderator)
+
+	suite.router = gin.New()
+	suite.router.Use(middlewares.ErrorHandlerMiddleware(), middlewares.AuthMiddleware())
+	routes.AdminRoutes(suite.router.Group("/api/v2"), lib.NewHub(nil))
+}
+
+// entries returns the audit log entries recorded against the entity, newest first.
+func (suite *AuditLogTestSuite) entries(entityID string) []models.AuditLog {
+	var logs []models.AuditLog
+	suite.Require().NoError(suite.db.Where("entity_id = ?", entityID).Order("created_at DESC").Find(&logs).Error)
+	return logs
+}
+
+func (suite *AuditLogTestSuite) TestAdminActionsAreRecorded() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	w := utils.MakeAuthenticatedRequest(suite.router, "PUT", "/api/v2/admin/users/"+user.ID.String()+"/suspend", suite.token,
+		map[string]string{"reason": "Spamming recruiters"})
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	logs := suite.entries(user.ID.String())
+	suite.Require().Len(logs, 1)
+	suite.Equal(models.AuditUserSuspended, logs[0].Action)
+	suite.Equal("user", logs[0].EntityType)
+	suite.Require().NotNil(logs[0].ActorID)
+	suite.Equal(suite.moderator.ID, *logs[0].ActorID)
+	suite.Equal("Spamming recruiters", logs[0].Changes["suspension_reason"].After)
+
+	// Staff can find it by what was done and to whom
+	w = utils.MakeAuthenticatedRequest(suite.router, "GET",
+		"/api/v2/admin/audit-logs?action=admin.*&entity_id="+user.ID.String(), suite.token, nil)
+	response := utils.AssertJSONResponse(suite.T(), w, http.StatusOK)
+	page := response["data"].(map[string]interface{})
+	suite.Equal(float64(1), page["total_items"])
+	suite.Equal(string(models.AuditUserSuspended), page["data"].([]interface{})[0].(map[string]interface{})["action"])
+}
+
+func (suite *AuditLogTestSuite) TestSecretsAreRedacted() {
+	entityID := uuid.NewString()
+	suite.service.Record(services.AuditEntry{
+		Action:     models.AuditPasswordChanged,
+		EntityType: "user",
+		EntityID:   entityID,
+		Before:     map[string]interface{}{"password": "old-hash", "name": "Ada"},
+		After:      map[string]interface{}{"password": "new-hash", "name": "Ada L."},
+	})
+
+	logs := suite.entries(entityID)
+	suite.Require().Len(logs, 1)
+	suite.Equal(models.AuditChange{Before: "[REDACTED]", After: "[REDACTED]"}, logs[0].Changes["password"])
+	suite.Equal(models.AuditChange{Before: "Ada", After: "Ada L."}, logs[0].Changes["name"])
+}
+
+func (suite *AuditLogTestSuite) TestEntriesCannotBeChangedOrDeleted() {
+	entityID := uuid.NewString()
+	suite.service.Record(services.AuditEntry{Action: models.AuditUserDeleted, EntityType: "user", EntityID: entityID})
+	logs := suite.entries(entityID)
+	suite.Require().Len(logs, 1)
+
+	err := suite.db.Model(&logs[0]).Update("action", models.AuditUserRestored).Error
+	suite.ErrorContains(err, "append-only")
+	err = suite.db.Delete(&logs[0]).Error
+	suite.ErrorContains(err, "append-only")
+
+	logs = suite.entries(entityID)
+	suite.Require().Len(logs, 1)
+	suite.Equal(models.AuditUserDeleted, logs[0].Action)
+}
+
+func (suite *AuditLogTestSuite) TestExportNeutralisesSpreadsheetFormulas() {
+	entityID := uuid.NewString()
+	suite.service.Record(services.AuditEntry{
+		Action:     models.AuditSignInFailed,
+		EntityType: "user",
+		EntityID:   entityID,
+		UserAgent:  `=HYPERLINK("https://attacker.example","click")`,
+	})
+
+	w := utils.MakeAuthenticatedRequest(suite.router, "GET", "/api/v2/admin/audit-logs/export?entity_id="+entityID, suite.token, nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	suite.Require().NoError(err)
+	suite.Require().Len(rows, 2)
+	suite.Equal("user_agent", rows[0][8])
+	suite.Equal(`'=HYPERLINK("https://attacker.example","click")`, rows[1][8])
+}
+
+func (suite *AuditLogTestSuite) TestOnlyStaffReadTheLog() {
+	member := utils.SignIn(suite.T(), suite.db, utils.CreateTestUser(suite.T(), suite.db, ""))
+	for _, path := range []string{"/api/v2/admin/audit-logs", "/api/v2/admin/audit-logs/export"} {
+		w := utils.MakeAuthenticatedRequest(suite.router, "GET", path, member, nil)
+		suite.Equal(http.StatusForbidden, w.Code, path)
+	}
+}
+
+func TestAuditLogTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditLogTestSuite))
+}
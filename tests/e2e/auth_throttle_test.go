@@ -0,0 +1,181 @@
+package e2e
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// Free sign-in failures allowed per account and per IP address before attempts are delayed.
+const (
+	accountFreeSigninAttempts = 5
+	ipFreeSigninAttempts      = 20
+)
+
+type AuthThrottleTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.AuthService
+}
+
+func (suite *AuthThrottleTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewAuthService(suite.db)
+}
+
+// client comes from a fresh documentation address, so runs and attempts never share an IP throttle.
+func (suite *AuthThrottleTestSuite) client() dto.SessionClient {
+	id := uuid.New()
+	return dto.SessionClient{UserAgent: "test", IPAddress: fmt.Sprintf("2001:db8:%x:%x::1", id[0:2], id[2:4])}
+}
+
+func (suite *AuthThrottleTestSuite) accountThrottle(identifier string) *models.AuthThrottle {
+	var throttle models.AuthThrottle
+	err := suite.db.Where("action = ? AND key = ?", models.AuthActionSignin,
+		lib.HashToken("account:"+strings.ToLower(identifier))).First(&throttle).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	suite.Require().NoError(err)
+	return &throttle
+}
+
+// failSignins makes the free attempts with a wrong password, then returns the error of the next two.
+func (suite *AuthThrottleTestSuite) failSignins(identifier string) (error, error) {
+	for i := 0; i < accountFreeSigninAttempts; i++ {
+		_, err := suite.service.Signin(dto.SigninDto{Identifier: identifier, Password: "wrong-password"}, suite.client())
+		suite.Require().ErrorIs(err, services.ErrInvalidCredentials)
+	}
+	_, delayed := suite.service.Signin(dto.SigninDto{Identifier: identifier, Password: "wrong-password"}, suite.client())
+	_, throttled := suite.service.Signin(dto.SigninDto{Identifier: identifier, Password: "wrong-password"}, suite.client())
+	return delayed, throttled
+}
+
+func (suite *AuthThrottleTestSuite) TestExistingAndUnknownAccountsAreThrottledAlike() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "correct-password")
+	unknown := "test_missing_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+
+	existingDelayed, existingThrottled := suite.failSignins(user.Username)
+	// The right password does not get past the delay either
+	_, err := suite.service.Signin(dto.SigninDto{Identifier: user.Username, Password: "correct-password"}, suite.client())
+	suite.ErrorIs(err, services.ErrTooManyAttempts)
+	unknownDelayed, unknownThrottled := suite.failSignins(unknown)
+
+	suite.ErrorIs(existingDelayed, services.ErrInvalidCredentials)
+	suite.ErrorIs(unknownDelayed, services.ErrInvalidCredentials)
+	suite.ErrorIs(existingThrottled, services.ErrTooManyAttempts)
+	suite.ErrorIs(unknownThrottled, services.ErrTooManyAttempts)
+
+	// Both are counted under the identifier that was typed, never under the account's ID
+	existing, missing := suite.accountThrottle(user.Username), suite.accountThrottle(unknown)
+	suite.Require().NotNil(existing)
+	suite.Require().NotNil(missing)
+	suite.Equal(missing.Failures, existing.Failures)
+	suite.NotNil(existing.BlockedUntil)
+	suite.NotNil(missing.BlockedUntil)
+	suite.Nil(suite.accountThrottle(user.ID.String()))
+}
+
+func (suite *AuthThrottleTestSuite) TestIdentifierCaseDoesNotEvadeTheThrottle() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "correct-password")
+
+	for i := 0; i <= accountFreeSigninAttempts; i++ {
+		identifier := user.Username
+		if i%2 == 1 {
+			identifier = strings.ToUpper(identifier)
+		}
+		_, err := suite.service.Signin(dto.SigninDto{Identifier: identifier, Password: "wrong-password"}, suite.client())
+		suite.Require().ErrorIs(err, services.ErrInvalidCredentials)
+	}
+
+	_, err := suite.service.Signin(dto.SigninDto{Identifier: strings.ToUpper(user.Username), Password: "wrong-password"}, suite.client())
+	suite.ErrorIs(err, services.ErrTooManyAttempts)
+}
+
+func (suite *AuthThrottleTestSuite) TestOneAddressIsThrottledAcrossAccounts() {
+	client := suite.client()
+
+	for i := 0; i <= ipFreeSigninAttempts; i++ {
+		identifier := fmt.Sprintf("test_spray_%s_%d", strings.ReplaceAll(uuid.NewString(), "-", "")[:8], i)
+		_, err := suite.service.Signin(dto.SigninDto{Identifier: identifier, Password: "wrong-password"}, client)
+		suite.Require().ErrorIs(err, services.ErrInvalidCredentials)
+	}
+
+	user := utils.CreateTestUser(suite.T(), suite.db, "correct-password")
+	_, err := suite.service.Signin(dto.SigninDto{Identifier: user.Username, Password: "correct-password"}, client)
+	suite.ErrorIs(err, services.ErrTooManyAttempts)
+
+	// Another address is unaffected
+	_, err = suite.service.Signin(dto.SigninDto{Identifier: user.Username, Password: "correct-password"}, suite.client())
+	suite.NoError(err)
+}
+
+func (suite *AuthThrottleTestSuite) TestConcurrentAttemptsCannotOutrunTheThrottle() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "correct-password")
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		compared int
+	)
+	for i := 0; i < 4*accountFreeSigninAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := suite.service.Signin(dto.SigninDto{Identifier: user.Username, Password: "wrong-password"}, suite.client())
+			if errors.Is(err, services.ErrInvalidCredentials) {
+				mu.Lock()
+				compared++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every attempt is counted before the password is compared, so the burst gets no more tries than a
+	// client sending them one by one
+	suite.LessOrEqual(compared, accountFreeSigninAttempts+1)
+	throttle := suite.accountThrottle(user.Username)
+	suite.Require().NotNil(throttle)
+	suite.Equal(compared, throttle.Failures)
+}
+
+func (suite *AuthThrottleTestSuite) TestSuccessfulSigninsDoNotCountAgainstTheAddress() {
+	client := suite.client()
+	user := utils.CreateTestUser(suite.T(), suite.db, "correct-password")
+
+	for i := 0; i <= ipFreeSigninAttempts; i++ {
+		_, err := suite.service.Signin(dto.SigninDto{Identifier: user.Username, Password: "correct-password"}, client)
+		suite.Require().NoError(err)
+	}
+}
+
+func (suite *AuthThrottleTestSuite) TestPasswordlessAccountsFailLikeWrongPasswords() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	suite.Require().NoError(suite.db.Model(user).Update("provider", "github").Error)
+
+	_, err := suite.service.Signin(dto.SigninDto{Identifier: user.Username, Password: ""}, suite.client())
+	suite.ErrorIs(err, services.ErrInvalidCredentials)
+	_, err = suite.service.Signin(dto.SigninDto{Identifier: user.Username, Password: "anything"}, suite.client())
+	suite.ErrorIs(err, services.ErrInvalidCredentials)
+
+	throttle := suite.accountThrottle(user.Username)
+	suite.Require().NotNil(throttle)
+	suite.Equal(2, throttle.Failures)
+}
+
+func TestAuthThrottleTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthThrottleTestSuite))
+}
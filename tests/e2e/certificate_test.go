@@ -0,0 +1,121 @@
+package e2e
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"foglio/v2/src/config"
+	"foglio/v2/src/models"
+	"foglio/v2/src/routes"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCertificateIssuedByPebble obtains a certificate for a verified custom domain from a local Pebble CA
+// and serves it through GetCertificate. It runs when PEBBLE_URL is the directory of a Pebble server, e.g.
+//
+//	pebble -config test/config/pebble-config.json -dnsserver 127.0.0.1:8053
+//	pebble-challtestsrv -defaultIPv4 127.0.0.1
+//	PEBBLE_URL=https://localhost:14000/dir PEBBLE_CA_FILE=test/certs/pebble.minica.pem go test ./tests/e2e/ -run Pebble
+//
+// Pebble validates the HTTP-01 challenge against PEBBLE_HTTP_ADDR (":5002" by default), where the test
+// serves the challenge route; PEBBLE_VA_ALWAYS_VALID=1 on the Pebble side skips that validation instead.
+func TestCertificateIssuedByPebble(t *testing.T) {
+	directoryURL := os.Getenv("PEBBLE_URL")
+	if directoryURL == "" {
+		t.Skip("PEBBLE_URL is not set; skipping the ACME integration test")
+	}
+	db := utils.RequireDatabase(t)
+
+	previous := *config.AppConfig
+	config.AppConfig.AcmeDirectoryUrl = directoryURL
+	config.AppConfig.AcmeCaFile = os.Getenv("PEBBLE_CA_FILE")
+	config.AppConfig.AcmeEmail = "ops@example.com"
+	t.Cleanup(func() { *config.AppConfig = previous })
+
+	challengeAddr := os.Getenv("PEBBLE_HTTP_ADDR")
+	if challengeAddr == "" {
+		challengeAddr = ":5002"
+	}
+	gin.SetMode(gin.TestMode)
+	app := gin.New()
+	routes.ACMEChallengeRoutes(app)
+	listener, err := net.Listen("tcp", challengeAddr)
+	require.NoError(t, err)
+	challengeServer := &http.Server{Handler: app, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := challengeServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("Challenge server failed: %v", err)
+		}
+	}()
+	t.Cleanup(func() { challengeServer.Close() })
+
+	domain := "pebble-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12] + ".example.com"
+	user := utils.CreateTestUser(t, db, "")
+	verifiedAt := time.Now()
+	user.Domain = &models.Domain{CustomDomain: domain, CustomDomainStatus: models.DomainStatusVerified, CustomDomainVerifiedAt: &verifiedAt}
+	require.NoError(t, db.Model(user).Select("domain").Updates(user).Error)
+
+	service := services.NewCertificateService(db)
+	t.Cleanup(func() { _ = service.RemoveCertificate(domain) })
+	require.NoError(t, service.RequestCertificate(user.ID, domain))
+
+	var stored *models.DomainCertificate
+	require.Eventually(t, func() bool {
+		certificate, err := service.GetDomainCertificate(domain)
+		if err != nil || certificate == nil {
+			return false
+		}
+		stored = certificate
+		return stored.Status == models.CertificateIssued || stored.Status == models.CertificateFailed
+	}, 2*time.Minute, time.Second)
+	require.Equal(t, models.CertificateIssued, stored.Status, "issuance failed: %v", stored.Error)
+	require.NotNil(t, stored.ExpiresAt)
+	require.NotNil(t, stored.RenewAt)
+	assert.True(t, stored.RenewAt.Before(*stored.ExpiresAt))
+
+	served, err := service.GetCertificate(&tls.ClientHelloInfo{ServerName: domain})
+	require.NoError(t, err)
+	require.NotNil(t, served)
+	leaf, err := x509.ParseCertificate(served.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{domain}, leaf.DNSNames)
+
+	// A full handshake picks the same certificate for the name; Pebble's root changes every run, so the
+	// chain is compared rather than verified
+	tlsConfig, err := service.TLSConfig()
+	require.NoError(t, err)
+	tlsListener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	require.NoError(t, err)
+	t.Cleanup(func() { tlsListener.Close() })
+	go func() {
+		conn, err := tlsListener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", tlsListener.Addr().String(), &tls.Config{ServerName: domain, InsecureSkipVerify: true})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, leaf.Raw, conn.ConnectionState().PeerCertificates[0].Raw)
+
+	// Names without a certificate fall through to the configured ones
+	other, err := service.GetCertificate(&tls.ClientHelloInfo{ServerName: "unknown-" + domain})
+	require.NoError(t, err)
+	assert.Nil(t, other)
+}
@@ -0,0 +1,244 @@
+package e2e
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"foglio/v2/src/config"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
+	"foglio/v2/src/routes"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+func TestOnlyMediaAttachmentsAreServedInline(t *testing.T) {
+	for _, mimeType := range []string{"image/png", "image/jpeg", "video/mp4", "audio/mpeg"} {
+		assert.True(t, services.IsInlineAttachmentType(mimeType), mimeType)
+	}
+	for _, mimeType := range []string{"text/html", "image/svg+xml", "application/pdf", "text/plain", "application/octet-stream", ""} {
+		assert.False(t, services.IsInlineAttachmentType(mimeType), mimeType)
+	}
+}
+
+// signedLink splits a signed attachment link into the path and the expires and signature parameters.
+func signedLink(t *testing.T, link string) (string, string, string) {
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	return parsed.Path, parsed.Query().Get("expires"), parsed.Query().Get("signature")
+}
+
+func TestAttachmentLinksAreSignedForBrowsers(t *testing.T) {
+	config.InitializeConfig()
+	t.Cleanup(config.InitializeConfig)
+	config.AppConfig.ApiUrl = "https://api.test"
+	config.AppConfig.JWTSecret = []byte("attachment-link-secret")
+
+	id := uuid.NewString()
+	stored := "https://api.test/api/v2/chat/attachments/" + id
+	media := services.SignAttachmentMedia(models.MessageMedia{ID: id, Type: models.MediaTypeImage, URL: stored, Thumbnail: stored + "/thumbnail"})
+
+	path, expires, signature := signedLink(t, media.URL)
+	assert.Equal(t, "/api/v2/chat/media/"+id, path)
+	assert.NotEmpty(t, signature)
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	require.NoError(t, err)
+	assert.Greater(t, expiresAt, time.Now().Add(10*time.Minute).Unix())
+	assert.LessOrEqual(t, expiresAt, time.Now().Add(30*time.Minute).Unix())
+
+	thumbnailPath, _, thumbnailSignature := signedLink(t, media.Thumbnail)
+	assert.Equal(t, "/api/v2/chat/media/"+id+"/thumbnail", thumbnailPath)
+	assert.NotEqual(t, signature, thumbnailSignature)
+
+	// Links are stable within a window so browsers can cache them
+	assert.Equal(t, media, services.SignAttachmentMedia(models.MessageMedia{ID: id, Type: models.MediaTypeImage, URL: stored, Thumbnail: stored + "/thumbnail"}))
+
+	external := models.MessageMedia{ID: id, Type: models.MediaTypeImage, URL: "https://cdn.example.com/cat.png"}
+	assert.Equal(t, external, services.SignAttachmentMedia(external))
+
+	// Links are checked before anything is looked up, so no database is needed to refuse them
+	service := services.NewChatService(nil, nil, nil)
+	for name, link := range map[string][3]string{
+		"tampered signature":   {id, expires, strings.ToUpper(signature)},
+		"extended expiry":      {id, strconv.FormatInt(expiresAt+3600, 10), signature},
+		"other attachment":     {uuid.NewString(), expires, signature},
+		"missing parameters":   {id, "", ""},
+		"signed for thumbnail": {id, expires, thumbnailSignature},
+	} {
+		_, _, err := service.OpenSignedAttachment(link[0], false, link[1], link[2])
+		assert.ErrorIs(t, err, services.ErrAttachmentLinkInvalid, name)
+	}
+
+	config.AppConfig.JWTSecret = []byte("rotated")
+	_, _, err = service.OpenSignedAttachment(id, false, expires, signature)
+	assert.ErrorIs(t, err, services.ErrAttachmentLinkInvalid)
+}
+
+type ChatAttachmentTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.ChatService
+	router  *gin.Engine
+}
+
+func (suite *ChatAttachmentTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewChatService(suite.db, nil, nil)
+
+	suite.router = gin.New()
+	suite.router.Use(middlewares.ErrorHandlerMiddleware(), middlewares.AuthMiddleware())
+	routes.ChatRoutes(suite.router.Group("/api/v2"), lib.NewHub(nil))
+}
+
+// createAttachment records an upload to the conversation without storing a file.
+func (suite *ChatAttachmentTestSuite) createAttachment(conversation *models.Conversation, uploader *models.User, fileName, mimeType string, age time.Duration) *models.ChatAttachment {
+	attachment := &models.ChatAttachment{
+		ConversationID:  conversation.ID,
+		UploaderID:      uploader.ID,
+		Type:            models.MediaTypeFile,
+		FileName:        fileName,
+		MimeType:        mimeType,
+		StoragePublicID: "foglio-chat/" + uuid.NewString(),
+		StorageResource: "raw",
+		CreatedAt:       time.Now().Add(-age),
+	}
+	suite.Require().NoError(suite.db.Create(attachment).Error)
+	return attachment
+}
+
+func (suite *ChatAttachmentTestSuite) exists(attachment *models.ChatAttachment) bool {
+	var count int64
+	suite.Require().NoError(suite.db.Model(&models.ChatAttachment{}).Where("id = ?", attachment.ID).Count(&count).Error)
+	return count == 1
+}
+
+func (suite *ChatAttachmentTestSuite) TestUnattachedUploadsArePurged() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	conversation := utils.CreateDirectConversation(suite.T(), suite.db, user, other)
+
+	abandoned := suite.createAttachment(conversation, user, "notes.txt", "text/plain", 25*time.Hour)
+	sent := suite.createAttachment(conversation, user, "notes.txt", "text/plain", 25*time.Hour)
+	pending := suite.createAttachment(conversation, user, "notes.txt", "text/plain", time.Hour)
+	suite.Require().NoError(suite.db.Create(&models.Message{ConversationID: conversation.ID, SenderID: user.ID,
+		Media: models.MessageMediaList{{ID: sent.ID.String(), Type: sent.Type, URL: "https://api.test/api/v2/chat/attachments/" + sent.ID.String()}}}).Error)
+
+	// Deleting the stored files fails without storage configured, which is only logged
+	suite.Require().NoError(suite.service.PurgeUnattachedAttachments())
+	suite.False(suite.exists(abandoned))
+	suite.True(suite.exists(sent))
+	suite.True(suite.exists(pending))
+}
+
+func (suite *ChatAttachmentTestSuite) TestUploadsOfDisallowedTypesAreRejected() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	conversation := utils.CreateDirectConversation(suite.T(), suite.db, user, other)
+	token := utils.SignIn(suite.T(), suite.db, user)
+
+	uploads := []struct{ mediaType, fileName string }{
+		{"FILE", "setup.exe"},
+		{"FILE", "install.SH"},
+		{"DOCUMENT", "page.html"},
+		{"IMAGE", "vector.svg"},
+		{"STICKER", "sticker.png"},
+	}
+	for _, upload := range uploads {
+		w := utils.MakeMultipartRequest(suite.router, "/api/v2/chat/attachments", token,
+			map[string]string{"conversation_id": conversation.ID.String(), "type": upload.mediaType}, "file", upload.fileName, []byte("content"))
+		response := utils.AssertJSONResponse(suite.T(), w, http.StatusBadRequest)
+		suite.Equal("UNSUPPORTED_ATTACHMENT", response["code"], upload.fileName)
+	}
+}
+
+func (suite *ChatAttachmentTestSuite) TestImagesMustReallyBeImages() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	conversation := utils.CreateDirectConversation(suite.T(), suite.db, user, other)
+	token := utils.SignIn(suite.T(), suite.db, user)
+
+	// A page named like an image would run as HTML if it were ever served inline
+	w := utils.MakeMultipartRequest(suite.router, "/api/v2/chat/attachments", token,
+		map[string]string{"conversation_id": conversation.ID.String(), "type": "IMAGE"}, "file", "cat.png", []byte("<html><script>alert(1)</script></html>"))
+	response := utils.AssertJSONResponse(suite.T(), w, http.StatusBadRequest)
+	suite.Equal("UNSUPPORTED_ATTACHMENT", response["code"])
+
+	var count int64
+	suite.Require().NoError(suite.db.Model(&models.ChatAttachment{}).Where("conversation_id = ?", conversation.ID).Count(&count).Error)
+	suite.Zero(count)
+}
+
+func (suite *ChatAttachmentTestSuite) TestOnlyParticipantsCanFetchAttachments() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	conversation := utils.CreateDirectConversation(suite.T(), suite.db, user, other)
+	attachment := suite.createAttachment(conversation, user, "notes.txt", "text/plain", 0)
+	outsider := utils.SignIn(suite.T(), suite.db, utils.CreateTestUser(suite.T(), suite.db, ""))
+
+	for _, path := range []string{"/api/v2/chat/attachments/" + attachment.ID.String(), "/api/v2/chat/attachments/" + attachment.ID.String() + "/thumbnail"} {
+		w := utils.MakeAuthenticatedRequest(suite.router, "GET", path, outsider, nil)
+		utils.AssertJSONResponse(suite.T(), w, http.StatusForbidden)
+	}
+	w := utils.MakeMultipartRequest(suite.router, "/api/v2/chat/attachments", outsider,
+		map[string]string{"conversation_id": conversation.ID.String(), "type": "DOCUMENT"}, "file", "notes.txt", []byte("hello"))
+	utils.AssertJSONResponse(suite.T(), w, http.StatusForbidden)
+
+	// Without a token, only a signed link gets through
+	w = utils.MakeRequest(suite.router, "GET", "/api/v2/chat/attachments/"+attachment.ID.String(), nil)
+	suite.Equal(http.StatusUnauthorized, w.Code)
+	w = utils.MakeRequest(suite.router, "GET", "/api/v2/chat/media/"+attachment.ID.String(), nil)
+	utils.AssertJSONResponse(suite.T(), w, http.StatusForbidden)
+}
+
+func (suite *ChatAttachmentTestSuite) TestOnlyMediaIsShownInline() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	conversation := utils.CreateDirectConversation(suite.T(), suite.db, user, other)
+	token := utils.SignIn(suite.T(), suite.db, other)
+	page := suite.createAttachment(conversation, user, "page.html", "text/html", 0)
+	photo := suite.createAttachment(conversation, user, "photo.png", "image/png", 0)
+	utils.UseFakeStorage(suite.T(), []byte("<html><script>alert(1)</script></html>"))
+
+	signed := func(attachment *models.ChatAttachment) string {
+		media := services.SignAttachmentMedia(models.MessageMedia{ID: attachment.ID.String(), URL: config.AppConfig.ApiUrl + "/api/v2/chat/attachments/" + attachment.ID.String()})
+		link, err := url.Parse(media.URL)
+		suite.Require().NoError(err)
+		return link.RequestURI()
+	}
+
+	for _, w := range []*httptest.ResponseRecorder{
+		utils.MakeAuthenticatedRequest(suite.router, "GET", "/api/v2/chat/attachments/"+page.ID.String(), token, nil),
+		utils.MakeRequest(suite.router, "GET", signed(page), nil),
+	} {
+		suite.Equal(http.StatusOK, w.Code)
+		suite.Equal(`attachment; filename="page.html"`, w.Header().Get("Content-Disposition"))
+		suite.Equal("nosniff", w.Header().Get("X-Content-Type-Options"))
+		suite.Equal("text/html", w.Header().Get("Content-Type"))
+	}
+
+	for _, w := range []*httptest.ResponseRecorder{
+		utils.MakeAuthenticatedRequest(suite.router, "GET", "/api/v2/chat/attachments/"+photo.ID.String(), token, nil),
+		utils.MakeRequest(suite.router, "GET", signed(photo), nil),
+	} {
+		suite.Equal(http.StatusOK, w.Code)
+		suite.Equal(`inline; filename="photo.png"`, w.Header().Get("Content-Disposition"))
+		suite.Equal("nosniff", w.Header().Get("X-Content-Type-Options"))
+	}
+}
+
+func TestChatAttachmentTestSuite(t *testing.T) {
+	suite.Run(t, new(ChatAttachmentTestSuite))
+}
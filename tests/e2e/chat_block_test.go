@@ -0,0 +1,108 @@
+package e2e
+
+import (
+	"encoding/json"
+	"testing"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// events returns the chat event types published to the user.
+func (b *recordingBroker) events(userID string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var events []string
+	for _, message := range b.published {
+		if message.Target == lib.BrokerTargetUser && message.UserID == userID {
+			if eventType, ok := message.Notification.Data["event_type"].(string); ok {
+				events = append(events, eventType)
+			}
+		}
+	}
+	return events
+}
+
+type ChatBlockTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	broker  *recordingBroker
+	service *services.ChatService
+}
+
+func (suite *ChatBlockTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.broker = &recordingBroker{MemoryBroker: lib.NewMemoryBroker()}
+	suite.service = services.NewChatService(suite.db, lib.NewHub(suite.broker), nil)
+}
+
+func (suite *ChatBlockTestSuite) typing(sender *models.User, payload dto.WebSocketTypingDto) error {
+	raw, err := json.Marshal(payload)
+	suite.Require().NoError(err)
+	_, err = suite.service.HandleWebSocketMessage(sender.ID.String(), lib.SocketEnvelope{Type: lib.SocketTypeTyping, Payload: raw})
+	return err
+}
+
+func (suite *ChatBlockTestSuite) block(blocker, blocked *models.User) {
+	_, err := suite.service.BlockUser(blocker.ID.String(), dto.BlockUserDto{UserID: blocked.ID.String()})
+	suite.Require().NoError(err)
+}
+
+func (suite *ChatBlockTestSuite) TestBlockStopsMessagesBothWays() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	conversation := utils.CreateDirectConversation(suite.T(), suite.db, user, other)
+	suite.block(user, other)
+
+	_, err := suite.service.SendMessage(other.ID.String(), dto.SendMessageDto{RecipientID: user.ID.String(), Content: "hello?"})
+	suite.ErrorIs(err, services.ErrUserBlocked)
+	_, err = suite.service.SendMessage(other.ID.String(), dto.SendMessageDto{ConversationID: conversation.ID.String(), Content: "hello?"})
+	suite.ErrorIs(err, services.ErrUserBlocked)
+	_, err = suite.service.SendMessage(user.ID.String(), dto.SendMessageDto{RecipientID: other.ID.String(), Content: "hello?"})
+	suite.ErrorIs(err, services.ErrUserBlocked)
+
+	suite.Require().NoError(suite.service.UnblockUser(user.ID.String(), other.ID.String()))
+	_, err = suite.service.SendMessage(other.ID.String(), dto.SendMessageDto{RecipientID: user.ID.String(), Content: "hello again"})
+	suite.NoError(err)
+}
+
+func (suite *ChatBlockTestSuite) TestTypingReachesTheOtherSide() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	conversation := utils.CreateDirectConversation(suite.T(), suite.db, user, other)
+
+	suite.Require().NoError(suite.typing(user, dto.WebSocketTypingDto{ConversationID: conversation.ID.String()}))
+	suite.Require().NoError(suite.typing(user, dto.WebSocketTypingDto{RecipientID: other.ID.String()}))
+	suite.Equal([]string{"typing", "typing"}, suite.broker.events(other.ID.String()))
+}
+
+func (suite *ChatBlockTestSuite) TestTypingIsRefusedAcrossABlock() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	conversation := utils.CreateDirectConversation(suite.T(), suite.db, user, other)
+	suite.block(user, other)
+
+	suite.ErrorIs(suite.typing(other, dto.WebSocketTypingDto{RecipientID: user.ID.String()}), services.ErrUserBlocked)
+	suite.ErrorIs(suite.typing(other, dto.WebSocketTypingDto{ConversationID: conversation.ID.String()}), services.ErrUserBlocked)
+	suite.Empty(suite.broker.events(user.ID.String()))
+}
+
+func (suite *ChatBlockTestSuite) TestUsersBannedFromChatCannotSendTyping() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	stranger := utils.CreateTestUser(suite.T(), suite.db, "")
+	suite.Require().NoError(suite.db.Model(user).Update("chat_banned_at", gorm.Expr("NOW()")).Error)
+
+	suite.ErrorIs(suite.typing(user, dto.WebSocketTypingDto{RecipientID: stranger.ID.String()}), services.ErrChatBanned)
+	suite.Empty(suite.broker.events(stranger.ID.String()))
+}
+
+func TestChatBlockTestSuite(t *testing.T) {
+	suite.Run(t, new(ChatBlockTestSuite))
+}
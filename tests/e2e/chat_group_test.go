@@ -0,0 +1,134 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ChatGroupTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.ChatService
+}
+
+func (suite *ChatGroupTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewChatService(suite.db, nil, nil)
+}
+
+// createGroup starts a group owned by owner with the given members.
+func (suite *ChatGroupTestSuite) createGroup(owner *models.User, members ...*models.User) *dto.ConversationResponse {
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.ID.String())
+	}
+	group, err := suite.service.CreateGroupConversation(owner.ID.String(), dto.CreateGroupConversationDto{Name: "Test Group", ParticipantIDs: ids})
+	suite.Require().NoError(err)
+	return group
+}
+
+func (suite *ChatGroupTestSuite) TestNewMembersOnlySeeMessagesFromWhenTheyJoined() {
+	owner := utils.CreateTestUser(suite.T(), suite.db, "")
+	member := utils.CreateTestUser(suite.T(), suite.db, "")
+	newcomer := utils.CreateTestUser(suite.T(), suite.db, "")
+	group := suite.createGroup(owner, member)
+
+	before := &models.Message{ConversationID: uuid.MustParse(group.ID), SenderID: owner.ID, Content: "before you joined",
+		CreatedAt: time.Now().Add(-time.Minute)}
+	suite.Require().NoError(suite.db.Create(before).Error)
+
+	_, err := suite.service.AddParticipants(owner.ID.String(), group.ID, dto.AddParticipantsDto{UserIDs: []string{newcomer.ID.String()}})
+	suite.Require().NoError(err)
+	after, err := suite.service.SendMessage(owner.ID.String(), dto.SendMessageDto{ConversationID: group.ID, Content: "welcome"})
+	suite.Require().NoError(err)
+
+	response, err := suite.service.GetMessages(newcomer.ID.String(), group.ID, dto.ChatQueryParams{Limit: 20})
+	suite.Require().NoError(err)
+	suite.Equal([]string{after.ID.String()}, messageIDs(response))
+	suite.Equal(1, response.TotalItems)
+
+	// Nor can an older message be used as a cursor to reach it
+	_, err = suite.service.GetMessages(newcomer.ID.String(), group.ID, dto.ChatQueryParams{Around: before.ID.String()})
+	suite.ErrorIs(err, services.ErrMessageNotFound)
+
+	// Existing members keep the whole history
+	response, err = suite.service.GetMessages(member.ID.String(), group.ID, dto.ChatQueryParams{Limit: 20})
+	suite.Require().NoError(err)
+	suite.Len(response.Data, 2)
+}
+
+// roles returns each participant's role in the conversation.
+func (suite *ChatGroupTestSuite) roles(conversationID string) map[uuid.UUID]models.ParticipantRole {
+	var participants []models.ConversationParticipant
+	suite.Require().NoError(suite.db.Where("conversation_id = ?", conversationID).Find(&participants).Error)
+	roles := make(map[uuid.UUID]models.ParticipantRole, len(participants))
+	for _, participant := range participants {
+		roles[participant.UserID] = participant.Role
+	}
+	return roles
+}
+
+func (suite *ChatGroupTestSuite) TestOnlyTheOwnerManagesMembers() {
+	owner := utils.CreateTestUser(suite.T(), suite.db, "")
+	member := utils.CreateTestUser(suite.T(), suite.db, "")
+	newcomer := utils.CreateTestUser(suite.T(), suite.db, "")
+	group := suite.createGroup(owner, member)
+	add := dto.AddParticipantsDto{UserIDs: []string{newcomer.ID.String()}}
+
+	_, err := suite.service.AddParticipants(member.ID.String(), group.ID, add)
+	suite.ErrorIs(err, services.ErrNotConversationOwner)
+
+	response, err := suite.service.AddParticipants(owner.ID.String(), group.ID, add)
+	suite.Require().NoError(err)
+	suite.Len(response.Participants, 3)
+	_, err = suite.service.AddParticipants(owner.ID.String(), group.ID, add)
+	suite.ErrorIs(err, services.ErrAlreadyParticipant)
+
+	suite.ErrorIs(suite.service.RemoveParticipant(member.ID.String(), group.ID, newcomer.ID.String()), services.ErrNotConversationOwner)
+	suite.Require().NoError(suite.service.RemoveParticipant(owner.ID.String(), group.ID, newcomer.ID.String()))
+	_, err = suite.service.GetMessages(newcomer.ID.String(), group.ID, dto.ChatQueryParams{Limit: 20})
+	suite.ErrorIs(err, services.ErrNotParticipant)
+
+	// Members may still leave on their own
+	suite.Require().NoError(suite.service.RemoveParticipant(member.ID.String(), group.ID, member.ID.String()))
+	suite.Equal(map[uuid.UUID]models.ParticipantRole{owner.ID: models.ParticipantRoleOwner}, suite.roles(group.ID))
+}
+
+func (suite *ChatGroupTestSuite) TestLeavingOwnerHandsTheGroupToTheLongestStandingMember() {
+	owner := utils.CreateTestUser(suite.T(), suite.db, "")
+	first := utils.CreateTestUser(suite.T(), suite.db, "")
+	second := utils.CreateTestUser(suite.T(), suite.db, "")
+	group := suite.createGroup(owner, first)
+	_, err := suite.service.AddParticipants(owner.ID.String(), group.ID, dto.AddParticipantsDto{UserIDs: []string{second.ID.String()}})
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.service.RemoveParticipant(owner.ID.String(), group.ID, owner.ID.String()))
+	suite.Equal(map[uuid.UUID]models.ParticipantRole{
+		first.ID:  models.ParticipantRoleOwner,
+		second.ID: models.ParticipantRoleMember,
+	}, suite.roles(group.ID))
+
+	// The new owner can manage the group straight away
+	_, err = suite.service.RenameConversation(first.ID.String(), group.ID, dto.RenameConversationDto{Name: "Renamed"})
+	suite.NoError(err)
+
+	// Once everyone has left, the group is gone
+	suite.Require().NoError(suite.service.RemoveParticipant(second.ID.String(), group.ID, second.ID.String()))
+	suite.Require().NoError(suite.service.RemoveParticipant(first.ID.String(), group.ID, first.ID.String()))
+	var count int64
+	suite.Require().NoError(suite.db.Model(&models.Conversation{}).Where("id = ?", group.ID).Count(&count).Error)
+	suite.Zero(count)
+}
+
+func TestChatGroupTestSuite(t *testing.T) {
+	suite.Run(t, new(ChatGroupTestSuite))
+}
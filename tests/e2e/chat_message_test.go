@@ -0,0 +1,113 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ChatMessageTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.ChatService
+}
+
+func (suite *ChatMessageTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewChatService(suite.db, nil, nil)
+}
+
+// send posts a message from sender into their direct conversation with recipient.
+func (suite *ChatMessageTestSuite) send(sender, recipient *models.User, content string) *models.Message {
+	message, err := suite.service.SendMessage(sender.ID.String(), dto.SendMessageDto{RecipientID: recipient.ID.String(), Content: content})
+	suite.Require().NoError(err)
+	return message
+}
+
+// latest returns the newest message of the conversation as user sees it.
+func (suite *ChatMessageTestSuite) latest(user *models.User, conversationID string) dto.MessageResponse {
+	response, err := suite.service.GetMessages(user.ID.String(), conversationID, dto.ChatQueryParams{Limit: 1})
+	suite.Require().NoError(err)
+	suite.Require().Len(response.Data, 1)
+	return response.Data[0]
+}
+
+func (suite *ChatMessageTestSuite) TestEditsKeepTheirHistoryWithinTheWindow() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	message := suite.send(user, other, "see you at 5")
+
+	_, err := suite.service.EditMessage(other.ID.String(), message.ID.String(), dto.EditMessageDto{Content: "hijacked"})
+	suite.ErrorIs(err, services.ErrNotMessageSender)
+
+	edited, err := suite.service.EditMessage(user.ID.String(), message.ID.String(), dto.EditMessageDto{Content: "see you at 6"})
+	suite.Require().NoError(err)
+	suite.Equal("see you at 6", edited.Content)
+	suite.NotNil(edited.EditedAt)
+
+	// Either participant can see what the message said before
+	edits, err := suite.service.GetMessageEdits(other.ID.String(), message.ID.String())
+	suite.Require().NoError(err)
+	suite.Require().Len(edits, 1)
+	suite.Equal("see you at 5", edits[0].Content)
+
+	suite.Require().NoError(suite.db.Model(message).Update("created_at", time.Now().Add(-16*time.Minute)).Error)
+	_, err = suite.service.EditMessage(user.ID.String(), message.ID.String(), dto.EditMessageDto{Content: "see you at 7"})
+	suite.ErrorIs(err, services.ErrEditWindowExpired)
+}
+
+func (suite *ChatMessageTestSuite) TestDeleteForEveryoneRetractsTheMessage() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	message := suite.send(user, other, "wrong chat, sorry")
+	conversationID := message.ConversationID.String()
+	_, err := suite.service.EditMessage(user.ID.String(), message.ID.String(), dto.EditMessageDto{Content: "wrong chat"})
+	suite.Require().NoError(err)
+	_, err = suite.service.AddReaction(other.ID.String(), message.ID.String(), dto.ReactionDto{Emoji: "👍"})
+	suite.Require().NoError(err)
+
+	suite.ErrorIs(suite.service.DeleteMessage(other.ID.String(), message.ID.String(), true), services.ErrNotMessageSender)
+	suite.Require().NoError(suite.service.DeleteMessage(user.ID.String(), message.ID.String(), true))
+
+	// The row stays so replies resolve, but nothing of what it said does
+	retracted := suite.latest(other, conversationID)
+	suite.Equal(message.ID.String(), retracted.ID)
+	suite.True(retracted.IsDeleted)
+	suite.Empty(retracted.Content)
+	suite.Empty(retracted.Reactions)
+	var edits int64
+	suite.Require().NoError(suite.db.Model(&models.MessageEdit{}).Where("message_id = ?", message.ID).Count(&edits).Error)
+	suite.Zero(edits)
+
+	_, err = suite.service.EditMessage(user.ID.String(), message.ID.String(), dto.EditMessageDto{Content: "back again"})
+	suite.ErrorIs(err, services.ErrMessageDeleted)
+	_, err = suite.service.AddReaction(other.ID.String(), message.ID.String(), dto.ReactionDto{Emoji: "👍"})
+	suite.ErrorIs(err, services.ErrMessageDeleted)
+}
+
+func (suite *ChatMessageTestSuite) TestDeleteForMeOnlyHidesItFromMe() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	first := suite.send(user, other, "first")
+	second := suite.send(user, other, "second")
+	conversationID := first.ConversationID.String()
+
+	// Anyone may hide a message for themselves, not just its sender
+	suite.Require().NoError(suite.service.DeleteMessage(other.ID.String(), second.ID.String(), false))
+
+	suite.Equal(first.ID.String(), suite.latest(other, conversationID).ID)
+	kept := suite.latest(user, conversationID)
+	suite.Equal(second.ID.String(), kept.ID)
+	suite.False(kept.IsDeleted)
+}
+
+func TestChatMessageTestSuite(t *testing.T) {
+	suite.Run(t, new(ChatMessageTestSuite))
+}
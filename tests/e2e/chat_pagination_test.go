@@ -0,0 +1,156 @@
+package e2e
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ChatPaginationTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.ChatService
+}
+
+func (suite *ChatPaginationTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewChatService(suite.db, nil, nil)
+}
+
+// seed writes count messages newest last. Every three share a timestamp, so pages have to break ties on
+// the ID to neither skip nor repeat a message. It returns the IDs newest first, as the API lists them.
+func (suite *ChatPaginationTestSuite) seed(conversation *models.Conversation, sender *models.User, count int) []string {
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	messages := make([]models.Message, 0, count)
+	for i := 0; i < count; i++ {
+		messages = append(messages, models.Message{
+			ConversationID: conversation.ID,
+			SenderID:       sender.ID,
+			Content:        fmt.Sprintf("message %d", i),
+			CreatedAt:      start.Add(time.Duration(i/3) * time.Second),
+		})
+	}
+	suite.Require().NoError(suite.db.Create(&messages).Error)
+
+	// Within a timestamp the API orders by ID, which is random, so the expected order is read back
+	var ids []string
+	suite.Require().NoError(suite.db.Model(&models.Message{}).
+		Where("conversation_id = ?", conversation.ID).
+		Order("created_at DESC, id DESC").
+		Pluck("id", &ids).Error)
+	return ids
+}
+
+func (suite *ChatPaginationTestSuite) setup(count int) (*models.User, *models.Conversation, []string) {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	conversation := utils.CreateDirectConversation(suite.T(), suite.db, user, other)
+	return user, conversation, suite.seed(conversation, other, count)
+}
+
+func (suite *ChatPaginationTestSuite) page(user *models.User, conversation *models.Conversation, params dto.ChatQueryParams) *dto.MessageListResponse {
+	response, err := suite.service.GetMessages(user.ID.String(), conversation.ID.String(), params)
+	suite.Require().NoError(err)
+	return response
+}
+
+func messageIDs(response *dto.MessageListResponse) []string {
+	list := make([]string, 0, len(response.Data))
+	for _, message := range response.Data {
+		list = append(list, message.ID)
+	}
+	return list
+}
+
+func (suite *ChatPaginationTestSuite) TestOlderCursorsWalkEveryMessageOnce() {
+	user, conversation, expected := suite.setup(23)
+
+	response := suite.page(user, conversation, dto.ChatQueryParams{Limit: 5})
+	seen := messageIDs(response)
+	for response.HasOlder {
+		suite.Require().NotEmpty(response.OlderCursor)
+		response = suite.page(user, conversation, dto.ChatQueryParams{Limit: 5, Before: response.OlderCursor})
+		suite.True(response.HasNewer)
+		seen = append(seen, messageIDs(response)...)
+	}
+
+	suite.Equal(expected, seen)
+	suite.Empty(response.OlderCursor)
+}
+
+func (suite *ChatPaginationTestSuite) TestNewerCursorsWalkBackToTheLatest() {
+	user, conversation, expected := suite.setup(17)
+	oldest := expected[len(expected)-1]
+
+	var seen []string
+	response := suite.page(user, conversation, dto.ChatQueryParams{Limit: 4, After: oldest})
+	for {
+		seen = append(messageIDs(response), seen...)
+		if !response.HasNewer {
+			break
+		}
+		response = suite.page(user, conversation, dto.ChatQueryParams{Limit: 4, After: response.NewerCursor})
+	}
+
+	suite.Equal(expected[:len(expected)-1], seen)
+}
+
+func (suite *ChatPaginationTestSuite) TestNewMessagesDoNotShiftOlderPages() {
+	user, conversation, expected := suite.setup(12)
+
+	first := suite.page(user, conversation, dto.ChatQueryParams{Limit: 6})
+	suite.Require().NoError(suite.db.Create(&models.Message{
+		ConversationID: conversation.ID,
+		SenderID:       user.ID,
+		Content:        "arrived while scrolling",
+	}).Error)
+	second := suite.page(user, conversation, dto.ChatQueryParams{Limit: 6, Before: first.OlderCursor})
+
+	suite.Equal(expected[6:], messageIDs(second))
+	suite.False(second.HasOlder)
+}
+
+func (suite *ChatPaginationTestSuite) TestAroundCentresOnTheMessage() {
+	user, conversation, expected := suite.setup(20)
+	pivot := expected[10]
+
+	response := suite.page(user, conversation, dto.ChatQueryParams{Limit: 6, Around: pivot})
+
+	suite.Equal(expected[7:13], messageIDs(response))
+	suite.True(response.HasOlder)
+	suite.True(response.HasNewer)
+}
+
+func (suite *ChatPaginationTestSuite) TestMessagesDeletedForMeAreSkippedAndRejectedAsCursors() {
+	user, conversation, expected := suite.setup(9)
+	deleted := expected[3]
+	suite.Require().NoError(suite.db.Create(&models.MessageDeletion{MessageID: uuid.MustParse(deleted), UserID: user.ID}).Error)
+
+	response := suite.page(user, conversation, dto.ChatQueryParams{Limit: 20})
+	suite.NotContains(messageIDs(response), deleted)
+	suite.Equal(len(expected)-1, response.TotalItems)
+
+	_, err := suite.service.GetMessages(user.ID.String(), conversation.ID.String(), dto.ChatQueryParams{Before: deleted})
+	suite.ErrorIs(err, services.ErrMessageNotFound)
+}
+
+func (suite *ChatPaginationTestSuite) TestCursorsFromAnotherConversationAreRejected() {
+	user, conversation, _ := suite.setup(3)
+	_, _, foreign := suite.setup(3)
+
+	_, err := suite.service.GetMessages(user.ID.String(), conversation.ID.String(), dto.ChatQueryParams{Before: foreign[0]})
+	suite.ErrorIs(err, services.ErrMessageNotFound)
+}
+
+func TestChatPaginationTestSuite(t *testing.T) {
+	suite.Run(t, new(ChatPaginationTestSuite))
+}
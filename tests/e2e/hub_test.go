@@ -0,0 +1,195 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowBroker stands in for a broker whose round-trips are slow, e.g. an unreachable Redis.
+type slowBroker struct {
+	*lib.MemoryBroker
+	delay time.Duration
+}
+
+func (b *slowBroker) Register(nodeID, userID string) error {
+	time.Sleep(b.delay)
+	return b.MemoryBroker.Register(nodeID, userID)
+}
+
+func (b *slowBroker) Unregister(nodeID, userID string) error {
+	time.Sleep(b.delay)
+	return b.MemoryBroker.Unregister(nodeID, userID)
+}
+
+// testSocketAuthenticator accepts tokens of the form "<user id>" or "<user id>:<session id>".
+func testSocketAuthenticator(token string) (string, string, error) {
+	userID, sessionID, _ := strings.Cut(token, ":")
+	return userID, sessionID, nil
+}
+
+func startHubServer(t *testing.T, hub *lib.Hub) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws", lib.NewWebSocketHandler(hub).HandleWebSocket)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dialHub(t *testing.T, server *httptest.Server, token string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?token=" + token
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readEnvelope(t *testing.T, conn *websocket.Conn, timeout time.Duration) lib.SocketEnvelope {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(timeout)))
+	var envelope lib.SocketEnvelope
+	require.NoError(t, conn.ReadJSON(&envelope))
+	return envelope
+}
+
+func TestHubDeliversWhileBrokerIsSlow(t *testing.T) {
+	broker := &slowBroker{MemoryBroker: lib.NewMemoryBroker(), delay: 2 * time.Second}
+	hub := lib.NewHub(broker)
+	hub.SetAuthenticator(testSocketAuthenticator)
+	go hub.Run()
+
+	server := startHubServer(t, hub)
+
+	first := dialHub(t, server, uuid.NewString())
+	assert.Equal(t, lib.SocketTypeAuthenticated, readEnvelope(t, first, time.Second).Type)
+
+	// The first registration is still stuck in the broker; the hub must keep serving others.
+	userID := uuid.NewString()
+	second := dialHub(t, server, userID)
+	assert.Equal(t, lib.SocketTypeAuthenticated, readEnvelope(t, second, time.Second).Type)
+
+	hub.SendToUser(userID, models.Notification{ID: uuid.New(), Title: "Hello"})
+	envelope := readEnvelope(t, second, time.Second)
+	assert.Equal(t, lib.SocketTypeNotification, envelope.Type)
+}
+
+// recordingChatHandler notes the order actions reach it and how many ever ran at once.
+type recordingChatHandler struct {
+	delay   time.Duration
+	running atomic.Int32
+	peak    atomic.Int32
+	mu      sync.Mutex
+	seen    []string
+}
+
+func (h *recordingChatHandler) HandleWebSocketMessage(senderID string, message lib.SocketEnvelope) (interface{}, error) {
+	running := h.running.Add(1)
+	defer h.running.Add(-1)
+	for {
+		peak := h.peak.Load()
+		if running <= peak || h.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+
+	time.Sleep(h.delay)
+	h.mu.Lock()
+	h.seen = append(h.seen, message.ID)
+	h.mu.Unlock()
+	return map[string]string{"id": message.ID}, nil
+}
+
+func TestHubRunsChatActionsInOrderPerClient(t *testing.T) {
+	handler := &recordingChatHandler{delay: 5 * time.Millisecond}
+	hub := lib.NewHub(lib.NewMemoryBroker())
+	hub.SetAuthenticator(testSocketAuthenticator)
+	hub.SetChatMessageHandler(handler)
+	go hub.Run()
+
+	conn := dialHub(t, startHubServer(t, hub), uuid.NewString())
+	assert.Equal(t, lib.SocketTypeAuthenticated, readEnvelope(t, conn, time.Second).Type)
+
+	var sent []string
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("m%d", i)
+		sent = append(sent, id)
+		require.NoError(t, conn.WriteJSON(lib.NewSocketEnvelope(lib.SocketTypeSendMessage, id, nil)))
+	}
+
+	var received []string
+	for range sent {
+		envelope := readEnvelope(t, conn, time.Second)
+		assert.Equal(t, lib.SocketTypeSendMessage+"_response", envelope.Type)
+		assert.Nil(t, envelope.Error)
+		received = append(received, envelope.ID)
+	}
+
+	assert.Equal(t, sent, received)
+	handler.mu.Lock()
+	assert.Equal(t, sent, handler.seen)
+	handler.mu.Unlock()
+	assert.Equal(t, int32(1), handler.peak.Load())
+}
+
+func TestHubRejectsChatActionsBeyondTheQueue(t *testing.T) {
+	handler := &recordingChatHandler{delay: 200 * time.Millisecond}
+	hub := lib.NewHub(lib.NewMemoryBroker())
+	hub.SetAuthenticator(testSocketAuthenticator)
+	hub.SetChatMessageHandler(handler)
+	go hub.Run()
+
+	conn := dialHub(t, startHubServer(t, hub), uuid.NewString())
+	assert.Equal(t, lib.SocketTypeAuthenticated, readEnvelope(t, conn, time.Second).Type)
+
+	// A burst the rate limiter allows, but longer than the queue holds while the first action runs.
+	for i := 0; i < 20; i++ {
+		require.NoError(t, conn.WriteJSON(lib.NewSocketEnvelope(lib.SocketTypeTyping, fmt.Sprintf("t%d", i), nil)))
+	}
+
+	envelope := readEnvelope(t, conn, time.Second)
+	require.NotNil(t, envelope.Error)
+	assert.Equal(t, lib.SocketErrRateLimited, envelope.Error.Code)
+	assert.Contains(t, envelope.Error.Message, "pending actions")
+}
+
+func TestHubDropsRevokedSessions(t *testing.T) {
+	hub := lib.NewHub(lib.NewMemoryBroker())
+	hub.SetAuthenticator(testSocketAuthenticator)
+	go hub.Run()
+
+	server := startHubServer(t, hub)
+	userID, revokedSession, keptSession := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	revoked := dialHub(t, server, userID+":"+revokedSession)
+	assert.Equal(t, lib.SocketTypeAuthenticated, readEnvelope(t, revoked, time.Second).Type)
+	kept := dialHub(t, server, userID+":"+keptSession)
+	assert.Equal(t, lib.SocketTypeAuthenticated, readEnvelope(t, kept, time.Second).Type)
+
+	hub.RevokeSessions(userID, []string{revokedSession})
+
+	envelope := readEnvelope(t, revoked, time.Second)
+	require.NotNil(t, envelope.Error)
+	assert.Equal(t, lib.SocketErrUnauthorized, envelope.Error.Code)
+	require.NoError(t, revoked.SetReadDeadline(time.Now().Add(time.Second)))
+	_, _, err := revoked.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived), "expected the socket to close, got %v", err)
+
+	hub.SendToUser(userID, models.Notification{ID: uuid.New(), Title: "Still here"})
+	assert.Equal(t, lib.SocketTypeNotification, readEnvelope(t, kept, time.Second).Type)
+}
@@ -0,0 +1,184 @@
+package e2e
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"foglio/v2/src/lib"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
+	"foglio/v2/src/routes"
+	"foglio/v2/tests/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+func TestIdentityDocumentDetailsAreHiddenFromUserJSON(t *testing.T) {
+	number, document := "A1234567", "https://example.com/passport.jpg"
+	documentType := models.InternationalPassport
+	user := &models.User{VerificationNumber: &number, VerificationType: &documentType, VerificationDocument: &document}
+
+	public := utils.MarshalToMap(t, user)
+	assert.NotContains(t, public, "verification_number")
+	assert.NotContains(t, public, "verification_type")
+	assert.NotContains(t, public, "verification_document")
+
+	// Moderators read them from the submission on the verification endpoints
+	submission := utils.MarshalToMap(t, models.IdentityVerification{Type: documentType, Number: number, User: user})
+	assert.Equal(t, number, submission["number"])
+	assert.Equal(t, string(documentType), submission["type"])
+	assert.NotContains(t, submission["user"], "verification_number")
+}
+
+type IdentityVerificationTestSuite struct {
+	suite.Suite
+	db        *gorm.DB
+	router    *gin.Engine
+	moderator string
+	document  []byte
+}
+
+func (suite *IdentityVerificationTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+
+	moderator := utils.CreateTestUser(suite.T(), suite.db, "")
+	utils.GrantSuperAdmin(suite.T(), suite.db, moderator)
+	suite.moderator = utils.SignIn(suite.T(), suite.db, moderator)
+
+	var scan bytes.Buffer
+	suite.Require().NoError(png.Encode(&scan, image.NewRGBA(image.Rect(0, 0, 8, 8))))
+	suite.document = scan.Bytes()
+
+	hub := lib.NewHub(nil)
+	suite.router = gin.New()
+	suite.router.Use(middlewares.ErrorHandlerMiddleware(), middlewares.AuthMiddleware())
+	router := suite.router.Group("/api/v2")
+	routes.SelfRoutes(router, hub)
+	routes.AdminRoutes(router, hub)
+}
+
+func passportNumber() string {
+	return "P" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:8])
+}
+
+// submit uploads a passport scan for review as the signed-in user.
+func (suite *IdentityVerificationTestSuite) submit(token, number, fileName string, document []byte) *httptest.ResponseRecorder {
+	return utils.MakeMultipartRequest(suite.router, "/api/v2/me/verification", token,
+		map[string]string{"type": string(models.InternationalPassport), "number": number}, "document", fileName, document)
+}
+
+// refused checks that a submission was turned away with the given error code.
+func (suite *IdentityVerificationTestSuite) refused(w *httptest.ResponseRecorder, code string) {
+	response := utils.AssertJSONResponse(suite.T(), w, http.StatusBadRequest)
+	suite.Equal(code, response["code"])
+}
+
+func (suite *IdentityVerificationTestSuite) reload(user *models.User) *models.User {
+	var reloaded models.User
+	suite.Require().NoError(suite.db.First(&reloaded, "id = ?", user.ID).Error)
+	return &reloaded
+}
+
+func (suite *IdentityVerificationTestSuite) TestApprovedDocumentVerifiesTheUser() {
+	utils.UseFakeStorage(suite.T(), suite.document)
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	token := utils.SignIn(suite.T(), suite.db, user)
+	number := passportNumber()
+
+	// Numbers are stored the way they are printed, whatever spacing and case they were typed in
+	w := suite.submit(token, strings.ToLower(number[:4])+" "+number[4:], "passport.png", suite.document)
+	utils.AssertJSONResponse(suite.T(), w, http.StatusCreated)
+	suite.False(suite.reload(user).IdentityVerified)
+
+	w = utils.MakeAuthenticatedRequest(suite.router, "GET", "/api/v2/admin/users/verifications", suite.moderator, nil)
+	suite.Equal(http.StatusOK, w.Code)
+
+	w = utils.MakeAuthenticatedRequest(suite.router, "GET", "/api/v2/admin/users/"+user.ID.String()+"/verification/document", suite.moderator, nil)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal(suite.document, w.Body.Bytes())
+
+	w = utils.MakeAuthenticatedRequest(suite.router, "PUT", "/api/v2/admin/users/"+user.ID.String()+"/verification/approve", suite.moderator, nil)
+	suite.Equal(http.StatusOK, w.Code)
+
+	reloaded := suite.reload(user)
+	suite.True(reloaded.IdentityVerified)
+	suite.Equal(models.VerificationApproved, *reloaded.VerificationStatus)
+	suite.Require().NotNil(reloaded.VerificationNumber)
+	suite.Equal(number, *reloaded.VerificationNumber)
+
+	// The badge is granted once; a second submission or approval is refused
+	suite.refused(suite.submit(token, passportNumber(), "passport.png", suite.document), "IDENTITY_ALREADY_VERIFIED")
+	w = utils.MakeAuthenticatedRequest(suite.router, "PUT", "/api/v2/admin/users/"+user.ID.String()+"/verification/approve", suite.moderator, nil)
+	suite.refused(w, "NO_PENDING_VERIFICATION")
+}
+
+func (suite *IdentityVerificationTestSuite) TestRejectedDocumentLeavesTheUserUnverified() {
+	utils.UseFakeStorage(suite.T(), suite.document)
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	token := utils.SignIn(suite.T(), suite.db, user)
+	utils.AssertJSONResponse(suite.T(), suite.submit(token, passportNumber(), "passport.png", suite.document), http.StatusCreated)
+
+	w := utils.MakeAuthenticatedRequest(suite.router, "PUT", "/api/v2/admin/users/"+user.ID.String()+"/verification/reject", suite.moderator,
+		map[string]string{"reason": "The scan is unreadable"})
+	suite.Equal(http.StatusOK, w.Code)
+
+	reloaded := suite.reload(user)
+	suite.False(reloaded.IdentityVerified)
+	suite.Nil(reloaded.VerificationNumber)
+	suite.Equal(models.VerificationRejected, *reloaded.VerificationStatus)
+
+	w = utils.MakeAuthenticatedRequest(suite.router, "GET", "/api/v2/me/verification", token, nil)
+	response := utils.AssertJSONResponse(suite.T(), w, http.StatusOK)
+	suite.Equal("The scan is unreadable", response["data"].(map[string]interface{})["review_note"])
+
+	// A rejected user may try again
+	utils.AssertJSONResponse(suite.T(), suite.submit(token, passportNumber(), "passport.png", suite.document), http.StatusCreated)
+}
+
+func (suite *IdentityVerificationTestSuite) TestInvalidSubmissionsAreRefused() {
+	utils.UseFakeStorage(suite.T(), suite.document)
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	token := utils.SignIn(suite.T(), suite.db, user)
+
+	suite.refused(suite.submit(token, "12", "passport.png", suite.document), "INVALID_VERIFICATION_NUMBER")
+	suite.refused(suite.submit(token, passportNumber(), "passport.png", []byte("<html></html>")), "UNSUPPORTED_DOCUMENT")
+	suite.refused(suite.submit(token, passportNumber(), "passport.svg", suite.document), "UNSUPPORTED_DOCUMENT")
+
+	utils.AssertJSONResponse(suite.T(), suite.submit(token, passportNumber(), "passport.png", suite.document), http.StatusCreated)
+	suite.refused(suite.submit(token, passportNumber(), "passport.png", suite.document), "VERIFICATION_PENDING")
+}
+
+func (suite *IdentityVerificationTestSuite) TestOnlyModeratorsReviewSubmissions() {
+	utils.UseFakeStorage(suite.T(), suite.document)
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	utils.AssertJSONResponse(suite.T(), suite.submit(utils.SignIn(suite.T(), suite.db, user), passportNumber(), "passport.png", suite.document), http.StatusCreated)
+	member := utils.SignIn(suite.T(), suite.db, utils.CreateTestUser(suite.T(), suite.db, ""))
+
+	requests := []struct{ method, path string }{
+		{"GET", "/api/v2/admin/users/verifications"},
+		{"GET", "/api/v2/admin/users/" + user.ID.String() + "/verification/document"},
+		{"PUT", "/api/v2/admin/users/" + user.ID.String() + "/verification/approve"},
+		{"PUT", "/api/v2/admin/users/" + user.ID.String() + "/verification/reject"},
+	}
+	for _, request := range requests {
+		w := utils.MakeAuthenticatedRequest(suite.router, request.method, request.path, member, map[string]string{"reason": "Looks fake"})
+		suite.Equal(http.StatusForbidden, w.Code, request.path)
+	}
+
+	reloaded := suite.reload(user)
+	suite.False(reloaded.IdentityVerified)
+	suite.Equal(models.VerificationPending, *reloaded.VerificationStatus)
+}
+
+func TestIdentityVerificationTestSuite(t *testing.T) {
+	suite.Run(t, new(IdentityVerificationTestSuite))
+}
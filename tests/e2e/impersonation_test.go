@@ -0,0 +1,83 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
+	"foglio/v2/src/routes"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ImpersonationTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	token  string
+}
+
+func (suite *ImpersonationTestSuite) SetupSuite() {
+	db := utils.RequireDatabase(suite.T())
+
+	admin := utils.CreateTestUser(suite.T(), db, "")
+	utils.GrantSuperAdmin(suite.T(), db, admin)
+	user := utils.CreateTestUser(suite.T(), db, "")
+
+	impersonation, err := services.NewUserAdminService(db).
+		Impersonate(admin.ID.String(), "", user.ID.String(), dto.ImpersonateUserDto{Reason: "Support ticket"})
+	suite.Require().NoError(err)
+	suite.token = impersonation.Token
+
+	suite.router = gin.New()
+	suite.router.Use(middlewares.ErrorHandlerMiddleware(), middlewares.AuthMiddleware())
+	router := suite.router.Group("/api/v2")
+	routes.AuthRoutes(router)
+	routes.SelfRoutes(router, lib.NewHub(nil))
+}
+
+func (suite *ImpersonationTestSuite) TestReadsAreAllowed() {
+	w := utils.MakeAuthenticatedRequest(suite.router, "GET", "/api/v2/me", suite.token, nil)
+	suite.Equal(http.StatusOK, w.Code)
+}
+
+func (suite *ImpersonationTestSuite) TestAccountChangesAreRefused() {
+	requests := []struct{ method, path string }{
+		{"POST", "/api/v2/auth/update-password"},
+		{"POST", "/api/v2/auth/email"},
+		{"POST", "/api/v2/auth/2fa/setup"},
+		{"POST", "/api/v2/auth/passkeys/register/begin"},
+		{"DELETE", "/api/v2/auth/sessions/00000000-0000-0000-0000-000000000000"},
+		{"POST", "/api/v2/me/exports"},
+		{"POST", "/api/v2/me/deletion"},
+		{"DELETE", "/api/v2/me/deletion"},
+	}
+	for _, request := range requests {
+		w := utils.MakeAuthenticatedRequest(suite.router, request.method, request.path, suite.token, map[string]string{})
+		suite.Equal(http.StatusForbidden, w.Code, "%s %s", request.method, request.path)
+	}
+}
+
+func TestImpersonationTestSuite(t *testing.T) {
+	suite.Run(t, new(ImpersonationTestSuite))
+}
+
+func TestModerationNotesAreHiddenFromUserJSON(t *testing.T) {
+	reason, note := "Spam", "Blurry photo"
+	user := &models.User{Name: "Ada", SuspensionReason: &reason, VerificationNote: &note}
+
+	public := utils.MarshalToMap(t, user)
+	assert.NotContains(t, public, "suspension_reason")
+	assert.NotContains(t, public, "verification_note")
+
+	moderated := utils.MarshalToMap(t, dto.NewModeratedUserResponse(user))
+	assert.Equal(t, reason, moderated["suspension_reason"])
+	assert.Equal(t, note, moderated["verification_note"])
+	assert.Equal(t, "Ada", moderated["name"])
+}
@@ -0,0 +1,15 @@
+package e2e
+
+import (
+	"os"
+	"testing"
+
+	"foglio/v2/src/database"
+)
+
+// TestMain closes the shared database connection once every suite in the package has run.
+func TestMain(m *testing.M) {
+	code := m.Run()
+	_ = database.CloseDatabase()
+	os.Exit(code)
+}
@@ -0,0 +1,160 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"foglio/v2/src/config"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+const testIdentityProvider = "testidp"
+
+// fakeIssuer is an OpenID Connect provider that signs anyone in as the account it is told to.
+type fakeIssuer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	account map[string]interface{}
+}
+
+func newFakeIssuer() *fakeIssuer {
+	issuer := &fakeIssuer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeIssuerJSON(w, map[string]string{
+			"issuer":                 issuer.URL,
+			"authorization_endpoint": issuer.URL + "/authorize",
+			"token_endpoint":         issuer.URL + "/token",
+			"userinfo_endpoint":      issuer.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		writeIssuerJSON(w, map[string]string{"access_token": "access-token", "token_type": "Bearer"})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		defer issuer.mu.Unlock()
+		writeIssuerJSON(w, issuer.account)
+	})
+	issuer.Server = httptest.NewServer(mux)
+	return issuer
+}
+
+func (i *fakeIssuer) signInAs(account map[string]interface{}) {
+	i.mu.Lock()
+	i.account = account
+	i.mu.Unlock()
+}
+
+func writeIssuerJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+type OAuthTestSuite struct {
+	suite.Suite
+	db        *gorm.DB
+	issuer    *fakeIssuer
+	service   *services.AuthService
+	providers []config.OIDCProvider
+}
+
+func (suite *OAuthTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewAuthService(suite.db)
+
+	suite.issuer = newFakeIssuer()
+	suite.providers = config.AppConfig.OIDCProviders
+	config.AppConfig.OIDCProviders = append([]config.OIDCProvider{{
+		Name:        testIdentityProvider,
+		DisplayName: "Test IdP",
+		Issuer:      suite.issuer.URL,
+		ClientId:    "foglio",
+		RedirectUrl: "https://foglio.test/auth/callback",
+		Scopes:      []string{"openid", "email"},
+	}}, suite.providers...)
+}
+
+func (suite *OAuthTestSuite) TearDownSuite() {
+	config.AppConfig.OIDCProviders = suite.providers
+	suite.issuer.Close()
+}
+
+// signIn goes through the provider's sign-in flow as the account with the given email.
+func (suite *OAuthTestSuite) signIn(subject, email string, verified bool) (*services.SigninResponse, error) {
+	suite.issuer.signInAs(map[string]interface{}{"sub": subject, "email": email, "email_verified": verified, "name": "Ada Lovelace"})
+
+	_, state, err := suite.service.GetOAuthURL(testIdentityProvider)
+	suite.Require().NoError(err)
+	return suite.service.HandleOAuthCallback(testIdentityProvider, dto.OAuthCallbackDto{Code: "code", State: state}, state,
+		dto.SessionClient{UserAgent: "test", IPAddress: "203.0.113.1"})
+}
+
+func (suite *OAuthTestSuite) linkedIdentities(user *models.User) int64 {
+	var count int64
+	suite.Require().NoError(suite.db.Model(&models.UserIdentity{}).Where("user_id = ?", user.ID).Count(&count).Error)
+	return count
+}
+
+func (suite *OAuthTestSuite) TestUnverifiedEmailCannotTakeOverAnAccount() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+
+	_, err := suite.signIn(uuid.NewString(), user.Email, false)
+	suite.ErrorIs(err, services.ErrOAuthEmailInUse)
+	suite.Zero(suite.linkedIdentities(user))
+}
+
+func (suite *OAuthTestSuite) TestVerifiedEmailLinksTheExistingAccount() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	subject := uuid.NewString()
+
+	response, err := suite.signIn(subject, user.Email, true)
+	suite.Require().NoError(err)
+	suite.Equal(user.ID, response.User.ID)
+	suite.NotEmpty(response.Token)
+	suite.Equal(int64(1), suite.linkedIdentities(user))
+
+	// The linked identity signs in even once the provider stops vouching for the email
+	response, err = suite.signIn(subject, user.Email, false)
+	suite.Require().NoError(err)
+	suite.Equal(user.ID, response.User.ID)
+}
+
+func (suite *OAuthTestSuite) TestNewAccountsKeepTheProvidersVerification() {
+	email := "oauth_" + uuid.NewString()[:8] + "@example.com"
+
+	response, err := suite.signIn(uuid.NewString(), email, false)
+	suite.Require().NoError(err)
+	suite.Equal(email, response.User.Email)
+	suite.False(response.User.Verified)
+	suite.Equal(int64(1), suite.linkedIdentities(&response.User))
+}
+
+func (suite *OAuthTestSuite) TestStateIsBoundToTheBrowserAndUsedOnce() {
+	suite.issuer.signInAs(map[string]interface{}{"sub": uuid.NewString(), "email": "oauth_" + uuid.NewString()[:8] + "@example.com", "email_verified": true})
+	client := dto.SessionClient{UserAgent: "test", IPAddress: "203.0.113.1"}
+
+	_, state, err := suite.service.GetOAuthURL(testIdentityProvider)
+	suite.Require().NoError(err)
+	_, err = suite.service.HandleOAuthCallback(testIdentityProvider, dto.OAuthCallbackDto{Code: "code", State: state}, "another-browser", client)
+	suite.ErrorIs(err, services.ErrInvalidOAuthState)
+
+	_, err = suite.service.HandleOAuthCallback(testIdentityProvider, dto.OAuthCallbackDto{Code: "code", State: state}, state, client)
+	suite.Require().NoError(err)
+	_, err = suite.service.HandleOAuthCallback(testIdentityProvider, dto.OAuthCallbackDto{Code: "code", State: state}, state, client)
+	suite.ErrorIs(err, services.ErrInvalidOAuthState)
+}
+
+func TestOAuthTestSuite(t *testing.T) {
+	suite.Run(t, new(OAuthTestSuite))
+}
@@ -0,0 +1,146 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type OutreachTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	chat    *services.ChatService
+	service *services.OutreachService
+}
+
+func (suite *OutreachTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.chat = services.NewChatService(suite.db, nil, nil)
+	suite.service = services.NewOutreachService(suite.db, suite.chat, services.NewJobService(suite.db, nil))
+}
+
+// createRecruiter returns a recruiter on the free plan.
+func (suite *OutreachTestSuite) createRecruiter() *models.User {
+	recruiter := utils.CreateTestUser(suite.T(), suite.db, "")
+	suite.Require().NoError(suite.db.Model(recruiter).Update("is_recruiter", true).Error)
+	return recruiter
+}
+
+// postJob creates a job by the recruiter with an application from each applicant.
+func (suite *OutreachTestSuite) postJob(recruiter *models.User, applicants ...*models.User) *models.Job {
+	company := &models.Company{Name: "Foglio Labs"}
+	suite.Require().NoError(suite.db.Create(company).Error)
+	job := &models.Job{Title: "Backend Engineer", CompanyId: company.ID, Location: "Lagos", Description: "Build APIs",
+		PostedDate: time.Now(), EmploymentType: models.FullTime, CreatedBy: recruiter.ID}
+	suite.Require().NoError(suite.db.Create(job).Error)
+
+	for _, applicant := range applicants {
+		suite.Require().NoError(suite.db.Create(&models.JobApplication{JobID: job.ID, ApplicantID: applicant.ID,
+			Status: models.Pending, SubmissionDate: time.Now(), LastUpdated: time.Now()}).Error)
+	}
+	return job
+}
+
+func (suite *OutreachTestSuite) applicant(name string) *models.User {
+	applicant := utils.CreateTestUser(suite.T(), suite.db, "")
+	suite.Require().NoError(suite.db.Model(applicant).Update("name", name).Error)
+	return applicant
+}
+
+func (suite *OutreachTestSuite) sentContent(result dto.BulkSendResult) string {
+	suite.Require().NotNil(result.MessageID)
+	var message models.Message
+	suite.Require().NoError(suite.db.First(&message, "id = ?", *result.MessageID).Error)
+	return message.Content
+}
+
+func (suite *OutreachTestSuite) TestTemplatesArePersonalisedForEachApplicant() {
+	recruiter := suite.createRecruiter()
+	ada, grace := suite.applicant("Ada Lovelace"), suite.applicant("Grace Hopper")
+	job := suite.postJob(recruiter, ada, grace)
+
+	_, err := suite.service.CreateTemplate(recruiter.ID.String(), dto.CreateMessageTemplateDto{Name: "Broken", Content: "Hi {{.Salary}}"})
+	suite.ErrorIs(err, services.ErrInvalidTemplate)
+	template, err := suite.service.CreateTemplate(recruiter.ID.String(), dto.CreateMessageTemplateDto{
+		Name: "Invite", Content: "Hi {{.CandidateFirstName}}, thanks for applying to {{.JobTitle}} at {{.CompanyName}}.",
+	})
+	suite.Require().NoError(err)
+
+	response, err := suite.service.BulkSend(recruiter.ID.String(), job.ID.String(), dto.BulkSendDto{TemplateID: template.ID.String()})
+	suite.Require().NoError(err)
+	suite.Equal(2, response.Sent)
+	suite.Equal(2, response.Quota.Used)
+
+	contents := map[string]string{}
+	for _, result := range response.Results {
+		contents[result.RecipientID] = suite.sentContent(result)
+	}
+	suite.Equal(map[string]string{
+		ada.ID.String():   "Hi Ada, thanks for applying to Backend Engineer at Foglio Labs.",
+		grace.ID.String(): "Hi Grace, thanks for applying to Backend Engineer at Foglio Labs.",
+	}, contents)
+}
+
+func (suite *OutreachTestSuite) TestSendsStopAtTheDailyLimit() {
+	recruiter := suite.createRecruiter()
+	first, second := suite.applicant("First Applicant"), suite.applicant("Second Applicant")
+	job := suite.postJob(recruiter, first, second)
+
+	// Use up all but one of the free plan's sends for today
+	var application models.JobApplication
+	suite.Require().NoError(suite.db.Where("job_id = ?", job.ID).First(&application).Error)
+	for i := 0; i < recruiter.DailyOutreachLimit()-1; i++ {
+		suite.Require().NoError(suite.db.Create(&models.OutreachMessage{SenderID: recruiter.ID, JobID: job.ID, ApplicationID: application.ID,
+			RecipientID: application.ApplicantID, Status: models.OutreachStatusSent}).Error)
+	}
+
+	response, err := suite.service.BulkSend(recruiter.ID.String(), job.ID.String(), dto.BulkSendDto{Content: "Hello {{.CandidateFirstName}}"})
+	suite.Require().NoError(err)
+	suite.Equal(1, response.Sent)
+	suite.Equal(1, response.Skipped)
+	suite.Zero(response.Quota.Remaining)
+
+	_, err = suite.service.BulkSend(recruiter.ID.String(), job.ID.String(), dto.BulkSendDto{Content: "Hello again"})
+	suite.ErrorIs(err, services.ErrOutreachLimitReached)
+}
+
+func (suite *OutreachTestSuite) TestFailedSendsGiveTheirSlotBack() {
+	recruiter := suite.createRecruiter()
+	applicant := suite.applicant("Blocking Applicant")
+	job := suite.postJob(recruiter, applicant)
+	_, err := suite.chat.BlockUser(applicant.ID.String(), dto.BlockUserDto{UserID: recruiter.ID.String()})
+	suite.Require().NoError(err)
+
+	response, err := suite.service.BulkSend(recruiter.ID.String(), job.ID.String(), dto.BulkSendDto{Content: "Hello {{.CandidateFirstName}}"})
+	suite.Require().NoError(err)
+	suite.Equal(1, response.Failed)
+	suite.Require().NotNil(response.Results[0].Error)
+	suite.Contains(*response.Results[0].Error, services.ErrUserBlocked.Error())
+	suite.Zero(response.Quota.Used)
+}
+
+func (suite *OutreachTestSuite) TestOnlyRecruitersSendToTheirOwnJobs() {
+	recruiter := suite.createRecruiter()
+	job := suite.postJob(recruiter, suite.applicant("Some Applicant"))
+
+	member := utils.CreateTestUser(suite.T(), suite.db, "")
+	_, err := suite.service.BulkSend(member.ID.String(), job.ID.String(), dto.BulkSendDto{Content: "Hello"})
+	suite.ErrorIs(err, services.ErrRecruiterOnly)
+
+	_, err = suite.service.BulkSend(suite.createRecruiter().ID.String(), job.ID.String(), dto.BulkSendDto{Content: "Hello"})
+	suite.Error(err)
+	var sent int64
+	suite.Require().NoError(suite.db.Model(&models.OutreachMessage{}).Where("job_id = ?", job.ID).Count(&sent).Error)
+	suite.Zero(sent)
+}
+
+func TestOutreachTestSuite(t *testing.T) {
+	suite.Run(t, new(OutreachTestSuite))
+}
@@ -0,0 +1,140 @@
+package e2e
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/routes"
+	"foglio/v2/tests/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// hostileSite fills every kind of template context with content trying to break out of it.
+func hostileSite(template string) *dto.PortfolioSite {
+	return &dto.PortfolioSite{
+		Template:  template,
+		Title:     `</title><script>alert("title")</script>`,
+		Canonical: "https://example.com/p/jane",
+		Name:      `<img src=x onerror=alert("name")>`,
+		Theme: dto.SiteTheme{
+			PrimaryColor:    `red;}</style><script>alert("theme")</script>`,
+			BackgroundColor: "url(https://attacker.example/track.png)",
+			TextColor:       "#0f172a",
+		},
+		CustomCSS: `@import url(https://attacker.example/steal.css); h1 { color: red; } </style><script>alert("css")</script>`,
+		Sections: []dto.SiteSection{
+			{Anchor: "about", Type: "about", Title: "About", Content: "First paragraph\n\n<b>Second</b> paragraph"},
+			{Anchor: "projects", Type: "projects", Title: "Projects"},
+			{Anchor: "contact", Type: "contact", Title: "Contact"},
+		},
+		Projects: []dto.SiteProject{{Title: "Exploit", URL: "javascript:alert('project')"}},
+		Links:    []dto.SiteLink{{Label: "Website", URL: "javascript:alert('link')"}, {Label: "GitHub", URL: "https://github.com/jane"}},
+		JSONLD:   map[string]interface{}{"@type": "Person", "name": `</script><script>alert("jsonld")</script>`},
+		Year:     2026,
+	}
+}
+
+func TestSiteTemplatesEscapeUserContent(t *testing.T) {
+	for _, template := range append(append([]string{}, lib.SiteTemplates...), "unknown") {
+		var page bytes.Buffer
+		require.NoError(t, lib.GetSiteRenderer().Render(&page, template, hostileSite(template)), template)
+		html := page.String()
+
+		for _, payload := range []string{"title", "name", "theme", "css", "jsonld"} {
+			assert.NotContains(t, html, `alert("`+payload+`")</script>`, template)
+		}
+		assert.NotContains(t, html, `<img src=x`, template)
+		assert.Contains(t, html, `&lt;img src=x onerror=alert(&#34;name&#34;)&gt;`, template)
+
+		// Links keep working unless they would run script
+		assert.NotContains(t, html, `href="javascript:`, template)
+		assert.Contains(t, html, `href="https://github.com/jane"`, template)
+
+		// Only plain theme values and the portfolio's own rules reach the stylesheets
+		assert.Contains(t, html, ":root { --text: #0f172a;\n", template)
+		assert.NotContains(t, html, "attacker.example", template)
+		assert.Contains(t, html, "h1 { color: red; }", template)
+
+		assert.Contains(t, html, "<p>First paragraph</p>", template)
+		assert.Contains(t, html, "<p>&lt;b&gt;Second&lt;/b&gt; paragraph</p>", template)
+	}
+}
+
+type PortfolioSiteTestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	router *gin.Engine
+}
+
+func (suite *PortfolioSiteTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.router = gin.New()
+	routes.SiteRoutes(suite.router)
+}
+
+// publish creates a portfolio for a new user in the given status and returns its slug.
+func (suite *PortfolioSiteTestSuite) publish(status models.PortfolioStatus) (*models.User, string) {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	suite.Require().NoError(suite.db.Model(user).Update("name", "Jane <Doe>").Error)
+
+	about := "Builds things & writes about them."
+	portfolio := &models.Portfolio{UserID: user.ID, Title: "Jane's work", Slug: randomLabel(), Template: "modern", Status: status}
+	suite.Require().NoError(suite.db.Create(portfolio).Error)
+	suite.Require().NoError(suite.db.Create(&models.PortfolioSection{PortfolioID: portfolio.ID, Title: "About", Type: "about", Content: &about}).Error)
+	return user, portfolio.Slug
+}
+
+func (suite *PortfolioSiteTestSuite) get(slug string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/p/"+slug, nil))
+	return w
+}
+
+func (suite *PortfolioSiteTestSuite) TestPublishedPortfolioIsServedAsHTML() {
+	_, slug := suite.publish(models.PortfolioStatusPublished)
+
+	w := suite.get(slug)
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal("text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	suite.Equal("public, max-age=300", w.Header().Get("Cache-Control"))
+
+	html := w.Body.String()
+	suite.Contains(html, `<body class="template-modern">`)
+	suite.Contains(html, "Jane &lt;Doe&gt;")
+	suite.Contains(html, "<p>Builds things &amp; writes about them.</p>")
+	suite.Contains(html, `/p/`+slug+`"`)
+}
+
+func (suite *PortfolioSiteTestSuite) TestUnpublishedPortfoliosAreNotFound() {
+	_, draft := suite.publish(models.PortfolioStatusDraft)
+
+	for _, slug := range []string{draft, randomLabel()} {
+		w := suite.get(slug)
+		suite.Equal(http.StatusNotFound, w.Code, slug)
+		suite.Equal("no-store", w.Header().Get("Cache-Control"), slug)
+		suite.True(strings.HasPrefix(w.Header().Get("Content-Type"), "text/html"), slug)
+		suite.Contains(w.Body.String(), "Portfolio not found", slug)
+	}
+}
+
+func (suite *PortfolioSiteTestSuite) TestSuspendedOwnersPortfolioIsNotFound() {
+	user, slug := suite.publish(models.PortfolioStatusPublished)
+	suite.Require().NoError(suite.db.Model(user).Update("suspended_at", gorm.Expr("NOW()")).Error)
+
+	suite.Equal(http.StatusNotFound, suite.get(slug).Code)
+}
+
+func TestPortfolioSiteTestSuite(t *testing.T) {
+	suite.Run(t, new(PortfolioSiteTestSuite))
+}
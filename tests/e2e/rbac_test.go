@@ -0,0 +1,146 @@
+package e2e
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"foglio/v2/src/config"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type RBACTestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	roles  *services.RoleService
+	router *gin.Engine
+}
+
+func (suite *RBACTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.roles = services.NewRoleService(suite.db)
+
+	gin.SetMode(gin.TestMode)
+	suite.router = gin.New()
+	suite.router.Use(middlewares.ErrorHandlerMiddleware())
+	// Stands in for AuthMiddleware: the caller is whoever the header names
+	suite.router.Use(func(ctx *gin.Context) {
+		if userID := ctx.GetHeader("X-Test-User"); userID != "" {
+			ctx.Set(config.AppConfig.CurrentUserId, userID)
+		}
+		ctx.Next()
+	})
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	suite.router.GET("/moderate", middlewares.RequirePermission(models.PermissionUsersModerate), ok)
+	suite.router.GET("/roles", middlewares.RequirePermission(models.PermissionRolesManage), ok)
+	suite.router.GET("/either", middlewares.RequirePermission(models.PermissionRolesManage, models.PermissionAnalyticsRead), ok)
+	suite.router.GET("/users/:id", middlewares.RequireSelfOrPermission("id", models.PermissionUsersModerate), ok)
+}
+
+func (suite *RBACTestSuite) request(user *models.User, path string) int {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	if user != nil {
+		request.Header.Set("X-Test-User", user.ID.String())
+	}
+	recorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+func (suite *RBACTestSuite) createRole(permissions ...models.Permission) *models.Role {
+	granted := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		granted = append(granted, string(permission))
+	}
+	role, err := suite.roles.CreateRole(dto.CreateRoleDto{
+		Name:        "test_role_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12],
+		Permissions: granted,
+	})
+	suite.Require().NoError(err)
+	return role
+}
+
+func (suite *RBACTestSuite) assign(user *models.User, role *models.Role) {
+	_, err := suite.roles.AssignRole(user.ID.String(), role.ID.String(), "")
+	suite.Require().NoError(err)
+}
+
+func (suite *RBACTestSuite) TestRoutesNeedTheirPermission() {
+	moderator := utils.CreateTestUser(suite.T(), suite.db, "")
+	suite.assign(moderator, suite.createRole(models.PermissionUsersModerate))
+
+	suite.Equal(http.StatusOK, suite.request(moderator, "/moderate"))
+	suite.Equal(http.StatusForbidden, suite.request(moderator, "/roles"))
+	suite.Equal(http.StatusForbidden, suite.request(moderator, "/either"))
+}
+
+func (suite *RBACTestSuite) TestAnyListedPermissionIsEnough() {
+	analyst := utils.CreateTestUser(suite.T(), suite.db, "")
+	suite.assign(analyst, suite.createRole(models.PermissionAnalyticsRead))
+
+	suite.Equal(http.StatusOK, suite.request(analyst, "/either"))
+	suite.Equal(http.StatusForbidden, suite.request(analyst, "/roles"))
+}
+
+func (suite *RBACTestSuite) TestSuperAdminWildcardGrantsEverything() {
+	admin := utils.CreateTestUser(suite.T(), suite.db, "")
+	utils.GrantSuperAdmin(suite.T(), suite.db, admin)
+
+	for _, path := range []string{"/moderate", "/roles", "/either", "/users/" + uuid.NewString()} {
+		suite.Equal(http.StatusOK, suite.request(admin, path), path)
+	}
+}
+
+func (suite *RBACTestSuite) TestUsersWithoutRolesAreRefused() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+
+	suite.Equal(http.StatusForbidden, suite.request(user, "/moderate"))
+	suite.Equal(http.StatusUnauthorized, suite.request(nil, "/moderate"))
+}
+
+func (suite *RBACTestSuite) TestSelfOrPermission() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+
+	suite.Equal(http.StatusOK, suite.request(user, "/users/"+user.ID.String()))
+	suite.Equal(http.StatusForbidden, suite.request(user, "/users/"+other.ID.String()))
+}
+
+func (suite *RBACTestSuite) TestRevokingARoleTakesEffect() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	role := suite.createRole(models.PermissionUsersModerate)
+	suite.assign(user, role)
+	suite.Equal(http.StatusOK, suite.request(user, "/moderate"))
+
+	suite.Require().NoError(suite.roles.RevokeRole(user.ID.String(), role.ID.String()))
+	suite.Equal(http.StatusForbidden, suite.request(user, "/moderate"))
+}
+
+func (suite *RBACTestSuite) TestRolesOnlyTakeKnownPermissions() {
+	_, err := suite.roles.CreateRole(dto.CreateRoleDto{Name: "test_role_invalid", Permissions: []string{"users:everything"}})
+	suite.ErrorIs(err, services.ErrInvalidPermission)
+}
+
+func (suite *RBACTestSuite) TestSystemRolesAreImmutable() {
+	var superAdmin models.Role
+	suite.Require().NoError(suite.db.Where("name = ?", models.SuperAdminRole).First(&superAdmin).Error)
+
+	suite.ErrorIs(suite.roles.DeleteRole(superAdmin.ID.String()), services.ErrSystemRoleImmutable)
+	name := "renamed_admin"
+	_, err := suite.roles.UpdateRole(superAdmin.ID.String(), dto.UpdateRoleDto{Name: &name})
+	suite.ErrorIs(err, services.ErrSystemRoleImmutable)
+}
+
+func TestRBACTestSuite(t *testing.T) {
+	suite.Run(t, new(RBACTestSuite))
+}
@@ -0,0 +1,109 @@
+package e2e
+
+import (
+	"sync"
+	"testing"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// recordingBroker keeps every message published through it.
+type recordingBroker struct {
+	*lib.MemoryBroker
+	mu        sync.Mutex
+	published []lib.BrokerMessage
+}
+
+func (b *recordingBroker) Publish(message lib.BrokerMessage) error {
+	b.mu.Lock()
+	b.published = append(b.published, message)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *recordingBroker) revokedSessions(userID string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var sessionIDs []string
+	for _, message := range b.published {
+		if message.Target == lib.BrokerTargetSessions && message.UserID == userID {
+			sessionIDs = append(sessionIDs, message.SessionIDs...)
+		}
+	}
+	return sessionIDs
+}
+
+type SessionTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.SessionService
+	broker  *recordingBroker
+	client  dto.SessionClient
+}
+
+func (suite *SessionTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewSessionService(suite.db)
+	suite.broker = &recordingBroker{MemoryBroker: lib.NewMemoryBroker()}
+	services.SetSessionHub(lib.NewHub(suite.broker))
+	suite.client = dto.SessionClient{UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/130.0", IPAddress: "203.0.113.7"}
+}
+
+func (suite *SessionTestSuite) TearDownSuite() {
+	services.SetSessionHub(nil)
+}
+
+func (suite *SessionTestSuite) signIn(user *models.User) (*dto.TokenPair, string) {
+	pair, err := suite.service.CreateSession(user.ID, suite.client)
+	suite.Require().NoError(err)
+	claims, err := lib.ValidateToken(pair.Token)
+	suite.Require().NoError(err)
+	return pair, claims.ID
+}
+
+func (suite *SessionTestSuite) TestRevokeSessionDropsItsSockets() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	_, sessionID := suite.signIn(user)
+
+	suite.Require().NoError(suite.service.RevokeSession(user.ID.String(), sessionID))
+	suite.Equal([]string{sessionID}, suite.broker.revokedSessions(user.ID.String()))
+}
+
+func (suite *SessionTestSuite) TestRevokeAllSessionsDropsEverySocketButTheCurrent() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	_, current := suite.signIn(user)
+	_, first := suite.signIn(user)
+	_, second := suite.signIn(user)
+
+	revoked, err := suite.service.RevokeAllSessions(user.ID.String(), current)
+	suite.Require().NoError(err)
+	suite.Equal(int64(2), revoked)
+	suite.ElementsMatch([]string{first, second}, suite.broker.revokedSessions(user.ID.String()))
+}
+
+func (suite *SessionTestSuite) TestRefreshTokenReuseRevokesTheSession() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	pair, sessionID := suite.signIn(user)
+
+	_, rotated, err := suite.service.Refresh(pair.RefreshToken, suite.client)
+	suite.Require().NoError(err)
+
+	_, _, err = suite.service.Refresh(pair.RefreshToken, suite.client)
+	suite.ErrorIs(err, services.ErrRefreshTokenReused)
+
+	_, _, err = suite.service.Refresh(rotated.RefreshToken, suite.client)
+	suite.ErrorIs(err, services.ErrInvalidRefreshToken)
+	suite.Contains(suite.broker.revokedSessions(user.ID.String()), sessionID)
+}
+
+func TestSessionTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionTestSuite))
+}
@@ -0,0 +1,168 @@
+package e2e
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"foglio/v2/src/config"
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// routeHosts serves the API behind the site host middleware and returns what answered a request for host.
+func routeHosts(siteHosts *services.SiteHostService) func(host, path string) string {
+	gin.SetMode(gin.TestMode)
+	app := gin.New()
+	app.Use(middlewares.SiteHostMiddleware(siteHosts, func(ctx *gin.Context) { ctx.String(http.StatusOK, "site") }))
+	app.GET("/api/v2/health", func(ctx *gin.Context) { ctx.String(http.StatusOK, "api") })
+	app.GET(services.ACMEChallengePrefix+":token", func(ctx *gin.Context) { ctx.String(http.StatusOK, "challenge") })
+
+	return func(host, path string) string {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Host = host
+		recorder := httptest.NewRecorder()
+		app.ServeHTTP(recorder, request)
+		return recorder.Body.String()
+	}
+}
+
+// TestSiteHostRouting checks which hosts the middleware hands to the portfolio site and which reach the API
+// without looking anything up.
+func TestSiteHostRouting(t *testing.T) {
+	config.InitializeConfig()
+	t.Cleanup(config.InitializeConfig)
+	config.AppConfig.SitesDomain = "sites.test"
+	config.AppConfig.ApiHosts = []string{"api.example.com"}
+	serve := routeHosts(services.NewSiteHostService(nil))
+
+	for _, host := range []string{"api.example.com", "API.example.com:443", "localhost:8080", "127.0.0.1", "[::1]:8080",
+		"sites.test", "www.sites.test", "api.sites.test."} {
+		assert.Equal(t, "api", serve(host, "/api/v2/health"), host)
+	}
+	for _, host := range []string{"jane.sites.test", "Jane.Sites.Test:443", "a.b.sites.test"} {
+		assert.Equal(t, "site", serve(host, "/api/v2/health"), host)
+	}
+	assert.Equal(t, "challenge", serve("jane.sites.test", services.ACMEChallengePrefix+"token"))
+}
+
+type SiteHostTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.SiteHostService
+}
+
+func (suite *SiteHostTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewSiteHostService(suite.db)
+}
+
+func randomLabel() string {
+	return "test-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+}
+
+func (suite *SiteHostTestSuite) setDomain(user *models.User, domain *models.Domain) {
+	user.Domain = domain
+	suite.Require().NoError(suite.db.Model(user).Select("domain").Updates(user).Error)
+}
+
+// customDomain gives user a custom domain in the given status and returns its host.
+func (suite *SiteHostTestSuite) customDomain(user *models.User, status models.DomainStatus) string {
+	host := randomLabel() + ".example.org"
+	verifiedAt := time.Now()
+	suite.setDomain(user, &models.Domain{CustomDomain: host, CustomDomainStatus: status, CustomDomainVerifiedAt: &verifiedAt})
+	return host
+}
+
+func (suite *SiteHostTestSuite) TestClaimedSubdomainResolvesToItsOwner() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	subdomain := randomLabel()
+	suite.setDomain(user, &models.Domain{Subdomain: subdomain})
+
+	userID, err := suite.service.ResolveHost(strings.ToUpper(services.SubdomainHost(subdomain)) + ":443")
+	suite.Require().NoError(err)
+	suite.Equal(user.ID, userID)
+}
+
+func (suite *SiteHostTestSuite) TestNestedAndUnclaimedSubdomainsServeNothing() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	subdomain := randomLabel()
+	suite.setDomain(user, &models.Domain{Subdomain: subdomain})
+
+	_, err := suite.service.ResolveHost("www." + services.SubdomainHost(subdomain))
+	suite.ErrorIs(err, services.ErrSiteHostNotFound)
+	_, err = suite.service.ResolveHost(services.SubdomainHost(randomLabel()))
+	suite.ErrorIs(err, services.ErrSiteHostNotFound)
+}
+
+func (suite *SiteHostTestSuite) TestVerifiedCustomDomainNeedsAPlanThatAllowsIt() {
+	premium := utils.CreateTestUser(suite.T(), suite.db, "")
+	suite.Require().NoError(suite.db.Model(premium).Update("is_premium", true).Error)
+	premiumHost := suite.customDomain(premium, models.DomainStatusVerified)
+	free := utils.CreateTestUser(suite.T(), suite.db, "")
+	freeHost := suite.customDomain(free, models.DomainStatusVerified)
+
+	userID, err := suite.service.ResolveHost(premiumHost)
+	suite.Require().NoError(err)
+	suite.Equal(premium.ID, userID)
+	_, err = suite.service.ResolveHost(freeHost)
+	suite.ErrorIs(err, services.ErrSiteHostNotFound)
+}
+
+func (suite *SiteHostTestSuite) TestUnverifiedCustomDomainServesNothing() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	suite.Require().NoError(suite.db.Model(user).Update("is_premium", true).Error)
+	host := suite.customDomain(user, models.DomainStatusPending)
+
+	_, err := suite.service.ResolveHost(host)
+	suite.ErrorIs(err, services.ErrSiteHostNotFound)
+}
+
+func (suite *SiteHostTestSuite) TestInvalidationPicksUpChangesStraightAway() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	subdomain := randomLabel()
+	host := services.SubdomainHost(subdomain)
+
+	// The miss is cached until the host is invalidated
+	_, err := suite.service.ResolveHost(host)
+	suite.Require().ErrorIs(err, services.ErrSiteHostNotFound)
+	suite.setDomain(user, &models.Domain{Subdomain: subdomain})
+	_, err = suite.service.ResolveHost(host)
+	suite.ErrorIs(err, services.ErrSiteHostNotFound)
+
+	services.InvalidateSiteHosts(host)
+	userID, err := suite.service.ResolveHost(host)
+	suite.Require().NoError(err)
+	suite.Equal(user.ID, userID)
+}
+
+func (suite *SiteHostTestSuite) TestOnlyKnownCustomDomainsReachTheSite() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	suite.Require().NoError(suite.db.Model(user).Update("is_premium", true).Error)
+	host := suite.customDomain(user, models.DomainStatusVerified)
+	pending := suite.customDomain(utils.CreateTestUser(suite.T(), suite.db, ""), models.DomainStatusPending)
+	serve := routeHosts(suite.service)
+
+	suite.Equal("site", serve(host, "/api/v2/health"))
+	suite.Equal("site", serve(strings.ToUpper(host)+":443", "/"))
+	// Custom domains answer their own ACME challenges, but nothing else of the API
+	suite.Equal("challenge", serve(host, services.ACMEChallengePrefix+"token"))
+
+	for _, unknown := range []string{pending, randomLabel() + ".example.org", "evil.example.net"} {
+		suite.Equal("api", serve(unknown, "/api/v2/health"), unknown)
+	}
+}
+
+func TestSiteHostTestSuite(t *testing.T) {
+	suite.Run(t, new(SiteHostTestSuite))
+}
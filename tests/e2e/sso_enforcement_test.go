@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"foglio/v2/src/middlewares"
+	"foglio/v2/src/models"
+	"foglio/v2/src/routes"
+	"foglio/v2/tests/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+const ssoTestPassword = "Password123!"
+
+type SSOEnforcementTestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	router *gin.Engine
+}
+
+func (suite *SSOEnforcementTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+
+	suite.router = gin.New()
+	suite.router.Use(middlewares.ErrorHandlerMiddleware(), middlewares.AuthMiddleware())
+	routes.AuthRoutes(suite.router.Group("/api/v2"))
+}
+
+// companyDomain sets up SSO for a new company on a fresh email domain and returns the domain.
+func (suite *SSOEnforcementTestSuite) companyDomain(enforced, verified bool) string {
+	company := &models.Company{Name: "SSO Co"}
+	suite.Require().NoError(suite.db.Create(company).Error)
+	suite.Require().NoError(suite.db.Create(&models.CompanySSO{
+		CompanyID: company.ID, Enabled: true, EnforceSSO: enforced, SPCertificate: "certificate", SPPrivateKey: "key",
+	}).Error)
+
+	domain := randomLabel() + ".example.com"
+	claim := &models.CompanySSODomain{CompanyID: company.ID, Domain: domain, VerificationToken: "token"}
+	if verified {
+		now := time.Now()
+		claim.VerifiedAt = &now
+	}
+	suite.Require().NoError(suite.db.Create(claim).Error)
+	return domain
+}
+
+// employee creates a password account with an email at the domain.
+func (suite *SSOEnforcementTestSuite) employee(domain string) *models.User {
+	user := utils.CreateTestUser(suite.T(), suite.db, ssoTestPassword)
+	user.Email = user.Username + "@" + domain
+	suite.Require().NoError(suite.db.Model(user).Update("email", user.Email).Error)
+	return user
+}
+
+func (suite *SSOEnforcementTestSuite) signIn(identifier, password string) *httptest.ResponseRecorder {
+	return utils.MakeRequest(suite.router, "POST", "/api/v2/auth/signin", map[string]string{"identifier": identifier, "password": password})
+}
+
+func (suite *SSOEnforcementTestSuite) TestEnforcedDomainsCannotUsePasswords() {
+	user := suite.employee(suite.companyDomain(true, true))
+
+	response := utils.AssertJSONResponse(suite.T(), suite.signIn(user.Email, ssoTestPassword), http.StatusBadRequest)
+	suite.Equal("SSO_REQUIRED", response["code"])
+
+	// Signing in by username ends up at the same account, so it is refused too
+	response = utils.AssertJSONResponse(suite.T(), suite.signIn(user.Username, ssoTestPassword), http.StatusBadRequest)
+	suite.Equal("SSO_REQUIRED", response["code"])
+}
+
+func (suite *SSOEnforcementTestSuite) TestUsernameSignInChecksThePasswordFirst() {
+	user := suite.employee(suite.companyDomain(true, true))
+
+	w := suite.signIn(user.Username, "wrong-password")
+	suite.Equal(http.StatusUnauthorized, w.Code)
+}
+
+func (suite *SSOEnforcementTestSuite) TestPasswordsStillWorkUnlessEnforced() {
+	optional := suite.employee(suite.companyDomain(false, true))
+	utils.AssertJSONResponse(suite.T(), suite.signIn(optional.Email, ssoTestPassword), http.StatusOK)
+
+	// A domain only counts once the company has proven it owns it
+	unproven := suite.employee(suite.companyDomain(true, false))
+	utils.AssertJSONResponse(suite.T(), suite.signIn(unproven.Email, ssoTestPassword), http.StatusOK)
+}
+
+func TestSSOEnforcementTestSuite(t *testing.T) {
+	suite.Run(t, new(SSOEnforcementTestSuite))
+}
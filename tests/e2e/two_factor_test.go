@@ -0,0 +1,165 @@
+package e2e
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/lib/pq"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// twoFactorAttemptLimit mirrors the number of wrong codes that locks 2FA sign-in.
+const twoFactorAttemptLimit = 5
+
+type TwoFactorTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.TwoFactorService
+}
+
+func (suite *TwoFactorTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewTwoFactorService(suite.db)
+}
+
+func (suite *TwoFactorTestSuite) createTwoFactorUser() *models.User {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "Foglio", AccountName: user.Email})
+	suite.Require().NoError(err)
+
+	secret := key.Secret()
+	suite.Require().NoError(suite.db.Model(user).Updates(map[string]interface{}{
+		"is_two_factor_enabled": true,
+		"two_factor_secret":     secret,
+	}).Error)
+	user.IsTwoFactorEnabled = true
+	user.TwoFactorSecret = &secret
+	return user
+}
+
+func (suite *TwoFactorTestSuite) reload(user *models.User) *models.User {
+	var fresh models.User
+	suite.Require().NoError(suite.db.First(&fresh, "id = ?", user.ID).Error)
+	return &fresh
+}
+
+// client gives every attempt its own address so the per-IP throttle does not interfere.
+func (suite *TwoFactorTestSuite) client(i int) dto.SessionClient {
+	return dto.SessionClient{UserAgent: "test", IPAddress: fmt.Sprintf("198.51.100.%d", i+1)}
+}
+
+func (suite *TwoFactorTestSuite) TestWrongCodesLockSignIn() {
+	user := suite.createTwoFactorUser()
+
+	for i := 0; i < twoFactorAttemptLimit-1; i++ {
+		_, err := suite.service.VerifyLoginCode(suite.reload(user), "wrong", suite.client(i))
+		suite.ErrorIs(err, services.ErrInvalidTwoFactorCode)
+	}
+	_, err := suite.service.VerifyLoginCode(suite.reload(user), "wrong", suite.client(twoFactorAttemptLimit))
+	suite.ErrorIs(err, services.ErrTwoFactorLocked)
+
+	code, err := totp.GenerateCode(*user.TwoFactorSecret, time.Now())
+	suite.Require().NoError(err)
+	_, err = suite.service.VerifyLoginCode(suite.reload(user), code, suite.client(twoFactorAttemptLimit+1))
+	suite.ErrorIs(err, services.ErrTwoFactorLocked)
+}
+
+func (suite *TwoFactorTestSuite) TestConcurrentWrongCodesStillLock() {
+	user := suite.createTwoFactorUser()
+	// Every attempt starts from the same snapshot, as concurrent requests would
+	snapshot := suite.reload(user)
+
+	var wg sync.WaitGroup
+	for i := 0; i < twoFactorAttemptLimit; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			attempt := *snapshot
+			_, _ = suite.service.VerifyLoginCode(&attempt, "wrong", suite.client(i))
+		}(i)
+	}
+	wg.Wait()
+
+	locked := suite.reload(user)
+	suite.Require().NotNil(locked.TwoFactorLockedUntil)
+	suite.Equal(twoFactorAttemptLimit, locked.TwoFactorFailedAttempts)
+}
+
+func (suite *TwoFactorTestSuite) TestConcurrentGuessesShareTheAttemptLimit() {
+	user := suite.createTwoFactorUser()
+	snapshot := suite.reload(user)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		checked int
+	)
+	for i := 0; i < 4*twoFactorAttemptLimit; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			attempt := *snapshot
+			_, err := suite.service.VerifyLoginCode(&attempt, "wrong", suite.client(i))
+			if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+				mu.Lock()
+				checked++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// The attempt that reached the limit reports the lockout rather than a wrong code
+	suite.Equal(twoFactorAttemptLimit-1, checked)
+	suite.NotNil(suite.reload(user).TwoFactorLockedUntil)
+}
+
+func (suite *TwoFactorTestSuite) TestBackupCodeIsRedeemedOnce() {
+	user := suite.createTwoFactorUser()
+	hash, err := lib.HashPassword("ABCD1234")
+	suite.Require().NoError(err)
+	other, err := lib.HashPassword("WXYZ9876")
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.db.Model(user).
+		Update("two_factor_backup_codes", pq.StringArray{hash, other}).Error)
+	snapshot := suite.reload(user)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		redeemed int
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			attempt := *snapshot
+			if _, err := suite.service.VerifyLoginCode(&attempt, "abcd-1234", suite.client(i)); err == nil {
+				mu.Lock()
+				redeemed++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	suite.Equal(1, redeemed)
+	// Only that code is used up, and nothing else about the user is written back
+	codes := suite.reload(user).TwoFactorBackupCodes
+	suite.Equal(pq.StringArray{"USED", other}, codes)
+}
+
+func TestTwoFactorTestSuite(t *testing.T) {
+	suite.Run(t, new(TwoFactorTestSuite))
+}
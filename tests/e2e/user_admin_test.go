@@ -0,0 +1,161 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type UserAdminTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.UserAdminService
+	admin   *models.User
+}
+
+func (suite *UserAdminTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewUserAdminService(suite.db)
+	suite.admin = utils.CreateTestUser(suite.T(), suite.db, "")
+	utils.GrantSuperAdmin(suite.T(), suite.db, suite.admin)
+}
+
+func (suite *UserAdminTestSuite) merge(targetID, sourceID string) (*models.User, error) {
+	return suite.service.MergeUsers(suite.admin.ID.String(), targetID, dto.MergeUsersDto{SourceUserID: sourceID})
+}
+
+func (suite *UserAdminTestSuite) TestMergeMovesContentAndDeletesSource() {
+	target := utils.CreateTestUser(suite.T(), suite.db, "")
+	source := utils.CreateTestUser(suite.T(), suite.db, "")
+	project := models.Project{UserID: source.ID, Title: "Side project", Description: "Built on weekends"}
+	suite.Require().NoError(suite.db.Create(&project).Error)
+
+	_, err := suite.merge(target.ID.String(), source.ID.String())
+	suite.Require().NoError(err)
+
+	var moved models.Project
+	suite.Require().NoError(suite.db.First(&moved, "id = ?", project.ID).Error)
+	suite.Equal(target.ID, moved.UserID)
+
+	err = suite.db.First(&models.User{}, "id = ?", source.ID).Error
+	suite.ErrorIs(err, gorm.ErrRecordNotFound)
+}
+
+func (suite *UserAdminTestSuite) TestMergeRejectsStaffOnEitherSide() {
+	staff := utils.CreateTestUser(suite.T(), suite.db, "")
+	utils.GrantSuperAdmin(suite.T(), suite.db, staff)
+	regular := utils.CreateTestUser(suite.T(), suite.db, "")
+
+	_, err := suite.merge(staff.ID.String(), regular.ID.String())
+	suite.ErrorIs(err, services.ErrCannotModerateStaff)
+
+	_, err = suite.merge(regular.ID.String(), staff.ID.String())
+	suite.ErrorIs(err, services.ErrCannotModerateStaff)
+
+	var roles int64
+	suite.Require().NoError(suite.db.Model(&models.UserRole{}).Where("user_id = ?", regular.ID).Count(&roles).Error)
+	suite.Zero(roles)
+}
+
+func (suite *UserAdminTestSuite) TestMergeRejectsTheAdminsOwnAccount() {
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+
+	_, err := suite.merge(suite.admin.ID.String(), other.ID.String())
+	suite.ErrorIs(err, services.ErrCannotModerateSelf)
+
+	_, err = suite.merge(other.ID.String(), suite.admin.ID.String())
+	suite.ErrorIs(err, services.ErrCannotModerateSelf)
+}
+
+func (suite *UserAdminTestSuite) TestSuspendUserSignsOutAndStopsChat() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	other := utils.CreateTestUser(suite.T(), suite.db, "")
+	_, err := services.NewSessionService(suite.db).CreateSession(user.ID, dto.SessionClient{UserAgent: "test", IPAddress: "203.0.113.9"})
+	suite.Require().NoError(err)
+
+	_, err = suite.service.SuspendUser(suite.admin.ID.String(), user.ID.String(), dto.SuspendUserDto{Reason: "Spamming recruiters"})
+	suite.Require().NoError(err)
+
+	var active int64
+	suite.Require().NoError(suite.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&active).Error)
+	suite.Zero(active)
+
+	// A socket opened before the suspension cannot keep chatting either
+	chat := services.NewChatService(suite.db, nil, nil)
+	_, err = chat.SendMessage(user.ID.String(), dto.SendMessageDto{RecipientID: other.ID.String(), Content: "still here"})
+	suite.ErrorIs(err, services.ErrChatBanned)
+}
+
+func (suite *UserAdminTestSuite) TestDeleteUserTakesTheAccountDownUntilTheGracePeriodEnds() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	project := models.Project{UserID: user.ID, Title: "Side project", Description: "Built on weekends"}
+	suite.Require().NoError(suite.db.Create(&project).Error)
+
+	deletion, err := suite.service.DeleteUser(suite.admin.ID.String(), user.ID.String())
+	suite.Require().NoError(err)
+	suite.Equal(models.DeletionScheduled, deletion.Status)
+	suite.Require().NotNil(deletion.RequestedByID)
+	suite.Equal(suite.admin.ID, *deletion.RequestedByID)
+	suite.WithinDuration(time.Now().Add(services.AccountDeletionGracePeriod), deletion.ScheduledFor, time.Minute)
+
+	// The account is gone from the platform, but nothing is erased yet
+	err = suite.db.First(&models.User{}, "id = ?", user.ID).Error
+	suite.ErrorIs(err, gorm.ErrRecordNotFound)
+	var kept models.User
+	suite.Require().NoError(suite.db.Unscoped().First(&kept, "id = ?", user.ID).Error)
+	suite.Equal(user.Email, kept.Email)
+	suite.NoError(suite.db.First(&models.Project{}, "id = ?", project.ID).Error)
+
+	restored, err := suite.service.RestoreUser(user.ID.String())
+	suite.Require().NoError(err)
+	suite.Equal(user.ID, restored.ID)
+
+	var cancelled models.AccountDeletion
+	suite.Require().NoError(suite.db.First(&cancelled, "id = ?", deletion.ID).Error)
+	suite.Equal(models.DeletionCancelled, cancelled.Status)
+
+	_, err = suite.service.RestoreUser(user.ID.String())
+	suite.ErrorIs(err, services.ErrDeletionNotFound)
+}
+
+func (suite *UserAdminTestSuite) TestDeleteUserRejectsStaffAndTheAdminsOwnAccount() {
+	staff := utils.CreateTestUser(suite.T(), suite.db, "")
+	utils.GrantSuperAdmin(suite.T(), suite.db, staff)
+
+	_, err := suite.service.DeleteUser(suite.admin.ID.String(), staff.ID.String())
+	suite.ErrorIs(err, services.ErrCannotModerateStaff)
+	_, err = suite.service.DeleteUser(suite.admin.ID.String(), suite.admin.ID.String())
+	suite.ErrorIs(err, services.ErrCannotModerateSelf)
+
+	suite.NoError(suite.db.First(&models.User{}, "id = ?", staff.ID).Error)
+	var deletions int64
+	suite.Require().NoError(suite.db.Model(&models.AccountDeletion{}).
+		Where("user_id IN ?", []interface{}{staff.ID, suite.admin.ID}).Count(&deletions).Error)
+	suite.Zero(deletions)
+}
+
+func (suite *UserAdminTestSuite) TestStaffDeletionIsErasedOnceDue() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	deletion, err := suite.service.DeleteUser(suite.admin.ID.String(), user.ID.String())
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.db.Model(deletion).Update("scheduled_for", time.Now().Add(-time.Minute)).Error)
+
+	suite.Require().NoError(services.NewAccountDeletionService(suite.db).ProcessDueDeletions())
+
+	var erased models.User
+	suite.Require().NoError(suite.db.Unscoped().First(&erased, "id = ?", user.ID).Error)
+	suite.Equal("deleted-"+user.ID.String(), erased.Username)
+	suite.Equal("Deleted User", erased.Name)
+}
+
+func TestUserAdminTestSuite(t *testing.T) {
+	suite.Run(t, new(UserAdminTestSuite))
+}
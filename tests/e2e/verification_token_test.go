@@ -0,0 +1,83 @@
+package e2e
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// Wrong codes a verification code accepts before it stops working.
+const verificationCodeAttempts = 5
+
+type VerificationTokenTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.VerificationTokenService
+}
+
+func (suite *VerificationTokenTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	suite.service = services.NewVerificationTokenService(suite.db)
+}
+
+func (suite *VerificationTokenTestSuite) TestCodeIsSingleUse() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	code, err := suite.service.Issue(user.ID, models.PurposeEmailVerification, "")
+	suite.Require().NoError(err)
+
+	record, err := suite.service.ConsumeCode(user.ID, models.PurposeEmailVerification, code)
+	suite.Require().NoError(err)
+	suite.NotNil(record.ConsumedAt)
+
+	_, err = suite.service.ConsumeCode(user.ID, models.PurposeEmailVerification, code)
+	suite.ErrorIs(err, services.ErrInvalidVerificationCode)
+}
+
+func (suite *VerificationTokenTestSuite) TestConcurrentGuessesShareTheAttemptLimit() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	code, err := suite.service.Issue(user.ID, models.PurposeEmailVerification, "")
+	suite.Require().NoError(err)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		guessed int
+	)
+	for i := 0; i < 4*verificationCodeAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := suite.service.ConsumeCode(user.ID, models.PurposeEmailVerification, wrong)
+			if errors.Is(err, services.ErrInvalidVerificationCode) {
+				mu.Lock()
+				guessed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Only the attempts left before the limit were compared; the last one reports the limit instead
+	suite.Equal(verificationCodeAttempts-1, guessed)
+	var record models.VerificationToken
+	suite.Require().NoError(suite.db.Where("user_id = ? AND purpose = ?", user.ID, models.PurposeEmailVerification).First(&record).Error)
+	suite.Equal(verificationCodeAttempts, record.Attempts)
+
+	_, err = suite.service.ConsumeCode(user.ID, models.PurposeEmailVerification, code)
+	suite.ErrorIs(err, services.ErrVerificationAttemptsExceeded)
+}
+
+func TestVerificationTokenTestSuite(t *testing.T) {
+	suite.Run(t, new(VerificationTokenTestSuite))
+}
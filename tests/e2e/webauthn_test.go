@@ -0,0 +1,143 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"foglio/v2/src/config"
+	"foglio/v2/src/dto"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
+	"foglio/v2/tests/utils"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+const (
+	testWebAuthnRPID   = "localhost"
+	testWebAuthnOrigin = "http://localhost:3000"
+)
+
+type WebAuthnTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *services.WebAuthnService
+}
+
+func (suite *WebAuthnTestSuite) SetupSuite() {
+	suite.db = utils.RequireDatabase(suite.T())
+	config.AppConfig.WebAuthnRPID = testWebAuthnRPID
+	config.AppConfig.WebAuthnOrigins = []string{testWebAuthnOrigin}
+	suite.service = services.NewWebAuthnService(suite.db)
+}
+
+// register adds a passkey held by a new virtual authenticator to the user's account.
+func (suite *WebAuthnTestSuite) register(user *models.User) *utils.VirtualAuthenticator {
+	authenticator := utils.NewVirtualAuthenticator(suite.T(), testWebAuthnRPID, testWebAuthnOrigin)
+	authenticator.UserHandle = user.ID[:]
+
+	options, err := suite.service.BeginRegistration(user)
+	suite.Require().NoError(err)
+	creation := options.Options.(protocol.PublicKeyCredentialCreationOptions)
+
+	_, err = suite.service.FinishRegistration(user, dto.RegisterPasskeyRequest{
+		CeremonyID: options.CeremonyID,
+		Name:       "Laptop",
+		Credential: authenticator.Register(suite.T(), creation.Challenge),
+	})
+	suite.Require().NoError(err)
+	return authenticator
+}
+
+// login runs a passwordless sign-in, answering with the given signature counter.
+func (suite *WebAuthnTestSuite) login(authenticator *utils.VirtualAuthenticator, signCount uint32) (*models.User, error) {
+	options, err := suite.service.BeginLogin()
+	suite.Require().NoError(err)
+	request := options.Options.(protocol.PublicKeyCredentialRequestOptions)
+
+	return suite.service.FinishLogin(dto.PasskeyLoginRequest{
+		CeremonyID: options.CeremonyID,
+		Credential: authenticator.AssertWithCount(suite.T(), request.Challenge, signCount),
+	})
+}
+
+func (suite *WebAuthnTestSuite) TestRegistrationStoresThePasskey() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	authenticator := suite.register(user)
+
+	credentials, err := suite.service.GetCredentials(user.ID.String())
+	suite.Require().NoError(err)
+	suite.Require().Len(credentials, 1)
+	suite.Equal("Laptop", credentials[0].Name)
+	suite.Equal(authenticator.CredentialID, credentials[0].CredentialID)
+}
+
+func (suite *WebAuthnTestSuite) TestRegisteringTheSamePasskeyTwiceIsRefused() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	authenticator := suite.register(user)
+
+	options, err := suite.service.BeginRegistration(user)
+	suite.Require().NoError(err)
+	creation := options.Options.(protocol.PublicKeyCredentialCreationOptions)
+	_, err = suite.service.FinishRegistration(user, dto.RegisterPasskeyRequest{
+		CeremonyID: options.CeremonyID,
+		Name:       "Laptop again",
+		Credential: authenticator.Register(suite.T(), creation.Challenge),
+	})
+	suite.ErrorIs(err, services.ErrWebAuthnCredentialExists)
+}
+
+func (suite *WebAuthnTestSuite) TestLoginReturnsTheUserAndRecordsTheCounter() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	authenticator := suite.register(user)
+
+	signedIn, err := suite.login(authenticator, 1)
+	suite.Require().NoError(err)
+	suite.Equal(user.ID, signedIn.ID)
+
+	var stored models.WebAuthnCredential
+	suite.Require().NoError(suite.db.First(&stored, "user_id = ?", user.ID).Error)
+	suite.Equal(int64(1), stored.SignCount)
+	suite.NotNil(stored.LastUsedAt)
+}
+
+func (suite *WebAuthnTestSuite) TestSignCountRegressionIsRefused() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	authenticator := suite.register(user)
+
+	_, err := suite.login(authenticator, 5)
+	suite.Require().NoError(err)
+
+	_, err = suite.login(authenticator, 3)
+	suite.ErrorIs(err, services.ErrWebAuthnVerificationFailed)
+}
+
+func (suite *WebAuthnTestSuite) TestSuspendedUserCannotSignIn() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	authenticator := suite.register(user)
+	suite.Require().NoError(suite.db.Model(user).Update("suspended_at", time.Now()).Error)
+
+	_, err := suite.login(authenticator, 1)
+	suite.ErrorIs(err, services.ErrAccountSuspended)
+}
+
+func (suite *WebAuthnTestSuite) TestChallengeCannotBeAnsweredTwice() {
+	user := utils.CreateTestUser(suite.T(), suite.db, "")
+	authenticator := suite.register(user)
+
+	options, err := suite.service.BeginLogin()
+	suite.Require().NoError(err)
+	request := options.Options.(protocol.PublicKeyCredentialRequestOptions)
+	payload := dto.PasskeyLoginRequest{CeremonyID: options.CeremonyID, Credential: authenticator.Assert(suite.T(), request.Challenge)}
+
+	_, err = suite.service.FinishLogin(payload)
+	suite.Require().NoError(err)
+	_, err = suite.service.FinishLogin(payload)
+	suite.ErrorIs(err, services.ErrInvalidWebAuthnCeremony)
+}
+
+func TestWebAuthnTestSuite(t *testing.T) {
+	suite.Run(t, new(WebAuthnTestSuite))
+}
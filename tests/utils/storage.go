@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"foglio/v2/src/config"
+
+	"github.com/google/uuid"
+)
+
+// fakeStorage stands in for Cloudinary: uploads and deletions succeed, and every file fetched holds content.
+type fakeStorage []byte
+
+func (f fakeStorage) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := []byte(f)
+	if req.Method == http.MethodPost {
+		body, _ = json.Marshal(map[string]interface{}{
+			"public_id":     "test/" + uuid.NewString(),
+			"resource_type": "raw",
+			"version":       1,
+			"result":        "ok",
+		})
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(body)), Request: req}, nil
+}
+
+// UseFakeStorage routes file storage to an in-memory fake for the rest of the test.
+func UseFakeStorage(t *testing.T, content []byte) {
+	t.Helper()
+
+	previous, transport := *config.AppConfig, http.DefaultTransport
+	config.AppConfig.CloudinaryName, config.AppConfig.CloudinaryKey, config.AppConfig.CloudinarySecret = "test", "key", "secret"
+	http.DefaultTransport = fakeStorage(content)
+	t.Cleanup(func() {
+		http.DefaultTransport = transport
+		*config.AppConfig = previous
+	})
+}
@@ -5,34 +5,46 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/joho/godotenv"
 
 	"foglio/v2/src/config"
 	"foglio/v2/src/database"
+	"foglio/v2/src/dto"
 	"foglio/v2/src/lib"
+	"foglio/v2/src/models"
+	"foglio/v2/src/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 type TestServer struct {
 	Router *gin.Engine
 }
 
-func SetupTestServer() *TestServer {
-	gin.SetMode(gin.TestMode)
-
+func loadTestConfig() {
 	_ = godotenv.Load(".env")
 	_ = godotenv.Load("../../.env")
 	if os.Getenv("POSTGRES_URL") == "" && os.Getenv("DATABASE_URL") != "" {
 		_ = os.Setenv("POSTGRES_URL", os.Getenv("DATABASE_URL"))
 	}
 	config.InitializeConfig()
+}
+
+func SetupTestServer() *TestServer {
+	gin.SetMode(gin.TestMode)
+
+	loadTestConfig()
 
 	err := database.InitializeDatabase()
 	if err != nil {
@@ -48,6 +60,94 @@ func SetupTestServer() *TestServer {
 	}
 }
 
+// RequireDatabase connects to the test database, skipping the test when none is configured.
+// The connection stays open for the rest of the package's tests.
+func RequireDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	loadTestConfig()
+	if config.AppConfig.PostgresUrl == "" {
+		t.Skip("POSTGRES_URL is not set")
+	}
+	if err := database.InitializeDatabase(); err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	lib.InitialiseJWT(string(config.AppConfig.JWTSecret))
+
+	return database.GetDatabase()
+}
+
+// CreateTestUser inserts a local account with a unique email and username and the given password.
+func CreateTestUser(t *testing.T, db *gorm.DB, password string) *models.User {
+	t.Helper()
+
+	handle := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:16]
+	user := &models.User{
+		Name:     "Test User",
+		Username: handle,
+		Email:    handle + "@example.com",
+		Provider: "local",
+	}
+	if password != "" {
+		hash, err := lib.HashPassword(password)
+		if err != nil {
+			t.Fatalf("Failed to hash password: %v", err)
+		}
+		user.Password = hash
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	return user
+}
+
+// GrantSuperAdmin gives the user the seeded super admin role, making them staff.
+func GrantSuperAdmin(t *testing.T, db *gorm.DB, user *models.User) {
+	t.Helper()
+
+	var role models.Role
+	if err := db.Where("name = ?", models.SuperAdminRole).First(&role).Error; err != nil {
+		t.Fatalf("Failed to load the super admin role: %v", err)
+	}
+	if err := db.Create(&models.UserRole{UserID: user.ID, RoleID: role.ID}).Error; err != nil {
+		t.Fatalf("Failed to grant the super admin role: %v", err)
+	}
+}
+
+// CreateDirectConversation starts a direct chat between two users the way ChatService does.
+func CreateDirectConversation(t *testing.T, db *gorm.DB, a, b *models.User) *models.Conversation {
+	t.Helper()
+
+	p1, p2 := a.ID, b.ID
+	if p1.String() > p2.String() {
+		p1, p2 = p2, p1
+	}
+	conversation := &models.Conversation{Type: models.ConversationTypeDirect, Participant1: &p1, Participant2: &p2}
+	if err := db.Create(conversation).Error; err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	now := time.Now()
+	if err := db.Create(&[]models.ConversationParticipant{
+		{ConversationID: conversation.ID, UserID: p1, Role: models.ParticipantRoleMember, JoinedAt: now},
+		{ConversationID: conversation.ID, UserID: p2, Role: models.ParticipantRoleMember, JoinedAt: now},
+	}).Error; err != nil {
+		t.Fatalf("Failed to add conversation participants: %v", err)
+	}
+	return conversation
+}
+
+// SignIn starts a session for the user and returns its access token.
+func SignIn(t *testing.T, db *gorm.DB, user *models.User) string {
+	t.Helper()
+
+	pair, err := services.NewSessionService(db).CreateSession(user.ID, dto.SessionClient{UserAgent: "test", IPAddress: "203.0.113.1"})
+	if err != nil {
+		t.Fatalf("Failed to sign in: %v", err)
+	}
+	return pair.Token
+}
+
 func (ts *TestServer) Cleanup() {
 	if err := database.CloseDatabase(); err != nil {
 		log.Printf("Error closing database: %v", err)
@@ -85,6 +185,27 @@ func MakeAuthenticatedRequest(router *gin.Engine, method, url, token string, bod
 	return w
 }
 
+// MakeMultipartRequest posts the form fields with content uploaded as fileName in fileField.
+func MakeMultipartRequest(router *gin.Engine, url, token string, fields map[string]string, fileField, fileName string, content []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		_ = writer.WriteField(name, value)
+	}
+	part, _ := writer.CreateFormFile(fileField, fileName)
+	_, _ = part.Write(content)
+	_ = writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, url, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
 func AssertJSONResponse(t *testing.T, w *httptest.ResponseRecorder, expectedStatus int) map[string]interface{} {
 	assert.Equal(t, expectedStatus, w.Code)
 	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
@@ -95,3 +216,18 @@ func AssertJSONResponse(t *testing.T, w *httptest.ResponseRecorder, expectedStat
 
 	return response
 }
+
+// MarshalToMap encodes value as JSON and decodes it into a map, showing exactly which fields an API response exposes.
+func MarshalToMap(t *testing.T, value interface{}) map[string]interface{} {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	return result
+}
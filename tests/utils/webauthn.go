@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+)
+
+const (
+	authenticatorFlagUserPresent  = 0x01
+	authenticatorFlagUserVerified = 0x04
+	authenticatorFlagAttestedData = 0x40
+)
+
+// VirtualAuthenticator is a software passkey: one ES256 credential that answers registration and sign-in
+// ceremonies the way a browser would hand them to the server, with "none" attestation.
+type VirtualAuthenticator struct {
+	RPID         string
+	Origin       string
+	CredentialID []byte
+	UserHandle   []byte
+	SignCount    uint32
+	key          *ecdsa.PrivateKey
+}
+
+func NewVirtualAuthenticator(t *testing.T, rpID, origin string) *VirtualAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate passkey: %v", err)
+	}
+	credentialID := make([]byte, 32)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatalf("Failed to generate credential ID: %v", err)
+	}
+
+	return &VirtualAuthenticator{RPID: rpID, Origin: origin, CredentialID: credentialID, key: key}
+}
+
+// Register answers navigator.credentials.create for the challenge, returning the PublicKeyCredential JSON.
+func (a *VirtualAuthenticator) Register(t *testing.T, challenge []byte) json.RawMessage {
+	t.Helper()
+
+	publicKey, err := webauthncbor.Marshal(map[int]interface{}{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		-3: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode public key: %v", err)
+	}
+
+	authData := a.authenticatorData(authenticatorFlagUserPresent | authenticatorFlagUserVerified | authenticatorFlagAttestedData)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.CredentialID)))
+	authData = append(authData, a.CredentialID...)
+	authData = append(authData, publicKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode attestation: %v", err)
+	}
+
+	return a.credential(t, map[string]interface{}{
+		"clientDataJSON":    encodeBase64URL(a.clientData(t, "webauthn.create", challenge)),
+		"attestationObject": encodeBase64URL(attestation),
+		"transports":        []string{"internal"},
+	})
+}
+
+// Assert answers navigator.credentials.get for the challenge, advancing the signature counter first.
+func (a *VirtualAuthenticator) Assert(t *testing.T, challenge []byte) json.RawMessage {
+	t.Helper()
+
+	a.SignCount++
+	return a.AssertWithCount(t, challenge, a.SignCount)
+}
+
+// AssertWithCount answers navigator.credentials.get reporting the given signature counter, e.g. one that
+// went backwards as a cloned key's would.
+func (a *VirtualAuthenticator) AssertWithCount(t *testing.T, challenge []byte, signCount uint32) json.RawMessage {
+	t.Helper()
+
+	saved := a.SignCount
+	a.SignCount = signCount
+	authData := a.authenticatorData(authenticatorFlagUserPresent | authenticatorFlagUserVerified)
+	a.SignCount = max(saved, signCount)
+
+	clientData := a.clientData(t, "webauthn.get", challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign assertion: %v", err)
+	}
+
+	return a.credential(t, map[string]interface{}{
+		"clientDataJSON":    encodeBase64URL(clientData),
+		"authenticatorData": encodeBase64URL(authData),
+		"signature":         encodeBase64URL(signature),
+		"userHandle":        encodeBase64URL(a.UserHandle),
+	})
+}
+
+func (a *VirtualAuthenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	return binary.BigEndian.AppendUint32(data, a.SignCount)
+}
+
+func (a *VirtualAuthenticator) clientData(t *testing.T, ceremony string, challenge []byte) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   encodeBase64URL(challenge),
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode client data: %v", err)
+	}
+	return data
+}
+
+func (a *VirtualAuthenticator) credential(t *testing.T, response map[string]interface{}) json.RawMessage {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       encodeBase64URL(a.CredentialID),
+		"rawId":    encodeBase64URL(a.CredentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode credential: %v", err)
+	}
+	return data
+}
+
+func encodeBase64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}